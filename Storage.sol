@@ -11,18 +11,28 @@ contract SaveContract {
     
     DataItem public data;
 
+    // Latest value of every key and field
+    mapping(string => mapping(string => string)) private values;
+
     // Define event, returns key, field, value in order
     event DataSaved(string key, string field, string value);
 
     // Save struct data and return via event
     function save(DataItem memory _data) public {
         data = _data;
+        values[_data.key][_data.field] = _data.value;
         emit DataSaved(_data.key, _data.field, _data.value);
     }
     
     // Overloaded function: also supports passing three string parameters directly
     function save(string memory _key, string memory _field, string memory _value) public {
         data = DataItem(_key, _field, _value);
+        values[_key][_field] = _value;
         emit DataSaved(_key, _field, _value);
     }
+
+    // Return the latest value saved for a key and field
+    function get(string memory _key, string memory _field) public view returns (string memory) {
+        return values[_key][_field];
+    }
 }
\ No newline at end of file
@@ -0,0 +1,38 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+)
+
+// handleCanary serves GET /canary, answering 503 while the latest canary
+// failed or none has completed yet
+func (s *Server) handleCanary(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Canary == nil {
+		writeError(w, http.StatusNotFound, errors.New("canary is disabled"))
+		return
+	}
+	result := s.opts.Canary.Last()
+	switch {
+	case result == nil:
+		writeError(w, http.StatusServiceUnavailable, errors.New("no canary has completed yet"))
+	case !result.OK:
+		writeJSON(w, http.StatusServiceUnavailable, result)
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"contract-storage-eth/fees"
+)
+
+// EstimateRequest is the record whose write POST /estimate prices.
+type EstimateRequest struct {
+	Key   string            `json:"key"`
+	Field string            `json:"field"`
+	Value string            `json:"value"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// EstimateFunc estimates the cost of saving a record. It returns an error
+// wrapping ErrInvalidRecord when the record cannot be written as given.
+type EstimateFunc func(ctx context.Context, req EstimateRequest) (*fees.Estimate, error)
+
+// ErrInvalidRecord marks records rejected before estimating them.
+var ErrInvalidRecord = errors.New("invalid record")
+
+// maxEstimateBody bounds the request body of POST /estimate.
+const maxEstimateBody = 1 << 20
+
+// handleEstimate serves POST /estimate
+func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Estimate == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("estimates are not available"))
+		return
+	}
+
+	var req EstimateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEstimateBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+
+	est, err := s.opts.Estimate(r.Context(), req)
+	if errors.Is(err, ErrInvalidRecord) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, est)
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Check is a readiness check. It returns nil when the dependency it checks
+// is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a Check.
+type CheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type readyResponse struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// checkTimeout bounds the readiness checks, which probes expect to answer
+// within seconds.
+const checkTimeout = 5 * time.Second
+
+// handleHealth serves GET /healthz, answering as long as the server runs
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady serves GET /readyz, running every readiness check at once
+// and answering 503 when any of them fails
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	resp := readyResponse{Ready: true, Checks: make([]CheckResult, len(s.opts.Checks))}
+	done := make(chan struct{})
+	for i, check := range s.opts.Checks {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			resp.Checks[i] = CheckResult{Name: check.Name, OK: true}
+			if err := check.Run(ctx); err != nil {
+				resp.Checks[i] = CheckResult{Name: check.Name, Error: err.Error()}
+			}
+		}()
+	}
+	for range s.opts.Checks {
+		<-done
+	}
+
+	status := http.StatusOK
+	for _, result := range resp.Checks {
+		if !result.OK {
+			resp.Ready = false
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"contract-storage-eth/api"
+)
+
+func TestHealth(t *testing.T) {
+	var health map[string]string
+	server := newServer(t, api.Options{})
+	if status := getJSON(t, server, "/healthz", &health); status != 200 || health["status"] != "ok" {
+		t.Errorf("GET /healthz = %d %v", status, health)
+	}
+}
+
+func TestReady(t *testing.T) {
+	up := api.Check{Name: "node", Run: func(ctx context.Context) error { return nil }}
+	down := api.Check{Name: "signer", Run: func(ctx context.Context) error { return errors.New("connection refused") }}
+	tests := []struct {
+		name   string
+		checks []api.Check
+		status int
+		ready  bool
+		want   []api.CheckResult
+	}{
+		{"no checks", nil, 200, true, []api.CheckResult{}},
+		{"all pass", []api.Check{up}, 200, true, []api.CheckResult{{Name: "node", OK: true}}},
+		{"one fails", []api.Check{up, down}, 503, false, []api.CheckResult{{Name: "node", OK: true}, {Name: "signer", Error: "connection refused"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(t, api.Options{Checks: tt.checks})
+			var ready struct {
+				Ready  bool              `json:"ready"`
+				Checks []api.CheckResult `json:"checks"`
+			}
+			if status := getJSON(t, server, "/readyz", &ready); status != tt.status {
+				t.Errorf("status %d, want %d", status, tt.status)
+			}
+			if ready.Ready != tt.ready || !reflect.DeepEqual(ready.Checks, tt.want) {
+				t.Errorf("GET /readyz = %+v, want ready %v with %+v", ready, tt.ready, tt.want)
+			}
+		})
+	}
+}
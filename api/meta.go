@@ -0,0 +1,106 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"contract-storage-eth/forwarder"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// PrepareRequest is the save POST /meta/prepare turns into a request for
+// the account From to sign.
+type PrepareRequest struct {
+	SaveRequest
+	From common.Address `json:"from"`
+}
+
+// PreparedRequest is a request of the forwarder with the EIP-712 data the
+// account signs with eth_signTypedData_v4.
+type PreparedRequest struct {
+	Request   *forwarder.Request  `json:"request"`
+	TypedData *apitypes.TypedData `json:"typed_data"`
+}
+
+// RelayRequest is a signed request POST /meta/relay executes.
+type RelayRequest struct {
+	Request   *forwarder.Request `json:"request"`
+	Signature hexutil.Bytes      `json:"signature"`
+}
+
+// Relayer sends saves signed by other accounts through a trusted
+// forwarder, paying their gas.
+type Relayer interface {
+	// Prepare seals the value of a save as Records does and returns the
+	// request for its account to sign.
+	Prepare(ctx context.Context, req PrepareRequest) (*PreparedRequest, error)
+	// Relay executes a signed request and waits for it to be mined. It
+	// returns an error wrapping ErrInvalidRecord when the request is not a
+	// save of the contract signed by its account.
+	Relay(ctx context.Context, req RelayRequest) (*WriteResult, error)
+}
+
+// handlePrepareMeta serves POST /meta/prepare
+func (s *Server) handlePrepareMeta(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Relayer == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("meta-transactions are not available"))
+		return
+	}
+	var req PrepareRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRecordBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Key == "" || req.From == (common.Address{}) {
+		writeError(w, http.StatusBadRequest, errors.New("key and from are required"))
+		return
+	}
+	prepared, err := s.opts.Relayer.Prepare(r.Context(), req)
+	if err != nil {
+		writeRecordError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, prepared)
+}
+
+// handleRelayMeta serves POST /meta/relay
+func (s *Server) handleRelayMeta(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Relayer == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("meta-transactions are not available"))
+		return
+	}
+	var req RelayRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRecordBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Request == nil || len(req.Signature) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("request and signature are required"))
+		return
+	}
+	result, err := s.opts.Relayer.Relay(r.Context(), req)
+	if err != nil {
+		writeRecordError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"contract-storage-eth/indexer"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SaveRequest is the record POST /records writes.
+type SaveRequest struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+	// Encoding is "hex" or "base64" when Value encodes a binary value, which
+	// is stored as bytes.
+	Encoding string            `json:"encoding,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	// Supersedes is the record this one replaces, as key[#field][@version]
+	// or a record ID.
+	Supersedes string `json:"supersedes,omitempty"`
+	// ExpiresAt is when the record expires, after which prune deletes it.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// IdempotencyKey makes the save safe to retry: once a save with the key
+	// is confirmed, saves with the same key return its result instead of
+	// writing again. POST /records takes it from the Idempotency-Key header.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// WriteResult is a mined write.
+type WriteResult struct {
+	ID     string      `json:"id"`
+	TxHash common.Hash `json:"tx_hash"`
+	Block  uint64      `json:"block"`
+	// Replayed is set when the result is that of an earlier save with the
+	// same idempotency key, and nothing was written.
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// Record is the value the contract holds for a key and field.
+type Record struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	// Value is the stored value and Content what its envelope holds, the
+	// same as Value when the value is not sealed.
+	Value   string `json:"value"`
+	Content string `json:"content"`
+	// Encoding is "base64" when the content is binary, which Content then
+	// holds base64 encoded.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Event is an indexed DataSaved event with its record ID.
+type Event struct {
+	ID string `json:"id"`
+	*indexer.Record
+}
+
+// Records performs the contract operations behind /records.
+type Records interface {
+	// Save writes a record and waits for it to be mined.
+	Save(ctx context.Context, req SaveRequest) (*WriteResult, error)
+	// Get reads the latest value of a key and field from the contract. It
+	// returns ErrNotFound when none is stored.
+	Get(ctx context.Context, key, field string) (*Record, error)
+	// Delete clears the value of a key and field. It returns ErrNotFound
+	// when none is stored.
+	Delete(ctx context.Context, key, field string) (*WriteResult, error)
+}
+
+// Errors of Records. ErrNotFound is answered with 404, ErrKeyReused with
+// 422 and the others with 409.
+var (
+	ErrNotFound   = errors.New("no value stored")
+	ErrRejected   = errors.New("the contract rejected the write")
+	ErrKeyReused  = errors.New("the idempotency key was used for another save")
+	ErrInProgress = errors.New("a save with the same idempotency key is in progress")
+)
+
+// Bounds of the request body of POST /records and of GET /events.
+const (
+	maxRecordBody     = 4 << 20
+	defaultEventLimit = 100
+	maxEventLimit     = 10000
+)
+
+// writeRecordError answers err with the status of its kind
+func writeRecordError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRecord):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrRejected), errors.Is(err, ErrInProgress):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, ErrKeyReused):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeError(w, http.StatusBadGateway, err)
+	}
+}
+
+// recordPath returns the path of a record under /records
+func recordPath(key, field string) string {
+	path := "/records/" + url.PathEscape(key)
+	if field != "" {
+		path += "/" + url.PathEscape(field)
+	}
+	return path
+}
+
+// handleSaveRecord serves POST /records
+func (s *Server) handleSaveRecord(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Records == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("records are not available"))
+		return
+	}
+
+	var req SaveRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRecordBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		if req.IdempotencyKey != "" && req.IdempotencyKey != key {
+			writeError(w, http.StatusBadRequest, errors.New("the Idempotency-Key header and idempotency_key differ"))
+			return
+		}
+		req.IdempotencyKey = key
+	}
+
+	result, err := s.opts.Records.Save(r.Context(), req)
+	if err != nil {
+		writeRecordError(w, err)
+		return
+	}
+	w.Header().Set("Location", recordPath(req.Key, req.Field))
+	if result.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// handleGetRecord serves GET /records/{key}[/{field}]
+func (s *Server) handleGetRecord(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Records == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("records are not available"))
+		return
+	}
+	record, err := s.opts.Records.Get(r.Context(), r.PathValue("key"), r.PathValue("field"))
+	if err != nil {
+		writeRecordError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// handleDeleteRecord serves DELETE /records/{key}[/{field}]
+func (s *Server) handleDeleteRecord(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Records == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("records are not available"))
+		return
+	}
+	result, err := s.opts.Records.Delete(r.Context(), r.PathValue("key"), r.PathValue("field"))
+	if err != nil {
+		writeRecordError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+type eventsResponse struct {
+	Events []Event `json:"events"`
+	// Next is the query of the following page, empty on the last one.
+	Next string `json:"next,omitempty"`
+}
+
+// handleEvents serves GET /events?key=&field=&from_block=&from_log=&to_block=&tag=NAME=VALUE&limit=
+// from the local index
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q, err := parseEventQuery(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Fetch one more to tell whether another page follows
+	limit := q.Limit
+	q.Limit++
+	records, err := s.opts.Index.Query(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := eventsResponse{Events: []Event{}}
+	if len(records) > limit {
+		// The next page starts at the first event left out, which may be in
+		// the middle of a block
+		next := records[limit]
+		records = records[:limit]
+		query.Set("from_block", strconv.FormatUint(next.BlockNumber, 10))
+		query.Set("from_log", strconv.FormatUint(uint64(next.LogIndex), 10))
+		resp.Next = "/events?" + query.Encode()
+	}
+	for _, record := range records {
+		resp.Events = append(resp.Events, Event{ID: s.opts.Namespace.ID(record.Key, record.Field, record.Version).String(), Record: record})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseEventQuery reads the filter of GET /events
+func parseEventQuery(query url.Values) (indexer.Query, error) {
+	q := indexer.Query{Key: query.Get("key"), Limit: defaultEventLimit}
+	if query.Has("field") {
+		field := query.Get("field")
+		q.Field = &field
+	}
+	for name, bound := range map[string]*uint64{"from_block": &q.FromBlock, "to_block": &q.ToBlock} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*bound = n
+		}
+	}
+	if v := query.Get("from_log"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return q, fmt.Errorf("invalid from_log: %w", err)
+		}
+		q.FromLog = uint(n)
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxEventLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxEventLimit)
+		}
+		q.Limit = n
+	}
+	for _, tag := range query["tag"] {
+		name, value, err := indexer.ParseTag(tag)
+		if err != nil {
+			return q, err
+		}
+		if q.Tags == nil {
+			q.Tags = map[string]string{}
+		}
+		q.Tags[name] = value
+	}
+	return q, nil
+}
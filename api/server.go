@@ -0,0 +1,196 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api implements the HTTP interface of serve mode.
+package api
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"contract-storage-eth/canary"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/tracing"
+	"contract-storage-eth/webhook"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Options configure a Server.
+type Options struct {
+	// Index is the local event index backing the query endpoints.
+	Index *indexer.Store
+	// Tenancy attributes usage to tenants in the billing endpoint.
+	Tenancy indexer.Tenancy
+	// AdminToken protects the admin endpoints; they are disabled when empty.
+	AdminToken string
+	// Keyring holds the webhook signing secrets.
+	Keyring *webhook.Keyring
+	// RotationOverlap is how long replaced webhook secrets stay valid.
+	RotationOverlap time.Duration
+	// Dispatcher delivers webhooks and holds the failed deliveries.
+	Dispatcher *webhook.Dispatcher
+	// Estimate prices record writes; POST /estimate is disabled when nil.
+	Estimate EstimateFunc
+	// Canary runs the write path self-test reported by GET /canary.
+	Canary *canary.Runner
+	// Records backs the /records endpoints, which are disabled when nil.
+	Records Records
+	// Relayer backs the /meta endpoints, which are disabled when nil.
+	Relayer Relayer
+	// WriteToken protects the endpoints writing to the contract; they are
+	// disabled when empty.
+	WriteToken string
+	// Namespace names the records in responses.
+	Namespace recordid.Namespace
+	// PollInterval is how often GET /events/stream looks for new events.
+	// Defaults to a second.
+	PollInterval time.Duration
+	// Checks decide whether GET /readyz reports the server as ready.
+	Checks []Check
+	// Metrics serves GET /metrics when set.
+	Metrics http.Handler
+	// AllowedOrigins lists the web origins other than the server's own
+	// whose pages may open GET /events/stream, "*" for any.
+	AllowedOrigins []string
+}
+
+// Server is the HTTP handler of serve mode.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// NewServer returns a server with all routes registered.
+func NewServer(opts Options) *Server {
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /stats", s.handleStats)
+	s.mux.HandleFunc("GET /stats/tenants", s.handleTenantStats)
+	s.mux.HandleFunc("POST /estimate", s.handleEstimate)
+	s.mux.HandleFunc("GET /canary", s.handleCanary)
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /readyz", s.handleReady)
+	if opts.Metrics != nil {
+		s.mux.Handle("GET /metrics", opts.Metrics)
+	}
+	s.mux.HandleFunc("POST /records", s.writer(s.handleSaveRecord))
+	s.mux.HandleFunc("GET /records/{key}", s.handleGetRecord)
+	s.mux.HandleFunc("GET /records/{key}/{field}", s.handleGetRecord)
+	s.mux.HandleFunc("DELETE /records/{key}", s.writer(s.handleDeleteRecord))
+	s.mux.HandleFunc("DELETE /records/{key}/{field}", s.writer(s.handleDeleteRecord))
+	s.mux.HandleFunc("POST /meta/prepare", s.writer(s.handlePrepareMeta))
+	s.mux.HandleFunc("POST /meta/relay", s.writer(s.handleRelayMeta))
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	s.mux.HandleFunc("GET /events/stream", s.handleStream)
+	s.mux.HandleFunc("POST /webhooks/secrets/rotate", s.admin(s.handleRotateSecret))
+	s.mux.HandleFunc("GET /webhooks/dlq", s.admin(s.handleListDeadLetters))
+	s.mux.HandleFunc("POST /webhooks/dlq/{id}/retry", s.admin(s.handleRetryDeadLetter))
+	return s
+}
+
+// admin restricts a handler to requests bearing the admin token.
+func (s *Server) admin(next http.HandlerFunc) http.HandlerFunc {
+	return bearer("admin", s.opts.AdminToken, next)
+}
+
+// writer restricts a handler to requests bearing the write token.
+func (s *Server) writer(next http.HandlerFunc) http.HandlerFunc {
+	return bearer("write", s.opts.WriteToken, next)
+}
+
+// bearer restricts a handler to requests bearing token, disabling it when
+// the token is empty.
+func bearer(name, want string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if want == "" {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s API is disabled", name))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid %s token", name))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ServeHTTP implements http.Handler. Each request is traced as a span named
+// after its route, continuing the trace of the caller's traceparent header.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	_, pattern := s.mux.Handler(r)
+	if pattern == "" {
+		pattern = r.Method
+	}
+	ctx, span := tracing.Start(ctx, pattern, attribute.String("http.request.method", r.Method))
+	defer span.End()
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(sw, r.WithContext(ctx))
+	span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+	if sw.status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(sw.status))
+	}
+}
+
+// statusWriter records the status of a response. It can be hijacked for
+// WebSocket upgrades.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+)
+
+// newServer serves the API over the index of the recorded chain
+func newServer(t *testing.T, opts api.Options) *httptest.Server {
+	t.Helper()
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ix := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1, ScanFailures: true})
+	if _, err := ix.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.Index = store
+	opts.Namespace = recordid.Namespace{ChainID: 1337, Contract: chain.Contract}
+	server := httptest.NewServer(api.NewServer(opts))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getJSON decodes the response to GET path into v and returns its status
+func getJSON(t *testing.T, server *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestStats(t *testing.T) {
+	server := newServer(t, api.Options{})
+
+	type stats struct {
+		Granularity string            `json:"granularity"`
+		Buckets     []*indexer.Bucket `json:"buckets"`
+		Error       string            `json:"error"`
+	}
+	tests := []struct {
+		path    string
+		status  int
+		buckets int
+		writes  int
+	}{
+		{"/stats", http.StatusOK, 3, 5},
+		{"/stats?granularity=day", http.StatusOK, 2, 5},
+		{"/stats?granularity=day&from=2025-01-02T00:00:00Z", http.StatusOK, 1, 1},
+		{"/stats?to=2025-01-01T01:00:00Z", http.StatusOK, 1, 3},
+		{"/stats?granularity=week", http.StatusBadRequest, 0, 0},
+		{"/stats?from=yesterday", http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		var got stats
+		if status := getJSON(t, server, tt.path, &got); status != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, status, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			if got.Error == "" {
+				t.Errorf("GET %s: no error message", tt.path)
+			}
+			continue
+		}
+		writes := 0
+		for _, b := range got.Buckets {
+			writes += b.Writes
+		}
+		if len(got.Buckets) != tt.buckets || writes != tt.writes {
+			t.Errorf("GET %s: %d buckets with %d writes, want %d with %d", tt.path, len(got.Buckets), writes, tt.buckets, tt.writes)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"contract-storage-eth/indexer"
+)
+
+type statsResponse struct {
+	Granularity string            `json:"granularity"`
+	Buckets     []*indexer.Bucket `json:"buckets"`
+}
+
+// handleStats serves GET /stats?granularity=hour|day&from=RFC3339&to=RFC3339
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = "hour"
+	}
+	width, err := indexer.ParseGranularity(granularity)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := parseRange(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	buckets, err := s.opts.Index.Stats(width, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statsResponse{Granularity: granularity, Buckets: buckets})
+}
+
+type tenantsResponse struct {
+	TenantBy string           `json:"tenant_by"`
+	Tenants  []*indexer.Usage `json:"tenants"`
+}
+
+// handleTenantStats serves GET /stats/tenants?from=RFC3339&to=RFC3339
+func (s *Server) handleTenantStats(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	usage, err := s.opts.Index.Usage(s.opts.Tenancy, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tenantsResponse{TenantBy: s.opts.Tenancy.By, Tenants: usage})
+}
+
+// parseRange reads the optional from and to RFC 3339 query parameters
+func parseRange(query url.Values) (from, to time.Time, err error) {
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	return from, to, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Timing of GET /events/stream connections.
+const (
+	defaultPollInterval = time.Second
+	pingInterval        = 30 * time.Second
+	writeTimeout        = 10 * time.Second
+	streamBatch         = 500
+)
+
+// checkOrigin accepts browser connections from the same host or from
+// Options.AllowedOrigins, and connections of other clients, which send no
+// Origin.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(s.opts.AllowedOrigins, "*") || slices.Contains(s.opts.AllowedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// handleStream serves GET /events/stream, a WebSocket pushing each indexed
+// DataSaved event as a JSON message as soon as the index syncs it. It takes
+// the filters of GET /events; without from_block, only events indexed
+// after the connection are sent.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q, err := parseEventQuery(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !query.Has("from_block") {
+		if q.FromBlock, err = s.opts.Index.NextBlock(0); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	q.Limit = streamBatch
+
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already answered
+		return
+	}
+	defer conn.Close()
+
+	// Read until the client goes away, answering its pings and close
+	// messages
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	interval := s.opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		records, err := s.opts.Index.Query(q)
+		if err != nil {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()), time.Now().Add(writeTimeout))
+			return
+		}
+		for _, record := range records {
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(Event{ID: s.opts.Namespace.ID(record.Key, record.Field, record.Version).String(), Record: record}); err != nil {
+				return
+			}
+		}
+		if n := len(records); n > 0 {
+			// Resume after the last event sent
+			q.FromBlock, q.FromLog = records[n-1].BlockNumber, records[n-1].LogIndex+1
+		}
+		if len(records) == streamBatch {
+			continue
+		}
+
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(writeTimeout))
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-poll.C:
+		}
+	}
+}
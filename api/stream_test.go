@@ -0,0 +1,77 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/api"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialStream opens GET /events/stream with the given query and Origin
+func dialStream(server string, query, origin string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server, "http")+"/events/stream?"+query, header)
+}
+
+func TestStream(t *testing.T) {
+	server := newServer(t, api.Options{PollInterval: 10 * time.Millisecond, AllowedOrigins: []string{"https://app.example.com"}})
+
+	// Indexed events are replayed from from_block, in chain order
+	conn, _, err := dialStream(server.URL, "from_block=1&key=invoice-42", "https://app.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, want := range []string{"invoice-42?version=1#pdf", "invoice-42?version=2#pdf"} {
+		var event api.Event
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(event.ID, want) {
+			t.Errorf("event %s, want %s", event.ID, want)
+		}
+	}
+
+	// Without from_block only events indexed later are sent, and the
+	// index of the recorded chain is complete
+	later, _, err := dialStream(server.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer later.Close()
+	later.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, message, err := later.ReadMessage(); err == nil {
+		t.Errorf("stream sent %s before any new event", message)
+	}
+
+	_, resp, err := dialStream(server.URL, "", "https://other.example.com")
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign origin: got %v, %v", resp, err)
+	}
+	_, resp, err = dialStream(server.URL, "from_block=x", "")
+	if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid query: got %v, %v", resp, err)
+	}
+}
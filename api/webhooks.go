@@ -0,0 +1,93 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"contract-storage-eth/webhook"
+)
+
+type rotateResponse struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	Overlap   string    `json:"overlap"`
+}
+
+// handleRotateSecret serves POST /webhooks/secrets/rotate
+func (s *Server) handleRotateSecret(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Keyring == nil {
+		writeError(w, http.StatusNotFound, errors.New("webhooks are not configured"))
+		return
+	}
+
+	overlap := s.opts.RotationOverlap
+	if v := r.URL.Query().Get("overlap"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		overlap = d
+	}
+
+	secret, err := s.opts.Keyring.Rotate(overlap)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rotateResponse{
+		ID:        secret.ID,
+		Key:       hex.EncodeToString(secret.Key),
+		CreatedAt: secret.CreatedAt,
+		Overlap:   overlap.String(),
+	})
+}
+
+type deadLettersResponse struct {
+	DeadLetters []*webhook.DeadLetter `json:"dead_letters"`
+}
+
+// handleListDeadLetters serves GET /webhooks/dlq
+func (s *Server) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Dispatcher == nil {
+		writeError(w, http.StatusNotFound, errors.New("webhooks are not configured"))
+		return
+	}
+	writeJSON(w, http.StatusOK, deadLettersResponse{DeadLetters: s.opts.Dispatcher.DLQ.List()})
+}
+
+// handleRetryDeadLetter serves POST /webhooks/dlq/{id}/retry
+func (s *Server) handleRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Dispatcher == nil {
+		writeError(w, http.StatusNotFound, errors.New("webhooks are not configured"))
+		return
+	}
+
+	err := s.opts.Dispatcher.Redeliver(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"contract-storage-eth/arweave"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// arweaveUploader returns the bundler of arweave.bundler_url, signing with
+// the key of arweave.private_key
+func arweaveUploader(config *Config) (arweave.Uploader, error) {
+	hexKey, err := resolveSecret(config.Arweave.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("arweave.private_key: %w", err)
+	}
+	if hexKey == "" {
+		return nil, fail(codeConfig, "arweave.private_key is not configured")
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("arweave.private_key: %w", err)
+	}
+	return &arweave.Bundler{
+		URL:    config.Arweave.BundlerURL,
+		Key:    key,
+		Tags:   []arweave.Tag{{Name: "App-Name", Value: "contract-storage-eth"}, {Name: "Content-Type", Value: "application/octet-stream"}},
+		Client: &http.Client{Timeout: arweaveTimeout(config)},
+	}, nil
+}
+
+// arweaveFetcher returns the gateway of arweave.gateway_url, arweave.net
+// when unset
+func arweaveFetcher(config *Config) arweave.Fetcher {
+	return &arweave.Gateway{URL: config.Arweave.GatewayURL, Client: &http.Client{Timeout: arweaveTimeout(config)}}
+}
+
+func arweaveTimeout(config *Config) time.Duration {
+	if config.Arweave.Timeout > 0 {
+		return config.Arweave.Timeout
+	}
+	return time.Minute
+}
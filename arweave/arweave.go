@@ -0,0 +1,218 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package arweave stores values too large to keep on chain on Arweave,
+// which keeps them permanently once paid for. The value is uploaded to a
+// bundler as a signed ANS-104 data item, and a pointer holding the
+// transaction ID, length and digest of the value is written on chain in its
+// place, so that readers can fetch it from any gateway and check what they
+// got.
+package arweave
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Prefix marks a stored value as a pointer. It cannot start an envelope,
+// a chunk manifest, a tombstone or an IPFS pointer.
+const Prefix = "cse-ar:"
+
+// Pointer is what is stored on chain for a value kept on Arweave.
+type Pointer struct {
+	// ID is the transaction ID of the data item holding the value.
+	ID string `json:"id"`
+	// Length and SHA256 are the length and digest of the value.
+	Length int    `json:"length"`
+	SHA256 string `json:"sha256"`
+}
+
+// String returns the pointer in the form stored on chain.
+func (p *Pointer) String() string {
+	data, _ := json.Marshal(p)
+	return Prefix + string(data)
+}
+
+// Parse returns the pointer a stored value holds, or nil when the value is
+// not one.
+func Parse(value string) (*Pointer, error) {
+	body, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return nil, nil
+	}
+	p := &Pointer{}
+	if err := json.Unmarshal([]byte(body), p); err != nil {
+		return nil, fmt.Errorf("arweave: malformed pointer: %w", err)
+	}
+	if !validID(p.ID) || p.Length < 0 || len(p.SHA256) != 2*sha256.Size {
+		return nil, errors.New("arweave: malformed pointer")
+	}
+	return p, nil
+}
+
+// validID reports whether id is a transaction ID, the base64url encoding of
+// 32 bytes
+func validID(id string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil && len(raw) == sha256.Size
+}
+
+// Uploader stores content on Arweave, returning its transaction ID.
+type Uploader interface {
+	Upload(ctx context.Context, data []byte) (string, error)
+}
+
+// Fetcher reads content from Arweave.
+type Fetcher interface {
+	Fetch(ctx context.Context, id string, limit int64) ([]byte, error)
+}
+
+// Put uploads a value and returns its pointer.
+func Put(ctx context.Context, uploader Uploader, value string) (*Pointer, error) {
+	id, err := uploader.Upload(ctx, []byte(value))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(value))
+	return &Pointer{ID: id, Length: len(value), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// Get fetches the value a pointer points to, checking it against the
+// pointer.
+func Get(ctx context.Context, fetcher Fetcher, p *Pointer) (string, error) {
+	data, err := fetcher.Fetch(ctx, p.ID, int64(p.Length))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	if len(data) != p.Length || hex.EncodeToString(sum[:]) != p.SHA256 {
+		return "", fmt.Errorf("arweave: %s does not match its pointer", p.ID)
+	}
+	return string(data), nil
+}
+
+// DefaultBundlerURL is the upload endpoint of the ArDrive Turbo bundler.
+const DefaultBundlerURL = "https://upload.ardrive.io/v1/tx"
+
+// Bundler is a bundling service such as ArDrive Turbo or Irys, which
+// takes signed data items and settles them on Arweave, billing the address
+// of their signer.
+type Bundler struct {
+	// URL is the endpoint data items are posted to, DefaultBundlerURL when
+	// empty.
+	URL string
+	// Key signs the data items.
+	Key *ecdsa.PrivateKey
+	// Tags are added to every data item.
+	Tags   []Tag
+	Client *http.Client
+}
+
+// Upload implements Uploader.
+func (b *Bundler) Upload(ctx context.Context, data []byte) (string, error) {
+	item, err := NewDataItem(b.Key, data, b.Tags)
+	if err != nil {
+		return "", err
+	}
+	endpoint := b.URL
+	if endpoint == "" {
+		endpoint = DefaultBundlerURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(item.Bytes()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := httpClient(b.Client).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("arweave: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", statusError(resp)
+	}
+	var receipt struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&receipt); err != nil {
+		return "", fmt.Errorf("arweave: malformed answer: %w", err)
+	}
+	// The ID is the digest of the signature, so a bundler cannot answer
+	// with another one
+	if receipt.ID != item.ID {
+		return "", fmt.Errorf("arweave: the bundler returned ID %q for data item %s", receipt.ID, item.ID)
+	}
+	return item.ID, nil
+}
+
+// DefaultGatewayURL is the gateway of arweave.net.
+const DefaultGatewayURL = "https://arweave.net"
+
+// Gateway is an HTTP gateway, such as DefaultGatewayURL.
+type Gateway struct {
+	// URL is DefaultGatewayURL when empty.
+	URL    string
+	Client *http.Client
+}
+
+// Fetch implements Fetcher.
+func (g *Gateway) Fetch(ctx context.Context, id string, limit int64) ([]byte, error) {
+	base := g.URL
+	if base == "" {
+		base = DefaultGatewayURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient(g.Client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arweave: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, statusError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("arweave: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("arweave: content longer than %d bytes", limit)
+	}
+	return data, nil
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("arweave: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
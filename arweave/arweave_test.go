@@ -0,0 +1,150 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/arweave"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeBundler takes data items without tags and serves their data like a
+// gateway. With wrongID, it answers uploads with another ID
+func fakeBundler(t *testing.T, wrongID bool) *httptest.Server {
+	items := map[string][]byte{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tx", func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		// Signature type, signature, owner, no target, no anchor, no
+		// tags, then the data
+		if len(raw) < 2+65+65+2+16 || binary.LittleEndian.Uint16(raw) != 3 {
+			http.Error(w, "malformed data item", http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256(raw[2:67])
+		id := base64.RawURLEncoding.EncodeToString(sum[:])
+		items[id] = raw[2+65+65+2+16:]
+		if wrongID {
+			id = strings.Repeat("A", 43)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	})
+	mux.HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := items[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPutGet(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	server := fakeBundler(t, false)
+	ctx := context.Background()
+
+	value := strings.Repeat("large value ", 100)
+	p, err := arweave.Put(ctx, &arweave.Bundler{URL: server.URL + "/v1/tx", Key: key}, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := arweave.Parse(p.String())
+	if err != nil || *parsed != *p {
+		t.Fatalf("parsed %+v, %v, want %+v", parsed, err, p)
+	}
+	got, err := arweave.Get(ctx, &arweave.Gateway{URL: server.URL}, parsed)
+	if err != nil || got != value {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	// Content that does not match the pointer is refused
+	other, err := arweave.Put(ctx, &arweave.Bundler{URL: server.URL + "/v1/tx", Key: key}, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed.ID = other.ID
+	if _, err := arweave.Get(ctx, &arweave.Gateway{URL: server.URL}, parsed); err == nil {
+		t.Error("read content that does not match its pointer")
+	}
+}
+
+func TestBundlerID(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	server := fakeBundler(t, true)
+	if _, err := arweave.Put(context.Background(), &arweave.Bundler{URL: server.URL + "/v1/tx", Key: key}, "value"); err == nil {
+		t.Error("accepted an ID that is not the one of the data item")
+	}
+}
+
+func TestDataItem(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	item, err := arweave.NewDataItem(key, []byte("data"), []arweave.Tag{{Name: "Content-Type", Value: "text/plain"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := item.Bytes()
+	if owner := raw[67:132]; !bytes.Equal(owner, crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Errorf("owner is %x", owner)
+	}
+	if v := raw[66]; v != 27 && v != 28 {
+		t.Errorf("signature ends with %d, want 27 or 28", v)
+	}
+	tags := raw[134:]
+	if n := binary.LittleEndian.Uint64(tags); n != 1 {
+		t.Errorf("%d tags, want 1", n)
+	}
+	// One block of one record, then the end of the array
+	want := append([]byte{2, 24}, "Content-Type"...)
+	want = append(append(want, 20), "text/plain"...)
+	want = append(append(want, 0), "data"...)
+	if size := binary.LittleEndian.Uint64(tags[8:]); size != uint64(len(want)-len("data")) || !bytes.Equal(tags[16:], want) {
+		t.Errorf("tags and data are %q (%d bytes of tags)", tags[16:], size)
+	}
+	sum := sha256.Sum256(raw[2:67])
+	if item.ID != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Errorf("ID %s is not the digest of the signature", item.ID)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if p, err := arweave.Parse("plain value"); p != nil || err != nil {
+		t.Errorf("parsed %+v, %v from a plain value", p, err)
+	}
+	for _, value := range []string{
+		arweave.Prefix + "{",
+		arweave.Prefix + `{"id":"short","length":1,"sha256":"` + strings.Repeat("0", 64) + `"}`,
+		arweave.Prefix + `{"id":"` + strings.Repeat("A", 43) + `","length":1,"sha256":"00"}`,
+	} {
+		if _, err := arweave.Parse(value); err == nil {
+			t.Errorf("parsed %s", value)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signatureEthereum is the ANS-104 signature type of secp256k1 keys signing
+// like Ethereum wallets.
+const signatureEthereum = 3
+
+// Tag is a name and value attached to a data item, which gateways index.
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// DataItem is a signed ANS-104 data item, the unit bundlers settle on
+// Arweave.
+type DataItem struct {
+	// ID is the transaction ID the content is read by.
+	ID  string
+	raw []byte
+}
+
+// Bytes returns the binary form of the item, which is what bundlers take.
+func (d *DataItem) Bytes() []byte {
+	return d.raw
+}
+
+// NewDataItem signs data as a data item without target nor anchor. The
+// signature is the EIP-191 signature of the deep hash of the item, so its
+// owner is the Ethereum address of key.
+func NewDataItem(key *ecdsa.PrivateKey, data []byte, tags []Tag) (*DataItem, error) {
+	if key == nil {
+		return nil, errors.New("arweave: no signing key")
+	}
+	owner := crypto.FromECDSAPub(&key.PublicKey)
+	rawTags := encodeTags(tags)
+
+	message := deepHash([][]byte{
+		[]byte("dataitem"),
+		[]byte("1"),
+		[]byte(strconv.Itoa(signatureEthereum)),
+		owner,
+		nil, // target
+		nil, // anchor
+		rawTags,
+		data,
+	})
+	signature, err := crypto.Sign(accounts.TextHash(message), key)
+	if err != nil {
+		return nil, fmt.Errorf("arweave: %w", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	raw := binary.LittleEndian.AppendUint16(nil, signatureEthereum)
+	raw = append(raw, signature...)
+	raw = append(raw, owner...)
+	raw = append(raw, 0, 0) // no target, no anchor
+	raw = binary.LittleEndian.AppendUint64(raw, uint64(len(tags)))
+	raw = binary.LittleEndian.AppendUint64(raw, uint64(len(rawTags)))
+	raw = append(raw, rawTags...)
+	raw = append(raw, data...)
+
+	id := sha256.Sum256(signature)
+	return &DataItem{ID: base64.RawURLEncoding.EncodeToString(id[:]), raw: raw}, nil
+}
+
+// encodeTags encodes tags as the Avro array of name and value records
+// ANS-104 uses, which is empty without tags
+func encodeTags(tags []Tag) []byte {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := appendLong(nil, int64(len(tags)))
+	for _, tag := range tags {
+		out = appendLong(out, int64(len(tag.Name)))
+		out = append(out, tag.Name...)
+		out = appendLong(out, int64(len(tag.Value)))
+		out = append(out, tag.Value...)
+	}
+	return appendLong(out, 0)
+}
+
+// appendLong appends an Avro long, a zigzag varint
+func appendLong(out []byte, n int64) []byte {
+	return binary.AppendUvarint(out, uint64(n<<1^n>>63))
+}
+
+// deepHash is the SHA-384 deep hash of a list of blobs
+func deepHash(blobs [][]byte) []byte {
+	acc := sha384([]byte("list" + strconv.Itoa(len(blobs))))
+	for _, blob := range blobs {
+		tagged := sha384(sha384([]byte("blob"+strconv.Itoa(len(blob)))), sha384(blob))
+		acc = sha384(acc, tagged)
+	}
+	return acc
+}
+
+func sha384(parts ...[]byte) []byte {
+	h := sha512.New384()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"contract-storage-eth/audit"
+	"contract-storage-eth/gasreport"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tombstone"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// auditLog is where the transactions sent are recorded, nil when audit.file
+// is empty
+var auditLog struct {
+	log     *audit.Log
+	command string
+}
+
+// setupAudit opens the audit log. Commands must not send anything without
+// it, so failing to open it is fatal
+func setupAudit(config *Config, command string) (func(), error) {
+	if config.Audit.File == "" {
+		return func() {}, nil
+	}
+	l, err := audit.Open(config.Audit.File)
+	if err != nil {
+		return nil, err
+	}
+	auditLog.log, auditLog.command = l, command
+	return func() { l.Close() }, nil
+}
+
+// txCall is what a transaction does, from its calldata
+type txCall struct {
+	operation         string
+	key, field, value string
+}
+
+// describeTx tells deploys, saves and deletes apart by the calldata of tx,
+// naming the method of other calls. Writing an empty value or a tombstone
+// deletes it. Keys are as stored, namespace included
+func describeTx(tx *types.Transaction) txCall {
+	if tx.To() == nil {
+		return txCall{operation: gasreport.OpDeploy}
+	}
+	call := txCall{operation: "call"}
+	parsedABI, err := storage.ABI()
+	if err != nil || len(tx.Data()) < 4 {
+		return call
+	}
+	method, err := parsedABI.MethodById(tx.Data()[:4])
+	if err != nil {
+		return call
+	}
+	call.operation = method.Name
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if method.Name != "save" || err != nil || len(args) != 3 {
+		return call
+	}
+	call.key, call.field, call.value = args[0].(string), args[1].(string), args[2].(string)
+	call.operation = gasreport.OpSave
+	if call.value == "" || tombstone.Is(call.value) {
+		call.operation = gasreport.OpDelete
+	}
+	return call
+}
+
+// auditSent records a transaction sent, or refused by the node
+func auditSent(tx *types.Transaction, sendErr error) {
+	if auditLog.log == nil {
+		return
+	}
+	call := describeTx(tx)
+	entry := audit.Entry{
+		Event:      audit.EventSent,
+		Command:    auditLog.command,
+		ChainID:    tx.ChainId().Uint64(),
+		TxHash:     tx.Hash(),
+		To:         tx.To(),
+		Nonce:      tx.Nonce(),
+		Operation:  call.operation,
+		Key:        call.key,
+		Field:      call.field,
+		ValueBytes: len(call.value),
+		Gas:        tx.Gas(),
+	}
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		entry.GasPrice = tx.GasPrice().String()
+	} else {
+		entry.GasFeeCap, entry.GasTipCap = tx.GasFeeCap().String(), tx.GasTipCap().String()
+	}
+	if sendErr != nil {
+		entry.Event, entry.Error = audit.EventSendFailed, sendErr.Error()
+	}
+	appendAudit(tx, entry)
+}
+
+// auditMined records the receipt of a transaction
+func auditMined(tx *types.Transaction, receipt *types.Receipt) {
+	if auditLog.log == nil {
+		return
+	}
+	status := receipt.Status
+	entry := audit.Entry{
+		Event:   audit.EventMined,
+		Command: auditLog.command,
+		ChainID: tx.ChainId().Uint64(),
+		TxHash:  tx.Hash(),
+		Nonce:   tx.Nonce(),
+		Status:  &status,
+		GasUsed: receipt.GasUsed,
+	}
+	if receipt.BlockNumber != nil {
+		entry.Block = receipt.BlockNumber.Uint64()
+	}
+	if receipt.EffectiveGasPrice != nil {
+		entry.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+	}
+	appendAudit(tx, entry)
+}
+
+func appendAudit(tx *types.Transaction, entry audit.Entry) {
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+		entry.From = from
+	}
+	if err := auditLog.log.Append(entry); err != nil {
+		slog.Error("Failed to write audit log", "tx_hash", entry.TxHash.Hex(), "event", entry.Event, "error", err)
+	}
+}
+
+func runAudit(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	file := flags.String("file", config.Audit.File, "audit log to read")
+	limit := flags.Int("limit", 20, "latest transactions shown, 0 for all")
+	since := flags.Duration("since", 0, "only show transactions sent within this long")
+	txFlag := flags.String("tx", "", "only show the transaction with this hash")
+	command := flags.String("command", "", "only show the transactions of this command")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return fail(codeConfig, "audit.file is not configured")
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return fmt.Errorf("Failed to open audit log: %w", err)
+	}
+	entries, err := audit.Read(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to read audit log: %w", err)
+	}
+
+	var shown []*audit.Transaction
+	for _, tx := range audit.Transactions(entries) {
+		switch {
+		case *txFlag != "" && tx.Sent.TxHash != common.HexToHash(*txFlag):
+		case *command != "" && tx.Sent.Command != *command:
+		case *since > 0 && time.Since(tx.Sent.Time) > *since:
+		default:
+			shown = append(shown, tx)
+		}
+	}
+	if *limit > 0 && len(shown) > *limit {
+		shown = shown[len(shown)-*limit:]
+	}
+	if len(shown) == 0 {
+		fmt.Println("No transactions found")
+		printResult(shown)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SENT\tCOMMAND\tOPERATION\tRECORD\tFROM\tNONCE\tTX\tSTATUS\tBLOCK\tGAS USED")
+	for _, tx := range shown {
+		record := "-"
+		if tx.Sent.Key != "" {
+			record = tx.Sent.Key + "#" + tx.Sent.Field
+		}
+		status, block, gasUsed := "pending", "-", "-"
+		switch {
+		case tx.Sent.Event == audit.EventSendFailed:
+			status = "send failed: " + tx.Sent.Error
+		case tx.Mined != nil:
+			status = "success"
+			if *tx.Mined.Status != types.ReceiptStatusSuccessful {
+				status = "reverted"
+			}
+			block, gasUsed = fmt.Sprint(tx.Mined.Block), fmt.Sprint(tx.Mined.GasUsed)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			tx.Sent.Time.Local().Format(time.DateTime), tx.Sent.Command, tx.Sent.Operation, record, tx.Sent.From.Hex(), tx.Sent.Nonce, tx.Sent.TxHash.Hex(), strings.TrimSpace(status), block, gasUsed)
+	}
+	tw.Flush()
+	printResult(shown)
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit keeps an append-only trail of the transactions sent with a
+// key: one JSON line when a transaction is sent, or fails to be, and one
+// when its receipt is seen. Lines are only ever appended, and each is
+// synced to disk before the write returns.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Events of the entries.
+const (
+	// EventSent is a transaction accepted by the node.
+	EventSent = "sent"
+	// EventSendFailed is a transaction the node refused.
+	EventSendFailed = "send_failed"
+	// EventMined is the receipt of a transaction.
+	EventMined = "mined"
+)
+
+// Entry is a line of the log. Sent entries describe the transaction, mined
+// ones its receipt.
+type Entry struct {
+	Time    time.Time   `json:"time"`
+	Event   string      `json:"event"`
+	Command string      `json:"command,omitempty"`
+	ChainID uint64      `json:"chain_id,omitempty"`
+	TxHash  common.Hash `json:"tx_hash"`
+
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Nonce uint64          `json:"nonce"`
+	// Operation summarizes the payload: deploy, save, delete or the
+	// method called.
+	Operation  string `json:"operation,omitempty"`
+	Key        string `json:"key,omitempty"`
+	Field      string `json:"field,omitempty"`
+	ValueBytes int    `json:"value_bytes,omitempty"`
+	Gas        uint64 `json:"gas,omitempty"`
+	// Fees in wei: GasPrice for legacy transactions, GasFeeCap and
+	// GasTipCap for dynamic fee ones.
+	GasPrice  string `json:"gas_price,omitempty"`
+	GasFeeCap string `json:"gas_fee_cap,omitempty"`
+	GasTipCap string `json:"gas_tip_cap,omitempty"`
+
+	Status            *uint64 `json:"status,omitempty"`
+	Block             uint64  `json:"block,omitempty"`
+	GasUsed           uint64  `json:"gas_used,omitempty"`
+	EffectiveGasPrice string  `json:"effective_gas_price,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Log appends entries to a file. It is safe for concurrent use.
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens the log at path for appending, creating it readable by its
+// owner only.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return &Log{file: f}, nil
+}
+
+// Append writes e as a line, setting its time when zero.
+func (l *Log) Append(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return errors.New("audit: log is closed")
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// Close closes the file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Read returns the entries of a log. A torn last line, such as one being
+// written when the process was killed, is left out.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var torn error
+	for n := 1; scanner.Scan(); n++ {
+		if torn != nil {
+			return nil, torn
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			torn = fmt.Errorf("audit: line %d: %w", n, err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Transaction is what the log holds about a transaction: the entry of its
+// send and that of its receipt, when seen.
+type Transaction struct {
+	Sent  Entry  `json:"sent"`
+	Mined *Entry `json:"mined,omitempty"`
+}
+
+// Transactions joins the entries of the same transactions, in the order
+// they were sent. Receipts of transactions sent outside the log, such as
+// before it was enabled, are left out.
+func Transactions(entries []Entry) []*Transaction {
+	var txs []*Transaction
+	byHash := map[common.Hash]*Transaction{}
+	for _, e := range entries {
+		switch e.Event {
+		case EventSent, EventSendFailed:
+			tx := &Transaction{Sent: e}
+			txs = append(txs, tx)
+			if e.Event == EventSent {
+				byHash[e.TxHash] = tx
+			}
+		case EventMined:
+			if tx, ok := byHash[e.TxHash]; ok {
+				mined := e
+				tx.Mined = &mined
+			}
+		}
+	}
+	return txs
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/audit"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	status := uint64(1)
+	for _, entries := range [][]audit.Entry{
+		{
+			{Event: audit.EventSent, Command: "deploy", TxHash: common.HexToHash("0x01"), Operation: "deploy"},
+			{Event: audit.EventSendFailed, Command: "save", TxHash: common.HexToHash("0x02"), Error: "insufficient funds"},
+		},
+		// A later command appends to the same log
+		{
+			{Event: audit.EventSent, Command: "save", TxHash: common.HexToHash("0x03"), Operation: "save", Key: "a", Field: "b"},
+			{Event: audit.EventMined, TxHash: common.HexToHash("0x03"), Status: &status, Block: 9},
+			{Event: audit.EventMined, TxHash: common.HexToHash("0x04"), Status: &status},
+		},
+	} {
+		l, err := audit.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if err := l.Append(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		l.Close()
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("log mode %v, %v", info.Mode(), err)
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	entries, err := audit.Read(f)
+	if err != nil || len(entries) != 5 || entries[0].Time.IsZero() {
+		t.Fatalf("read %d entries, %v", len(entries), err)
+	}
+	txs := audit.Transactions(entries)
+	if len(txs) != 3 || txs[0].Mined != nil || txs[1].Sent.Error == "" || txs[2].Mined == nil || txs[2].Mined.Block != 9 {
+		t.Errorf("transactions %+v", txs)
+	}
+}
+
+func TestReadTornLine(t *testing.T) {
+	full := `{"event":"sent","tx_hash":"0x0000000000000000000000000000000000000000000000000000000000000001"}` + "\n"
+	entries, err := audit.Read(strings.NewReader(full + `{"event":"mi`))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("read %d entries, %v, want the torn last line left out", len(entries), err)
+	}
+	if _, err := audit.Read(strings.NewReader(`{"event":"mi` + "\n" + full)); err == nil {
+		t.Error("read a corrupt line in the middle of the log")
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"contract-storage-eth/bench"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/importer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func runBench(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	records := flags.Int("records", 100, "synthetic records to write")
+	concurrency := flags.Int("concurrency", 8, "transactions of each nonce lane awaiting confirmation at once")
+	batch := flags.Int("batch", 100, "records sent before waiting for them")
+	size := flags.Int("size", 256, "bytes of each value, before sealing")
+	prefix := flags.String("key-prefix", "_bench/", "put in front of the keys, followed by the run and record numbers")
+	field := flags.String("field", "value", "field of the records")
+	addSignerFlags(flags, config)
+	addPoolFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *records <= 0 || *concurrency <= 0 || *batch <= 0 || *size <= 0 {
+		return fail(codeInvalidArgument, "Invalid flags: --records, --concurrency, --batch and --size must be positive")
+	}
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return err
+	} else if viaSafe {
+		return errors.New("bench sends its transactions directly, unset safe.address to use it")
+	}
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	// Keys are new on every run, so that each write fills empty storage
+	// like the records of production do
+	run := time.Now().UTC().Format("20060102T150405")
+	items := make([]importItem, *records)
+	for i := range items {
+		value := make([]byte, (*size+1)/2)
+		if _, err := rand.Read(value); err != nil {
+			return fmt.Errorf("Failed to generate values: %w", err)
+		}
+		items[i] = importItem{index: i, record: &importer.Record{
+			Key:   fmt.Sprintf("%s%s/%06d", *prefix, run, i),
+			Field: *field,
+			Value: hex.EncodeToString(value)[:*size],
+		}}
+	}
+
+	im, closeSigners, err := newImportRun(ctx, config, client, address, *concurrency)
+	if err != nil {
+		return err
+	}
+	defer closeSigners()
+	samples := make([]bench.Sample, *records)
+	sentAt := make([]time.Time, *records)
+	txs := make([]*types.Transaction, *records)
+	im.onSent = func(index int, tx *types.Transaction) {
+		sentAt[index], txs[index] = time.Now(), tx
+	}
+	im.onResult = func(index int, tx common.Hash, err error) {
+		samples[index].Err = err
+		if !sentAt[index].IsZero() {
+			samples[index].Latency = time.Since(sentAt[index])
+		}
+	}
+
+	fmt.Printf("Writing %d record(s) of %d bytes to %s from %d lane(s), %d at a time each\n", *records, *size, address.Hex(), len(im.lanes), *concurrency)
+	start := time.Now()
+	for i := 0; i < len(items) && ctx.Err() == nil; i += *batch {
+		if err := im.sendBatch(ctx, items[i:min(i+*batch, len(items))], 0); err != nil {
+			slog.Error("Benchmark stopped early", "error", err)
+			break
+		}
+	}
+	elapsed := time.Since(start)
+	// Records never sent are left out of the report
+	n := 0
+	for i := range samples {
+		if txs[i] != nil || samples[i].Err != nil {
+			samples[n], txs[n] = samples[i], txs[i]
+			n++
+		}
+	}
+	samples, txs = samples[:n], txs[:n]
+
+	// Gas and cost are those of the receipts, with the L1 data fee of
+	// rollups on top
+	oracle := l1Oracle(config, client)
+	for i := range samples {
+		if samples[i].Err != nil {
+			continue
+		}
+		receipt, err := client.TransactionReceipt(context.WithoutCancel(ctx), txs[i].Hash())
+		if err != nil {
+			return fmt.Errorf("Failed to get receipt: %w", err)
+		}
+		samples[i].GasUsed = receipt.GasUsed
+		samples[i].Cost = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+		if oracle != nil {
+			if fee, ok, err := oracle.L1Fee(ctx, txs[i]); err != nil {
+				slog.Warn("Failed to get L1 data fee", "tx_hash", txs[i].Hash().Hex(), "error", err)
+			} else if ok {
+				samples[i].Cost.Add(samples[i].Cost, fee)
+			}
+		}
+	}
+
+	report := bench.Summarize(samples, elapsed)
+	result := benchResult{Report: report, Size: *size, Lanes: len(im.lanes), Concurrency: *concurrency, Batch: *batch}
+	// Costs are converted like those of estimate
+	if e, err := newEstimator(config, client, ""); err == nil && e.Price != nil {
+		if price, err := e.Price.Price(ctx); err != nil {
+			slog.Warn("Failed to get the ether price", "error", err)
+		} else {
+			result.Fiat = &benchFiat{Currency: e.Price.Currency(), Total: fees.Ether(report.Cost) * price, PerRecord: fees.Ether(report.CostPerRecord) * price}
+		}
+	}
+	printBenchReport(result)
+	if report.Failed > 0 || report.Records < *records {
+		printFailure("bench_incomplete", fmt.Sprintf("%d of %d record(s) not written", *records-report.Written, *records), result)
+		return exitError(1)
+	}
+	printResult(result)
+	return nil
+}
+
+// benchResult is the result of bench in JSON output mode
+type benchResult struct {
+	*bench.Report
+	Size        int        `json:"size"`
+	Lanes       int        `json:"lanes"`
+	Concurrency int        `json:"concurrency"`
+	Batch       int        `json:"batch"`
+	Fiat        *benchFiat `json:"fiat,omitempty"`
+}
+
+type benchFiat struct {
+	Currency  string  `json:"currency"`
+	Total     float64 `json:"total"`
+	PerRecord float64 `json:"per_record"`
+}
+
+func printBenchReport(r benchResult) {
+	ms := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	fmt.Printf("\nWrote %d of %d record(s) in %s, %d failed\n", r.Written, r.Records, ms(r.Duration), r.Failed)
+	if r.Written == 0 {
+		return
+	}
+	fmt.Printf("Throughput: %.2f records/s\n", r.Throughput)
+	fmt.Printf("Latency:    min %s, mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		ms(r.Latency.Min), ms(r.Latency.Mean), ms(r.Latency.P50), ms(r.Latency.P90), ms(r.Latency.P95), ms(r.Latency.P99), ms(r.Latency.Max))
+	fmt.Printf("Gas:        %d per record (min %d, max %d), %d total\n", r.Gas.PerRecord, r.Gas.Min, r.Gas.Max, r.Gas.Total)
+	fmt.Printf("Cost:       %s ETH total, %s ETH per record\n", fees.FormatEther(r.Cost), fees.FormatEther(r.CostPerRecord))
+	if r.Fiat != nil {
+		fmt.Printf("            %.2f %s total, %.6f %s per record\n", r.Fiat.Total, r.Fiat.Currency, r.Fiat.PerRecord, r.Fiat.Currency)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench summarizes the writes of a load test: throughput, latency
+// percentiles, gas per record and total cost.
+package bench
+
+import (
+	"math/big"
+	"slices"
+	"time"
+)
+
+// Sample is the outcome of one write.
+type Sample struct {
+	// Latency is the time from sending the transaction to its receipt.
+	Latency time.Duration
+	GasUsed uint64
+	// Cost is what the transaction paid in wei, L1 data fees included.
+	Cost *big.Int
+	// Err is set when the write failed, whose other fields are then
+	// ignored.
+	Err error
+}
+
+// Report summarizes the samples of a run.
+type Report struct {
+	Records  int           `json:"records"`
+	Written  int           `json:"written"`
+	Failed   int           `json:"failed"`
+	Duration time.Duration `json:"duration"`
+	// Throughput is the number of records written per second.
+	Throughput float64 `json:"throughput"`
+	Latency    Latency `json:"latency"`
+	Gas        Gas     `json:"gas"`
+	// Cost is the total cost in wei, and CostPerRecord its mean per
+	// record written.
+	Cost          *big.Int `json:"cost"`
+	CostPerRecord *big.Int `json:"cost_per_record"`
+}
+
+// Latency holds the distribution of the latencies of the writes.
+type Latency struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Gas holds the gas used by the writes.
+type Gas struct {
+	Total     uint64 `json:"total"`
+	PerRecord uint64 `json:"per_record"`
+	Min       uint64 `json:"min"`
+	Max       uint64 `json:"max"`
+}
+
+// Summarize reports on samples written over elapsed.
+func Summarize(samples []Sample, elapsed time.Duration) *Report {
+	r := &Report{Records: len(samples), Duration: elapsed, Cost: new(big.Int), CostPerRecord: new(big.Int)}
+	var latencies []time.Duration
+	var total time.Duration
+	for _, s := range samples {
+		if s.Err != nil {
+			r.Failed++
+			continue
+		}
+		r.Written++
+		latencies = append(latencies, s.Latency)
+		total += s.Latency
+		r.Gas.Total += s.GasUsed
+		if r.Written == 1 || s.GasUsed < r.Gas.Min {
+			r.Gas.Min = s.GasUsed
+		}
+		r.Gas.Max = max(r.Gas.Max, s.GasUsed)
+		if s.Cost != nil {
+			r.Cost.Add(r.Cost, s.Cost)
+		}
+	}
+	if r.Written == 0 {
+		return r
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(r.Written) / elapsed.Seconds()
+	}
+	slices.Sort(latencies)
+	r.Latency = Latency{
+		Min:  latencies[0],
+		Mean: total / time.Duration(r.Written),
+		P50:  Percentile(latencies, 50),
+		P90:  Percentile(latencies, 90),
+		P95:  Percentile(latencies, 95),
+		P99:  Percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+	r.Gas.PerRecord = r.Gas.Total / uint64(r.Written)
+	r.CostPerRecord.Div(r.Cost, big.NewInt(int64(r.Written)))
+	return r
+}
+
+// Percentile returns the p-th percentile of sorted by the nearest-rank
+// method, zero when sorted is empty.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted)) + 0.999999)
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"contract-storage-eth/bench"
+)
+
+func TestSummarize(t *testing.T) {
+	var samples []bench.Sample
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, bench.Sample{Latency: time.Duration(i) * time.Millisecond, GasUsed: uint64(50000 + i), Cost: big.NewInt(int64(1000 * i))})
+	}
+	samples = append(samples, bench.Sample{Err: errors.New("reverted"), GasUsed: 1})
+
+	r := bench.Summarize(samples, 10*time.Second)
+	if r.Records != 101 || r.Written != 100 || r.Failed != 1 || r.Throughput != 10 {
+		t.Errorf("counts %+v", r)
+	}
+	want := bench.Latency{Min: time.Millisecond, Mean: 50500 * time.Microsecond, P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond, Max: 100 * time.Millisecond}
+	if r.Latency != want {
+		t.Errorf("latency %+v, want %+v", r.Latency, want)
+	}
+	if r.Gas != (bench.Gas{Total: 5005050, PerRecord: 50050, Min: 50001, Max: 50100}) {
+		t.Errorf("gas %+v", r.Gas)
+	}
+	if r.Cost.Int64() != 5050000 || r.CostPerRecord.Int64() != 50500 {
+		t.Errorf("cost %s, per record %s", r.Cost, r.CostPerRecord)
+	}
+}
+
+func TestSummarizeFailures(t *testing.T) {
+	r := bench.Summarize([]bench.Sample{{Err: errors.New("reverted")}}, time.Second)
+	if r.Written != 0 || r.Failed != 1 || r.Throughput != 0 || r.Cost.Sign() != 0 {
+		t.Errorf("report %+v", r)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4}
+	for p, want := range map[float64]time.Duration{0: 1, 25: 1, 50: 2, 51: 3, 99: 4, 100: 4} {
+		if got := bench.Percentile(sorted, p); got != want {
+			t.Errorf("p%v = %d, want %d", p, got, want)
+		}
+	}
+	if bench.Percentile(nil, 50) != 0 {
+		t.Error("percentile of no samples")
+	}
+}
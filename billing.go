@@ -0,0 +1,170 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"contract-storage-eth/indexer"
+)
+
+const billingUsage = `Usage: contract-storage-eth billing <command> [flags]
+
+Commands:
+  report    Export gas, writes and storage bytes per tenant as CSV or JSON
+`
+
+// loadTenancy returns the configured tenant attribution
+func loadTenancy(config *Config) (indexer.Tenancy, error) {
+	return indexer.ParseTenancy(config.Billing.TenantBy, config.Billing.Separator, config.Billing.Tag)
+}
+
+func runBilling(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, billingUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "report":
+		return runBillingReport(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown billing command: %s\n\n%s", args[0], billingUsage)
+		return exitError(2)
+	}
+}
+
+func runBillingReport(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("billing report", flag.ContinueOnError)
+	from := flags.String("from", "", "start of the period, RFC 3339 or YYYY-MM-DD (inclusive)")
+	to := flags.String("to", "", "end of the period, RFC 3339 or YYYY-MM-DD (exclusive)")
+	format := flags.String("format", "csv", "output format: csv or json")
+	outFile := flags.String("output", "", "write the report to this file instead of stdout")
+	noSync := flags.Bool("no-sync", false, "report from the local index without syncing it first")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	start, err := parseDay(*from)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --from: %w", err)
+	}
+	end, err := parseDay(*to)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --to: %w", err)
+	}
+	if *format != "csv" && *format != "json" {
+		return fail(codeInvalidArgument, "Invalid --format: want csv or json, got %v", *format)
+	}
+	t, err := loadTenancy(config)
+	if err != nil {
+		return fail(codeConfig, "Invalid billing config: %w", err)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+
+	usage, err := store.Usage(t, start, end)
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+
+	if output.json && *outFile == "" {
+		printResult(billingReport{TenantBy: t.By, Tenants: usage})
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			return fmt.Errorf("Failed to create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		err = writeBillingJSON(w, t, usage)
+	} else {
+		err = writeBillingCSV(w, usage)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write report: %w", err)
+	}
+	if *outFile != "" {
+		printResult(exportResult{File: *outFile, Format: *format, Records: len(usage)})
+	}
+	return nil
+}
+
+// parseDay accepts an RFC 3339 time, with or without seconds, or a UTC
+// date, empty meaning unbounded
+func parseDay(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02T15:04Z07:00", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func writeBillingCSV(w io.Writer, usage []*indexer.Usage) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"tenant", "writes", "storage_bytes", "gas_used", "failures"})
+	for _, u := range usage {
+		out.Write([]string{
+			u.Tenant,
+			strconv.Itoa(u.Writes),
+			strconv.FormatUint(u.StorageBytes, 10),
+			strconv.FormatUint(u.GasUsed, 10),
+			strconv.Itoa(u.Failures),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// billingReport is the JSON report of billing report
+type billingReport struct {
+	TenantBy string           `json:"tenant_by"`
+	Tenants  []*indexer.Usage `json:"tenants"`
+}
+
+func writeBillingJSON(w io.Writer, t indexer.Tenancy, usage []*indexer.Usage) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(billingReport{t.By, usage})
+}
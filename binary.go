@@ -0,0 +1,76 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"contract-storage-eth/envelope"
+)
+
+// Encodings of binary values on the command line and in the APIs
+const (
+	encodingHex    = "hex"
+	encodingBase64 = "base64"
+)
+
+// decodeBinary decodes a binary value given as hex, with or without 0x,
+// or as standard base64
+func decodeBinary(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case encodingHex:
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+		return hex.DecodeString(s)
+	case encodingBase64:
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return nil, fmt.Errorf("unknown encoding %q, want hex or base64", encoding)
+}
+
+// contentCodec returns the codec to seal content with: bytes unless it is
+// text
+func contentCodec(content []byte) envelope.Codec {
+	if utf8.Valid(content) {
+		return envelope.CodecText
+	}
+	return envelope.CodecBytes
+}
+
+// openContent opens a stored value like openValue, reporting whether its
+// content is binary: sealed as bytes, or not valid UTF-8 text
+func openContent(value string) ([]byte, bool, error) {
+	content, codec, err := openValue(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return content, codec == envelope.CodecBytes || !utf8.Valid(content), nil
+}
+
+// recordContent returns the content of a stored value for JSON output,
+// base64 encoded when binary, with its encoding
+func recordContent(value string) (content, encoding string) {
+	opened, binary, err := openContent(value)
+	if err != nil {
+		return decodeValue(value), ""
+	}
+	if binary {
+		return base64.StdEncoding.EncodeToString(opened), encodingBase64
+	}
+	return string(opened), ""
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Exit code of verify-bytecode when the deployed code differs from the build
+const exitBytecodeMismatch = 4
+
+func runVerifyBytecode(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("verify-bytecode", flag.ContinueOnError)
+	addressFlag := flags.String("address", config.Contract.Address, "contract to check")
+	deployTx := flags.String("deploy-tx", "", "deployment transaction to compare with the creation bytecode, when the build has no .bin-runtime file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *addressFlag == "" {
+		return fail(codeInvalidArgument, "verify-bytecode: --address or contract.address is required")
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	address, err := resolveAddress(ctx, config, client, *addressFlag)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --address: %w", err)
+	}
+
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to get contract code: %w", err)
+	}
+	if len(code) == 0 {
+		if err := bytecodeMismatch(address, fmt.Sprintf("no contract code at %s", address.Hex())); err != nil {
+			return err
+		}
+	}
+
+	base := filepath.Join(config.Build.Directory, config.Build.ContractName)
+	runtime, err := readHexFile(base + ".bin-runtime")
+	switch {
+	case err == nil:
+		// The metadata hash changes with unrelated source details such as
+		// comments, so only the executable part is compared
+		if !bytes.Equal(stripMetadata(code), stripMetadata(runtime)) {
+			if err := bytecodeMismatch(address, fmt.Sprintf("code at %s differs from %s.bin-runtime", address.Hex(), base)); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(code, runtime) {
+			bytecodeVerified(address, fmt.Sprintf("code at %s matches %s.bin-runtime, except for its metadata hash", address.Hex(), base))
+			return nil
+		}
+		bytecodeVerified(address, fmt.Sprintf("code at %s matches %s.bin-runtime", address.Hex(), base))
+	case errors.Is(err, os.ErrNotExist) && *deployTx != "":
+		creation, err := readHexFile(base + ".bin")
+		if err != nil {
+			return fmt.Errorf("Failed to read bytecode file: %w", err)
+		}
+		hash := common.HexToHash(*deployTx)
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("Failed to get deployment receipt: %w", err)
+		}
+		if receipt.ContractAddress != address {
+			if err := bytecodeMismatch(address, fmt.Sprintf("transaction %s deployed %s, not %s", hash.Hex(), receipt.ContractAddress.Hex(), address.Hex())); err != nil {
+				return err
+			}
+		}
+		tx, _, err := client.TransactionByHash(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("Failed to get deployment transaction: %w", err)
+		}
+		// Constructor arguments follow the creation code
+		if !bytes.HasPrefix(tx.Data(), creation) {
+			if err := bytecodeMismatch(address, fmt.Sprintf("transaction %s did not deploy %s.bin", hash.Hex(), base)); err != nil {
+				return err
+			}
+		}
+		bytecodeVerified(address, fmt.Sprintf("%s was deployed from %s.bin by transaction %s", address.Hex(), base, hash.Hex()))
+	case errors.Is(err, os.ErrNotExist):
+		return fail(codeNotFound, "verify-bytecode: %s.bin-runtime not found, build it with `solc --bin-runtime` or pass --deploy-tx", base)
+	default:
+		return fmt.Errorf("Failed to read runtime bytecode file: %w", err)
+	}
+	return nil
+}
+
+// bytecodeResult is the result of verify-bytecode in JSON output mode
+type bytecodeResult struct {
+	Address  common.Address `json:"address"`
+	Verified bool           `json:"verified"`
+	Detail   string         `json:"detail"`
+}
+
+// bytecodeVerified reports matching code
+func bytecodeVerified(address common.Address, detail string) {
+	fmt.Printf("VERIFIED: %s\n", detail)
+	printResult(bytecodeResult{Address: address, Verified: true, Detail: detail})
+}
+
+// bytecodeMismatch reports code that does not match and exits
+func bytecodeMismatch(address common.Address, detail string) error {
+	fmt.Printf("MISMATCH: %s\n", detail)
+	printFailure("mismatch", detail, bytecodeResult{Address: address, Detail: detail})
+	return exitError(exitBytecodeMismatch)
+}
+
+func readHexFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return common.FromHex(strings.TrimSpace(string(data))), nil
+}
+
+// stripMetadata removes the CBOR metadata solc appends to the code, whose
+// length is given by the last two bytes
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	n := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if n+2 > len(code) || n == 0 {
+		return code
+	}
+	// The CBOR map starts with 0xa1 or 0xa2 entries in every solc version
+	if start := code[len(code)-2-n]; start < 0xa1 || start > 0xa5 {
+		return code
+	}
+	return code[:len(code)-2-n]
+}
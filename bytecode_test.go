@@ -0,0 +1,112 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Runtime code ending with solc metadata: a CBOR map and its length
+var (
+	runtimeCode  = common.FromHex("0x6080604052348015600f57600080fd5b50")
+	metadata     = common.FromHex("0xa264697066735822beef0033")
+	otherRuntime = common.FromHex("0x6080604052600080fd")
+)
+
+func TestStripMetadata(t *testing.T) {
+	code := append(append([]byte{}, runtimeCode...), metadata...)
+	code = append(code, 0x00, byte(len(metadata)))
+	if got := stripMetadata(code); !bytes.Equal(got, runtimeCode) {
+		t.Errorf("stripMetadata = %x, want %x", got, runtimeCode)
+	}
+	// Code without metadata, or whose last bytes only look like a length
+	for _, code := range [][]byte{runtimeCode, {}, {0x00, 0x05}, append([]byte{0x60, 0x80, 0x60}, 0x00, 0x02)} {
+		if got := stripMetadata(code); !bytes.Equal(got, code) {
+			t.Errorf("stripMetadata(%x) = %x", code, got)
+		}
+	}
+}
+
+func TestVerifyBytecode(t *testing.T) {
+	deployed := append(append([]byte{}, runtimeCode...), metadata...)
+	deployed = append(deployed, 0x00, byte(len(metadata)))
+	// The node of a chain whose contract 0x...c5 has the deployed code,
+	// and no code anywhere else
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := `"0x539"`
+		if req.Method == "eth_getCode" {
+			result = `"0x"`
+			if strings.Contains(strings.ToLower(string(req.Params[0])), "c5") {
+				result = fmt.Sprintf(`"%#x"`, deployed)
+			}
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer node.Close()
+
+	// Another build of the same source differs in its metadata hash only
+	rebuilt := append(append([]byte{}, runtimeCode...), common.FromHex("0xa264697066735822cafe0033")...)
+	rebuilt = append(rebuilt, 0x00, byte(len(metadata)))
+
+	tests := []struct {
+		name    string
+		runtime []byte
+		address string
+		status  int
+		want    string
+	}{
+		{"identical", deployed, "0x00000000000000000000000000000000000000c5", 0, "VERIFIED: code at 0x00000000000000000000000000000000000000c5 matches Storage.bin-runtime"},
+		{"other metadata", rebuilt, "0x00000000000000000000000000000000000000c5", 0, "matches Storage.bin-runtime, except for its metadata hash"},
+		{"other code", otherRuntime, "0x00000000000000000000000000000000000000c5", exitBytecodeMismatch, "MISMATCH: code at 0x00000000000000000000000000000000000000c5 differs from Storage.bin-runtime"},
+		{"no contract", deployed, "0x00000000000000000000000000000000000000d6", exitBytecodeMismatch, "MISMATCH: no contract code at 0x00000000000000000000000000000000000000D6"},
+		{"no build", nil, "0x00000000000000000000000000000000000000c5", 1, "Storage.bin-runtime not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// No key is configured: verifying the code only reads the chain
+			config := "ethereum:\n  rpc_url: " + node.URL + "\nbuild:\n  directory: .\n  contract_name: Storage\n"
+			if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.runtime != nil {
+				if err := os.WriteFile(filepath.Join(dir, "Storage.bin-runtime"), []byte(common.Bytes2Hex(tt.runtime)+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out, status := runMain(t, dir, "verify-bytecode", "--address", tt.address)
+			if status != tt.status || !strings.Contains(out, tt.want) {
+				t.Errorf("exit status %d, want %d with %q, output:\n%s", status, tt.status, tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"contract-storage-eth/cache"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/metrics"
+	"contract-storage-eth/recordid"
+)
+
+// openCache connects to the Redis cache of cache.redis_url, or returns nil
+// when none is configured. Entries are prefixed with the chain and contract
+// unless cache.prefix is set
+func openCache(ctx context.Context, config *Config, ns recordid.Namespace) (*cache.Redis, error) {
+	if config.Cache.RedisURL == "" {
+		return nil, nil
+	}
+	url, err := resolveSecret(config.Cache.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("cache.redis_url: %w", err)
+	}
+	prefix := config.Cache.Prefix
+	if prefix == "" {
+		prefix = fmt.Sprintf("cse:%d:%s:", ns.ChainID, ns.Contract.Hex())
+	}
+	c, err := cache.NewRedis(cache.Options{URL: url, Prefix: prefix, TTL: config.Cache.TTL})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Ping(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// cachedValue reads the latest value of a key and field through the
+// cache, caching what it reads. Reads go to the contract when the cache
+// fails
+func (s *recordService) cachedValue(ctx context.Context, key, field string) (string, error) {
+	value, ok, err := s.cache.Get(ctx, key, field)
+	if err != nil {
+		slog.Warn("Failed to read cache", "key", key, "field", field, "error", err)
+	}
+	metrics.CacheLookup("redis", ok)
+	if ok {
+		return value, nil
+	}
+	if value, err = readRecordValue(ctx, s.client, s.config, s.address, key, field, nil); err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(ctx, key, field, value); err != nil {
+		slog.Warn("Failed to fill cache", "key", key, "field", field, "error", err)
+	}
+	return value, nil
+}
+
+// invalidateCache drops the cached values of every key and field the index
+// sees written until ctx is done. The position is kept in Redis, so writes
+// made while the server was down are caught up with on start; a cache
+// without one starts after the events indexed already
+func invalidateCache(ctx context.Context, config *Config, store *indexer.Store, c *cache.Redis) {
+	interval := config.Index.SyncInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	cache.Follow(ctx, store, c, interval, func(err error) {
+		slog.Warn("Failed to invalidate cache", "error", err)
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache keeps the values read from the contract, so that hot keys
+// are not read over RPC on every request: in Redis, shared by the
+// processes using it, or in a bounded in-memory LRU. Entries can expire
+// after a TTL and are invalidated as soon as a write to their key and
+// field is observed, so the TTL only bounds how stale an entry can get
+// when a write goes unnoticed.
+package cache
+
+import (
+	"context"
+	"time"
+
+	"contract-storage-eth/indexer"
+)
+
+// Pair is a key and field.
+type Pair struct {
+	Key   string
+	Field string
+}
+
+// Cache holds the latest values of key/field pairs. An unset value is
+// cached as "".
+type Cache interface {
+	// Get returns the cached value of a key and field, and whether there
+	// was one.
+	Get(ctx context.Context, key, field string) (string, bool, error)
+	Set(ctx context.Context, key, field, value string) error
+	Invalidator
+}
+
+// Cursor is the position after the last write event invalidated.
+type Cursor struct {
+	Block uint64
+	Log   uint
+}
+
+// Invalidator drops cached values as their key and field are written.
+type Invalidator interface {
+	// Invalidate drops the cached values of pairs.
+	Invalidate(ctx context.Context, pairs ...Pair) error
+	// Cursor returns the position the invalidation got to, and false when
+	// there is none yet.
+	Cursor(ctx context.Context) (Cursor, bool, error)
+	SetCursor(ctx context.Context, c Cursor) error
+}
+
+// Source is where write events are read from, such as an *indexer.Store.
+type Source interface {
+	Query(q indexer.Query) ([]*indexer.Record, error)
+	NextBlock(start uint64) (uint64, error)
+}
+
+// Invalidate invalidates the writes of source after the cursor of c,
+// moving the cursor after them. Without a cursor, the writes the source
+// holds already are skipped.
+func Invalidate(ctx context.Context, source Source, c Invalidator) error {
+	cursor, ok, err := c.Cursor(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		next, err := source.NextBlock(0)
+		if err != nil {
+			return err
+		}
+		return c.SetCursor(ctx, Cursor{Block: next})
+	}
+	for {
+		records, err := source.Query(indexer.Query{FromBlock: cursor.Block, FromLog: cursor.Log, Limit: 500})
+		if err != nil || len(records) == 0 {
+			return err
+		}
+		pairs := make([]Pair, len(records))
+		for i, r := range records {
+			pairs[i] = Pair{Key: r.Key, Field: r.Field}
+		}
+		if err := c.Invalidate(ctx, pairs...); err != nil {
+			return err
+		}
+		last := records[len(records)-1]
+		cursor = Cursor{Block: last.BlockNumber, Log: last.LogIndex + 1}
+		if err := c.SetCursor(ctx, cursor); err != nil {
+			return err
+		}
+	}
+}
+
+// Follow calls Invalidate every interval until ctx is done, passing its
+// errors to onError when not nil.
+func Follow(ctx context.Context, source Source, c Invalidator, interval time.Duration, onError func(error)) {
+	for {
+		if err := Invalidate(ctx, source, c); err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultSize is the number of entries of an LRU when LRUOptions.Size is
+// zero.
+const DefaultSize = 10000
+
+// LRUOptions configures an LRU.
+type LRUOptions struct {
+	// Size bounds the number of entries, the least recently used ones
+	// being evicted first.
+	Size int
+	// TTL is the lifetime of the entries, unbounded when zero.
+	TTL time.Duration
+	// OnLookup is called on each Get, with whether it was a hit, such as
+	// to export a hit rate metric.
+	OnLookup func(hit bool)
+}
+
+// LRU is a bounded in-memory cache, for processes without Redis. It is
+// safe for concurrent use.
+type LRU struct {
+	opts LRUOptions
+
+	mu      sync.Mutex
+	entries map[Pair]*list.Element
+	order   *list.List
+	cursor  *Cursor
+	stats   Stats
+}
+
+type lruEntry struct {
+	pair    Pair
+	value   string
+	expires time.Time
+}
+
+// Stats counts the lookups of an LRU.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// HitRate returns the share of lookups that were hits, 0 without lookups.
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewLRU returns an empty LRU.
+func NewLRU(opts LRUOptions) *LRU {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSize
+	}
+	return &LRU{opts: opts, entries: map[Pair]*list.Element{}, order: list.New()}
+}
+
+// Get implements Cache.
+func (c *LRU) Get(ctx context.Context, key, field string) (string, bool, error) {
+	value, ok := c.get(Pair{Key: key, Field: field})
+	if c.opts.OnLookup != nil {
+		c.opts.OnLookup(ok)
+	}
+	return value, ok, nil
+}
+
+func (c *LRU) get(p Pair) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[p]
+	if ok && c.expired(el.Value.(*lruEntry)) {
+		c.remove(el)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return "", false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+// Set implements Cache.
+func (c *LRU) Set(ctx context.Context, key, field, value string) error {
+	p := Pair{Key: key, Field: field}
+	var expires time.Time
+	if c.opts.TTL > 0 {
+		expires = time.Now().Add(c.opts.TTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[p]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.entries[p] = c.order.PushFront(&lruEntry{pair: p, value: value, expires: expires})
+	for c.order.Len() > c.opts.Size {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+	return nil
+}
+
+// Invalidate implements Invalidator.
+func (c *LRU) Invalidate(ctx context.Context, pairs ...Pair) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range pairs {
+		if el, ok := c.entries[p]; ok {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+// Cursor implements Invalidator. The cursor is kept in memory, so a new
+// LRU starts after the writes indexed already, having nothing cached.
+func (c *LRU) Cursor(ctx context.Context) (Cursor, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cursor == nil {
+		return Cursor{}, false, nil
+	}
+	return *c.cursor, true, nil
+}
+
+// SetCursor implements Invalidator.
+func (c *LRU) SetCursor(ctx context.Context, cursor Cursor) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cursor = &cursor
+	return nil
+}
+
+// Stats returns the counts of lookups since the LRU was created.
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *LRU) expired(e *lruEntry) bool {
+	return !e.expires.IsZero() && time.Now().After(e.expires)
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).pair)
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"contract-storage-eth/cache"
+	"contract-storage-eth/indexer"
+)
+
+func TestLRU(t *testing.T) {
+	var hits, misses int
+	c := cache.NewLRU(cache.LRUOptions{Size: 2, OnLookup: func(hit bool) {
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+	}})
+	ctx := context.Background()
+
+	c.Set(ctx, "a", "f", "1")
+	c.Set(ctx, "b", "f", "2")
+	c.Get(ctx, "a", "f")
+	// b is the least recently used, so c evicts it
+	c.Set(ctx, "c", "f", "3")
+	if _, ok, _ := c.Get(ctx, "b", "f"); ok {
+		t.Error("b was not evicted")
+	}
+	if value, ok, _ := c.Get(ctx, "a", "f"); !ok || value != "1" {
+		t.Errorf("got %q, %v for a", value, ok)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 || hits != 2 || misses != 1 {
+		t.Errorf("stats %+v, %d hits and %d misses reported", stats, hits, misses)
+	}
+	if rate := stats.HitRate(); rate < 0.66 || rate > 0.67 {
+		t.Errorf("hit rate %f, want 2/3", rate)
+	}
+}
+
+func TestLRUTTL(t *testing.T) {
+	c := cache.NewLRU(cache.LRUOptions{TTL: 10 * time.Millisecond})
+	ctx := context.Background()
+	c.Set(ctx, "a", "f", "1")
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "a", "f"); ok {
+		t.Error("hit an expired entry")
+	}
+}
+
+// source serves write events from memory
+type source []*indexer.Record
+
+func (s source) Query(q indexer.Query) ([]*indexer.Record, error) {
+	var out []*indexer.Record
+	for _, r := range s {
+		if r.BlockNumber > q.FromBlock || r.BlockNumber == q.FromBlock && r.LogIndex >= q.FromLog {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s source) NextBlock(start uint64) (uint64, error) {
+	if len(s) == 0 {
+		return start, nil
+	}
+	return s[len(s)-1].BlockNumber + 1, nil
+}
+
+func TestInvalidate(t *testing.T) {
+	c := cache.NewLRU(cache.LRUOptions{})
+	ctx := context.Background()
+	events := source{{Key: "a", Field: "f", BlockNumber: 1}}
+
+	// The first pass skips the writes indexed already
+	c.Set(ctx, "a", "f", "1")
+	if err := cache.Invalidate(ctx, events, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "a", "f"); !ok {
+		t.Error("invalidated a write seen before the cache was filled")
+	}
+
+	events = append(events, &indexer.Record{Key: "a", Field: "f", BlockNumber: 2})
+	if err := cache.Invalidate(ctx, events, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "a", "f"); ok {
+		t.Error("kept a value written since")
+	}
+	if cursor, _, _ := c.Cursor(ctx); cursor != (cache.Cursor{Block: 2, Log: 1}) {
+		t.Errorf("cursor %+v", cursor)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL is the lifetime of the entries when Options.TTL is zero.
+const DefaultTTL = 5 * time.Minute
+
+// Options configures a Redis cache.
+type Options struct {
+	// URL is the address of the server, such as redis://:password@host:6379/0.
+	URL string
+	// Prefix starts the name of every Redis key, so that the caches of
+	// several contracts can share a server.
+	Prefix string
+	TTL    time.Duration
+}
+
+// Redis is a cache of contract values in Redis.
+type Redis struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedis connects to the server of opts.URL.
+func NewRedis(opts Options) (*Redis, error) {
+	redisOpts, err := redis.ParseURL(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Redis{client: redis.NewClient(redisOpts), prefix: opts.Prefix, ttl: ttl}, nil
+}
+
+// Ping checks that the server answers.
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the connections to the server.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// entry returns the Redis key of a key and field, escaped so that no two
+// pairs share one
+func (r *Redis) entry(key, field string) string {
+	return r.prefix + "value:" + url.QueryEscape(key) + "#" + url.QueryEscape(field)
+}
+
+// Get implements Cache.
+func (r *Redis) Get(ctx context.Context, key, field string) (string, bool, error) {
+	value, err := r.client.Get(ctx, r.entry(key, field)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("cache: %w", err)
+	}
+	return value, true, nil
+}
+
+// Set implements Cache.
+func (r *Redis) Set(ctx context.Context, key, field, value string) error {
+	if err := r.client.Set(ctx, r.entry(key, field), value, r.ttl).Err(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate implements Invalidator.
+func (r *Redis) Invalidate(ctx context.Context, pairs ...Pair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	entries := make([]string, len(pairs))
+	for i, p := range pairs {
+		entries[i] = r.entry(p.Key, p.Field)
+	}
+	if err := r.client.Del(ctx, entries...).Err(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
+
+// Cursor implements Invalidator. The cursor is kept in Redis with the
+// entries it invalidates, so it survives restarts of the processes
+// sharing the cache.
+func (r *Redis) Cursor(ctx context.Context) (Cursor, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+"cursor").Result()
+	if errors.Is(err, redis.Nil) {
+		return Cursor{}, false, nil
+	}
+	if err != nil {
+		return Cursor{}, false, fmt.Errorf("cache: %w", err)
+	}
+	block, log, ok := strings.Cut(value, ":")
+	b, errBlock := strconv.ParseUint(block, 10, 64)
+	l, errLog := strconv.ParseUint(log, 10, 32)
+	if !ok || errBlock != nil || errLog != nil {
+		return Cursor{}, false, fmt.Errorf("cache: malformed cursor %q", value)
+	}
+	return Cursor{Block: b, Log: uint(l)}, true, nil
+}
+
+// SetCursor implements Invalidator.
+func (r *Redis) SetCursor(ctx context.Context, c Cursor) error {
+	if err := r.client.Set(ctx, r.prefix+"cursor", fmt.Sprintf("%d:%d", c.Block, c.Log), 0).Err(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
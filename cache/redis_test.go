@@ -0,0 +1,88 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"contract-storage-eth/cache"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newCache(t *testing.T) (*cache.Redis, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	c, err := cache.NewRedis(cache.Options{URL: "redis://" + server.Addr(), Prefix: "cse:1:", TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, server
+}
+
+func TestGetSet(t *testing.T) {
+	c, server := newCache(t)
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "a", "f"); ok || err != nil {
+		t.Fatalf("hit an empty cache: %v", err)
+	}
+	if err := c.Set(ctx, "a", "f", "1"); err != nil {
+		t.Fatal(err)
+	}
+	// Unset values are cached too
+	if err := c.Set(ctx, "a#f", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := c.Get(ctx, "a", "f"); value != "1" || !ok || err != nil {
+		t.Errorf("got %q, %v, %v", value, ok, err)
+	}
+	if value, ok, _ := c.Get(ctx, "a#f", ""); value != "" || !ok {
+		t.Errorf("got %q, %v for a#f", value, ok)
+	}
+
+	if err := c.Invalidate(ctx, cache.Pair{Key: "a", Field: "f"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "a", "f"); ok {
+		t.Error("hit an invalidated entry")
+	}
+	if _, ok, _ := c.Get(ctx, "a#f", ""); !ok {
+		t.Error("invalidated another pair")
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, ok, _ := c.Get(ctx, "a#f", ""); ok {
+		t.Error("hit an expired entry")
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c, _ := newCache(t)
+	ctx := context.Background()
+	if _, ok, err := c.Cursor(ctx); ok || err != nil {
+		t.Fatalf("found a cursor in an empty cache: %v", err)
+	}
+	want := cache.Cursor{Block: 42, Log: 3}
+	if err := c.SetCursor(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := c.Cursor(ctx); got != want || !ok || err != nil {
+		t.Errorf("got %+v, %v, %v", got, ok, err)
+	}
+}
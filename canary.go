@@ -0,0 +1,145 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	"contract-storage-eth/canary"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// newCanary returns a runner writing canaries to the contract at address
+// through the regular save path, with the signer active at each run
+func newCanary(config *Config, client *chain.Client, signers signer.Selector, chainID *big.Int, address common.Address) (*canary.Runner, error) {
+	contract, err := storage.Bind(address, client)
+	if err != nil {
+		return nil, err
+	}
+
+	field := config.Canary.Field
+	if field == "" {
+		if field, err = os.Hostname(); err != nil {
+			return nil, err
+		}
+	}
+
+	key := config.Canary.Key
+	if key == "" {
+		key = canary.DefaultKey
+	}
+
+	return &canary.Runner{
+		Contract: address,
+		Key:      keyNamespace.Key(key),
+		Field:    field,
+		Timeout:  config.Canary.Timeout,
+		// Seal the value as save does, so the envelope path is covered too
+		Value: func() (string, error) {
+			return sealValue(config, []byte("canary "+time.Now().UTC().Format(time.RFC3339Nano)), nil)
+		},
+		Write: func(ctx context.Context, key, field, value string) (*types.Receipt, error) {
+			active, err := signers.Active(ctx)
+			if err != nil {
+				return nil, err
+			}
+			auth, err := signer.NewTransactOpts(ctx, active, chainID)
+			if err != nil {
+				return nil, err
+			}
+			tx, err := sendSave(ctx, client, contract, auth, config, key, field, value)
+			if err != nil {
+				return nil, err
+			}
+			return waitMined(ctx, client, tx, config)
+		},
+		Read: func(ctx context.Context, key, field string) (string, error) {
+			return readValue(ctx, client, config, address, key, field, nil)
+		},
+	}, nil
+}
+
+func printCanary(result *canary.Result) {
+	if result.OK {
+		fmt.Printf("Canary %s#%s passed in %s (tx %s, block %d)\n", result.Key, result.Field, result.Duration.Round(time.Millisecond), result.TxHash.Hex(), result.Block)
+		return
+	}
+	fmt.Printf("Canary %s#%s FAILED at %s after %s: %s\n", result.Key, result.Field, result.Stage, result.Duration.Round(time.Millisecond), result.Error)
+}
+
+func runCanary(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("canary", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+
+	runner, err := newCanary(config, client, signers, chainID, address)
+	if err != nil {
+		return fmt.Errorf("Failed to set up canary: %w", err)
+	}
+	result := runner.Run(ctx)
+	switch {
+	case output.json && result.OK:
+		printResult(result)
+	case output.json:
+		printFailure("canary_failed", result.Error, result)
+	case *asJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	default:
+		printCanary(result)
+	}
+	if !result.OK {
+		return exitError(1)
+	}
+	return nil
+}
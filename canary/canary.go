@@ -0,0 +1,190 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canary checks the write path end to end by writing a canary
+// record, verifying its DataSaved event and reading it back, so that a
+// broken write path is noticed before real writes fail.
+package canary
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultKey is the reserved key canary records are written under.
+const DefaultKey = "_canary"
+
+// Stages of a canary run, as reported by a failed Result.
+const (
+	StageWrite = "write"
+	StageEvent = "event"
+	StageRead  = "read"
+)
+
+// WriteFunc saves value under key and field and returns the receipt of the
+// mined transaction.
+type WriteFunc func(ctx context.Context, key, field, value string) (*types.Receipt, error)
+
+// ReadFunc returns the value the contract holds for key and field.
+type ReadFunc func(ctx context.Context, key, field string) (string, error)
+
+// Result is the outcome of a canary run.
+type Result struct {
+	OK        bool          `json:"ok"`
+	Key       string        `json:"key"`
+	Field     string        `json:"field"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	TxHash    common.Hash   `json:"tx_hash,omitempty"`
+	Block     uint64        `json:"block,omitempty"`
+	// Stage is where a failed run stopped.
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Runner runs canaries against a contract and keeps the latest result. It
+// is safe for concurrent use.
+type Runner struct {
+	Contract common.Address
+	Write    WriteFunc
+	Read     ReadFunc
+	// Key is the reserved key of canary records, DefaultKey when empty.
+	Key string
+	// Field tells instances apart, so that concurrent canaries do not read
+	// each other's records.
+	Field string
+	// Value returns the value of the next canary. By default it is unique
+	// to the run, so that a stale value read back cannot pass.
+	Value func() (string, error)
+	// Timeout bounds a run, unbounded when zero.
+	Timeout time.Duration
+	// OnResult is called after every run.
+	OnResult func(*Result)
+
+	mu   sync.Mutex
+	last *Result
+}
+
+// Last returns the result of the latest run, nil before the first one.
+func (r *Runner) Last() *Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run writes, verifies and reads back one canary record.
+func (r *Runner) Run(ctx context.Context) *Result {
+	key := r.Key
+	if key == "" {
+		key = DefaultKey
+	}
+	result := &Result{Key: key, Field: r.Field, StartedAt: time.Now().UTC()}
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	stage, err := r.run(ctx, result)
+	result.Duration = time.Since(result.StartedAt)
+	if err != nil {
+		result.Stage, result.Error = stage, err.Error()
+	} else {
+		result.OK = true
+	}
+
+	r.mu.Lock()
+	r.last = result
+	r.mu.Unlock()
+	if r.OnResult != nil {
+		r.OnResult(result)
+	}
+	return result
+}
+
+func (r *Runner) run(ctx context.Context, result *Result) (string, error) {
+	value := fmt.Sprintf("canary %s", result.StartedAt.Format(time.RFC3339Nano))
+	if r.Value != nil {
+		var err error
+		if value, err = r.Value(); err != nil {
+			return StageWrite, err
+		}
+	}
+
+	receipt, err := r.Write(ctx, result.Key, result.Field, value)
+	if err != nil {
+		return StageWrite, err
+	}
+	result.TxHash = receipt.TxHash
+	if receipt.BlockNumber != nil {
+		result.Block = receipt.BlockNumber.Uint64()
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return StageWrite, fmt.Errorf("transaction %s reverted", receipt.TxHash.Hex())
+	}
+
+	if err := verifyEvent(receipt, r.Contract, result.Key, result.Field, value); err != nil {
+		return StageEvent, err
+	}
+
+	stored, err := r.Read(ctx, result.Key, result.Field)
+	if err != nil {
+		return StageRead, err
+	}
+	if stored != value {
+		return StageRead, fmt.Errorf("read back %q, want %q", stored, value)
+	}
+	return "", nil
+}
+
+// verifyEvent checks that the receipt holds the DataSaved event of the
+// write
+func verifyEvent(receipt *types.Receipt, contract common.Address, key, field, value string) error {
+	for _, l := range receipt.Logs {
+		if l.Address != contract {
+			continue
+		}
+		ev, err := storage.ParseDataSaved(*l)
+		if err != nil {
+			continue
+		}
+		if ev.Key == key && ev.Field == field && ev.Value == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("transaction %s emitted no DataSaved event for the canary", receipt.TxHash.Hex())
+}
+
+// Start runs a canary right away and then every interval until ctx is
+// done.
+func (r *Runner) Start(ctx context.Context, interval time.Duration) {
+	for {
+		r.Run(ctx)
+		if interval <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"contract-storage-eth/canary"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var contract = common.HexToAddress("0x3A220f351252089D385b29beca14e27F204c296A")
+
+// fakeContract keeps written values and emits their events from emitter
+type fakeContract struct {
+	t       *testing.T
+	emitter common.Address
+	status  uint64
+	values  map[string]string
+}
+
+func (c *fakeContract) write(ctx context.Context, key, field, value string) (*types.Receipt, error) {
+	parsed, err := storage.ABI()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	event := parsed.Events["DataSaved"]
+	data, err := event.Inputs.Pack(key, field, value)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	receipt := &types.Receipt{Status: c.status, TxHash: common.HexToHash("0x01"), BlockNumber: big.NewInt(7)}
+	if c.status == types.ReceiptStatusSuccessful {
+		c.values[key+"#"+field] = value
+		receipt.Logs = []*types.Log{{Address: c.emitter, Topics: []common.Hash{event.ID}, Data: data}}
+	}
+	return receipt, nil
+}
+
+func (c *fakeContract) read(ctx context.Context, key, field string) (string, error) {
+	return c.values[key+"#"+field], nil
+}
+
+func runner(c *fakeContract) *canary.Runner {
+	return &canary.Runner{Contract: contract, Write: c.write, Read: c.read, Field: "host-1"}
+}
+
+func TestRun(t *testing.T) {
+	c := &fakeContract{t: t, emitter: contract, status: types.ReceiptStatusSuccessful, values: map[string]string{}}
+	r := runner(c)
+	if r.Last() != nil {
+		t.Fatal("result before the first run")
+	}
+	result := r.Run(context.Background())
+	if !result.OK || result.Key != canary.DefaultKey || result.Block != 7 || r.Last() != result {
+		t.Errorf("result %+v", result)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contract *fakeContract
+		stage    string
+	}{
+		"reverted":   {&fakeContract{emitter: contract, status: types.ReceiptStatusFailed}, canary.StageWrite},
+		"no event":   {&fakeContract{emitter: common.HexToAddress("0x02"), status: types.ReceiptStatusSuccessful}, canary.StageEvent},
+		"stale read": {&fakeContract{emitter: contract, status: types.ReceiptStatusSuccessful}, canary.StageRead},
+	} {
+		tc.contract.t = t
+		tc.contract.values = map[string]string{}
+		r := runner(tc.contract)
+		if name == "stale read" {
+			r.Read = func(ctx context.Context, key, field string) (string, error) { return "canary of yesterday", nil }
+		}
+		result := r.Run(context.Background())
+		if result.OK || result.Stage != tc.stage || result.Error == "" {
+			t.Errorf("%s: result %+v, want failure at %s", name, result, tc.stage)
+		}
+	}
+}
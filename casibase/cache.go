@@ -0,0 +1,57 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package casibase
+
+import (
+	"context"
+
+	"contract-storage-eth/cache"
+)
+
+// cachedContract reads a contract through a cache.
+type cachedContract struct {
+	Contract
+	cache cache.Cache
+}
+
+// CachedContract returns contract reading through c, such as a
+// cache.LRU, so that objects read again are not read over RPC. Saves
+// invalidate what they write; what other writers save is only invalidated
+// by following their events, with cache.Follow. Reads go to the contract
+// when the cache fails.
+func CachedContract(contract Contract, c cache.Cache) Contract {
+	return &cachedContract{Contract: contract, cache: c}
+}
+
+// Save implements Contract.
+func (c *cachedContract) Save(ctx context.Context, key, field, value string) error {
+	err := c.Contract.Save(ctx, key, field, value)
+	// A failed save may still have been mined
+	c.cache.Invalidate(ctx, cache.Pair{Key: key, Field: field})
+	return err
+}
+
+// Get implements Contract.
+func (c *cachedContract) Get(ctx context.Context, key, field string) (string, error) {
+	if value, ok, err := c.cache.Get(ctx, key, field); err == nil && ok {
+		return value, nil
+	}
+	value, err := c.Contract.Get(ctx, key, field)
+	if err != nil {
+		return "", err
+	}
+	c.cache.Set(ctx, key, field, value)
+	return value, nil
+}
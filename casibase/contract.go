@@ -0,0 +1,125 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package casibase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"contract-storage-eth/confirm"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend is the node a contract is reached through, such as a
+// chain.Client.
+type Backend interface {
+	bind.ContractBackend
+	confirm.WaitBackend
+}
+
+// ContractOptions configure the Contract of a deployed storage contract.
+type ContractOptions struct {
+	// Signer writes to the contract; without it the contract is read-only.
+	Signer  signer.Signer
+	ChainID *big.Int
+	// Wait configures how writes wait for their transactions, such as the
+	// confirmations to wait for.
+	Wait confirm.WaitOptions
+	// Timeout bounds the wait for each transaction, unbounded when zero.
+	Timeout time.Duration
+}
+
+// chainContract is the Contract of a deployed storage contract.
+type chainContract struct {
+	backend  confirm.WaitBackend
+	contract storage.ContractBinder
+	opts     ContractOptions
+
+	// mu serializes writes, so that they do not race for a nonce
+	mu sync.Mutex
+}
+
+// NewContract returns the storage contract at address.
+func NewContract(backend Backend, address common.Address, opts ContractOptions) (Contract, error) {
+	binder, err := storage.Bind(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return NewBoundContract(binder, backend, opts), nil
+}
+
+// NewBoundContract returns the storage contract behind binder, waiting for
+// its writes to be mined through backend. Tests pass the mocks of the
+// mocks package here.
+func NewBoundContract(binder storage.ContractBinder, backend confirm.WaitBackend, opts ContractOptions) Contract {
+	return &chainContract{backend: backend, contract: binder, opts: opts}
+}
+
+// Save implements Contract.
+func (c *chainContract) Save(ctx context.Context, key, field, value string) error {
+	if c.opts.Signer == nil {
+		return fmt.Errorf("casibase: no signer to write %s#%s with", key, field)
+	}
+	auth, err := signer.NewTransactOpts(ctx, c.opts.Signer, c.opts.ChainID)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	tx, err := c.contract.Transact(auth, "save", key, field, value)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	receipt, err := c.wait(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+	return nil
+}
+
+// wait waits for tx to be mined with the confirmations of the options.
+func (c *chainContract) wait(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	waitCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.opts.Timeout > 0 {
+		waitCtx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+	}
+	defer cancel()
+	receipt, err := confirm.Wait(waitCtx, c.backend, tx, c.opts.Wait)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("no receipt within %s: %w", c.opts.Timeout, err)
+	}
+	return receipt, err
+}
+
+// Get implements Contract.
+func (c *chainContract) Get(ctx context.Context, key, field string) (string, error) {
+	var out []interface{}
+	if err := c.contract.Call(&bind.CallOpts{Context: ctx}, &out, "get", key, field); err != nil {
+		return "", err
+	}
+	return out[0].(string), nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package casibase_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/casibase"
+	"contract-storage-eth/confirm"
+	"contract-storage-eth/mocks"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBoundContract(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	binder := mocks.NewMockContractBinder(ctrl)
+	client := mocks.NewMockChainClient(ctrl)
+	s := mocks.NewMockSigner(ctrl)
+	ctx := context.Background()
+
+	writer := common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")
+	s.EXPECT().Address().Return(writer).AnyTimes()
+	saved := types.NewTx(&types.LegacyTx{Nonce: 1})
+	reverted := types.NewTx(&types.LegacyTx{Nonce: 2})
+	gomock.InOrder(
+		binder.EXPECT().Transact(gomock.Any(), "save", "invoice-42", "pdf", "v1").
+			DoAndReturn(func(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+				if opts.From != writer {
+					t.Errorf("sent from %s", opts.From.Hex())
+				}
+				return saved, nil
+			}),
+		binder.EXPECT().Transact(gomock.Any(), "save", "invoice-42", "pdf", "v2").Return(reverted, nil),
+	)
+	// The saved transaction is mined in block 10 and waited for until block
+	// 11 confirms it
+	client.EXPECT().TransactionReceipt(gomock.Any(), saved.Hash()).Return(&types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(10)}, nil).Times(2)
+	client.EXPECT().TransactionReceipt(gomock.Any(), reverted.Hash()).Return(&types.Receipt{Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(10)}, nil)
+	gomock.InOrder(
+		client.EXPECT().HeaderByNumber(gomock.Any(), nil).Return(&types.Header{Number: big.NewInt(10)}, nil),
+		client.EXPECT().HeaderByNumber(gomock.Any(), nil).Return(&types.Header{Number: big.NewInt(11)}, nil).Times(2),
+	)
+	binder.EXPECT().Call(gomock.Any(), gomock.Any(), "get", "invoice-42", "pdf").
+		DoAndReturn(func(opts *bind.CallOpts, results *[]interface{}, method string, params ...interface{}) error {
+			*results = []interface{}{"v1"}
+			return nil
+		})
+
+	contract := casibase.NewBoundContract(binder, client, casibase.ContractOptions{
+		Signer:  s,
+		ChainID: big.NewInt(1337),
+		Wait:    confirm.WaitOptions{Confirmations: 2, PollInterval: time.Millisecond},
+	})
+	if err := contract.Save(ctx, "invoice-42", "pdf", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := contract.Save(ctx, "invoice-42", "pdf", "v2"); err == nil || !strings.Contains(err.Error(), "reverted") {
+		t.Errorf("saved with a reverted transaction: %v", err)
+	}
+	if value, err := contract.Get(ctx, "invoice-42", "pdf"); err != nil || value != "v1" {
+		t.Errorf("got %q, %v", value, err)
+	}
+
+	readOnly := casibase.NewBoundContract(binder, client, casibase.ContractOptions{ChainID: big.NewInt(1337)})
+	if err := readOnly.Save(ctx, "invoice-42", "pdf", "v3"); err == nil {
+		t.Error("saved without a signer")
+	}
+}
+
+func TestBoundContractTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	binder := mocks.NewMockContractBinder(ctrl)
+	client := mocks.NewMockChainClient(ctrl)
+	s := mocks.NewMockSigner(ctrl)
+	s.EXPECT().Address().Return(common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")).AnyTimes()
+
+	stuck := types.NewTx(&types.LegacyTx{Nonce: 1})
+	binder.EXPECT().Transact(gomock.Any(), "save", "invoice-42", "pdf", "v1").Return(stuck, nil)
+	client.EXPECT().TransactionReceipt(gomock.Any(), stuck.Hash()).Return(nil, ethereum.NotFound).MinTimes(1)
+	client.EXPECT().TransactionByHash(gomock.Any(), stuck.Hash()).Return(stuck, true, nil).MinTimes(1)
+
+	contract := casibase.NewBoundContract(binder, client, casibase.ContractOptions{
+		Signer:  s,
+		ChainID: big.NewInt(1337),
+		Wait:    confirm.WaitOptions{PollInterval: time.Millisecond},
+		Timeout: 20 * time.Millisecond,
+	})
+	err := contract.Save(context.Background(), "invoice-42", "pdf", "v1")
+	if err == nil || !strings.Contains(err.Error(), "no receipt within 20ms") {
+		t.Errorf("saved with a transaction never mined: %v", err)
+	}
+}
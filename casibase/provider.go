@@ -0,0 +1,312 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package casibase adapts the storage contract to the object storage
+// providers of Casibase: objects are put, read, deleted and listed by key,
+// with a content type and metadata, so that Casibase can use the contract
+// as a backend in-process instead of running the command line tool.
+//
+// An object is stored as a record of its key in Options.Field, its content
+// sealed in a value envelope whose metadata holds the content type, the
+// size and the object metadata, which are indexed as record tags. Large
+// objects are stored in chunks.
+package casibase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"contract-storage-eth/chunk"
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/keyspace"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/tombstone"
+)
+
+// ErrNotFound is returned for keys holding no object.
+var ErrNotFound = errors.New("object not found")
+
+// Envelope metadata attributes of objects.
+const (
+	metaContentType = "content_type"
+	metaSize        = "size"
+)
+
+// Contract is the storage contract objects are stored in.
+type Contract interface {
+	// Save writes a value and returns once its transaction is mined.
+	Save(ctx context.Context, key, field, value string) error
+	// Get reads the latest value of a key and field, empty when unset.
+	Get(ctx context.Context, key, field string) (string, error)
+}
+
+// Index lists the records of the contract, as an indexer.Store does.
+type Index interface {
+	Keys(prefix string) ([]indexer.Ref, error)
+	Get(ref indexer.Ref) (*indexer.Record, error)
+}
+
+// Object describes a stored object.
+type Object struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+	// LastModified is the time of the block the object was written in,
+	// zero when read without an index.
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	// URL is the record ID of the object.
+	URL string `json:"url"`
+}
+
+// Options configure a Provider.
+type Options struct {
+	Contract Contract
+	// Index serves List and the modification times; List fails without it.
+	Index Index
+	// Records names the deployment in the URLs of objects.
+	Records recordid.Namespace
+	// Namespace is put in front of the keys of objects.
+	Namespace keyspace.Namespace
+	// Field is the field objects are stored in.
+	Field string
+	// Seal configures the envelopes of objects; Codec and Meta are set by
+	// the provider.
+	Seal envelope.Options
+	// Keys decrypt the objects, Seal.Key included.
+	Keys []*envelope.Key
+	// ChunkSize is the longest value written in one transaction, unlimited
+	// when zero.
+	ChunkSize int
+	// SoftDelete makes Delete write tombstones instead of empty values.
+	SoftDelete bool
+}
+
+// StorageProvider is the object storage provider interface Casibase plugs
+// backends in through: objects are put, read, deleted and listed by key,
+// with their metadata.
+type StorageProvider interface {
+	Put(ctx context.Context, key string, content []byte, contentType string, metadata map[string]string) (*Object, error)
+	Get(ctx context.Context, key string) (*Object, []byte, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]*Object, error)
+}
+
+// Provider stores objects in the storage contract.
+type Provider struct {
+	opts Options
+}
+
+var _ StorageProvider = (*Provider)(nil)
+
+// New returns a Provider.
+func New(opts Options) (*Provider, error) {
+	if opts.Contract == nil {
+		return nil, errors.New("casibase: no contract")
+	}
+	if opts.Seal.Key != nil {
+		opts.Keys = append([]*envelope.Key{opts.Seal.Key}, opts.Keys...)
+	}
+	return &Provider{opts: opts}, nil
+}
+
+// Put stores an object, replacing the one stored under key. Metadata names
+// must not hold '='.
+func (p *Provider) Put(ctx context.Context, key string, content []byte, contentType string, metadata map[string]string) (*Object, error) {
+	if key == "" {
+		return nil, errors.New("casibase: empty key")
+	}
+	meta := indexer.TagMeta(metadata)
+	meta[metaSize] = strconv.Itoa(len(content))
+	if contentType != "" {
+		meta[metaContentType] = contentType
+	}
+	opts := p.opts.Seal
+	opts.Codec, opts.Meta = envelope.CodecBytes, meta
+	sealed, err := envelope.Seal(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := p.opts.Namespace.Key(key)
+	// Chunks go first, so that the object only changes once they are all
+	// written
+	m, chunks := chunk.Split(sealed, p.opts.ChunkSize)
+	if m != nil {
+		for i, c := range chunks {
+			if err := p.opts.Contract.Save(ctx, stored, m.Field(p.opts.Field, i), c); err != nil {
+				return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+		}
+		sealed = m.String()
+	}
+	if err := p.opts.Contract.Save(ctx, stored, p.opts.Field, sealed); err != nil {
+		return nil, err
+	}
+	return &Object{
+		Key:          key,
+		Size:         int64(len(content)),
+		ContentType:  contentType,
+		LastModified: time.Now().UTC(),
+		Metadata:     maps.Clone(metadata),
+		URL:          p.url(stored),
+	}, nil
+}
+
+// Get reads an object and its content. It returns ErrNotFound when key
+// holds none.
+func (p *Provider) Get(ctx context.Context, key string) (*Object, []byte, error) {
+	stored := p.opts.Namespace.Key(key)
+	value, err := p.opts.Contract.Get(ctx, stored, p.opts.Field)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m, err := chunk.Parse(value); err != nil {
+		return nil, nil, err
+	} else if m != nil {
+		chunks := make([]string, m.Chunks)
+		for i := range chunks {
+			if chunks[i], err = p.opts.Contract.Get(ctx, stored, m.Field(p.opts.Field, i)); err != nil {
+				return nil, nil, fmt.Errorf("chunk %d of %d: %w", i+1, m.Chunks, err)
+			}
+		}
+		if value, err = m.Join(chunks); err != nil {
+			return nil, nil, err
+		}
+	}
+	if value == "" || tombstone.Is(value) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	env, err := envelope.Parse(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("casibase: %s is not an object: %w", key, err)
+	}
+	content, err := env.OpenWith(p.opts.Keys...)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj := p.object(key, stored, env.Meta)
+	obj.Size = int64(len(content))
+	if p.opts.Index != nil {
+		if r, err := p.opts.Index.Get(indexer.Ref{Key: stored, Field: p.opts.Field}); err == nil && r.Value == value {
+			obj.LastModified = time.Unix(int64(r.Timestamp), 0).UTC()
+		}
+	}
+	return obj, content, nil
+}
+
+// Delete deletes an object. It returns ErrNotFound when key holds none.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	stored := p.opts.Namespace.Key(key)
+	value, err := p.opts.Contract.Get(ctx, stored, p.opts.Field)
+	if err != nil {
+		return err
+	}
+	if value == "" || tombstone.Is(value) {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	deleted := ""
+	if p.opts.SoftDelete {
+		deleted = tombstone.New(time.Now())
+	}
+	return p.opts.Contract.Save(ctx, stored, p.opts.Field, deleted)
+}
+
+// List returns the objects whose key starts with prefix, ordered by key,
+// from the index. Objects written since it last synced are missing.
+func (p *Provider) List(ctx context.Context, prefix string) ([]*Object, error) {
+	if p.opts.Index == nil {
+		return nil, errors.New("casibase: listing objects needs an index")
+	}
+	refs, err := p.opts.Index.Keys(p.opts.Namespace.Key(prefix))
+	if err != nil {
+		return nil, err
+	}
+	objects := []*Object{}
+	for _, ref := range refs {
+		if ref.Field != p.opts.Field {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r, err := p.opts.Index.Get(ref)
+		if err != nil {
+			return nil, err
+		}
+		env, err := p.indexedEnvelope(r)
+		if err != nil || env == nil {
+			// Deleted, or not an object
+			continue
+		}
+		key, _ := p.opts.Namespace.Local(r.Key)
+		obj := p.object(key, r.Key, env.Meta)
+		obj.LastModified = time.Unix(int64(r.Timestamp), 0).UTC()
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// indexedEnvelope returns the envelope of an indexed object, reassembled
+// from its indexed chunks when stored in chunks, or nil when it was deleted
+func (p *Provider) indexedEnvelope(r *indexer.Record) (*envelope.Envelope, error) {
+	value := r.Value
+	if value == "" || tombstone.Is(value) {
+		return nil, nil
+	}
+	m, err := chunk.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		chunks := make([]string, m.Chunks)
+		for i := range chunks {
+			c, err := p.opts.Index.Get(indexer.Ref{Key: r.Key, Field: m.Field(r.Field, i)})
+			if err != nil {
+				return nil, err
+			}
+			chunks[i] = c.Value
+		}
+		if value, err = m.Join(chunks); err != nil {
+			return nil, err
+		}
+	}
+	return envelope.Parse(value)
+}
+
+// object describes an object from the metadata of its envelope
+func (p *Provider) object(key, stored string, meta map[string]string) *Object {
+	obj := &Object{Key: key, ContentType: meta[metaContentType], URL: p.url(stored)}
+	obj.Size, _ = strconv.ParseInt(meta[metaSize], 10, 64)
+	for k, v := range meta {
+		if name, ok := strings.CutPrefix(k, indexer.MetaTagPrefix); ok && name != "" {
+			if obj.Metadata == nil {
+				obj.Metadata = map[string]string{}
+			}
+			obj.Metadata[name] = v
+		}
+	}
+	return obj
+}
+
+func (p *Provider) url(stored string) string {
+	return p.opts.Records.ID(stored, p.opts.Field, 0).String()
+}
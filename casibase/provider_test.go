@@ -0,0 +1,189 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package casibase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/cache"
+	"contract-storage-eth/casibase"
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+)
+
+// memory is a contract and its index in memory
+type memory struct {
+	records map[indexer.Ref][]*indexer.Record
+	writes  int
+	reads   int
+}
+
+func (m *memory) Save(ctx context.Context, key, field, value string) error {
+	m.writes++
+	ref := indexer.Ref{Key: key, Field: field}
+	versions := m.records[ref]
+	m.records[ref] = append(versions, &indexer.Record{Key: key, Field: field, Value: value, Version: uint64(len(versions) + 1), Timestamp: 1700000000 + uint64(m.writes)})
+	return nil
+}
+
+func (m *memory) Get(ctx context.Context, key, field string) (string, error) {
+	m.reads++
+	versions := m.records[indexer.Ref{Key: key, Field: field}]
+	if len(versions) == 0 {
+		return "", nil
+	}
+	return versions[len(versions)-1].Value, nil
+}
+
+func (m *memory) Keys(prefix string) ([]indexer.Ref, error) {
+	var refs []indexer.Ref
+	for ref, versions := range m.records {
+		if strings.HasPrefix(ref.Key, prefix) {
+			refs = append(refs, indexer.Ref{Key: ref.Key, Field: ref.Field, Version: uint64(len(versions))})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Key+"#"+refs[i].Field < refs[j].Key+"#"+refs[j].Field })
+	return refs, nil
+}
+
+func (m *memory) indexGet(ref indexer.Ref) (*indexer.Record, error) {
+	versions := m.records[indexer.Ref{Key: ref.Key, Field: ref.Field}]
+	if len(versions) == 0 {
+		return nil, indexer.ErrNotFound
+	}
+	if ref.Version == 0 {
+		ref.Version = uint64(len(versions))
+	}
+	return versions[ref.Version-1], nil
+}
+
+// index is the index of a memory contract
+type index struct{ *memory }
+
+func (i index) Get(ref indexer.Ref) (*indexer.Record, error) { return i.indexGet(ref) }
+
+func newProvider(t *testing.T, opts casibase.Options) (*casibase.Provider, *memory) {
+	t.Helper()
+	m := &memory{records: map[indexer.Ref][]*indexer.Record{}}
+	opts.Contract, opts.Index = m, index{m}
+	p, err := casibase.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, m
+}
+
+func TestProvider(t *testing.T) {
+	key, err := envelope.DeriveKey([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, m := newProvider(t, casibase.Options{
+		Records:   recordid.Namespace{ChainID: 1337},
+		Namespace: "casibase:",
+		Field:     "object",
+		Seal:      envelope.Options{Key: key},
+		ChunkSize: 256,
+	})
+	ctx := context.Background()
+
+	small := []byte("hello")
+	large := bytes.Repeat([]byte("0123456789"), 100)
+	if _, err := p.Put(ctx, "docs/a.txt", small, "text/plain", map[string]string{"owner": "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := p.Put(ctx, "docs/b.bin", large, "application/octet-stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.writes < 3 {
+		t.Errorf("the large object was written in %d transaction(s), want chunks", m.writes-1)
+	}
+	if want := "ethstore://1337/0x0000000000000000000000000000000000000000/casibase:docs%2Fb.bin#object"; obj.URL != want {
+		t.Errorf("URL = %s, want %s", obj.URL, want)
+	}
+
+	obj, content, err := p.Get(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hello" || obj.Size != 5 || obj.ContentType != "text/plain" || obj.Metadata["owner"] != "alice" || obj.LastModified.IsZero() {
+		t.Errorf("got %+v, %q", obj, content)
+	}
+	if _, content, err := p.Get(ctx, "docs/b.bin"); err != nil || !bytes.Equal(content, large) {
+		t.Errorf("large object: %d bytes, %v", len(content), err)
+	}
+
+	objects, err := p.List(ctx, "docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for _, o := range objects {
+		listed = append(listed, o.Key+":"+o.ContentType)
+	}
+	if want := "docs/a.txt:text/plain docs/b.bin:application/octet-stream"; strings.Join(listed, " ") != want {
+		t.Errorf("listed %v, want %s", listed, want)
+	}
+
+	if err := p.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := p.Get(ctx, "docs/a.txt"); !errors.Is(err, casibase.ErrNotFound) {
+		t.Errorf("got %v after delete, want ErrNotFound", err)
+	}
+	if err := p.Delete(ctx, "docs/a.txt"); !errors.Is(err, casibase.ErrNotFound) {
+		t.Errorf("deleted twice: %v", err)
+	}
+	if objects, _ := p.List(ctx, ""); len(objects) != 1 {
+		t.Errorf("listed %d object(s) after delete, want 1", len(objects))
+	}
+}
+
+func TestCachedContract(t *testing.T) {
+	mem := &memory{records: map[indexer.Ref][]*indexer.Record{}}
+	contract := casibase.CachedContract(mem, cache.NewLRU(cache.LRUOptions{}))
+	p, err := casibase.New(casibase.Options{Contract: contract, Field: "object"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := p.Put(ctx, "a.txt", []byte("one"), "text/plain", nil); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, content, err := p.Get(ctx, "a.txt"); err != nil || string(content) != "one" {
+			t.Fatalf("got %q, %v", content, err)
+		}
+	}
+	if mem.reads != 1 {
+		t.Errorf("read the contract %d times, want 1", mem.reads)
+	}
+
+	// Saves through the cache invalidate what they write
+	if _, err := p.Put(ctx, "a.txt", []byte("two"), "text/plain", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, content, err := p.Get(ctx, "a.txt"); err != nil || string(content) != "two" {
+		t.Errorf("got %q, %v after an overwrite", content, err)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ccip implements EIP-3668 (CCIP-Read) for contract calls. A
+// contract serving data from an off-chain gateway reverts with
+// OffchainLookup; Call then fetches the data from the gateway and passes it
+// to the contract's callback, which verifies the gateway's proof on-chain.
+package ccip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const offchainLookupABI = `[{"type":"error","name":"OffchainLookup","inputs":[
+	{"name":"sender","type":"address"},
+	{"name":"urls","type":"string[]"},
+	{"name":"callData","type":"bytes"},
+	{"name":"callbackFunction","type":"bytes4"},
+	{"name":"extraData","type":"bytes"}]}]`
+
+var (
+	lookupError abi.Error
+	lookupOnce  sync.Once
+
+	callbackArgs = abi.Arguments{{Type: mustType("bytes")}, {Type: mustType("bytes")}}
+)
+
+func mustType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+func offchainLookup() abi.Error {
+	lookupOnce.Do(func() {
+		parsed, err := abi.JSON(strings.NewReader(offchainLookupABI))
+		if err != nil {
+			panic(err)
+		}
+		lookupError = parsed.Errors["OffchainLookup"]
+	})
+	return lookupError
+}
+
+var (
+	// ErrTooManyLookups is returned when a call keeps redirecting.
+	ErrTooManyLookups = errors.New("ccip: too many offchain lookups")
+	// ErrSenderMismatch is returned when the lookup names another contract
+	// than the one called, which EIP-3668 requires clients to reject.
+	ErrSenderMismatch = errors.New("ccip: lookup sender does not match the called contract")
+	// ErrGateway is returned when no gateway answered the lookup.
+	ErrGateway = errors.New("ccip: gateway request failed")
+)
+
+// Lookup is the content of an OffchainLookup revert.
+type Lookup struct {
+	Sender           common.Address
+	URLs             []string
+	CallData         []byte
+	CallbackFunction [4]byte
+	ExtraData        []byte
+}
+
+// ParseLookup decodes an OffchainLookup revert. It returns false when data
+// is a different revert.
+func ParseLookup(data []byte) (*Lookup, bool) {
+	e := offchainLookup()
+	if len(data) < 4 || !bytes.Equal(data[:4], e.ID[:4]) {
+		return nil, false
+	}
+	values, err := e.Inputs.Unpack(data[4:])
+	if err != nil || len(values) != 5 {
+		return nil, false
+	}
+	l := &Lookup{}
+	var ok bool
+	if l.Sender, ok = values[0].(common.Address); !ok {
+		return nil, false
+	}
+	if l.URLs, ok = values[1].([]string); !ok {
+		return nil, false
+	}
+	if l.CallData, ok = values[2].([]byte); !ok {
+		return nil, false
+	}
+	if l.CallbackFunction, ok = values[3].([4]byte); !ok {
+		return nil, false
+	}
+	if l.ExtraData, ok = values[4].([]byte); !ok {
+		return nil, false
+	}
+	return l, true
+}
+
+// Caller is the subset of the Ethereum client used by Call.
+type Caller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Options configure Call.
+type Options struct {
+	// HTTPClient sends the gateway requests.
+	HTTPClient *http.Client
+	// MaxLookups bounds the number of lookups followed for one call.
+	MaxLookups int
+}
+
+const defaultMaxLookups = 4
+
+// Call executes msg, following offchain lookups requested by the contract.
+func Call(ctx context.Context, caller Caller, msg ethereum.CallMsg, blockNumber *big.Int, opts Options) ([]byte, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.MaxLookups <= 0 {
+		opts.MaxLookups = defaultMaxLookups
+	}
+
+	for i := 0; ; i++ {
+		out, err := caller.CallContract(ctx, msg, blockNumber)
+		if err == nil {
+			return out, nil
+		}
+		lookup, ok := ParseLookup(revertData(err))
+		if !ok {
+			return nil, err
+		}
+		if i >= opts.MaxLookups {
+			return nil, ErrTooManyLookups
+		}
+		if msg.To == nil || lookup.Sender != *msg.To {
+			return nil, ErrSenderMismatch
+		}
+
+		response, err := fetch(ctx, opts.HTTPClient, lookup)
+		if err != nil {
+			return nil, err
+		}
+		args, err := callbackArgs.Pack(response, lookup.ExtraData)
+		if err != nil {
+			return nil, err
+		}
+		msg.Data = append(lookup.CallbackFunction[:], args...)
+	}
+}
+
+// revertData extracts the revert data carried by an eth_call error.
+func revertData(err error) []byte {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil
+	}
+	s, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil
+	}
+	data, err := hexutil.Decode(s)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// fetch asks the gateways of the lookup in order, moving on to the next one
+// on server errors. Client errors end the lookup, as the spec requires.
+func fetch(ctx context.Context, client *http.Client, l *Lookup) ([]byte, error) {
+	sender := strings.ToLower(l.Sender.Hex())
+	data := hexutil.Encode(l.CallData)
+
+	var lastErr error
+	for _, template := range l.URLs {
+		url := strings.ReplaceAll(template, "{sender}", sender)
+		url = strings.ReplaceAll(url, "{data}", data)
+
+		var req *http.Request
+		var err error
+		if strings.Contains(template, "{data}") {
+			req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		} else {
+			body, _ := json.Marshal(map[string]string{"data": data, "sender": sender})
+			req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+			if req != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+		resp.Body.Close()
+		switch {
+		case err != nil:
+			lastErr = err
+			continue
+		case resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("%s: %s", template, resp.Status)
+			continue
+		case resp.StatusCode >= 400:
+			return nil, fmt.Errorf("%w: %s: %s", ErrGateway, template, resp.Status)
+		}
+
+		var result struct {
+			Data hexutil.Bytes `json:"data"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			lastErr = fmt.Errorf("%s: invalid response: %v", template, err)
+			continue
+		}
+		return result.Data, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no gateway URLs")
+	}
+	return nil, fmt.Errorf("%w: %v", ErrGateway, lastErr)
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccip_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/ccip"
+	"contract-storage-eth/fixtures"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestParseLookupFixtures(t *testing.T) {
+	reverts, err := fixtures.Reverts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(reverts))
+	for name := range reverts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	got := map[string]interface{}{}
+	for _, name := range names {
+		lookup, ok := ccip.ParseLookup(reverts[name])
+		if !ok {
+			got[name] = "not a lookup"
+			continue
+		}
+		got[name] = map[string]interface{}{
+			"sender":    lookup.Sender.Hex(),
+			"urls":      lookup.URLs,
+			"call_data": hexutil.Encode(lookup.CallData),
+			"callback":  hexutil.Encode(lookup.CallbackFunction[:]),
+			"extra":     hexutil.Encode(lookup.ExtraData),
+		}
+	}
+	fixtures.GoldenJSON(t, "lookups", got)
+}
+
+// revertError is an eth_call error carrying revert data, as returned by
+// the RPC client
+type revertError struct{ data []byte }
+
+func (e revertError) Error() string          { return "execution reverted" }
+func (e revertError) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+// caller reverts with the lookup until it is called with the callback
+type caller struct {
+	lookup []byte
+	calls  []string
+}
+
+func (c *caller) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	c.calls = append(c.calls, hexutil.Encode(msg.Data))
+	if strings.HasPrefix(hexutil.Encode(msg.Data), "0xdeadbeef") {
+		return []byte("verified"), nil
+	}
+	return nil, revertError{c.lookup}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestCallFollowsGateway(t *testing.T) {
+	reverts, err := fixtures.Reverts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first gateway fails and the second one answers
+	var requests []string
+	client := &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		if r.Body != nil {
+			data, _ := io.ReadAll(r.Body)
+			body = " " + string(data)
+		}
+		requests = append(requests, r.Method+" "+r.URL.String()+body)
+		status, response := http.StatusOK, `{"data":"0x1234"}`
+		if r.URL.Host == "gateway.example" {
+			status, response = http.StatusBadGateway, ""
+		}
+		return &http.Response{StatusCode: status, Status: fmt.Sprint(status), Body: io.NopCloser(strings.NewReader(response)), Header: http.Header{}}, nil
+	})}
+
+	c := &caller{lookup: reverts["offchain_lookup"]}
+	out, err := ccip.Call(context.Background(), c, ethereum.CallMsg{To: &chain.Contract, Data: []byte{1, 2, 3, 4}}, nil, ccip.Options{HTTPClient: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "verified" {
+		t.Errorf("got %q", out)
+	}
+	fixtures.GoldenJSON(t, "gateway_exchange", map[string][]string{"calls": c.calls, "requests": requests})
+
+	// A lookup naming another contract must be rejected
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	_, err = ccip.Call(context.Background(), c, ethereum.CallMsg{To: &other}, nil, ccip.Options{HTTPClient: client})
+	if !errors.Is(err, ccip.ErrSenderMismatch) {
+		t.Errorf("lookup from another contract: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when every endpoint is cooling down after
+// repeated failures.
+var ErrCircuitOpen = errors.New("all RPC endpoints are cooling down after repeated failures")
+
+// breaker stops sending requests to an endpoint after a number of
+// consecutive failures. Once the cooldown has passed the breaker is
+// half-open: a single trial request is let through, whose success closes
+// the breaker and whose failure opens it again.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	// probing is when the trial request of the half-open breaker was let
+	// through, zero when none is in flight.
+	probing time.Time
+}
+
+// allow reports whether a request may be sent to the endpoint. A trial
+// request that neither succeeds nor fails, such as one whose context is
+// canceled first, is replaced by another after a cooldown.
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	now := time.Now()
+	if now.Before(b.openUntil) || !b.probing.IsZero() && now.Sub(b.probing) < b.cooldown {
+		return false
+	}
+	b.probing = now
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = time.Time{}
+}
+
+// failure records a failed request and reports whether it opened the breaker.
+func (b *breaker) failure() bool {
+	if b.threshold <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	b.probing = time.Time{}
+	return true
+}
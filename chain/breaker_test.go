@@ -0,0 +1,135 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"contract-storage-eth/chain"
+)
+
+// flakyNode answers eth_blockNumber, failing with 503 while failing is set.
+// Requests block while hold is set, until it is cleared
+type flakyNode struct {
+	failing  atomic.Bool
+	requests atomic.Int32
+	hold     chan struct{}
+}
+
+func (n *flakyNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.requests.Add(1)
+	if n.hold != nil {
+		<-n.hold
+	}
+	if n.failing.Load() {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+}
+
+func TestBreaker(t *testing.T) {
+	const cooldown = 100 * time.Millisecond
+	n := &flakyNode{}
+	server := httptest.NewServer(n)
+	defer server.Close()
+	opened := 0
+	client, err := chain.Dial([]string{server.URL}, chain.Options{
+		Retry:            chain.RetryPolicy{MaxAttempts: 1},
+		BreakerThreshold: 2,
+		BreakerCooldown:  cooldown,
+		OnCircuitOpen:    func(string, time.Duration, error) { opened++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// Closed: failures go through until the threshold trips the breaker
+	n.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := client.BlockNumber(ctx); err == nil || errors.Is(err, chain.ErrCircuitOpen) {
+			t.Fatalf("request %d: got %v, want the node's error", i+1, err)
+		}
+	}
+	if opened != 1 {
+		t.Fatalf("breaker opened %d times, want 1", opened)
+	}
+
+	// Open: nothing reaches the node
+	if _, err := client.BlockNumber(ctx); !errors.Is(err, chain.ErrCircuitOpen) || n.requests.Load() != 2 {
+		t.Fatalf("open breaker: got %v after %d requests", err, n.requests.Load())
+	}
+
+	// Half-open: a single trial goes through, whose failure opens the
+	// breaker again
+	time.Sleep(cooldown)
+	if got := concurrentCalls(t, client, n); got != 1 {
+		t.Fatalf("half-open breaker let %d requests through, want 1", got)
+	}
+	if opened != 2 {
+		t.Fatalf("breaker opened %d times, want 2", opened)
+	}
+	if _, err := client.BlockNumber(ctx); !errors.Is(err, chain.ErrCircuitOpen) {
+		t.Fatalf("breaker not reopened by a failed trial: %v", err)
+	}
+
+	// A successful trial closes it
+	time.Sleep(cooldown)
+	n.failing.Store(false)
+	if got := concurrentCalls(t, client, n); got != 1 {
+		t.Fatalf("half-open breaker let %d requests through, want 1", got)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.BlockNumber(ctx); err != nil {
+			t.Fatalf("closed breaker: %v", err)
+		}
+	}
+}
+
+// concurrentCalls sends 5 requests at once, holding the node's answers
+// until all of them are sent, and returns how many reached the node
+func concurrentCalls(t *testing.T, client *chain.Client, n *flakyNode) int {
+	t.Helper()
+	before := n.requests.Load()
+	n.hold = make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.BlockNumber(context.Background())
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for n.requests.Load() == before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// Give the other requests time to reach the node if they were let through
+	time.Sleep(20 * time.Millisecond)
+	close(n.hold)
+	wg.Wait()
+	n.hold = nil
+	return int(n.requests.Load() - before)
+}
@@ -0,0 +1,616 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chain provides a resilient connection to Ethereum JSON-RPC
+// endpoints. Client has the same methods as ethclient.Client that the rest of
+// the tool relies on, fails over between endpoints and retries transient
+// provider errors with jittered exponential backoff.
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"contract-storage-eth/tracing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// Options configure a Client.
+type Options struct {
+	// Retry controls how transient errors are retried.
+	Retry RetryPolicy
+	// CallTimeout bounds a single request to an endpoint, so a node that
+	// stops answering is failed over instead of hanging the caller.
+	// Retries get a fresh timeout each.
+	CallTimeout time.Duration
+	// MaxBlockLag marks an endpoint as stale when its head is more than
+	// this many blocks behind the best endpoint. Zero disables the check.
+	MaxBlockLag uint64
+	// HealthInterval is the minimum delay between staleness checks.
+	HealthInterval time.Duration
+	// RequestsPerSecond limits the request rate to each endpoint, so bulk
+	// operations stay within provider quotas. Zero means unlimited.
+	RequestsPerSecond float64
+	// Burst is the number of requests allowed above the rate at once.
+	Burst int
+	// BreakerThreshold is the number of consecutive failures after which an
+	// endpoint is skipped for BreakerCooldown. Zero disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long a tripped endpoint is skipped.
+	BreakerCooldown time.Duration
+	// OnFailover is called when the client switches endpoints.
+	OnFailover func(from, to string, reason error)
+	// OnCircuitOpen is called when an endpoint's breaker trips.
+	OnCircuitOpen func(url string, cooldown time.Duration, reason error)
+	// OnError is called for each request to an endpoint that failed with
+	// a transient error, before it is retried or failed over.
+	OnError func(url string, err error)
+	// OnSend is called once SendTransaction returns, with its error.
+	OnSend func(tx *types.Transaction, err error)
+	// Logger, when set, receives failovers and tripped breakers as
+	// warnings and each transient error at debug level. Endpoints are
+	// logged by host, leaving out credentials in their URLs.
+	Logger *slog.Logger
+	// ChainID, when set, is the chain every endpoint must serve. An
+	// endpoint reporting another chain is refused when it is dialed.
+	ChainID *big.Int
+}
+
+func (o Options) withDefaults() Options {
+	o.Retry = o.Retry.withDefaults()
+	if o.CallTimeout <= 0 {
+		o.CallTimeout = defaultCallTimeout
+	}
+	if o.HealthInterval <= 0 {
+		o.HealthInterval = defaultHealthInterval
+	}
+	if o.Burst <= 0 {
+		o.Burst = 1
+	}
+	if o.BreakerCooldown <= 0 {
+		o.BreakerCooldown = defaultBreakerCooldown
+	}
+	return o
+}
+
+func (o Options) newBreaker() *breaker {
+	return &breaker{threshold: o.BreakerThreshold, cooldown: o.BreakerCooldown}
+}
+
+func (o Options) newLimiter() *rate.Limiter {
+	if o.RequestsPerSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, o.Burst)
+	}
+	return rate.NewLimiter(rate.Limit(o.RequestsPerSecond), o.Burst)
+}
+
+const (
+	defaultCallTimeout     = 30 * time.Second
+	defaultHealthInterval  = 30 * time.Second
+	defaultBreakerCooldown = time.Minute
+)
+
+type endpoint struct {
+	url     string
+	chainID *big.Int
+	limiter *rate.Limiter
+	breaker *breaker
+
+	mu  sync.Mutex
+	eth *ethclient.Client
+}
+
+// ChainMismatchError is returned when an endpoint serves another chain than
+// the configured one.
+type ChainMismatchError struct {
+	URL  string
+	Want *big.Int
+	Got  *big.Int
+}
+
+func (e *ChainMismatchError) Error() string {
+	return fmt.Sprintf("RPC endpoint %s serves chain %s, but chain %s is configured", e.URL, e.Got, e.Want)
+}
+
+// client returns the connection of the endpoint, dialing it and checking
+// its chain on first use.
+func (e *endpoint) client(ctx context.Context) (*ethclient.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.eth == nil {
+		eth, err := ethclient.DialContext(ctx, e.url)
+		if err != nil {
+			return nil, err
+		}
+		if e.chainID != nil {
+			got, err := eth.ChainID(ctx)
+			if err != nil {
+				eth.Close()
+				return nil, err
+			}
+			if got.Cmp(e.chainID) != 0 {
+				eth.Close()
+				return nil, &ChainMismatchError{URL: e.url, Want: e.chainID, Got: got}
+			}
+		}
+		e.eth = eth
+	}
+	return e.eth, nil
+}
+
+func (e *endpoint) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.eth != nil {
+		e.eth.Close()
+		e.eth = nil
+	}
+}
+
+// Client is an Ethereum client that retries transient errors and fails
+// over between several endpoints. Endpoints are tried in the configured
+// order; the client returns to an earlier endpoint once it is healthy again.
+type Client struct {
+	endpoints []*endpoint
+	opts      Options
+
+	mu          sync.Mutex
+	current     int
+	lastHealth  time.Time
+	healthCheck sync.Mutex
+}
+
+var _ bind.ContractBackend = (*Client)(nil)
+var _ bind.DeployBackend = (*Client)(nil)
+
+// ErrNoEndpoints is returned by Dial when no URL is given.
+var ErrNoEndpoints = errors.New("no RPC endpoints configured")
+
+// Dial connects to the given endpoints, the first one being the primary.
+func Dial(urls []string, opts Options) (*Client, error) {
+	return DialContext(context.Background(), urls, opts)
+}
+
+// DialContext is like Dial but gives up connecting when ctx is done.
+func DialContext(ctx context.Context, urls []string, opts Options) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	opts = opts.withDefaults()
+	c := &Client{opts: opts}
+	for _, u := range urls {
+		c.endpoints = append(c.endpoints, &endpoint{url: u, chainID: opts.ChainID, limiter: opts.newLimiter(), breaker: opts.newBreaker()})
+	}
+
+	// Connect eagerly to the first reachable endpoint so configuration
+	// errors show up immediately. An endpoint of the wrong chain is not
+	// failed over but reported, as it means the configuration is wrong.
+	var err error
+	var mismatch *ChainMismatchError
+	for i, ep := range c.endpoints {
+		if _, err = c.dial(ctx, ep); err == nil {
+			c.current = i
+			return c, nil
+		}
+		if errors.As(err, &mismatch) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
+
+// NewClient wraps an existing ethclient.
+func NewClient(eth *ethclient.Client, opts Options) *Client {
+	opts = opts.withDefaults()
+	return &Client{endpoints: []*endpoint{{eth: eth, limiter: opts.newLimiter(), breaker: opts.newBreaker()}}, opts: opts}
+}
+
+// Close closes all connections.
+func (c *Client) Close() {
+	for _, ep := range c.endpoints {
+		ep.close()
+	}
+}
+
+// Policy returns the retry policy of the client.
+func (c *Client) Policy() RetryPolicy {
+	return c.opts.Retry
+}
+
+// URL returns the endpoint currently in use.
+func (c *Client) URL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endpoints[c.current].url
+}
+
+func (c *Client) switchTo(i int, reason error) {
+	c.mu.Lock()
+	from := c.current
+	c.current = i
+	c.mu.Unlock()
+
+	if from == i {
+		return
+	}
+	if c.opts.Logger != nil {
+		c.opts.Logger.Warn("RPC endpoint failed over", "from", host(c.endpoints[from].url), "to", host(c.endpoints[i].url), "reason", reason)
+	}
+	if c.opts.OnFailover != nil {
+		c.opts.OnFailover(c.endpoints[from].url, c.endpoints[i].url, reason)
+	}
+}
+
+// checkHealth moves away from an endpoint that lags behind the others.
+func (c *Client) checkHealth(ctx context.Context) {
+	if c.opts.MaxBlockLag == 0 || len(c.endpoints) < 2 {
+		return
+	}
+	if !c.healthCheck.TryLock() {
+		return
+	}
+	defer c.healthCheck.Unlock()
+
+	c.mu.Lock()
+	due := time.Since(c.lastHealth) >= c.opts.HealthInterval
+	if due {
+		c.lastHealth = time.Now()
+	}
+	c.mu.Unlock()
+	if !due {
+		return
+	}
+
+	heads := make([]uint64, len(c.endpoints))
+	reachable := make([]bool, len(c.endpoints))
+	var best uint64
+	for i, ep := range c.endpoints {
+		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		eth, err := ep.client(checkCtx)
+		if err == nil {
+			err = ep.limiter.Wait(checkCtx)
+		}
+		if err == nil {
+			heads[i], err = eth.BlockNumber(checkCtx)
+		}
+		cancel()
+		if err != nil {
+			continue
+		}
+		reachable[i] = true
+		if heads[i] > best {
+			best = heads[i]
+		}
+	}
+
+	for i := range c.endpoints {
+		if reachable[i] && heads[i]+c.opts.MaxBlockLag >= best {
+			c.mu.Lock()
+			current := c.current
+			c.mu.Unlock()
+			if i != current {
+				reason := fmt.Errorf("endpoint is %d blocks behind", best-heads[current])
+				if !reachable[current] {
+					reason = errors.New("endpoint unreachable")
+				}
+				c.switchTo(i, reason)
+			}
+			return
+		}
+	}
+}
+
+// do runs fn against the current endpoint, failing over to the next ones
+// on transient errors and retrying with backoff once all of them failed.
+// Endpoints whose circuit breaker is open are skipped. The whole call is
+// traced as a span named after the JSON-RPC method.
+func (c *Client) do(ctx context.Context, method string, retriable func(error) bool, fn func(ctx context.Context, eth *ethclient.Client) error) (err error) {
+	ctx, span := tracing.Start(ctx, method, attribute.String("rpc.system", "jsonrpc"), attribute.String("rpc.method", method))
+	defer func() { tracing.End(span, err) }()
+
+	return Retry(ctx, c.opts.Retry, retriable, func(ctx context.Context) error {
+		c.checkHealth(ctx)
+
+		c.mu.Lock()
+		start := c.current
+		c.mu.Unlock()
+
+		err := ErrCircuitOpen
+		for i := 0; i < len(c.endpoints); i++ {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			idx := (start + i) % len(c.endpoints)
+			ep := c.endpoints[idx]
+			if !ep.breaker.allow() {
+				continue
+			}
+
+			eth, dialErr := c.dial(ctx, ep)
+			if dialErr != nil {
+				err = dialErr
+				c.recordFailure(ctx, ep, err)
+				continue
+			}
+			if err := ep.limiter.Wait(ctx); err != nil {
+				return err
+			}
+
+			// The failure of the previous endpoint is why this one is used
+			reason := err
+			err = c.attempt(ctx, eth, fn)
+			if err == nil || !retriable(err) {
+				ep.breaker.success()
+				if idx != start {
+					c.switchTo(idx, reason)
+				}
+				return err
+			}
+			c.recordFailure(ctx, ep, err)
+		}
+		return err
+	})
+}
+
+// dial returns the connection of ep, bounded by the call timeout.
+func (c *Client) dial(ctx context.Context, ep *endpoint) (*ethclient.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.CallTimeout)
+	defer cancel()
+	return ep.client(ctx)
+}
+
+// attempt runs fn once, bounded by the call timeout.
+func (c *Client) attempt(ctx context.Context, eth *ethclient.Client, fn func(ctx context.Context, eth *ethclient.Client) error) error {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.CallTimeout)
+	defer cancel()
+	return fn(ctx, eth)
+}
+
+func (c *Client) recordFailure(ctx context.Context, ep *endpoint, err error) {
+	trace.SpanFromContext(ctx).AddEvent("endpoint failed", trace.WithAttributes(attribute.String("server.address", host(ep.url)), attribute.String("error", err.Error())))
+	if c.opts.Logger != nil {
+		c.opts.Logger.DebugContext(ctx, "RPC request failed", "endpoint", host(ep.url), "error", err)
+	}
+	if c.opts.OnError != nil {
+		c.opts.OnError(ep.url, err)
+	}
+	if !ep.breaker.failure() {
+		return
+	}
+	if c.opts.Logger != nil {
+		c.opts.Logger.WarnContext(ctx, "RPC endpoint keeps failing, pausing it", "endpoint", host(ep.url), "cooldown", c.opts.BreakerCooldown, "reason", err)
+	}
+	if c.opts.OnCircuitOpen != nil {
+		c.opts.OnCircuitOpen(ep.url, c.opts.BreakerCooldown, err)
+	}
+}
+
+// host returns the host of an endpoint URL, leaving out the credentials and
+// API keys URLs of providers often hold.
+func host(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func call[T any](ctx context.Context, c *Client, method string, fn func(ctx context.Context, eth *ethclient.Client) (T, error)) (T, error) {
+	var result T
+	err := c.do(ctx, method, IsRetriable, func(ctx context.Context, eth *ethclient.Client) error {
+		var err error
+		result, err = fn(ctx, eth)
+		return err
+	})
+	return result, err
+}
+
+// ChainID retrieves the current chain ID.
+func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, "eth_chainId", func(ctx context.Context, eth *ethclient.Client) (*big.Int, error) {
+		return eth.ChainID(ctx)
+	})
+}
+
+// BlockNumber returns the most recent block number.
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	return call(ctx, c, "eth_blockNumber", func(ctx context.Context, eth *ethclient.Client) (uint64, error) {
+		return eth.BlockNumber(ctx)
+	})
+}
+
+// HeaderByNumber returns a block header from the current canonical chain.
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return call(ctx, c, "eth_getBlockByNumber", func(ctx context.Context, eth *ethclient.Client) (*types.Header, error) {
+		return eth.HeaderByNumber(ctx, number)
+	})
+}
+
+// BlockByNumber returns a block from the current canonical chain.
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return call(ctx, c, "eth_getBlockByNumber", func(ctx context.Context, eth *ethclient.Client) (*types.Block, error) {
+		return eth.BlockByNumber(ctx, number)
+	})
+}
+
+// HeaderByHash returns the block header with the given hash.
+func (c *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return call(ctx, c, "eth_getBlockByHash", func(ctx context.Context, eth *ethclient.Client) (*types.Header, error) {
+		return eth.HeaderByHash(ctx, hash)
+	})
+}
+
+// BalanceAt returns the wei balance of the given account.
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return call(ctx, c, "eth_getBalance", func(ctx context.Context, eth *ethclient.Client) (*big.Int, error) {
+		return eth.BalanceAt(ctx, account, blockNumber)
+	})
+}
+
+// CodeAt returns the contract code of the given account.
+func (c *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, "eth_getCode", func(ctx context.Context, eth *ethclient.Client) ([]byte, error) {
+		return eth.CodeAt(ctx, account, blockNumber)
+	})
+}
+
+// StorageAt returns the value of a storage slot of the given account.
+func (c *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, "eth_getStorageAt", func(ctx context.Context, eth *ethclient.Client) ([]byte, error) {
+		return eth.StorageAt(ctx, account, key, blockNumber)
+	})
+}
+
+// PendingCodeAt returns the contract code of the given account in the pending state.
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return call(ctx, c, "eth_getCode", func(ctx context.Context, eth *ethclient.Client) ([]byte, error) {
+		return eth.PendingCodeAt(ctx, account)
+	})
+}
+
+// NonceAt returns the account nonce of the given account.
+func (c *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return call(ctx, c, "eth_getTransactionCount", func(ctx context.Context, eth *ethclient.Client) (uint64, error) {
+		return eth.NonceAt(ctx, account, blockNumber)
+	})
+}
+
+// PendingNonceAt returns the account nonce of the given account in the pending state.
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return call(ctx, c, "eth_getTransactionCount", func(ctx context.Context, eth *ethclient.Client) (uint64, error) {
+		return eth.PendingNonceAt(ctx, account)
+	})
+}
+
+// CallContract executes a message call transaction.
+func (c *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, c, "eth_call", func(ctx context.Context, eth *ethclient.Client) ([]byte, error) {
+		return eth.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+// SuggestGasPrice retrieves the currently suggested gas price.
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, "eth_gasPrice", func(ctx context.Context, eth *ethclient.Client) (*big.Int, error) {
+		return eth.SuggestGasPrice(ctx)
+	})
+}
+
+// SuggestGasTipCap retrieves the currently suggested gas tip cap.
+func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return call(ctx, c, "eth_maxPriorityFeePerGas", func(ctx context.Context, eth *ethclient.Client) (*big.Int, error) {
+		return eth.SuggestGasTipCap(ctx)
+	})
+}
+
+// EstimateGas estimates the gas needed to execute a transaction.
+func (c *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return call(ctx, c, "eth_estimateGas", func(ctx context.Context, eth *ethclient.Client) (uint64, error) {
+		return eth.EstimateGas(ctx, msg)
+	})
+}
+
+// TransactionByHash returns the transaction with the given hash.
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	type result struct {
+		tx      *types.Transaction
+		pending bool
+	}
+	r, err := call(ctx, c, "eth_getTransactionByHash", func(ctx context.Context, eth *ethclient.Client) (result, error) {
+		tx, pending, err := eth.TransactionByHash(ctx, hash)
+		return result{tx, pending}, err
+	})
+	return r.tx, r.pending, err
+}
+
+// TransactionReceipt returns the receipt of a transaction by transaction hash.
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return call(ctx, c, "eth_getTransactionReceipt", func(ctx context.Context, eth *ethclient.Client) (*types.Receipt, error) {
+		return eth.TransactionReceipt(ctx, txHash)
+	})
+}
+
+// FilterLogs executes a filter query.
+func (c *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return call(ctx, c, "eth_getLogs", func(ctx context.Context, eth *ethclient.Client) ([]types.Log, error) {
+		return eth.FilterLogs(ctx, q)
+	})
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query.
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return call(ctx, c, "eth_subscribe", func(ctx context.Context, eth *ethclient.Client) (ethereum.Subscription, error) {
+		return eth.SubscribeFilterLogs(ctx, q, ch)
+	})
+}
+
+// ErrNonceConflict is returned when a signed transaction can no longer be
+// sent because its nonce was already used. The transaction has to be signed
+// again with a fresh nonce.
+var ErrNonceConflict = errors.New("nonce already used")
+
+// SendTransaction injects a signed transaction into the pending pool.
+// Resending the same transaction after a timeout is safe: if a previous
+// attempt already reached the node, the call succeeds.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	retriable := func(err error) bool {
+		return !errors.Is(err, ErrNonceConflict) && IsRetriable(err)
+	}
+
+	attempt := 0
+	err := c.do(ctx, "eth_sendRawTransaction", retriable, func(ctx context.Context, eth *ethclient.Client) error {
+		attempt++
+		err := eth.SendTransaction(ctx, tx)
+		if err == nil {
+			return nil
+		}
+		if attempt > 1 && IsAlreadyKnown(err) {
+			return nil
+		}
+		if IsNonceTooLow(err) {
+			// The nonce may have been consumed by our own earlier attempt.
+			if attempt > 1 {
+				if _, _, lookupErr := eth.TransactionByHash(ctx, tx.Hash()); lookupErr == nil {
+					return nil
+				}
+			}
+			return errors.Join(ErrNonceConflict, err)
+		}
+		return err
+	})
+	if c.opts.OnSend != nil {
+		c.opts.OnSend(tx, err)
+	}
+	return err
+}
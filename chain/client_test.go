@@ -0,0 +1,187 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/chain"
+)
+
+// node answers eth_chainId with chainID
+func node(t *testing.T, chainID uint64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Method != "eth_chainId" {
+			t.Errorf("unexpected call %s", req.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": %s, "result": "0x%x"}`, req.ID, chainID)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDialChecksChainID(t *testing.T) {
+	sepolia := node(t, 11155111)
+	mainnet := node(t, 1)
+
+	client, err := chain.Dial([]string{sepolia.URL}, chain.Options{ChainID: big.NewInt(11155111)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	// A wrong primary is reported rather than failed over
+	_, err = chain.Dial([]string{mainnet.URL, sepolia.URL}, chain.Options{ChainID: big.NewInt(11155111)})
+	var mismatch *chain.ChainMismatchError
+	if !errors.As(err, &mismatch) || mismatch.URL != mainnet.URL || mismatch.Got.Uint64() != 1 {
+		t.Errorf("got %v, want a mismatch of %s", err, mainnet.URL)
+	}
+}
+
+func TestDialReportsFallbackOfOtherChain(t *testing.T) {
+	mainnet := node(t, 1)
+
+	// The unreachable primary is failed over, to an endpoint of the wrong chain
+	_, err := chain.Dial([]string{"http://127.0.0.1:1", mainnet.URL}, chain.Options{ChainID: big.NewInt(11155111)})
+	var mismatch *chain.ChainMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Errorf("got %v, want a chain mismatch", err)
+	}
+}
+
+func TestLoggerReportsFailover(t *testing.T) {
+	primary := node(t, 1)
+	standby := node(t, 1)
+	var logs bytes.Buffer
+	client, err := chain.Dial([]string{primary.URL, standby.URL}, chain.Options{
+		Retry:  chain.RetryPolicy{MaxAttempts: 1},
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	primary.Close()
+	if _, err := client.ChainID(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var entry struct {
+		Level, Msg, From, To, Reason string
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("%v in %q", err, logs.String())
+	}
+	if entry.Level != "WARN" || entry.From != strings.TrimPrefix(primary.URL, "http://") || entry.To != strings.TrimPrefix(standby.URL, "http://") || entry.Reason == "" {
+		t.Errorf("logged %+v", entry)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := node(t, 1)
+	client, err := chain.Dial([]string{server.URL}, chain.Options{RequestsPerSecond: 50, Burst: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	start := time.Now()
+	for range 6 {
+		if _, err := client.ChainID(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// At least five waits of 20ms after the first request
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("6 requests at 50/s took %s", elapsed)
+	}
+
+	// A request that would wait past its deadline fails at once
+	slow, err := chain.Dial([]string{server.URL}, chain.Options{RequestsPerSecond: 0.1, Burst: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+	if _, err := slow.ChainID(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := slow.ChainID(ctx); err == nil {
+		t.Error("rate limited request did not fail at its deadline")
+	}
+}
+
+func TestCallTimeoutFailsOver(t *testing.T) {
+	// The primary accepts requests but never answers them
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hung.Close()
+	defer close(release)
+	standby := node(t, 1)
+
+	client, err := chain.Dial([]string{hung.URL, standby.URL}, chain.Options{
+		Retry:       chain.RetryPolicy{MaxAttempts: 1},
+		CallTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	id, err := client.ChainID(context.Background())
+	if err != nil || id.Uint64() != 1 {
+		t.Fatalf("ChainID = %v, %v; want 1 from the standby", id, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("failing over took %s", elapsed)
+	}
+
+	// The caller's deadline still applies over the call timeout
+	only, err := chain.Dial([]string{hung.URL}, chain.Options{Retry: chain.RetryPolicy{MaxAttempts: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer only.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := only.ChainID(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline of the caller", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// HeaderReader reads block headers, the latest one for a nil number.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// BlockAt returns the header of the last block mined at or before t. It
+// binary searches the chain, which takes about log2 of its height requests.
+func BlockAt(ctx context.Context, headers HeaderReader, t time.Time) (*types.Header, error) {
+	header := func(n uint64) (*types.Header, error) {
+		h, err := headers.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", n, err)
+		}
+		return h, nil
+	}
+	target := uint64(t.Unix())
+	if t.Unix() < 0 {
+		target = 0
+	}
+
+	latest, err := headers.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("latest block: %w", err)
+	}
+	if latest.Time <= target {
+		return latest, nil
+	}
+	lo, err := header(0)
+	if err != nil {
+		return nil, err
+	}
+	if lo.Time > target {
+		return nil, fmt.Errorf("%s is before the first block of the chain (%s)", t.UTC().Format(time.RFC3339), time.Unix(int64(lo.Time), 0).UTC().Format(time.RFC3339))
+	}
+
+	// lo is mined at or before t, hi after it
+	hi := latest
+	for hi.Number.Uint64()-lo.Number.Uint64() > 1 {
+		mid, err := header(lo.Number.Uint64() + (hi.Number.Uint64()-lo.Number.Uint64())/2)
+		if err != nil {
+			return nil, err
+		}
+		if mid.Time <= target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"contract-storage-eth/chain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// headers is a chain whose blocks are mined at the given Unix times
+type headers []uint64
+
+func (h headers) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	n := uint64(len(h) - 1)
+	if number != nil {
+		n = number.Uint64()
+	}
+	if n >= uint64(len(h)) {
+		return nil, ethereum.NotFound
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(n), Time: h[n]}, nil
+}
+
+func TestBlockAt(t *testing.T) {
+	// Several blocks can share a timestamp on fast chains
+	chainHeaders := headers{100, 112, 124, 124, 136, 200, 212}
+	for _, tt := range []struct {
+		at   uint64
+		want uint64
+	}{
+		{100, 0},
+		{111, 0},
+		{112, 1},
+		{124, 3},
+		{199, 4},
+		{212, 6},
+		{5000, 6},
+	} {
+		h, err := chain.BlockAt(context.Background(), chainHeaders, time.Unix(int64(tt.at), 0))
+		if err != nil {
+			t.Fatalf("at %d: %v", tt.at, err)
+		}
+		if h.Number.Uint64() != tt.want {
+			t.Errorf("at %d: got block %d, want %d", tt.at, h.Number, tt.want)
+		}
+	}
+
+	if _, err := chain.BlockAt(context.Background(), chainHeaders, time.Unix(99, 0)); err == nil {
+		t.Error("found a block before the first one")
+	}
+}
+
+func TestIsMissingState(t *testing.T) {
+	if !chain.IsMissingState(errors.New("missing trie node 1f8c... (path ) state 0x1f8c... is not available")) {
+		t.Error("geth pruned state not detected")
+	}
+	if chain.IsMissingState(errors.New("execution reverted")) {
+		t.Error("revert reported as missing state")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RetryPolicy controls how failed RPC calls are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on every
+	// further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when no policy is configured.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+// backoff returns the jittered delay before the given retry (1-based).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Equal jitter: somewhere between half and the full delay.
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// Retry calls fn until it succeeds, returns an error rejected by retriable,
+// the policy runs out of attempts or ctx is done.
+func Retry(ctx context.Context, policy RetryPolicy, retriable func(error) bool, fn func(ctx context.Context) error) error {
+	policy = policy.withDefaults()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil || !retriable(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(policy.backoff(attempt)):
+		}
+	}
+}
+
+var retriableMessages = []string{
+	"rate limit",
+	"too many requests",
+	"timeout",
+	"timed out",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"eof",
+	"header not found",
+	"service unavailable",
+	"bad gateway",
+	"temporarily unavailable",
+}
+
+// limitExceededCode is the EIP-1474 "limit exceeded" error code.
+const limitExceededCode = -32005
+
+// executionRevertedCode is the error code geth returns for reverted calls.
+const executionRevertedCode = 3
+
+// IsRetriable reports whether err is a transient provider error that is
+// worth retrying. Answers from the node such as reverts, used nonces,
+// invalid parameters and "not found" are permanent.
+func IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ethereum.NotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return httpErr.StatusCode >= 500
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == limitExceededCode {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, m := range retriableMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReverted reports whether err says the call reverted, as estimating gas
+// for a transaction to a paused contract or to a removed method does.
+func IsReverted(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == executionRevertedCode {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
+
+// IsUnreachable reports whether err says no endpoint could be reached at
+// all: the connection was refused, the host did not resolve or every
+// endpoint is cooling down. Errors the node answered with are not.
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host")
+}
+
+// IsInsufficientFunds reports whether err says the sender cannot pay for
+// the gas and value of a transaction.
+func IsInsufficientFunds(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "insufficient funds")
+}
+
+// IsNonceTooLow reports whether err says the transaction nonce was already used.
+func IsNonceTooLow(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
+
+// IsAlreadyKnown reports whether err says the node already has the transaction.
+func IsAlreadyKnown(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// missingStateMessages are what nodes answer for calls at a block whose
+// state they pruned: geth, Erigon, Nethermind and the common providers.
+var missingStateMessages = []string{
+	"missing trie node",
+	"historical state",
+	"state is not available",
+	"state not available",
+	"pruned",
+	"archive",
+}
+
+// IsMissingState reports whether err says the node no longer has the state
+// of the requested block, which only archive nodes keep.
+func IsMissingState(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range missingStateMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"contract-storage-eth/chain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcError is a JSON-RPC error answer
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string  { return e.message }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestIsRetriable(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"not found", ethereum.NotFound, false},
+		{"canceled", fmt.Errorf("call: %w", context.Canceled), false},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		{"eof", io.ErrUnexpectedEOF, true},
+		{"circuit open", chain.ErrCircuitOpen, true},
+		{"429", rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, true},
+		{"502", rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, true},
+		{"500", rpc.HTTPError{StatusCode: 500, Status: "500 Internal Server Error"}, true},
+		{"400", rpc.HTTPError{StatusCode: 400, Status: "400 Bad Request"}, false},
+		{"limit exceeded", rpcError{-32005, "limit exceeded"}, true},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}, true},
+		{"rate limit message", errors.New("project ID request rate exceeded: rate limit"), true},
+		{"header not found", errors.New("header not found"), true},
+		{"reverted", rpcError{3, "execution reverted: paused"}, false},
+		{"nonce too low", errors.New("nonce too low: next nonce 8, tx nonce 7"), false},
+		{"insufficient funds", errors.New("insufficient funds for gas * price + value"), false},
+		{"invalid params", rpcError{-32602, "invalid argument 0: hex string without 0x prefix"}, false},
+	} {
+		if got := chain.IsRetriable(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetriable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	reverted := []error{rpcError{3, "execution reverted"}, errors.New("estimating gas: Execution Reverted: paused")}
+	for _, err := range reverted {
+		if !chain.IsReverted(err) {
+			t.Errorf("IsReverted(%v) = false", err)
+		}
+	}
+	if chain.IsReverted(errors.New("nonce too low")) || chain.IsReverted(nil) {
+		t.Error("IsReverted accepts other errors")
+	}
+	if !chain.IsNonceTooLow(errors.New("Nonce too low")) || chain.IsNonceTooLow(errors.New("replacement transaction underpriced")) {
+		t.Error("IsNonceTooLow misclassifies")
+	}
+	if !chain.IsAlreadyKnown(errors.New("already known")) || !chain.IsAlreadyKnown(errors.New("known transaction: 0x12")) || chain.IsAlreadyKnown(errors.New("nonce too low")) {
+		t.Error("IsAlreadyKnown misclassifies")
+	}
+	if !chain.IsInsufficientFunds(errors.New("insufficient funds for gas * price + value")) || chain.IsInsufficientFunds(errors.New("nonce too low")) {
+		t.Error("IsInsufficientFunds misclassifies")
+	}
+
+	unreachable := []error{
+		fmt.Errorf("call: %w", chain.ErrCircuitOpen),
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: connection refused")},
+		&net.DNSError{Err: "no such host", Name: "rpc.invalid", IsNotFound: true},
+	}
+	for _, err := range unreachable {
+		if !chain.IsUnreachable(err) {
+			t.Errorf("IsUnreachable(%v) = false", err)
+		}
+	}
+	answered := []error{nil, ethereum.NotFound, rpcError{3, "execution reverted"}, rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, fmt.Errorf("call: %w", context.DeadlineExceeded)}
+	for _, err := range answered {
+		if chain.IsUnreachable(err) {
+			t.Errorf("IsUnreachable(%v) = true", err)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := chain.RetryPolicy{MaxAttempts: 4, BaseDelay: 2 * time.Millisecond, MaxDelay: 4 * time.Millisecond}
+	transient, permanent := errors.New("connection reset by peer"), errors.New("execution reverted")
+
+	for _, tt := range []struct {
+		name     string
+		errs     []error
+		want     error
+		attempts int
+	}{
+		{"succeeds", nil, nil, 1},
+		{"recovers", []error{transient, transient}, nil, 3},
+		{"gives up", []error{transient, transient, transient, transient, transient}, transient, 4},
+		{"permanent", []error{permanent}, permanent, 1},
+	} {
+		attempts := 0
+		start := time.Now()
+		err := chain.Retry(context.Background(), policy, chain.IsRetriable, func(ctx context.Context) error {
+			attempts++
+			if attempts <= len(tt.errs) {
+				return tt.errs[attempts-1]
+			}
+			return nil
+		})
+		if err != tt.want || attempts != tt.attempts {
+			t.Errorf("%s: got %v after %d attempts, want %v after %d", tt.name, err, attempts, tt.want, tt.attempts)
+		}
+		// Each retry waits between half and all of its delay: 2ms, then
+		// 4ms, capped at MaxDelay
+		if least := []time.Duration{0, 0, time.Millisecond, 3 * time.Millisecond, 5 * time.Millisecond}[attempts]; time.Since(start) < least {
+			t.Errorf("%s: retried %d times in %s, want at least %s of backoff", tt.name, attempts-1, time.Since(start), least)
+		}
+	}
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := chain.Retry(ctx, chain.RetryPolicy{MaxAttempts: 10, BaseDelay: time.Hour, MaxDelay: time.Hour}, chain.IsRetriable, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return io.EOF
+	})
+	if err != io.EOF || attempts != 1 {
+		t.Errorf("got %v after %d attempts, want EOF after 1", err, attempts)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chunk splits values too large for one transaction across several
+// fields of their key. The chunks are written first, under fields named
+// after the digest of the value, then a manifest listing them is written to
+// the field of the value, so that readers never see a partial value: until
+// the manifest lands, they read the previous one, whose chunks are left in
+// place.
+package chunk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix marks a stored value as a manifest. It cannot start an envelope
+// or be mistaken for one.
+const Prefix = "cse-chunked:"
+
+// MaxLength bounds the length of chunked values, like the decompressed
+// size of envelopes, so that a manifest cannot make readers allocate or
+// fetch without bound.
+const MaxLength = 64 << 20
+
+// Manifest describes a value stored in chunks.
+type Manifest struct {
+	// Chunks is the number of chunks, each holding Size bytes but the last.
+	Chunks int `json:"chunks"`
+	Size   int `json:"chunk_size"`
+	// Length and SHA256 are the length and digest of the whole value.
+	Length int    `json:"length"`
+	SHA256 string `json:"sha256"`
+}
+
+// Split cuts value into chunks of size bytes. Values no longer than size
+// are not split, and Split returns a nil manifest for them.
+func Split(value string, size int) (*Manifest, []string) {
+	if size <= 0 || len(value) <= size {
+		return nil, nil
+	}
+	sum := sha256.Sum256([]byte(value))
+	m := &Manifest{Size: size, Length: len(value), SHA256: hex.EncodeToString(sum[:])}
+	var chunks []string
+	for start := 0; start < len(value); start += size {
+		chunks = append(chunks, value[start:min(start+size, len(value))])
+	}
+	m.Chunks = len(chunks)
+	return m, chunks
+}
+
+// Field returns the field chunk i of a value stored in field is written
+// to. Fields differ between values, so writing a new value leaves the
+// chunks of the previous one readable.
+func (m *Manifest) Field(field string, i int) string {
+	return fmt.Sprintf("%s~chunk/%s/%d", field, m.SHA256[:16], i)
+}
+
+// String returns the manifest in the form stored on chain.
+func (m *Manifest) String() string {
+	data, _ := json.Marshal(m)
+	return Prefix + string(data)
+}
+
+// Parse returns the manifest a stored value holds, or nil when the value
+// is not one. Manifests whose chunks do not add up to their length, or of
+// values longer than MaxLength, are refused.
+func Parse(value string) (*Manifest, error) {
+	body, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return nil, nil
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal([]byte(body), m); err != nil {
+		return nil, fmt.Errorf("chunk: malformed manifest: %w", err)
+	}
+	switch {
+	case m.Size <= 0 || m.Length <= m.Size || len(m.SHA256) != 2*sha256.Size:
+		return nil, errors.New("chunk: malformed manifest")
+	case m.Length > MaxLength:
+		return nil, fmt.Errorf("chunk: manifest of a value of %d bytes, longer than %d", m.Length, MaxLength)
+	case m.Chunks != (m.Length+m.Size-1)/m.Size:
+		return nil, fmt.Errorf("chunk: manifest of %d chunks for %d bytes in chunks of %d", m.Chunks, m.Length, m.Size)
+	}
+	if _, err := hex.DecodeString(m.SHA256); err != nil {
+		return nil, errors.New("chunk: malformed manifest")
+	}
+	return m, nil
+}
+
+// Join reassembles a value from its chunks, checking it against the
+// manifest.
+func (m *Manifest) Join(chunks []string) (string, error) {
+	if len(chunks) != m.Chunks {
+		return "", fmt.Errorf("chunk: %d of %d chunks", len(chunks), m.Chunks)
+	}
+	for i, c := range chunks {
+		if len(c) > m.Size {
+			return "", fmt.Errorf("chunk: chunk %d is longer than %d bytes", i+1, m.Size)
+		}
+	}
+	value := strings.Join(chunks, "")
+	sum := sha256.Sum256([]byte(value))
+	if len(value) != m.Length || hex.EncodeToString(sum[:]) != m.SHA256 {
+		return "", errors.New("chunk: the chunks do not match the manifest, some are missing or were overwritten")
+	}
+	return value, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk_test
+
+import (
+	"strings"
+	"testing"
+
+	"contract-storage-eth/chunk"
+)
+
+func TestSplitJoin(t *testing.T) {
+	if m, chunks := chunk.Split("short", 8); m != nil || chunks != nil {
+		t.Errorf("split a short value: %v %v", m, chunks)
+	}
+
+	value := strings.Repeat("0123456789", 5)
+	m, chunks := chunk.Split(value, 16)
+	if m == nil || len(chunks) != 4 || len(chunks[3]) != 2 {
+		t.Fatalf("got %+v, %q", m, chunks)
+	}
+	if got := m.Field("pdf", 2); got != "pdf~chunk/"+m.SHA256[:16]+"/2" {
+		t.Errorf("chunk field %s", got)
+	}
+
+	parsed, err := chunk.Parse(m.String())
+	if err != nil || parsed == nil || *parsed != *m {
+		t.Fatalf("parsed %+v (%v), want %+v", parsed, err, m)
+	}
+	joined, err := parsed.Join(chunks)
+	if err != nil || joined != value {
+		t.Errorf("joined %q (%v)", joined, err)
+	}
+
+	chunks[1] = "overwritten....."
+	if _, err := parsed.Join(chunks); err == nil {
+		t.Error("joined overwritten chunks")
+	}
+	if _, err := parsed.Join(chunks[:3]); err == nil {
+		t.Error("joined missing chunks")
+	}
+	if m, err := chunk.Parse("cse:AQU="); m != nil || err != nil {
+		t.Errorf("parsed an envelope as a manifest: %v %v", m, err)
+	}
+	if _, err := chunk.Parse(chunk.Prefix + "{}"); err == nil {
+		t.Error("parsed an empty manifest")
+	}
+}
+
+func TestParseHostile(t *testing.T) {
+	digest := `"sha256":"` + strings.Repeat("ab", 32) + `"`
+	tests := []struct {
+		name, manifest string
+	}{
+		{"not JSON", `{"chunks":`},
+		{"no chunks", `{"chunks":0,"chunk_size":16,"length":50,` + digest + `}`},
+		{"negative chunks", `{"chunks":-1,"chunk_size":16,"length":50,` + digest + `}`},
+		{"no chunk size", `{"chunks":4,"chunk_size":0,"length":50,` + digest + `}`},
+		{"no length", `{"chunks":4,"chunk_size":16,` + digest + `}`},
+		{"negative length", `{"chunks":4,"chunk_size":16,"length":-50,` + digest + `}`},
+		{"too few chunks", `{"chunks":3,"chunk_size":16,"length":50,` + digest + `}`},
+		{"too many chunks", `{"chunks":1000000000,"chunk_size":16,"length":50,` + digest + `}`},
+		{"a single chunk", `{"chunks":1,"chunk_size":64,"length":50,` + digest + `}`},
+		{"too long", `{"chunks":65537,"chunk_size":1024,"length":67109888,` + digest + `}`},
+		{"huge length", `{"chunks":2,"chunk_size":4611686018427387904,"length":9223372036854775807,` + digest + `}`},
+		{"short digest", `{"chunks":4,"chunk_size":16,"length":50,"sha256":"abcd"}`},
+		{"not hex", `{"chunks":4,"chunk_size":16,"length":50,"sha256":"` + strings.Repeat("zz", 32) + `"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if m, err := chunk.Parse(chunk.Prefix + tt.manifest); err == nil {
+				t.Errorf("parsed %+v", m)
+			}
+		})
+	}
+
+	longest := `{"chunks":65536,"chunk_size":1024,"length":67108864,` + digest + `}`
+	if m, err := chunk.Parse(chunk.Prefix + longest); err != nil || m.Chunks != 65536 {
+		t.Errorf("parsed a manifest of %d bytes: %+v, %v", chunk.MaxLength, m, err)
+	}
+}
+
+func TestJoinOversizedChunk(t *testing.T) {
+	m, chunks := chunk.Split(strings.Repeat("x", 40), 16)
+	chunks[0], chunks[1] = chunks[0]+chunks[1], ""
+	if _, err := m.Join(chunks); err == nil {
+		t.Error("joined a chunk longer than the chunk size")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"contract-storage-eth/ccip"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/chunk"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tombstone"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// storedWrite is one of the writes storing a value under a key
+type storedWrite struct {
+	Field string
+	Value string
+}
+
+// splitValue returns the writes storing a sealed value in field: the value
+// itself, or its chunks followed by their manifest when it is longer than
+// storage.chunk_size
+func splitValue(config *Config, field, sealed string) []storedWrite {
+	m, chunks := chunk.Split(sealed, config.Storage.ChunkSize)
+	if m == nil {
+		return []storedWrite{{Field: field, Value: sealed}}
+	}
+	writes := make([]storedWrite, 0, len(chunks)+1)
+	for i, c := range chunks {
+		writes = append(writes, storedWrite{Field: m.Field(field, i), Value: c})
+	}
+	return append(writes, storedWrite{Field: field, Value: m.String()})
+}
+
+// readRecordValue reads the value of a field like readValue, reassembling
+// it from its chunks when the field holds a manifest and fetching it from
+// Arweave or IPFS when it holds a pointer. A tombstone reads as no value
+func readRecordValue(ctx context.Context, client ccip.Caller, config *Config, address common.Address, key, field string, block *big.Int) (string, error) {
+	value, err := readValue(ctx, client, config, address, key, field, block)
+	if err != nil {
+		return "", err
+	}
+	// Soft-deleted values read as unset
+	if tombstone.Is(value) {
+		return "", nil
+	}
+	m, err := chunk.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	if m == nil {
+		return resolveValue(ctx, config, value)
+	}
+	chunks := make([]string, m.Chunks)
+	for i := range chunks {
+		if chunks[i], err = readValue(ctx, client, config, address, key, m.Field(field, i), block); err != nil {
+			return "", fmt.Errorf("chunk %d of %d: %w", i+1, m.Chunks, err)
+		}
+	}
+	return m.Join(chunks)
+}
+
+// saveChunks writes the chunks of a value before its manifest, skipping
+// those the contract holds already, such as after an interrupted save. It
+// returns once they are all mined
+func saveChunks(ctx context.Context, client *chain.Client, contract storage.ContractBinder, auth *bind.TransactOpts, config *Config, address common.Address, key string, chunks []storedWrite) error {
+	var txs []*types.Transaction
+	for i, c := range chunks {
+		if stored, err := readValue(ctx, client, config, address, key, c.Field, nil); err == nil && stored == c.Value {
+			continue
+		}
+		tx, err := sendSave(ctx, client, contract, auth, config, key, c.Field, c.Value)
+		if err != nil {
+			return fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	fmt.Printf("Saving %d chunk(s) of up to %d bytes...\n", len(txs), config.Storage.ChunkSize)
+	for _, tx := range txs {
+		receipt, err := waitMined(ctx, client, tx, config)
+		if err != nil {
+			return fmt.Errorf("chunk transaction %s: %w", tx.Hash().Hex(), err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("chunk transaction %s failed", tx.Hash().Hex())
+		}
+	}
+	return nil
+}
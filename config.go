@@ -0,0 +1,827 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"contract-storage-eth/ens"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/presets"
+	"contract-storage-eth/schema"
+	"contract-storage-eth/smoke"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v2"
+)
+
+// URLList accepts either a single URL or a list of URLs
+type URLList []string
+
+func (l *URLList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*l = URLList{single}
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// KeyConfig tells where a signing key comes from
+type KeyConfig struct {
+	PrivateKey    string `yaml:"private_key"`
+	SignerURL     string `yaml:"signer_url"`
+	SignerAccount string `yaml:"signer_account"`
+	Keystore      struct {
+		File          string `yaml:"file"`
+		PassphraseEnv string `yaml:"passphrase_env"`
+	} `yaml:"keystore"`
+	Mnemonic struct {
+		Phrase         string `yaml:"phrase"`
+		PassphraseEnv  string `yaml:"passphrase_env"`
+		DerivationPath string `yaml:"derivation_path"`
+		AccountIndex   uint32 `yaml:"account_index"`
+	} `yaml:"mnemonic"`
+	KMS struct {
+		KeyID    string `yaml:"key_id"`
+		Region   string `yaml:"region"`
+		Endpoint string `yaml:"endpoint"`
+	} `yaml:"kms"`
+	Hardware struct {
+		Wallet         string `yaml:"wallet"`
+		DerivationPath string `yaml:"derivation_path"`
+		AccountIndex   uint32 `yaml:"account_index"`
+	} `yaml:"hardware"`
+	Vault struct {
+		Address   string `yaml:"address"`
+		Namespace string `yaml:"namespace"`
+		Token     string `yaml:"token"`
+		AppRole   struct {
+			RoleID   string `yaml:"role_id"`
+			SecretID string `yaml:"secret_id"`
+			Mount    string `yaml:"mount"`
+		} `yaml:"approle"`
+		Mount     string `yaml:"mount"`
+		KVVersion int    `yaml:"kv_version"`
+		Path      string `yaml:"path"`
+		Field     string `yaml:"field"`
+	} `yaml:"vault"`
+}
+
+// configured reports whether any key source is set
+func (k KeyConfig) configured() bool {
+	return k.PrivateKey != "" || k.SignerURL != "" || k.Keystore.File != "" || k.Mnemonic.Phrase != "" || k.KMS.KeyID != "" || k.Vault.Path != "" || k.Hardware.Wallet != ""
+}
+
+// NetworkConfig is a named network profile, overriding the settings it
+// sets when selected with --network
+type NetworkConfig struct {
+	RpcURL          URLList `yaml:"rpc_url"`
+	WsURL           string  `yaml:"ws_url"`
+	Preset          string  `yaml:"preset"`
+	ChainID         int64   `yaml:"chain_id"`
+	ExplorerURL     string  `yaml:"explorer_url"`
+	MinPriorityFee  string  `yaml:"min_priority_fee"`
+	Rollup          string  `yaml:"rollup"`
+	GasLimit        uint64  `yaml:"gas_limit"`
+	MaxBlockLag     uint64  `yaml:"max_block_lag"`
+	KeyConfig       `yaml:",inline"`
+	ContractAddress string            `yaml:"contract_address"`
+	Contracts       map[string]string `yaml:"contracts"`
+	IndexPath       string            `yaml:"index_path"`
+	StartBlock      uint64            `yaml:"start_block"`
+}
+
+// Config structure for deployment configuration
+type Config struct {
+	// Network is the profile used when --network is not given
+	Network string `yaml:"network"`
+	// Output is the output mode when --output is not given, text or json
+	Output   string                   `yaml:"output"`
+	Networks map[string]NetworkConfig `yaml:"networks"`
+	// Contracts maps aliases to the addresses or ENS names of contracts
+	Contracts map[string]string `yaml:"contracts"`
+	Ethereum  struct {
+		RpcURL      URLList `yaml:"rpc_url"`
+		WsURL       string  `yaml:"ws_url"`
+		MaxBlockLag uint64  `yaml:"max_block_lag"`
+		KeyConfig   `yaml:",inline"`
+		StrictKeys  bool   `yaml:"strict_keys"`
+		Preset      string `yaml:"preset"`
+		ChainID     int64  `yaml:"chain_id"`
+		ExplorerURL string `yaml:"explorer_url"`
+		// MinPriorityFee is in gwei
+		MinPriorityFee string `yaml:"min_priority_fee"`
+		Rollup         string `yaml:"rollup"`
+		GasLimit       uint64 `yaml:"gas_limit"`
+		Retry          struct {
+			MaxAttempts int           `yaml:"max_attempts"`
+			BaseDelay   time.Duration `yaml:"base_delay"`
+			MaxDelay    time.Duration `yaml:"max_delay"`
+		} `yaml:"retry"`
+		RateLimit struct {
+			RequestsPerSecond float64 `yaml:"requests_per_second"`
+			Burst             int     `yaml:"burst"`
+		} `yaml:"rate_limit"`
+		CircuitBreaker struct {
+			Threshold int           `yaml:"threshold"`
+			Cooldown  time.Duration `yaml:"cooldown"`
+		} `yaml:"circuit_breaker"`
+		Standby struct {
+			KeyConfig `yaml:",inline"`
+			LockFile  string        `yaml:"lock_file"`
+			LockTTL   time.Duration `yaml:"lock_ttl"`
+		} `yaml:"standby"`
+	} `yaml:"ethereum"`
+	Contract struct {
+		Address    string `yaml:"address"`
+		ProxyAdmin string `yaml:"proxy_admin"`
+	} `yaml:"contract"`
+	ENS struct {
+		Registry  string        `yaml:"registry"`
+		CacheFile string        `yaml:"cache_file"`
+		CacheTTL  time.Duration `yaml:"cache_ttl"`
+	} `yaml:"ens"`
+	Deployments struct {
+		File     string `yaml:"file"`
+		Operator string `yaml:"operator"`
+	} `yaml:"deployments"`
+	Index struct {
+		Path         string        `yaml:"path"`
+		StartBlock   uint64        `yaml:"start_block"`
+		BatchSize    uint64        `yaml:"batch_size"`
+		ScanFailures bool          `yaml:"scan_failures"`
+		SyncInterval time.Duration `yaml:"sync_interval"`
+	} `yaml:"index"`
+	Estimate struct {
+		From string `yaml:"from"`
+		Fiat struct {
+			Currency   string        `yaml:"currency"`
+			EtherPrice float64       `yaml:"ether_price"`
+			PriceURL   string        `yaml:"price_url"`
+			CacheTTL   time.Duration `yaml:"cache_ttl"`
+		} `yaml:"fiat"`
+	} `yaml:"estimate"`
+	Faucet struct {
+		MinBalance  string        `yaml:"min_balance"`
+		Wait        bool          `yaml:"wait"`
+		WaitTimeout time.Duration `yaml:"wait_timeout"`
+		Providers   []struct {
+			Name        string            `yaml:"name"`
+			ChainID     uint64            `yaml:"chain_id"`
+			URL         string            `yaml:"url"`
+			Method      string            `yaml:"method"`
+			Body        string            `yaml:"body"`
+			ContentType string            `yaml:"content_type"`
+			Headers     map[string]string `yaml:"headers"`
+		} `yaml:"providers"`
+	} `yaml:"faucet"`
+	BalanceCheck struct {
+		Disable       bool   `yaml:"disable"`
+		BufferPercent uint64 `yaml:"buffer_percent"`
+		MinBalance    string `yaml:"min_balance"`
+	} `yaml:"balance_check"`
+	Billing struct {
+		TenantBy  string `yaml:"tenant_by"`
+		Separator string `yaml:"separator"`
+		Tag       string `yaml:"tag"`
+	} `yaml:"billing"`
+	Export struct {
+		CSV struct {
+			Columns []struct {
+				Header string `yaml:"header"`
+				Source string `yaml:"source"`
+			} `yaml:"columns"`
+			Delimiter      string `yaml:"delimiter"`
+			QuoteAll       bool   `yaml:"quote_all"`
+			CRLF           bool   `yaml:"crlf"`
+			EscapeFormulas bool   `yaml:"escape_formulas"`
+		} `yaml:"csv"`
+	} `yaml:"export"`
+	Server struct {
+		Address     string `yaml:"address"`
+		GRPCAddress string `yaml:"grpc_address"`
+		AdminToken  string `yaml:"admin_token"`
+		WriteToken  string `yaml:"write_token"`
+		TLS         struct {
+			CertFile     string `yaml:"cert_file"`
+			KeyFile      string `yaml:"key_file"`
+			ClientCAFile string `yaml:"client_ca_file"`
+		} `yaml:"tls"`
+		AllowedIPs     []string `yaml:"allowed_ips"`
+		AllowedOrigins []string `yaml:"allowed_origins"`
+		Idempotency    struct {
+			File string        `yaml:"file"`
+			TTL  time.Duration `yaml:"ttl"`
+		} `yaml:"idempotency"`
+	} `yaml:"server"`
+	S3 struct {
+		Address       string `yaml:"address"`
+		AccessKey     string `yaml:"access_key"`
+		SecretKey     string `yaml:"secret_key"`
+		Region        string `yaml:"region"`
+		Domain        string `yaml:"domain"`
+		PublicRead    bool   `yaml:"public_read"`
+		MaxObjectSize int64  `yaml:"max_object_size"`
+	} `yaml:"s3"`
+	Webhook struct {
+		URLs            []string      `yaml:"urls"`
+		SecretsFile     string        `yaml:"secrets_file"`
+		RotationOverlap time.Duration `yaml:"rotation_overlap"`
+		Timeout         time.Duration `yaml:"timeout"`
+		Retry           struct {
+			MaxAttempts int           `yaml:"max_attempts"`
+			BaseDelay   time.Duration `yaml:"base_delay"`
+			MaxDelay    time.Duration `yaml:"max_delay"`
+		} `yaml:"retry"`
+		DLQFile    string `yaml:"dlq_file"`
+		CursorFile string `yaml:"cursor_file"`
+	} `yaml:"webhook"`
+	Cache struct {
+		// RedisURL enables the read cache of serve.
+		RedisURL string        `yaml:"redis_url"`
+		TTL      time.Duration `yaml:"ttl"`
+		Prefix   string        `yaml:"prefix"`
+	} `yaml:"cache"`
+	Publish struct {
+		// Broker is kafka or nats.
+		Broker string `yaml:"broker"`
+		Kafka  struct {
+			Brokers  []string `yaml:"brokers"`
+			Topic    string   `yaml:"topic"`
+			Username string   `yaml:"username"`
+			Password string   `yaml:"password"`
+			TLS      bool     `yaml:"tls"`
+		} `yaml:"kafka"`
+		NATS struct {
+			URL         string `yaml:"url"`
+			Subject     string `yaml:"subject"`
+			Credentials string `yaml:"credentials"`
+			Token       string `yaml:"token"`
+		} `yaml:"nats"`
+		Batch      int    `yaml:"batch"`
+		CursorFile string `yaml:"cursor_file"`
+	} `yaml:"publish"`
+	Mirror struct {
+		// Driver is postgres or mysql.
+		Driver      string `yaml:"driver"`
+		DSN         string `yaml:"dsn"`
+		TablePrefix string `yaml:"table_prefix"`
+	} `yaml:"mirror"`
+	Build struct {
+		Directory    string `yaml:"directory"`
+		ContractName string `yaml:"contract_name"`
+	} `yaml:"build"`
+	Confirmation struct {
+		Confirmations uint64        `yaml:"confirmations"`
+		Finalized     bool          `yaml:"finalized"`
+		PollInterval  time.Duration `yaml:"poll_interval"`
+	} `yaml:"confirmation"`
+	Reorg struct {
+		Depth        uint64        `yaml:"depth"`
+		PollInterval time.Duration `yaml:"poll_interval"`
+		Rebroadcast  bool          `yaml:"rebroadcast"`
+		DropTimeout  time.Duration `yaml:"drop_timeout"`
+	} `yaml:"reorg"`
+	State struct {
+		File string `yaml:"file"`
+	} `yaml:"state"`
+	Safe struct {
+		Address      string        `yaml:"address"`
+		ServiceURL   string        `yaml:"service_url"`
+		CreateCall   string        `yaml:"create_call"`
+		Wait         bool          `yaml:"wait"`
+		PollInterval time.Duration `yaml:"poll_interval"`
+	} `yaml:"safe"`
+	Forwarder struct {
+		Address    string        `yaml:"address"`
+		Kind       string        `yaml:"kind"`
+		Name       string        `yaml:"name"`
+		Version    string        `yaml:"version"`
+		Gas        uint64        `yaml:"gas"`
+		RequestTTL time.Duration `yaml:"request_ttl"`
+	} `yaml:"forwarder"`
+	WriteQueue struct {
+		Enabled       bool          `yaml:"enabled"`
+		File          string        `yaml:"file"`
+		ProbeInterval time.Duration `yaml:"probe_interval"`
+	} `yaml:"write_queue"`
+	Timeouts struct {
+		RPC          time.Duration `yaml:"rpc"`
+		Transaction  time.Duration `yaml:"transaction"`
+		Confirmation time.Duration `yaml:"confirmation"`
+		Reorg        time.Duration `yaml:"reorg"`
+		Sync         time.Duration `yaml:"sync"`
+		Shutdown     time.Duration `yaml:"shutdown"`
+	} `yaml:"timeouts"`
+	Read struct {
+		DisableCCIP    bool          `yaml:"disable_ccip"`
+		GatewayTimeout time.Duration `yaml:"gateway_timeout"`
+		MaxLookups     int           `yaml:"max_lookups"`
+	} `yaml:"read"`
+	Storage struct {
+		// Namespace is put in front of every key, so that applications
+		// sharing the contract keep apart
+		Namespace string `yaml:"namespace"`
+		// SoftDelete makes deletes write tombstones restore can undo
+		SoftDelete    bool          `yaml:"soft_delete"`
+		Envelope      bool          `yaml:"envelope"`
+		HashAlg       string        `yaml:"hash_alg"`
+		Compression   string        `yaml:"compression"`
+		CompressAbove int           `yaml:"compress_above"`
+		ChunkSize     int           `yaml:"chunk_size"`
+		Schemas       []schema.Rule `yaml:"schemas"`
+		Encryption    struct {
+			Enabled   bool   `yaml:"enabled"`
+			SecretEnv string `yaml:"secret_env"`
+			KMS       struct {
+				KeyID    string `yaml:"key_id"`
+				Region   string `yaml:"region"`
+				Endpoint string `yaml:"endpoint"`
+			} `yaml:"kms"`
+		} `yaml:"encryption"`
+	} `yaml:"storage"`
+	IPFS struct {
+		Enabled bool `yaml:"enabled"`
+		// StoreAbove is the length of the sealed values stored on IPFS
+		// rather than on chain
+		StoreAbove int    `yaml:"store_above"`
+		Pinning    string `yaml:"pinning"`
+		APIURL     string `yaml:"api_url"`
+		GatewayURL string `yaml:"gateway_url"`
+		Pinata     struct {
+			JWT string `yaml:"jwt"`
+			URL string `yaml:"url"`
+		} `yaml:"pinata"`
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"ipfs"`
+	Arweave struct {
+		Enabled bool `yaml:"enabled"`
+		// StoreAbove is the length of the sealed values stored on Arweave
+		// rather than on chain
+		StoreAbove int           `yaml:"store_above"`
+		BundlerURL string        `yaml:"bundler_url"`
+		PrivateKey string        `yaml:"private_key"`
+		GatewayURL string        `yaml:"gateway_url"`
+		Timeout    time.Duration `yaml:"timeout"`
+	} `yaml:"arweave"`
+	Replication struct {
+		Networks []string `yaml:"networks"`
+		File     string   `yaml:"file"`
+	} `yaml:"replication"`
+	Canary struct {
+		Enabled  bool          `yaml:"enabled"`
+		Key      string        `yaml:"key"`
+		Field    string        `yaml:"field"`
+		Interval time.Duration `yaml:"interval"`
+		Timeout  time.Duration `yaml:"timeout"`
+	} `yaml:"canary"`
+	Tracing struct {
+		Enabled     bool              `yaml:"enabled"`
+		Endpoint    string            `yaml:"endpoint"`
+		Insecure    bool              `yaml:"insecure"`
+		Headers     map[string]string `yaml:"headers"`
+		ServiceName string            `yaml:"service_name"`
+		SampleRatio float64           `yaml:"sample_ratio"`
+	} `yaml:"tracing"`
+	Log struct {
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"`
+	} `yaml:"log"`
+	Audit struct {
+		File string `yaml:"file"`
+	} `yaml:"audit"`
+	WriterPool struct {
+		Workers   int         `yaml:"workers"`
+		QueueSize int         `yaml:"queue_size"`
+		Lanes     []KeyConfig `yaml:"lanes"`
+	} `yaml:"writer_pool"`
+	Outbox struct {
+		File         string        `yaml:"file"`
+		MaxAttempts  int           `yaml:"max_attempts"`
+		RetryDelay   time.Duration `yaml:"retry_delay"`
+		PollInterval time.Duration `yaml:"poll_interval"`
+	} `yaml:"outbox"`
+	Spool struct {
+		Directory    string        `yaml:"directory"`
+		FIFO         string        `yaml:"fifo"`
+		PollInterval time.Duration `yaml:"poll_interval"`
+	} `yaml:"spool"`
+	GasReport struct {
+		Enabled   bool   `yaml:"enabled"`
+		TypeBy    string `yaml:"type_by"`
+		Separator string `yaml:"separator"`
+		CSV       string `yaml:"csv"`
+	} `yaml:"gas_report"`
+	SmokeTest struct {
+		AfterDeploy bool         `yaml:"after_deploy"`
+		KeyPrefix   string       `yaml:"key_prefix"`
+		Cases       []smoke.Case `yaml:"cases"`
+	} `yaml:"smoke_test"`
+
+	// base is the configuration before a network was selected, which the
+	// replica networks apply to
+	base *Config
+	// overrides holds the variable or flag that set each overridden
+	// setting, by path
+	overrides map[string]string
+}
+
+// samplePrivateKey is the placeholder of the sample config.yaml
+const samplePrivateKey = "YOUR_PRIVATE_KEY_HERE"
+
+func loadConfig(filename string, flagValues map[string]settingValue) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = configYAML(filename, data); err != nil {
+		return nil, err
+	}
+	var config Config
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateTest(data, &config); err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(&config, flagValues); err != nil {
+		return nil, err
+	}
+	// The sample key counts as no key, leaving the read commands usable
+	if config.Ethereum.PrivateKey == samplePrivateKey {
+		config.Ethereum.PrivateKey = ""
+	}
+	for name, network := range config.Networks {
+		if network.PrivateKey == samplePrivateKey {
+			network.PrivateKey = ""
+			config.Networks[name] = network
+		}
+	}
+	if err := checkStrictKeys(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// migrateTest carries the test section of older files, which ran one
+// canary record after deploy, over to smoke_test: an enabled test becomes
+// smoke_test.after_deploy, with its record as the only case unless cases are
+// configured
+func migrateTest(data []byte, config *Config) error {
+	var legacy struct {
+		Test *struct {
+			Enable    bool   `yaml:"enable"`
+			TestKey   string `yaml:"test_key"`
+			TestField string `yaml:"test_field"`
+			TestValue string `yaml:"test_value"`
+		} `yaml:"test"`
+	}
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	test := legacy.Test
+	if test == nil || !test.Enable {
+		return nil
+	}
+	config.SmokeTest.AfterDeploy = true
+	if len(config.SmokeTest.Cases) == 0 && test.TestKey != "" {
+		config.SmokeTest.Cases = []smoke.Case{{
+			Name:  "test",
+			Key:   test.TestKey,
+			Field: test.TestField,
+			Value: test.TestValue,
+		}}
+	}
+	return nil
+}
+
+// configYAML returns a configuration file in YAML, converting JSON and TOML
+// files, told apart by their extension, so that every format decodes with
+// the yaml tags of Config
+func configYAML(filename string, data []byte) ([]byte, error) {
+	var doc interface{}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		doc = jsonNumbers(doc)
+	case ".toml":
+		var table map[string]interface{}
+		if err := toml.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		doc = table
+	default:
+		return data, nil
+	}
+	return yaml.Marshal(doc)
+}
+
+// jsonNumbers turns the numbers of a decoded JSON document into integers
+// where they are whole, since YAML would write large floats as 1e+06, which
+// integer settings refuse
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	}
+	return v
+}
+
+// selectNetwork applies the named network profile over the top-level
+// settings, then the chain preset. An empty name selects the default
+// profile, if any, and a preset name without a profile selects the preset
+func selectNetwork(config *Config, name string) error {
+	base := *config
+	config.base = &base
+	if name == "" {
+		name = config.Network
+	}
+	if name != "" {
+		network, ok := config.Networks[name]
+		if !ok {
+			if _, ok := presets.Lookup(name); !ok {
+				names := make([]string, 0, len(config.Networks))
+				for n := range config.Networks {
+					names = append(names, n)
+				}
+				sort.Strings(names)
+				return fmt.Errorf("unknown network %q, known networks: %s", name, strings.Join(append(names, presets.Names()...), ", "))
+			}
+			network = NetworkConfig{Preset: name}
+		}
+		config.Network = name
+		applyNetwork(config, network)
+	}
+	return applyPreset(config)
+}
+
+// selectContract checks the contract aliases and points contract.address to
+// the contract given with --contract: an alias, an address or an ENS name
+func selectContract(config *Config, name string) error {
+	for alias, address := range config.Contracts {
+		if alias == "" || common.IsHexAddress(alias) {
+			return fmt.Errorf("contracts: alias %q is not a name", alias)
+		}
+		if !common.IsHexAddress(address) && !ens.IsName(address) {
+			return fmt.Errorf("contracts: %s is %q, neither an address nor an ENS name", alias, address)
+		}
+	}
+	if name == "" {
+		return nil
+	}
+	if address, ok := config.Contracts[name]; ok {
+		config.Contract.Address = address
+		return nil
+	}
+	if !common.IsHexAddress(name) && !ens.IsName(name) {
+		names := make([]string, 0, len(config.Contracts))
+		for n := range config.Contracts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown contract %q, known contracts: %s", name, strings.Join(names, ", "))
+	}
+	config.Contract.Address = name
+	return nil
+}
+
+// applyNetwork overlays the settings a network profile sets
+func applyNetwork(config *Config, network NetworkConfig) {
+	// The WebSocket endpoint of the top level belongs to its nodes
+	if len(network.RpcURL) > 0 {
+		config.Ethereum.RpcURL = network.RpcURL
+		config.Ethereum.WsURL = ""
+	}
+	if network.WsURL != "" {
+		config.Ethereum.WsURL = network.WsURL
+	}
+	// A network naming a preset is another chain, the chain settings of the
+	// top level do not apply to it
+	if network.Preset != "" {
+		config.Ethereum.Preset = network.Preset
+		config.Ethereum.ChainID = 0
+		config.Ethereum.ExplorerURL = ""
+		config.Ethereum.MinPriorityFee = ""
+		config.Ethereum.Rollup = ""
+	}
+	if network.ChainID != 0 {
+		config.Ethereum.ChainID = network.ChainID
+	}
+	if network.ExplorerURL != "" {
+		config.Ethereum.ExplorerURL = network.ExplorerURL
+	}
+	if network.MinPriorityFee != "" {
+		config.Ethereum.MinPriorityFee = network.MinPriorityFee
+	}
+	if network.Rollup != "" {
+		config.Ethereum.Rollup = network.Rollup
+	}
+	if network.GasLimit != 0 {
+		config.Ethereum.GasLimit = network.GasLimit
+	}
+	if network.MaxBlockLag != 0 {
+		config.Ethereum.MaxBlockLag = network.MaxBlockLag
+	}
+	// A network naming a key source replaces the top-level one entirely
+	if network.KeyConfig.configured() {
+		config.Ethereum.KeyConfig = network.KeyConfig
+	}
+	if network.ContractAddress != "" {
+		config.Contract.Address = network.ContractAddress
+	}
+	// Aliases of the top level name contracts of its chain, so a network
+	// with aliases of its own replaces them entirely
+	if len(network.Contracts) > 0 {
+		config.Contracts = network.Contracts
+	}
+	if network.IndexPath != "" {
+		config.Index.Path = network.IndexPath
+	}
+	if network.StartBlock != 0 {
+		config.Index.StartBlock = network.StartBlock
+	}
+}
+
+// applyPreset fills in the chain ID, explorer and rollup of the configured
+// preset where the configuration leaves them unset
+func applyPreset(config *Config) error {
+	if config.Ethereum.Preset == "" {
+		return checkFeeSettings(config)
+	}
+	preset, ok := presets.Lookup(config.Ethereum.Preset)
+	if !ok {
+		return fmt.Errorf("unknown preset %q, available presets: %s", config.Ethereum.Preset, strings.Join(presets.Names(), ", "))
+	}
+	switch {
+	case config.Ethereum.ChainID == 0:
+		config.Ethereum.ChainID = int64(preset.ChainID)
+	case uint64(config.Ethereum.ChainID) != preset.ChainID:
+		return fmt.Errorf("chain_id %d contradicts preset %s, which is chain %d", config.Ethereum.ChainID, preset.Name, preset.ChainID)
+	}
+	if config.Ethereum.ExplorerURL == "" {
+		config.Ethereum.ExplorerURL = preset.Explorer
+	}
+	if config.Ethereum.Rollup == "" {
+		config.Ethereum.Rollup = preset.Rollup
+	}
+	return checkFeeSettings(config)
+}
+
+// checkFeeSettings validates min_priority_fee and rollup
+func checkFeeSettings(config *Config) error {
+	switch config.Ethereum.Rollup {
+	case "", presets.RollupOPStack, presets.RollupArbitrum:
+	default:
+		return fmt.Errorf("unknown rollup %q, use %s or %s", config.Ethereum.Rollup, presets.RollupOPStack, presets.RollupArbitrum)
+	}
+	_, err := minPriorityFee(config)
+	return err
+}
+
+// minPriorityFee returns the lowest tip to send in wei, from
+// min_priority_fee or else the preset, nil when neither sets one
+func minPriorityFee(config *Config) (*big.Int, error) {
+	if config.Ethereum.MinPriorityFee != "" {
+		fee, err := fees.ParseGwei(config.Ethereum.MinPriorityFee)
+		if err != nil {
+			return nil, fmt.Errorf("min_priority_fee: %w", err)
+		}
+		return fee, nil
+	}
+	if preset, ok := presets.Lookup(config.Ethereum.Preset); ok {
+		return preset.MinPriorityFee, nil
+	}
+	return nil, nil
+}
+
+// explorerURL links path (such as "tx/0x...") on the configured block
+// explorer, empty without one
+func explorerURL(config *Config, path string) string {
+	if config.Ethereum.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(config.Ethereum.ExplorerURL, "/") + "/" + path
+}
+
+// globalFlag removes --NAME VALUE (or --NAME=VALUE) from the command
+// line, wherever it appears before "--", and returns its value. When
+// accept is set, occurrences with other values are left to the command
+func globalFlag(args []string, flagName string, accept func(string) bool) (string, []string, error) {
+	var value string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		switch {
+		case arg == "--"+flagName || arg == "-"+flagName:
+			if i+1 < len(args) && (accept == nil || accept(args[i+1])) {
+				value = args[i+1]
+				i++
+				continue
+			}
+			if accept == nil {
+				return "", nil, fmt.Errorf("--%s requires a value", flagName)
+			}
+		case strings.HasPrefix(arg, "--"+flagName+"=") || strings.HasPrefix(arg, "-"+flagName+"="):
+			if v := arg[strings.Index(arg, "=")+1:]; accept == nil || accept(v) {
+				value = v
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return value, rest, nil
+}
+
+// globalSwitch takes the boolean flag flagName out of args, the way
+// globalFlag does. Only the --name=value form takes a value
+func globalSwitch(args []string, flagName string) (bool, []string, error) {
+	var value bool
+	rest := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		switch {
+		case arg == "--"+flagName || arg == "-"+flagName:
+			value = true
+			continue
+		case strings.HasPrefix(arg, "--"+flagName+"=") || strings.HasPrefix(arg, "-"+flagName+"="):
+			v, err := strconv.ParseBool(arg[strings.Index(arg, "=")+1:])
+			if err != nil {
+				return false, nil, fmt.Errorf("--%s takes true or false", flagName)
+			}
+			value = v
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return value, rest, nil
+}
+
+// contractAddress returns the configured address of the deployed contract
+func contractAddress(config *Config) (common.Address, error) {
+	if config.Contract.Address == "" && len(config.Contracts) > 0 {
+		return common.Address{}, fail(codeConfig, "contract.address is not configured, set it or pick one of contracts with --contract")
+	}
+	if config.Contract.Address == "" {
+		return common.Address{}, fail(codeConfig, "contract.address is not configured")
+	}
+	if !common.IsHexAddress(config.Contract.Address) {
+		return common.Address{}, errors.New("contract.address is not a valid address")
+	}
+	return common.HexToAddress(config.Contract.Address), nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/smoke"
+)
+
+func TestMigrateTest(t *testing.T) {
+	tests := []struct {
+		name  string
+		yaml  string
+		after bool
+		cases []smoke.Case
+	}{
+		{
+			name:  "enabled",
+			yaml:  "test:\n  enable: true\n  test_key: \"k\"\n  test_field: \"f\"\n  test_value: \"v\"\n",
+			after: true,
+			cases: []smoke.Case{{Name: "test", Key: "k", Field: "f", Value: "v"}},
+		},
+		{
+			name: "disabled",
+			yaml: "test:\n  enable: false\n  test_key: \"k\"\n",
+		},
+		{
+			name:  "configured cases win",
+			yaml:  "test:\n  enable: true\n  test_key: \"k\"\nsmoke_test:\n  cases:\n    - name: \"a\"\n      value: \"x\"\n",
+			after: true,
+			cases: []smoke.Case{{Name: "a", Value: "x"}},
+		},
+		{
+			name:  "smoke_test only",
+			yaml:  "smoke_test:\n  after_deploy: true\n",
+			after: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			config, err := loadConfig(file, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.SmokeTest.AfterDeploy != tt.after {
+				t.Errorf("after_deploy = %v, want %v", config.SmokeTest.AfterDeploy, tt.after)
+			}
+			if !reflect.DeepEqual(config.SmokeTest.Cases, tt.cases) {
+				t.Errorf("cases = %+v, want %+v", config.SmokeTest.Cases, tt.cases)
+			}
+		})
+	}
+}
+
+func TestSelectNetwork(t *testing.T) {
+	const yaml = `network: local
+ethereum:
+  rpc_url: "http://127.0.0.1:8545"
+  ws_url: "ws://127.0.0.1:8546"
+  chain_id: 1337
+  private_key: "env:LOCAL_KEY"
+contract:
+  address: "0x00000000000000000000000000000000000000c5"
+contracts:
+  archive: "0x00000000000000000000000000000000000000a1"
+index:
+  path: "index.db"
+networks:
+  local: {}
+  staging:
+    preset: sepolia
+    rpc_url: "https://sepolia.example.com"
+    private_key: "env:STAGING_KEY"
+    contract_address: "0x00000000000000000000000000000000000000d6"
+    index_path: "sepolia.db"
+  l2:
+    rpc_url: "https://l2.example.com"
+    chain_id: 10
+    rollup: op-stack
+    contracts:
+      ledger: "0x00000000000000000000000000000000000000e7"
+`
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		network   string
+		rpc       string
+		ws        string
+		chainID   int64
+		explorer  string
+		key       string
+		contract  string
+		contracts []string
+		index     string
+	}{
+		{"default profile", "", "http://127.0.0.1:8545", "ws://127.0.0.1:8546", 1337, "", "env:LOCAL_KEY", "0x00000000000000000000000000000000000000c5", []string{"archive"}, "index.db"},
+		{"preset profile", "staging", "https://sepolia.example.com", "", 11155111, "https://sepolia.etherscan.io", "env:STAGING_KEY", "0x00000000000000000000000000000000000000d6", []string{"archive"}, "sepolia.db"},
+		{"profile with aliases", "l2", "https://l2.example.com", "", 10, "", "env:LOCAL_KEY", "0x00000000000000000000000000000000000000c5", []string{"ledger"}, "index.db"},
+		{"bare preset", "mainnet", "http://127.0.0.1:8545", "ws://127.0.0.1:8546", 1, "https://etherscan.io", "env:LOCAL_KEY", "0x00000000000000000000000000000000000000c5", []string{"archive"}, "index.db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadConfig(file, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := selectNetwork(config, tt.network); err != nil {
+				t.Fatal(err)
+			}
+			var contracts []string
+			for alias := range config.Contracts {
+				contracts = append(contracts, alias)
+			}
+			got := []interface{}{strings.Join(config.Ethereum.RpcURL, ","), config.Ethereum.WsURL, config.Ethereum.ChainID, config.Ethereum.ExplorerURL, config.Ethereum.PrivateKey, config.Contract.Address, contracts, config.Index.Path}
+			want := []interface{}{tt.rpc, tt.ws, tt.chainID, tt.explorer, tt.key, tt.contract, tt.contracts, tt.index}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("settings %v, want %v", got, want)
+			}
+		})
+	}
+
+	config, err := loadConfig(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := selectNetwork(config, "prod"); err == nil || !strings.Contains(err.Error(), `unknown network "prod", known networks: l2, local, staging, mainnet`) {
+		t.Errorf("unknown network: got %v", err)
+	}
+}
+
+func TestSelectContract(t *testing.T) {
+	aliases := map[string]string{
+		"archive": "0x00000000000000000000000000000000000000a1",
+		"ledger":  "ledger.storage.eth",
+	}
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		arg     string
+		address string
+		err     string
+	}{
+		{"no selection", aliases, "", "0x00000000000000000000000000000000000000c5", ""},
+		{"alias", aliases, "archive", "0x00000000000000000000000000000000000000a1", ""},
+		{"alias of an ENS name", aliases, "ledger", "ledger.storage.eth", ""},
+		{"address", aliases, "0x00000000000000000000000000000000000000d6", "0x00000000000000000000000000000000000000d6", ""},
+		{"ENS name", aliases, "other.eth", "other.eth", ""},
+		{"unknown alias", aliases, "archiv", "", `unknown contract "archiv", known contracts: archive, ledger`},
+		{"address as alias", map[string]string{"0x00000000000000000000000000000000000000a1": "0x00000000000000000000000000000000000000a1"}, "", "", "is not a name"},
+		{"invalid target", map[string]string{"archive": "not an address"}, "", "", `contracts: archive is "not an address", neither an address nor an ENS name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			config.Contracts = tt.aliases
+			config.Contract.Address = "0x00000000000000000000000000000000000000c5"
+			err := selectContract(&config, tt.arg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got %v, want error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Contract.Address != tt.address {
+				t.Errorf("contract.address = %s, want %s", config.Contract.Address, tt.address)
+			}
+		})
+	}
+}
+
+func TestResolveAlias(t *testing.T) {
+	var config Config
+	config.Contracts = map[string]string{"archive": "0x00000000000000000000000000000000000000a1"}
+	// Aliases and addresses resolve without asking the node
+	address, err := resolveAddress(context.Background(), &config, nil, "archive")
+	if err != nil || address.Hex() != "0x00000000000000000000000000000000000000A1" {
+		t.Errorf("resolveAddress(archive) = %s, %v", address.Hex(), err)
+	}
+	if _, err := resolveAddress(context.Background(), &config, nil, "not an alias"); err == nil || !strings.Contains(err.Error(), "neither a contract alias, an address nor an ENS name") {
+		t.Errorf("unknown alias: got %v", err)
+	}
+}
+
+func TestConfigFormats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `ethereum:
+  rpc_url: ["http://127.0.0.1:8545", "http://127.0.0.1:8546"]
+  chain_id: 1337
+  gas_limit: 3000000
+timeouts:
+  rpc: 30s
+contracts:
+  archive: "0x00000000000000000000000000000000000000a1"
+`,
+		"config.json": `{
+  "ethereum": {"rpc_url": ["http://127.0.0.1:8545", "http://127.0.0.1:8546"], "chain_id": 1337, "gas_limit": 3000000},
+  "timeouts": {"rpc": "30s"},
+  "contracts": {"archive": "0x00000000000000000000000000000000000000a1"}
+}`,
+		"config.toml": `[ethereum]
+rpc_url = ["http://127.0.0.1:8545", "http://127.0.0.1:8546"]
+chain_id = 1337
+gas_limit = 3000000
+
+[timeouts]
+rpc = "30s"
+
+[contracts]
+archive = "0x00000000000000000000000000000000000000a1"
+`,
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, name)
+			if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			config, err := loadConfig(file, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []interface{}{[]string(config.Ethereum.RpcURL), config.Ethereum.ChainID, config.Ethereum.GasLimit, config.Timeouts.RPC.String(), config.Contracts["archive"]}
+			want := []interface{}{[]string{"http://127.0.0.1:8545", "http://127.0.0.1:8546"}, int64(1337), uint64(3000000), "30s", "0x00000000000000000000000000000000000000a1"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("settings %v, want %v", got, want)
+			}
+		})
+	}
+
+	for name, content := range map[string]string{"broken.json": `{"ethereum": `, "broken.toml": "[ethereum\n"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfig(file, nil); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: got %v, want an error naming the file", name, err)
+		}
+	}
+}
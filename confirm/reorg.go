@@ -0,0 +1,172 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confirm tracks transactions after they have been mined, making
+// sure they stay in the canonical chain.
+package confirm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"contract-storage-eth/chain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend is the subset of the Ethereum client used to track transactions.
+type Backend interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// ReorgKind describes what happened to a watched transaction.
+type ReorgKind int
+
+const (
+	// ReorgDropped means the transaction is no longer in the canonical chain.
+	ReorgDropped ReorgKind = iota
+	// ReorgMoved means the transaction was re-included in a different block.
+	ReorgMoved
+	// ReorgRebroadcast means the dropped transaction was sent to the node again.
+	ReorgRebroadcast
+)
+
+func (k ReorgKind) String() string {
+	switch k {
+	case ReorgDropped:
+		return "dropped"
+	case ReorgMoved:
+		return "moved"
+	case ReorgRebroadcast:
+		return "rebroadcast"
+	default:
+		return "unknown"
+	}
+}
+
+// ReorgEvent is reported whenever a watched transaction is affected by a reorg.
+type ReorgEvent struct {
+	Kind     ReorgKind
+	TxHash   common.Hash
+	OldBlock common.Hash
+	NewBlock common.Hash
+	Err      error
+}
+
+// ReorgOptions configure WatchReorg.
+type ReorgOptions struct {
+	// Depth is the number of blocks on top of the inclusion block after
+	// which the transaction is considered final.
+	Depth uint64
+	// PollInterval is the delay between inclusion checks.
+	PollInterval time.Duration
+	// Rebroadcast resends the signed transaction when it is dropped, once
+	// per drop.
+	Rebroadcast bool
+	// DropTimeout is how long the transaction may stay out of the chain
+	// before WatchReorg gives up with ErrDropped, DefaultDropTimeout when
+	// zero.
+	DropTimeout time.Duration
+	// OnReorg is called for every reorg affecting the transaction.
+	OnReorg func(ReorgEvent)
+}
+
+const defaultPollInterval = 5 * time.Second
+
+// DefaultDropTimeout is the DropTimeout of options without one.
+const DefaultDropTimeout = 10 * time.Minute
+
+// ErrDropped is returned when a dropped transaction is not mined again
+// within the drop timeout.
+var ErrDropped = errors.New("transaction dropped by a reorg and not mined again")
+
+// WatchReorg re-checks that tx, mined in receipt, stays in the canonical
+// chain until it is buried under opts.Depth blocks. If a reorg drops it the
+// transaction is optionally rebroadcast and watching continues from its new
+// inclusion block, or fails with ErrDropped if it stays out of the chain for
+// opts.DropTimeout. It returns the final receipt, which may differ from the
+// original one if the transaction moved.
+func WatchReorg(ctx context.Context, b Backend, tx *types.Transaction, receipt *types.Receipt, opts ReorgOptions) (*types.Receipt, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	notify := func(ev ReorgEvent) {
+		if opts.OnReorg != nil {
+			opts.OnReorg(ev)
+		}
+	}
+
+	dropTimeout := opts.DropTimeout
+	if dropTimeout <= 0 {
+		dropTimeout = DefaultDropTimeout
+	}
+
+	current := receipt
+	var (
+		dropped     bool
+		droppedAt   time.Time
+		rebroadcast bool
+	)
+	for {
+		latest, err := b.TransactionReceipt(ctx, tx.Hash())
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			if !dropped {
+				dropped, droppedAt, rebroadcast = true, time.Now(), false
+				notify(ReorgEvent{Kind: ReorgDropped, TxHash: tx.Hash(), OldBlock: current.BlockHash})
+			}
+			if time.Since(droppedAt) >= dropTimeout {
+				return current, fmt.Errorf("%w within %s", ErrDropped, dropTimeout)
+			}
+			// Sent again until the node accepts it, then left to be mined
+			if opts.Rebroadcast && !rebroadcast {
+				err = b.SendTransaction(ctx, tx)
+				if err != nil && chain.IsAlreadyKnown(err) {
+					err = nil
+				}
+				rebroadcast = err == nil
+				notify(ReorgEvent{Kind: ReorgRebroadcast, TxHash: tx.Hash(), OldBlock: current.BlockHash, Err: err})
+			}
+		case err != nil:
+			return current, err
+		default:
+			if dropped || latest.BlockHash != current.BlockHash {
+				notify(ReorgEvent{Kind: ReorgMoved, TxHash: tx.Hash(), OldBlock: current.BlockHash, NewBlock: latest.BlockHash})
+			}
+			dropped = false
+			current = latest
+
+			head, err := b.BlockNumber(ctx)
+			if err != nil {
+				return current, err
+			}
+			if head >= current.BlockNumber.Uint64()+opts.Depth {
+				return current, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return current, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
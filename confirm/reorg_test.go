@@ -0,0 +1,158 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confirm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"contract-storage-eth/confirm"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func (f *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.current().head, nil
+}
+
+func (f *fakeChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sends++
+	if len(f.sendErrs) > 0 {
+		err := f.sendErrs[0]
+		f.sendErrs = f.sendErrs[1:]
+		return err
+	}
+	return nil
+}
+
+// dropped returns n polls without the transaction.
+func dropped(n int) []step {
+	return make([]step, n)
+}
+
+func TestWatchReorg(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []step
+		sendErrs []error
+		opts     confirm.ReorgOptions
+		events   []string
+		sends    int
+		block    string
+		wantErr  error
+	}{
+		{
+			name: "buried",
+			steps: []step{
+				{receipt: minedIn(10, "0xa"), head: 10},
+				{receipt: minedIn(10, "0xa"), head: 12},
+			},
+			opts:  confirm.ReorgOptions{Depth: 2},
+			block: "0xa",
+		},
+		{
+			name: "moved",
+			steps: []step{
+				{receipt: minedIn(11, "0xb"), head: 11},
+				{receipt: minedIn(11, "0xb"), head: 13},
+			},
+			opts:   confirm.ReorgOptions{Depth: 2},
+			events: []string{"moved 0xb"},
+			block:  "0xb",
+		},
+		{
+			name: "dropped and rebroadcast once",
+			steps: append(dropped(5),
+				step{receipt: minedIn(12, "0xc"), head: 14},
+			),
+			opts:   confirm.ReorgOptions{Depth: 2, Rebroadcast: true},
+			events: []string{"dropped", "rebroadcast", "moved 0xc"},
+			sends:  1,
+			block:  "0xc",
+		},
+		{
+			name: "failed rebroadcast retried",
+			steps: append(dropped(5),
+				step{receipt: minedIn(12, "0xc"), head: 14},
+			),
+			sendErrs: []error{errors.New("connection reset")},
+			opts:     confirm.ReorgOptions{Depth: 2, Rebroadcast: true},
+			events:   []string{"dropped", "rebroadcast error", "rebroadcast", "moved 0xc"},
+			sends:    2,
+			block:    "0xc",
+		},
+		{
+			name:  "dropped again",
+			steps: append(append(dropped(2), step{receipt: minedIn(12, "0xc"), head: 12}), append(dropped(2), step{receipt: minedIn(13, "0xd"), head: 15})...),
+			opts:  confirm.ReorgOptions{Depth: 2, Rebroadcast: true},
+			events: []string{
+				"dropped", "rebroadcast", "moved 0xc",
+				"dropped", "rebroadcast", "moved 0xd",
+			},
+			sends: 2,
+			block: "0xd",
+		},
+		{
+			name:    "never mined again",
+			steps:   dropped(1),
+			opts:    confirm.ReorgOptions{Depth: 2, Rebroadcast: true, DropTimeout: 20 * time.Millisecond},
+			events:  []string{"dropped", "rebroadcast"},
+			sends:   1,
+			block:   "0xa",
+			wantErr: confirm.ErrDropped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			opts := tt.opts
+			opts.PollInterval = time.Millisecond
+			opts.OnReorg = func(ev confirm.ReorgEvent) {
+				switch {
+				case ev.Kind == confirm.ReorgMoved:
+					events = append(events, fmt.Sprintf("moved %#x", ev.NewBlock.Big()))
+				case ev.Err != nil:
+					events = append(events, ev.Kind.String()+" error")
+				default:
+					events = append(events, ev.Kind.String())
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			fake := &fakeChain{steps: tt.steps, sendErrs: tt.sendErrs}
+			receipt, err := confirm.WatchReorg(ctx, fake, testTx(), minedIn(10, "0xa"), opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(events, tt.events) {
+				t.Errorf("events %q, want %q", events, tt.events)
+			}
+			if fake.sends != tt.sends {
+				t.Errorf("%d rebroadcasts, want %d", fake.sends, tt.sends)
+			}
+			if receipt.BlockHash != common.HexToHash(tt.block) {
+				t.Errorf("receipt mined in %s, want %s", receipt.BlockHash.Hex(), tt.block)
+			}
+		})
+	}
+}
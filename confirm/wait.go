@@ -0,0 +1,192 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confirm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// WaitBackend is the subset of the Ethereum client used by Wait.
+type WaitBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Stage is a step in the life of a submitted transaction.
+type Stage int
+
+const (
+	// StageSubmitted means the transaction was sent but not seen yet.
+	StageSubmitted Stage = iota
+	// StagePending means the node has the transaction in its mempool.
+	StagePending
+	// StageIncluded means the transaction was mined in a block.
+	StageIncluded
+	// StageConfirmed means another block was built on top of the inclusion block.
+	StageConfirmed
+	// StageFinalized means the inclusion block was finalized by consensus.
+	StageFinalized
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageSubmitted:
+		return "submitted"
+	case StagePending:
+		return "pending"
+	case StageIncluded:
+		return "included"
+	case StageConfirmed:
+		return "confirmed"
+	case StageFinalized:
+		return "finalized"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress is emitted whenever a waited transaction advances.
+type Progress struct {
+	Stage         Stage
+	TxHash        common.Hash
+	BlockNumber   uint64
+	BlockHash     common.Hash
+	Confirmations uint64
+	Receipt       *types.Receipt
+}
+
+// WaitOptions configure Wait.
+type WaitOptions struct {
+	// Confirmations is the number of blocks (including the inclusion block)
+	// to wait for. Zero and one both return as soon as the receipt exists.
+	Confirmations uint64
+	// Finalized additionally waits until the inclusion block is finalized.
+	Finalized bool
+	// PollInterval is the delay between checks.
+	PollInterval time.Duration
+	// OnProgress receives every progress update.
+	OnProgress func(Progress)
+}
+
+// ErrFinalityUnsupported is returned when waiting for finality on a node
+// that does not expose the finalized block.
+var ErrFinalityUnsupported = errors.New("node does not report finalized blocks")
+
+// Wait polls the node until tx is mined with the requested number of
+// confirmations (and finality when requested), reporting each stage through
+// opts.OnProgress. If a reorg removes the transaction while waiting, it goes
+// back to the pending stage and waiting continues.
+func Wait(ctx context.Context, b WaitBackend, tx *types.Transaction, opts WaitOptions) (*types.Receipt, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	confirmations := opts.Confirmations
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
+	last := Progress{Stage: StageSubmitted, TxHash: tx.Hash()}
+	emit := func(p Progress) {
+		if p.Stage == last.Stage && p.BlockHash == last.BlockHash && p.Confirmations == last.Confirmations {
+			return
+		}
+		last = p
+		if opts.OnProgress != nil {
+			opts.OnProgress(p)
+		}
+	}
+	if opts.OnProgress != nil {
+		opts.OnProgress(last)
+	}
+
+	for {
+		receipt, err := b.TransactionReceipt(ctx, tx.Hash())
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			_, pending, err := b.TransactionByHash(ctx, tx.Hash())
+			if err == nil && pending {
+				emit(Progress{Stage: StagePending, TxHash: tx.Hash()})
+			} else if err != nil && !errors.Is(err, ethereum.NotFound) {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		default:
+			head, err := b.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			mined := receipt.BlockNumber.Uint64()
+			p := Progress{
+				Stage:       StageIncluded,
+				TxHash:      tx.Hash(),
+				BlockNumber: mined,
+				BlockHash:   receipt.BlockHash,
+				Receipt:     receipt,
+			}
+			if head.Number.Uint64() >= mined {
+				p.Confirmations = head.Number.Uint64() - mined + 1
+			}
+			if p.Confirmations > 1 {
+				p.Stage = StageConfirmed
+			}
+			emit(p)
+
+			if p.Confirmations >= confirmations {
+				if !opts.Finalized {
+					return receipt, nil
+				}
+
+				finalized, err := b.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+				if err != nil {
+					return receipt, errors.Join(ErrFinalityUnsupported, err)
+				}
+				if finalized.Number.Uint64() >= mined {
+					p.Stage = StageFinalized
+					emit(p)
+					return receipt, nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ProgressChannel adapts a channel to the OnProgress callback. Sends block
+// until the receiver is ready or ctx is done.
+func ProgressChannel(ctx context.Context, ch chan<- Progress) func(Progress) {
+	return func(p Progress) {
+		select {
+		case ch <- p:
+		case <-ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confirm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"contract-storage-eth/confirm"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// step is the state of the chain at one poll.
+type step struct {
+	// receipt is nil while the transaction is not mined.
+	receipt *types.Receipt
+	pending bool
+	head    uint64
+	// finalized is the finalized block, or -1 when the node reports none.
+	finalized int64
+	err       error
+}
+
+// fakeChain moves to its next step at every receipt lookup, and stays at
+// the last one.
+type fakeChain struct {
+	mu    sync.Mutex
+	steps []step
+	polls int
+	// sendErrs fail the first rebroadcasts, one each.
+	sendErrs []error
+	sends    int
+}
+
+func (f *fakeChain) current() step {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.steps[max(0, min(f.polls, len(f.steps))-1)]
+}
+
+func (f *fakeChain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	f.polls++
+	f.mu.Unlock()
+	s := f.current()
+	switch {
+	case s.err != nil:
+		return nil, s.err
+	case s.receipt == nil:
+		return nil, ethereum.NotFound
+	}
+	return s.receipt, nil
+}
+
+func (f *fakeChain) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if !f.current().pending {
+		return nil, false, ethereum.NotFound
+	}
+	return nil, true, nil
+}
+
+func (f *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	s := f.current()
+	if number == nil {
+		return &types.Header{Number: new(big.Int).SetUint64(s.head)}, nil
+	}
+	if number.Int64() != int64(rpc.FinalizedBlockNumber) {
+		return nil, fmt.Errorf("unexpected block %v", number)
+	}
+	if s.finalized < 0 {
+		return nil, errors.New("finalized block not found")
+	}
+	return &types.Header{Number: big.NewInt(s.finalized)}, nil
+}
+
+func minedIn(block uint64, hash string) *types.Receipt {
+	return &types.Receipt{BlockNumber: new(big.Int).SetUint64(block), BlockHash: common.HexToHash(hash), Status: types.ReceiptStatusSuccessful}
+}
+
+func testTx() *types.Transaction {
+	return types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000, GasPrice: big.NewInt(1)})
+}
+
+func TestWait(t *testing.T) {
+	errNode := errors.New("node down")
+	tests := []struct {
+		name      string
+		steps     []step
+		opts      confirm.WaitOptions
+		stages    []string
+		block     string
+		wantErr   error
+		noReceipt bool
+	}{
+		{
+			name: "mined",
+			steps: []step{
+				{receipt: minedIn(10, "0xa"), head: 10},
+			},
+			stages: []string{"submitted 0", "included 1"},
+			block:  "0xa",
+		},
+		{
+			name: "pending to finalized",
+			steps: []step{
+				{},
+				{pending: true},
+				{receipt: minedIn(10, "0xa"), head: 10},
+				{receipt: minedIn(10, "0xa"), head: 11},
+				{receipt: minedIn(10, "0xa"), head: 12, finalized: 9},
+				{receipt: minedIn(10, "0xa"), head: 12, finalized: 10},
+			},
+			opts:   confirm.WaitOptions{Confirmations: 3, Finalized: true},
+			stages: []string{"submitted 0", "pending 0", "included 1", "confirmed 2", "confirmed 3", "finalized 3"},
+			block:  "0xa",
+		},
+		{
+			name: "reorg back to pending",
+			steps: []step{
+				{receipt: minedIn(10, "0xa"), head: 10},
+				{pending: true, head: 10},
+				{receipt: minedIn(11, "0xb"), head: 11},
+				{receipt: minedIn(11, "0xb"), head: 12},
+			},
+			opts:   confirm.WaitOptions{Confirmations: 2},
+			stages: []string{"submitted 0", "included 1", "pending 0", "included 1", "confirmed 2"},
+			block:  "0xb",
+		},
+		{
+			name: "finality unsupported",
+			steps: []step{
+				{receipt: minedIn(10, "0xa"), head: 10, finalized: -1},
+			},
+			opts:    confirm.WaitOptions{Finalized: true},
+			stages:  []string{"submitted 0", "included 1"},
+			block:   "0xa",
+			wantErr: confirm.ErrFinalityUnsupported,
+		},
+		{
+			name: "node error",
+			steps: []step{
+				{pending: true},
+				{err: errNode},
+			},
+			stages:    []string{"submitted 0", "pending 0"},
+			wantErr:   errNode,
+			noReceipt: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stages []string
+			opts := tt.opts
+			opts.PollInterval = time.Millisecond
+			opts.OnProgress = func(p confirm.Progress) {
+				stages = append(stages, fmt.Sprintf("%s %d", p.Stage, p.Confirmations))
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			receipt, err := confirm.Wait(ctx, &fakeChain{steps: tt.steps}, testTx(), opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(stages, tt.stages) {
+				t.Errorf("stages %q, want %q", stages, tt.stages)
+			}
+			switch {
+			case tt.noReceipt && receipt != nil:
+				t.Errorf("got a receipt after %v", err)
+			case !tt.noReceipt && (receipt == nil || receipt.BlockHash != common.HexToHash(tt.block)):
+				t.Errorf("receipt %+v, want one mined in %s", receipt, tt.block)
+			}
+		})
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := confirm.Wait(ctx, &fakeChain{steps: []step{{pending: true}}}, testTx(), confirm.WaitOptions{PollInterval: time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline", err)
+	}
+}
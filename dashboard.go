@@ -0,0 +1,356 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/indexer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/term"
+)
+
+// dashboardState is what the dashboard shows, refreshed at each interval.
+// Sections that could not be read are left empty and their errors listed
+type dashboardState struct {
+	Network      string            `json:"network,omitempty"`
+	ChainID      uint64            `json:"chain_id"`
+	Contract     common.Address    `json:"contract"`
+	Head         uint64            `json:"head"`
+	Records      int               `json:"records"`
+	Keys         int               `json:"keys"`
+	RecentEvents []*indexer.Record `json:"recent_events"`
+	Account      *common.Address   `json:"account,omitempty"`
+	Balance      *big.Int          `json:"balance,omitempty"`
+	// Mempool counts the transactions of the account sent but not mined
+	Mempool   uint64     `json:"mempool"`
+	PendingTx *pendingTx `json:"pending_tx,omitempty"`
+	BaseFee   *big.Int   `json:"base_fee,omitempty"`
+	TipCap    *big.Int   `json:"tip_cap,omitempty"`
+	GasPrice  *big.Int   `json:"gas_price,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Errors    []string   `json:"errors,omitempty"`
+}
+
+func runDashboard(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	interval := flags.Duration("interval", 5*time.Second, "delay between refreshes")
+	events := flags.Int("events", 10, "recent events shown")
+	accountFlag := flags.String("account", "", "account whose balance and transactions are shown, the signer's by default")
+	once := flags.Bool("once", false, "print the dashboard once instead of redrawing it")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *interval <= 0 || *events <= 0 {
+		return fail(codeInvalidArgument, "Invalid flags: --interval and --events must be positive")
+	}
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	account, err := dashboardAccount(ctx, config, client, *accountFlag)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --account: %w", err)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return fail(codeIndex, "Failed to sync index: %w", err)
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return err
+	}
+
+	refresh := func() *dashboardState {
+		state := &dashboardState{Network: config.Network, Contract: address, Account: account, UpdatedAt: time.Now()}
+		state.refresh(ctx, config, client, ix, store, *events)
+		return state
+	}
+	if *once || output.json {
+		state := refresh()
+		if !output.json {
+			fmt.Print(state.render(*interval, false))
+		}
+		printResult(state)
+		return nil
+	}
+
+	screen, err := openScreen()
+	if err != nil {
+		return fmt.Errorf("Failed to open the terminal: %w", err)
+	}
+	defer screen.close()
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		screen.draw(refresh().render(*interval, screen.keys != nil))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-screen.keys:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// dashboardAccount returns the account of --account, or the signer's when
+// a key is configured, nil otherwise
+func dashboardAccount(ctx context.Context, config *Config, client *chain.Client, flagValue string) (*common.Address, error) {
+	if flagValue != "" {
+		account, err := resolveAddress(ctx, config, client, flagValue)
+		return &account, err
+	}
+	key, err := loadKey(ctx, config.Ethereum.KeyConfig)
+	if errors.Is(err, errNoKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := key.(io.Closer); ok {
+		defer closer.Close()
+	}
+	account := key.Address()
+	return &account, nil
+}
+
+// refresh reads every section of the dashboard, syncing the index first
+func (s *dashboardState) refresh(ctx context.Context, config *Config, client *chain.Client, ix *indexer.Indexer, store *indexer.Store, events int) {
+	ctx, cancel := withTimeout(ctx, config.Timeouts.Sync)
+	defer cancel()
+	fail := func(section string, err error) {
+		s.Errors = append(s.Errors, fmt.Sprintf("%s: %v", section, err))
+	}
+
+	if id, err := client.ChainID(ctx); err != nil {
+		fail("chain", err)
+	} else {
+		s.ChainID = id.Uint64()
+	}
+	if head, err := ix.Sync(ctx); err != nil {
+		fail("index", err)
+	} else {
+		s.Head = head
+	}
+	if sum, err := store.Summary(); err != nil {
+		fail("index", err)
+	} else {
+		s.Records, s.Keys = sum.Records, sum.Keys
+		if s.RecentEvents, err = recentRecords(store, sum.NextBlock, config.Index.StartBlock, events); err != nil {
+			fail("events", err)
+		}
+	}
+
+	if s.Account != nil {
+		var err error
+		if s.Balance, err = client.BalanceAt(ctx, *s.Account, nil); err != nil {
+			fail("balance", err)
+		}
+		pending, err := client.PendingNonceAt(ctx, *s.Account)
+		var mined uint64
+		if err == nil {
+			mined, err = client.NonceAt(ctx, *s.Account, nil)
+		}
+		if err != nil {
+			fail("nonce", err)
+		} else if pending > mined {
+			s.Mempool = pending - mined
+		}
+	}
+	if p, err := loadPending(config); err == nil {
+		s.PendingTx = p
+	} else if !errors.Is(err, os.ErrNotExist) {
+		fail("state", err)
+	}
+
+	if header, err := client.HeaderByNumber(ctx, nil); err != nil {
+		fail("gas", err)
+	} else {
+		s.BaseFee = header.BaseFee
+	}
+	if tip, err := client.SuggestGasTipCap(ctx); err == nil {
+		s.TipCap = tip
+	}
+	if price, err := client.SuggestGasPrice(ctx); err != nil {
+		fail("gas", err)
+	} else {
+		s.GasPrice = price
+	}
+}
+
+// recentRecords returns the last n records indexed before next, newest
+// first, looking back over a doubling range of blocks
+func recentRecords(store *indexer.Store, next, start uint64, n int) ([]*indexer.Record, error) {
+	if next == 0 {
+		return nil, nil
+	}
+	for window := uint64(1000); ; window *= 2 {
+		from := start
+		if next > start+window {
+			from = next - window
+		}
+		records, err := store.Query(indexer.Query{FromBlock: from})
+		if err != nil {
+			return nil, err
+		}
+		if len(records) >= n || from == start {
+			records = records[max(len(records)-n, 0):]
+			for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+				records[i], records[j] = records[j], records[i]
+			}
+			return records, nil
+		}
+	}
+}
+
+// render lays the dashboard out as text
+func (s *dashboardState) render(interval time.Duration, interactive bool) string {
+	var b strings.Builder
+	title := "contract-storage-eth dashboard"
+	if s.Network != "" {
+		title += " on " + s.Network
+	}
+	fmt.Fprintf(&b, "%s (chain %d), updated %s", title, s.ChainID, s.UpdatedAt.Format(time.TimeOnly))
+	if interactive {
+		fmt.Fprintf(&b, ", every %s, q to quit", interval)
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "Contract   %s\n", s.Contract.Hex())
+	fmt.Fprintf(&b, "Records    %d record(s) of %d key(s), indexed to block %d\n", s.Records, s.Keys, s.Head)
+	if s.Account != nil {
+		balance := "?"
+		if s.Balance != nil {
+			balance = fees.FormatEther(s.Balance)
+		}
+		fmt.Fprintf(&b, "Account    %s holds %s ETH, %d transaction(s) in the mempool\n", s.Account.Hex(), balance, s.Mempool)
+	} else {
+		b.WriteString("Account    none, no key configured\n")
+	}
+	if p := s.PendingTx; p != nil {
+		fmt.Fprintf(&b, "Pending    %s %s, nonce %d, sent %s ago\n", p.Kind, p.Hash.Hex(), p.Nonce, time.Since(p.SentAt).Round(time.Second))
+	} else {
+		b.WriteString("Pending    none\n")
+	}
+	var gas []string
+	for _, fee := range []struct {
+		name string
+		wei  *big.Int
+	}{{"base fee", s.BaseFee}, {"tip", s.TipCap}, {"gas price", s.GasPrice}} {
+		if fee.wei != nil {
+			gas = append(gas, fmt.Sprintf("%s %.2f gwei", fee.name, fees.Gwei(fee.wei)))
+		}
+	}
+	fmt.Fprintf(&b, "Gas        %s\n", strings.Join(gas, ", "))
+
+	b.WriteString("\nRecent events\n")
+	if len(s.RecentEvents) == 0 {
+		b.WriteString("  none\n")
+	} else {
+		fmt.Fprintf(&b, "  %-10s %-8s %-40s %-7s %s\n", "BLOCK", "TIME", "KEY#FIELD", "VERSION", "TX")
+		for _, r := range s.RecentEvents {
+			ref := r.Key + "#" + r.Field
+			if len(ref) > 40 {
+				ref = ref[:39] + "…"
+			}
+			fmt.Fprintf(&b, "  %-10d %-8s %-40s %-7d %s\n", r.BlockNumber, time.Unix(int64(r.Timestamp), 0).Format(time.TimeOnly), ref, r.Version, r.TxHash.Hex())
+		}
+	}
+	for _, err := range s.Errors {
+		fmt.Fprintf(&b, "\nError: %s", err)
+	}
+	if len(s.Errors) > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// screen draws the dashboard over the whole terminal, in its alternate
+// screen so that the shell comes back as it was
+type screen struct {
+	fd    int
+	state *term.State
+	// keys receives a quit key pressed, nil when stdin is not a terminal
+	keys chan struct{}
+}
+
+func openScreen() (*screen, error) {
+	s := &screen{fd: int(os.Stdin.Fd())}
+	if term.IsTerminal(s.fd) {
+		// Raw mode reads keys unbuffered, Ctrl+C included since it no
+		// longer interrupts
+		state, err := term.MakeRaw(s.fd)
+		if err != nil {
+			return nil, err
+		}
+		s.state, s.keys = state, make(chan struct{})
+		go s.readKeys()
+	}
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	return s, nil
+}
+
+func (s *screen) readKeys() {
+	buf := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(buf); err != nil {
+			return
+		}
+		switch buf[0] {
+		case 'q', 'Q', 3, 4:
+			close(s.keys)
+			return
+		}
+	}
+}
+
+// draw replaces the screen with text
+func (s *screen) draw(text string) {
+	if s.state != nil {
+		// Raw mode no longer turns newlines into line breaks
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	fmt.Print("\x1b[H\x1b[2J" + text)
+}
+
+func (s *screen) close() {
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	if s.state != nil {
+		term.Restore(s.fd, s.state)
+	}
+}
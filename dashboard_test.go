@@ -0,0 +1,118 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRecentRecords(t *testing.T) {
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1}).Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	next, err := store.NextBlock(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{3, []string{"invoice-43#pdf@1", "doc#sha256@1", "invoice-42#pdf@2"}},
+		{10, []string{"invoice-43#pdf@1", "doc#sha256@1", "invoice-42#pdf@2", "plain#note@1", "invoice-42#pdf@1"}},
+	}
+	for _, tt := range tests {
+		records, err := recentRecords(store, next, 1, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range records {
+			got = append(got, indexer.Ref{Key: r.Key, Field: r.Field, Version: r.Version}.String())
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("last %d records %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	if records, err := recentRecords(store, 0, 1, 3); err != nil || len(records) != 0 {
+		t.Errorf("empty index: %v, %v", records, err)
+	}
+}
+
+func TestDashboardRender(t *testing.T) {
+	account := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	state := &dashboardState{
+		Network:  "sepolia",
+		ChainID:  11155111,
+		Contract: common.HexToAddress("0x3A220f351252089D385b29beca14e27F204c296A"),
+		Head:     6,
+		Records:  5,
+		Keys:     4,
+		RecentEvents: []*indexer.Record{
+			{Key: strings.Repeat("k", 45), Field: "pdf", Version: 2, BlockNumber: 3, TxHash: common.HexToHash("0x01")},
+		},
+		Account:   &account,
+		Balance:   big.NewInt(1500000000000000000),
+		Mempool:   1,
+		BaseFee:   big.NewInt(12500000000),
+		UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Errors:    []string{"fee history: not supported"},
+	}
+	out := state.render(5*time.Second, true)
+	for _, want := range []string{
+		"contract-storage-eth dashboard on sepolia (chain 11155111), updated 03:04:05, every 5s, q to quit",
+		"Records    5 record(s) of 4 key(s), indexed to block 6",
+		"Account    0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266 holds 1.5 ETH, 1 transaction(s) in the mempool",
+		"Pending    none",
+		"Gas        base fee 12.50 gwei\n",
+		"  3          ",
+		strings.Repeat("k", 39) + "… 2 ",
+		"Error: fee history: not supported",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dashboard lacks %q:\n%s", want, out)
+		}
+	}
+
+	out = (&dashboardState{ChainID: 1337}).render(time.Second, false)
+	for _, want := range []string{"dashboard (chain 1337), updated", "Account    none, no key configured", "Recent events\n  none"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("empty dashboard lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "q to quit") {
+		t.Errorf("non-interactive dashboard offers keys:\n%s", out)
+	}
+}
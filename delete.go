@@ -0,0 +1,219 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/tombstone"
+)
+
+func runDelete(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	key := flags.String("key", "", "record key, or a record ID")
+	field := flags.String("field", "", "record field")
+	soft := flags.Bool("soft", config.Storage.SoftDelete, "write a tombstone restore can undo instead of clearing the value")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *key == "" && flags.NArg() > 0 {
+		*key = flags.Arg(0)
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "delete: --key or a record ID is required")
+	}
+	s, closeService, err := cliRecordService(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+	result, err := s.remove(ctx, *key, *field, *soft)
+	if errors.Is(err, api.ErrNotFound) {
+		return fail(codeNotFound, "No value stored: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to delete record: %w", err)
+	}
+	if *soft {
+		fmt.Printf("Soft-deleted %s in block %d, run restore to bring it back\n", result.ID, result.Block)
+	} else {
+		fmt.Printf("Deleted %s in block %d\n", result.ID, result.Block)
+	}
+	printResult(result)
+	return nil
+}
+
+func runRestore(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	key := flags.String("key", "", "record key, or a record ID")
+	field := flags.String("field", "", "record field")
+	version := flags.Uint64("version", 0, "version to restore (default the last one before the deletion)")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *key == "" && flags.NArg() > 0 {
+		*key = flags.Arg(0)
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "restore: --key or a record ID is required")
+	}
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+	ref, err := resolveRef(ns, *key, *field)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --key: %w", err)
+	}
+	if *version > 0 {
+		ref.Version = *version
+	}
+
+	history, err := store.History(ref.Key, ref.Field)
+	if errors.Is(err, indexer.ErrNotFound) {
+		return fail(codeNotFound, "Record %s not found", refID(ns, ref))
+	}
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	latest := history[len(history)-1]
+	deletedAt, deleted := tombstone.Parse(latest.Value)
+	if !deleted {
+		return fmt.Errorf("Record %s is not soft-deleted", refID(ns, latest.Ref()))
+	}
+	target, err := restoredVersion(history, ref.Version)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid version to restore of %s: %w", refID(ns, indexer.Ref{Key: ref.Key, Field: ref.Field}), err)
+	}
+
+	// The value is written again as it was stored, envelope included
+	s, closeService, err := cliRecordService(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+	written, err := s.write(ctx, target.Key, target.Field, target.Value)
+	if err != nil {
+		return fmt.Errorf("Failed to restore record: %w", err)
+	}
+	fmt.Printf("Restored %s, deleted at %s, in block %d\n", refID(ns, target.Ref()), deletedAt.Format(time.RFC3339), written.Block)
+	printResult(restoreResult{WriteResult: written, Restored: refID(ns, target.Ref()).String(), DeletedAt: deletedAt})
+	return nil
+}
+
+// restoreResult is the result of restore in JSON output mode
+type restoreResult struct {
+	*api.WriteResult
+	// Restored is the ID of the version written again
+	Restored  string    `json:"restored"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// restoredVersion returns the version of a history to restore: the given
+// one, or the last one holding a value
+func restoredVersion(history []*indexer.Record, version uint64) (*indexer.Record, error) {
+	if version > uint64(len(history)) {
+		return nil, fmt.Errorf("there are %d version(s)", len(history))
+	}
+	if version > 0 {
+		r := history[version-1]
+		if r.Value == "" || tombstone.Is(r.Value) {
+			return nil, fmt.Errorf("version %d holds no value", version)
+		}
+		return r, nil
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if r := history[i]; r.Value != "" && !tombstone.Is(r.Value) {
+			return r, nil
+		}
+	}
+	return nil, errors.New("no version holds a value")
+}
+
+// cliRecordService returns the service of the /records endpoints for the
+// commands writing records directly, with the signers loaded. The returned
+// function releases them
+func cliRecordService(ctx context.Context, config *Config) (*recordService, func(), error) {
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return nil, nil, err
+	} else if viaSafe {
+		return nil, nil, errors.New("This command sends its transaction directly, unset safe.address to use it")
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return nil, nil, fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	ns, err := recordNamespace(ctx, config, nil, client)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		client.Close()
+		return nil, nil, fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	return &recordService{config: config, client: client, address: ns.Contract, ns: ns, signers: signers}, func() {
+		if closer, ok := signers.(io.Closer); ok {
+			closer.Close()
+		}
+		client.Close()
+	}, nil
+}
+
+// hideDeleted leaves out the records of the key/field pairs soft-deleted
+// last, their tombstones included
+func hideDeleted(store *indexer.Store, records []*indexer.Record) ([]*indexer.Record, error) {
+	deleted := map[indexer.Ref]bool{}
+	var shown []*indexer.Record
+	for _, r := range records {
+		pair := indexer.Ref{Key: r.Key, Field: r.Field}
+		isDeleted, ok := deleted[pair]
+		if !ok {
+			head, err := store.Get(pair)
+			if err != nil {
+				return nil, err
+			}
+			isDeleted = tombstone.Is(head.Value)
+			deleted[pair] = isDeleted
+		}
+		if !isDeleted {
+			shown = append(shown, r)
+		}
+	}
+	return shown, nil
+}
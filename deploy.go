@@ -16,80 +16,93 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
-
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/confirm"
+	"contract-storage-eth/deployments"
+	"contract-storage-eth/metrics"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/safe"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/smoke"
+	"contract-storage-eth/tracing"
+
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"gopkg.in/yaml.v2"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Config structure for deployment configuration
-type Config struct {
-	Ethereum struct {
-		RpcURL     string `yaml:"rpc_url"`
-		PrivateKey string `yaml:"private_key"`
-		ChainID    int64  `yaml:"chain_id"`
-		GasLimit   uint64 `yaml:"gas_limit"`
-	} `yaml:"ethereum"`
-	Build struct {
-		Directory    string `yaml:"directory"`
-		ContractName string `yaml:"contract_name"`
-	} `yaml:"build"`
-	Test struct {
-		Enable    bool   `yaml:"enable"`
-		TestKey   string `yaml:"test_key"`
-		TestField string `yaml:"test_field"`
-		TestValue string `yaml:"test_value"`
-	} `yaml:"test"`
-}
+func runDeploy(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	upgrade := flags.Bool("upgrade", false, "point the EIP-1967 proxy at contract.address to the new contract")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
 
-func main() {
 	fmt.Println("Starting contract deployment...")
 
-	// Load configuration file
-	config, err := loadConfig("config.yaml")
-	if err != nil {
-		log.Fatal("Failed to load config:", err)
-	}
-
 	// Connect to Ethereum node
-	client, err := ethclient.Dial(config.Ethereum.RpcURL)
+	client, err := dialClient(ctx, config)
 	if err != nil {
-		log.Fatal("Failed to connect to Ethereum node:", err)
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
 	}
 	defer client.Close()
-	fmt.Printf("Connected to Ethereum node: %s\n", config.Ethereum.RpcURL)
+	fmt.Printf("Connected to Ethereum node: %s\n", client.URL())
+
+	// Check the proxy before deploying anything
+	var proxy, previous common.Address
+	if *upgrade {
+		if _, viaSafe, err := safeAddress(config); err != nil {
+			return err
+		} else if viaSafe {
+			return errors.New("deploy --upgrade sends its transactions directly, unset safe.address to use it")
+		}
+		if proxy, err = contractAddress(config); err != nil {
+			return err
+		}
+		if previous, err = proxyImplementation(ctx, client, proxy); err != nil {
+			return fail(codeInvalidArgument, "Invalid contract.address: %w", err)
+		}
+		fmt.Printf("Upgrading proxy %s, now pointing to %s\n", proxy.Hex(), previous.Hex())
+	}
 
-	// Load private key
-	privateKey, err := crypto.HexToECDSA(config.Ethereum.PrivateKey)
+	// Load signers
+	signers, err := loadSigners(ctx, config)
 	if err != nil {
-		log.Fatal("Failed to load private key:", err)
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("Cannot assert type: publicKey is not of type *ecdsa.PublicKey")
+	activeSigner, err := signers.Active(ctx)
+	if err != nil {
+		return fail(codeSigner, "No signer available: %w", err)
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	fromAddress := activeSigner.Address()
 	fmt.Printf("Deploying from address: %s\n", fromAddress.Hex())
 
 	// Read contract bytecode
 	bytecodeFile := filepath.Join(config.Build.Directory, config.Build.ContractName+".bin")
 	bytecodeBytes, err := os.ReadFile(bytecodeFile)
 	if err != nil {
-		log.Fatal("Failed to read bytecode file:", err)
+		return fmt.Errorf("Failed to read bytecode file: %w", err)
 	}
 	bytecode := strings.TrimSpace(string(bytecodeBytes))
 	fmt.Printf("Loaded bytecode from: %s\n", bytecodeFile)
@@ -98,7 +111,7 @@ func main() {
 	abiFile := filepath.Join(config.Build.Directory, config.Build.ContractName+".abi")
 	abiBytes, err := os.ReadFile(abiFile)
 	if err != nil {
-		log.Fatal("Failed to read ABI file:", err)
+		return fmt.Errorf("Failed to read ABI file: %w", err)
 	}
 	abiString := strings.TrimSpace(string(abiBytes))
 	fmt.Printf("Loaded ABI from: %s\n", abiFile)
@@ -106,151 +119,385 @@ func main() {
 	// Parse ABI
 	parsedABI, err := abi.JSON(strings.NewReader(abiString))
 	if err != nil {
-		log.Fatal("Failed to parse ABI:", err)
+		return fmt.Errorf("Failed to parse ABI: %w", err)
 	}
 
-	// Get nonce
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		log.Fatal("Failed to get nonce:", err)
+	// Let the Safe owners deploy it instead, from the Safe
+	if safeAddr, ok, err := safeAddress(config); err != nil {
+		return err
+	} else if ok {
+		createCall := safe.DefaultCreateCall
+		if config.Safe.CreateCall != "" {
+			createCall = common.HexToAddress(config.Safe.CreateCall)
+		}
+		code := common.FromHex(bytecode)
+		return proposeSafe(ctx, config, client, activeSigner, safeAddr, func(nonce uint64) (*safe.Transaction, error) {
+			return safe.NewCreate(createCall, code, nonce)
+		})
 	}
 
 	// Get gas price
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	gasPrice, err := client.SuggestGasPrice(ctx)
 	if err != nil {
-		log.Fatal("Failed to get gas price:", err)
+		return fmt.Errorf("Failed to get gas price: %w", err)
 	}
 
 	// Get chain ID
-	chainID, err := client.ChainID(context.Background())
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
-		log.Fatal("Failed to get chain ID:", err)
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
 	}
 
 	// Create auth object
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	auth, err := signer.NewTransactOpts(ctx, activeSigner, chainID)
 	if err != nil {
-		log.Fatal("Failed to create auth:", err)
+		return fail(codeSigner, "Failed to create auth: %w", err)
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
 	auth.Value = big.NewInt(0)
 	auth.GasLimit = config.Ethereum.GasLimit
 	auth.GasPrice = gasPrice
 
-	fmt.Printf("Gas price: %s wei\n", gasPrice.String())
+	// A configured minimum tip prices the deployment by EIP-1559 fees
+	if err := setFees(ctx, config, client, auth); err != nil {
+		return fmt.Errorf("Failed to get fees: %w", err)
+	}
+	if auth.GasFeeCap != nil {
+		gasPrice = auth.GasFeeCap
+		fmt.Printf("Max fee: %s wei (tip %s wei)\n", auth.GasFeeCap.String(), auth.GasTipCap.String())
+	} else {
+		gasPrice = auth.GasPrice
+		fmt.Printf("Gas price: %s wei\n", gasPrice.String())
+	}
 	fmt.Printf("Gas limit: %d\n", auth.GasLimit)
 
-	// Deploy contract
-	fmt.Println("Deploying contract...")
+	// Make sure the deployment and the test write can be paid for
 	bytecodeData := common.FromHex(bytecode)
-	address, tx, _, err := bind.DeployContract(auth, parsedABI, bytecodeData, client)
+	gas := auth.GasLimit
+	if gas == 0 {
+		if gas, err = client.EstimateGas(ctx, ethereum.CallMsg{From: fromAddress, Data: bytecodeData}); err != nil {
+			return fmt.Errorf("Failed to estimate deployment gas: %w", err)
+		}
+	}
+	if config.SmokeTest.AfterDeploy {
+		writes, err := smokeWrites(config)
+		if err != nil {
+			return err
+		}
+		gas += testGasLimit * writes
+	}
+	cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas))
+	// Rollups charge the L1 data of the deployment on top
+	if config.Ethereum.Rollup != "" {
+		e, err := newEstimator(config, client, "")
+		if err != nil {
+			return err
+		}
+		e.From, e.Price = fromAddress, nil
+		est, err := e.ForTx(ctx, nil, bytecodeData, gas)
+		if err != nil {
+			return fmt.Errorf("Failed to estimate deployment cost: %w", err)
+		}
+		if est.L1Fee != nil {
+			fmt.Printf("L1 data fee: %s wei\n", est.L1Fee.String())
+		}
+		cost = est.MaxCost
+	}
+	if err := checkFunds(ctx, config, client, fromAddress, cost, "the deployment"); err != nil {
+		return err
+	}
+
+	// Deploy contract, signing again with a fresh nonce if it was already used
+	fmt.Println("Deploying contract...")
+	var address common.Address
+	var tx *types.Transaction
+	txCtx, cancel := withTimeout(ctx, config.Timeouts.Transaction)
+	defer cancel()
+	txCtx, span := tracing.Start(txCtx, "contract.deploy")
+	err = chain.Retry(txCtx, client.Policy(), chain.IsNonceTooLow, func(ctx context.Context) error {
+		nonce, err := client.PendingNonceAt(ctx, fromAddress)
+		if err != nil {
+			return err
+		}
+		auth.Nonce = big.NewInt(int64(nonce))
+		auth.Context = ctx
+
+		address, tx, _, err = bind.DeployContract(auth, parsedABI, bytecodeData, client)
+		return err
+	})
+	if err == nil {
+		span.SetAttributes(attribute.String("tx.hash", tx.Hash().Hex()), attribute.Int64("tx.gas_limit", int64(tx.Gas())), attribute.String("contract.address", address.Hex()))
+	}
+	tracing.End(span, err)
 	if err != nil {
-		log.Fatal("Failed to deploy contract:", err)
+		return fmt.Errorf("Failed to deploy contract: %w", err)
 	}
+	metrics.TransactionsSent.WithLabelValues("deploy").Inc()
 
 	fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
 	fmt.Printf("Contract address: %s\n", address.Hex())
+	if link := explorerURL(config, "address/"+address.Hex()); link != "" {
+		fmt.Printf("Explorer: %s\n", link)
+	}
+	trackPending(config, "deploy", fromAddress, address, tx)
 
 	// Wait for transaction confirmation
 	fmt.Println("Waiting for transaction confirmation...")
-	receipt, err := bind.WaitMined(context.Background(), client, tx)
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err != nil {
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to wait for transaction: %w", err)
+	}
+
+	receipt, err = watchReorg(ctx, client, tx, receipt, config)
 	if err != nil {
-		log.Fatal("Failed to wait for transaction:", err)
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to watch transaction for reorgs: %w", err)
 	}
+	clearPending(config)
 
 	if receipt.Status == types.ReceiptStatusSuccessful {
 		fmt.Println("Contract deployed successfully!")
 		fmt.Printf("Gas used: %d\n", receipt.GasUsed)
 		fmt.Printf("Block number: %d\n", receipt.BlockNumber.Uint64())
 	} else {
-		log.Fatal("Contract deployment failed!")
+		return fail(codeTransactionFailed, "Contract deployment failed!")
+	}
+
+	result := deployResult{
+		Contract: address,
+		TxHash:   tx.Hash(),
+		From:     fromAddress,
+		Block:    receipt.BlockNumber.Uint64(),
+		GasUsed:  receipt.GasUsed,
+	}
+	entry := &deployments.Deployment{
+		Kind:    deployments.KindDeploy,
+		ChainID: chainID.Uint64(),
+		Address: address,
+		Signer:  fromAddress,
+		TxHash:  tx.Hash(),
+		Block:   result.Block,
+	}
+
+	// Records keep going to the proxy, which now runs the new code
+	tested := address
+	if *upgrade {
+		fmt.Printf("\nPointing proxy %s to %s...\n", proxy.Hex(), address.Hex())
+		upgradeTx, upgradeReceipt, err := upgradeProxy(ctx, config, client, auth, proxy, address)
+		if err != nil {
+			recordDeployment(ctx, config, client, entry)
+			return fmt.Errorf("Failed to upgrade the proxy: %w", err)
+		}
+		fmt.Printf("Proxy %s now points to %s\n", proxy.Hex(), address.Hex())
+		result.Proxy = &proxy
+		entry = &deployments.Deployment{
+			Kind:           deployments.KindUpgrade,
+			ChainID:        chainID.Uint64(),
+			Address:        proxy,
+			Implementation: &address,
+			Previous:       &previous,
+			Signer:         fromAddress,
+			TxHash:         upgradeTx.Hash(),
+			Block:          upgradeReceipt.BlockNumber.Uint64(),
+		}
+		tested = proxy
 	}
+	recordDeployment(ctx, config, client, entry)
 
 	// Optional testing
-	if config.Test.Enable {
-		fmt.Println("\nRunning contract test...")
-		testContract(client, address, privateKey, chainID, parsedABI, config)
+	if config.SmokeTest.AfterDeploy {
+		fmt.Println("\nRunning smoke test...")
+		if result.Test, err = testContract(ctx, client, tested, signers, chainID, config); err != nil {
+			return err
+		}
+		if !result.Test.OK {
+			printFailure("smoke_test_failed", fmt.Sprintf("contract deployed at %s, but %d smoke test case(s) failed", address.Hex(), result.Test.Failed()), result)
+			return exitError(1)
+		}
 	}
 
 	fmt.Println("\nDeployment completed!")
+	printResult(result)
+	return nil
+}
+
+// deployResult is the result of deploy in JSON output mode
+type deployResult struct {
+	Contract common.Address `json:"contract"`
+	TxHash   common.Hash    `json:"tx_hash"`
+	From     common.Address `json:"from"`
+	Block    uint64         `json:"block"`
+	GasUsed  uint64         `json:"gas_used"`
+	// Proxy is the proxy pointed to the contract by --upgrade
+	Proxy *common.Address `json:"proxy,omitempty"`
+	// Test is the post-deployment smoke test, when enabled
+	Test *smoke.Result `json:"test,omitempty"`
 }
 
-func loadConfig(filename string) (*Config, error) {
-	data, err := os.ReadFile(filename)
+// loadSigners builds the signer selection from the configuration, with the
+// standby account taking over when the primary signer is unavailable
+func loadSigners(ctx context.Context, config *Config) (signer.Selector, error) {
+	primary, err := loadKey(ctx, config.Ethereum.KeyConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	var config Config
-	err = yaml.Unmarshal(data, &config)
+	standby := config.Ethereum.Standby
+	if !standby.configured() {
+		return signer.Fixed(primary), nil
+	}
+
+	secondary, err := loadKey(ctx, standby.KeyConfig)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("standby: %w", err)
 	}
 
-	return &config, nil
+	lockFile := standby.LockFile
+	if lockFile == "" {
+		lockFile = "signer.lock"
+	}
+	failover := signer.NewFailover(primary, secondary, signer.NewFileLock(lockFile, standby.LockTTL))
+	failover.OnSwitch = func(active signer.Signer, reason error) {
+		if reason != nil {
+			slog.Error("Primary signer unavailable, standby took over", "signer", active.Address().Hex(), "reason", reason)
+		} else {
+			slog.Info("Primary signer is available again", "signer", active.Address().Hex())
+		}
+	}
+	return failover, nil
 }
 
-func testContract(client *ethclient.Client, contractAddress common.Address, privateKey *ecdsa.PrivateKey, chainID *big.Int, parsedABI abi.ABI, config *Config) {
-	// Create contract instance
-	contract := bind.NewBoundContract(contractAddress, parsedABI, client, client, client)
-
-	// Create auth object
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
-	if err != nil {
-		log.Printf("Failed to create auth for testing: %v", err)
-		return
+// waitMined waits for the configured number of confirmations, showing each
+// stage the transaction goes through on its progress bar, or printing it
+// with --no-progress
+func waitMined(ctx context.Context, client chain.ChainClient, tx *types.Transaction, config *Config) (*types.Receipt, error) {
+	if bar, onProgress := confirmationProgress(tx, config.Confirmation.Confirmations); bar != nil {
+		defer bar.Finish()
+		return waitMinedWith(ctx, client, tx, config, onProgress)
 	}
-	auth.GasLimit = uint64(300000)
+	return waitMinedWith(ctx, client, tx, config, func(p confirm.Progress) {
+		switch p.Stage {
+		case confirm.StageSubmitted, confirm.StagePending:
+			fmt.Printf("Transaction %s: %s\n", p.TxHash.Hex(), p.Stage)
+		default:
+			fmt.Printf("Transaction %s: %s in block %d (%d confirmations)\n", p.TxHash.Hex(), p.Stage, p.BlockNumber, p.Confirmations)
+		}
+	})
+}
 
-	// Call save function
-	fmt.Printf("Calling save function with: key=%s, field=%s, value=%s\n",
-		config.Test.TestKey, config.Test.TestField, config.Test.TestValue)
+// waitMinedWith is waitMined reporting progress to onProgress, which may be
+// nil to wait quietly
+func waitMinedWith(ctx context.Context, client chain.ChainClient, tx *types.Transaction, config *Config, onProgress func(confirm.Progress)) (*types.Receipt, error) {
+	parent := ctx
+	ctx, cancel := withTimeout(ctx, config.Timeouts.Confirmation)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "confirm", attribute.String("tx.hash", tx.Hash().Hex()))
+	start := time.Now()
+	receipt, err := confirm.Wait(ctx, client, tx, confirm.WaitOptions{
+		Confirmations: config.Confirmation.Confirmations,
+		Finalized:     config.Confirmation.Finalized,
+		PollInterval:  config.Confirmation.PollInterval,
+		OnProgress:    onProgress,
+	})
+	if err == nil {
+		status := metrics.Status(receipt.Status)
+		metrics.ConfirmationSeconds.WithLabelValues(status).Observe(time.Since(start).Seconds())
+		metrics.GasUsed.WithLabelValues(status).Add(float64(receipt.GasUsed))
+		recordGas(tx, receipt)
+		auditMined(tx, receipt)
+		span.SetAttributes(attribute.Int64("block.number", receipt.BlockNumber.Int64()), attribute.Int64("tx.gas_used", int64(receipt.GasUsed)), attribute.String("tx.status", status))
+	}
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		err = fail(codeReceiptTimeout, "no receipt within timeouts.confirmation (%s): %w", config.Timeouts.Confirmation, err)
+	}
+	tracing.End(span, err)
+	return receipt, err
+}
 
-	tx, err := contract.Transact(auth, "save", config.Test.TestKey, config.Test.TestField, config.Test.TestValue)
-	if err != nil {
-		log.Printf("Failed to call save function: %v", err)
-		return
+// watchReorg keeps checking that a mined transaction stays in the canonical
+// chain for the configured number of blocks
+func watchReorg(ctx context.Context, client chain.ChainClient, tx *types.Transaction, receipt *types.Receipt, config *Config) (*types.Receipt, error) {
+	if config.Reorg.Depth == 0 {
+		return receipt, nil
 	}
 
-	fmt.Printf("Save transaction: %s\n", tx.Hash().Hex())
+	fmt.Printf("Watching transaction %s for reorgs (%d blocks)...\n", tx.Hash().Hex(), config.Reorg.Depth)
+	ctx, cancel := withTimeout(ctx, config.Timeouts.Reorg)
+	defer cancel()
+
+	return confirm.WatchReorg(ctx, client, tx, receipt, confirm.ReorgOptions{
+		Depth:        config.Reorg.Depth,
+		PollInterval: config.Reorg.PollInterval,
+		Rebroadcast:  config.Reorg.Rebroadcast,
+		DropTimeout:  config.Reorg.DropTimeout,
+		OnReorg: func(ev confirm.ReorgEvent) {
+			switch ev.Kind {
+			case confirm.ReorgDropped:
+				slog.Error("Transaction dropped by a reorg", "tx_hash", ev.TxHash.Hex(), "block_hash", ev.OldBlock.Hex())
+			case confirm.ReorgMoved:
+				slog.Warn("Transaction re-included after a reorg", "tx_hash", ev.TxHash.Hex(), "block_hash", ev.NewBlock.Hex())
+			case confirm.ReorgRebroadcast:
+				if ev.Err != nil {
+					slog.Error("Failed to rebroadcast transaction", "tx_hash", ev.TxHash.Hex(), "error", ev.Err)
+				}
+			}
+		},
+	})
+}
 
-	// Wait for transaction confirmation
-	receipt, err := bind.WaitMined(context.Background(), client, tx)
+// testGasLimit is the gas reserved for each write of the post-deployment
+// smoke test
+const testGasLimit = 300000
+
+// smokeWrites returns the number of writes of the smoke test matrix
+func smokeWrites(config *Config) (uint64, error) {
+	cases, err := smokeCases(config, "")
 	if err != nil {
-		log.Printf("Failed to wait for save transaction: %v", err)
-		return
+		return 0, fail(codeConfig, "Invalid smoke_test config: %w", err)
 	}
-
-	if receipt.Status == types.ReceiptStatusSuccessful {
-		fmt.Println("Save function called successfully!")
-		// Read data back
-		var result []interface{}
-		err = contract.Call(nil, &result, "data")
-		if err != nil {
-			log.Printf("Failed to read data: %v", err)
-			return
+	var n uint64
+	for _, c := range cases {
+		ops := c.Ops
+		if len(ops) == 0 {
+			ops = smoke.DefaultOps
 		}
-
-		if len(result) == 3 {
-			fmt.Printf("Retrieved data - Key: %s, Field: %s, Value: %s\n",
-				result[0].(string), result[1].(string), result[2].(string))
-		}
-
-		// Check logs
-		for _, log := range receipt.Logs {
-			if log.Address == contractAddress {
-				logData, err := parsedABI.Unpack("DataSaved", log.Data)
-				if err != nil {
-					fmt.Printf("Failed to unpack log data: %v", err)
-					continue
-				}
-				if len(logData) == 3 {
-					fmt.Printf("Log data - Key: %s, Field: %s, Value: %s\n",
-						logData[0].(string), logData[1].(string), logData[2].(string))
-				}
+		for _, op := range ops {
+			if op == smoke.OpSave || op == smoke.OpDelete {
+				n++
 			}
 		}
-	} else {
-		fmt.Println("Save function call failed!")
 	}
+	return n, nil
+}
+
+// testContract runs the smoke test against the contract just deployed
+func testContract(ctx context.Context, client *chain.Client, contractAddress common.Address, signers signer.Selector, chainID *big.Int, config *Config) (*smoke.Result, error) {
+	cases, err := smokeCases(config, "")
+	if err != nil {
+		return nil, fail(codeConfig, "Invalid smoke_test config: %w", err)
+	}
+	s := &recordService{
+		config:  config,
+		client:  client,
+		address: contractAddress,
+		ns:      recordid.Namespace{ChainID: chainID.Uint64(), Contract: contractAddress},
+		signers: signers,
+	}
+	return runSmoke(ctx, s, cases), nil
+}
+
+// decodeValue opens an enveloped value for display, decrypting it with
+// storage.encryption, falling back to the raw string if it cannot be
+// decoded. Binary content is shown as 0x-prefixed hex
+func decodeValue(value string) string {
+	content, binary, err := openContent(value)
+	if err != nil {
+		slog.Warn("Failed to decode value", "error", err)
+		return value
+	}
+	if binary {
+		return hexutil.Encode(content)
+	}
+	return string(content)
 }
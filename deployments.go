@@ -0,0 +1,341 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/deployments"
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// recordDeployment appends d to the deployment history, with the network,
+// git commit and operator filled in. The transaction is mined already, so
+// failing to record it is only logged
+func recordDeployment(ctx context.Context, config *Config, client *chain.Client, d *deployments.Deployment) {
+	if config.Deployments.File == "" {
+		return
+	}
+	d.Network, d.GitCommit, d.Operator = config.Network, gitCommit(), operatorName(config)
+	code, err := client.CodeAt(ctx, d.Target(), nil)
+	if err != nil {
+		slog.Warn("Failed to read the deployed code", "address", d.Target().Hex(), "error", err)
+	}
+	d.CodeHash = crypto.Keccak256Hash(code)
+
+	h, err := deployments.Load(config.Deployments.File)
+	if err == nil {
+		h.Add(d)
+		var data []byte
+		if data, err = h.Marshal(); err == nil {
+			err = writeFileAtomic(config.Deployments.File, data)
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to record the deployment", "file", config.Deployments.File, "tx_hash", d.TxHash.Hex(), "error", err)
+		return
+	}
+	fmt.Printf("Recorded the %s in %s\n", d.Kind, config.Deployments.File)
+}
+
+// gitCommit returns the commit of the working tree, which the contract was
+// built from, marked +dirty when it has changes. Out of a work tree it is
+// the commit the binary was built from, if known
+func gitCommit() string {
+	if out, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+		commit := strings.TrimSpace(string(out))
+		if status, err := exec.Command("git", "status", "--porcelain").Output(); err == nil && len(status) > 0 {
+			commit += "+dirty"
+		}
+		return commit
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var commit, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if commit != "" && modified == "true" {
+		commit += "+dirty"
+	}
+	return commit
+}
+
+// operatorName returns deployments.operator, or user@host
+func operatorName(config *Config) string {
+	if config.Deployments.Operator != "" {
+		return config.Deployments.Operator
+	}
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
+
+// proxyAdmin returns contract.proxy_admin, the zero address when the proxy
+// is upgraded directly
+func proxyAdmin(config *Config) (common.Address, error) {
+	if config.Contract.ProxyAdmin == "" {
+		return common.Address{}, nil
+	}
+	if !common.IsHexAddress(config.Contract.ProxyAdmin) {
+		return common.Address{}, errors.New("contract.proxy_admin is not a valid address")
+	}
+	return common.HexToAddress(config.Contract.ProxyAdmin), nil
+}
+
+// upgradeProxy points proxy to implementation, through contract.proxy_admin
+// when set, and waits for the transaction
+func upgradeProxy(ctx context.Context, config *Config, client *chain.Client, auth *bind.TransactOpts, proxy, implementation common.Address) (*types.Transaction, *types.Receipt, error) {
+	admin, err := proxyAdmin(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, data, err := deployments.UpgradeCall(proxy, implementation, admin)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth.Nonce, auth.Context = nil, ctx
+	tx, err := bind.NewBoundContract(to, abi.ABI{}, client, client, client).RawTransact(auth, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err != nil {
+		return tx, nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return tx, receipt, fail(codeTransactionFailed, "transaction %s reverted, is the signer allowed to upgrade the proxy?", tx.Hash().Hex())
+	}
+	current, err := deployments.Implementation(ctx, client, proxy)
+	if err == nil && current != implementation {
+		err = fmt.Errorf("the proxy points to %s after the upgrade, not %s", current.Hex(), implementation.Hex())
+	}
+	return tx, receipt, err
+}
+
+// proxyImplementation returns the implementation of the EIP-1967 proxy at
+// contract.address
+func proxyImplementation(ctx context.Context, client chain.ChainClient, proxy common.Address) (common.Address, error) {
+	current, err := deployments.Implementation(ctx, client, proxy)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if current == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%s is not an EIP-1967 proxy: its implementation slot is empty", proxy.Hex())
+	}
+	return current, nil
+}
+
+func runDeployments(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("deployments", flag.ContinueOnError)
+	all := flags.Bool("all", false, "list the deployments of every network")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if config.Deployments.File == "" {
+		return fail(codeConfig, "deployments.file is not configured")
+	}
+	h, err := deployments.Load(config.Deployments.File)
+	if err != nil {
+		return fmt.Errorf("Failed to load deployment history: %w", err)
+	}
+	entries := h.Deployments
+	if !*all {
+		chainID := uint64(config.Ethereum.ChainID)
+		if chainID == 0 {
+			client, err := dialClient(ctx, config)
+			if err != nil {
+				return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+			}
+			id, err := client.ChainID(ctx)
+			client.Close()
+			if err != nil {
+				return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+			}
+			chainID = id.Uint64()
+		}
+		entries = h.Chain(chainID)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No deployments recorded")
+		printResult(entries)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCHAIN\tKIND\tADDRESS\tIMPLEMENTATION\tCODE HASH\tCOMMIT\tOPERATOR\tBLOCK")
+	for _, d := range entries {
+		impl, commit := "-", d.GitCommit
+		if d.Implementation != nil {
+			impl = d.Implementation.Hex()
+		}
+		if commit == "" {
+			commit = "-"
+		} else if len(commit) > 12 && !strings.HasSuffix(commit, "+dirty") {
+			commit = commit[:12]
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			d.Time.Local().Format(time.DateTime), d.ChainID, d.Kind, d.Address.Hex(), impl, d.CodeHash.Hex()[:18]+"...", commit, d.Operator, d.Block)
+	}
+	tw.Flush()
+	printResult(entries)
+	return nil
+}
+
+func runRollback(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	toFlag := flags.String("to", "", "implementation to switch to (default: the one recorded before the current one)")
+	dryRun := flags.Bool("dry-run", false, "only show the implementation the proxy would switch to")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	proxy, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return err
+	} else if viaSafe && !*dryRun {
+		return errors.New("rollback sends its transaction directly, unset safe.address to use it")
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	current, err := proxyImplementation(ctx, client, proxy)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid contract.address: %w", err)
+	}
+
+	var target common.Address
+	switch {
+	case *toFlag != "":
+		if target, err = resolveAddress(ctx, config, client, *toFlag); err != nil {
+			return fail(codeInvalidArgument, "Invalid --to: %w", err)
+		}
+	case config.Deployments.File == "":
+		return fail(codeConfig, "deployments.file is not configured, give the implementation with --to")
+	default:
+		h, err := deployments.Load(config.Deployments.File)
+		if err != nil {
+			return fmt.Errorf("Failed to load deployment history: %w", err)
+		}
+		if target, err = h.Previous(chainID.Uint64(), proxy, current); err != nil {
+			return fmt.Errorf("Cannot roll back: %w", err)
+		}
+	}
+	if target == current {
+		return fail(codeInvalidArgument, "Invalid --to: the proxy points to %s already", target.Hex())
+	}
+	if code, err := client.CodeAt(ctx, target, nil); err != nil {
+		return fmt.Errorf("Failed to read the implementation code: %w", err)
+	} else if len(code) == 0 {
+		return fmt.Errorf("Cannot roll back: %s holds no code", target.Hex())
+	}
+
+	result := rollbackResult{Proxy: proxy, From: current, To: target, DryRun: *dryRun}
+	fmt.Printf("Rolling back proxy %s from %s to %s\n", proxy.Hex(), current.Hex(), target.Hex())
+	if *dryRun {
+		printResult(result)
+		return nil
+	}
+
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	active, err := signers.Active(ctx)
+	if err != nil {
+		return fail(codeSigner, "No signer available: %w", err)
+	}
+	auth, err := signer.NewTransactOpts(ctx, active, chainID)
+	if err != nil {
+		return fail(codeSigner, "Failed to create auth: %w", err)
+	}
+	if err := setFees(ctx, config, client, auth); err != nil {
+		return fmt.Errorf("Failed to get fees: %w", err)
+	}
+	tx, receipt, err := upgradeProxy(ctx, config, client, auth, proxy, target)
+	if err != nil {
+		return fail(codeTransactionFailed, "Rollback failed! %w", err)
+	}
+	result.TxHash, result.Block = tx.Hash(), receipt.BlockNumber.Uint64()
+	fmt.Printf("Proxy %s now points to %s (block %d)\n", proxy.Hex(), target.Hex(), result.Block)
+	recordDeployment(ctx, config, client, &deployments.Deployment{
+		Kind:           deployments.KindRollback,
+		ChainID:        chainID.Uint64(),
+		Address:        proxy,
+		Implementation: &target,
+		Previous:       &current,
+		Signer:         active.Address(),
+		TxHash:         tx.Hash(),
+		Block:          result.Block,
+	})
+	printResult(result)
+	return nil
+}
+
+// rollbackResult is the result of rollback in JSON output mode
+type rollbackResult struct {
+	Proxy  common.Address `json:"proxy"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	DryRun bool           `json:"dry_run,omitempty"`
+	TxHash common.Hash    `json:"tx_hash,omitempty"`
+	Block  uint64         `json:"block,omitempty"`
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deployments keeps the history of the deployments of a contract,
+// and of the upgrades and rollbacks of the EIP-1967 proxies in front of
+// it, so that a proxy can be switched back to the implementation it
+// pointed to before.
+package deployments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Kinds of entries.
+const (
+	// KindDeploy is a contract deployed on its own.
+	KindDeploy = "deploy"
+	// KindUpgrade is an implementation deployed and put behind a proxy.
+	KindUpgrade = "upgrade"
+	// KindRollback is a proxy switched back to an earlier implementation.
+	KindRollback = "rollback"
+)
+
+// Deployment is an entry of the history.
+type Deployment struct {
+	Kind    string    `json:"kind"`
+	Time    time.Time `json:"time"`
+	Network string    `json:"network,omitempty"`
+	ChainID uint64    `json:"chain_id"`
+	// Address is the contract deployed, or the proxy for upgrades and
+	// rollbacks.
+	Address common.Address `json:"address"`
+	// Implementation is the implementation the proxy points to after an
+	// upgrade or rollback, and Previous the one it pointed to before.
+	Implementation *common.Address `json:"implementation,omitempty"`
+	Previous       *common.Address `json:"previous,omitempty"`
+	// CodeHash is the Keccak-256 hash of the runtime code of the contract
+	// deployed or of the implementation.
+	CodeHash  common.Hash    `json:"code_hash"`
+	GitCommit string         `json:"git_commit,omitempty"`
+	Operator  string         `json:"operator,omitempty"`
+	Signer    common.Address `json:"signer"`
+	TxHash    common.Hash    `json:"tx_hash"`
+	Block     uint64         `json:"block"`
+}
+
+// Target returns the address the entry leaves in use: the contract
+// deployed, or the implementation of the proxy.
+func (d *Deployment) Target() common.Address {
+	if d.Implementation != nil {
+		return *d.Implementation
+	}
+	return d.Address
+}
+
+// History is the file holding the entries, in the order they were made.
+type History struct {
+	Deployments []*Deployment `json:"deployments"`
+}
+
+// Load reads the history of path, an empty one when the file does not
+// exist yet.
+func Load(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &History{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &h, nil
+}
+
+// Marshal returns the file of the history.
+func (h *History) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Add appends d, setting its time when zero.
+func (h *History) Add(d *Deployment) {
+	if d.Time.IsZero() {
+		d.Time = time.Now().UTC()
+	}
+	h.Deployments = append(h.Deployments, d)
+}
+
+// Chain returns the entries of a chain, oldest first.
+func (h *History) Chain(chainID uint64) []*Deployment {
+	var entries []*Deployment
+	for _, d := range h.Deployments {
+		if d.ChainID == chainID {
+			entries = append(entries, d)
+		}
+	}
+	return entries
+}
+
+// ErrNoPrevious is returned by Previous when no earlier implementation of a
+// proxy is recorded.
+var ErrNoPrevious = errors.New("no earlier implementation recorded")
+
+// Previous returns the implementation a proxy pointed to before current,
+// from the upgrades and rollbacks recorded for it. Rolling back follows
+// the upgrades backwards: after a rollback from B to A, the previous
+// implementation of A is the one before A, not B.
+func (h *History) Previous(chainID uint64, proxy, current common.Address) (common.Address, error) {
+	// Replay the history of the proxy as a stack of implementations
+	var stack []common.Address
+	for _, d := range h.Chain(chainID) {
+		if d.Address != proxy || d.Implementation == nil {
+			continue
+		}
+		switch d.Kind {
+		case KindUpgrade:
+			if len(stack) == 0 && d.Previous != nil {
+				stack = append(stack, *d.Previous)
+			}
+			stack = append(stack, *d.Implementation)
+		case KindRollback:
+			for len(stack) > 0 && stack[len(stack)-1] != *d.Implementation {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				stack = append(stack, *d.Implementation)
+			}
+		}
+	}
+	for i := len(stack) - 1; i > 0; i-- {
+		if stack[i] == current {
+			return stack[i-1], nil
+		}
+	}
+	if len(stack) > 0 && stack[len(stack)-1] != current {
+		return common.Address{}, fmt.Errorf("%w: the proxy points to %s, which the history does not hold, last upgraded to %s", ErrNoPrevious, current.Hex(), stack[len(stack)-1].Hex())
+	}
+	return common.Address{}, ErrNoPrevious
+}
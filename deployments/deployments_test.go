@@ -0,0 +1,122 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"contract-storage-eth/deployments"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	proxy = common.HexToAddress("0x0100")
+	implA = common.HexToAddress("0x0a")
+	implB = common.HexToAddress("0x0b")
+	implC = common.HexToAddress("0x0c")
+)
+
+func upgrade(previous, implementation common.Address) *deployments.Deployment {
+	return &deployments.Deployment{Kind: deployments.KindUpgrade, ChainID: 1, Address: proxy, Previous: &previous, Implementation: &implementation}
+}
+
+func rollback(previous, implementation common.Address) *deployments.Deployment {
+	d := upgrade(previous, implementation)
+	d.Kind = deployments.KindRollback
+	return d
+}
+
+func TestPrevious(t *testing.T) {
+	h := &deployments.History{}
+	h.Add(&deployments.Deployment{Kind: deployments.KindDeploy, ChainID: 1, Address: implA})
+	h.Add(upgrade(implA, implB))
+	h.Add(&deployments.Deployment{Kind: deployments.KindUpgrade, ChainID: 5, Address: proxy, Implementation: &implA})
+	h.Add(upgrade(implB, implC))
+
+	for _, tc := range []struct {
+		current, want common.Address
+	}{
+		{implC, implB},
+		{implB, implA},
+	} {
+		if got, err := h.Previous(1, proxy, tc.current); err != nil || got != tc.want {
+			t.Errorf("previous of %s = %s, %v, want %s", tc.current.Hex(), got.Hex(), err, tc.want.Hex())
+		}
+	}
+	if _, err := h.Previous(1, proxy, implA); !errors.Is(err, deployments.ErrNoPrevious) {
+		t.Errorf("previous of the first implementation: %v", err)
+	}
+	if _, err := h.Previous(1, proxy, common.HexToAddress("0x0d")); !errors.Is(err, deployments.ErrNoPrevious) {
+		t.Errorf("previous of an unknown implementation: %v", err)
+	}
+
+	// Rolling back again goes further back, not to the implementation
+	// rolled back from
+	h.Add(rollback(implC, implB))
+	if got, err := h.Previous(1, proxy, implB); err != nil || got != implA {
+		t.Errorf("previous after a rollback = %s, %v, want %s", got.Hex(), err, implA.Hex())
+	}
+	if len(h.Chain(1)) != 4 || h.Deployments[0].Time.IsZero() {
+		t.Errorf("chain 1 holds %d entries", len(h.Chain(1)))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deployments.json")
+	h, err := deployments.Load(path)
+	if err != nil || len(h.Deployments) != 0 {
+		t.Fatalf("loaded %+v, %v without a file", h, err)
+	}
+	h.Add(upgrade(implA, implB))
+	data, err := h.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, data, 0o644)
+	loaded, err := deployments.Load(path)
+	if err != nil || len(loaded.Deployments) != 1 || loaded.Deployments[0].Target() != implB {
+		t.Errorf("loaded %+v, %v", loaded, err)
+	}
+}
+
+type storage map[common.Hash][]byte
+
+func (s storage) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return s[key], nil
+}
+
+func TestProxy(t *testing.T) {
+	impl, err := deployments.Implementation(context.Background(), storage{deployments.ImplementationSlot: common.LeftPadBytes(implB.Bytes(), 32)}, proxy)
+	if err != nil || impl != implB {
+		t.Errorf("implementation %s, %v", impl.Hex(), err)
+	}
+
+	to, data, err := deployments.UpgradeCall(proxy, implA, common.Address{})
+	if err != nil || to != proxy || !bytes.Equal(data[:4], common.FromHex("0x4f1ef286")) {
+		t.Errorf("upgradeToAndCall to %s, data %x, %v", to.Hex(), data, err)
+	}
+	admin := common.HexToAddress("0xad")
+	to, data, err = deployments.UpgradeCall(proxy, implA, admin)
+	if err != nil || to != admin || !bytes.Equal(data[:4], common.FromHex("0x9623609d")) {
+		t.Errorf("upgradeAndCall to %s, data %x, %v", to.Hex(), data, err)
+	}
+}
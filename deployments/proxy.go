@@ -0,0 +1,65 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ImplementationSlot is the storage slot of EIP-1967 proxies holding their
+// implementation, keccak256("eip1967.proxy.implementation") - 1.
+var ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+// StorageReader reads contract storage, as ethclient does.
+type StorageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// Implementation returns the implementation of an EIP-1967 proxy, the zero
+// address when proxy is none.
+func Implementation(ctx context.Context, backend StorageReader, proxy common.Address) (common.Address, error) {
+	value, err := backend.StorageAt(ctx, proxy, ImplementationSlot, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(value), nil
+}
+
+// upgradeABI holds upgradeToAndCall of UUPS proxies, and of transparent
+// proxies called by their admin before OpenZeppelin 5, and upgradeAndCall
+// of their ProxyAdmin contracts.
+var upgradeABI, _ = abi.JSON(strings.NewReader(`[
+	{"type":"function","name":"upgradeToAndCall","stateMutability":"payable",
+		"inputs":[{"name":"newImplementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"upgradeAndCall","stateMutability":"payable",
+		"inputs":[{"name":"proxy","type":"address"},{"name":"implementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`))
+
+// UpgradeCall returns the transaction that points proxy to implementation
+// without calling it: upgradeToAndCall on the proxy, or upgradeAndCall on
+// admin, its ProxyAdmin, when admin is not the zero address.
+func UpgradeCall(proxy, implementation, admin common.Address) (to common.Address, data []byte, err error) {
+	if admin != (common.Address{}) {
+		data, err = upgradeABI.Pack("upgradeAndCall", proxy, implementation, []byte{})
+		return admin, data, err
+	}
+	data, err = upgradeABI.Pack("upgradeToAndCall", implementation, []byte{})
+	return proxy, data, err
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package devnode launches an ephemeral development node, Anvil or geth in
+// --dev mode, as a child process, for end-to-end tests against a real
+// client. The node listens on a free local port, keeps its chain in a
+// temporary directory and is torn down by Stop.
+package devnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Kinds of development nodes.
+const (
+	Anvil = "anvil"
+	Geth  = "geth"
+)
+
+// ChainID is the chain ID the nodes are started with, the one of geth's
+// development mode.
+const ChainID = 1337
+
+// DefaultStartTimeout bounds the wait for the RPC endpoint of a node.
+const DefaultStartTimeout = 30 * time.Second
+
+// ErrNotFound is returned by Start when no node binary is installed.
+var ErrNotFound = errors.New("neither anvil nor geth is installed")
+
+// Options configures a node.
+type Options struct {
+	// Kind is Anvil or Geth. When empty, the first one found on the PATH
+	// is used, Anvil first.
+	Kind string
+	// Binary is the path of the node binary, looked up on the PATH by
+	// the name of Kind when empty.
+	Binary string
+	// StartTimeout bounds the wait for the node to serve RPC requests,
+	// DefaultStartTimeout when zero.
+	StartTimeout time.Duration
+	// Output receives what the node prints, discarded when nil.
+	Output io.Writer
+}
+
+// Node is a running development node.
+type Node struct {
+	// Kind is Anvil or Geth.
+	Kind string
+	// URL is the HTTP RPC endpoint of the node.
+	URL string
+
+	cmd    *exec.Cmd
+	dir    string
+	exited chan struct{}
+	err    error
+}
+
+// Find returns the kind and path of the node binary Start would run.
+func Find(opts Options) (kind, path string, err error) {
+	kinds := []string{Anvil, Geth}
+	if opts.Kind != "" {
+		if opts.Kind != Anvil && opts.Kind != Geth {
+			return "", "", fmt.Errorf("unknown node kind %q, want %s or %s", opts.Kind, Anvil, Geth)
+		}
+		kinds = []string{opts.Kind}
+	}
+	if opts.Binary != "" {
+		return kinds[0], opts.Binary, nil
+	}
+	for _, k := range kinds {
+		if path, err := exec.LookPath(k); err == nil {
+			return k, path, nil
+		}
+	}
+	if opts.Kind != "" {
+		return "", "", fmt.Errorf("%s is not installed", opts.Kind)
+	}
+	return "", "", ErrNotFound
+}
+
+// Start launches a node and returns once it serves RPC requests. The node
+// is killed when ctx is done.
+func Start(ctx context.Context, opts Options) (*Node, error) {
+	kind, path, err := Find(opts)
+	if err != nil {
+		return nil, err
+	}
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "contract-storage-eth-"+kind+"-")
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	switch kind {
+	case Anvil:
+		args = []string{"--host", "127.0.0.1", "--port", strconv.Itoa(port), "--chain-id", strconv.Itoa(ChainID), "--silent"}
+	case Geth:
+		// Period 0 mines a block for each transaction
+		args = []string{"--dev", "--datadir", dir, "--ipcdisable", "--nodiscover", "--maxpeers", "0",
+			"--http", "--http.addr", "127.0.0.1", "--http.port", strconv.Itoa(port), "--http.api", "eth,net,web3", "--verbosity", "2"}
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+	cmd.Stdout, cmd.Stderr = output, output
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("start %s: %w", kind, err)
+	}
+
+	n := &Node{Kind: kind, URL: fmt.Sprintf("http://127.0.0.1:%d", port), cmd: cmd, dir: dir, exited: make(chan struct{})}
+	go func() {
+		n.err = cmd.Wait()
+		close(n.exited)
+	}()
+
+	timeout := opts.StartTimeout
+	if timeout <= 0 {
+		timeout = DefaultStartTimeout
+	}
+	if err := n.waitReady(ctx, timeout); err != nil {
+		n.Stop()
+		return nil, err
+	}
+	return n, nil
+}
+
+// waitReady polls the endpoint until it answers eth_chainId
+func (n *Node) waitReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		if client, err := ethclient.DialContext(ctx, n.URL); err == nil {
+			_, err = client.ChainID(ctx)
+			client.Close()
+			if err == nil {
+				return nil
+			}
+		}
+		select {
+		case <-n.exited:
+			return fmt.Errorf("%s exited before serving requests: %v", n.Kind, n.err)
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not serve requests within %s", n.Kind, timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Stop kills the node and removes its chain.
+func (n *Node) Stop() error {
+	select {
+	case <-n.exited:
+	default:
+		n.cmd.Process.Signal(os.Interrupt)
+		select {
+		case <-n.exited:
+		case <-time.After(5 * time.Second):
+			n.cmd.Process.Kill()
+			<-n.exited
+		}
+	}
+	return os.RemoveAll(n.dir)
+}
+
+// Fund sends amount wei to account from the first account of the node,
+// which development nodes fund and unlock, and waits for the transfer to
+// be mined.
+func (n *Node) Fund(ctx context.Context, account common.Address, amount *big.Int) error {
+	client, err := rpc.DialContext(ctx, n.URL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var accounts []common.Address
+	if err := client.CallContext(ctx, &accounts, "eth_accounts"); err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("%s has no unlocked account to fund from", n.Kind)
+	}
+	var hash common.Hash
+	tx := map[string]interface{}{"from": accounts[0], "to": account, "value": (*hexutil.Big)(amount)}
+	if err := client.CallContext(ctx, &hash, "eth_sendTransaction", tx); err != nil {
+		return err
+	}
+	eth := ethclient.NewClient(client)
+	for {
+		receipt, err := eth.TransactionReceipt(ctx, hash)
+		if err == nil {
+			if receipt.Status != 1 {
+				return fmt.Errorf("funding transaction %s failed", hash.Hex())
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// freePort returns a local port nothing listens on
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devnode_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"contract-storage-eth/devnode"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestFind(t *testing.T) {
+	if _, _, err := devnode.Find(devnode.Options{Kind: "hardhat"}); err == nil {
+		t.Error("found an unknown kind of node")
+	}
+	kind, path, err := devnode.Find(devnode.Options{Kind: devnode.Geth, Binary: "/opt/geth/bin/geth"})
+	if err != nil || kind != devnode.Geth || path != "/opt/geth/bin/geth" {
+		t.Errorf("got %s %s, %v", kind, path, err)
+	}
+}
+
+// TestStartAndFund runs against whichever node is installed
+func TestStartAndFund(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	node, err := devnode.Start(ctx, devnode.Options{})
+	if errors.Is(err, devnode.ErrNotFound) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Stop()
+
+	account := common.HexToAddress("0x00000000000000000000000000000000000e2e01")
+	amount := big.NewInt(1_000_000_000)
+	if err := node.Fund(ctx, account, amount); err != nil {
+		t.Fatal(err)
+	}
+	client, err := ethclient.DialContext(ctx, node.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if id, err := client.ChainID(ctx); err != nil || id.Int64() != devnode.ChainID {
+		t.Errorf("chain ID %v, %v", id, err)
+	}
+	if balance, err := client.BalanceAt(ctx, account, nil); err != nil || balance.Cmp(amount) != 0 {
+		t.Errorf("balance %v, %v, want %v", balance, err, amount)
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/devnode"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/simchain"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testUsage = `Usage: contract-storage-eth test <command> [flags]
+
+Commands:
+  e2e     Deploy to a throwaway dev node and run save, get, index and delete
+          against it (--node anvil|geth|simulated)
+`
+
+// e2eSimulated runs the end-to-end test on the in-memory chain instead of
+// a node process
+const e2eSimulated = "simulated"
+
+func runTest(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, testUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "e2e":
+		return runTestE2E(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown test command: %s\n\n%s", args[0], testUsage)
+		return exitError(2)
+	}
+}
+
+func runTestE2E(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("test e2e", flag.ContinueOnError)
+	node := flags.String("node", "", "anvil, geth or simulated, the first of anvil and geth installed by default")
+	binary := flags.String("node-binary", "", "path of the anvil or geth binary")
+	fund := flags.String("fund", "10", "ether sent to the signer before deploying")
+	nodeOutput := flags.Bool("node-output", false, "print the output of the node to stderr")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	amount, err := fees.ParseEther(*fund)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --fund: %w", err)
+	}
+	opts := devnode.Options{Binary: *binary}
+	if *node != e2eSimulated {
+		opts.Kind = *node
+		if *nodeOutput {
+			opts.Output = os.Stderr
+		}
+		if _, _, err := devnode.Find(opts); err != nil {
+			return fmt.Errorf("No dev node to test against, install anvil or geth or pass --node simulated: %w", err)
+		}
+	}
+
+	// Everything of the run is torn down before exiting with its status
+	result, err := runE2E(ctx, config, *node == e2eSimulated, opts, amount)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	if !result.OK {
+		fmt.Println("End-to-end test FAILED")
+		printFailure("e2e_failed", "the end-to-end test failed", result)
+		return exitError(1)
+	}
+	fmt.Println("End-to-end test passed")
+	printResult(result)
+	return nil
+}
+
+// e2eResult is the result of test e2e in JSON output mode
+type e2eResult struct {
+	OK       bool           `json:"ok"`
+	Node     string         `json:"node"`
+	ChainID  uint64         `json:"chain_id"`
+	Account  common.Address `json:"account"`
+	Contract common.Address `json:"contract"`
+	Steps    []e2eStep      `json:"steps"`
+}
+
+type e2eStep struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Duration time.Duration `json:"duration"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// step runs one step of the test, unless an earlier one failed, which the
+// later ones depend on
+func (r *e2eResult) step(name string, fn func() (string, error)) {
+	if len(r.Steps) > 0 && !r.Steps[len(r.Steps)-1].OK {
+		return
+	}
+	start := time.Now()
+	detail, err := fn()
+	s := e2eStep{Name: name, OK: err == nil, Duration: time.Since(start), Detail: detail}
+	if err != nil {
+		s.Error = err.Error()
+		fmt.Printf("FAIL %-8s %s\n", name, err)
+	} else {
+		fmt.Printf("PASS %-8s %s (%s)\n", name, detail, s.Duration.Round(time.Millisecond))
+	}
+	r.Steps = append(r.Steps, s)
+	r.OK = err == nil
+}
+
+// runE2E starts a dev node, funds the signer, deploys the contract and
+// goes through the write and read paths, tearing the node down at the end
+func runE2E(ctx context.Context, config *Config, simulated bool, opts devnode.Options, amount *big.Int) (*e2eResult, error) {
+	result := &e2eResult{Node: opts.Kind, ChainID: simchain.ChainID}
+	if simulated {
+		result.Node = e2eSimulated
+	}
+	dir, err := os.MkdirTemp("", "contract-storage-eth-e2e-")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	useDevChain(config, nil, dir)
+	config.Ethereum.Standby.KeyConfig = KeyConfig{}
+
+	var signers signer.Selector
+	result.step("signer", func() (string, error) {
+		// A throwaway key signs when none is configured
+		generated := false
+		if signers, err = loadSigners(ctx, config); errors.Is(err, errNoKey) {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return "", err
+			}
+			generated = true
+			config.Ethereum.KeyConfig = KeyConfig{PrivateKey: common.Bytes2Hex(crypto.FromECDSA(key))}
+		}
+		if generated {
+			signers, err = loadSigners(ctx, config)
+		}
+		if err != nil {
+			return "", err
+		}
+		active, err := signers.Active(ctx)
+		if err != nil {
+			return "", err
+		}
+		result.Account = active.Address()
+		if generated {
+			return "generated account " + result.Account.Hex(), nil
+		}
+		return "configured account " + result.Account.Hex(), nil
+	})
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var node *devnode.Node
+	result.step("node", func() (string, error) {
+		if simulated {
+			// The genesis block funds the signer already
+			sim, err := simchain.New(simchain.Options{Accounts: []common.Address{result.Account}, Balance: amount})
+			if err != nil {
+				return "", err
+			}
+			simulatedChain = sim
+			config.Ethereum.RpcURL = URLList{e2eSimulated}
+			return "in-memory chain", nil
+		}
+		if node, err = devnode.Start(ctx, opts); err != nil {
+			return "", err
+		}
+		result.Node = node.Kind
+		config.Ethereum.RpcURL = URLList{node.URL}
+		return fmt.Sprintf("%s at %s", node.Kind, node.URL), nil
+	})
+	defer func() {
+		if simulatedChain != nil {
+			simulatedChain.Close()
+			simulatedChain = nil
+		}
+		if node != nil {
+			node.Stop()
+		}
+	}()
+
+	var client *chain.Client
+	result.step("fund", func() (string, error) {
+		if client, err = dialClient(ctx, config); err != nil {
+			return "", err
+		}
+		if node != nil {
+			if err := node.Fund(ctx, result.Account, amount); err != nil {
+				return "", err
+			}
+		}
+		balance, err := client.BalanceAt(ctx, result.Account, nil)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s holds %s ETH", result.Account.Hex(), fees.FormatEther(balance)), nil
+	})
+	if client != nil {
+		defer client.Close()
+	}
+
+	result.step("deploy", func() (string, error) {
+		address, tx, err := e2eDeploy(ctx, config, client, signers)
+		if err != nil {
+			return "", err
+		}
+		result.Contract = address
+		config.Contract.Address = address.Hex()
+		return fmt.Sprintf("contract %s (tx %s)", address.Hex(), tx.Hex()), nil
+	})
+
+	key, field := "e2e", "check"
+	value := "e2e " + time.Now().UTC().Format(time.RFC3339Nano)
+	var records *recordService
+	var saved *api.WriteResult
+	result.step("save", func() (string, error) {
+		records = &recordService{
+			config:  config,
+			client:  client,
+			address: result.Contract,
+			ns:      recordid.Namespace{ChainID: simchain.ChainID, Contract: result.Contract},
+			signers: signers,
+		}
+		if saved, err = records.Save(ctx, api.SaveRequest{Key: key, Field: field, Value: value}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s in block %d", saved.ID, saved.Block), nil
+	})
+
+	result.step("get", func() (string, error) {
+		record, err := records.Get(ctx, key, field)
+		if err != nil {
+			return "", err
+		}
+		if record.Content != value {
+			return "", fmt.Errorf("read back %q, want %q", record.Content, value)
+		}
+		return fmt.Sprintf("%s reads back %q", record.ID, record.Content), nil
+	})
+
+	result.step("index", func() (string, error) {
+		store, err := openIndex(config)
+		if err != nil {
+			return "", err
+		}
+		defer store.Close()
+		if err := checkIndexChain(ctx, client, store); err != nil {
+			return "", err
+		}
+		ix, err := newIndexer(config, client, store)
+		if err != nil {
+			return "", err
+		}
+		head, err := ix.Sync(ctx)
+		if err != nil {
+			return "", err
+		}
+		history, err := store.History(keyNamespace.Key(key), field)
+		if err != nil {
+			return "", err
+		}
+		if len(history) != 1 || history[0].TxHash != saved.TxHash {
+			return "", fmt.Errorf("indexed %d record(s) for %s#%s, want the one of tx %s", len(history), key, field, saved.TxHash.Hex())
+		}
+		return fmt.Sprintf("synced to block %d, DataSaved event of tx %s indexed", head, saved.TxHash.Hex()), nil
+	})
+
+	result.step("delete", func() (string, error) {
+		deleted, err := records.remove(ctx, key, field, false)
+		if err != nil {
+			return "", err
+		}
+		if _, err := records.Get(ctx, key, field); !errors.Is(err, api.ErrNotFound) {
+			return "", fmt.Errorf("record still readable after delete: %v", err)
+		}
+		return fmt.Sprintf("deleted in block %d, no longer readable", deleted.Block), nil
+	})
+	return result, nil
+}
+
+// e2eDeploy deploys the contract of the build directory and waits for it
+func e2eDeploy(ctx context.Context, config *Config, client *chain.Client, signers signer.Selector) (common.Address, common.Hash, error) {
+	bytecode, err := os.ReadFile(filepath.Join(config.Build.Directory, config.Build.ContractName+".bin"))
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	active, err := signers.Active(ctx)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	auth, err := signer.NewTransactOpts(ctx, active, big.NewInt(simchain.ChainID))
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	auth.GasLimit = config.Ethereum.GasLimit
+	address, tx, _, err := bind.DeployContract(auth, parsedABI, common.FromHex(strings.TrimSpace(string(bytecode))), client)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	receipt, err := waitMinedWith(ctx, client, tx, config, nil)
+	if err != nil {
+		return common.Address{}, tx.Hash(), err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, tx.Hash(), fail(codeTransactionFailed, "deployment %s reverted", tx.Hash().Hex())
+	}
+	return address, tx.Hash(), nil
+}
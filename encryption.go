@@ -0,0 +1,152 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"contract-storage-eth/envelope"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// Environment variable holding the encryption secret when none is configured
+const defaultSecretEnv = "CSE_ENCRYPTION_SECRET"
+
+// kmsKeyMessage is the message MACed by the KMS key to derive the
+// encryption key, so that the key never needs storing
+const kmsKeyMessage = "contract-storage-eth value encryption key v1"
+
+// Time allowed to derive the key with KMS
+const kmsKeyTimeout = 30 * time.Second
+
+// valueKeys holds the key encrypting stored values, derived on first use so
+// that commands not reading or writing values never need the secret
+var valueKeys struct {
+	once   sync.Once
+	config *Config
+	key    *envelope.Key
+	err    error
+}
+
+// setupEncryption selects the configuration of the value encryption key
+func setupEncryption(config *Config) {
+	valueKeys.config = config
+}
+
+// valueKey returns the key encrypting stored values, nil when
+// storage.encryption is disabled
+func valueKey() (*envelope.Key, error) {
+	valueKeys.once.Do(func() {
+		if valueKeys.config != nil && valueKeys.config.Storage.Encryption.Enabled {
+			valueKeys.key, valueKeys.err = loadValueKey(valueKeys.config)
+		}
+	})
+	return valueKeys.key, valueKeys.err
+}
+
+// loadValueKey derives the key from the KMS key when one is configured,
+// from the secret in the environment otherwise
+func loadValueKey(config *Config) (*envelope.Key, error) {
+	enc := config.Storage.Encryption
+	if enc.KMS.KeyID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), kmsKeyTimeout)
+		defer cancel()
+		return kmsValueKey(ctx, config)
+	}
+
+	env := enc.SecretEnv
+	if env == "" {
+		env = defaultSecretEnv
+	}
+	secret := os.Getenv(env)
+	if secret == "" {
+		return nil, fmt.Errorf("storage.encryption is enabled but %s is empty", env)
+	}
+	secrets.Add(secret)
+	return envelope.DeriveKey([]byte(secret))
+}
+
+// kmsValueKey derives the key as the MAC of a fixed message by an HMAC_256
+// key of AWS KMS, which never leaves it
+func kmsValueKey(ctx context.Context, config *Config) (*envelope.Key, error) {
+	enc := config.Storage.Encryption
+	var opts []func(*awsconfig.LoadOptions) error
+	if enc.KMS.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(enc.KMS.Region))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("kms: %w", err)
+	}
+	client := kms.NewFromConfig(awsConfig, func(o *kms.Options) {
+		if enc.KMS.Endpoint != "" {
+			o.BaseEndpoint = &enc.KMS.Endpoint
+		}
+	})
+	out, err := client.GenerateMac(ctx, &kms.GenerateMacInput{
+		KeyId:        aws.String(enc.KMS.KeyID),
+		MacAlgorithm: kmstypes.MacAlgorithmSpecHmacSha256,
+		Message:      []byte(kmsKeyMessage),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kms: generate mac: %w", err)
+	}
+	return envelope.NewKey(out.Mac)
+}
+
+// openValue opens a stored value, decrypting it if needed, and returns its
+// content with the codec it was sealed with
+func openValue(value string) ([]byte, envelope.Codec, error) {
+	env, err := envelope.Parse(value)
+	if err != nil {
+		return nil, 0, err
+	}
+	if env.Encryption == envelope.EncryptionNone {
+		content, err := env.Open()
+		return content, env.Codec, err
+	}
+	key, err := valueKey()
+	if err != nil {
+		return nil, 0, err
+	}
+	if key == nil {
+		return nil, 0, errors.New("the value is encrypted, enable storage.encryption to read it")
+	}
+	content, err := env.OpenWith(key)
+	return content, env.Codec, err
+}
+
+// decryptionKeys returns the keys that may decrypt stored values, logging
+// why there are none
+func decryptionKeys() []*envelope.Key {
+	key, err := valueKey()
+	if err != nil {
+		slog.Warn("Failed to load encryption key", "error", err)
+	}
+	if key == nil {
+		return nil
+	}
+	return []*envelope.Key{key}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/ens"
+	"contract-storage-eth/presets"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ensResolver caches the ENS names resolved during the command, on the
+// chain ensChainID
+var (
+	ensResolver *ens.Resolver
+	ensChainID  string
+)
+
+// resolveAddress returns the address s stands for: a contract alias, a hex
+// address or an ENS name resolved on the chain of client
+func resolveAddress(ctx context.Context, config *Config, client chain.ChainClient, s string) (common.Address, error) {
+	if address, ok := config.Contracts[s]; ok {
+		s = address
+	}
+	if common.IsHexAddress(s) {
+		return common.HexToAddress(s), nil
+	}
+	if !ens.IsName(s) {
+		return common.Address{}, fmt.Errorf("%q is neither a contract alias, an address nor an ENS name", s)
+	}
+	name, err := ens.Normalize(s)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if ensResolver == nil {
+		chainID, err := client.ChainID(ctx)
+		if err != nil {
+			return common.Address{}, err
+		}
+		registry := config.ENS.Registry
+		if registry == "" {
+			preset, _ := presets.ByChainID(chainID.Uint64())
+			if registry = preset.ENSRegistry; registry == "" {
+				return common.Address{}, fmt.Errorf("cannot resolve %s: chain %s has no known ENS registry, set ens.registry", s, chainID)
+			}
+		}
+		if !common.IsHexAddress(registry) {
+			return common.Address{}, fmt.Errorf("ens.registry %q is not an address", registry)
+		}
+		ttl := config.ENS.CacheTTL
+		if ttl == 0 {
+			ttl = 10 * time.Minute
+		}
+		ensResolver, ensChainID = ens.NewResolver(common.HexToAddress(registry), ttl), chainID.String()
+		for key, entry := range readENSCache(config) {
+			if chain, name, ok := strings.Cut(key, "/"); ok && chain == ensChainID {
+				ensResolver.Add(name, entry)
+			}
+		}
+	}
+
+	_, cached := ensResolver.Entries()[name]
+	address, err := ensResolver.Resolve(ctx, client, name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	slog.Info("Resolved ENS name", "name", name, "address", address.Hex(), "cached", cached)
+	if !cached && config.ENS.CacheFile != "" {
+		if err := writeENSCache(config); err != nil {
+			slog.Warn("Failed to write ENS cache", "file", config.ENS.CacheFile, "error", err)
+		}
+	}
+	return address, nil
+}
+
+// readENSCache returns the entries of ens.cache_file, keyed by chain ID and
+// name. A missing or unreadable file is an empty cache
+func readENSCache(config *Config) map[string]ens.Entry {
+	entries := map[string]ens.Entry{}
+	if config.ENS.CacheFile == "" {
+		return entries
+	}
+	data, err := os.ReadFile(config.ENS.CacheFile)
+	if err == nil {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Ignoring ENS cache", "file", config.ENS.CacheFile, "error", err)
+	}
+	return entries
+}
+
+// writeENSCache saves the names resolved on this chain to ens.cache_file,
+// keeping the unexpired entries of other chains
+func writeENSCache(config *Config) error {
+	entries := map[string]ens.Entry{}
+	for key, entry := range readENSCache(config) {
+		if chain, _, _ := strings.Cut(key, "/"); chain != ensChainID && time.Now().Before(entry.Expires) {
+			entries[key] = entry
+		}
+	}
+	for name, entry := range ensResolver.Entries() {
+		entries[ensChainID+"/"+name] = entry
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(config.ENS.CacheFile, append(data, '\n'))
+}
+
+// setupAddresses replaces the contract aliases and ENS names of the address
+// settings by the addresses they stand for, once when the command starts
+func setupAddresses(ctx context.Context, config *Config) error {
+	settings := []struct {
+		name  string
+		value *string
+	}{
+		{"contract.address", &config.Contract.Address},
+		{"contract.proxy_admin", &config.Contract.ProxyAdmin},
+		{"safe.address", &config.Safe.Address},
+		{"forwarder.address", &config.Forwarder.Address},
+		{"estimate.from", &config.Estimate.From},
+	}
+	var client *chain.Client
+	for _, setting := range settings {
+		if address, ok := config.Contracts[*setting.value]; ok {
+			*setting.value = address
+		}
+		if !ens.IsName(*setting.value) {
+			continue
+		}
+		if client == nil {
+			var err error
+			if client, err = dialClient(ctx, config); err != nil {
+				return err
+			}
+			defer client.Close()
+		}
+		address, err := resolveAddress(ctx, config, client, *setting.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", setting.name, err)
+		}
+		*setting.value = address.Hex()
+	}
+	return nil
+}
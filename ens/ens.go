@@ -0,0 +1,177 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ens resolves Ethereum Name Service names to addresses through the
+// ENS registry and the public resolver of each name.
+package ens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Selectors of the registry's resolver(bytes32) and the resolver's
+// addr(bytes32).
+var (
+	resolverSelector = crypto.Keccak256([]byte("resolver(bytes32)"))[:4]
+	addrSelector     = crypto.Keccak256([]byte("addr(bytes32)"))[:4]
+)
+
+// ErrNotFound is returned for names without a resolver or an address.
+var ErrNotFound = errors.New("ENS name not found")
+
+// IsName reports whether s is meant as an ENS name rather than an address:
+// anything with a dot that is not a hex address.
+func IsName(s string) bool {
+	return strings.Contains(s, ".") && !common.IsHexAddress(s)
+}
+
+// Normalize lowercases a name and checks that none of its labels is empty.
+// Only the case folding of the ENS normalization is applied, so names
+// outside ASCII should be given normalized already.
+func Normalize(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", errors.New("empty ENS name")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", fmt.Errorf("invalid ENS name %q: empty label", name)
+		}
+	}
+	return name, nil
+}
+
+// Namehash returns the node of a normalized name, as defined by EIP-137.
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// Resolver resolves names through a registry, caching the addresses it
+// finds. It is safe for concurrent use.
+type Resolver struct {
+	Registry common.Address
+	// TTL is how long a resolved address is reused, 0 to resolve names on
+	// every call.
+	TTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]Entry
+}
+
+// Entry is a cached resolution of a name.
+type Entry struct {
+	Address common.Address `json:"address"`
+	Expires time.Time      `json:"expires"`
+}
+
+// NewResolver returns a resolver using the given registry.
+func NewResolver(registry common.Address, ttl time.Duration) *Resolver {
+	return &Resolver{Registry: registry, TTL: ttl, cache: map[string]Entry{}}
+}
+
+// Add caches an entry for a name, such as one kept from an earlier run.
+// Expired entries are ignored.
+func (r *Resolver) Add(name string, entry Entry) {
+	if name, err := Normalize(name); err == nil && time.Now().Before(entry.Expires) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.cache == nil {
+			r.cache = map[string]Entry{}
+		}
+		r.cache[name] = entry
+	}
+}
+
+// Entries returns the names cached and not expired yet.
+func (r *Resolver) Entries() map[string]Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := map[string]Entry{}
+	for name, entry := range r.cache {
+		if time.Now().Before(entry.Expires) {
+			entries[name] = entry
+		}
+	}
+	return entries
+}
+
+// Resolve returns the address a name points to, reading the registry and
+// the name's resolver with caller.
+func (r *Resolver) Resolve(ctx context.Context, caller ethereum.ContractCaller, name string) (common.Address, error) {
+	name, err := Normalize(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	r.mu.Lock()
+	entry, ok := r.cache[name]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.Expires) {
+		return entry.Address, nil
+	}
+
+	node := Namehash(name)
+	resolver, err := callAddress(ctx, caller, r.Registry, resolverSelector, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ENS registry %s: %w", r.Registry.Hex(), err)
+	}
+	if resolver == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%w: %s has no resolver", ErrNotFound, name)
+	}
+	address, err := callAddress(ctx, caller, resolver, addrSelector, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ENS resolver %s of %s: %w", resolver.Hex(), name, err)
+	}
+	if address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%w: %s has no address", ErrNotFound, name)
+	}
+
+	if r.TTL > 0 {
+		r.Add(name, Entry{Address: address, Expires: time.Now().Add(r.TTL)})
+	}
+	return address, nil
+}
+
+// callAddress calls a method of contract taking a node and returning an
+// address.
+func callAddress(ctx context.Context, caller ethereum.ContractCaller, contract common.Address, selector []byte, node common.Hash) (common.Address, error) {
+	out, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: append(append([]byte{}, selector...), node.Bytes()...)}, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(out) == 0 {
+		// Calls to accounts without code return nothing
+		return common.Address{}, fmt.Errorf("no contract at %s", contract.Hex())
+	}
+	if len(out) != 32 {
+		return common.Address{}, fmt.Errorf("unexpected answer of %d bytes", len(out))
+	}
+	return common.BytesToAddress(out), nil
+}
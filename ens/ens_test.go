@@ -0,0 +1,122 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ens_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"contract-storage-eth/ens"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestNamehash(t *testing.T) {
+	for name, want := range map[string]string{
+		"":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	} {
+		if got := ens.Namehash(name).Hex(); got != want {
+			t.Errorf("Namehash(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestIsName(t *testing.T) {
+	for s, want := range map[string]bool{
+		"storage.casibase.eth":                       true,
+		"0x5FbDB2315678afecb367f032d93F642f64180aa3": false,
+		"5FbDB2315678afecb367f032d93F642f64180aa3":   false,
+		"localhost": false,
+	} {
+		if got := ens.IsName(s); got != want {
+			t.Errorf("IsName(%q) = %v", s, got)
+		}
+	}
+	if _, err := ens.Normalize("foo..eth"); err == nil {
+		t.Error("normalized a name with an empty label")
+	}
+}
+
+// fakeENS answers the registry and resolver calls of one name
+type fakeENS struct {
+	registry, resolver, address common.Address
+	node                        common.Hash
+	calls                       int
+}
+
+func (f *fakeENS) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	f.calls++
+	word := func(a common.Address) []byte { return common.LeftPadBytes(a.Bytes(), 32) }
+	switch {
+	case !bytes.Equal(msg.Data[4:], f.node.Bytes()):
+		return make([]byte, 32), nil
+	case *msg.To == f.registry && bytes.Equal(msg.Data[:4], crypto.Keccak256([]byte("resolver(bytes32)"))[:4]):
+		return word(f.resolver), nil
+	case *msg.To == f.resolver && bytes.Equal(msg.Data[:4], crypto.Keccak256([]byte("addr(bytes32)"))[:4]):
+		return word(f.address), nil
+	}
+	return nil, nil
+}
+
+func TestResolve(t *testing.T) {
+	registry := common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+	f := &fakeENS{
+		registry: registry,
+		resolver: common.HexToAddress("0x231b0Ee14048e9dCcD1d247744d114a4EB5E8E63"),
+		address:  common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"),
+		node:     ens.Namehash("storage.casibase.eth"),
+	}
+	r := ens.NewResolver(registry, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		got, err := r.Resolve(ctx, f, "Storage.Casibase.eth")
+		if err != nil || got != f.address {
+			t.Fatalf("got %s, %v", got.Hex(), err)
+		}
+	}
+	if f.calls != 2 {
+		t.Errorf("made %d calls, want 2 with the second lookup cached", f.calls)
+	}
+	if _, err := r.Resolve(ctx, f, "other.eth"); !errors.Is(err, ens.ErrNotFound) {
+		t.Errorf("got %v for a name without a resolver", err)
+	}
+	if _, err := ens.NewResolver(common.HexToAddress("0x01"), 0).Resolve(ctx, f, "storage.casibase.eth"); err == nil {
+		t.Error("resolved through an account without code")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	f := &fakeENS{}
+	r := ens.NewResolver(common.HexToAddress("0x01"), time.Minute)
+	address := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	r.Add("Kept.eth", ens.Entry{Address: address, Expires: time.Now().Add(time.Hour)})
+	r.Add("expired.eth", ens.Entry{Address: address, Expires: time.Now().Add(-time.Hour)})
+
+	if got, err := r.Resolve(context.Background(), f, "kept.eth"); err != nil || got != address || f.calls != 0 {
+		t.Errorf("got %s, %v after %d call(s)", got.Hex(), err, f.calls)
+	}
+	if entries := r.Entries(); len(entries) != 1 || entries["kept.eth"].Address != address {
+		t.Errorf("entries %v", entries)
+	}
+}
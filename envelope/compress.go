@@ -0,0 +1,106 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MaxDecompressedSize bounds the size of decompressed payloads, so that a
+// crafted value cannot exhaust memory. Values are bounded by the size of a
+// transaction, which compresses to far less than this.
+const MaxDecompressedSize = 64 << 20
+
+// ParseCompression returns the compression of a configuration name: none,
+// gzip or zstd.
+func ParseCompression(name string) (Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "zstd":
+		return CompressionZstd, nil
+	}
+	return CompressionNone, fmt.Errorf("unknown compression %q, want none, gzip or zstd", name)
+}
+
+func compress(c Compression, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch c {
+	case CompressionGzip:
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case CompressionZstd:
+		w, err := zstd.NewWriter(&buf, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderCRC(false))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: compression %d", ErrUnsupported, c)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(c Compression, data []byte) ([]byte, error) {
+	var r io.Reader
+	switch c {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: gzip: %v", ErrMalformed, err)
+		}
+		defer gz.Close()
+		r = gz
+	case CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderMaxMemory(MaxDecompressedSize), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("%w: compression %d", ErrUnsupported, c)
+	}
+
+	out, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(out) > MaxDecompressedSize {
+		return nil, fmt.Errorf("%w: decompresses to more than %d bytes", ErrMalformed, MaxDecompressedSize)
+	}
+	return out, nil
+}
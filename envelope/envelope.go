@@ -0,0 +1,397 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package envelope implements the versioned wrapper around values stored in
+// the contract. An envelope records how a value was encoded (codec,
+// compression, encryption, integrity hash) so that values written by newer
+// versions of the tool can still be recognised by older ones, and values
+// written before envelopes existed are still readable.
+//
+// Wire format (before base64 encoding, after the "cse:" prefix):
+//
+//	version     1 byte
+//	header_len  1 byte, number of header bytes that follow
+//	codec       1 byte
+//	compression 1 byte
+//	encryption  1 byte
+//	hash_alg    1 byte
+//	digest_len  1 byte
+//	digest      digest_len bytes
+//	meta_len    2 bytes big-endian, version 2 only
+//	...         future header fields, skipped by older readers
+//	meta        meta_len bytes, a JSON object of strings (version 2 only)
+//	payload     remaining bytes
+//
+// Version 2 is only written when metadata is attached, so values without
+// metadata stay readable by tools that only know version 1.
+package envelope
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Prefix marks a stored value as an envelope. Values without it are legacy
+// plain strings.
+const Prefix = "cse:"
+
+// Version is the newest envelope layout version written by this package.
+const Version = 2
+
+// versionNoMeta is the layout written for envelopes without metadata.
+const versionNoMeta = 1
+
+// Codec describes how the payload should be interpreted.
+type Codec byte
+
+const (
+	CodecText Codec = iota
+	CodecBytes
+	CodecJSON
+)
+
+// Compression identifies the compression applied to the payload.
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZstd
+)
+
+// Encryption identifies the cipher applied to the payload.
+type Encryption byte
+
+const (
+	EncryptionNone Encryption = iota
+	EncryptionAESGCM
+)
+
+// HashAlg identifies the algorithm used for the plaintext digest.
+type HashAlg byte
+
+const (
+	HashNone HashAlg = iota
+	HashSHA256
+	HashKeccak256
+)
+
+// fixedHeaderLen is the number of version 1 header bytes, excluding the
+// digest. Version 2 adds metaLenSize bytes after the digest.
+const (
+	fixedHeaderLen = 5
+	metaLenSize    = 2
+)
+
+var (
+	ErrMalformed          = errors.New("envelope: malformed value")
+	ErrUnsupportedVersion = errors.New("envelope: unsupported version")
+	ErrUnsupported        = errors.New("envelope: unsupported feature")
+	ErrDigestMismatch     = errors.New("envelope: digest mismatch")
+	ErrNoKey              = errors.New("envelope: value is encrypted, no key given")
+	ErrWrongKey           = errors.New("envelope: value is encrypted with another key")
+	ErrDecrypt            = errors.New("envelope: decryption failed")
+)
+
+// Envelope is a parsed stored value.
+type Envelope struct {
+	Version     byte
+	Codec       Codec
+	Compression Compression
+	Encryption  Encryption
+	HashAlg     HashAlg
+	Digest      []byte
+	// Meta holds attributes describing the record, such as lineage links.
+	Meta    map[string]string
+	Payload []byte
+
+	// prefix holds the bytes before the payload of a parsed envelope, which
+	// authenticate an encrypted payload.
+	prefix []byte
+}
+
+// Options control how a value is sealed.
+type Options struct {
+	Codec   Codec
+	HashAlg HashAlg
+	Meta    map[string]string
+	// Compression compresses values longer than CompressAbove bytes, when
+	// that makes them shorter.
+	Compression   Compression
+	CompressAbove int
+	// Key encrypts the payload with AES-GCM when set, after compressing it.
+	// The header and the metadata stay readable, so that records can still
+	// be indexed, and no digest is written, as it would let anyone check
+	// guesses of the value.
+	Key *Key
+}
+
+// Seal wraps value in an envelope and returns its string form.
+func Seal(value []byte, opts Options) (string, error) {
+	env := &Envelope{
+		Version: Version,
+		Codec:   opts.Codec,
+		HashAlg: opts.HashAlg,
+		Meta:    opts.Meta,
+		Payload: value,
+	}
+	if len(env.Meta) == 0 {
+		env.Version = versionNoMeta
+	}
+	if opts.Compression != CompressionNone && len(value) > opts.CompressAbove {
+		compressed, err := compress(opts.Compression, value)
+		if err != nil {
+			return "", err
+		}
+		if len(compressed) < len(value) {
+			env.Compression, env.Payload = opts.Compression, compressed
+		}
+	}
+	if opts.Key != nil {
+		env.Encryption, env.HashAlg = EncryptionAESGCM, HashNone
+		prefix, err := env.marshalPrefix()
+		if err != nil {
+			return "", err
+		}
+		if env.Payload, err = opts.Key.seal(env.Payload, prefix); err != nil {
+			return "", err
+		}
+		return env.String()
+	}
+
+	if opts.HashAlg != HashNone {
+		digest, err := Digest(opts.HashAlg, value)
+		if err != nil {
+			return "", err
+		}
+		env.Digest = digest
+	}
+
+	return env.String()
+}
+
+// String returns the envelope in the form stored on-chain.
+func (e *Envelope) String() (string, error) {
+	if e.Version == 0 {
+		return string(e.Payload), nil
+	}
+	prefix, err := e.marshalPrefix()
+	if err != nil {
+		return "", err
+	}
+	return Prefix + base64.StdEncoding.EncodeToString(append(prefix, e.Payload...)), nil
+}
+
+// marshalPrefix returns the bytes of the envelope before its payload: the
+// version, the header and the metadata.
+func (e *Envelope) marshalPrefix() ([]byte, error) {
+	if e.Version == versionNoMeta && len(e.Meta) > 0 {
+		return nil, fmt.Errorf("%w: version 1 cannot carry metadata", ErrUnsupported)
+	}
+
+	headerLen := fixedHeaderLen + len(e.Digest)
+	var meta []byte
+	if e.Version >= 2 {
+		headerLen += metaLenSize
+		if len(e.Meta) > 0 {
+			var err error
+			if meta, err = json.Marshal(e.Meta); err != nil {
+				return nil, err
+			}
+		}
+		if len(meta) > 0xffff {
+			return nil, fmt.Errorf("%w: metadata too long", ErrMalformed)
+		}
+	}
+	if headerLen > 255 {
+		return nil, fmt.Errorf("%w: digest too long", ErrMalformed)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(e.Version)
+	buf.WriteByte(byte(headerLen))
+	buf.WriteByte(byte(e.Codec))
+	buf.WriteByte(byte(e.Compression))
+	buf.WriteByte(byte(e.Encryption))
+	buf.WriteByte(byte(e.HashAlg))
+	buf.WriteByte(byte(len(e.Digest)))
+	buf.Write(e.Digest)
+	if e.Version >= 2 {
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(len(meta))))
+	}
+	buf.Write(meta)
+	return buf.Bytes(), nil
+}
+
+// Parse decodes the header of a stored value. Values written before
+// envelopes existed are returned as a version 0 text envelope. Parse does
+// not interpret the payload, so values using features this version does not
+// implement can still be inspected.
+func Parse(s string) (*Envelope, error) {
+	if !strings.HasPrefix(s, Prefix) {
+		return &Envelope{Codec: CodecText, Payload: []byte(s)}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(raw) < 2 {
+		return nil, ErrMalformed
+	}
+
+	version := raw[0]
+	if version == 0 || version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	headerLen := int(raw[1])
+	if headerLen < fixedHeaderLen || len(raw) < 2+headerLen {
+		return nil, ErrMalformed
+	}
+	header := raw[2 : 2+headerLen]
+
+	env := &Envelope{
+		Version:     version,
+		Codec:       Codec(header[0]),
+		Compression: Compression(header[1]),
+		Encryption:  Encryption(header[2]),
+		HashAlg:     HashAlg(header[3]),
+	}
+
+	digestLen := int(header[4])
+	if fixedHeaderLen+digestLen > headerLen {
+		return nil, ErrMalformed
+	}
+	if digestLen > 0 {
+		env.Digest = append([]byte(nil), header[fixedHeaderLen:fixedHeaderLen+digestLen]...)
+	}
+	body := raw[2+headerLen:]
+
+	if version >= 2 {
+		offset := fixedHeaderLen + digestLen
+		if offset+metaLenSize > headerLen {
+			return nil, ErrMalformed
+		}
+		metaLen := int(binary.BigEndian.Uint16(header[offset:]))
+		if metaLen > len(body) {
+			return nil, ErrMalformed
+		}
+		if metaLen > 0 {
+			if err := json.Unmarshal(body[:metaLen], &env.Meta); err != nil {
+				return nil, fmt.Errorf("%w: metadata: %v", ErrMalformed, err)
+			}
+		}
+		body = body[metaLen:]
+	}
+	env.Payload = body
+	env.prefix = raw[:len(raw)-len(body)]
+
+	return env, nil
+}
+
+// Open returns the original value carried by the envelope, decompressing it
+// and verifying its digest when one is present. Encrypted envelopes need OpenWith.
+func (e *Envelope) Open() ([]byte, error) {
+	return e.OpenWith()
+}
+
+// OpenWith is Open for envelopes that may be encrypted, decrypting them
+// with the key they were sealed with among keys.
+func (e *Envelope) OpenWith(keys ...*Key) ([]byte, error) {
+	if e.Compression > CompressionZstd {
+		return nil, fmt.Errorf("%w: compression %d", ErrUnsupported, e.Compression)
+	}
+
+	value := e.Payload
+	switch e.Encryption {
+	case EncryptionNone:
+	case EncryptionAESGCM:
+		prefix, err := e.prefix, error(nil)
+		if prefix == nil {
+			// Built by hand rather than parsed
+			if prefix, err = e.marshalPrefix(); err != nil {
+				return nil, err
+			}
+		}
+		if value, err = open(keys, value, prefix); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: encryption %d", ErrUnsupported, e.Encryption)
+	}
+	if e.Compression != CompressionNone {
+		var err error
+		if value, err = decompress(e.Compression, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.HashAlg != HashNone {
+		digest, err := Digest(e.HashAlg, value)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(digest, e.Digest) {
+			return nil, ErrDigestMismatch
+		}
+	}
+
+	return value, nil
+}
+
+// Decode parses and opens a stored value in one step, decrypting it with
+// one of keys if it is encrypted.
+func Decode(s string, keys ...*Key) ([]byte, error) {
+	env, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return env.OpenWith(keys...)
+}
+
+// Digest hashes data with the given algorithm.
+func Digest(alg HashAlg, data []byte) ([]byte, error) {
+	switch alg {
+	case HashSHA256:
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	case HashKeccak256:
+		return crypto.Keccak256(data), nil
+	default:
+		return nil, fmt.Errorf("%w: hash algorithm %d", ErrUnsupported, alg)
+	}
+}
+
+// ParseHashAlg converts a configuration name into a HashAlg.
+func ParseHashAlg(name string) (HashAlg, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return HashNone, nil
+	case "sha256":
+		return HashSHA256, nil
+	case "keccak256":
+		return HashKeccak256, nil
+	default:
+		return HashNone, fmt.Errorf("unknown hash algorithm: %s", name)
+	}
+}
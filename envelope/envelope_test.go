@@ -0,0 +1,187 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/fixtures"
+)
+
+type parsed struct {
+	Name        string            `json:"name"`
+	Version     byte              `json:"version"`
+	Codec       envelope.Codec    `json:"codec"`
+	Compression byte              `json:"compression"`
+	Encryption  byte              `json:"encryption"`
+	HashAlg     byte              `json:"hash_alg"`
+	Digest      string            `json:"digest,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
+	Payload     string            `json:"payload,omitempty"`
+	ParseError  string            `json:"parse_error,omitempty"`
+	Opened      string            `json:"opened,omitempty"`
+	OpenError   string            `json:"open_error,omitempty"`
+}
+
+func TestParseFixtures(t *testing.T) {
+	lines, err := fixtures.Lines("envelopes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []parsed
+	for _, line := range lines {
+		name, value, _ := strings.Cut(line, " ")
+		p := parsed{Name: name}
+		env, err := envelope.Parse(value)
+		if err != nil {
+			p.ParseError = err.Error()
+			got = append(got, p)
+			continue
+		}
+		p.Version = env.Version
+		p.Codec = env.Codec
+		p.Compression = byte(env.Compression)
+		p.Encryption = byte(env.Encryption)
+		p.HashAlg = byte(env.HashAlg)
+		p.Digest = hex.EncodeToString(env.Digest)
+		p.Meta = env.Meta
+		p.Payload = hex.EncodeToString(env.Payload)
+		if opened, err := env.Open(); err != nil {
+			p.OpenError = err.Error()
+		} else {
+			p.Opened = string(opened)
+		}
+		got = append(got, p)
+	}
+	fixtures.GoldenJSON(t, "envelopes", got)
+}
+
+func TestSealRoundTrip(t *testing.T) {
+	for _, opts := range []envelope.Options{
+		{Codec: envelope.CodecText},
+		{Codec: envelope.CodecBytes, HashAlg: envelope.HashSHA256},
+		{Codec: envelope.CodecJSON, HashAlg: envelope.HashKeccak256, Meta: map[string]string{"tag.env": "prod"}},
+	} {
+		value := []byte("round trip")
+		sealed, err := envelope.Seal(value, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := envelope.Parse(sealed)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		again, err := env.String()
+		if err != nil || again != sealed {
+			t.Errorf("%+v: re-encoded %q (%v), want %q", opts, again, err, sealed)
+		}
+		opened, err := env.Open()
+		if err != nil || !bytes.Equal(opened, value) {
+			t.Errorf("%+v: opened %q (%v)", opts, opened, err)
+		}
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	key, err := envelope.DeriveKey([]byte("correct horse battery staple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := envelope.DeriveKey([]byte("correct horse battery staple"))
+	other, _ := envelope.DeriveKey([]byte("another secret"))
+	if key.ID() != again.ID() || key.ID() == other.ID() {
+		t.Fatalf("key IDs %s, %s and %s", key.ID(), again.ID(), other.ID())
+	}
+
+	value := []byte("confidential")
+	sealed, err := envelope.Seal(value, envelope.Options{Codec: envelope.CodecText, HashAlg: envelope.HashSHA256, Meta: map[string]string{"tag.env": "prod"}, Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := envelope.Parse(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Encryption != envelope.EncryptionAESGCM || len(env.Digest) != 0 || env.Meta["tag.env"] != "prod" {
+		t.Errorf("parsed %+v", env)
+	}
+	if bytes.Contains(env.Payload, value) {
+		t.Error("the payload holds the plaintext")
+	}
+
+	if _, err := envelope.Decode(sealed); !errors.Is(err, envelope.ErrNoKey) {
+		t.Errorf("opened without a key: %v", err)
+	}
+	if _, err := envelope.Decode(sealed, other); !errors.Is(err, envelope.ErrWrongKey) {
+		t.Errorf("opened with another key: %v", err)
+	}
+	opened, err := envelope.Decode(sealed, other, key)
+	if err != nil || !bytes.Equal(opened, value) {
+		t.Errorf("opened %q (%v)", opened, err)
+	}
+
+	// The metadata is authenticated with the payload
+	env.Meta["tag.env"] = "dev"
+	tampered, err := env.String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envelope.Decode(tampered, key); !errors.Is(err, envelope.ErrDecrypt) {
+		t.Errorf("opened tampered metadata: %v", err)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	large := []byte(`{"items":[` + strings.Repeat(`{"name":"invoice","amount":100},`, 50) + `{}]}`)
+	key, err := envelope.DeriveKey([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []envelope.Compression{envelope.CompressionGzip, envelope.CompressionZstd} {
+		for _, k := range []*envelope.Key{nil, key} {
+			opts := envelope.Options{Codec: envelope.CodecJSON, HashAlg: envelope.HashSHA256, Compression: c, CompressAbove: 256, Key: k}
+			sealed, err := envelope.Seal(large, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := envelope.Parse(sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if env.Compression != c || len(env.Payload) >= len(large)/4 {
+				t.Errorf("compression %d: got compression %d, %d payload bytes for %d", c, env.Compression, len(env.Payload), len(large))
+			}
+			opened, err := env.OpenWith(key)
+			if err != nil || !bytes.Equal(opened, large) {
+				t.Errorf("compression %d: opened %d bytes (%v)", c, len(opened), err)
+			}
+
+			// Short values are left alone
+			small, err := envelope.Seal([]byte(`{"a":1}`), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if env, err := envelope.Parse(small); err != nil || env.Compression != envelope.CompressionNone {
+				t.Errorf("compression %d: compressed a short value (%v)", c, err)
+			}
+		}
+	}
+}
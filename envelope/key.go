@@ -0,0 +1,120 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeySize is the size of the AES-256 keys of EncryptionAESGCM.
+const KeySize = 32
+
+// keyIDSize is the size of the key ID starting encrypted payloads.
+const keyIDSize = 4
+
+// keyInfo binds derived keys to their use.
+const keyInfo = "contract-storage-eth envelope AES-GCM key v1"
+
+// Key encrypts and decrypts payloads with AES-GCM. Encrypted payloads are
+// the ID of their key, a random nonce and the ciphertext, authenticated
+// together with the bytes of the envelope before them.
+type Key struct {
+	id   [keyIDSize]byte
+	aead cipher.AEAD
+}
+
+// NewKey returns the key of KeySize bytes of raw key material.
+func NewKey(raw []byte) (*Key, error) {
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("envelope: key is %d bytes, want %d", len(raw), KeySize)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	k := &Key{aead: aead}
+	sum := sha256.Sum256(raw)
+	copy(k.id[:], sum[:])
+	return k, nil
+}
+
+// DeriveKey derives a key from a secret of any length with HKDF-SHA256.
+// The secret should be long and random, such as a passphrase of several
+// words: the key is no harder to guess than it.
+func DeriveKey(secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("envelope: empty secret")
+	}
+	raw := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(keyInfo)), raw); err != nil {
+		return nil, err
+	}
+	return NewKey(raw)
+}
+
+// ID identifies the key in the payloads it encrypts, without revealing it.
+func (k *Key) ID() string {
+	return hex.EncodeToString(k.id[:])
+}
+
+func (k *Key) seal(plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte(nil), k.id[:]...), nonce...)
+	return k.aead.Seal(out, nonce, plaintext, additional), nil
+}
+
+// open decrypts a payload with the key among keys that encrypted it
+func open(keys []*Key, payload, additional []byte) ([]byte, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	if len(payload) < keyIDSize {
+		return nil, ErrMalformed
+	}
+	id := payload[:keyIDSize]
+	for _, k := range keys {
+		if !bytes.Equal(k.id[:], id) {
+			continue
+		}
+		rest := payload[keyIDSize:]
+		if len(rest) < k.aead.NonceSize()+k.aead.Overhead() {
+			return nil, ErrMalformed
+		}
+		nonce, ciphertext := rest[:k.aead.NonceSize()], rest[k.aead.NonceSize():]
+		plaintext, err := k.aead.Open(nil, nonce, ciphertext, additional)
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+		return plaintext, nil
+	}
+	return nil, fmt.Errorf("%w %x", ErrWrongKey, id)
+}
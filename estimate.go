@@ -0,0 +1,232 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/ens"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/metrics"
+	"contract-storage-eth/presets"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const estimateUsage = `Usage: contract-storage-eth estimate <command> [flags]
+
+Commands:
+  save      Estimate the gas and fees of saving a record
+`
+
+// newEstimator prices transactions for the configured sender and currency
+func newEstimator(config *Config, client *chain.Client, from string) (*fees.Estimator, error) {
+	minTip, err := minPriorityFee(config)
+	if err != nil {
+		return nil, err
+	}
+	e := &fees.Estimator{Backend: client, MinPriorityFee: minTip, L1: l1Oracle(config, client)}
+	if from != "" {
+		if !common.IsHexAddress(from) {
+			return nil, fmt.Errorf("invalid sender address %q", from)
+		}
+		e.From = common.HexToAddress(from)
+	}
+
+	fiat := config.Estimate.Fiat
+	switch {
+	case fiat.Currency == "":
+	case fiat.EtherPrice > 0:
+		e.Price = fees.StaticPrice{Value: fiat.EtherPrice, Unit: fiat.Currency}
+	default:
+		e.Price = &fees.CoinGecko{URL: fiat.PriceURL, Unit: fiat.Currency, TTL: fiat.CacheTTL, OnCache: func(hit bool) {
+			metrics.CacheLookup("price", hit)
+		}}
+	}
+	return e, nil
+}
+
+// l1Oracle returns the L1 data fee oracle of the configured rollup, nil on
+// other chains
+func l1Oracle(config *Config, client *chain.Client) fees.L1Oracle {
+	switch config.Ethereum.Rollup {
+	case presets.RollupOPStack:
+		return &fees.OPStack{Backend: client}
+	case presets.RollupArbitrum:
+		return &fees.Arbitrum{Backend: client}
+	}
+	return nil
+}
+
+// setFees prices auth at the current fees with the tip raised to the
+// minimum priority fee, leaving the pricing to the node's suggestion when
+// no minimum is configured
+func setFees(ctx context.Context, config *Config, client *chain.Client, auth *bind.TransactOpts) error {
+	minTip, err := minPriorityFee(config)
+	if err != nil || minTip == nil {
+		return err
+	}
+	e := &fees.Estimator{Backend: client, MinPriorityFee: minTip}
+	est, err := e.ForGas(ctx, 0)
+	if err != nil {
+		return err
+	}
+	if est.BaseFee == nil {
+		auth.GasPrice = est.MaxFee
+		return nil
+	}
+	auth.GasPrice = nil
+	auth.GasTipCap, auth.GasFeeCap = est.PriorityFee, est.MaxFee
+	return nil
+}
+
+// saveEstimator returns the estimate function of the API, sealing values as
+// save does before estimating their write
+func saveEstimator(config *Config, e *fees.Estimator) (api.EstimateFunc, error) {
+	address, err := contractAddress(config)
+	if err != nil {
+		return nil, err
+	}
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, req api.EstimateRequest) (*fees.Estimate, error) {
+		codec, err := checkSchema(config, req.Key, req.Field, []byte(req.Value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err)
+		}
+		sealed, err := sealValueAs(config, []byte(req.Value), indexer.TagMeta(req.Tags), codec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err)
+		}
+		return e.EstimateCall(ctx, address, parsedABI, "save", keyNamespace.Key(req.Key), req.Field, offloadPlaceholder(config, sealed))
+	}, nil
+}
+
+func runEstimate(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, estimateUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "save":
+		return runEstimateSave(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown estimate command: %s\n\n%s", args[0], estimateUsage)
+		return exitError(2)
+	}
+}
+
+func runEstimateSave(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("estimate save", flag.ContinueOnError)
+	key := flags.String("key", "", "record key")
+	field := flags.String("field", "", "record field")
+	value := flags.String("value", "", "value to store")
+	valueFile := flags.String("value-file", "", "file whose content to store instead of --value")
+	from := flags.String("from", config.Estimate.From, "sender to estimate the gas for")
+	asJSON := flags.Bool("json", false, "print the estimate as JSON")
+	tags := tagFlag{}
+	flags.Var(tags, "tag", "tag the record with NAME=VALUE (repeatable)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "estimate save: --key is required")
+	}
+	content := []byte(*value)
+	if *valueFile != "" {
+		var err error
+		if content, err = os.ReadFile(*valueFile); err != nil {
+			return fmt.Errorf("Failed to read value file: %w", err)
+		}
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	if ens.IsName(*from) {
+		address, err := resolveAddress(ctx, config, client, *from)
+		if err != nil {
+			return fail(codeInvalidArgument, "Invalid --from: %w", err)
+		}
+		*from = address.Hex()
+	}
+	e, err := newEstimator(config, client, *from)
+	if err != nil {
+		return err
+	}
+	estimate, err := saveEstimator(config, e)
+	if err != nil {
+		return err
+	}
+	est, err := estimate(ctx, api.EstimateRequest{Key: *key, Field: *field, Value: string(content), Tags: tags})
+	if errors.Is(err, api.ErrInvalidRecord) {
+		return fmt.Errorf("Failed to seal value: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to estimate save: %w", err)
+	}
+
+	if output.json {
+		printResult(est)
+		return nil
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(est)
+		return nil
+	}
+	printEstimate(est)
+	return nil
+}
+
+func printEstimate(est *fees.Estimate) {
+	fmt.Printf("Gas:            %d\n", est.Gas)
+	if est.BaseFee != nil {
+		fmt.Printf("Base fee:       %.4f gwei\n", fees.Gwei(est.BaseFee))
+		fmt.Printf("Priority fee:   %.4f gwei\n", fees.Gwei(est.PriorityFee))
+		fmt.Printf("Max fee:        %.4f gwei\n", fees.Gwei(est.MaxFee))
+	} else {
+		fmt.Printf("Gas price:      %.4f gwei\n", fees.Gwei(est.MaxFee))
+	}
+	if est.L1Fee != nil {
+		fmt.Printf("L1 data fee:    %.8f ETH\n", fees.Ether(est.L1Fee))
+	}
+	expected := fmt.Sprintf("%.8f ETH", fees.Ether(est.ExpectedCost))
+	maximum := fmt.Sprintf("%.8f ETH", fees.Ether(est.MaxCost))
+	if est.Fiat != nil {
+		expected += fmt.Sprintf(" (%.4f %s)", est.Fiat.ExpectedCost, est.Fiat.Currency)
+		maximum += fmt.Sprintf(" (%.4f %s)", est.Fiat.MaxCost, est.Fiat.Currency)
+	}
+	fmt.Printf("Expected cost:  %s\n", expected)
+	fmt.Printf("Maximum cost:   %s\n", maximum)
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func runEvents(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("events", flag.ContinueOnError)
+	fromBlock := flags.Uint64("from-block", config.Index.StartBlock, "first block to scan")
+	toBlock := flags.Uint64("to-block", 0, "last block to scan (default latest)")
+	key := flags.String("key", "", "only show events of this key, or of the record with this ID")
+	field := flags.String("field", "", "only show events of this field")
+	raw := flags.Bool("raw", false, "print stored values without opening their envelope")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	var ns recordid.Namespace
+	if recordid.IsID(*key) {
+		id, err := parseChainID(ctx, client, *key)
+		if err != nil {
+			return err
+		}
+		ns, *key = id.Namespace, id.Key
+		if id.Field != "" {
+			*field = id.Field
+		}
+	} else if ns, err = recordNamespace(ctx, config, nil, client); err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	} else if *key != "" {
+		*key = keyNamespace.Key(*key)
+	}
+	address := ns.Contract
+
+	last := *toBlock
+	if last == 0 {
+		if last, err = client.BlockNumber(ctx); err != nil {
+			return fmt.Errorf("Failed to get latest block: %w", err)
+		}
+	}
+	batch := config.Index.BatchSize
+	if batch == 0 {
+		batch = 2000
+	}
+
+	// Query the logs in batches, as providers cap the range of a query
+	found := 0
+	var events []chainEvent
+	for from := *fromBlock; from <= last; from += batch {
+		to := min(from+batch-1, last)
+		q, err := storage.DataSavedQuery(address, from, &to)
+		if err != nil {
+			return fmt.Errorf("Failed to build log query: %w", err)
+		}
+		logs, err := client.FilterLogs(ctx, q)
+		if err != nil {
+			return fmt.Errorf("Failed to query events: %w", err)
+		}
+		for _, l := range logs {
+			ev, err := storage.ParseDataSaved(l)
+			if err != nil {
+				slog.Warn("Skipping undecodable log", "tx_hash", l.TxHash.Hex(), "log_index", l.Index, "error", err)
+				continue
+			}
+			if (*key != "" && ev.Key != *key) || (*field != "" && ev.Field != *field) {
+				continue
+			}
+			value := ev.Value
+			if !*raw {
+				value = decodeValue(value)
+			}
+			found++
+			if output.json {
+				events = append(events, chainEvent{Block: l.BlockNumber, TxHash: l.TxHash, LogIndex: l.Index, ID: ns.ID(ev.Key, ev.Field, 0).String(), Key: ev.Key, Field: ev.Field, Value: value})
+				continue
+			}
+			fmt.Printf("block %d  tx %s  %s  %s\n", l.BlockNumber, l.TxHash.Hex(), ns.ID(ev.Key, ev.Field, 0), value)
+		}
+	}
+	fmt.Printf("%d event(s) in blocks %d-%d\n", found, *fromBlock, last)
+	printResult(eventsResult{FromBlock: *fromBlock, ToBlock: last, Events: append([]chainEvent{}, events...)})
+	return nil
+}
+
+// eventsResult is the result of events in JSON output mode
+type eventsResult struct {
+	FromBlock uint64       `json:"from_block"`
+	ToBlock   uint64       `json:"to_block"`
+	Events    []chainEvent `json:"events"`
+}
+
+type chainEvent struct {
+	Block    uint64      `json:"block"`
+	TxHash   common.Hash `json:"tx_hash"`
+	LogIndex uint        `json:"log_index"`
+	ID       string      `json:"id"`
+	Key      string      `json:"key"`
+	Field    string      `json:"field"`
+	Value    string      `json:"value"`
+	// Removed is set by watch for events a reorg removed
+	Removed bool `json:"removed,omitempty"`
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"contract-storage-eth/export"
+)
+
+func runExport(ctx context.Context, config *Config, args []string) error {
+	csvConfig := config.Export.CSV
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "csv", "output format: csv, jsonl or json")
+	columns := flags.String("columns", "", "CSV columns as SOURCE or HEADER=SOURCE, comma separated (default from export.csv.columns)")
+	delimiter := flags.String("delimiter", csvConfig.Delimiter, "CSV cell separator")
+	quoteAll := flags.Bool("quote-all", csvConfig.QuoteAll, "quote every CSV cell")
+	crlf := flags.Bool("crlf", csvConfig.CRLF, "end CSV rows with CRLF")
+	escapeFormulas := flags.Bool("escape-formulas", csvConfig.EscapeFormulas, "prefix CSV cells that spreadsheets would run as formulas with a quote")
+	noHeader := flags.Bool("no-header", false, "omit the CSV header row")
+	outFile := flags.String("output", "", "write the export to this file instead of stdout")
+	filter := tagFlag{}
+	flags.Var(filter, "tag", "only export records tagged NAME=VALUE (repeatable, all must match)")
+	latest := flags.Bool("latest", false, "only export the current state: the latest value of each key/field, without superseded or deleted ones")
+	noSync := flags.Bool("no-sync", false, "export the local index without syncing it first")
+	allNamespaces := flags.Bool("all-namespaces", false, "export the records of every namespace, not only the selected one")
+	showDeleted := flags.Bool("deleted", false, "also export the records of soft-deleted keys and fields, with their tombstones")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	opts := export.CSVOptions{QuoteAll: *quoteAll, CRLF: *crlf, EscapeFormulas: *escapeFormulas, NoHeader: *noHeader}
+	switch *format {
+	case "csv":
+		var err error
+		if opts.Columns, err = csvColumns(config, *columns); err != nil {
+			return fail(codeInvalidArgument, "Invalid columns: %w", err)
+		}
+		if *delimiter == `\t` {
+			*delimiter = "\t"
+		}
+		if *delimiter != "" {
+			r, size := utf8.DecodeRuneInString(*delimiter)
+			if size != len(*delimiter) {
+				return fail(codeInvalidArgument, "Invalid --delimiter: want a single character, got %v", *delimiter)
+			}
+			opts.Delimiter = r
+		}
+	case "jsonl", "json":
+	default:
+		return fail(codeInvalidArgument, "Invalid --format: want csv, jsonl or json, got %v", *format)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+
+	if opts.Namespace, err = recordNamespace(ctx, config, store, nil); err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+
+	records, err := store.FindByTags(filter)
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	records = scopeRecords(records, *allNamespaces)
+	if !*showDeleted {
+		if records, err = hideDeleted(store, records); err != nil {
+			return fail(codeIndex, "Failed to query index: %w", err)
+		}
+	}
+	if *latest {
+		records = export.Latest(records)
+	}
+
+	// The result object is all JSON output mode writes to stdout, so it
+	// holds the records unless they go to a file
+	if output.json && *outFile == "" {
+		printResult(listResult{Records: recordEvents(opts.Namespace, records)})
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			return fmt.Errorf("Failed to create export: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	switch *format {
+	case "jsonl":
+		err = export.WriteJSONL(w, records, opts.Namespace)
+	case "json":
+		var next uint64
+		if next, err = store.NextBlock(config.Index.StartBlock); err == nil {
+			err = export.WriteJSON(w, records, opts.Namespace, max(next, 1)-1)
+		}
+	default:
+		err = export.WriteCSV(w, records, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write export: %w", err)
+	}
+	if *outFile != "" {
+		fmt.Printf("Exported %d record(s) to %s\n", len(records), *outFile)
+		printResult(exportResult{File: *outFile, Format: *format, Records: len(records)})
+	}
+	return nil
+}
+
+// exportResult is the result of an export to a file in JSON output mode
+type exportResult struct {
+	File    string `json:"file"`
+	Format  string `json:"format"`
+	Records int    `json:"records"`
+}
+
+// csvColumns returns the columns given on the command line, else the
+// configured ones
+func csvColumns(config *Config, spec string) ([]export.Column, error) {
+	if spec != "" {
+		return export.ParseColumns(spec)
+	}
+	var columns []export.Column
+	for _, c := range config.Export.CSV.Columns {
+		header := c.Header
+		if header == "" {
+			header = c.Source
+		}
+		columns = append(columns, export.Column{Header: header, Source: c.Source})
+	}
+	return columns, nil
+}
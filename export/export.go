@@ -0,0 +1,277 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export writes indexed records as CSV, with a configurable
+// column mapping and escaping, as JSON Lines or as a JSON document.
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/tombstone"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Record attributes a column can hold. A column can also hold the value of
+// a tag, with the source "tag.NAME".
+const (
+	SourceID     = "id"
+	SourceKey    = "key"
+	SourceField  = "field"
+	SourceValue  = "value"
+	SourceWriter = "writer"
+	SourceBlock  = "block"
+	SourceTime   = "time"
+	SourceTx     = "tx"
+)
+
+var sources = []string{SourceID, SourceKey, SourceField, SourceValue, SourceWriter, SourceBlock, SourceTime, SourceTx}
+
+// Column maps a CSV column to a record attribute.
+type Column struct {
+	// Header is the name of the column in the header row.
+	Header string
+	// Source is the record attribute, one of the Source constants or
+	// "tag.NAME".
+	Source string
+}
+
+// DefaultColumns returns every record attribute, named after its source.
+func DefaultColumns() []Column {
+	columns := make([]Column, len(sources))
+	for i, source := range sources {
+		columns[i] = Column{Header: source, Source: source}
+	}
+	return columns
+}
+
+// ParseColumns parses a comma separated list of columns, each given as
+// SOURCE or HEADER=SOURCE, such as "Key=key,Anchored at=time,tx".
+func ParseColumns(spec string) ([]Column, error) {
+	var columns []Column
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		header, source, ok := strings.Cut(item, "=")
+		if !ok {
+			source = header
+		}
+		c := Column{Header: strings.TrimSpace(header), Source: strings.TrimSpace(source)}
+		if err := c.check(); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns in %q", spec)
+	}
+	return columns, nil
+}
+
+func (c Column) check() error {
+	if name, ok := strings.CutPrefix(c.Source, indexer.MetaTagPrefix); ok && name != "" {
+		return nil
+	}
+	for _, source := range sources {
+		if c.Source == source {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown column source %q, want one of %s or tag.NAME", c.Source, strings.Join(sources, ", "))
+}
+
+// cell returns the text of column c for r
+func (c Column) cell(r *indexer.Record, ns recordid.Namespace) string {
+	switch c.Source {
+	case SourceID:
+		return ns.ID(r.Key, r.Field, r.Version).String()
+	case SourceKey:
+		return r.Key
+	case SourceField:
+		return r.Field
+	case SourceValue:
+		return r.Value
+	case SourceWriter:
+		return r.Writer.Hex()
+	case SourceBlock:
+		return strconv.FormatUint(r.BlockNumber, 10)
+	case SourceTime:
+		return time.Unix(int64(r.Timestamp), 0).UTC().Format(time.RFC3339)
+	case SourceTx:
+		return r.TxHash.Hex()
+	}
+	return r.Tags[strings.TrimPrefix(c.Source, indexer.MetaTagPrefix)]
+}
+
+// CSVOptions configure WriteCSV.
+type CSVOptions struct {
+	// Namespace is the deployment the record IDs of the id column name.
+	Namespace recordid.Namespace
+	// Columns to write, DefaultColumns when empty.
+	Columns []Column
+	// Delimiter separates the cells, ',' by default.
+	Delimiter rune
+	// QuoteAll quotes every cell instead of only those that need it.
+	QuoteAll bool
+	// CRLF ends rows with \r\n as RFC 4180 does, instead of \n.
+	CRLF bool
+	// EscapeFormulas prefixes cells starting with =, +, -, @, tab or
+	// carriage return with a single quote, so that spreadsheets do not
+	// evaluate values written by third parties as formulas.
+	EscapeFormulas bool
+	// NoHeader omits the header row.
+	NoHeader bool
+}
+
+// WriteCSV writes records as CSV, one row per record.
+func WriteCSV(w io.Writer, records []*indexer.Record, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns()
+	}
+	for _, c := range columns {
+		if err := c.check(); err != nil {
+			return err
+		}
+	}
+	delimiter := opts.Delimiter
+	if delimiter == 0 {
+		delimiter = ','
+	}
+	if delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		return fmt.Errorf("invalid delimiter %q", delimiter)
+	}
+	eol := "\n"
+	if opts.CRLF {
+		eol = "\r\n"
+	}
+
+	out := bufio.NewWriter(w)
+	row := make([]string, len(columns))
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i > 0 {
+				out.WriteRune(delimiter)
+			}
+			out.WriteString(quote(cell, delimiter, opts.QuoteAll))
+		}
+		out.WriteString(eol)
+	}
+
+	if !opts.NoHeader {
+		for i, c := range columns {
+			row[i] = c.Header
+		}
+		writeRow(row)
+	}
+	for _, r := range records {
+		for i, c := range columns {
+			row[i] = c.cell(r, opts.Namespace)
+			if opts.EscapeFormulas {
+				row[i] = escapeFormula(row[i])
+			}
+		}
+		writeRow(row)
+	}
+	return out.Flush()
+}
+
+// quote quotes a cell when forced or when it holds the delimiter, a quote,
+// a line break or leading space
+func quote(cell string, delimiter rune, force bool) string {
+	needed := cell != "" && (cell[0] == ' ' || cell[0] == '\t' || strings.ContainsAny(cell, string(delimiter)+"\"\r\n"))
+	if !force && !needed {
+		return cell
+	}
+	return `"` + strings.ReplaceAll(cell, `"`, `""`) + `"`
+}
+
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// jsonRecord is a record with its ID
+type jsonRecord struct {
+	ID recordid.ID `json:"id"`
+	*indexer.Record
+}
+
+// WriteJSONL writes records as JSON Lines, one record object per line with
+// its record ID in ns.
+func WriteJSONL(w io.Writer, records []*indexer.Record, ns recordid.Namespace) error {
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	for _, r := range records {
+		if err := encoder.Encode(jsonRecord{ID: ns.ID(r.Key, r.Field, r.Version), Record: r}); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// Latest returns the current state of the contract the records were indexed
+// from: the latest version of each key/field pair, in chain order, leaving
+// out the pairs whose latest value is empty or a tombstone, which read as
+// unset.
+func Latest(records []*indexer.Record) []*indexer.Record {
+	latest := map[indexer.Ref]*indexer.Record{}
+	for _, r := range records {
+		ref := indexer.Ref{Key: r.Key, Field: r.Field}
+		if prev, ok := latest[ref]; !ok || r.Version > prev.Version {
+			latest[ref] = r
+		}
+	}
+	var state []*indexer.Record
+	for _, r := range records {
+		if latest[indexer.Ref{Key: r.Key, Field: r.Field}] == r && r.Value != "" && !tombstone.Is(r.Value) {
+			state = append(state, r)
+		}
+	}
+	return state
+}
+
+// Document is the JSON document WriteJSON writes. Block is the last block
+// the records were indexed up to.
+type Document struct {
+	ChainID  uint64         `json:"chain_id"`
+	Contract common.Address `json:"contract"`
+	Block    uint64         `json:"block"`
+	Records  []jsonRecord   `json:"records"`
+}
+
+// WriteJSON writes records as one indented JSON document naming their
+// deployment, each record with its record ID in ns.
+func WriteJSON(w io.Writer, records []*indexer.Record, ns recordid.Namespace, block uint64) error {
+	doc := Document{ChainID: ns.ChainID, Contract: ns.Contract, Block: block, Records: make([]jsonRecord, 0, len(records))}
+	for _, r := range records {
+		doc.Records = append(doc.Records, jsonRecord{ID: ns.ID(r.Key, r.Field, r.Version), Record: r})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/export"
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/tombstone"
+)
+
+// testNamespace is the deployment of the recorded chain, set by
+// recordedRecords
+var testNamespace recordid.Namespace
+
+// recordedRecords returns the records of the recorded chain, plus one whose
+// value needs escaping
+func recordedRecords(t *testing.T) []*indexer.Record {
+	t.Helper()
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1}).Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	records, err := store.FindByTags(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNamespace = recordid.Namespace{ChainID: 1337, Contract: chain.Contract}
+	return append(records, &indexer.Record{Key: "report, \"final\"", Field: " note", Value: "=HYPERLINK(\"http://x\")\nsecond line", Tags: map[string]string{"env": "prod"}})
+}
+
+func TestCSV(t *testing.T) {
+	records := recordedRecords(t)
+	mapped, err := export.ParseColumns("Key=key, Document=field,Anchored at=time,Environment=tag.env,tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opts := range map[string]export.CSVOptions{
+		"default":  {Namespace: testNamespace},
+		"mapped":   {Columns: mapped, EscapeFormulas: true},
+		"escaping": {Columns: mapped[:2], Delimiter: ';', QuoteAll: true, CRLF: true, NoHeader: true},
+	} {
+		var out bytes.Buffer
+		if err := export.WriteCSV(&out, records, opts); err != nil {
+			t.Fatal(err)
+		}
+		fixtures.Golden(t, "csv_"+name, out.Bytes())
+	}
+
+	for _, spec := range []string{"", "key,owner", "File=tag."} {
+		if _, err := export.ParseColumns(spec); err == nil {
+			t.Errorf("columns %q accepted", spec)
+		}
+	}
+	if err := export.WriteCSV(&bytes.Buffer{}, records, export.CSVOptions{Delimiter: '"'}); err == nil {
+		t.Error("quote accepted as delimiter")
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	var out bytes.Buffer
+	if err := export.WriteJSONL(&out, recordedRecords(t), testNamespace); err != nil {
+		t.Fatal(err)
+	}
+	fixtures.Golden(t, "records_jsonl", out.Bytes())
+}
+
+func TestLatest(t *testing.T) {
+	records := recordedRecords(t)
+	records = append(records, &indexer.Record{Key: "plain", Field: "note", Version: 2})
+	records = append(records, &indexer.Record{Key: "doc", Field: "sha256", Version: 2, Value: tombstone.New(time.Now())})
+
+	var got []string
+	for _, r := range export.Latest(records) {
+		got = append(got, r.Ref().String())
+	}
+	// plain#note is deleted by its second, empty version, and doc#sha256
+	// soft-deleted
+	if want := "invoice-42#pdf@2 invoice-43#pdf@1 report, \"final\"# note"; strings.Join(got, " ") != want {
+		t.Errorf("got %q, want %s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := export.WriteJSON(&out, export.Latest(recordedRecords(t)), testNamespace, 4); err != nil {
+		t.Fatal(err)
+	}
+	fixtures.Golden(t, "state_json", out.Bytes())
+}
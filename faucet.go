@@ -0,0 +1,195 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/faucet"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/presets"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func runFaucet(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("faucet", flag.ContinueOnError)
+	address := flags.String("address", "", "account to fund (default the signer address)")
+	minBalance := flags.String("min-balance", config.Faucet.MinBalance, "skip the request when the account holds this much ether already")
+	wait := flags.Bool("wait", config.Faucet.Wait, "wait until the funds arrive")
+	waitTimeout := flags.Duration("wait-timeout", config.Faucet.WaitTimeout, "give up waiting for the funds after this long")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	if preset, ok := presets.ByChainID(chainID.Uint64()); ok && !preset.Testnet {
+		return fmt.Errorf("The node is on %s, faucets only fund testnet accounts", preset.Name)
+	}
+
+	account, err := faucetAccount(ctx, config, client, *address)
+	if err != nil {
+		return err
+	}
+
+	balance, err := client.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to get balance: %w", err)
+	}
+	fmt.Printf("Account %s on %s holds %s ETH\n", account.Hex(), faucet.ChainName(chainID.Uint64()), fees.FormatEther(balance))
+	if *minBalance != "" {
+		minimum, err := fees.ParseEther(*minBalance)
+		if err != nil {
+			return fail(codeInvalidArgument, "Invalid --min-balance: %w", err)
+		}
+		if balance.Cmp(minimum) >= 0 {
+			fmt.Printf("Already funded with at least %s ETH, not requesting more\n", fees.FormatEther(minimum))
+			printResult(faucetResult{Account: account, Balance: fees.FormatEther(balance)})
+			return nil
+		}
+	}
+
+	providers, err := faucetProviders(config)
+	if err != nil {
+		return fail(codeConfig, "Invalid faucet config: %w", err)
+	}
+	result, err := faucet.Request(ctx, &http.Client{Timeout: 30 * time.Second}, providers, chainID.Uint64(), account)
+	if errors.Is(err, faucet.ErrNoProvider) {
+		return fail(codeConfig, "No faucet configured for %s, add one to faucet.providers", faucet.ChainName(chainID.Uint64()))
+	}
+	if err != nil {
+		return fmt.Errorf("Faucet request failed: %w", err)
+	}
+	fmt.Printf("Faucet %s accepted the request", result.Provider)
+	if result.TxHash != (common.Hash{}) {
+		fmt.Printf(", transaction %s", result.TxHash.Hex())
+	}
+	if result.Message != "" {
+		fmt.Printf(": %s", result.Message)
+	}
+	fmt.Println()
+
+	if *wait {
+		if balance, err = waitFunded(ctx, client, account, balance, *waitTimeout); err != nil {
+			return err
+		}
+	}
+	printResult(faucetResult{Account: account, Balance: fees.FormatEther(balance), Request: result})
+	return nil
+}
+
+// faucetResult is the result of faucet in JSON output mode. Request is
+// nil when the account was funded already
+type faucetResult struct {
+	Account common.Address `json:"account"`
+	// Balance is in ether, after the funds arrived when waiting for them
+	Balance string         `json:"balance"`
+	Request *faucet.Result `json:"request,omitempty"`
+}
+
+// faucetAccount returns the account to fund, the signer's unless given
+func faucetAccount(ctx context.Context, config *Config, client *chain.Client, address string) (common.Address, error) {
+	if address != "" {
+		account, err := resolveAddress(ctx, config, client, address)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("invalid --address: %w", err)
+		}
+		return account, nil
+	}
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to load private key (or pass --address): %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	active, err := signers.Active(ctx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("no signer available: %w", err)
+	}
+	return active.Address(), nil
+}
+
+// faucetProviders returns the configured faucets with their header secrets
+// resolved
+func faucetProviders(config *Config) ([]faucet.Provider, error) {
+	var providers []faucet.Provider
+	for _, p := range config.Faucet.Providers {
+		headers := map[string]string{}
+		for name, value := range p.Headers {
+			secret, err := resolveSecret(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: header %s: %w", p.Name, name, err)
+			}
+			headers[name] = secret
+		}
+		providers = append(providers, faucet.Provider{
+			Name:        p.Name,
+			ChainID:     p.ChainID,
+			URL:         p.URL,
+			Method:      p.Method,
+			Body:        p.Body,
+			ContentType: p.ContentType,
+			Headers:     headers,
+		})
+	}
+	return providers, nil
+}
+
+// waitFunded polls the balance until it grows past before, and returns it
+func waitFunded(ctx context.Context, client chain.ChainClient, account common.Address, before *big.Int, timeout time.Duration) (*big.Int, error) {
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fmt.Println("Waiting for the funds to arrive...")
+	for {
+		balance, err := client.BalanceAt(ctx, account, nil)
+		if err == nil && balance.Cmp(before) > 0 {
+			fmt.Printf("Received %s ETH, balance is %s ETH\n", fees.FormatEther(new(big.Int).Sub(balance, before)), fees.FormatEther(balance))
+			return balance, nil
+		}
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to get balance", "account", account.Hex(), "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Funds did not arrive within %s", timeout)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
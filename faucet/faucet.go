@@ -0,0 +1,194 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package faucet requests test ether from testnet faucet APIs, so that CI
+// pipelines can fund their own accounts.
+package faucet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Chain IDs of the public testnets faucets usually serve.
+const (
+	ChainSepolia uint64 = 11155111
+	ChainHolesky uint64 = 17000
+)
+
+// ChainName returns the name of a well-known chain, or its number.
+func ChainName(id uint64) string {
+	switch id {
+	case 1:
+		return "mainnet"
+	case ChainSepolia:
+		return "sepolia"
+	case ChainHolesky:
+		return "holesky"
+	}
+	return strconv.FormatUint(id, 10)
+}
+
+// DefaultBody is the request body sent when a provider sets none.
+const DefaultBody = `{"address": "{address}"}`
+
+// Provider is a faucet API. Faucets differ in their requests, so the
+// placeholders {address} and {chain_id} are replaced in URL and Body.
+type Provider struct {
+	Name    string
+	ChainID uint64
+	URL     string
+	// Method is POST by default.
+	Method string
+	// Body is DefaultBody when empty; it is not sent with GET.
+	Body string
+	// ContentType is application/json by default.
+	ContentType string
+	// Headers are added to the request, such as an API token.
+	Headers map[string]string
+}
+
+// Result is a granted request.
+type Result struct {
+	Provider string `json:"provider"`
+	// TxHash is the funding transaction when the faucet reports it.
+	TxHash common.Hash `json:"tx_hash"`
+	// Message is what the faucet answered, if anything.
+	Message string `json:"message,omitempty"`
+}
+
+// ErrRateLimited is returned when a faucet refuses to pay out again yet.
+var ErrRateLimited = errors.New("faucet: rate limited")
+
+// ErrNoProvider is returned by Request when no provider serves the chain.
+var ErrNoProvider = errors.New("faucet: no provider for this chain")
+
+// maxResponse bounds the faucet responses read.
+const maxResponse = 64 << 10
+
+// Request asks the provider to send test ether to address.
+func (p Provider) Request(ctx context.Context, client *http.Client, address common.Address) (*Result, error) {
+	expand := strings.NewReplacer("{address}", address.Hex(), "{chain_id}", strconv.FormatUint(p.ChainID, 10))
+	method := p.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	var body io.Reader
+	if method != http.MethodGet {
+		text := p.Body
+		if text == "" {
+			text = DefaultBody
+		}
+		body = strings.NewReader(expand.Replace(text))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, expand.Replace(p.URL), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		contentType := p.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("faucet %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return nil, fmt.Errorf("faucet %s: %w", p.Name, err)
+	}
+
+	result := parseResponse(data)
+	result.Provider = p.Name
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w by %s: %s", ErrRateLimited, p.Name, result.Message)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("faucet %s answered %s: %s", p.Name, resp.Status, result.Message)
+	}
+	return result, nil
+}
+
+// parseResponse picks the transaction hash and message out of the common
+// faucet response shapes, falling back to the raw text
+func parseResponse(data []byte) *Result {
+	result := &Result{Message: strings.TrimSpace(string(data))}
+	var fields map[string]interface{}
+	if json.Unmarshal(data, &fields) != nil {
+		return result
+	}
+	// Some faucets wrap the answer in data or result
+	for _, wrapper := range []string{"data", "result"} {
+		if inner, ok := fields[wrapper].(map[string]interface{}); ok {
+			for k, v := range inner {
+				if _, exists := fields[k]; !exists {
+					fields[k] = v
+				}
+			}
+		}
+	}
+	for _, name := range []string{"txHash", "tx_hash", "transactionHash", "hash", "tx"} {
+		if s, ok := fields[name].(string); ok && len(common.FromHex(s)) == common.HashLength {
+			result.TxHash = common.HexToHash(s)
+			break
+		}
+	}
+	for _, name := range []string{"message", "msg", "error", "status"} {
+		if s, ok := fields[name].(string); ok && s != "" {
+			result.Message = s
+			break
+		}
+	}
+	return result
+}
+
+// Request tries the providers serving chainID in order until one grants
+// the request, returning the errors of all of them otherwise.
+func Request(ctx context.Context, client *http.Client, providers []Provider, chainID uint64, address common.Address) (*Result, error) {
+	var errs []error
+	for _, p := range providers {
+		if p.ChainID != chainID {
+			continue
+		}
+		result, err := p.Request(ctx, client, address)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w %s", ErrNoProvider, ChainName(chainID))
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faucet_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contract-storage-eth/faucet"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var address = common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")
+
+const txHash = "0x3456a69e0b911dbe158c61d86b431684f2e65184f92c05a562e8cce1dcd5893f"
+
+func TestRequestFallsBack(t *testing.T) {
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"message": "come back in 24h"}`)
+	}))
+	defer limited.Close()
+
+	var got string
+	granting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = r.Method + " " + r.URL.Path + " " + r.Header.Get("Authorization") + " " + string(body)
+		fmt.Fprintf(w, `{"data": {"txHash": %q}, "message": "sent 0.05 ETH"}`, txHash)
+	}))
+	defer granting.Close()
+
+	providers := []faucet.Provider{
+		{Name: "mainnet", ChainID: 1, URL: granting.URL},
+		{Name: "limited", ChainID: faucet.ChainSepolia, URL: limited.URL},
+		{Name: "ci", ChainID: faucet.ChainSepolia, URL: granting.URL + "/{chain_id}/claim",
+			Body: `{"to": "{address}"}`, Headers: map[string]string{"Authorization": "Bearer t"}},
+	}
+	result, err := faucet.Request(context.Background(), http.DefaultClient, providers, faucet.ChainSepolia, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Provider != "ci" || result.TxHash != common.HexToHash(txHash) || result.Message != "sent 0.05 ETH" {
+		t.Errorf("result %+v", result)
+	}
+	want := `POST /11155111/claim Bearer t {"to": "0x71562b71999873DB5b286dF957af199Ec94617F7"}`
+	if got != want {
+		t.Errorf("request %q\nwant %q", got, want)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+
+	providers := []faucet.Provider{{Name: "limited", ChainID: faucet.ChainHolesky, URL: limited.URL, Method: http.MethodGet}}
+	_, err := faucet.Request(context.Background(), http.DefaultClient, providers, faucet.ChainHolesky, address)
+	if !errors.Is(err, faucet.ErrRateLimited) {
+		t.Errorf("got %v, want ErrRateLimited", err)
+	}
+	_, err = faucet.Request(context.Background(), http.DefaultClient, providers, faucet.ChainSepolia, address)
+	if !errors.Is(err, faucet.ErrNoProvider) {
+		t.Errorf("got %v, want ErrNoProvider", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fees
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BalanceReader reads account balances.
+type BalanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// Buffer is the margin required on top of an estimated cost, covering fee
+// rises while a long run is in progress.
+type Buffer struct {
+	// Percent of the cost added to it.
+	Percent uint64
+	// Minimum is the balance that must be left once everything is paid.
+	Minimum *big.Int
+}
+
+// Required returns cost with the buffer added.
+func (b Buffer) Required(cost *big.Int) *big.Int {
+	required := new(big.Int).Mul(cost, new(big.Int).SetUint64(100+b.Percent))
+	required.Div(required, big.NewInt(100))
+	if b.Minimum != nil {
+		required.Add(required, b.Minimum)
+	}
+	return required
+}
+
+// InsufficientFundsError is returned by CheckBalance when an account cannot
+// pay for what it is about to send.
+type InsufficientFundsError struct {
+	Account  common.Address
+	Balance  *big.Int
+	Cost     *big.Int
+	Required *big.Int
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("insufficient funds: %s holds %s ETH but needs %s ETH (%s ETH estimated plus buffer), short by %s ETH",
+		e.Account.Hex(), FormatEther(e.Balance), FormatEther(e.Required), FormatEther(e.Cost),
+		FormatEther(new(big.Int).Sub(e.Required, e.Balance)))
+}
+
+// CheckBalance returns the balance of account, with an
+// InsufficientFundsError unless it covers cost plus the buffer.
+func CheckBalance(ctx context.Context, backend BalanceReader, account common.Address, cost *big.Int, buffer Buffer) (*big.Int, error) {
+	balance, err := backend.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return nil, err
+	}
+	required := buffer.Required(cost)
+	if balance.Cmp(required) < 0 {
+		return balance, &InsufficientFundsError{Account: account, Balance: balance, Cost: cost, Required: required}
+	}
+	return balance, nil
+}
+
+// ParseEther parses a decimal amount of ether, such as "0.05", into wei.
+func ParseEther(s string) (*big.Int, error) {
+	return parseUnit(s, params.Ether, "ether")
+}
+
+// ParseGwei parses a decimal amount of gwei, such as "1.5", into wei.
+func ParseGwei(s string) (*big.Int, error) {
+	return parseUnit(s, params.GWei, "gwei")
+}
+
+func parseUnit(s string, unit int64, name string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	amount, ok := new(big.Rat).SetString(s)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s amount %q", name, s)
+	}
+	amount.Mul(amount, new(big.Rat).SetInt64(unit))
+	if !amount.IsInt() {
+		return nil, fmt.Errorf("%s amount %q is below one wei", name, s)
+	}
+	return amount.Num(), nil
+}
+
+// FormatEther prints an amount in wei as ether with up to 18 decimals,
+// without trailing zeros.
+func FormatEther(wei *big.Int) string {
+	text := new(big.Rat).SetFrac(wei, big.NewInt(params.Ether)).FloatString(18)
+	text = strings.TrimRight(text, "0")
+	return strings.TrimSuffix(text, ".")
+}
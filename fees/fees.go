@@ -0,0 +1,209 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fees estimates what writing a record costs under the current
+// network conditions, in gas, ether and optionally a fiat currency.
+package fees
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Backend is the subset of the Ethereum client API used for estimates.
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
+// Estimate is the cost of a transaction. Amounts are in wei.
+type Estimate struct {
+	Gas uint64 `json:"gas"`
+	// BaseFee is the base fee of the latest block, nil before London.
+	BaseFee *big.Int `json:"base_fee,omitempty"`
+	// PriorityFee is the suggested tip, or the gas price before London.
+	PriorityFee *big.Int `json:"priority_fee"`
+	// MaxFee is the fee cap sent with the transaction: twice the base fee
+	// plus the tip, so that it stays valid while the base fee rises.
+	MaxFee *big.Int `json:"max_fee"`
+	// ExpectedCost is Gas at the current base fee plus the tip.
+	ExpectedCost *big.Int `json:"expected_cost"`
+	// MaxCost is Gas at MaxFee, what the sender must hold.
+	MaxCost *big.Int `json:"max_cost"`
+	// L1Fee is what a rollup charges for posting the transaction data to
+	// L1, nil on other chains. It is included in both costs.
+	L1Fee *big.Int `json:"l1_fee,omitempty"`
+	// Fiat converts the costs when a price source is configured.
+	Fiat *Fiat `json:"fiat,omitempty"`
+}
+
+// Fiat is an estimate converted to a fiat currency.
+type Fiat struct {
+	Currency     string  `json:"currency"`
+	EtherPrice   float64 `json:"ether_price"`
+	ExpectedCost float64 `json:"expected_cost"`
+	MaxCost      float64 `json:"max_cost"`
+}
+
+// Estimator estimates transactions of a contract.
+type Estimator struct {
+	Backend Backend
+	// From is the sender the gas is estimated for.
+	From common.Address
+	// Price converts costs to fiat, they are left in ether when nil.
+	Price PriceSource
+	// MinPriorityFee raises the suggested tip (or gas price before
+	// London) to at least this amount, on chains whose nodes suggest less
+	// than validators accept.
+	MinPriorityFee *big.Int
+	// L1 prices the L1 data fee of transactions on rollups.
+	L1 L1Oracle
+}
+
+// EstimateCall estimates calling method of the contract at address with
+// args.
+func (e *Estimator) EstimateCall(ctx context.Context, address common.Address, contract abi.ABI, method string, args ...interface{}) (*Estimate, error) {
+	data, err := contract.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return e.EstimateTx(ctx, &address, data)
+}
+
+// EstimateTx estimates a transaction with data to the given address, or a
+// contract creation when to is nil.
+func (e *Estimator) EstimateTx(ctx context.Context, to *common.Address, data []byte) (*Estimate, error) {
+	gas, err := e.Backend.EstimateGas(ctx, ethereum.CallMsg{From: e.From, To: to, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return e.ForTx(ctx, to, data, gas)
+}
+
+// ForTx prices a transaction with data to the given address using gas,
+// including its L1 data fee on rollups.
+func (e *Estimator) ForTx(ctx context.Context, to *common.Address, data []byte, gas uint64) (*Estimate, error) {
+	est, err := e.price(ctx, gas)
+	if err != nil {
+		return nil, err
+	}
+	if e.L1 != nil {
+		tx := types.NewTx(&types.DynamicFeeTx{GasTipCap: est.PriorityFee, GasFeeCap: est.MaxFee, Gas: gas, To: to, Data: data})
+		fee, inGas, err := e.L1.L1Fee(ctx, tx)
+		if err != nil {
+			return nil, fmt.Errorf("L1 data fee: %w", err)
+		}
+		est.L1Fee = fee
+		if !inGas {
+			est.ExpectedCost.Add(est.ExpectedCost, fee)
+			est.MaxCost.Add(est.MaxCost, fee)
+		}
+	}
+	if err := e.convert(ctx, est); err != nil {
+		return nil, err
+	}
+	return est, nil
+}
+
+// ForGas prices the given amount of gas, without the L1 data fee of a
+// transaction.
+func (e *Estimator) ForGas(ctx context.Context, gas uint64) (*Estimate, error) {
+	est, err := e.price(ctx, gas)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.convert(ctx, est); err != nil {
+		return nil, err
+	}
+	return est, nil
+}
+
+// price prices gas at the current fees.
+func (e *Estimator) price(ctx context.Context, gas uint64) (*Estimate, error) {
+	head, err := e.Backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	est := &Estimate{Gas: gas}
+	if head.BaseFee == nil {
+		price, err := e.Backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		price = e.floor(price)
+		est.PriorityFee, est.MaxFee = price, price
+		est.ExpectedCost = new(big.Int).Mul(price, new(big.Int).SetUint64(gas))
+	} else {
+		tip, err := e.Backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tip = e.floor(tip)
+		est.BaseFee = head.BaseFee
+		est.PriorityFee = tip
+		est.MaxFee = new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		expected := new(big.Int).Add(head.BaseFee, tip)
+		est.ExpectedCost = expected.Mul(expected, new(big.Int).SetUint64(gas))
+	}
+	est.MaxCost = new(big.Int).Mul(est.MaxFee, new(big.Int).SetUint64(gas))
+	return est, nil
+}
+
+// convert adds the fiat costs when a price source is configured.
+func (e *Estimator) convert(ctx context.Context, est *Estimate) error {
+	if e.Price == nil {
+		return nil
+	}
+	price, err := e.Price.Price(ctx)
+	if err != nil {
+		return err
+	}
+	est.Fiat = &Fiat{
+		Currency:     e.Price.Currency(),
+		EtherPrice:   price,
+		ExpectedCost: Ether(est.ExpectedCost) * price,
+		MaxCost:      Ether(est.MaxCost) * price,
+	}
+	return nil
+}
+
+// floor raises fee to MinPriorityFee.
+func (e *Estimator) floor(fee *big.Int) *big.Int {
+	if e.MinPriorityFee != nil && fee.Cmp(e.MinPriorityFee) < 0 {
+		return new(big.Int).Set(e.MinPriorityFee)
+	}
+	return fee
+}
+
+// Ether converts an amount in wei to ether.
+func Ether(wei *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
+	return f
+}
+
+// Gwei converts an amount in wei to gwei.
+func Gwei(wei *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei)).Float64()
+	return f
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fees_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"contract-storage-eth/fees"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// backend answers fixed fees, with no base fee when baseFee is nil
+type backend struct {
+	baseFee *big.Int
+	tip     *big.Int
+	gas     uint64
+	msg     ethereum.CallMsg
+	// l1 answers the calls of the L1 fee oracles
+	l1   []byte
+	call ethereum.CallMsg
+}
+
+func (b *backend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.call = msg
+	return b.l1, nil
+}
+
+func (b *backend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{BaseFee: b.baseFee}, nil
+}
+
+func (b *backend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return b.tip, nil
+}
+
+func (b *backend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.tip, nil
+}
+
+func (b *backend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	b.msg = msg
+	return b.gas, nil
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei))
+}
+
+func TestEstimateLondon(t *testing.T) {
+	b := &backend{baseFee: gwei(10), tip: gwei(2), gas: 50000}
+	e := &fees.Estimator{Backend: b, Price: fees.StaticPrice{Value: 2000, Unit: "USD"}}
+	est, err := e.EstimateTx(context.Background(), nil, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if est.Gas != 50000 || len(b.msg.Data) != 1 {
+		t.Errorf("gas %d for %x", est.Gas, b.msg.Data)
+	}
+	if est.MaxFee.Cmp(gwei(22)) != 0 {
+		t.Errorf("max fee %s, want 22 gwei", est.MaxFee)
+	}
+	// 50000 gas at 12 gwei is 0.0006 ether, 1.2 USD
+	if est.ExpectedCost.Cmp(gwei(600000)) != 0 || est.MaxCost.Cmp(gwei(1100000)) != 0 {
+		t.Errorf("costs %s and %s", est.ExpectedCost, est.MaxCost)
+	}
+	if est.Fiat == nil || est.Fiat.Currency != "USD" || math.Abs(est.Fiat.ExpectedCost-1.2) > 1e-9 || math.Abs(est.Fiat.MaxCost-2.2) > 1e-9 {
+		t.Errorf("fiat %+v", est.Fiat)
+	}
+}
+
+func TestEstimateLegacy(t *testing.T) {
+	e := &fees.Estimator{Backend: &backend{tip: gwei(5), gas: 21000}}
+	est, err := e.ForGas(context.Background(), 21000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.BaseFee != nil || est.Fiat != nil {
+		t.Errorf("base fee %v, fiat %v", est.BaseFee, est.Fiat)
+	}
+	if est.ExpectedCost.Cmp(est.MaxCost) != 0 || est.MaxCost.Cmp(gwei(105000)) != 0 {
+		t.Errorf("costs %s and %s", est.ExpectedCost, est.MaxCost)
+	}
+}
+
+func TestEstimateMinPriorityFee(t *testing.T) {
+	e := &fees.Estimator{Backend: &backend{baseFee: gwei(10), tip: gwei(1)}, MinPriorityFee: gwei(30)}
+	est, err := e.ForGas(context.Background(), 21000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.PriorityFee.Cmp(gwei(30)) != 0 || est.MaxFee.Cmp(gwei(50)) != 0 {
+		t.Errorf("tip %s, max fee %s, want 30 and 50 gwei", est.PriorityFee, est.MaxFee)
+	}
+}
+
+// words ABI-encodes unsigned integers
+func words(values ...*big.Int) []byte {
+	var out []byte
+	for _, v := range values {
+		out = append(out, common.LeftPadBytes(v.Bytes(), 32)...)
+	}
+	return out
+}
+
+func TestEstimateL1Fee(t *testing.T) {
+	address := common.HexToAddress("0x3A220f351252089D385b29beca14e27F204c296A")
+
+	// OP-stack chains charge the L1 fee on top of the L2 gas
+	b := &backend{baseFee: gwei(1), tip: gwei(1), gas: 50000, l1: words(gwei(40000))}
+	est, err := (&fees.Estimator{Backend: b, L1: &fees.OPStack{Backend: b}}).EstimateTx(context.Background(), &address, []byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *b.call.To != fees.GasPriceOracle {
+		t.Errorf("called %s", b.call.To.Hex())
+	}
+	if est.L1Fee.Cmp(gwei(40000)) != 0 || est.ExpectedCost.Cmp(gwei(140000)) != 0 || est.MaxCost.Cmp(gwei(190000)) != 0 {
+		t.Errorf("L1 fee %s, costs %s and %s", est.L1Fee, est.ExpectedCost, est.MaxCost)
+	}
+
+	// Arbitrum charges it as gas, which the estimate already holds
+	b = &backend{baseFee: gwei(1), tip: big.NewInt(0), gas: 50000, l1: words(big.NewInt(20000), gwei(1), gwei(30))}
+	est, err = (&fees.Estimator{Backend: b, L1: &fees.Arbitrum{Backend: b}}).EstimateTx(context.Background(), &address, []byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *b.call.To != fees.NodeInterface {
+		t.Errorf("called %s", b.call.To.Hex())
+	}
+	if est.L1Fee.Cmp(gwei(20000)) != 0 || est.ExpectedCost.Cmp(gwei(50000)) != 0 {
+		t.Errorf("L1 fee %s, expected cost %s", est.L1Fee, est.ExpectedCost)
+	}
+}
+
+func TestCoinGeckoCaches(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Query().Get("ids") != "ethereum" || r.URL.Query().Get("vs_currencies") != "eur" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"ethereum":{"eur":3012.5}}`)
+	}))
+	defer server.Close()
+
+	source := &fees.CoinGecko{URL: server.URL, Unit: "EUR"}
+	for i := 0; i < 2; i++ {
+		price, err := source.Price(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if price != 3012.5 {
+			t.Errorf("price %v", price)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d requests, want the price cached", calls.Load())
+	}
+	if source.Currency() != "EUR" {
+		t.Errorf("currency %q", source.Currency())
+	}
+}
+
+type balances map[common.Address]*big.Int
+
+func (b balances) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return b[account], nil
+}
+
+func TestCheckBalance(t *testing.T) {
+	account := common.HexToAddress("0x1")
+	minimum, err := fees.ParseEther("0.01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := fees.Buffer{Percent: 20, Minimum: minimum}
+	cost := gwei(50_000_000) // 0.05 ether, 0.07 with the buffer
+
+	if _, err := fees.CheckBalance(context.Background(), balances{account: gwei(70_000_000)}, account, cost, buffer); err != nil {
+		t.Errorf("exact balance: %v", err)
+	}
+
+	_, err = fees.CheckBalance(context.Background(), balances{account: gwei(69_000_000)}, account, cost, buffer)
+	var short *fees.InsufficientFundsError
+	if !errors.As(err, &short) {
+		t.Fatalf("got %v, want InsufficientFundsError", err)
+	}
+	want := "insufficient funds: 0x0000000000000000000000000000000000000001 holds 0.069 ETH but needs 0.07 ETH (0.05 ETH estimated plus buffer), short by 0.001 ETH"
+	if err.Error() != want {
+		t.Errorf("got %q\nwant %q", err, want)
+	}
+}
+
+func TestParseEther(t *testing.T) {
+	for _, s := range []string{"0", "1", "0.000000000000000001", "12.5"} {
+		wei, err := fees.ParseEther(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if got := fees.FormatEther(wei); got != s {
+			t.Errorf("%s formats back as %s", s, got)
+		}
+	}
+	if wei, err := fees.ParseGwei("0.1"); err != nil || wei.Int64() != params.GWei/10 {
+		t.Errorf("0.1 gwei parsed as %v, %v", wei, err)
+	}
+	for _, s := range []string{"", "-1", "abc", "0.0000000000000000001"} {
+		if _, err := fees.ParseEther(s); err == nil {
+			t.Errorf("%q parsed", s)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fees
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// L1Oracle prices the L1 data fee of rollup transactions.
+type L1Oracle interface {
+	// L1Fee returns the L1 data fee of the unsigned transaction tx, and
+	// whether the chain charges it as part of the gas of tx, as Arbitrum
+	// does, rather than on top of it, as OP-stack chains do.
+	L1Fee(ctx context.Context, tx *types.Transaction) (fee *big.Int, inGas bool, err error)
+}
+
+// Caller runs read-only contract calls.
+type Caller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Addresses of the fee contracts of the rollups.
+var (
+	// GasPriceOracle is the predeploy of OP-stack chains pricing L1 data.
+	GasPriceOracle = common.HexToAddress("0x420000000000000000000000000000000000000F")
+	// NodeInterface is the virtual contract of Arbitrum nodes estimating
+	// the L1 component of transactions.
+	NodeInterface = common.HexToAddress("0x00000000000000000000000000000000000000C8")
+)
+
+const l1ABI = `[
+	{"type":"function","name":"getL1Fee","stateMutability":"view",
+	 "inputs":[{"name":"_data","type":"bytes"}],
+	 "outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"gasEstimateL1Component","stateMutability":"payable",
+	 "inputs":[{"name":"to","type":"address"},{"name":"contractCreation","type":"bool"},{"name":"data","type":"bytes"}],
+	 "outputs":[{"name":"gasEstimateForL1","type":"uint64"},{"name":"baseFee","type":"uint256"},{"name":"l1BaseFeeEstimate","type":"uint256"}]}
+]`
+
+// call calls method of the contract at address with args and unpacks its
+// outputs.
+func call(ctx context.Context, backend Caller, address common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	parsed, err := abi.JSON(strings.NewReader(l1ABI))
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := backend.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Unpack(method, out)
+}
+
+// OPStack prices L1 data with the GasPriceOracle of OP-stack chains such
+// as Optimism and Base, which charge it on top of the L2 gas.
+type OPStack struct {
+	Backend Caller
+}
+
+// L1Fee implements L1Oracle. The oracle accounts for the signature the
+// unsigned transaction still lacks.
+func (o *OPStack) L1Fee(ctx context.Context, tx *types.Transaction) (*big.Int, bool, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, false, err
+	}
+	out, err := call(ctx, o.Backend, GasPriceOracle, "getL1Fee", raw)
+	if err != nil {
+		return nil, false, err
+	}
+	return out[0].(*big.Int), false, nil
+}
+
+// Arbitrum prices L1 data with the NodeInterface of Arbitrum chains, which
+// charge it as extra L2 gas that gas estimates already include.
+type Arbitrum struct {
+	Backend Caller
+}
+
+// L1Fee implements L1Oracle.
+func (a *Arbitrum) L1Fee(ctx context.Context, tx *types.Transaction) (*big.Int, bool, error) {
+	var to common.Address
+	if tx.To() != nil {
+		to = *tx.To()
+	}
+	out, err := call(ctx, a.Backend, NodeInterface, "gasEstimateL1Component", to, tx.To() == nil, tx.Data())
+	if err != nil {
+		return nil, true, err
+	}
+	gas, baseFee := out[0].(uint64), out[1].(*big.Int)
+	return new(big.Int).Mul(new(big.Int).SetUint64(gas), baseFee), true, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PriceSource returns the price of one ether in a fiat currency.
+type PriceSource interface {
+	Price(ctx context.Context) (float64, error)
+	Currency() string
+}
+
+// StaticPrice is a fixed ether price.
+type StaticPrice struct {
+	Value float64
+	Unit  string
+}
+
+// Price returns the fixed price.
+func (p StaticPrice) Price(ctx context.Context) (float64, error) {
+	return p.Value, nil
+}
+
+// Currency returns the currency of the price.
+func (p StaticPrice) Currency() string {
+	return p.Unit
+}
+
+// DefaultCoinGeckoURL is the public CoinGecko simple price endpoint.
+const DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3/simple/price"
+
+// CoinGecko reads the ether price from the CoinGecko simple price API,
+// caching it for TTL so that estimates do not hit the rate limit.
+type CoinGecko struct {
+	// URL of the simple price endpoint, DefaultCoinGeckoURL when empty.
+	URL string
+	// Unit is the currency code, such as "usd".
+	Unit string
+	// TTL is how long a price is reused, one minute by default.
+	TTL        time.Duration
+	HTTPClient *http.Client
+	// OnCache is called on each lookup with whether the cached price was
+	// fresh.
+	OnCache func(hit bool)
+
+	mu      sync.Mutex
+	price   float64
+	fetched time.Time
+}
+
+// Price returns the cached price, fetching it when stale.
+func (c *CoinGecko) Price(ctx context.Context) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	fresh := !c.fetched.IsZero() && time.Since(c.fetched) < ttl
+	if c.OnCache != nil {
+		c.OnCache(fresh)
+	}
+	if fresh {
+		return c.price, nil
+	}
+
+	endpoint := c.URL
+	if endpoint == "" {
+		endpoint = DefaultCoinGeckoURL
+	}
+	currency := strings.ToLower(c.Unit)
+	query := url.Values{"ids": {"ethereum"}, "vs_currencies": {currency}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("price: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price: %s answered %s", endpoint, resp.Status)
+	}
+	var prices map[string]map[string]float64
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&prices); err != nil {
+		return 0, fmt.Errorf("price: invalid response: %w", err)
+	}
+	price, ok := prices["ethereum"][currency]
+	if !ok {
+		return 0, fmt.Errorf("price: no ether price in %s", currency)
+	}
+	c.price, c.fetched = price, time.Now()
+	return price, nil
+}
+
+// Currency returns the currency code in upper case.
+func (c *CoinGecko) Currency() string {
+	return strings.ToUpper(c.Unit)
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+)
+
+func runFind(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("find", flag.ContinueOnError)
+	valueFile := flags.String("value-file", "", "file whose content to look up")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *valueFile == "" {
+		return fail(codeInvalidArgument, "find: --value-file is required")
+	}
+	content, err := os.ReadFile(*valueFile)
+	if err != nil {
+		return fmt.Errorf("Failed to read value file: %w", err)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+
+	// Look the file up under every supported content hash
+	hashes := indexer.ContentHashes(content)
+	records, err := findByContent(store, content)
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+
+	if output.json {
+		printResult(findResult{File: *valueFile, SHA256: hex.EncodeToString(hashes[0]), Keccak256: hex.EncodeToString(hashes[1]), Records: recordEvents(ns, records)})
+		return nil
+	}
+	fmt.Printf("File: %s\n", *valueFile)
+	fmt.Printf("SHA-256: %s\n", hex.EncodeToString(hashes[0]))
+	fmt.Printf("Keccak-256: %s\n", hex.EncodeToString(hashes[1]))
+	if len(records) == 0 {
+		fmt.Println("No records anchor this content")
+		return nil
+	}
+
+	fmt.Printf("Found %d record(s) anchoring this content:\n", len(records))
+	for _, r := range records {
+		fmt.Printf("  %s  Key: %s, Field: %s, Block: %d, Transaction: %s\n", recordID(ns, r), r.Key, r.Field, r.BlockNumber, r.TxHash.Hex())
+	}
+	return nil
+}
+
+// findResult is the result of find in JSON output mode
+type findResult struct {
+	File      string      `json:"file"`
+	SHA256    string      `json:"sha256"`
+	Keccak256 string      `json:"keccak256"`
+	Records   []api.Event `json:"records"`
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixtures ships recorded chain data (blocks, transactions,
+// receipts and event logs of a storage contract), ABI variants and
+// malformed inputs, so that the decoding, indexing and export code can be
+// tested without a live chain.
+//
+// The chain snapshot is written by gen.go (`go generate ./fixtures`).
+// Tests compare their output with golden files in their own testdata
+// directory; run `go test ./... -update` to rewrite them after an
+// intended change, and review the diff.
+package fixtures
+
+//go:generate go run gen.go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+//go:embed testdata
+var files embed.FS
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// Read returns a fixture file, relative to the testdata directory.
+func Read(name string) ([]byte, error) {
+	return files.ReadFile("testdata/" + name)
+}
+
+// ABI returns an ABI variant of the storage contract: "v1" is the contract
+// before get was added, "indexed" a fork declaring the DataSaved key as an
+// indexed topic, which changes the event data layout but not its id.
+func ABI(name string) (string, error) {
+	data, err := Read("abi/" + name + ".json")
+	return string(data), err
+}
+
+// Lines returns the non-empty lines of a fixture file that are not
+// # comments.
+func Lines(name string) ([]string, error) {
+	data, err := Read(name)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// Logs returns a named set of event logs, such as "malformed_logs".
+func Logs(name string) ([]types.Log, error) {
+	data, err := Read(name + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var logs []types.Log
+	return logs, json.Unmarshal(data, &logs)
+}
+
+// Reverts returns named eth_call revert data, such as a valid
+// OffchainLookup and truncated variants of it.
+func Reverts() (map[string]hexutil.Bytes, error) {
+	data, err := Read("reverts.json")
+	if err != nil {
+		return nil, err
+	}
+	var reverts map[string]hexutil.Bytes
+	return reverts, json.Unmarshal(data, &reverts)
+}
+
+// Golden compares got with testdata/<name>.golden in the directory of the
+// calling test, rewriting the file instead when -update is given.
+func Golden(tb testing.TB, name string, got []byte) {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("%s differs from the golden file (run with -update to accept):\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// GoldenJSON is Golden for the indented JSON encoding of v.
+func GoldenJSON(tb testing.TB, name string, v interface{}) {
+	tb.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	Golden(tb, name, append(got, '\n'))
+}
+
+// Block is a recorded block.
+type Block struct {
+	Header       *types.Header    `json:"header"`
+	Transactions []hexutil.Bytes  `json:"transactions"`
+	Receipts     []*types.Receipt `json:"receipts"`
+}
+
+// Chain is a recorded chain serving the subset of the Ethereum client API
+// used by the indexer.
+type Chain struct {
+	// Contract is the storage contract deployed in the first block.
+	Contract common.Address `json:"contract"`
+	// ChainID is the chain id the transactions are signed for.
+	ChainID *big.Int `json:"chain_id"`
+	Blocks  []*Block `json:"blocks"`
+
+	txs      map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+// LoadChain loads the recorded chain written by gen.go.
+func LoadChain() (*Chain, error) {
+	data, err := Read("chain.json")
+	if err != nil {
+		return nil, err
+	}
+	c := &Chain{txs: map[common.Hash]*types.Transaction{}, receipts: map[common.Hash]*types.Receipt{}}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	for _, b := range c.Blocks {
+		for i, raw := range b.Transactions {
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(raw); err != nil {
+				return nil, err
+			}
+			c.txs[tx.Hash()] = tx
+			c.receipts[tx.Hash()] = b.Receipts[i]
+		}
+	}
+	return c, nil
+}
+
+func (c *Chain) block(number *big.Int) (*Block, error) {
+	if number == nil {
+		return c.Blocks[len(c.Blocks)-1], nil
+	}
+	for _, b := range c.Blocks {
+		if b.Header.Number.Cmp(number) == 0 {
+			return b, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+// BlockNumber returns the number of the last recorded block.
+func (c *Chain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.Blocks[len(c.Blocks)-1].Header.Number.Uint64(), nil
+}
+
+// HeaderByNumber returns a recorded header, the latest one for nil.
+func (c *Chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b, err := c.block(number)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header, nil
+}
+
+// BlockByNumber returns a recorded block with its transactions.
+func (c *Chain) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	b, err := c.block(number)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*types.Transaction, 0, len(b.Transactions))
+	for _, r := range b.Receipts {
+		txs = append(txs, c.txs[r.TxHash])
+	}
+	return types.NewBlockWithHeader(b.Header).WithBody(types.Body{Transactions: txs}), nil
+}
+
+// TransactionByHash returns a recorded transaction.
+func (c *Chain) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	tx, ok := c.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return tx, false, nil
+}
+
+// TransactionReceipt returns a recorded receipt.
+func (c *Chain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	r, ok := c.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return r, nil
+}
+
+// FilterLogs returns the recorded logs matching q. Block hash queries are
+// not supported.
+func (c *Chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, b := range c.Blocks {
+		n := b.Header.Number
+		if (q.FromBlock != nil && n.Cmp(q.FromBlock) < 0) || (q.ToBlock != nil && n.Cmp(q.ToBlock) > 0) {
+			continue
+		}
+		for _, r := range b.Receipts {
+			for _, l := range r.Logs {
+				if matches(q, l) {
+					logs = append(logs, *l)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+func matches(q ethereum.FilterQuery, l *types.Log) bool {
+	if len(q.Addresses) > 0 {
+		found := false
+		for _, a := range q.Addresses {
+			found = found || a == l.Address
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(l.Topics) {
+			return false
+		}
+		found := false
+		for _, t := range alternatives {
+			found = found || t == l.Topics[i]
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SubscribeFilterLogs is not supported by recorded chains.
+func (c *Chain) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, ethereum.NotFound
+}
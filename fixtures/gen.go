@@ -0,0 +1,325 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build ignore
+
+// gen writes the recorded chain and revert fixtures. The output is
+// deterministic: keys are fixed and signatures are RFC 6979 nonces.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// Geth dev mode account, also used in the README
+	deployerKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+	// A second writer sending legacy EIP-155 transactions
+	writerKey = "8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a"
+
+	startTime = 1735689600 // 2025-01-01T00:00:00Z
+)
+
+var chainID = big.NewInt(1337)
+
+type block struct {
+	Header       *types.Header    `json:"header"`
+	Transactions []hexutil.Bytes  `json:"transactions"`
+	Receipts     []*types.Receipt `json:"receipts"`
+}
+
+type chain struct {
+	Contract common.Address `json:"contract"`
+	ChainID  *big.Int       `json:"chain_id"`
+	Blocks   []*block       `json:"blocks"`
+}
+
+type sender struct {
+	key    string
+	nonce  uint64
+	legacy bool
+}
+
+// call is a transaction of a block and the logs it emits
+type call struct {
+	from   *sender
+	to     *common.Address
+	data   []byte
+	failed bool
+	logs   []*types.Log
+}
+
+func main() {
+	parsed, err := storage.ABI()
+	check(err)
+	event := parsed.Events["DataSaved"]
+
+	deployer := &sender{key: deployerKey}
+	writer := &sender{key: writerKey, legacy: true}
+	contract := crypto.CreateAddress(address(deployer), 0)
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+
+	save := func(from *sender, key, field, value string) call {
+		data, err := parsed.Pack("save", key, field, value)
+		check(err)
+		return call{from: from, to: &contract, data: data, logs: []*types.Log{dataSaved(event, contract, key, field, value)}}
+	}
+	seal := func(value string, alg envelope.HashAlg, meta map[string]string) string {
+		s, err := envelope.Seal([]byte(value), envelope.Options{Codec: envelope.CodecText, HashAlg: alg, Meta: meta})
+		check(err)
+		return s
+	}
+
+	docHash := sha256.Sum256([]byte("anchored document"))
+	anchor := save(writer, "doc", "sha256", hex.EncodeToString(docHash[:]))
+	// Events the indexer must skip: same event from another contract, and
+	// another event of the contract
+	anchor.logs = append(anchor.logs,
+		dataSaved(event, other, "foreign", "field", "not ours"),
+		&types.Log{Address: contract, Topics: []common.Hash{crypto.Keccak256Hash([]byte("Upgraded(address)")), common.BytesToHash(other.Bytes())}},
+	)
+
+	blocks := []struct {
+		time  uint64
+		calls []call
+	}{
+		{startTime, []call{{from: deployer, data: []byte{0x60, 0x80, 0x60, 0x40, 0x52}}}},
+		{startTime + 600, []call{
+			save(deployer, "invoice-42", "pdf", seal("invoice 42 v1", envelope.HashSHA256, nil)),
+			save(writer, "plain", "note", "legacy plain value"),
+		}},
+		{startTime + 1200, []call{
+			save(deployer, "invoice-42", "pdf", seal("invoice 42 v2", envelope.HashSHA256, map[string]string{
+				"supersedes": "invoice-42#pdf@1",
+				"tag.env":    "prod",
+				"tag.team":   "billing",
+			})),
+		}},
+		{startTime + 3660, []call{{from: writer, to: &contract, data: mustPack(parsed, "save", "paused", "x", "y"), failed: true}}},
+		{startTime + 3900, []call{anchor}},
+		{startTime + 90000, []call{
+			save(deployer, "invoice-43", "pdf", seal("invoice 43 v1", envelope.HashKeccak256, map[string]string{"tag.env": "staging"})),
+		}},
+	}
+
+	c := &chain{Contract: contract, ChainID: chainID}
+	parent := common.Hash{}
+	for i, b := range blocks {
+		number := big.NewInt(int64(i + 1))
+		var txs []*types.Transaction
+		var receipts []*types.Receipt
+		var cumulative uint64
+		logIndex := uint(0)
+		for txIndex, call := range b.calls {
+			tx := sign(call.from, call.to, call.data)
+			gas := 21000 + 16*uint64(len(call.data))
+			cumulative += gas
+			receipt := &types.Receipt{
+				Type:              tx.Type(),
+				Status:            types.ReceiptStatusSuccessful,
+				CumulativeGasUsed: cumulative,
+				TxHash:            tx.Hash(),
+				GasUsed:           gas,
+				EffectiveGasPrice: big.NewInt(params.GWei),
+				BlockNumber:       number,
+				TransactionIndex:  uint(txIndex),
+				Logs:              []*types.Log{},
+			}
+			if call.to == nil {
+				receipt.ContractAddress = contract
+			}
+			if call.failed {
+				receipt.Status = types.ReceiptStatusFailed
+			} else {
+				for _, l := range call.logs {
+					l.BlockNumber = number.Uint64()
+					l.TxHash = tx.Hash()
+					l.TxIndex = uint(txIndex)
+					l.Index = logIndex
+					logIndex++
+					receipt.Logs = append(receipt.Logs, l)
+				}
+			}
+			receipt.Bloom = types.CreateBloom(receipt)
+			txs = append(txs, tx)
+			receipts = append(receipts, receipt)
+		}
+
+		header := &types.Header{
+			ParentHash:  parent,
+			UncleHash:   types.EmptyUncleHash,
+			Coinbase:    common.Address{},
+			Root:        types.EmptyRootHash,
+			TxHash:      types.DeriveSha(types.Transactions(txs), trie.NewStackTrie(nil)),
+			ReceiptHash: types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil)),
+			Bloom:       types.MergeBloom(receipts),
+			Difficulty:  big.NewInt(0),
+			Number:      number,
+			GasLimit:    30_000_000,
+			GasUsed:     cumulative,
+			Time:        b.time,
+			Extra:       []byte{},
+			BaseFee:     big.NewInt(params.GWei),
+		}
+		hash := header.Hash()
+		for _, r := range receipts {
+			r.BlockHash = hash
+			for _, l := range r.Logs {
+				l.BlockHash = hash
+			}
+		}
+		parent = hash
+
+		fb := &block{Header: header, Receipts: receipts}
+		for _, tx := range txs {
+			raw, err := tx.MarshalBinary()
+			check(err)
+			fb.Transactions = append(fb.Transactions, raw)
+		}
+		c.Blocks = append(c.Blocks, fb)
+	}
+	write("testdata/chain.json", c)
+	write("testdata/reverts.json", reverts(contract))
+	write("testdata/malformed_logs.json", malformedLogs(event, contract))
+}
+
+// malformedLogs builds logs of the contract that are not valid DataSaved
+// events, although some carry its topic
+func malformedLogs(event abi.Event, contract common.Address) []*types.Log {
+	valid := dataSaved(event, contract, "key", "field", "value")
+
+	// The indexed variant hashes the key into a topic and leaves two
+	// strings in the data
+	indexedData, err := abi.Arguments{event.Inputs[1], event.Inputs[2]}.Pack("field", "value")
+	check(err)
+
+	// Offsets of the three strings pointing past the end of the data
+	badOffsets := make([]byte, 96)
+	for i := 0; i < 3; i++ {
+		big.NewInt(4096).FillBytes(badOffsets[i*32 : (i+1)*32])
+	}
+
+	logs := []*types.Log{
+		{Topics: []common.Hash{}},
+		{Topics: []common.Hash{crypto.Keccak256Hash([]byte("DataSaved(string,string)"))}, Data: valid.Data},
+		{Topics: []common.Hash{event.ID}},
+		{Topics: []common.Hash{event.ID}, Data: valid.Data[:64]},
+		{Topics: []common.Hash{event.ID}, Data: badOffsets},
+		{Topics: []common.Hash{event.ID, crypto.Keccak256Hash([]byte("key"))}, Data: indexedData},
+	}
+	for i, l := range logs {
+		l.Address = contract
+		l.BlockNumber = 1
+		l.Index = uint(i)
+	}
+	return logs
+}
+
+// reverts builds an OffchainLookup revert of the contract and broken
+// variants of it
+func reverts(contract common.Address) map[string]hexutil.Bytes {
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"error","name":"OffchainLookup","inputs":[
+		{"name":"sender","type":"address"},
+		{"name":"urls","type":"string[]"},
+		{"name":"callData","type":"bytes"},
+		{"name":"callbackFunction","type":"bytes4"},
+		{"name":"extraData","type":"bytes"}]}]`))
+	check(err)
+	e := parsed.Errors["OffchainLookup"]
+	args, err := e.Inputs.Pack(contract,
+		[]string{"https://gateway.example/{sender}/{data}.json", "https://backup.example/lookup"},
+		[]byte("lookup call data"),
+		[4]byte{0xde, 0xad, 0xbe, 0xef},
+		[]byte("extra data"),
+	)
+	check(err)
+	lookup := append(append([]byte{}, e.ID[:4]...), args...)
+
+	revertString, err := abi.NewType("string", "", nil)
+	check(err)
+	reason, err := abi.Arguments{{Type: revertString}}.Pack("Pausable: paused")
+	check(err)
+
+	return map[string]hexutil.Bytes{
+		"offchain_lookup":   lookup,
+		"truncated_lookup":  lookup[:100],
+		"selector_only":     lookup[:4],
+		"error_string":      append(crypto.Keccak256([]byte("Error(string)"))[:4], reason...),
+		"empty":             {},
+		"wrong_arg_offsets": append(append([]byte{}, e.ID[:4]...), make([]byte, 32*5)...),
+	}
+}
+
+func dataSaved(event abi.Event, contract common.Address, key, field, value string) *types.Log {
+	data, err := event.Inputs.Pack(key, field, value)
+	check(err)
+	return &types.Log{Address: contract, Topics: []common.Hash{event.ID}, Data: data}
+}
+
+func mustPack(parsed abi.ABI, method string, args ...interface{}) []byte {
+	data, err := parsed.Pack(method, args...)
+	check(err)
+	return data
+}
+
+func address(s *sender) common.Address {
+	key, err := crypto.HexToECDSA(s.key)
+	check(err)
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func sign(s *sender, to *common.Address, data []byte) *types.Transaction {
+	key, err := crypto.HexToECDSA(s.key)
+	check(err)
+	gas := uint64(200_000)
+	var inner types.TxData
+	if s.legacy {
+		inner = &types.LegacyTx{Nonce: s.nonce, GasPrice: big.NewInt(2 * params.GWei), Gas: gas, To: to, Data: data}
+	} else {
+		inner = &types.DynamicFeeTx{ChainID: chainID, Nonce: s.nonce, GasTipCap: big.NewInt(params.GWei), GasFeeCap: big.NewInt(3 * params.GWei), Gas: gas, To: to, Data: data}
+	}
+	s.nonce++
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), inner)
+	check(err)
+	return tx
+}
+
+func write(path string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	check(err)
+	check(os.WriteFile(path, append(data, '\n'), 0o644))
+}
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forwarder builds and checks EIP-2771 meta-transactions: requests
+// an account signs with EIP-712 for a trusted forwarder contract, which a
+// relayer then executes and pays the gas of. It supports the forwarders of
+// OpenZeppelin: ERC2771Forwarder of version 5, and MinimalForwarder of
+// version 4.
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Kinds of forwarders.
+const (
+	// KindERC2771 is ERC2771Forwarder of OpenZeppelin 5, whose requests
+	// expire. It only forwards to contracts that trust it, as reported by
+	// their isTrustedForwarder function.
+	KindERC2771 = "erc2771"
+	// KindMinimal is MinimalForwarder of OpenZeppelin 4, which forwards
+	// to any contract.
+	KindMinimal = "minimal"
+)
+
+// Forwarder is a deployed forwarder contract.
+type Forwarder struct {
+	Kind    string
+	Address common.Address
+	ChainID *big.Int
+	// Name and Version are those of its EIP-712 domain, as set when it was
+	// deployed. They default to those of OpenZeppelin: "ERC2771Forwarder"
+	// and "1", or "MinimalForwarder" and "0.0.1".
+	Name    string
+	Version string
+}
+
+// Request is a call an account asks the forwarder to make for it.
+type Request struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *big.Int       `json:"value"`
+	Gas   uint64         `json:"gas"`
+	// Nonce is the nonce of From at the forwarder, which it increments
+	// with each request executed.
+	Nonce *big.Int `json:"nonce"`
+	// Deadline is the Unix time after which the request cannot be
+	// executed, for KindERC2771 only.
+	Deadline uint64        `json:"deadline,omitempty"`
+	Data     hexutil.Bytes `json:"data"`
+}
+
+var forwarderABI, _ = abi.JSON(strings.NewReader(`[
+	{"type":"function","name":"execute","stateMutability":"payable","inputs":[{"name":"request","type":"tuple","components":[
+		{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"gas","type":"uint256"},
+		{"name":"deadline","type":"uint48"},{"name":"data","type":"bytes"},{"name":"signature","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"nonces","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`))
+
+var minimalABI, _ = abi.JSON(strings.NewReader(`[
+	{"type":"function","name":"execute","stateMutability":"payable","inputs":[{"name":"req","type":"tuple","components":[
+		{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"gas","type":"uint256"},
+		{"name":"nonce","type":"uint256"},{"name":"data","type":"bytes"}]},{"name":"signature","type":"bytes"}],
+		"outputs":[{"name":"","type":"bool"},{"name":"","type":"bytes"}]},
+	{"type":"function","name":"getNonce","stateMutability":"view","inputs":[{"name":"from","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`))
+
+// check validates the kind of f.
+func (f *Forwarder) check() error {
+	switch f.Kind {
+	case KindERC2771, KindMinimal:
+		return nil
+	default:
+		return fmt.Errorf("unknown forwarder kind %q, want %s or %s", f.Kind, KindERC2771, KindMinimal)
+	}
+}
+
+func (f *Forwarder) domain() apitypes.TypedDataDomain {
+	name, version := f.Name, f.Version
+	if name == "" {
+		name = map[string]string{KindERC2771: "ERC2771Forwarder", KindMinimal: "MinimalForwarder"}[f.Kind]
+	}
+	if version == "" {
+		version = map[string]string{KindERC2771: "1", KindMinimal: "0.0.1"}[f.Kind]
+	}
+	return apitypes.TypedDataDomain{
+		Name:              name,
+		Version:           version,
+		ChainId:           (*math.HexOrDecimal256)(f.ChainID),
+		VerifyingContract: f.Address.Hex(),
+	}
+}
+
+// TypedData returns the EIP-712 data of req, which the account signs with
+// eth_signTypedData_v4.
+func (f *Forwarder) TypedData(req *Request) (*apitypes.TypedData, error) {
+	if err := f.check(); err != nil {
+		return nil, err
+	}
+	if req.Nonce == nil {
+		return nil, errors.New("the request has no nonce")
+	}
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	fields := []apitypes.Type{
+		{Name: "from", Type: "address"},
+		{Name: "to", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "gas", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+	}
+	message := apitypes.TypedDataMessage{
+		"from":  req.From.Hex(),
+		"to":    req.To.Hex(),
+		"value": value.String(),
+		"gas":   fmt.Sprint(req.Gas),
+		"nonce": req.Nonce.String(),
+		"data":  hexutil.Encode(req.Data),
+	}
+	if f.Kind == KindERC2771 {
+		fields = append(fields, apitypes.Type{Name: "deadline", Type: "uint48"})
+		message["deadline"] = fmt.Sprint(req.Deadline)
+	}
+	fields = append(fields, apitypes.Type{Name: "data", Type: "bytes"})
+
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"ForwardRequest": fields,
+		},
+		PrimaryType: "ForwardRequest",
+		Domain:      f.domain(),
+		Message:     message,
+	}, nil
+}
+
+// Hash returns the EIP-712 digest of req, the hash its signature signs.
+func (f *Forwarder) Hash(req *Request) (common.Hash, error) {
+	data, err := f.TypedData(req)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash, _, err := apitypes.TypedDataAndHash(*data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// Signer is what signs requests: signer.HashSigner, returning signatures
+// with V 0 or 1.
+type Signer interface {
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// Sign signs req with s, returning the signature with V 27 or 28 as the
+// forwarders expect.
+func (f *Forwarder) Sign(ctx context.Context, s Signer, req *Request) ([]byte, error) {
+	hash, err := f.Hash(req)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.SignHash(ctx, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("signature of %d bytes, want %d", len(sig), crypto.SignatureLength)
+	}
+	sig = append([]byte(nil), sig...)
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	return sig, nil
+}
+
+// Verify checks that sig is the signature of req by req.From.
+func (f *Forwarder) Verify(req *Request, sig []byte) error {
+	hash, err := f.Hash(req)
+	if err != nil {
+		return err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("signature of %d bytes, want %d", len(sig), crypto.SignatureLength)
+	}
+	recovery := append([]byte(nil), sig...)
+	if recovery[64] >= 27 {
+		recovery[64] -= 27
+	}
+	pub, err := crypto.SigToPub(hash[:], recovery)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != req.From {
+		return fmt.Errorf("the request is signed by %s, not by %s", signer.Hex(), req.From.Hex())
+	}
+	return nil
+}
+
+// ExecuteData returns the calldata of the call to the forwarder executing
+// req, signed with sig.
+func (f *Forwarder) ExecuteData(req *Request, sig []byte) ([]byte, error) {
+	if err := f.check(); err != nil {
+		return nil, err
+	}
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	gas := new(big.Int).SetUint64(req.Gas)
+	if f.Kind == KindMinimal {
+		return minimalABI.Pack("execute", struct {
+			From  common.Address
+			To    common.Address
+			Value *big.Int
+			Gas   *big.Int
+			Nonce *big.Int
+			Data  []byte
+		}{req.From, req.To, value, gas, req.Nonce, req.Data}, sig)
+	}
+	return forwarderABI.Pack("execute", struct {
+		From      common.Address
+		To        common.Address
+		Value     *big.Int
+		Gas       *big.Int
+		Deadline  *big.Int
+		Data      []byte
+		Signature []byte
+	}{req.From, req.To, value, gas, new(big.Int).SetUint64(req.Deadline), req.Data, sig})
+}
+
+// Nonce returns the next nonce of account at the forwarder.
+func (f *Forwarder) Nonce(ctx context.Context, caller ethereum.ContractCaller, account common.Address) (*big.Int, error) {
+	if err := f.check(); err != nil {
+		return nil, err
+	}
+	contract, method := forwarderABI, "nonces"
+	if f.Kind == KindMinimal {
+		contract, method = minimalABI, "getNonce"
+	}
+	data, err := contract.Pack(method, account)
+	if err != nil {
+		return nil, err
+	}
+	out, err := caller.CallContract(ctx, ethereum.CallMsg{To: &f.Address, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := contract.Unpack(method, out)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a %s forwarder: %w", f.Address.Hex(), f.Kind, err)
+	}
+	return values[0].(*big.Int), nil
+}
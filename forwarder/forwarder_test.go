@@ -0,0 +1,162 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"contract-storage-eth/forwarder"
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	forwarderAddress = common.HexToAddress("0xf0f0")
+	target           = common.HexToAddress("0x5a5a")
+)
+
+func newRequest(from common.Address) *forwarder.Request {
+	return &forwarder.Request{From: from, To: target, Value: new(big.Int), Gas: 100000, Nonce: big.NewInt(3), Deadline: 1900000000, Data: []byte{0x12, 0x34}}
+}
+
+func word(v *big.Int) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+// digest is the EIP-712 digest of req computed as the forwarders do
+func digest(kind, name, version string, req *forwarder.Request) common.Hash {
+	domain := crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256([]byte(name)), crypto.Keccak256([]byte(version)),
+		word(big.NewInt(1337)), common.LeftPadBytes(forwarderAddress.Bytes(), 32))
+	typ := "ForwardRequest(address from,address to,uint256 value,uint256 gas,uint256 nonce,bytes data)"
+	if kind == forwarder.KindERC2771 {
+		typ = "ForwardRequest(address from,address to,uint256 value,uint256 gas,uint256 nonce,uint48 deadline,bytes data)"
+	}
+	fields := [][]byte{crypto.Keccak256([]byte(typ)),
+		common.LeftPadBytes(req.From.Bytes(), 32), common.LeftPadBytes(req.To.Bytes(), 32),
+		word(req.Value), word(new(big.Int).SetUint64(req.Gas)), word(req.Nonce)}
+	if kind == forwarder.KindERC2771 {
+		fields = append(fields, word(new(big.Int).SetUint64(req.Deadline)))
+	}
+	fields = append(fields, crypto.Keccak256(req.Data))
+	return crypto.Keccak256Hash([]byte("\x19\x01"), domain, crypto.Keccak256(fields...))
+}
+
+func TestHash(t *testing.T) {
+	for _, c := range []struct{ kind, name, version string }{
+		{forwarder.KindERC2771, "ERC2771Forwarder", "1"},
+		{forwarder.KindMinimal, "MinimalForwarder", "0.0.1"},
+	} {
+		f := &forwarder.Forwarder{Kind: c.kind, Address: forwarderAddress, ChainID: big.NewInt(1337)}
+		req := newRequest(common.HexToAddress("0x7156"))
+		got, err := f.Hash(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := digest(c.kind, c.name, c.version, req); got != want {
+			t.Errorf("%s: hash %s, want %s", c.kind, got.Hex(), want.Hex())
+		}
+	}
+
+	named := &forwarder.Forwarder{Kind: forwarder.KindERC2771, Address: forwarderAddress, ChainID: big.NewInt(1337), Name: "Relay"}
+	req := newRequest(common.HexToAddress("0x7156"))
+	if got, _ := named.Hash(req); got != digest(forwarder.KindERC2771, "Relay", "1", req) {
+		t.Error("the domain name was not used")
+	}
+	if _, err := (&forwarder.Forwarder{Kind: "gsn"}).Hash(req); err == nil {
+		t.Error("hashed for an unknown kind")
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	s := signer.NewKeySigner(key)
+	f := &forwarder.Forwarder{Kind: forwarder.KindERC2771, Address: forwarderAddress, ChainID: big.NewInt(1337)}
+	req := newRequest(s.Address())
+
+	sig, err := f.Sign(context.Background(), s, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Errorf("V is %d, want 27 or 28", sig[64])
+	}
+	if err := f.Verify(req, sig); err != nil {
+		t.Error(err)
+	}
+	req.Gas++
+	if err := f.Verify(req, sig); err == nil {
+		t.Error("verified a changed request")
+	}
+	other := newRequest(common.HexToAddress("0x01"))
+	if err := f.Verify(other, sig); err == nil {
+		t.Error("verified a request of another account")
+	}
+}
+
+func TestExecuteData(t *testing.T) {
+	req := newRequest(common.HexToAddress("0x7156"))
+	sig := bytes.Repeat([]byte{1}, 65)
+	for kind, selector := range map[string]string{
+		forwarder.KindERC2771: "execute((address,address,uint256,uint256,uint48,bytes,bytes))",
+		forwarder.KindMinimal: "execute((address,address,uint256,uint256,uint256,bytes),bytes)",
+	} {
+		f := &forwarder.Forwarder{Kind: kind, Address: forwarderAddress, ChainID: big.NewInt(1337)}
+		data, err := f.ExecuteData(req, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := crypto.Keccak256([]byte(selector))[:4]; !bytes.Equal(data[:4], want) {
+			t.Errorf("%s: selector %x, want %x", kind, data[:4], want)
+		}
+		if !bytes.Contains(data, sig) {
+			t.Errorf("%s: the signature is not in the calldata", kind)
+		}
+	}
+}
+
+// nonceCaller answers the nonce calls of the forwarders
+type nonceCaller struct{ methods map[string][]byte }
+
+func (c nonceCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	for signature, out := range c.methods {
+		if bytes.Equal(msg.Data[:4], crypto.Keccak256([]byte(signature))[:4]) {
+			return out, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestNonce(t *testing.T) {
+	caller := nonceCaller{map[string][]byte{"nonces(address)": word(big.NewInt(7)), "getNonce(address)": word(big.NewInt(9))}}
+	for kind, want := range map[string]int64{forwarder.KindERC2771: 7, forwarder.KindMinimal: 9} {
+		f := &forwarder.Forwarder{Kind: kind, Address: forwarderAddress, ChainID: big.NewInt(1337)}
+		nonce, err := f.Nonce(context.Background(), caller, common.HexToAddress("0x7156"))
+		if err != nil || nonce.Int64() != want {
+			t.Errorf("%s: nonce %v, %v, want %d", kind, nonce, err, want)
+		}
+	}
+	f := &forwarder.Forwarder{Kind: forwarder.KindERC2771, Address: forwarderAddress, ChainID: big.NewInt(1337)}
+	if _, err := f.Nonce(context.Background(), nonceCaller{}, common.HexToAddress("0x7156")); err == nil {
+		t.Error("read a nonce from a contract without one")
+	}
+}
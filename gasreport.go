@@ -0,0 +1,102 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+
+	"contract-storage-eth/fees"
+	"contract-storage-eth/gasreport"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// gasReport collects the gas of every transaction the command waits for,
+// nil unless gas_report.enabled or --gas-report is set
+var gasReport struct {
+	report *gasreport.Report
+	typing gasreport.Typing
+	csv    string
+}
+
+// setupGasReport starts collecting when enabled or configured, writing
+// the CSV to csvFile, or to gas_report.csv without one
+func setupGasReport(config *Config, enabled bool, csvFile string) error {
+	if csvFile == "" {
+		csvFile = config.GasReport.CSV
+	}
+	if !enabled && !config.GasReport.Enabled && csvFile == "" {
+		return nil
+	}
+	typing, err := gasreport.ParseTyping(config.GasReport.TypeBy, config.GasReport.Separator)
+	if err != nil {
+		return err
+	}
+	gasReport.report, gasReport.typing, gasReport.csv = &gasreport.Report{}, typing, csvFile
+	return nil
+}
+
+// recordGas adds a mined transaction to the gas report
+func recordGas(tx *types.Transaction, receipt *types.Receipt) {
+	if gasReport.report == nil {
+		return
+	}
+	entry := gasreport.Entry{
+		TxHash:  tx.Hash(),
+		Failed:  receipt.Status != types.ReceiptStatusSuccessful,
+		GasUsed: receipt.GasUsed,
+	}
+	if receipt.EffectiveGasPrice != nil {
+		entry.Cost = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	call := describeTx(tx)
+	entry.Operation = call.operation
+	if call.operation == gasreport.OpSave || call.operation == gasreport.OpDelete {
+		key := call.key
+		if local, ok := keyNamespace.Local(key); ok {
+			key = local
+		}
+		entry.Type = gasReport.typing.Type(key, call.field)
+	}
+	gasReport.report.Add(entry)
+}
+
+// finishGasReport prints the summary of the transactions of the command
+// and writes its CSV
+func finishGasReport() {
+	if gasReport.report == nil || len(gasReport.report.Entries()) == 0 {
+		return
+	}
+	fmt.Println("\nGas report:")
+	gasReport.report.WriteTable(os.Stdout, func(wei *big.Int) string { return fees.FormatEther(wei) + " ETH" })
+	if gasReport.csv == "" {
+		return
+	}
+	f, err := os.Create(gasReport.csv)
+	if err == nil {
+		err = gasReport.report.WriteCSV(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to write gas report", "file", gasReport.csv, "error", err)
+		return
+	}
+	fmt.Printf("Gas report written to %s\n", gasReport.csv)
+}
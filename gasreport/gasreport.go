@@ -0,0 +1,212 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gasreport aggregates the gas used by the transactions of a run
+// per operation and record type, so that costs can be attributed to the
+// kinds of records written.
+package gasreport
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Operations of the transactions.
+const (
+	OpDeploy = "deploy"
+	OpSave   = "save"
+	OpDelete = "delete"
+)
+
+// Entry is a mined transaction.
+type Entry struct {
+	// Operation is OpDeploy, OpSave, OpDelete or the name of the method
+	// called.
+	Operation string
+	// Type is the record type of saves and deletes, empty otherwise.
+	Type    string
+	TxHash  common.Hash
+	Failed  bool
+	GasUsed uint64
+	// Cost is what the transaction paid in wei.
+	Cost *big.Int
+}
+
+// Row is the summary of the entries of an operation and record type.
+type Row struct {
+	Operation    string   `json:"operation"`
+	Type         string   `json:"type,omitempty"`
+	Transactions int      `json:"transactions"`
+	Failed       int      `json:"failed,omitempty"`
+	GasUsed      uint64   `json:"gas_used"`
+	GasMean      uint64   `json:"gas_mean"`
+	GasMin       uint64   `json:"gas_min"`
+	GasMax       uint64   `json:"gas_max"`
+	Cost         *big.Int `json:"cost"`
+}
+
+// Report collects entries. It is safe for concurrent use.
+type Report struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Add records an entry.
+func (r *Report) Add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// Entries returns the entries in the order they were added.
+func (r *Report) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.entries)
+}
+
+// Summary returns a row per operation and record type, sorted by
+// operation then type, followed by a row of the totals, whose operation is
+// "total". It returns nil without entries.
+func (r *Report) Summary() []Row {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return nil
+	}
+	rows := map[[2]string]*Row{}
+	total := &Row{Operation: "total", Cost: new(big.Int)}
+	for _, e := range entries {
+		k := [2]string{e.Operation, e.Type}
+		row, ok := rows[k]
+		if !ok {
+			row = &Row{Operation: e.Operation, Type: e.Type, Cost: new(big.Int)}
+			rows[k] = row
+		}
+		row.add(e)
+		total.add(e)
+	}
+	summary := make([]Row, 0, len(rows)+1)
+	for _, row := range rows {
+		summary = append(summary, *row)
+	}
+	slices.SortFunc(summary, func(a, b Row) int {
+		return cmp.Or(cmp.Compare(a.Operation, b.Operation), cmp.Compare(a.Type, b.Type))
+	})
+	return append(summary, *total)
+}
+
+func (row *Row) add(e Entry) {
+	row.Transactions++
+	if e.Failed {
+		row.Failed++
+	}
+	row.GasUsed += e.GasUsed
+	if row.Transactions == 1 || e.GasUsed < row.GasMin {
+		row.GasMin = e.GasUsed
+	}
+	row.GasMax = max(row.GasMax, e.GasUsed)
+	row.GasMean = row.GasUsed / uint64(row.Transactions)
+	if e.Cost != nil {
+		row.Cost.Add(row.Cost, e.Cost)
+	}
+}
+
+// WriteTable prints the summary as a table, with costs formatted by
+// formatCost.
+func (r *Report) WriteTable(w io.Writer, formatCost func(*big.Int) string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tTYPE\tTXS\tFAILED\tGAS\tMEAN\tMIN\tMAX\tCOST")
+	for _, row := range r.Summary() {
+		typ := row.Type
+		if typ == "" {
+			typ = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			row.Operation, typ, row.Transactions, row.Failed, row.GasUsed, row.GasMean, row.GasMin, row.GasMax, formatCost(row.Cost))
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes a row per entry, with a header, costs in wei.
+func (r *Report) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"operation", "type", "tx_hash", "status", "gas_used", "cost_wei"})
+	for _, e := range r.Entries() {
+		status, cost := "success", "0"
+		if e.Failed {
+			status = "failed"
+		}
+		if e.Cost != nil {
+			cost = e.Cost.String()
+		}
+		out.Write([]string{e.Operation, e.Type, e.TxHash.Hex(), status, strconv.FormatUint(e.GasUsed, 10), cost})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// Ways of telling the record type of a key and field.
+const (
+	// TypeByKeyPrefix uses the part of the key before the separator.
+	TypeByKeyPrefix = "key_prefix"
+	// TypeByField uses the field.
+	TypeByField = "field"
+)
+
+// Typing tells the record type of keys and fields.
+type Typing struct {
+	// By is TypeByKeyPrefix (the default) or TypeByField.
+	By string
+	// Separator ends the key prefix, "/" by default.
+	Separator string
+}
+
+// ParseTyping checks the typing mode and fills in defaults.
+func ParseTyping(by, separator string) (Typing, error) {
+	t := Typing{By: by, Separator: separator}
+	switch t.By {
+	case "":
+		t.By = TypeByKeyPrefix
+	case TypeByKeyPrefix, TypeByField:
+	default:
+		return Typing{}, fmt.Errorf("unknown record typing %q, want %s or %s", by, TypeByKeyPrefix, TypeByField)
+	}
+	if t.Separator == "" {
+		t.Separator = "/"
+	}
+	return t, nil
+}
+
+// Type returns the record type of key and field, "(none)" for keys
+// without a prefix and empty fields.
+func (t Typing) Type(key, field string) string {
+	if t.By == TypeByField {
+		if field != "" {
+			return field
+		}
+	} else if prefix, _, ok := strings.Cut(key, t.Separator); ok && prefix != "" {
+		return prefix
+	}
+	return "(none)"
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gasreport_test
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/gasreport"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSummary(t *testing.T) {
+	r := &gasreport.Report{}
+	if r.Summary() != nil {
+		t.Fatal("summary without entries")
+	}
+	r.Add(gasreport.Entry{Operation: gasreport.OpDeploy, GasUsed: 500000, Cost: big.NewInt(50)})
+	r.Add(gasreport.Entry{Operation: gasreport.OpSave, Type: "invoice", GasUsed: 60000, Cost: big.NewInt(6)})
+	r.Add(gasreport.Entry{Operation: gasreport.OpSave, Type: "invoice", GasUsed: 80000, Cost: big.NewInt(8)})
+	r.Add(gasreport.Entry{Operation: gasreport.OpSave, Type: "audit", GasUsed: 30000, Failed: true})
+	r.Add(gasreport.Entry{Operation: gasreport.OpDelete, Type: "invoice", GasUsed: 25000, Cost: big.NewInt(2)})
+
+	var got []string
+	for _, row := range r.Summary() {
+		got = append(got, strings.Join([]string{row.Operation, row.Type}, ":"))
+	}
+	if want := "delete:invoice deploy: save:audit save:invoice total:"; strings.Join(got, " ") != want {
+		t.Errorf("rows %v, want %s", got, want)
+	}
+	summary := r.Summary()
+	invoice, total := summary[3], summary[4]
+	if invoice.Transactions != 2 || invoice.GasUsed != 140000 || invoice.GasMean != 70000 || invoice.GasMin != 60000 || invoice.GasMax != 80000 || invoice.Cost.Int64() != 14 {
+		t.Errorf("invoice saves %+v", invoice)
+	}
+	if total.Transactions != 5 || total.Failed != 1 || total.GasUsed != 695000 || total.GasMin != 25000 || total.Cost.Int64() != 66 {
+		t.Errorf("total %+v", total)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := &gasreport.Report{}
+	r.Add(gasreport.Entry{Operation: gasreport.OpSave, Type: "invoice", TxHash: common.HexToHash("0x01"), GasUsed: 60000, Cost: big.NewInt(6)})
+	r.Add(gasreport.Entry{Operation: gasreport.OpSave, Type: "audit", GasUsed: 30000, Failed: true})
+
+	var table bytes.Buffer
+	if err := r.WriteTable(&table, func(wei *big.Int) string { return wei.String() + " wei" }); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(table.String()), "\n"); len(lines) != 4 || !strings.Contains(lines[3], "6 wei") {
+		t.Errorf("table:\n%s", table.String())
+	}
+
+	var csv bytes.Buffer
+	if err := r.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	want := "operation,type,tx_hash,status,gas_used,cost_wei\n" +
+		"save,invoice,0x0000000000000000000000000000000000000000000000000000000000000001,success,60000,6\n" +
+		"save,audit,0x0000000000000000000000000000000000000000000000000000000000000000,failed,30000,0\n"
+	if csv.String() != want {
+		t.Errorf("csv:\n%s\nwant:\n%s", csv.String(), want)
+	}
+}
+
+func TestTyping(t *testing.T) {
+	if _, err := gasreport.ParseTyping("tag", ""); err == nil {
+		t.Error("parsed an unknown typing")
+	}
+	byPrefix, _ := gasreport.ParseTyping("", "")
+	byField, _ := gasreport.ParseTyping(gasreport.TypeByField, "")
+	for _, tc := range []struct {
+		typing     gasreport.Typing
+		key, field string
+		want       string
+	}{
+		{byPrefix, "invoice/42", "pdf", "invoice"},
+		{byPrefix, "invoice-42", "pdf", "(none)"},
+		{byPrefix, "/42", "pdf", "(none)"},
+		{byField, "invoice/42", "pdf", "pdf"},
+		{byField, "invoice/42", "", "(none)"},
+	} {
+		if got := tc.typing.Type(tc.key, tc.field); got != tc.want {
+			t.Errorf("%s type of %s#%s = %q, want %q", tc.typing.By, tc.key, tc.field, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/ccip"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tracing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func runGet(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	key := flags.String("key", "", "record key, or a record ID")
+	field := flags.String("field", "", "record field")
+	raw := flags.Bool("raw", false, "print the stored value without opening its envelope")
+	blockFlag := flags.Uint64("block", 0, "read the value as of this block (needs an archive node for old blocks)")
+	at := flags.String("at", "", "read the value as of this time, RFC 3339 or a UTC date, resolved to the last block mined by then")
+	asHex := flags.Bool("hex", false, "print the content hex encoded")
+	asBase64 := flags.Bool("base64", false, "print the content base64 encoded")
+	outFile := flags.String("out", "", "write the content to this file, byte for byte, instead of printing it")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *key == "" && flags.NArg() > 0 {
+		*key = flags.Arg(0)
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "get: --key or a record ID is required")
+	}
+	if *at != "" && isFlagSet(flags, "block") {
+		return fail(codeInvalidArgument, "Invalid flags: use either --block or --at")
+	}
+	if *asHex && *asBase64 {
+		return fail(codeInvalidArgument, "Invalid flags: use either --hex or --base64")
+	}
+	atTime, err := parseDay(*at)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --at time: %w", err)
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	var address common.Address
+	if recordid.IsID(*key) {
+		// The ID names the contract, which may be another deployment of the
+		// same chain
+		id, err := parseChainID(ctx, client, *key)
+		if err != nil {
+			return err
+		}
+		if id.Version > 0 {
+			return fmt.Errorf("get reads the latest value from the contract, use lineage for version %d", id.Version)
+		}
+		address, *key, *field = id.Contract, id.Key, id.Field
+	} else if address, err = contractAddress(config); err != nil {
+		return err
+	} else {
+		*key = keyNamespace.Key(*key)
+	}
+
+	// The block of a historical read, nil for the latest state
+	var block *big.Int
+	var blockTime uint64
+	switch {
+	case *at != "":
+		header, err := chain.BlockAt(ctx, client, atTime)
+		if err != nil {
+			return fmt.Errorf("Failed to find the block at %s: %w", *at, err)
+		}
+		block, blockTime = header.Number, header.Time
+		slog.Info("Reading at block", "block", block, "block_time", time.Unix(int64(blockTime), 0).UTC().Format(time.RFC3339))
+	case isFlagSet(flags, "block"):
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(*blockFlag))
+		if err != nil {
+			return fmt.Errorf("Failed to get block %d: %w", *blockFlag, err)
+		}
+		block, blockTime = header.Number, header.Time
+	}
+
+	value, err := readRecordValue(ctx, client, config, address, *key, *field, block)
+	if err != nil {
+		return fmt.Errorf("Failed to read value: %w", err)
+	}
+	if value == "" {
+		if block != nil {
+			return fail(codeNotFound, "No value stored for %s#%s at block %s", *key, *field, block)
+		}
+		return fail(codeNotFound, "No value stored for %s#%s", *key, *field)
+	}
+
+	if output.json {
+		chainID, err := client.ChainID(ctx)
+		if err != nil {
+			return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+		}
+		ns := recordid.Namespace{ChainID: chainID.Uint64(), Contract: address}
+		content, encoding := recordContent(value)
+		result := getResult{Record: api.Record{ID: ns.ID(*key, *field, 0).String(), Key: *key, Field: *field, Value: value, Content: content, Encoding: encoding}}
+		if block != nil {
+			t := time.Unix(int64(blockTime), 0).UTC()
+			result.Block, result.BlockTime = block.Uint64(), &t
+		}
+		printResult(result)
+		return nil
+	}
+	if *raw {
+		fmt.Println(value)
+		return nil
+	}
+	if !*asHex && !*asBase64 && *outFile == "" {
+		fmt.Println(decodeValue(value))
+		return nil
+	}
+
+	content, _, err := openContent(value)
+	if err != nil {
+		return fmt.Errorf("Failed to decode value: %w", err)
+	}
+	switch {
+	case *outFile != "":
+		if err := os.WriteFile(*outFile, content, 0o644); err != nil {
+			return fmt.Errorf("Failed to write value file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d bytes to %s\n", len(content), *outFile)
+	case *asHex:
+		fmt.Println(hexutil.Encode(content))
+	default:
+		fmt.Println(base64.StdEncoding.EncodeToString(content))
+	}
+	return nil
+}
+
+// getResult is the result of get in JSON output mode. Block and BlockTime
+// are set for historical reads
+type getResult struct {
+	api.Record
+	Block     uint64     `json:"block,omitempty"`
+	BlockTime *time.Time `json:"block_time,omitempty"`
+}
+
+// parseChainID parses a record ID, checking that it is on the chain of the
+// node
+func parseChainID(ctx context.Context, client chain.ChainClient, s string) (recordid.ID, error) {
+	id, err := recordid.Parse(s)
+	if err != nil {
+		return id, err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return id, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	if chainID.Uint64() != id.ChainID {
+		return id, fmt.Errorf("%w: %s is on chain %d but the node is on chain %d", recordid.ErrOtherNamespace, id, id.ChainID, chainID.Uint64())
+	}
+	return id, nil
+}
+
+// readValue calls get(key, field) on the contract, following CCIP-Read
+// lookups to an off-chain gateway when the contract requests them. A nil
+// block reads the latest state
+func readValue(ctx context.Context, client ccip.Caller, config *Config, address common.Address, key, field string, block *big.Int) (value string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.get", attribute.String("record.key", key), attribute.String("record.field", field))
+	defer func() { tracing.End(span, err) }()
+
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return "", err
+	}
+	data, err := parsedABI.Pack("get", key, field)
+	if err != nil {
+		return "", err
+	}
+
+	msg := ethereum.CallMsg{To: &address, Data: data}
+	var out []byte
+	if config.Read.DisableCCIP {
+		out, err = client.CallContract(ctx, msg, block)
+	} else {
+		timeout := config.Read.GatewayTimeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		out, err = ccip.Call(ctx, client, msg, block, ccip.Options{
+			HTTPClient: &http.Client{Timeout: timeout},
+			MaxLookups: config.Read.MaxLookups,
+		})
+	}
+	if err != nil {
+		if block != nil && chain.IsMissingState(err) {
+			return "", fmt.Errorf("the node has no state of block %s, reading past values needs an archive node: %w", block, err)
+		}
+		return "", err
+	}
+	if len(out) == 0 {
+		if block != nil {
+			return "", fmt.Errorf("contract %s has no get at block %s, it was deployed later or does not implement it", address.Hex(), block)
+		}
+		return "", fmt.Errorf("contract %s does not implement get, redeploy it from Storage.sol", address.Hex())
+	}
+
+	results, err := parsedABI.Unpack("get", out)
+	if err != nil {
+		return "", err
+	}
+	return results[0].(string), nil
+}
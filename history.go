@@ -0,0 +1,136 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/tombstone"
+)
+
+func runHistory(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	raw := flags.Bool("raw", false, "print stored values without opening their envelope")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) == 0 || len(positional) > 2 {
+		return fail(codeInvalidArgument, "history: a key or record ID, and optionally a field, is required")
+	}
+	key, field := positional[0], ""
+	if len(positional) == 2 {
+		field = positional[1]
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+	ref, err := resolveRef(ns, key, field)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid key: %w", err)
+	}
+	fields := []string{ref.Field}
+	if len(positional) == 1 && ref.Field == "" {
+		if fields, err = store.Fields(ref.Key); err != nil {
+			return fail(codeIndex, "Failed to query index: %w", err)
+		}
+		if len(fields) == 0 {
+			return fail(codeNotFound, "No records found for key %s", ref.Key)
+		}
+	}
+
+	var result historyResult
+	for _, f := range fields {
+		ref := indexer.Ref{Key: ref.Key, Field: f}
+		records, err := store.History(ref.Key, ref.Field)
+		if errors.Is(err, indexer.ErrNotFound) {
+			return fail(codeNotFound, "Record %s not found", refID(ns, ref))
+		}
+		if err != nil {
+			return fail(codeIndex, "Failed to query index: %w", err)
+		}
+
+		if output.json {
+			result.Histories = append(result.Histories, history{ID: refID(ns, ref).String(), Records: recordEvents(ns, records)})
+			continue
+		}
+		fmt.Printf("History of %s (%d revision(s), oldest first):\n", refID(ns, ref), len(records))
+		for _, r := range records {
+			fmt.Printf("  v%d  block %d  tx %s  %s\n", r.Version, r.BlockNumber, r.TxHash.Hex(), time.Unix(int64(r.Timestamp), 0).UTC().Format(time.RFC3339))
+			switch {
+			case r.Value == "":
+				fmt.Println("    (deleted)")
+			case tombstone.Is(r.Value):
+				fmt.Println("    (soft-deleted, restorable)")
+			case *raw:
+				fmt.Printf("    %s\n", r.Value)
+			default:
+				fmt.Printf("    %s\n", decodeValue(r.Value))
+			}
+		}
+	}
+	printResult(result)
+	return nil
+}
+
+// historyResult is the result of history in JSON output mode, with a
+// history per field
+type historyResult struct {
+	Histories []history `json:"histories"`
+}
+
+type history struct {
+	ID string `json:"id"`
+	// Records are oldest first
+	Records []api.Event `json:"records"`
+}
+
+// parseInterspersed parses flags given before, between or after the
+// positional arguments, which it returns
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := parseFlags(flags, args); err != nil {
+			return nil, err
+		}
+		if n := len(args) - flags.NArg(); flags.NArg() == 0 || n > 0 && args[n-1] == "--" {
+			return append(positional, flags.Args()...), nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
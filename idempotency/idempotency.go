@@ -0,0 +1,244 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idempotency makes saves safe to retry. A save carrying an
+// idempotency key is written once: retries with the same key get the result
+// of the confirmed write instead of writing the record again, so a client
+// retrying after a timeout does not store a duplicate.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"contract-storage-eth/api"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MaxKeyLength bounds the length of idempotency keys.
+const MaxKeyLength = 255
+
+// entry is the stored result of a confirmed save.
+type entry struct {
+	// Fingerprint identifies the request, so that a key reused for another
+	// request is refused instead of answered with an unrelated result.
+	Fingerprint string           `json:"fingerprint"`
+	Result      *api.WriteResult `json:"result"`
+	SavedAt     time.Time        `json:"saved_at"`
+}
+
+// Store remembers the results of saves by idempotency key, in a bbolt
+// database. Results are kept for a TTL, after which the key can be used
+// again.
+type Store struct {
+	db  *bolt.DB
+	ttl time.Duration
+}
+
+// Open opens the store at path, creating it if needed, and removes the
+// results older than ttl. With a zero ttl results are kept forever.
+func Open(path string, ttl time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db, ttl: ttl}
+	if _, err := s.Prune(time.Now()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// expired reports whether a result saved at t is past the TTL at now.
+func (s *Store) expired(t, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(t) > s.ttl
+}
+
+// Prune removes the results past the TTL at now and returns their number.
+func (s *Store) Prune(now time.Time) (int, error) {
+	if s.ttl <= 0 {
+		return 0, nil
+	}
+	n := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(_ []byte, b *bolt.Bucket) error {
+			c := b.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				var e entry
+				if err := json.Unmarshal(v, &e); err != nil || s.expired(e.SavedAt, now) {
+					if err := c.Delete(); err != nil {
+						return err
+					}
+					n++
+				}
+			}
+			return nil
+		})
+	})
+	return n, err
+}
+
+// bucket names the bucket of scope, which may be empty where bucket names
+// cannot.
+func bucket(scope string) []byte {
+	return []byte("keys:" + scope)
+}
+
+// get returns the result saved for key in scope, nil when there is none or
+// it expired.
+func (s *Store) get(scope, key string) (*entry, error) {
+	var e *entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket(scope))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(key))
+		if v == nil {
+			return nil
+		}
+		e = new(entry)
+		return json.Unmarshal(v, e)
+	})
+	if err != nil || e == nil || s.expired(e.SavedAt, time.Now()) {
+		return nil, err
+	}
+	return e, nil
+}
+
+// put saves the result of key in scope.
+func (s *Store) put(scope, key string, e *entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket(scope))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// Fingerprint identifies what a save writes, whatever its idempotency key.
+func Fingerprint(req api.SaveRequest) string {
+	req.IdempotencyKey = ""
+	// A SaveRequest always encodes, with its map keys sorted
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// records is the api.Records returned by Records.
+type records struct {
+	api.Records
+	store *Store
+	scope string
+
+	// inFlight holds the keys of the saves being written, so that a retry
+	// arriving before the first attempt completes does not write too
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+// Records returns records answering saves with an idempotency key from
+// store. scope separates the keys of different contracts sharing a store,
+// such as recordid.Namespace.String. Only confirmed saves are remembered: a
+// save that failed, or whose transaction was not seen mined, is written
+// again when retried. A save whose context is canceled is completed in the
+// background, answering retries with api.ErrInProgress until it is.
+func Records(r api.Records, store *Store, scope string) api.Records {
+	return &records{Records: r, store: store, scope: scope, inFlight: map[string]bool{}}
+}
+
+// Save implements api.Records. It returns an error wrapping
+// api.ErrKeyReused when the key was used for a save writing something else,
+// and api.ErrInProgress while a save with the same key is being written.
+func (r *records) Save(ctx context.Context, req api.SaveRequest) (*api.WriteResult, error) {
+	key := req.IdempotencyKey
+	if key == "" {
+		return r.Records.Save(ctx, req)
+	}
+	if len(key) > MaxKeyLength {
+		return nil, fmt.Errorf("%w: idempotency key longer than %d bytes", api.ErrInvalidRecord, MaxKeyLength)
+	}
+	fingerprint := Fingerprint(req)
+
+	r.mu.Lock()
+	if r.inFlight[key] {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", api.ErrInProgress, key)
+	}
+	e, err := r.store.get(r.scope, key)
+	if err != nil || e != nil {
+		r.mu.Unlock()
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("idempotency store: %w", err)
+		case e.Fingerprint != fingerprint:
+			return nil, fmt.Errorf("%w: %s", api.ErrKeyReused, key)
+		}
+		result := *e.Result
+		result.Replayed = true
+		return &result, nil
+	}
+	r.inFlight[key] = true
+	r.mu.Unlock()
+
+	// The write outlives a caller giving up, such as a client disconnecting
+	// while the transaction is mined: its result is remembered all the same,
+	// so that the retry is answered with it rather than sending another
+	// transaction
+	type outcome struct {
+		result *api.WriteResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.inFlight, key)
+			r.mu.Unlock()
+		}()
+		result, err := r.Records.Save(context.WithoutCancel(ctx), req)
+		if err == nil {
+			// The record is written either way, so failing to remember it
+			// only loses the protection of later retries
+			if err := r.store.put(r.scope, key, &entry{Fingerprint: fingerprint, Result: result, SavedAt: time.Now().UTC()}); err != nil {
+				slog.Warn("Failed to store idempotency key", "idempotency_key", key, "tx_hash", result.TxHash.Hex(), "error", err)
+			}
+		}
+		done <- outcome{result, err}
+	}()
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/idempotency"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// countingRecords counts the saves that reach the contract
+type countingRecords struct {
+	api.Records
+	saves atomic.Int64
+	// entered is signaled, without blocking, and release, when set, holds
+	// saves until it is closed
+	entered, release chan struct{}
+}
+
+// holding returns records holding saves until release is closed
+func holding() *countingRecords {
+	return &countingRecords{entered: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (c *countingRecords) Save(ctx context.Context, req api.SaveRequest) (*api.WriteResult, error) {
+	n := c.saves.Add(1)
+	if c.release != nil {
+		select {
+		case c.entered <- struct{}{}:
+		default:
+		}
+		// Like waiting for the receipt, which gives up with the context
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &api.WriteResult{ID: req.Key, TxHash: common.BigToHash(common.Big1), Block: uint64(n)}, nil
+}
+
+func open(t *testing.T, path string, ttl time.Duration) *idempotency.Store {
+	t.Helper()
+	store, err := idempotency.Open(path, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestReplay(t *testing.T) {
+	inner := &countingRecords{}
+	path := filepath.Join(t.TempDir(), "idempotency.db")
+	records := idempotency.Records(inner, open(t, path, time.Hour), "1337/0x1")
+	ctx := context.Background()
+	req := api.SaveRequest{Key: "invoice-42", Value: "v1", Tags: map[string]string{"a": "1", "b": "2"}, IdempotencyKey: "req-1"}
+
+	first, err := records.Save(ctx, req)
+	if err != nil || first.Replayed {
+		t.Fatalf("first save: %+v, %v", first, err)
+	}
+	again, err := records.Save(ctx, req)
+	if err != nil || !again.Replayed || again.TxHash != first.TxHash || again.Block != first.Block {
+		t.Fatalf("retry: %+v, %v", again, err)
+	}
+	if n := inner.saves.Load(); n != 1 {
+		t.Errorf("wrote %d times, want 1", n)
+	}
+
+	other := req
+	other.Value = "v2"
+	if _, err := records.Save(ctx, other); !errors.Is(err, api.ErrKeyReused) {
+		t.Errorf("reused key: %v, want ErrKeyReused", err)
+	}
+
+	req.IdempotencyKey = ""
+	if result, err := records.Save(ctx, req); err != nil || result.Replayed {
+		t.Errorf("save without key: %+v, %v", result, err)
+	}
+	if n := inner.saves.Load(); n != 2 {
+		t.Errorf("wrote %d times, want 2", n)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	inner := &countingRecords{}
+	store := open(t, filepath.Join(t.TempDir(), "idempotency.db"), 0)
+	req := api.SaveRequest{Key: "k", Value: "v", IdempotencyKey: "req-1"}
+	for _, scope := range []string{"1/0x1", "5/0x1"} {
+		if result, err := idempotency.Records(inner, store, scope).Save(context.Background(), req); err != nil || result.Replayed {
+			t.Errorf("scope %s: %+v, %v", scope, result, err)
+		}
+	}
+}
+
+func TestInProgress(t *testing.T) {
+	inner := holding()
+	records := idempotency.Records(inner, open(t, filepath.Join(t.TempDir(), "idempotency.db"), 0), "")
+	req := api.SaveRequest{Key: "k", Value: "v", IdempotencyKey: "req-1"}
+
+	done := make(chan error)
+	go func() {
+		_, err := records.Save(context.Background(), req)
+		done <- err
+	}()
+	<-inner.entered
+	if _, err := records.Save(context.Background(), req); !errors.Is(err, api.ErrInProgress) {
+		t.Errorf("retry during the first save: %v, want ErrInProgress", err)
+	}
+	close(inner.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if result, err := records.Save(context.Background(), req); err != nil || !result.Replayed {
+		t.Errorf("after the first save: %+v, %v", result, err)
+	}
+}
+
+func TestCanceledSave(t *testing.T) {
+	inner := holding()
+	records := idempotency.Records(inner, open(t, filepath.Join(t.TempDir(), "idempotency.db"), 0), "")
+	req := api.SaveRequest{Key: "k", Value: "v", IdempotencyKey: "req-1"}
+
+	// The client gives up while the transaction is being mined
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := records.Save(ctx, req)
+		done <- err
+	}()
+	<-inner.entered
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled save: %v, want context.Canceled", err)
+	}
+	retryCtx, cancelRetry := context.WithTimeout(context.Background(), time.Second)
+	defer cancelRetry()
+	if _, err := records.Save(retryCtx, req); !errors.Is(err, api.ErrInProgress) {
+		t.Errorf("retry while mined: %v, want ErrInProgress", err)
+	}
+
+	close(inner.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		result, err := records.Save(context.Background(), req)
+		if errors.Is(err, api.ErrInProgress) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if err != nil || !result.Replayed {
+			t.Fatalf("retry after the write: %+v, %v", result, err)
+		}
+		break
+	}
+	if n := inner.saves.Load(); n != 1 {
+		t.Errorf("wrote %d times, want 1", n)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.db")
+	store, err := idempotency.Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &countingRecords{}
+	if _, err := idempotency.Records(inner, store, "").Save(context.Background(), api.SaveRequest{Key: "k", IdempotencyKey: "req-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.Prune(time.Now()); err != nil || n != 0 {
+		t.Errorf("pruned %d, %v before the TTL", n, err)
+	}
+	if n, err := store.Prune(time.Now().Add(2 * time.Minute)); err != nil || n != 1 {
+		t.Errorf("pruned %d, %v after the TTL, want 1", n, err)
+	}
+	store.Close()
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+)
+
+// recordNamespace returns the deployment record IDs are minted in. The
+// chain comes from ethereum.chain_id, then from the index, then from the
+// node; store and client may be nil
+func recordNamespace(ctx context.Context, config *Config, store *indexer.Store, client *chain.Client) (recordid.Namespace, error) {
+	ns := recordid.Namespace{ChainID: uint64(config.Ethereum.ChainID)}
+	address, err := contractAddress(config)
+	if err != nil {
+		return ns, err
+	}
+	ns.Contract = address
+	if ns.ChainID > 0 {
+		return ns, nil
+	}
+
+	if store != nil {
+		if ns.ChainID, err = store.ChainID(); err != nil || ns.ChainID > 0 {
+			return ns, err
+		}
+	}
+	if client == nil {
+		if client, err = dialClient(ctx, config); err != nil {
+			return ns, err
+		}
+		defer client.Close()
+	}
+	id, err := client.ChainID(ctx)
+	if err != nil {
+		return ns, err
+	}
+	if !id.IsUint64() || id.Sign() == 0 {
+		return ns, errors.New("node reports an invalid chain ID")
+	}
+	ns.ChainID = id.Uint64()
+	return ns, nil
+}
+
+// recordID returns the canonical ID of an indexed record
+func recordID(ns recordid.Namespace, r *indexer.Record) recordid.ID {
+	return ns.ID(r.Key, r.Field, r.Version)
+}
+
+// recordEvents pairs indexed records with their IDs, as the HTTP API and
+// JSON output show them
+func recordEvents(ns recordid.Namespace, records []*indexer.Record) []api.Event {
+	events := make([]api.Event, 0, len(records))
+	for _, r := range records {
+		events = append(events, api.Event{ID: recordID(ns, r).String(), Record: r})
+	}
+	return events
+}
+
+// refID returns the canonical ID of a record reference
+func refID(ns recordid.Namespace, ref indexer.Ref) recordid.ID {
+	return ns.ID(ref.Key, ref.Field, ref.Version)
+}
+
+// resolveRef accepts a record ID wherever a key is expected. IDs must name
+// a record of the configured deployment; plain keys are returned with the
+// given field
+func resolveRef(ns recordid.Namespace, key, field string) (indexer.Ref, error) {
+	if !recordid.IsID(key) {
+		return indexer.Ref{Key: keyNamespace.Key(key), Field: field}, nil
+	}
+	id, err := recordid.Parse(key)
+	if err != nil {
+		return indexer.Ref{}, err
+	}
+	if err := ns.Check(id); err != nil {
+		return indexer.Ref{}, err
+	}
+	return indexer.Ref{Key: id.Key, Field: id.Field, Version: id.Version}, nil
+}
@@ -0,0 +1,433 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/importer"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/progress"
+	"contract-storage-eth/writerpool"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func runImport(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "input format: csv, jsonl or json (default from the file extension)")
+	batch := flags.Int("batch", 100, "records sent between checkpoints")
+	concurrency := flags.Int("concurrency", 8, "transactions of each nonce lane awaiting confirmation at once")
+	checkpointFile := flags.String("checkpoint", "", "checkpoint file (default FILE.checkpoint)")
+	restart := flags.Bool("restart", false, "ignore the checkpoint and import the whole file again")
+	raw := flags.Bool("raw", false, "write values as they are, as exported, instead of sealing them (tags are ignored)")
+	addSignerFlags(flags, config)
+	addPoolFlags(flags, config)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return fail(codeInvalidArgument, "import: the file to import is required")
+	}
+	if *batch <= 0 || *concurrency <= 0 {
+		return fail(codeInvalidArgument, "Invalid flags: --batch and --concurrency must be positive")
+	}
+	path := positional[0]
+	if *format == "" {
+		var err error
+		if *format, err = importer.FormatOf(path); err != nil {
+			return fail(codeInvalidArgument, "Invalid --format: %w", err)
+		}
+	}
+	if *checkpointFile == "" {
+		*checkpointFile = path + ".checkpoint"
+	}
+	_, viaSafe, err := safeAddress(config)
+	if err != nil {
+		return err
+	}
+	if viaSafe {
+		return errors.New("import sends its transactions directly, unset safe.address to use it")
+	}
+
+	cp, err := loadCheckpoint(*checkpointFile, path)
+	if *restart || errors.Is(err, os.ErrNotExist) {
+		cp, err = &importCheckpoint{Input: filepath.Base(path)}, nil
+	}
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid checkpoint: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Failed to open import file: %w", err)
+	}
+	defer f.Close()
+	reader, err := importer.NewReader(f, *format)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid import file: %w", err)
+	}
+
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	im, closeSigners, err := newImportRun(ctx, config, client, address, *concurrency)
+	if err != nil {
+		return err
+	}
+	defer closeSigners()
+	im.raw, im.cp, im.cpFile = *raw, cp, *checkpointFile
+	for _, i := range cp.Failed {
+		im.retry[i] = true
+	}
+	if cp.Next > 0 || len(cp.Failed) > 0 {
+		fmt.Printf("Resuming the import of %s at record %d (%d failed record(s) to retry)\n", path, cp.Next+1, len(cp.Failed))
+	}
+	if !noProgress {
+		total, err := countImport(path, *format, cp.Next, im.retry)
+		if err != nil {
+			return fail(codeInvalidArgument, "Invalid import file: %w", err)
+		}
+		im.progress = startProgress("Importing", total, progress.Options{Unit: "records", Rate: true})
+	}
+
+	// Read the records a batch at a time, skipping those imported already
+	var pending []importItem
+	var stopErr error
+	for i := 0; stopErr == nil; i++ {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			stopErr = fmt.Errorf("invalid import file: %w", err)
+			break
+		}
+		if i < cp.Next && !im.retry[i] {
+			im.skipped++
+			continue
+		}
+		pending = append(pending, importItem{index: i, record: rec})
+		if len(pending) == *batch {
+			stopErr = im.sendBatch(ctx, pending, i+1)
+			pending = nil
+		}
+	}
+	if stopErr == nil && len(pending) > 0 {
+		stopErr = im.sendBatch(ctx, pending, pending[len(pending)-1].index+1)
+	}
+	if stopErr == nil && ctx.Err() != nil {
+		stopErr = ctx.Err()
+	}
+
+	im.progress.Finish()
+	if err := saveCheckpoint(*checkpointFile, cp); err != nil {
+		return fmt.Errorf("Failed to save checkpoint: %w", err)
+	}
+	result := importResult{File: path, Imported: im.imported, Skipped: im.skipped, Failed: im.failures, Checkpoint: *checkpointFile}
+	if stopErr != nil {
+		printFailure("import_interrupted", stopErr.Error(), result)
+		return fmt.Errorf("Import stopped after %d record(s), run it again to resume: %w", im.imported, stopErr)
+	}
+	fmt.Printf("Imported %d record(s) from %s in %s, skipped %d imported earlier\n", im.imported, path, time.Since(im.start).Round(time.Second), im.skipped)
+	if len(im.failures) > 0 {
+		for _, failure := range im.failures {
+			fmt.Printf("  record %d (%s#%s) failed: %s\n", failure.Record, failure.Key, failure.Field, failure.Error)
+		}
+		fmt.Printf("%d record(s) failed, run the import again to retry them\n", len(im.failures))
+		printFailure("import_failed", fmt.Sprintf("%d record(s) failed", len(im.failures)), result)
+		return exitError(1)
+	}
+	printResult(result)
+	return nil
+}
+
+// importResult is the result of import in JSON output mode
+type importResult struct {
+	File       string          `json:"file"`
+	Imported   int             `json:"imported"`
+	Skipped    int             `json:"skipped"`
+	Failed     []importFailure `json:"failed,omitempty"`
+	Checkpoint string          `json:"checkpoint"`
+}
+
+// importFailure is a record whose transaction reverted. Record counts from 1
+type importFailure struct {
+	Record int    `json:"record"`
+	Key    string `json:"key"`
+	Field  string `json:"field"`
+	Error  string `json:"error"`
+}
+
+// importCheckpoint is how far an import went. Next is the index of the
+// first record not sent yet, and Failed the indexes of the earlier records
+// still to retry
+type importCheckpoint struct {
+	Input     string    `json:"input"`
+	Next      int       `json:"next"`
+	Failed    []int     `json:"failed,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// loadCheckpoint reads the checkpoint of an import of path. It returns an
+// error wrapping os.ErrNotExist when there is none
+func loadCheckpoint(file, path string) (*importCheckpoint, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cp importCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if cp.Input != filepath.Base(path) {
+		return nil, fmt.Errorf("%s is the checkpoint of %s, use --restart to import %s from the start", file, cp.Input, path)
+	}
+	return &cp, nil
+}
+
+func saveCheckpoint(file string, cp *importCheckpoint) error {
+	cp.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(file, data)
+}
+
+// newImportRun prepares writing records to a contract from the nonce lanes
+// of the writer pool, each keeping up to concurrency transactions in
+// flight. The returned function releases the signers
+func newImportRun(ctx context.Context, config *Config, client *chain.Client, address common.Address, concurrency int) (*importRun, func(), error) {
+	lanes, closeSigners, err := saveLanes(ctx, config, client, address)
+	if err != nil {
+		return nil, nil, fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	return &importRun{
+		config:      config,
+		lanes:       lanes,
+		concurrency: concurrency,
+		cp:          &importCheckpoint{},
+		retry:       map[int]bool{},
+		start:       time.Now(),
+	}, closeSigners, nil
+}
+
+// countImport returns the number of records of an import file left to
+// write, those before next being written already unless they are retried
+func countImport(path, format string, next int, retry map[int]bool) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	reader, err := importer.NewReader(f, format)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for i := 0; ; i++ {
+		if _, err := reader.Next(); err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if i >= next || retry[i] {
+			n++
+		}
+	}
+}
+
+// importRun sends the records of an import through a writer pool, whose
+// lanes number their transactions themselves so that several can be
+// awaited at once
+type importRun struct {
+	config      *Config
+	lanes       []writerpool.Lane
+	concurrency int
+	raw         bool
+
+	// cp is saved to cpFile after each batch, unless cpFile is empty
+	cp     *importCheckpoint
+	cpFile string
+	// retry holds the failed records of earlier runs not written yet
+	retry map[int]bool
+	start time.Time
+	// progress shows the records written or failed, nil without a bar
+	progress *progress.Bar
+
+	imported int
+	skipped  int
+	failures []importFailure
+	// onSent is called once each record is sent, and onResult once it is
+	// written or failed
+	onSent   func(index int, tx *types.Transaction)
+	onResult func(index int, tx common.Hash, err error)
+}
+
+type importItem struct {
+	index  int
+	record *importer.Record
+}
+
+// sendBatch writes the records of a batch and waits for all of them, then
+// checkpoints the import up to next. It stops sending when ctx is done or a
+// transaction cannot be sent, still waiting for those sent already. Records
+// that could not be sent are retried on resume, like failed ones
+func (im *importRun) sendBatch(ctx context.Context, items []importItem, next int) error {
+	var (
+		mu      sync.Mutex
+		sendErr error
+		failed  []int
+	)
+	fail := func(item importItem, tx common.Hash, err error) {
+		if im.onResult != nil {
+			im.onResult(item.index, tx, err)
+		}
+		failed = append(failed, item.index)
+		im.failures = append(im.failures, importFailure{Record: item.index + 1, Key: item.record.Key, Field: item.record.Field, Error: err.Error()})
+		im.progress.Add(1)
+	}
+	pool := writerpool.Start(ctx, im.lanes, writerpool.Options{
+		InFlight:  im.concurrency,
+		QueueSize: im.config.WriterPool.QueueSize,
+		OnSent: func(lane int, op writerpool.Op, tx *types.Transaction) {
+			mu.Lock()
+			defer mu.Unlock()
+			if im.onSent != nil {
+				im.onSent(items[op.ID].index, tx)
+			}
+			im.progress.SetNote(feeNote(tx))
+		},
+		OnResult: func(r writerpool.Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			item := items[r.Op.ID]
+			switch {
+			case !r.Sent():
+				if sendErr == nil {
+					sendErr = fmt.Errorf("record %d: %w", item.index+1, r.Err)
+				}
+				failed = append(failed, item.index)
+			case r.Err != nil:
+				fail(item, r.Tx.Hash(), r.Err)
+			default:
+				if im.onResult != nil {
+					im.onResult(item.index, r.Tx.Hash(), nil)
+				}
+				im.imported++
+				im.progress.Add(1)
+				delete(im.retry, item.index)
+			}
+		},
+	})
+
+	sent := len(items)
+	for i, item := range items {
+		mu.Lock()
+		stop := sendErr != nil
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			sent = i
+			break
+		}
+		// Records the schemas reject fail alone, without spending gas
+		value, err := im.value(ctx, item.record)
+		if err != nil {
+			mu.Lock()
+			fail(item, common.Hash{}, err)
+			mu.Unlock()
+			continue
+		}
+		// Raw records are as exported, with the keys as stored
+		key := item.record.Key
+		if !im.raw {
+			key = keyNamespace.Key(key)
+		}
+		if err := pool.Submit(ctx, writerpool.Op{Key: key, Field: item.record.Field, Value: value, ID: i}); err != nil {
+			sent = i
+			break
+		}
+	}
+	// Interrupting stops sending, but what was sent is awaited so that the
+	// checkpoint tells whether it was written
+	pool.Close()
+	if sendErr == nil && sent < len(items) {
+		sendErr = ctx.Err()
+	}
+
+	// Records from the first one not sent are sent again on resume. Those
+	// of earlier runs are still in retry
+	if sent < len(items) {
+		next = items[sent].index
+	}
+	im.cp.Next = max(im.cp.Next, next)
+	im.cp.Failed = im.cp.Failed[:0]
+	for i := range im.retry {
+		im.cp.Failed = append(im.cp.Failed, i)
+	}
+	im.cp.Failed = append(im.cp.Failed, failed...)
+	slices.Sort(im.cp.Failed)
+	im.cp.Failed = slices.Compact(im.cp.Failed)
+	if im.cpFile != "" {
+		if err := saveCheckpoint(im.cpFile, im.cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+
+	if im.progress != nil {
+		return sendErr
+	}
+	elapsed := time.Since(im.start).Seconds()
+	fmt.Printf("Wrote %d record(s), %d failed (%.1f/s)\n", im.imported, len(im.failures), float64(im.imported)/max(elapsed, 1))
+	return sendErr
+}
+
+// value returns what to store for rec
+func (im *importRun) value(ctx context.Context, rec *importer.Record) (string, error) {
+	if im.raw {
+		return rec.Value, nil
+	}
+	codec, err := checkSchema(im.config, rec.Key, rec.Field, []byte(rec.Value))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := sealValueAs(im.config, []byte(rec.Value), indexer.TagMeta(rec.Tags), codec)
+	if err != nil {
+		return "", err
+	}
+	return offloadValue(ctx, im.config, sealed)
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package importer reads the records of bulk imports from CSV, JSON Lines
+// or JSON files, including the files written by package export.
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"contract-storage-eth/export"
+	"contract-storage-eth/indexer"
+)
+
+// Formats of the files a Reader reads.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+)
+
+// Record is a record to write.
+type Record struct {
+	Key   string            `json:"key"`
+	Field string            `json:"field"`
+	Value string            `json:"value"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// FormatOf returns the format of a file from its extension: .csv, .jsonl or
+// .ndjson, and .json.
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s from its extension, want .csv, .jsonl, .ndjson or .json", path)
+}
+
+// Reader streams the records of a file, without loading it whole.
+type Reader struct {
+	next func() (*Record, error)
+	// n counts the records read so far, for error messages
+	n int
+}
+
+// NewReader reads records in the given format.
+//
+// CSV files start with a header row naming the columns after the sources of
+// export.Column: key, which is required, field, value and tag.NAME. The other
+// columns export writes, such as id or block, are ignored. JSON Lines files
+// hold a record object per line, and JSON files an array of record objects,
+// or an object with a "records" array as export --format json writes.
+// Unknown members of record objects are ignored.
+func NewReader(r io.Reader, format string) (*Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	case FormatJSON:
+		return newJSONReader(r)
+	}
+	return nil, fmt.Errorf("unknown format %q, want csv, jsonl or json", format)
+}
+
+// Next returns the next record, or io.EOF after the last one.
+func (r *Reader) Next() (*Record, error) {
+	rec, err := r.next()
+	if err == io.EOF {
+		return nil, err
+	}
+	r.n++
+	if err != nil {
+		return nil, fmt.Errorf("record %d: %w", r.n, err)
+	}
+	if rec.Key == "" {
+		return nil, fmt.Errorf("record %d: key is required", r.n)
+	}
+	return rec, nil
+}
+
+// ignoredSources are the columns of exports that do not go into records
+var ignoredSources = map[string]bool{
+	export.SourceID:     true,
+	export.SourceWriter: true,
+	export.SourceBlock:  true,
+	export.SourceTime:   true,
+	export.SourceTx:     true,
+}
+
+func newCSVReader(r io.Reader) (*Reader, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	header, err := in.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing CSV header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, name := range header {
+		// Spreadsheets may start the file with a byte order mark
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	if err := checkColumns(header, "CSV"); err != nil {
+		return nil, err
+	}
+
+	return &Reader{next: func() (*Record, error) {
+		row, err := in.Read()
+		if err != nil {
+			return nil, err
+		}
+		if len(row) > len(header) {
+			return nil, fmt.Errorf("%d cells for %d columns", len(row), len(header))
+		}
+		return rowRecord(header, row), nil
+	}}, nil
+}
+
+// checkColumns checks that the columns of a table name record attributes,
+// including the key
+func checkColumns(columns []string, kind string) error {
+	hasKey := false
+	for _, name := range columns {
+		tag, isTag := strings.CutPrefix(name, indexer.MetaTagPrefix)
+		switch {
+		case name == export.SourceKey:
+			hasKey = true
+		case name == export.SourceField, name == export.SourceValue, ignoredSources[name]:
+		case isTag && tag != "":
+		default:
+			return fmt.Errorf("unknown %s column %q, want key, field, value or tag.NAME", kind, name)
+		}
+	}
+	if !hasKey {
+		return fmt.Errorf("the %s columns have no key", kind)
+	}
+	return nil
+}
+
+// rowRecord maps the cells of a row to the record attributes their columns
+// hold
+func rowRecord(columns, row []string) *Record {
+	rec := &Record{}
+	for i, cell := range row {
+		switch name := columns[i]; name {
+		case export.SourceKey:
+			rec.Key = cell
+		case export.SourceField:
+			rec.Field = cell
+		case export.SourceValue:
+			rec.Value = cell
+		default:
+			// Empty cells are records without the tag
+			if tag, ok := strings.CutPrefix(name, indexer.MetaTagPrefix); ok && cell != "" {
+				if rec.Tags == nil {
+					rec.Tags = map[string]string{}
+				}
+				rec.Tags[tag] = cell
+			}
+		}
+	}
+	return rec
+}
+
+func newJSONLReader(r io.Reader) *Reader {
+	lines := bufio.NewScanner(r)
+	// Values can be large, up to what a transaction carries
+	lines.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Reader{next: func() (*Record, error) {
+		for lines.Scan() {
+			line := strings.TrimSpace(lines.Text())
+			if line == "" {
+				continue
+			}
+			rec := &Record{}
+			return rec, json.Unmarshal([]byte(line), rec)
+		}
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}}
+}
+
+func newJSONReader(r io.Reader) (*Reader, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	// An export document holds the array under "records"
+	if token == json.Delim('{') {
+		for {
+			if token, err = decoder.Token(); err != nil {
+				return nil, err
+			}
+			name, ok := token.(string)
+			if !ok {
+				return nil, errors.New(`the JSON object has no "records" array`)
+			}
+			if name == "records" {
+				break
+			}
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	if token != json.Delim('[') {
+		return nil, errors.New(`want a JSON array of records, or an object with a "records" array`)
+	}
+
+	return &Reader{next: func() (*Record, error) {
+		if !decoder.More() {
+			return nil, io.EOF
+		}
+		rec := &Record{}
+		return rec, decoder.Decode(rec)
+	}}, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/importer"
+)
+
+// readAll reads every record of a file written by package export, as
+// key#field followed by the tags
+func readAll(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	format, err := importer.FormatOf(strings.TrimSuffix(path, ".golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := importer.NewReader(f, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Value == "" {
+			t.Errorf("%s#%s has no value", rec.Key, rec.Field)
+		}
+		got = append(got, fmt.Sprintf("%s#%s%v", rec.Key, rec.Field, rec.Tags))
+	}
+}
+
+func TestReadExports(t *testing.T) {
+	// The golden files of package export, renamed for their format
+	for _, tt := range []struct {
+		path, ext, want string
+	}{
+		{"../export/testdata/csv_default.golden", ".csv", "invoice-42#pdfmap[] plain#notemap[] invoice-42#pdfmap[] doc#sha256map[] invoice-43#pdfmap[] report, \"final\"# notemap[]"},
+		{"../export/testdata/records_jsonl.golden", ".jsonl", "invoice-42#pdfmap[] plain#notemap[] invoice-42#pdfmap[env:prod team:billing] doc#sha256map[] invoice-43#pdfmap[env:staging] report, \"final\"# notemap[env:prod]"},
+		{"../export/testdata/state_json.golden", ".json", "plain#notemap[] invoice-42#pdfmap[env:prod team:billing] doc#sha256map[] invoice-43#pdfmap[env:staging] report, \"final\"# notemap[env:prod]"},
+	} {
+		if got := readAll(t, copyAs(t, tt.path, tt.ext)); strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: got %q, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+// copyAs copies a file to one with the given extension
+func copyAs(t *testing.T, path, ext string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := t.TempDir() + "/records" + ext + ".golden"
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestReadCSVTags(t *testing.T) {
+	r, err := importer.NewReader(strings.NewReader("\ufeffkey,value,tag.env\na,1,prod\nb,2,\n"), importer.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := r.Next()
+	if err != nil || first.Tags["env"] != "prod" {
+		t.Fatalf("got %+v, %v", first, err)
+	}
+	second, err := r.Next()
+	if err != nil || second.Tags != nil {
+		t.Fatalf("got %+v, %v, want no tags for an empty cell", second, err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		format, input string
+	}{
+		{importer.FormatCSV, ""},
+		{importer.FormatCSV, "field,value\npdf,1\n"},
+		{importer.FormatCSV, "key,owner\na,b\n"},
+		{importer.FormatCSV, "key,value\n,1\n"},
+		{importer.FormatJSONL, `{"key": "a"}` + "\n{\n"},
+		{importer.FormatJSON, `{"chain_id": 1}`},
+		{importer.FormatJSON, `"records"`},
+		{"xml", "<records/>"},
+	} {
+		r, err := importer.NewReader(strings.NewReader(tt.input), tt.format)
+		for err == nil {
+			_, err = r.Next()
+		}
+		if err == io.EOF {
+			t.Errorf("%s %q: read without error", tt.format, tt.input)
+		}
+	}
+}
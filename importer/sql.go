@@ -0,0 +1,55 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"database/sql"
+	"io"
+)
+
+// NewRowsReader reads records from the result of a database query, whose
+// columns are named as the columns of CSV files, such as
+// SELECT name AS key, content AS value. NULL reads as empty.
+func NewRowsReader(rows *sql.Rows) (*Reader, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkColumns(columns, "query"); err != nil {
+		return nil, err
+	}
+
+	cells := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range cells {
+		dest[i] = &cells[i]
+	}
+	return &Reader{next: func() (*Record, error) {
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cells))
+		for i, cell := range cells {
+			row[i] = cell.String
+		}
+		return rowRecord(columns, row), nil
+	}}, nil
+}
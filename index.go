@@ -0,0 +1,302 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/metrics"
+)
+
+const indexUsage = `Usage: contract-storage-eth index <command> [flags]
+
+Commands:
+  sync      Backfill DataSaved events into the local index, and with
+            --follow keep it in sync until interrupted
+  status    Show what the local index holds and how far it is synced
+  keys      List the indexed key/field pairs with their latest version
+  reset     Drop the indexed data, so that the next sync backfills from
+            index.start_block
+`
+
+func runIndex(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, indexUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "sync":
+		return runIndexSync(ctx, config, args[1:])
+	case "status":
+		return runIndexStatus(ctx, config, args[1:])
+	case "keys":
+		return runIndexKeys(config, args[1:])
+	case "reset":
+		return runIndexReset(config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown index command: %s\n\n%s", args[0], indexUsage)
+		return exitError(2)
+	}
+}
+
+func runIndexSync(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("index sync", flag.ContinueOnError)
+	follow := flags.Bool("follow", false, "keep syncing until interrupted")
+	interval := flags.Duration("interval", config.Index.SyncInterval, "delay between syncs with --follow")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return fail(codeIndex, "Failed to sync index: %w", err)
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return err
+	}
+
+	// The first sync backfills from index.start_block, which can take a
+	// while, so it is not bounded by timeouts.sync
+	start := time.Now()
+	head, err := ix.Sync(ctx)
+	if err != nil {
+		return fail(codeIndex, "Failed to sync index: %w", err)
+	}
+	sum, err := store.Summary()
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	fmt.Printf("Index synced to block %d in %s: %d record(s) of %d key(s)\n", head, time.Since(start).Round(time.Millisecond), sum.Records, sum.Keys)
+	if !*follow {
+		printResult(sum)
+		return nil
+	}
+
+	fmt.Println("Following new blocks, press Ctrl+C to stop")
+	keepIndexSynced(ctx, ix, *interval, config.Timeouts.Sync)
+	if sum, err = store.Summary(); err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	printResult(sum)
+	return nil
+}
+
+func runIndexStatus(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("index status", flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	sum, err := store.Summary()
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	// How far behind the chain the index is, when the node answers
+	status := indexStatus{Summary: sum}
+	if sum.NextBlock > 0 {
+		if client, err := dialClient(ctx, config); err == nil {
+			if head, err := client.BlockNumber(ctx); err == nil {
+				status.Head = head
+				if head >= sum.NextBlock {
+					status.Behind = head - sum.NextBlock + 1
+				}
+			}
+			client.Close()
+		}
+	}
+	if output.json {
+		printResult(status)
+		return nil
+	}
+
+	if sum.NextBlock == 0 {
+		fmt.Println("Index is empty, run `contract-storage-eth index sync` to backfill it")
+		return nil
+	}
+	fmt.Printf("Chain:        %d\n", sum.ChainID)
+	fmt.Printf("Next block:   %d\n", sum.NextBlock)
+	if status.Head > 0 {
+		fmt.Printf("Chain head:   %d (%d block(s) behind)\n", status.Head, status.Behind)
+	}
+	fmt.Printf("Records:      %d\n", sum.Records)
+	fmt.Printf("Keys:         %d\n", sum.Keys)
+	fmt.Printf("Transactions: %d\n", sum.Transactions)
+	return nil
+}
+
+// indexStatus is the result of index status in JSON output mode. Head is
+// 0 when the node could not be reached
+type indexStatus struct {
+	*indexer.Summary
+	Head   uint64 `json:"head,omitempty"`
+	Behind uint64 `json:"behind"`
+}
+
+func runIndexKeys(config *Config, args []string) error {
+	flags := flag.NewFlagSet("index keys", flag.ContinueOnError)
+	prefix := flags.String("prefix", "", "only list keys starting with this prefix")
+	allNamespaces := flags.Bool("all-namespaces", false, "list the keys of every namespace, not only the selected one")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if !*allNamespaces {
+		*prefix = keyNamespace.Key(*prefix)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	refs, err := store.Keys(*prefix)
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	if output.json {
+		printResult(keysResult{Keys: append([]indexer.Ref{}, refs...)})
+		return nil
+	}
+	if len(refs) == 0 {
+		fmt.Println("No matching keys")
+		return nil
+	}
+	for _, ref := range refs {
+		fmt.Printf("%s#%s  %d version(s)\n", ref.Key, ref.Field, ref.Version)
+	}
+	return nil
+}
+
+// keysResult is the result of index keys in JSON output mode
+type keysResult struct {
+	Keys []indexer.Ref `json:"keys"`
+}
+
+func runIndexReset(config *Config, args []string) error {
+	flags := flag.NewFlagSet("index reset", flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.Reset(); err != nil {
+		return fail(codeIndex, "Failed to reset index: %w", err)
+	}
+	fmt.Printf("Index cleared, the next sync starts at block %d\n", config.Index.StartBlock)
+	return nil
+}
+
+// openIndex opens the configured local index database
+func openIndex(config *Config) (*indexer.Store, error) {
+	path := config.Index.Path
+	if path == "" {
+		path = "index.db"
+	}
+	return indexer.Open(path)
+}
+
+// newIndexer creates an indexer for the configured contract
+func newIndexer(config *Config, client *chain.Client, store *indexer.Store) (*indexer.Indexer, error) {
+	address, err := contractAddress(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexer.New(client, address, store, indexer.Options{
+		StartBlock:   config.Index.StartBlock,
+		BatchSize:    config.Index.BatchSize,
+		ScanFailures: config.Index.ScanFailures,
+		OnHeaderCache: func(hit bool) {
+			metrics.CacheLookup("headers", hit)
+		},
+		OnProgress: indexProgress(),
+	}), nil
+}
+
+// checkIndexChain records the chain of a new index and refuses to mix
+// records of another chain into it
+func checkIndexChain(ctx context.Context, client chain.ChainClient, store *indexer.Store) error {
+	id, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	known, err := store.ChainID()
+	if err != nil {
+		return err
+	}
+	switch {
+	case known == 0:
+		return store.SetChainID(id.Uint64())
+	case known != id.Uint64():
+		return fmt.Errorf("index was built from chain %d but the node is on chain %d, point index.path elsewhere", known, id.Uint64())
+	}
+	return nil
+}
+
+// syncIndex brings the local index up to date with the contract events
+func syncIndex(ctx context.Context, config *Config, store *indexer.Store) error {
+	ctx, cancel := withTimeout(ctx, config.Timeouts.Sync)
+	defer cancel()
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return err
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return err
+	}
+	head, err := ix.Sync(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Index synced to block %d\n", head)
+	return nil
+}
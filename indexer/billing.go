@@ -0,0 +1,181 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Ways of attributing records to tenants.
+const (
+	// TenantByKeyPrefix uses the part of the key before the separator.
+	TenantByKeyPrefix = "key_prefix"
+	// TenantByTag uses the value of a record tag.
+	TenantByTag = "tag"
+	// TenantByWriter uses the address that sent the transaction.
+	TenantByWriter = "writer"
+)
+
+// Unattributed is the tenant of records that cannot be attributed, such as
+// keys without a prefix or failed transactions, which emit no record.
+const Unattributed = "(unattributed)"
+
+// Tenancy attributes records to tenants for billing.
+type Tenancy struct {
+	// By is TenantByKeyPrefix (the default), TenantByTag or TenantByWriter.
+	By string
+	// Separator ends the key prefix, "/" by default.
+	Separator string
+	// Tag is the tag naming the tenant, "tenant" by default.
+	Tag string
+}
+
+// ParseTenancy checks the attribution mode and fills in defaults.
+func ParseTenancy(by, separator, tag string) (Tenancy, error) {
+	t := Tenancy{By: by, Separator: separator, Tag: tag}
+	switch t.By {
+	case "":
+		t.By = TenantByKeyPrefix
+	case TenantByKeyPrefix, TenantByTag, TenantByWriter:
+	default:
+		return Tenancy{}, fmt.Errorf("unknown tenant attribution %q, want %s, %s or %s", by, TenantByKeyPrefix, TenantByTag, TenantByWriter)
+	}
+	if t.Separator == "" {
+		t.Separator = "/"
+	}
+	if t.Tag == "" {
+		t.Tag = "tenant"
+	}
+	return t, nil
+}
+
+// Tenant returns the tenant a record is billed to.
+func (t Tenancy) Tenant(r *Record) string {
+	switch t.By {
+	case TenantByTag:
+		if v := r.Tags[t.Tag]; v != "" {
+			return v
+		}
+	case TenantByWriter:
+		return r.Writer.Hex()
+	default:
+		if prefix, _, ok := strings.Cut(r.Key, t.Separator); ok && prefix != "" {
+			return prefix
+		}
+	}
+	return Unattributed
+}
+
+// failedTenant returns the tenant of a failed transaction.
+func (t Tenancy) failedTenant(tx *Transaction) string {
+	if t.By == TenantByWriter {
+		return tx.From.Hex()
+	}
+	return Unattributed
+}
+
+// Usage is the activity billed to one tenant.
+type Usage struct {
+	Tenant string `json:"tenant"`
+	Writes int    `json:"writes"`
+	// StorageBytes counts the bytes of key, field and value written.
+	StorageBytes uint64 `json:"storage_bytes"`
+	// GasUsed includes failed transactions. A transaction saving records
+	// of several tenants is split evenly between them.
+	GasUsed  uint64 `json:"gas_used"`
+	Failures int    `json:"failures"`
+}
+
+// Usage aggregates indexed activity per tenant, for records and
+// transactions with a timestamp in [from, to); zero times leave the range
+// open. Tenants are sorted by name.
+func (s *Store) Usage(tenancy Tenancy, from, to time.Time) ([]*Usage, error) {
+	inRange := func(ts uint64) bool {
+		t := time.Unix(int64(ts), 0).UTC()
+		return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+	}
+
+	usage := map[string]*Usage{}
+	tenant := func(name string) *Usage {
+		u, ok := usage[name]
+		if !ok {
+			u = &Usage{Tenant: name}
+			usage[name] = u
+		}
+		return u
+	}
+
+	// Records and transactions are read from one snapshot, so that a sync
+	// committing in between cannot leave gas without its records
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// Tenants of the records of each transaction, to split its gas
+		tenants := map[common.Hash][]string{}
+		err := eachRecord(tx, func(r *Record) error {
+			if !inRange(r.Timestamp) {
+				return nil
+			}
+			name := tenancy.Tenant(r)
+			u := tenant(name)
+			u.Writes++
+			u.StorageBytes += uint64(len(r.Key) + len(r.Field) + len(r.Value))
+			tenants[r.TxHash] = append(tenants[r.TxHash], name)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return eachTransaction(tx, func(t *Transaction) error {
+			if !inRange(t.Timestamp) {
+				return nil
+			}
+			if t.Failed {
+				u := tenant(tenancy.failedTenant(t))
+				u.Failures++
+				u.GasUsed += t.GasUsed
+				return nil
+			}
+			names := tenants[t.Hash]
+			if len(names) == 0 {
+				return nil
+			}
+			share, rest := t.GasUsed/uint64(len(names)), t.GasUsed%uint64(len(names))
+			for i, name := range names {
+				u := tenant(name)
+				u.GasUsed += share
+				if uint64(i) < rest {
+					u.GasUsed++
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Usage, 0, len(usage))
+	for _, u := range usage {
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tenant < result[j].Tenant })
+	return result, nil
+}
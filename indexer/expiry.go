@@ -0,0 +1,73 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"contract-storage-eth/envelope"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MetaExpiresAt is the envelope metadata attribute holding the time a
+// record expires at, in RFC 3339.
+const MetaExpiresAt = "expires_at"
+
+// ExpiryMeta returns the envelope metadata storing an expiry time.
+func ExpiryMeta(t time.Time) map[string]string {
+	return map[string]string{MetaExpiresAt: t.UTC().Format(time.RFC3339)}
+}
+
+// expiresAt returns the expiry a stored value carries in its envelope, nil
+// when it has none.
+func expiresAt(value string) *time.Time {
+	env, err := envelope.Parse(value)
+	if err != nil || env.Meta[MetaExpiresAt] == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, env.Meta[MetaExpiresAt])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// Expired returns the latest version of every key/field pair that still
+// holds a value and expired by now, in the order they expired. Earlier
+// versions are not returned, as writing the pair again renews it.
+func (s *Store) Expired(now time.Time) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHeads).ForEach(func(k, v []byte) error {
+			n := binary.BigEndian.Uint32(k[:4])
+			ref := Ref{Key: string(k[4 : 4+n]), Field: string(k[4+n:]), Version: binary.BigEndian.Uint64(v)}
+			r, err := getRecord(tx, recordID(tx, ref))
+			if err != nil {
+				return err
+			}
+			if r.Value != "" && r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+				records = append(records, r)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ExpiresAt.Before(*records[j].ExpiresAt)
+	})
+	return records, err
+}
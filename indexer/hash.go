@@ -0,0 +1,84 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"contract-storage-eth/envelope"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ContentHashes returns the hashes under which content would be indexed:
+// its SHA-256 and Keccak-256 digests.
+func ContentHashes(content []byte) [][]byte {
+	sum := sha256.Sum256(content)
+	return [][]byte{sum[:], crypto.Keccak256(content)}
+}
+
+// valueHashes returns every content hash a stored value anchors. A value
+// anchors a document either by containing it (its digests, or the digest
+// recorded in its envelope) or by being the hex encoded hash of it.
+func valueHashes(value string) [][]byte {
+	var hashes [][]byte
+	add := func(h []byte) {
+		if len(h) != 32 {
+			return
+		}
+		for _, existing := range hashes {
+			if bytes.Equal(existing, h) {
+				return
+			}
+		}
+		hashes = append(hashes, h)
+	}
+
+	content := []byte(value)
+	if env, err := envelope.Parse(value); err == nil {
+		add(env.Digest)
+		if opened, err := env.Open(); err == nil {
+			content = opened
+		}
+	}
+
+	for _, h := range ContentHashes(content) {
+		add(h)
+	}
+
+	text := strings.TrimPrefix(strings.TrimSpace(string(content)), "0x")
+	if len(text) == 64 {
+		if h, err := hex.DecodeString(text); err == nil {
+			add(h)
+		}
+	}
+
+	return hashes
+}
+
+// Anchors reports whether the record's value anchors content.
+func (r *Record) Anchors(content []byte) bool {
+	for _, h := range valueHashes(r.Value) {
+		for _, c := range ContentHashes(content) {
+			if bytes.Equal(h, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
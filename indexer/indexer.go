@@ -0,0 +1,245 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package indexer copies DataSaved events of the storage contract into a
+// local database, so records can be looked up without scanning the chain.
+package indexer
+
+import (
+	"context"
+	"math/big"
+
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend is the subset of the Ethereum client used by the indexer.
+type Backend interface {
+	ethereum.LogFilterer
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Options configure an Indexer.
+type Options struct {
+	// StartBlock is the first block scanned on an empty index, usually the
+	// deployment block of the contract.
+	StartBlock uint64
+	// BatchSize is the number of blocks requested per eth_getLogs call.
+	BatchSize uint64
+	// ScanFailures also records reverted transactions sent to the
+	// contract. Reverts emit no events, so this fetches every block in
+	// full and is much slower.
+	ScanFailures bool
+	// OnHeaderCache is called on each lookup of the block header cache,
+	// with whether the header was cached.
+	OnHeaderCache func(hit bool)
+	// OnProgress is called after each batch of blocks a sync commits, with
+	// the blocks scanned so far and those the sync scans in all.
+	OnProgress func(scanned, total uint64)
+}
+
+const (
+	defaultBatchSize = 2000
+	maxCachedHeaders = 1024
+)
+
+// Indexer keeps a Store in sync with the contract events.
+type Indexer struct {
+	backend Backend
+	address common.Address
+	store   *Store
+	opts    Options
+	headers map[uint64]*types.Header
+}
+
+// New returns an indexer for the contract at address.
+func New(backend Backend, address common.Address, store *Store, opts Options) *Indexer {
+	if opts.BatchSize == 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	return &Indexer{backend: backend, address: address, store: store, opts: opts, headers: map[uint64]*types.Header{}}
+}
+
+// Sync indexes all events up to the current head and returns the head.
+func (ix *Indexer) Sync(ctx context.Context) (uint64, error) {
+	head, err := ix.backend.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	from, err := ix.store.NextBlock(ix.opts.StartBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	first := from
+	for from <= head {
+		to := from + ix.opts.BatchSize - 1
+		if to > head {
+			to = head
+		}
+
+		records, txs, err := ix.scan(ctx, from, to)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := ix.store.Commit(records, txs, to+1); err != nil {
+			return 0, err
+		}
+		if ix.opts.OnProgress != nil {
+			ix.opts.OnProgress(to+1-first, head+1-first)
+		}
+		from = to + 1
+	}
+
+	return head, nil
+}
+
+// scan collects the records and transactions in the inclusive block range.
+func (ix *Indexer) scan(ctx context.Context, from, to uint64) ([]*Record, []*Transaction, error) {
+	q, err := storage.DataSavedQuery(ix.address, from, &to)
+	if err != nil {
+		return nil, nil, err
+	}
+	logs, err := ix.backend.FilterLogs(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txs := map[common.Hash]*Transaction{}
+	var order []*Transaction
+	var records []*Record
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+		ev, err := storage.ParseDataSaved(l)
+		if err != nil {
+			continue
+		}
+
+		t, ok := txs[l.TxHash]
+		if !ok {
+			t, err = ix.transaction(ctx, l.TxHash, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			txs[l.TxHash] = t
+			order = append(order, t)
+		}
+
+		records = append(records, &Record{
+			Key:         ev.Key,
+			Field:       ev.Field,
+			Value:       ev.Value,
+			BlockNumber: l.BlockNumber,
+			BlockHash:   l.BlockHash,
+			TxHash:      l.TxHash,
+			LogIndex:    l.Index,
+			Timestamp:   t.Timestamp,
+			Writer:      t.From,
+		})
+	}
+
+	if ix.opts.ScanFailures {
+		for n := from; n <= to; n++ {
+			block, err := ix.backend.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, tx := range block.Transactions() {
+				if tx.To() == nil || *tx.To() != ix.address || txs[tx.Hash()] != nil {
+					continue
+				}
+				t, err := ix.transaction(ctx, tx.Hash(), tx)
+				if err != nil {
+					return nil, nil, err
+				}
+				if t.Failed {
+					txs[tx.Hash()] = t
+					order = append(order, t)
+				}
+			}
+		}
+	}
+
+	return records, order, nil
+}
+
+// transaction looks up the details of a transaction; tx may be nil.
+func (ix *Indexer) transaction(ctx context.Context, hash common.Hash, tx *types.Transaction) (*Transaction, error) {
+	receipt, err := ix.backend.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		tx, _, err = ix.backend.TransactionByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	header, err := ix.header(ctx, receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := types.Sender(senderSigner(tx), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		Hash:        hash,
+		BlockNumber: receipt.BlockNumber.Uint64(),
+		Timestamp:   header.Time,
+		From:        from,
+		GasUsed:     receipt.GasUsed,
+		Failed:      receipt.Status != types.ReceiptStatusSuccessful,
+	}, nil
+}
+
+func (ix *Indexer) header(ctx context.Context, number *big.Int) (*types.Header, error) {
+	h, ok := ix.headers[number.Uint64()]
+	if ix.opts.OnHeaderCache != nil {
+		ix.opts.OnHeaderCache(ok)
+	}
+	if ok {
+		return h, nil
+	}
+	h, err := ix.backend.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if len(ix.headers) > maxCachedHeaders {
+		ix.headers = map[uint64]*types.Header{}
+	}
+	ix.headers[number.Uint64()] = h
+	return h, nil
+}
+
+// senderSigner returns a signer able to recover the sender of tx.
+func senderSigner(tx *types.Transaction) types.Signer {
+	if !tx.Protected() {
+		return types.HomesteadSigner{}
+	}
+	return types.LatestSignerForChainID(tx.ChainId())
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+)
+
+// syncFixture indexes the recorded chain into a new store
+func syncFixture(t *testing.T) *indexer.Store {
+	t.Helper()
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	// Small batches so that records cross batch boundaries
+	ix := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1, BatchSize: 2, ScanFailures: true})
+	head, err := ix.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := chain.BlockNumber(context.Background()); head != want {
+		t.Fatalf("synced to %d, want %d", head, want)
+	}
+
+	// Syncing again finds nothing new
+	if _, err := ix.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSyncRecordedChain(t *testing.T) {
+	store := syncFixture(t)
+
+	records, err := store.FindByTags(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures.GoldenJSON(t, "records", records)
+}
+
+func TestSyncProgress(t *testing.T) {
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	var got [][2]uint64
+	ix := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1, BatchSize: 2, OnProgress: func(scanned, total uint64) {
+		got = append(got, [2]uint64{scanned, total})
+	}})
+	head, err := ix.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != int(head+1)/2 {
+		t.Fatalf("got %d progress reports for %d blocks in batches of 2", len(got), head)
+	}
+	for i, p := range got {
+		if p[1] != head || p[0] != min(uint64(2*(i+1)), head) {
+			t.Errorf("report %d: %d of %d blocks, want %d of %d", i, p[0], p[1], min(uint64(2*(i+1)), head), head)
+		}
+	}
+}
+
+func TestLineage(t *testing.T) {
+	store := syncFixture(t)
+
+	for _, ref := range []indexer.Ref{{Key: "invoice-42", Field: "pdf"}, {Key: "invoice-42", Field: "pdf", Version: 1}} {
+		chain, err := store.Lineage(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var refs []string
+		for _, r := range chain {
+			refs = append(refs, r.Ref().String())
+		}
+		if len(refs) != 2 || refs[0] != "invoice-42#pdf@2" || refs[1] != "invoice-42#pdf@1" {
+			t.Errorf("lineage of %s: %v", ref, refs)
+		}
+	}
+	if _, err := store.Lineage(indexer.Ref{Key: "missing", Field: "pdf"}); err != indexer.ErrNotFound {
+		t.Errorf("lineage of a missing record: %v", err)
+	}
+}
+
+func TestFindByTags(t *testing.T) {
+	store := syncFixture(t)
+
+	got := map[string][]string{}
+	for name, filter := range map[string]map[string]string{
+		"env=prod":              {"env": "prod"},
+		"env=staging":           {"env": "staging"},
+		"env=prod,team=billing": {"env": "prod", "team": "billing"},
+		"env=prod,team=other":   {"env": "prod", "team": "other"},
+		"env=pro":               {"env": "pro"},
+	} {
+		records, err := store.FindByTags(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[name] = []string{}
+		for _, r := range records {
+			got[name] = append(got[name], r.Ref().String())
+		}
+	}
+	fixtures.GoldenJSON(t, "tags", got)
+}
+
+func TestQuery(t *testing.T) {
+	store := syncFixture(t)
+
+	pdf := "pdf"
+	for name, tc := range map[string]struct {
+		query indexer.Query
+		want  []string
+	}{
+		"all":         {indexer.Query{}, []string{"invoice-42#pdf@1", "plain#note@1", "invoice-42#pdf@2", "doc#sha256@1", "invoice-43#pdf@1"}},
+		"key":         {indexer.Query{Key: "invoice-42"}, []string{"invoice-42#pdf@1", "invoice-42#pdf@2"}},
+		"field":       {indexer.Query{Field: &pdf, FromBlock: 3}, []string{"invoice-42#pdf@2", "invoice-43#pdf@1"}},
+		"blocks":      {indexer.Query{FromBlock: 3, ToBlock: 5}, []string{"invoice-42#pdf@2", "doc#sha256@1"}},
+		"tags, limit": {indexer.Query{Tags: map[string]string{"env": "prod"}, Limit: 1}, []string{"invoice-42#pdf@2"}},
+		"limit":       {indexer.Query{Limit: 2}, []string{"invoice-42#pdf@1", "plain#note@1"}},
+	} {
+		records, err := store.Query(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range records {
+			got = append(got, r.Ref().String())
+		}
+		if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("%s: got %v, want %v", name, got, tc.want)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	store := syncFixture(t)
+
+	for prefix, want := range map[string]string{
+		"":         "doc#sha256@1 invoice-42#pdf@2 invoice-43#pdf@1 plain#note@1",
+		"invoice-": "invoice-42#pdf@2 invoice-43#pdf@1",
+		"missing":  "",
+	} {
+		refs, err := store.Keys(prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, ref := range refs {
+			got = append(got, ref.String())
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("%q: got %v, want %s", prefix, got, want)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	store := syncFixture(t)
+
+	records, err := store.History("invoice-42", "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range records {
+		got = append(got, r.Ref().String())
+	}
+	if want := "invoice-42#pdf@1 invoice-42#pdf@2"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+	if records[0].BlockNumber > records[1].BlockNumber {
+		t.Errorf("versions out of chain order: blocks %d, %d", records[0].BlockNumber, records[1].BlockNumber)
+	}
+
+	if _, err := store.History("invoice-42", "xml"); !errors.Is(err, indexer.ErrNotFound) {
+		t.Errorf("got %v for a field never written, want ErrNotFound", err)
+	}
+}
+
+func TestSummaryAndReset(t *testing.T) {
+	store := syncFixture(t)
+	if err := store.SetChainID(1337); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := store.Summary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := store.FindByTags(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.ChainID != 1337 || sum.NextBlock == 0 || sum.Records != len(records) || sum.Keys != 4 || sum.Transactions == 0 {
+		t.Errorf("got %+v for %d records", sum, len(records))
+	}
+
+	if err := store.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if sum, err = store.Summary(); err != nil {
+		t.Fatal(err)
+	}
+	if *sum != (indexer.Summary{}) {
+		t.Errorf("got %+v after reset", sum)
+	}
+}
+
+// TestAnchors checks the records proving that a document was stored, by
+// content or by hash.
+func TestAnchors(t *testing.T) {
+	store := syncFixture(t)
+
+	got := map[string][]string{}
+	for _, content := range []string{"invoice 42 v1", "invoice 42 v2", "invoice 43 v1", "anchored document", "legacy plain value", "never stored"} {
+		for i, h := range indexer.ContentHashes([]byte(content)) {
+			name := content + " " + []string{"sha256", "keccak256"}[i]
+			got[name] = []string{}
+			records, err := store.FindByValueHash(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range records {
+				if !r.Anchors([]byte(content)) {
+					t.Errorf("%s found by hash but does not anchor %q", r.Ref(), content)
+				}
+				got[name] = append(got[name], r.Ref().String())
+			}
+		}
+	}
+	fixtures.GoldenJSON(t, "anchors", got)
+
+	sum := sha256.Sum256([]byte("anchored document"))
+	if records, _ := store.FindByValueHash(sum[:]); len(records) != 1 || records[0].Key != "doc" {
+		t.Errorf("hex anchored document: %v", records)
+	}
+}
+
+func TestStatsExport(t *testing.T) {
+	store := syncFixture(t)
+
+	for _, granularity := range []string{"hour", "day"} {
+		width, err := indexer.ParseGranularity(granularity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buckets, err := store.Stats(width, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures.GoldenJSON(t, "stats_"+granularity, buckets)
+	}
+}
+
+func TestUsageExport(t *testing.T) {
+	store := syncFixture(t)
+
+	for _, tc := range []struct{ by, separator, tag string }{
+		{indexer.TenantByKeyPrefix, "-", ""},
+		{indexer.TenantByTag, "", "env"},
+		{indexer.TenantByWriter, "", ""},
+	} {
+		tenancy, err := indexer.ParseTenancy(tc.by, tc.separator, tc.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		usage, err := store.Usage(tenancy, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures.GoldenJSON(t, "usage_"+tc.by, usage)
+	}
+
+	if _, err := indexer.ParseTenancy("team", "", ""); err == nil {
+		t.Error("unknown tenant attribution accepted")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	seal := func(expiry time.Time) string {
+		value, err := envelope.Seal([]byte("temporary"), envelope.Options{Meta: indexer.ExpiryMeta(expiry)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	records := []*indexer.Record{
+		{Key: "session-1", Value: seal(now.Add(-time.Hour))},
+		{Key: "session-2", Value: seal(now.Add(-2 * time.Hour))},
+		{Key: "session-3", Value: seal(now.Add(time.Hour))},
+		// Renewed, then expired and deleted
+		{Key: "session-4", Value: seal(now.Add(-time.Hour))},
+		{Key: "session-4", Value: seal(now.Add(time.Hour))},
+		{Key: "session-5", Value: seal(now.Add(-time.Hour))},
+		{Key: "session-5", Value: ""},
+		{Key: "permanent", Value: "kept"},
+	}
+	for i, r := range records {
+		r.BlockNumber = uint64(i + 1)
+	}
+	if err := store.Commit(records, nil, uint64(len(records)+1)); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := store.Expired(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range expired {
+		got = append(got, r.Key)
+	}
+	if want := "session-2 session-1"; strings.Join(got, " ") != want {
+		t.Errorf("expired %v, want %s", got, want)
+	}
+}
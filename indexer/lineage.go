@@ -0,0 +1,291 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"contract-storage-eth/envelope"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MetaSupersedes is the envelope metadata attribute naming the record a
+// value supersedes, as formatted by Ref.String.
+const MetaSupersedes = "supersedes"
+
+// ErrNotFound is returned when a referenced record is not indexed.
+var ErrNotFound = errors.New("record not found")
+
+// Ref identifies a version of a key/field. Versions count the writes to a
+// key/field in chain order, starting at 1; version 0 means the latest one.
+type Ref struct {
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Version uint64 `json:"version,omitempty"`
+}
+
+// String formats the reference as key#field, followed by @version when the
+// version is set.
+func (r Ref) String() string {
+	s := r.Key + "#" + r.Field
+	if r.Version > 0 {
+		s += "@" + strconv.FormatUint(r.Version, 10)
+	}
+	return s
+}
+
+// ParseRef parses key[#field][@version]. field is used when the reference
+// does not name one.
+func ParseRef(s, field string) (Ref, error) {
+	ref := Ref{Field: field}
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		version, err := strconv.ParseUint(s[i+1:], 10, 64)
+		if err != nil || version == 0 {
+			return Ref{}, fmt.Errorf("invalid record version in %q", s)
+		}
+		ref.Version = version
+		s = s[:i]
+	}
+	if i := strings.LastIndex(s, "#"); i >= 0 {
+		ref.Field = s[i+1:]
+		s = s[:i]
+	}
+	ref.Key = s
+	if ref.Key == "" {
+		return Ref{}, fmt.Errorf("missing record key in %q", s)
+	}
+	return ref, nil
+}
+
+// Ref returns the reference of the record.
+func (r *Record) Ref() Ref {
+	return Ref{Key: r.Key, Field: r.Field, Version: r.Version}
+}
+
+// supersedes returns the reference a stored value declares, if any.
+func supersedes(value string) (Ref, bool) {
+	env, err := envelope.Parse(value)
+	if err != nil || env.Meta[MetaSupersedes] == "" {
+		return Ref{}, false
+	}
+	ref, err := ParseRef(env.Meta[MetaSupersedes], "")
+	return ref, err == nil
+}
+
+// keyField is the database key of a key/field pair.
+func keyField(key, field string) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(key)))
+	b = append(b, key...)
+	return append(b, field...)
+}
+
+// latestVersion returns the number of writes to a key/field so far.
+func latestVersion(tx *bolt.Tx, key, field string) uint64 {
+	if v := tx.Bucket(bucketHeads).Get(keyField(key, field)); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+// recordID returns the id of a record version.
+func recordID(tx *bolt.Tx, ref Ref) []byte {
+	if ref.Version == 0 {
+		ref.Version = latestVersion(tx, ref.Key, ref.Field)
+	}
+	return tx.Bucket(bucketVersions).Get(binary.BigEndian.AppendUint64(keyField(ref.Key, ref.Field), ref.Version))
+}
+
+func getRecord(tx *bolt.Tx, id []byte) (*Record, error) {
+	data := tx.Bucket(bucketRecords).Get(id)
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func putRecord(tx *bolt.Tx, r *Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketRecords).Put(r.id(), data)
+}
+
+// link assigns the next version of its key/field to r and resolves the
+// record it supersedes, adding r to that record's successors. It must run
+// in chain order, before r is stored.
+func link(tx *bolt.Tx, r *Record) error {
+	if ref, ok := supersedes(r.Value); ok {
+		if ref.Field == "" {
+			ref.Field = r.Field
+		}
+		if ref.Version == 0 {
+			ref.Version = latestVersion(tx, ref.Key, ref.Field)
+		}
+		if id := recordID(tx, ref); id != nil {
+			target, err := getRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			r.Supersedes = &ref
+			target.SupersededBy = append(target.SupersededBy, Ref{Key: r.Key, Field: r.Field, Version: latestVersion(tx, r.Key, r.Field) + 1})
+			if err := putRecord(tx, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	kf := keyField(r.Key, r.Field)
+	r.Version = latestVersion(tx, r.Key, r.Field) + 1
+	if err := tx.Bucket(bucketHeads).Put(kf, binary.BigEndian.AppendUint64(nil, r.Version)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketVersions).Put(binary.BigEndian.AppendUint64(kf, r.Version), r.id())
+}
+
+// Get returns the record version ref points to.
+func (s *Store) Get(ref Ref) (*Record, error) {
+	var r *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := recordID(tx, ref)
+		if id == nil {
+			return ErrNotFound
+		}
+		var err error
+		r, err = getRecord(tx, id)
+		return err
+	})
+	return r, err
+}
+
+// Fields returns the fields written under key, in byte order.
+func (s *Store) Fields(key string) ([]string, error) {
+	var fields []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := keyField(key, "")
+		c := tx.Bucket(bucketHeads).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			fields = append(fields, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return fields, err
+}
+
+// History returns every version of a key/field pair, oldest first, or
+// ErrNotFound when it was never written.
+func (s *Store) History(key, field string) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		latest := latestVersion(tx, key, field)
+		if latest == 0 {
+			return ErrNotFound
+		}
+		for v := uint64(1); v <= latest; v++ {
+			r, err := getRecord(tx, recordID(tx, Ref{Key: key, Field: field, Version: v}))
+			if err != nil {
+				return err
+			}
+			records = append(records, r)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Keys returns the latest version of every key/field pair whose key starts
+// with prefix, ordered by key and then by field.
+func (s *Store) Keys(prefix string) ([]Ref, error) {
+	var refs []Ref
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHeads).ForEach(func(k, v []byte) error {
+			n := binary.BigEndian.Uint32(k[:4])
+			key, field := string(k[4:4+n]), string(k[4+n:])
+			if strings.HasPrefix(key, prefix) {
+				refs = append(refs, Ref{Key: key, Field: field, Version: binary.BigEndian.Uint64(v)})
+			}
+			return nil
+		})
+	})
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Key != refs[j].Key {
+			return refs[i].Key < refs[j].Key
+		}
+		return refs[i].Field < refs[j].Field
+	})
+	return refs, err
+}
+
+// Lineage returns the chain of records ref belongs to, newest first: the
+// latest record superseding it (directly or through other corrections),
+// down to the original record that started the chain.
+func (s *Store) Lineage(ref Ref) ([]*Record, error) {
+	var chain []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := recordID(tx, ref)
+		if id == nil {
+			return ErrNotFound
+		}
+		r, err := getRecord(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Follow the newest correction forward, then the links backward
+		seen := map[Ref]bool{r.Ref(): true}
+		for len(r.SupersededBy) > 0 {
+			next := r.SupersededBy[len(r.SupersededBy)-1]
+			if seen[next] {
+				break
+			}
+			seen[next] = true
+			id := recordID(tx, next)
+			if id == nil {
+				break
+			}
+			if r, err = getRecord(tx, id); err != nil {
+				return err
+			}
+		}
+
+		chain = append(chain, r)
+		seen = map[Ref]bool{r.Ref(): true}
+		for r.Supersedes != nil && !seen[*r.Supersedes] {
+			seen[*r.Supersedes] = true
+			id := recordID(tx, *r.Supersedes)
+			if id == nil {
+				break
+			}
+			if r, err = getRecord(tx, id); err != nil {
+				return err
+			}
+			chain = append(chain, r)
+		}
+		return nil
+	})
+	return chain, err
+}
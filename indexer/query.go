@@ -0,0 +1,80 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Query selects indexed records. The zero Query selects every record.
+type Query struct {
+	// Key selects the records of one key, every key when empty.
+	Key string
+	// Field selects the records of one field, every field when nil.
+	Field *string
+	// FromBlock and ToBlock bound the blocks of the records, inclusively.
+	// A zero ToBlock leaves the range open.
+	FromBlock uint64
+	ToBlock   uint64
+	// FromLog is the first log index of FromBlock to return, so that
+	// pages can end within a block.
+	FromLog uint
+	// Tags selects the records carrying every given tag.
+	Tags map[string]string
+	// Limit caps the number of records returned, unlimited when zero.
+	Limit int
+}
+
+func (q Query) matches(r *Record) bool {
+	if q.Key != "" && r.Key != q.Key {
+		return false
+	}
+	if q.Field != nil && r.Field != *q.Field {
+		return false
+	}
+	return r.hasTags(q.Tags)
+}
+
+// Query returns the records selected by q, in chain order.
+func (s *Store) Query(q Query) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		start := make([]byte, 12)
+		binary.BigEndian.PutUint64(start[:8], q.FromBlock)
+		binary.BigEndian.PutUint32(start[8:], uint32(q.FromLog))
+		c := tx.Bucket(bucketRecords).Cursor()
+		for k, data := c.Seek(start); k != nil; k, data = c.Next() {
+			if q.ToBlock > 0 && binary.BigEndian.Uint64(k[:8]) > q.ToBlock {
+				break
+			}
+			var r Record
+			if err := json.Unmarshal(data, &r); err != nil {
+				return err
+			}
+			if !q.matches(&r) {
+				continue
+			}
+			records = append(records, &r)
+			if q.Limit > 0 && len(records) == q.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Bucket holds the activity of one time bucket.
+type Bucket struct {
+	Start         time.Time `json:"start"`
+	Writes        int       `json:"writes"`
+	UniqueWriters int       `json:"unique_writers"`
+	GasUsed       uint64    `json:"gas_used"`
+	Failures      int       `json:"failures"`
+}
+
+// ParseGranularity converts "hour" or "day" into a bucket width.
+func ParseGranularity(name string) (time.Duration, error) {
+	switch name {
+	case "", "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unknown granularity: %s", name)
+	}
+}
+
+// Stats aggregates indexed activity into UTC buckets of the given width.
+// Only records with a timestamp in [from, to) are counted; zero times leave
+// the range open. Empty buckets are omitted.
+func (s *Store) Stats(width time.Duration, from, to time.Time) ([]*Bucket, error) {
+	buckets := map[int64]*Bucket{}
+	writers := map[int64]map[common.Address]bool{}
+	step := int64(width / time.Second)
+
+	bucket := func(ts uint64) *Bucket {
+		t := time.Unix(int64(ts), 0).UTC()
+		if (!from.IsZero() && t.Before(from)) || (!to.IsZero() && !t.Before(to)) {
+			return nil
+		}
+		start := int64(ts) - int64(ts)%step
+		b, ok := buckets[start]
+		if !ok {
+			b = &Bucket{Start: time.Unix(start, 0).UTC()}
+			buckets[start] = b
+			writers[start] = map[common.Address]bool{}
+		}
+		return b
+	}
+
+	err := s.forEachRecord(func(r *Record) error {
+		if b := bucket(r.Timestamp); b != nil {
+			b.Writes++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.forEachTransaction(func(t *Transaction) error {
+		b := bucket(t.Timestamp)
+		if b == nil {
+			return nil
+		}
+		b.GasUsed += t.GasUsed
+		if t.Failed {
+			b.Failures++
+			return nil
+		}
+		start := b.Start.Unix()
+		if !writers[start][t.From] {
+			writers[start][t.From] = true
+			b.UniqueWriters++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result, nil
+}
@@ -0,0 +1,345 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketRecords     = []byte("records")
+	bucketValueHashes = []byte("value_hashes")
+	bucketTxs         = []byte("transactions")
+	bucketHeads       = []byte("heads")
+	bucketVersions    = []byte("versions")
+	bucketTags        = []byte("tags")
+	bucketMeta        = []byte("meta")
+
+	metaNextBlock = []byte("next_block")
+	metaSchema    = []byte("schema")
+	metaChainID   = []byte("chain_id")
+
+	indexBuckets = [][]byte{bucketRecords, bucketValueHashes, bucketTxs, bucketHeads, bucketVersions, bucketTags}
+)
+
+// schemaVersion changes whenever indexed data gains information that can
+// only be filled in by re-indexing. Older indexes are cleared on Open.
+const schemaVersion = 3
+
+// Record is an indexed DataSaved event.
+type Record struct {
+	Key         string         `json:"key"`
+	Field       string         `json:"field"`
+	Value       string         `json:"value"`
+	BlockNumber uint64         `json:"block_number"`
+	BlockHash   common.Hash    `json:"block_hash"`
+	TxHash      common.Hash    `json:"tx_hash"`
+	LogIndex    uint           `json:"log_index"`
+	Timestamp   uint64         `json:"timestamp"`
+	Writer      common.Address `json:"writer"`
+	// Version counts the writes to the key/field up to this one.
+	Version      uint64 `json:"version"`
+	Supersedes   *Ref   `json:"supersedes,omitempty"`
+	SupersededBy []Ref  `json:"superseded_by,omitempty"`
+	// Tags are the NAME=VALUE pairs attached to the value's envelope.
+	Tags map[string]string `json:"tags,omitempty"`
+	// ExpiresAt is the expiry attached to the value's envelope.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Transaction is an indexed transaction sent to the contract.
+type Transaction struct {
+	Hash        common.Hash    `json:"hash"`
+	BlockNumber uint64         `json:"block_number"`
+	Timestamp   uint64         `json:"timestamp"`
+	From        common.Address `json:"from"`
+	GasUsed     uint64         `json:"gas_used"`
+	Failed      bool           `json:"failed"`
+}
+
+// id orders records by their position in the chain.
+func (r *Record) id() []byte {
+	id := make([]byte, 12)
+	binary.BigEndian.PutUint64(id[:8], r.BlockNumber)
+	binary.BigEndian.PutUint32(id[8:], uint32(r.LogIndex))
+	return id
+}
+
+// Store is the local index database.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the index database at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+		if err != nil {
+			return err
+		}
+		if v := meta.Get(metaSchema); v == nil || binary.BigEndian.Uint64(v) < schemaVersion {
+			if err := reset(tx); err != nil {
+				return err
+			}
+		}
+
+		for _, name := range indexBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// reset drops all indexed data so that the chain is scanned again.
+func reset(tx *bolt.Tx) error {
+	for _, name := range indexBuckets {
+		if tx.Bucket(name) == nil {
+			continue
+		}
+		if err := tx.DeleteBucket(name); err != nil {
+			return err
+		}
+	}
+	meta := tx.Bucket(bucketMeta)
+	if err := meta.Delete(metaNextBlock); err != nil {
+		return err
+	}
+	return meta.Put(metaSchema, binary.BigEndian.AppendUint64(nil, schemaVersion))
+}
+
+// Reset drops all indexed data, including the chain it was synced from,
+// so that the next sync starts over from the start block.
+func (s *Store) Reset() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := reset(tx); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketMeta).Delete(metaChainID); err != nil {
+			return err
+		}
+		for _, name := range indexBuckets {
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Summary describes what an index holds.
+type Summary struct {
+	ChainID uint64 `json:"chain_id"`
+	// NextBlock is the first block not indexed yet, 0 before the first
+	// sync.
+	NextBlock    uint64 `json:"next_block"`
+	Records      int    `json:"records"`
+	Keys         int    `json:"keys"`
+	Transactions int    `json:"transactions"`
+}
+
+// Summary counts the indexed records, key/field pairs and transactions.
+func (s *Store) Summary() (*Summary, error) {
+	var sum Summary
+	err := s.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if v := meta.Get(metaChainID); v != nil {
+			sum.ChainID = binary.BigEndian.Uint64(v)
+		}
+		if v := meta.Get(metaNextBlock); v != nil {
+			sum.NextBlock = binary.BigEndian.Uint64(v)
+		}
+		sum.Records = tx.Bucket(bucketRecords).Stats().KeyN
+		sum.Keys = tx.Bucket(bucketHeads).Stats().KeyN
+		sum.Transactions = tx.Bucket(bucketTxs).Stats().KeyN
+		return nil
+	})
+	return &sum, err
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// NextBlock returns the first block that has not been indexed yet, or
+// start if nothing has been indexed.
+func (s *Store) NextBlock(start uint64) (uint64, error) {
+	next := start
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(bucketMeta).Get(metaNextBlock); v != nil {
+			next = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	})
+	return next, err
+}
+
+// ChainID returns the chain the index was synced from, or 0 before the
+// first sync.
+func (s *Store) ChainID() (uint64, error) {
+	var id uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(bucketMeta).Get(metaChainID); v != nil {
+			id = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	})
+	return id, err
+}
+
+// SetChainID records the chain the index is synced from.
+func (s *Store) SetChainID(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put(metaChainID, binary.BigEndian.AppendUint64(nil, id))
+	})
+}
+
+// Commit stores records and transactions and advances the next block to
+// index, atomically.
+func (s *Store) Commit(records []*Record, txs []*Transaction, nextBlock uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		recs := tx.Bucket(bucketRecords)
+		hashes := tx.Bucket(bucketValueHashes)
+
+		txBucket := tx.Bucket(bucketTxs)
+		for _, t := range txs {
+			data, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if err := txBucket.Put(t.Hash.Bytes(), data); err != nil {
+				return err
+			}
+		}
+
+		for _, r := range records {
+			if err := link(tx, r); err != nil {
+				return err
+			}
+			r.Tags = tags(r.Value)
+			r.ExpiresAt = expiresAt(r.Value)
+			if err := indexTags(tx, r); err != nil {
+				return err
+			}
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			id := r.id()
+			if err := recs.Put(id, data); err != nil {
+				return err
+			}
+			for _, h := range valueHashes(r.Value) {
+				if err := hashes.Put(append(h, id...), nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		next := make([]byte, 8)
+		binary.BigEndian.PutUint64(next, nextBlock)
+		return tx.Bucket(bucketMeta).Put(metaNextBlock, next)
+	})
+}
+
+// FindByValueHash returns every record whose value matches the given
+// 32-byte content hash, in chain order.
+func (s *Store) FindByValueHash(hash []byte) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		recs := tx.Bucket(bucketRecords)
+		c := tx.Bucket(bucketValueHashes).Cursor()
+		for k, _ := c.Seek(hash); k != nil && bytes.HasPrefix(k, hash); k, _ = c.Next() {
+			data := recs.Get(k[len(hash):])
+			if data == nil {
+				continue
+			}
+			var r Record
+			if err := json.Unmarshal(data, &r); err != nil {
+				return err
+			}
+			records = append(records, &r)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Transaction returns an indexed transaction, or ErrNotFound.
+func (s *Store) Transaction(hash common.Hash) (*Transaction, error) {
+	var t *Transaction
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketTxs).Get(hash.Bytes())
+		if data == nil {
+			return ErrNotFound
+		}
+		t = &Transaction{}
+		return json.Unmarshal(data, t)
+	})
+	return t, err
+}
+
+// forEachRecord calls fn for every record in chain order.
+func (s *Store) forEachRecord(fn func(r *Record) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return eachRecord(tx, fn)
+	})
+}
+
+func eachRecord(tx *bolt.Tx, fn func(r *Record) error) error {
+	return tx.Bucket(bucketRecords).ForEach(func(_, data []byte) error {
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		return fn(&r)
+	})
+}
+
+// forEachTransaction calls fn for every indexed transaction.
+func (s *Store) forEachTransaction(fn func(t *Transaction) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return eachTransaction(tx, fn)
+	})
+}
+
+func eachTransaction(tx *bolt.Tx, fn func(t *Transaction) error) error {
+	return tx.Bucket(bucketTxs).ForEach(func(_, data []byte) error {
+		var t Transaction
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		return fn(&t)
+	})
+}
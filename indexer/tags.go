@@ -0,0 +1,133 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"contract-storage-eth/envelope"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MetaTagPrefix prefixes the envelope metadata attributes holding record
+// tags: tag NAME=VALUE is stored as "tag.NAME": "VALUE".
+const MetaTagPrefix = "tag."
+
+// ParseTag parses a NAME=VALUE tag.
+func ParseTag(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid tag %q, want NAME=VALUE", s)
+	}
+	return name, value, nil
+}
+
+// TagMeta returns the envelope metadata storing tags.
+func TagMeta(tags map[string]string) map[string]string {
+	meta := make(map[string]string, len(tags))
+	for name, value := range tags {
+		meta[MetaTagPrefix+name] = value
+	}
+	return meta
+}
+
+// tags returns the tags a stored value carries in its envelope.
+func tags(value string) map[string]string {
+	env, err := envelope.Parse(value)
+	if err != nil {
+		return nil
+	}
+	var t map[string]string
+	for k, v := range env.Meta {
+		if name, ok := strings.CutPrefix(k, MetaTagPrefix); ok && name != "" {
+			if t == nil {
+				t = map[string]string{}
+			}
+			t[name] = v
+		}
+	}
+	return t
+}
+
+// tagKey is the prefix of the tag index entries of NAME=VALUE, followed by
+// the ids of the records carrying the tag.
+func tagKey(name, value string) []byte {
+	return keyField(name, value)
+}
+
+// indexTags adds r to the tag index.
+func indexTags(tx *bolt.Tx, r *Record) error {
+	b := tx.Bucket(bucketTags)
+	for name, value := range r.Tags {
+		if err := b.Put(append(tagKey(name, value), r.id()...), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindByTags returns the records carrying every given tag, in chain order.
+// Without tags it returns every record.
+func (s *Store) FindByTags(filter map[string]string) ([]*Record, error) {
+	if len(filter) == 0 {
+		var records []*Record
+		err := s.forEachRecord(func(r *Record) error {
+			records = append(records, r)
+			return nil
+		})
+		return records, err
+	}
+
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// Scan the ids of the first tag, then check the others on the record
+		var first string
+		for name := range filter {
+			if first == "" || name < first {
+				first = name
+			}
+		}
+		prefix := tagKey(first, filter[first])
+		c := tx.Bucket(bucketTags).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			// Longer values of the same tag share the prefix
+			id := k[len(prefix):]
+			if len(id) != 12 {
+				continue
+			}
+			r, err := getRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			if r.hasTags(filter) {
+				records = append(records, r)
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func (r *Record) hasTags(filter map[string]string) bool {
+	for name, value := range filter {
+		if v, ok := r.Tags[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,471 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/ens"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/presets"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+// configTemplate is the documented config.yaml, which init fills in so that
+// the file it writes explains every setting and keeps their defaults
+//
+//go:embed config.yaml
+var configTemplate string
+
+// Networks of init besides the presets
+const (
+	initLocal  = "local"
+	initCustom = "custom"
+)
+
+// Key sources init offers
+var initKeySources = []string{"none", "env", "keystore", "mnemonic", "private-key"}
+
+// initDialTimeout bounds the connectivity check of each RPC URL
+const initDialTimeout = 10 * time.Second
+
+// runInit asks for the network, RPC URL, key source and contract build,
+// checks them against the node and writes the configuration file. It runs
+// before any configuration is loaded, since there may be none yet
+func runInit(ctx context.Context, configFile string, args []string) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	force := flags.Bool("force", false, "overwrite the configuration file without asking")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(configFile); err == nil && !*force {
+		overwrite, err := askYesNo(fmt.Sprintf("%s exists, overwrite it?", configFile), false)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			return fmt.Errorf("Not overwriting %s, run init with --force or --config FILE", configFile)
+		}
+	}
+
+	values := map[string]string{}
+	network, err := askChoice("Network", append(presets.Names(), initLocal, initCustom), initLocal)
+	if err != nil {
+		return err
+	}
+	preset, isPreset := presets.Lookup(network)
+	if isPreset {
+		values["ethereum.preset"] = strconv.Quote(preset.Name)
+	}
+
+	// RPC URL, checked by connecting to it
+	defaultURL := ""
+	if network == initLocal {
+		defaultURL = "http://127.0.0.1:8545"
+	}
+	var client *chain.Client
+	for {
+		rpcURL, err := ask("RPC URL", defaultURL)
+		if err != nil {
+			return err
+		}
+		if rpcURL == "" {
+			fmt.Fprintln(os.Stderr, "An RPC URL is required")
+			continue
+		}
+		id, err := initConnect(ctx, rpcURL, preset.ChainID, &client)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not use %s: %v\n", rpcURL, err)
+			keep, err := askYesNo("Keep it anyway?", false)
+			if err != nil {
+				return err
+			}
+			if !keep {
+				continue
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "Connected to chain %d\n", id)
+			if !isPreset {
+				values["ethereum.chain_id"] = strconv.FormatUint(id, 10)
+			}
+		}
+		values["ethereum.rpc_url"] = strconv.Quote(rpcURL)
+		break
+	}
+	if client != nil {
+		defer client.Close()
+	}
+
+	// Key source, checked by loading the key when it is at hand
+	for {
+		key, err := askKeySource(values)
+		if err == nil && key != nil {
+			err = initCheckKey(ctx, client, *key)
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, errNoAnswer) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Invalid key source: %v\n", err)
+	}
+
+	// Contract build, the .bin and .abi files deploy reads
+	directory, err := ask("Build directory", "./build")
+	if err != nil {
+		return err
+	}
+	name, err := ask("Contract name", "SaveContract")
+	if err != nil {
+		return err
+	}
+	values["build.directory"], values["build.contract_name"] = strconv.Quote(directory), strconv.Quote(name)
+	hasBuild := initCheckBuild(directory, name)
+
+	for {
+		address, err := ask("Contract address or ENS name (empty to deploy one later)", "")
+		if err != nil {
+			return err
+		}
+		if address == "" {
+			break
+		}
+		if !common.IsHexAddress(address) && !ens.IsName(address) {
+			fmt.Fprintln(os.Stderr, "Not an address or an ENS name")
+			continue
+		}
+		if client != nil && common.IsHexAddress(address) {
+			code, err := client.CodeAt(ctx, common.HexToAddress(address), nil)
+			if err != nil || len(code) == 0 {
+				fmt.Fprintf(os.Stderr, "Warning: no contract at %s on this chain\n", address)
+			}
+		}
+		values["contract.address"] = strconv.Quote(address)
+		break
+	}
+
+	data, err := fillConfigTemplate(configTemplate, values)
+	if err != nil {
+		return fail(codeConfig, "Failed to write config: %w", err)
+	}
+	var config Config
+	if err := yaml.UnmarshalStrict([]byte(data), &config); err != nil {
+		return fail(codeConfig, "Failed to write config: %w", err)
+	}
+	command := "get"
+	if hasBuild {
+		command = "deploy"
+	}
+	if err := validateConfig(&config, command, false); err != nil {
+		return fail(codeConfig, "Invalid config: %w", err)
+	}
+	if err := writeFileAtomic(configFile, []byte(data)); err != nil {
+		return fail(codeConfig, "Failed to write config: %w", err)
+	}
+
+	fmt.Printf("Wrote %s\n", configFile)
+	switch {
+	case values["contract.address"] != "":
+		fmt.Println("Next: contract-storage-eth get --key KEY, or save --key KEY --value VALUE")
+	case hasBuild:
+		fmt.Println("Next: contract-storage-eth deploy, then set contract.address to the deployed contract")
+	default:
+		fmt.Printf("Next: solc --bin --abi Storage.sol -o %s, then contract-storage-eth deploy\n", directory)
+	}
+	return nil
+}
+
+// initConnect dials rpcURL and returns the chain it serves, which must be
+// chainID unless it is zero. The client replaces *client on success
+func initConnect(ctx context.Context, rpcURL string, chainID uint64, client **chain.Client) (uint64, error) {
+	var problems configProblems
+	checkEndpoints(&problems, "rpc_url", []string{rpcURL})
+	if len(problems) > 0 {
+		return 0, errors.New(problems[0].Message)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, initDialTimeout)
+	defer cancel()
+	opts := chain.Options{}
+	if chainID != 0 {
+		opts.ChainID = new(big.Int).SetUint64(chainID)
+	}
+	c, err := chain.DialContext(ctx, []string{rpcURL}, opts)
+	if err != nil {
+		return 0, err
+	}
+	id, err := c.ChainID(ctx)
+	if err == nil {
+		_, err = c.BlockNumber(ctx)
+	}
+	if err != nil {
+		c.Close()
+		return 0, err
+	}
+	if *client != nil {
+		(*client).Close()
+	}
+	*client = c
+	return id.Uint64(), nil
+}
+
+// askKeySource asks how the signing key is provided and records it in
+// values. It returns the key to check, nil when it cannot be checked now
+func askKeySource(values map[string]string) (*KeyConfig, error) {
+	// The sample key would be taken for no key, but a stale one must go
+	values["ethereum.private_key"] = strconv.Quote("")
+	delete(values, "ethereum.keystore.file")
+	delete(values, "ethereum.mnemonic.phrase")
+
+	var key KeyConfig
+	source, err := askChoice("Key source", initKeySources, "env")
+	if err != nil {
+		return nil, err
+	}
+	switch source {
+	case "none":
+		fmt.Fprintln(os.Stderr, "No key: only the read commands will work")
+		return nil, nil
+	case "env":
+		name, err := ask("Environment variable holding the private key", "CSE_PRIVATE_KEY")
+		if err != nil {
+			return nil, err
+		}
+		values["ethereum.private_key"] = strconv.Quote(secretEnvPrefix + name)
+		if os.Getenv(name) == "" {
+			fmt.Fprintf(os.Stderr, "%s is not set now, set it before running the commands\n", name)
+			return nil, nil
+		}
+		key.PrivateKey = secretEnvPrefix + name
+	case "keystore":
+		if key.Keystore.File, err = ask("Keystore file", ""); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(key.Keystore.File); err != nil {
+			return nil, err
+		}
+		values["ethereum.keystore.file"] = strconv.Quote(key.Keystore.File)
+	case "mnemonic":
+		name, err := ask("Environment variable holding the mnemonic", "CSE_MNEMONIC")
+		if err != nil {
+			return nil, err
+		}
+		values["ethereum.mnemonic.phrase"] = strconv.Quote(secretEnvPrefix + name)
+		if os.Getenv(name) == "" {
+			fmt.Fprintf(os.Stderr, "%s is not set now, set it before running the commands\n", name)
+			return nil, nil
+		}
+		key.Mnemonic.Phrase = secretEnvPrefix + name
+	case "private-key":
+		fmt.Fprintln(os.Stderr, "Warning: the key will be written in plain text, prefer env")
+		secret, err := askSecret("Private key")
+		if err != nil {
+			return nil, err
+		}
+		secret = strings.TrimPrefix(secret, "0x")
+		values["ethereum.private_key"] = strconv.Quote(secret)
+		key.PrivateKey = secret
+	}
+	return &key, nil
+}
+
+// initCheckKey loads key and shows its account, with its balance when
+// connected
+func initCheckKey(ctx context.Context, client *chain.Client, key KeyConfig) error {
+	s, err := loadKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+	account := s.Address()
+	if client == nil {
+		fmt.Fprintf(os.Stderr, "Account %s\n", account.Hex())
+		return nil
+	}
+	balance, err := client.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Account %s holds %s ETH\n", account.Hex(), fees.FormatEther(balance))
+	return nil
+}
+
+// initCheckBuild reports whether the build of the contract is in
+// directory, listing the builds found there when it is not
+func initCheckBuild(directory, name string) bool {
+	var missing []string
+	for _, ext := range []string{".bin", ".abi"} {
+		if _, err := os.Stat(filepath.Join(directory, name+ext)); err != nil {
+			missing = append(missing, name+ext)
+		}
+	}
+	if len(missing) == 0 {
+		fmt.Fprintf(os.Stderr, "Found the build of %s\n", name)
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s not found in %s, deploy needs them\n", strings.Join(missing, " and "), directory)
+	if found, _ := filepath.Glob(filepath.Join(directory, "*.bin")); len(found) > 0 {
+		for i, file := range found {
+			found[i] = strings.TrimSuffix(filepath.Base(file), ".bin")
+		}
+		fmt.Fprintf(os.Stderr, "Builds in %s: %s\n", directory, strings.Join(found, ", "))
+	}
+	return false
+}
+
+// fillConfigTemplate sets the settings of values, keyed by their path, in
+// the YAML template, keeping its comments and layout. Each value must be a
+// YAML scalar
+func fillConfigTemplate(template string, values map[string]string) (string, error) {
+	lines := strings.Split(template, "\n")
+	for path, value := range values {
+		if err := setTemplateValue(lines, strings.Split(path, "."), value); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// setTemplateValue replaces the value of the setting at path, found by
+// descending the indentation of the sections
+func setTemplateValue(lines []string, path []string, value string) error {
+	lo, hi, indent := 0, len(lines), 0
+	for depth, name := range path {
+		at := -1
+		for i := lo; i < hi; i++ {
+			if n, ok := yamlIndent(lines[i]); ok && n == indent && strings.HasPrefix(lines[i][n:], name+":") {
+				at = i
+				break
+			}
+		}
+		if at < 0 {
+			return fmt.Errorf("template has no setting %s", strings.Join(path, "."))
+		}
+		if depth == len(path)-1 {
+			lines[at] = lines[at][:indent] + name + ": " + value
+			return nil
+		}
+
+		// The section ends at the next line indented as much as it or less
+		lo = at + 1
+		end := lo
+		for ; end < hi; end++ {
+			if n, ok := yamlIndent(lines[end]); ok && n <= indent {
+				break
+			}
+		}
+		hi, indent = end, -1
+		for i := lo; i < hi && indent < 0; i++ {
+			if n, ok := yamlIndent(lines[i]); ok {
+				indent = n
+			}
+		}
+	}
+	return nil
+}
+
+// yamlIndent returns the indentation of a line holding YAML, false for
+// blank and comment lines
+func yamlIndent(line string) (int, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" || trimmed[0] == '#' {
+		return 0, false
+	}
+	return len(line) - len(trimmed), true
+}
+
+// errNoAnswer is returned when no answer can be read from stdin
+var errNoAnswer = errors.New("Failed to read the answer")
+
+// ask prints a question and returns the answer read from stdin, def when
+// it is empty
+func ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Fprintln(os.Stderr)
+		return "", fmt.Errorf("%w: %w", errNoAnswer, err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// askChoice asks until the answer is one of options
+func askChoice(question string, options []string, def string) (string, error) {
+	for {
+		answer, err := ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def)
+		if err != nil {
+			return "", err
+		}
+		if answer = strings.ToLower(answer); slices.Contains(options, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(os.Stderr, "Answer one of %s\n", strings.Join(options, ", "))
+	}
+}
+
+func askYesNo(question string, def bool) (bool, error) {
+	options := "y/N"
+	if def {
+		options = "Y/n"
+	}
+	answer, err := ask(fmt.Sprintf("%s [%s]", question, options), "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	default:
+		return def, nil
+	}
+}
+
+// askSecret reads a secret without echoing it at a terminal, and as a line
+// when stdin is piped
+func askSecret(question string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return promptSecret(question)
+	}
+	return ask(question, "")
+}
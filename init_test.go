@@ -0,0 +1,113 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFillConfigTemplate(t *testing.T) {
+	template := "# Node\nethereum:\n  # Endpoint\n  rpc_url: \"http://localhost:8545\"\n  mnemonic:\n    phrase: \"\"\n\n    index: 0\nbuild:\n  directory: \"./build\"\n"
+	got, err := fillConfigTemplate(template, map[string]string{
+		"ethereum.rpc_url":         `"https://node.example.com"`,
+		"ethereum.mnemonic.phrase": `"env:MNEMONIC"`,
+		"build.directory":          `"out"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Node\nethereum:\n  # Endpoint\n  rpc_url: \"https://node.example.com\"\n  mnemonic:\n    phrase: \"env:MNEMONIC\"\n\n    index: 0\nbuild:\n  directory: \"out\"\n"
+	if got != want {
+		t.Errorf("filled template\n%s\nwant\n%s", got, want)
+	}
+	// index is in mnemonic, not directly in ethereum
+	if _, err := fillConfigTemplate(template, map[string]string{"ethereum.index": "1"}); err == nil || err.Error() != "template has no setting ethereum.index" {
+		t.Errorf("missing setting: got %v", err)
+	}
+}
+
+func TestInit(t *testing.T) {
+	// The node of chain 1337, with code at every address
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x539"}`, req.ID)
+	}))
+	defer node.Close()
+
+	dir := t.TempDir()
+	build := filepath.Join(dir, "build")
+	if err := os.Mkdir(build, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Storage.bin", "Storage.abi"} {
+		if err := os.WriteFile(filepath.Join(build, name), []byte("[]"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CSE_TEST_INIT_KEY", testKey)
+
+	answers := []string{
+		"local",
+		"ftp://node.example.com", // refused before connecting
+		"n",
+		node.URL,
+		"keystore",
+		filepath.Join(dir, "missing.json"), // asked again
+		"env",
+		"CSE_TEST_INIT_KEY",
+		build,
+		"Storage",
+		"not an address",
+		"0x00000000000000000000000000000000000000c5",
+	}
+	saved := stdinReader
+	defer func() { stdinReader = saved }()
+	stdinReader = bufio.NewReader(strings.NewReader(strings.Join(answers, "\n") + "\n"))
+
+	file := filepath.Join(dir, "config.yaml")
+	if err := runInit(context.Background(), file, nil); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadConfig(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []interface{}{strings.Join(config.Ethereum.RpcURL, ","), config.Ethereum.ChainID, config.Ethereum.PrivateKey, config.Build.Directory, config.Build.ContractName, config.Contract.Address}
+	want := []interface{}{node.URL, int64(1337), "env:CSE_TEST_INIT_KEY", build, "Storage", "0x00000000000000000000000000000000000000c5"}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("written settings %v, want %v", got, want)
+			break
+		}
+	}
+
+	// An existing file is kept unless the overwrite is confirmed
+	stdinReader = bufio.NewReader(strings.NewReader("n\n"))
+	if err := runInit(context.Background(), file, nil); err == nil || !strings.Contains(err.Error(), "Not overwriting") {
+		t.Errorf("existing file: got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"contract-storage-eth/ipfs"
+)
+
+// Pinning services of ipfs.pinning
+const (
+	pinningNode   = "node"
+	pinningPinata = "pinata"
+)
+
+// ipfsPinner returns the node or service of ipfs.pinning
+func ipfsPinner(config *Config) (ipfs.Pinner, error) {
+	client := &http.Client{Timeout: ipfsTimeout(config)}
+	switch config.IPFS.Pinning {
+	case "", pinningNode:
+		if config.IPFS.APIURL == "" {
+			return nil, fail(codeConfig, "ipfs.api_url is not configured")
+		}
+		return &ipfs.Node{URL: config.IPFS.APIURL, Client: client}, nil
+	case pinningPinata:
+		jwt, err := resolveSecret(config.IPFS.Pinata.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("ipfs.pinata.jwt: %w", err)
+		}
+		if jwt == "" {
+			return nil, fail(codeConfig, "ipfs.pinata.jwt is not configured")
+		}
+		return &ipfs.Pinata{JWT: jwt, URL: config.IPFS.Pinata.URL, Client: client}, nil
+	}
+	return nil, fmt.Errorf("ipfs.pinning must be node or pinata, not %q", config.IPFS.Pinning)
+}
+
+// ipfsFetcher returns where to read IPFS content from: ipfs.gateway_url,
+// else the node of ipfs.api_url, else the public gateway of ipfs.io
+func ipfsFetcher(config *Config) ipfs.Fetcher {
+	client := &http.Client{Timeout: ipfsTimeout(config)}
+	if config.IPFS.GatewayURL == "" && config.IPFS.APIURL != "" {
+		return &ipfs.Node{URL: config.IPFS.APIURL, Client: client}
+	}
+	url := config.IPFS.GatewayURL
+	if url == "" {
+		url = "https://ipfs.io"
+	}
+	return &ipfs.Gateway{URL: url, Client: client}
+}
+
+func ipfsTimeout(config *Config) time.Duration {
+	if config.IPFS.Timeout > 0 {
+		return config.IPFS.Timeout
+	}
+	return time.Minute
+}
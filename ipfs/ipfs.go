@@ -0,0 +1,244 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipfs stores values too large to keep on chain on IPFS. The value
+// is added to a node or a pinning service, and a pointer holding its CID,
+// length and digest is written on chain in its place, so that readers can
+// fetch it from any gateway and check what they got.
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Prefix marks a stored value as a pointer. It cannot start an envelope,
+// a chunk manifest or a tombstone.
+const Prefix = "cse-ipfs:"
+
+// Pointer is what is stored on chain for a value kept on IPFS.
+type Pointer struct {
+	CID string `json:"cid"`
+	// Length and SHA256 are the length and digest of the value.
+	Length int    `json:"length"`
+	SHA256 string `json:"sha256"`
+}
+
+// String returns the pointer in the form stored on chain.
+func (p *Pointer) String() string {
+	data, _ := json.Marshal(p)
+	return Prefix + string(data)
+}
+
+// Parse returns the pointer a stored value holds, or nil when the value is
+// not one.
+func Parse(value string) (*Pointer, error) {
+	body, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return nil, nil
+	}
+	p := &Pointer{}
+	if err := json.Unmarshal([]byte(body), p); err != nil {
+		return nil, fmt.Errorf("ipfs: malformed pointer: %w", err)
+	}
+	if p.CID == "" || p.Length < 0 || len(p.SHA256) != 2*sha256.Size {
+		return nil, errors.New("ipfs: malformed pointer")
+	}
+	return p, nil
+}
+
+// Pinner adds content to IPFS and keeps it pinned, returning its CID.
+type Pinner interface {
+	Pin(ctx context.Context, data []byte) (string, error)
+}
+
+// Fetcher reads content from IPFS.
+type Fetcher interface {
+	Fetch(ctx context.Context, cid string, limit int64) ([]byte, error)
+}
+
+// Put pins a value and returns its pointer.
+func Put(ctx context.Context, pinner Pinner, value string) (*Pointer, error) {
+	cid, err := pinner.Pin(ctx, []byte(value))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(value))
+	return &Pointer{CID: cid, Length: len(value), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// Get fetches the value a pointer points to, checking it against the
+// pointer.
+func Get(ctx context.Context, fetcher Fetcher, p *Pointer) (string, error) {
+	data, err := fetcher.Fetch(ctx, p.CID, int64(p.Length))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	if len(data) != p.Length || hex.EncodeToString(sum[:]) != p.SHA256 {
+		return "", fmt.Errorf("ipfs: %s does not match its pointer", p.CID)
+	}
+	return string(data), nil
+}
+
+// Node is the RPC API of an IPFS node such as Kubo, which pins what it adds.
+type Node struct {
+	// URL is the API address, such as http://127.0.0.1:5001.
+	URL    string
+	Client *http.Client
+}
+
+// Pin implements Pinner.
+func (n *Node) Pin(ctx context.Context, data []byte) (string, error) {
+	var added struct {
+		Hash string `json:"Hash"`
+	}
+	if err := upload(ctx, n.Client, strings.TrimRight(n.URL, "/")+"/api/v0/add?pin=true&cid-version=1", nil, data, &added); err != nil {
+		return "", err
+	}
+	if added.Hash == "" {
+		return "", errors.New("ipfs: the node returned no CID")
+	}
+	return added.Hash, nil
+}
+
+// Fetch implements Fetcher.
+func (n *Node) Fetch(ctx context.Context, cid string, limit int64) ([]byte, error) {
+	return fetch(ctx, n.Client, http.MethodPost, strings.TrimRight(n.URL, "/")+"/api/v0/cat?arg="+url.QueryEscape(cid), limit)
+}
+
+// DefaultPinataURL is the API of Pinata.
+const DefaultPinataURL = "https://api.pinata.cloud"
+
+// Pinata is the Pinata pinning service.
+type Pinata struct {
+	// JWT is the API key.
+	JWT string
+	// URL is DefaultPinataURL when empty.
+	URL    string
+	Client *http.Client
+}
+
+// Pin implements Pinner.
+func (p *Pinata) Pin(ctx context.Context, data []byte) (string, error) {
+	base := p.URL
+	if base == "" {
+		base = DefaultPinataURL
+	}
+	var pinned struct {
+		IpfsHash string `json:"IpfsHash"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + p.JWT}}
+	if err := upload(ctx, p.Client, strings.TrimRight(base, "/")+"/pinning/pinFileToIPFS", header, data, &pinned); err != nil {
+		return "", err
+	}
+	if pinned.IpfsHash == "" {
+		return "", errors.New("ipfs: Pinata returned no CID")
+	}
+	return pinned.IpfsHash, nil
+}
+
+// Gateway is an HTTP gateway, such as https://ipfs.io.
+type Gateway struct {
+	URL    string
+	Client *http.Client
+}
+
+// Fetch implements Fetcher.
+func (g *Gateway) Fetch(ctx context.Context, cid string, limit int64) ([]byte, error) {
+	return fetch(ctx, g.Client, http.MethodGet, strings.TrimRight(g.URL, "/")+"/ipfs/"+url.PathEscape(cid), limit)
+}
+
+// upload posts data as the file of a multipart form and decodes the JSON
+// answer into out
+func upload(ctx context.Context, client *http.Client, endpoint string, header http.Header, data []byte, out interface{}) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "value")
+	if err == nil {
+		_, err = part.Write(data)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := httpClient(client).Do(req)
+	if err != nil {
+		return fmt.Errorf("ipfs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return statusError(resp)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("ipfs: malformed answer: %w", err)
+	}
+	return nil
+}
+
+// fetch reads content, failing when it is longer than limit
+func fetch(ctx context.Context, client *http.Client, method, endpoint string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient(client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ipfs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, statusError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("ipfs: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("ipfs: content longer than %d bytes", limit)
+	}
+	return data, nil
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("ipfs: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipfs_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/ipfs"
+)
+
+// fakeIPFS serves the add and cat endpoints of a node, the pinning
+// endpoint of Pinata and the reads of a gateway, over the same content
+func fakeIPFS(t *testing.T) *httptest.Server {
+	blocks := map[string][]byte{}
+	add := func(w http.ResponseWriter, r *http.Request) ([]byte, string) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, ""
+		}
+		data, _ := io.ReadAll(file)
+		sum := sha256.Sum256(data)
+		cid := "bafk" + hex.EncodeToString(sum[:8])
+		blocks[cid] = data
+		return data, cid
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v0/add", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pin") != "true" {
+			t.Error("added without pinning")
+		}
+		if _, cid := add(w, r); cid != "" {
+			json.NewEncoder(w).Encode(map[string]string{"Name": "value", "Hash": cid})
+		}
+	})
+	mux.HandleFunc("POST /pinning/pinFileToIPFS", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer jwt" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if _, cid := add(w, r); cid != "" {
+			json.NewEncoder(w).Encode(map[string]string{"IpfsHash": cid})
+		}
+	})
+	mux.HandleFunc("POST /api/v0/cat", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(blocks[r.URL.Query().Get("arg")])
+	})
+	mux.HandleFunc("GET /ipfs/{cid}", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := blocks[r.PathValue("cid")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRoundTrip(t *testing.T) {
+	server := fakeIPFS(t)
+	ctx := context.Background()
+	value := strings.Repeat("large value ", 100)
+
+	for _, c := range []struct {
+		name    string
+		pinner  ipfs.Pinner
+		fetcher ipfs.Fetcher
+	}{
+		{"node", &ipfs.Node{URL: server.URL}, &ipfs.Node{URL: server.URL}},
+		{"pinata", &ipfs.Pinata{JWT: "jwt", URL: server.URL}, &ipfs.Gateway{URL: server.URL}},
+	} {
+		p, err := ipfs.Put(ctx, c.pinner, value)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		parsed, err := ipfs.Parse(p.String())
+		if err != nil || parsed == nil || *parsed != *p {
+			t.Fatalf("%s: parsed %s as %v, %v", c.name, p, parsed, err)
+		}
+		got, err := ipfs.Get(ctx, c.fetcher, parsed)
+		if err != nil || got != value {
+			t.Errorf("%s: got %d bytes, %v", c.name, len(got), err)
+		}
+	}
+
+	if _, err := ipfs.Put(ctx, &ipfs.Pinata{JWT: "wrong", URL: server.URL}, value); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("pinned with a wrong key: %v", err)
+	}
+}
+
+func TestGetChecksContent(t *testing.T) {
+	server := fakeIPFS(t)
+	ctx := context.Background()
+	node := &ipfs.Node{URL: server.URL}
+
+	p, err := ipfs.Put(ctx, node, "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ipfs.Put(ctx, node, "tampered")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A pointer naming other content
+	p.CID = other.CID
+	if _, err := ipfs.Get(ctx, node, p); err == nil {
+		t.Error("content not matching the pointer was accepted")
+	}
+
+	for _, v := range []string{"plain value", "cse-chunked:{}", "cse-ipfs:{\"cid\":\"\"}"} {
+		if p, err := ipfs.Parse(v); p != nil && err == nil {
+			t.Errorf("%q parsed as a pointer", v)
+		}
+	}
+}
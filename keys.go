@@ -0,0 +1,336 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"contract-storage-eth/signer"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/term"
+)
+
+const defaultPassphraseEnv = "CSE_KEYSTORE_PASSPHRASE"
+
+// expandHome expands a leading "~/" to the home directory, as in Clef's
+// default IPC path ~/.clef/clef.ipc
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// loadKey builds a signer from the configured key source. An external
+// signer takes precedence over a hardware wallet, then an AWS KMS key, then
+// a Vault secret, then a mnemonic, then a keystore file, then a raw private
+// key.
+func loadKey(ctx context.Context, key KeyConfig) (signer.Signer, error) {
+	if key.SignerURL != "" {
+		var account common.Address
+		if key.SignerAccount != "" {
+			if !common.IsHexAddress(key.SignerAccount) {
+				return nil, fmt.Errorf("invalid signer_account %q", key.SignerAccount)
+			}
+			account = common.HexToAddress(key.SignerAccount)
+		}
+		return signer.NewExternalSigner(ctx, expandHome(key.SignerURL), account)
+	}
+	if key.Hardware.Wallet != "" {
+		path, err := derivationPath(key.Hardware.DerivationPath, key.Hardware.AccountIndex)
+		if err != nil {
+			return nil, err
+		}
+		return signer.NewHardwareSigner(ctx, signer.HardwareOptions{
+			Kind:   key.Hardware.Wallet,
+			Path:   path,
+			Prompt: promptSecret,
+			Notify: func(message string) { fmt.Fprintln(os.Stderr, ">>", message) },
+		})
+	}
+	if key.KMS.KeyID != "" {
+		return loadKMSKey(ctx, key)
+	}
+	if key.Vault.Path != "" {
+		return loadVaultKey(ctx, key)
+	}
+	if key.Mnemonic.Phrase != "" {
+		path, err := derivationPath(key.Mnemonic.DerivationPath, key.Mnemonic.AccountIndex)
+		if err != nil {
+			return nil, err
+		}
+		phrase, err := resolveSecret(key.Mnemonic.Phrase)
+		if err != nil {
+			return nil, fmt.Errorf("mnemonic: %w", err)
+		}
+		passphrase := ""
+		if key.Mnemonic.PassphraseEnv != "" {
+			passphrase = os.Getenv(key.Mnemonic.PassphraseEnv)
+			secrets.Add(passphrase)
+		}
+		return signer.NewMnemonicSigner(phrase, passphrase, path)
+	}
+	if key.Keystore.File != "" {
+		passphrase, err := keystorePassphrase(key)
+		if err != nil {
+			return nil, err
+		}
+		return signer.NewKeystoreSigner(key.Keystore.File, passphrase)
+	}
+	if key.PrivateKey == "" {
+		return nil, errNoKey
+	}
+	privateKey, err := resolveSecret(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("private_key: %w", err)
+	}
+	return signer.NewKeySignerFromHex(privateKey)
+}
+
+// errNoKey is returned by commands that sign when no key source is set
+var errNoKey = errors.New("no signing key configured (private_key, keystore, mnemonic, vault, kms, hardware or signer_url); " +
+	"get, list, events, find, export and verify-bytecode work without one")
+
+// Secret sources usable instead of a literal value in the configuration
+const (
+	secretEnvPrefix = "env:"
+	secretStdin     = "stdin"
+)
+
+// isSecretReference reports whether value names a secret source rather
+// than holding the secret itself
+func isSecretReference(value string) bool {
+	return strings.HasPrefix(value, secretEnvPrefix) || value == secretStdin
+}
+
+// resolveSecret returns the secret a configuration value refers to:
+// "env:NAME" reads the environment variable NAME, "stdin" reads one line
+// from standard input, anything else is the secret itself
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		secrets.Add(strings.TrimSpace(secret))
+		return strings.TrimSpace(secret), nil
+	case value == secretStdin:
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprint(os.Stderr, "Enter secret: ")
+			secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Fprintln(os.Stderr)
+			secrets.Add(strings.TrimSpace(string(secret)))
+			return strings.TrimSpace(string(secret)), err
+		}
+		line, err := stdinReader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("reading stdin: %w", err)
+		}
+		secrets.Add(strings.TrimSpace(line))
+		return strings.TrimSpace(line), nil
+	default:
+		return value, nil
+	}
+}
+
+// stdinReader is shared so that several secrets can be piped one per line
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// checkStrictKeys rejects keys written in plain text into the
+// configuration when strict mode is enabled
+func checkStrictKeys(config *Config) error {
+	if !config.Ethereum.StrictKeys {
+		return nil
+	}
+	check := func(name, value string) error {
+		// Keys set from the environment or flags are not in the file
+		if config.overrides[name] != "" || (strings.HasPrefix(name, "networks.") && config.overrides["networks"] != "") {
+			return nil
+		}
+		if value != "" && !isSecretReference(value) {
+			return fmt.Errorf("%s must be read with env:NAME or stdin when strict_keys is enabled", name)
+		}
+		return nil
+	}
+	for _, c := range []struct{ name, value string }{
+		{"ethereum.private_key", config.Ethereum.PrivateKey},
+		{"ethereum.mnemonic.phrase", config.Ethereum.Mnemonic.Phrase},
+		{"ethereum.standby.private_key", config.Ethereum.Standby.PrivateKey},
+		{"ethereum.standby.mnemonic.phrase", config.Ethereum.Standby.Mnemonic.Phrase},
+		{"ethereum.vault.token", config.Ethereum.Vault.Token},
+		{"ethereum.vault.approle.secret_id", config.Ethereum.Vault.AppRole.SecretID},
+		{"ethereum.standby.vault.token", config.Ethereum.Standby.Vault.Token},
+		{"ethereum.standby.vault.approle.secret_id", config.Ethereum.Standby.Vault.AppRole.SecretID},
+	} {
+		if err := check(c.name, c.value); err != nil {
+			return err
+		}
+	}
+	for name, network := range config.Networks {
+		if err := check("networks."+name+".private_key", network.PrivateKey); err != nil {
+			return err
+		}
+		if err := check("networks."+name+".mnemonic.phrase", network.Mnemonic.Phrase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// derivationPath returns the derivation path base, or the default one,
+// with the account index added to its last component
+func derivationPath(base string, index uint32) (accounts.DerivationPath, error) {
+	if base == "" {
+		base = signer.DefaultDerivationPath
+	}
+	path, err := accounts.ParseDerivationPath(base)
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	if last&0x7fffffff+index >= 0x80000000 {
+		return nil, fmt.Errorf("account index %d out of range for %s", index, base)
+	}
+	path[len(path)-1] = last + index
+	return path, nil
+}
+
+// addSignerFlags registers the flags selecting the signing account
+func addSignerFlags(flags *flag.FlagSet, config *Config) {
+	flags.Func("account-index", "index of the account derived from the mnemonic or hardware wallet", func(s string) error {
+		index, err := strconv.ParseUint(s, 10, 31)
+		if err != nil {
+			return err
+		}
+		config.Ethereum.Mnemonic.AccountIndex = uint32(index)
+		config.Ethereum.Hardware.AccountIndex = uint32(index)
+		return nil
+	})
+}
+
+// promptSecret reads a secret typed at the terminal
+func promptSecret(message string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("%s required but no terminal is attached", message)
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", message)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	secrets.Add(string(secret))
+	return string(secret), err
+}
+
+// keystorePassphrase reads the keystore passphrase from the environment,
+// prompting for it when the variable is unset and a terminal is attached
+func keystorePassphrase(key KeyConfig) (string, error) {
+	env := key.Keystore.PassphraseEnv
+	if env == "" {
+		env = defaultPassphraseEnv
+	}
+	if passphrase, ok := os.LookupEnv(env); ok {
+		secrets.Add(passphrase)
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("keystore passphrase not provided, set %s", env)
+	}
+	fmt.Fprintf(os.Stderr, "Passphrase for %s: ", key.Keystore.File)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	secrets.Add(string(passphrase))
+	return string(passphrase), nil
+}
+
+// loadKMSKey connects to AWS KMS with the default credential chain
+// (environment, shared config, instance role)
+func loadKMSKey(ctx context.Context, key KeyConfig) (signer.Signer, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if key.KMS.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(key.KMS.Region))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("kms: %w", err)
+	}
+	client := kms.NewFromConfig(awsConfig, func(o *kms.Options) {
+		if key.KMS.Endpoint != "" {
+			o.BaseEndpoint = &key.KMS.Endpoint
+		}
+	})
+	return signer.NewKMSSigner(ctx, client, key.KMS.KeyID)
+}
+
+// Vault token used when none is configured, as with the vault CLI
+const defaultVaultToken = "env:VAULT_TOKEN"
+
+// loadVaultKey reads the key from a Vault KV secret, authenticating with
+// AppRole when a role_id is configured and with a token otherwise
+func loadVaultKey(ctx context.Context, key KeyConfig) (signer.Signer, error) {
+	v := key.Vault
+	opts := signer.VaultOptions{
+		Address:      v.Address,
+		Namespace:    v.Namespace,
+		RoleID:       v.AppRole.RoleID,
+		AppRoleMount: v.AppRole.Mount,
+		Mount:        v.Mount,
+		KVVersion:    v.KVVersion,
+		Path:         v.Path,
+		Field:        v.Field,
+	}
+	if opts.Address == "" {
+		opts.Address = os.Getenv("VAULT_ADDR")
+	}
+
+	var err error
+	if opts.RoleID != "" {
+		if opts.SecretID, err = resolveSecret(v.AppRole.SecretID); err != nil {
+			return nil, fmt.Errorf("vault secret_id: %w", err)
+		}
+	} else {
+		token := v.Token
+		if token == "" {
+			token = defaultVaultToken
+		}
+		if opts.Token, err = resolveSecret(token); err != nil {
+			return nil, fmt.Errorf("vault token: %w", err)
+		}
+	}
+	return signer.NewVaultSigner(ctx, opts)
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/term"
+)
+
+func TestLoadKeystore(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(privateKey, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key KeyConfig
+	key.Keystore.File = account.URL.Path
+	key.Keystore.PassphraseEnv = "TEST_KEYSTORE_PASSPHRASE"
+
+	t.Setenv("TEST_KEYSTORE_PASSPHRASE", "correct horse")
+	s, err := loadKey(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != common.HexToAddress(testAccount) {
+		t.Errorf("address %s, want %s", s.Address(), testAccount)
+	}
+
+	t.Setenv("TEST_KEYSTORE_PASSPHRASE", "wrong")
+	if _, err := loadKey(context.Background(), key); err == nil {
+		t.Error("keystore opened with a wrong passphrase")
+	}
+}
+
+func TestKeystorePassphraseRequired(t *testing.T) {
+	var key KeyConfig
+	key.Keystore.File = "key.json"
+	key.Keystore.PassphraseEnv = "TEST_KEYSTORE_PASSPHRASE_UNSET"
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal, which would be prompted")
+	}
+	_, err := keystorePassphrase(key)
+	if err == nil || !strings.Contains(err.Error(), "TEST_KEYSTORE_PASSPHRASE_UNSET") {
+		t.Errorf("err = %v, want the variable to set", err)
+	}
+}
+
+func TestLoadKeyFromEnvironment(t *testing.T) {
+	t.Setenv("CSE_TEST_PRIVATE_KEY", "0x"+testKey+"\n")
+	s, err := loadKey(context.Background(), KeyConfig{PrivateKey: "env:CSE_TEST_PRIVATE_KEY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != common.HexToAddress(testAccount) {
+		t.Errorf("address %s, want %s", s.Address().Hex(), testAccount)
+	}
+
+	_, err = loadKey(context.Background(), KeyConfig{PrivateKey: "env:CSE_TEST_UNSET_KEY"})
+	if err == nil || !strings.Contains(err.Error(), "CSE_TEST_UNSET_KEY is not set") {
+		t.Errorf("unset variable: got %v", err)
+	}
+}
+
+func TestResolveSecretStdin(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+	saved := stdinReader
+	defer func() { stdinReader = saved }()
+	stdinReader = bufio.NewReader(strings.NewReader("first secret\nsecond secret"))
+
+	for _, want := range []string{"first secret", "second secret"} {
+		got, err := resolveSecret(secretStdin)
+		if err != nil || got != want {
+			t.Errorf("resolveSecret(stdin) = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := resolveSecret(secretStdin); err == nil {
+		t.Error("resolveSecret on exhausted stdin succeeded")
+	}
+}
+
+func TestCheckStrictKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+		err    string
+	}{
+		{"disabled", func(c *Config) {
+			c.Ethereum.StrictKeys = false
+			c.Ethereum.PrivateKey = testKey
+		}, ""},
+		{"environment reference", func(c *Config) { c.Ethereum.PrivateKey = "env:PRIVATE_KEY" }, ""},
+		{"stdin reference", func(c *Config) { c.Ethereum.Mnemonic.Phrase = "stdin" }, ""},
+		{"plain private key", func(c *Config) { c.Ethereum.PrivateKey = testKey }, "ethereum.private_key must be read"},
+		{"plain vault token", func(c *Config) { c.Ethereum.Vault.Token = "s.token" }, "ethereum.vault.token must be read"},
+		{"plain network key", func(c *Config) {
+			c.Networks = map[string]NetworkConfig{"sepolia": {}}
+			network := c.Networks["sepolia"]
+			network.PrivateKey = testKey
+			c.Networks["sepolia"] = network
+		}, "networks.sepolia.private_key must be read"},
+		{"overridden key", func(c *Config) {
+			c.Ethereum.PrivateKey = testKey
+			c.overrides = map[string]string{"ethereum.private_key": "CSE_ETHEREUM_PRIVATE_KEY"}
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			config.Ethereum.StrictKeys = true
+			tt.config(&config)
+			err := checkStrictKeys(&config)
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("got %v, want error containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyspace scopes record keys to a namespace, a prefix such as
+// "casibase:prod:" put in front of every key an application writes, so that
+// several applications can share one storage contract without their keys
+// colliding.
+package keyspace
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+)
+
+// Namespace is the prefix of the keys of an application. The empty
+// namespace holds every key, unchanged.
+type Namespace string
+
+// Parse checks a namespace. It may not hold whitespace or the '#' and '@'
+// separating keys from fields and versions in record references, nor look
+// like a record ID.
+func Parse(s string) (Namespace, error) {
+	if strings.ContainsAny(s, "#@") || strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("invalid namespace %q: it may not hold whitespace, '#' or '@'", s)
+	}
+	if recordid.IsID(s) {
+		return "", fmt.Errorf("invalid namespace %q: it looks like a record ID", s)
+	}
+	return Namespace(s), nil
+}
+
+// Key returns the key stored on the contract for a key of the namespace.
+// Record IDs name a stored key already and are returned as they are.
+func (n Namespace) Key(key string) string {
+	if n == "" || recordid.IsID(key) {
+		return key
+	}
+	return string(n) + key
+}
+
+// Contains reports whether a stored key belongs to the namespace.
+func (n Namespace) Contains(stored string) bool {
+	return strings.HasPrefix(stored, string(n))
+}
+
+// Local returns the key of the namespace a stored key was written as, and
+// false when the key belongs to another namespace.
+func (n Namespace) Local(stored string) (string, bool) {
+	if !n.Contains(stored) {
+		return "", false
+	}
+	return stored[len(n):], true
+}
+
+// Records returns the records of the namespace, in their order.
+func (n Namespace) Records(records []*indexer.Record) []*indexer.Record {
+	if n == "" {
+		return records
+	}
+	var scoped []*indexer.Record
+	for _, r := range records {
+		if n.Contains(r.Key) {
+			scoped = append(scoped, r)
+		}
+	}
+	return scoped
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyspace_test
+
+import (
+	"testing"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/keyspace"
+)
+
+func TestNamespace(t *testing.T) {
+	ns, err := keyspace.Parse("casibase:prod:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ns.Key("invoice-42"); got != "casibase:prod:invoice-42" {
+		t.Errorf("Key = %q", got)
+	}
+	id := "ethstore://1337/0x0000000000000000000000000000000000000001/casibase:prod:a"
+	if got := ns.Key(id); got != id {
+		t.Errorf("Key(%q) = %q, want the ID unchanged", id, got)
+	}
+	if key, ok := ns.Local("casibase:prod:invoice-42"); !ok || key != "invoice-42" {
+		t.Errorf("Local = %q, %v", key, ok)
+	}
+	if _, ok := ns.Local("casibase:dev:invoice-42"); ok {
+		t.Error("Local accepted a key of another namespace")
+	}
+
+	records := []*indexer.Record{{Key: "casibase:prod:a"}, {Key: "other:a"}, {Key: "casibase:prod:b"}}
+	if got := ns.Records(records); len(got) != 2 || got[0].Key != "casibase:prod:a" || got[1].Key != "casibase:prod:b" {
+		t.Errorf("Records = %v", got)
+	}
+	if got := keyspace.Namespace("").Records(records); len(got) != 3 {
+		t.Errorf("the empty namespace kept %d of 3 records", len(got))
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"", "app:", "casibase/prod/"} {
+		if _, err := keyspace.Parse(s); err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"app#", "app@1", "my app:", "ethstore://1/0x0000000000000000000000000000000000000001/"} {
+		if _, err := keyspace.Parse(s); err == nil {
+			t.Errorf("Parse(%q) accepted an invalid namespace", s)
+		}
+	}
+}
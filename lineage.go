@@ -0,0 +1,126 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+)
+
+func runLineage(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("lineage", flag.ContinueOnError)
+	key := flags.String("key", "", "record key, or a record ID")
+	field := flags.String("field", "", "record field, all fields of the key when omitted")
+	version := flags.Uint64("version", 0, "record version to start from, the latest when omitted")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "lineage: --key is required")
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+	ref, err := resolveRef(ns, *key, *field)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --key: %w", err)
+	}
+	fieldSet := isFlagSet(flags, "field") || ref.Field != *field
+	*key, *field = ref.Key, ref.Field
+	if ref.Version > 0 && !isFlagSet(flags, "version") {
+		*version = ref.Version
+	}
+
+	fields := []string{*field}
+	if !fieldSet {
+		if fields, err = store.Fields(*key); err != nil {
+			return fail(codeIndex, "Failed to query index: %w", err)
+		}
+		if len(fields) == 0 {
+			return fail(codeNotFound, "No records found for key %s", *key)
+		}
+	}
+
+	var result lineageResult
+	for _, f := range fields {
+		ref := indexer.Ref{Key: *key, Field: f, Version: *version}
+		chain, err := store.Lineage(ref)
+		if errors.Is(err, indexer.ErrNotFound) {
+			return fail(codeNotFound, "Record %s not found", refID(ns, ref))
+		}
+		if err != nil {
+			return fail(codeIndex, "Failed to query index: %w", err)
+		}
+
+		if output.json {
+			result.Lineages = append(result.Lineages, lineage{ID: refID(ns, ref).String(), Records: recordEvents(ns, chain)})
+			continue
+		}
+		fmt.Printf("Lineage of %s (%d record(s), newest first):\n", refID(ns, ref), len(chain))
+		for _, r := range chain {
+			fmt.Printf("  %s  block %d  tx %s  %s\n", recordID(ns, r), r.BlockNumber, r.TxHash.Hex(), time.Unix(int64(r.Timestamp), 0).UTC().Format(time.RFC3339))
+			if r.Supersedes != nil {
+				fmt.Printf("    supersedes %s\n", refID(ns, *r.Supersedes))
+			}
+		}
+	}
+	printResult(result)
+	return nil
+}
+
+// lineageResult is the result of lineage in JSON output mode, with a
+// lineage per field
+type lineageResult struct {
+	Lineages []lineage `json:"lineages"`
+}
+
+type lineage struct {
+	ID string `json:"id"`
+	// Records are newest first
+	Records []api.Event `json:"records"`
+}
+
+// isFlagSet reports whether the flag was given on the command line
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
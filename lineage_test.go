@@ -0,0 +1,51 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLineageCommand(t *testing.T) {
+	dir := indexedDir(t)
+
+	out, status := runMain(t, dir, "--output", "json", "lineage", "--no-sync", "--key", "invoice-42", "--field", "pdf")
+	if status != 0 {
+		t.Fatalf("exit status %d, output:\n%s", status, out)
+	}
+	var result struct {
+		Result struct {
+			Lineages []struct {
+				Records []struct {
+					Version uint64 `json:"version"`
+				} `json:"records"`
+			} `json:"lineages"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(out[strings.Index(out, "{"):]), &result); err != nil {
+		t.Fatalf("%v in:\n%s", err, out)
+	}
+	lineages := result.Result.Lineages
+	if len(lineages) != 1 || len(lineages[0].Records) != 2 || lineages[0].Records[0].Version != 2 || lineages[0].Records[1].Version != 1 {
+		t.Errorf("lineage %+v, want versions 2 then 1, output:\n%s", lineages, out)
+	}
+
+	out, status = runMain(t, dir, "lineage", "--no-sync", "--key", "missing", "--field", "pdf")
+	if status != 1 || !strings.Contains(out, "not found") {
+		t.Errorf("missing record: exit status %d, output:\n%s", status, out)
+	}
+}
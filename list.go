@@ -0,0 +1,110 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+)
+
+func runList(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	filter := tagFlag{}
+	flags.Var(filter, "tag", "only list records tagged NAME=VALUE (repeatable, all must match)")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	allNamespaces := flags.Bool("all-namespaces", false, "list the records of every namespace, not only the selected one")
+	showDeleted := flags.Bool("deleted", false, "also list the records of soft-deleted keys and fields, with their tombstones")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+
+	records, err := store.FindByTags(filter)
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	records = scopeRecords(records, *allNamespaces)
+	if !*showDeleted {
+		if records, err = hideDeleted(store, records); err != nil {
+			return fail(codeIndex, "Failed to query index: %w", err)
+		}
+	}
+	if output.json {
+		printResult(listResult{Records: recordEvents(ns, records)})
+		return nil
+	}
+	if len(records) == 0 {
+		fmt.Println("No matching records")
+		return nil
+	}
+
+	fmt.Printf("Found %d record(s):\n", len(records))
+	for _, r := range records {
+		fmt.Printf("  %s  block %d  tx %s", recordID(ns, r), r.BlockNumber, r.TxHash.Hex())
+		if len(r.Tags) > 0 {
+			fmt.Printf("  %s", tagFlag(r.Tags))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// listResult is the result of list in JSON output mode
+type listResult struct {
+	Records []api.Event `json:"records"`
+}
+
+// tagFlag collects repeated NAME=VALUE flags
+type tagFlag map[string]string
+
+func (t tagFlag) String() string {
+	pairs := make([]string, 0, len(t))
+	for name, value := range t {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (t tagFlag) Set(s string) error {
+	name, value, err := indexer.ParseTag(s)
+	if err != nil {
+		return err
+	}
+	t[name] = value
+	return nil
+}
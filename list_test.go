@@ -0,0 +1,58 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListByTag(t *testing.T) {
+	dir := indexedDir(t)
+	tests := []struct {
+		tags   []string
+		status int
+		want   []string
+		absent []string
+	}{
+		{[]string{"env=prod"}, 0, []string{"Found 1 record(s)", "/invoice-42?version=2#pdf", "env=prod,team=billing"}, []string{"invoice-43"}},
+		{[]string{"env=staging"}, 0, []string{"Found 1 record(s)", "/invoice-43?version=1#pdf"}, []string{"invoice-42"}},
+		{[]string{"env=prod", "team=billing"}, 0, []string{"Found 1 record(s)", "/invoice-42?version=2#pdf"}, nil},
+		{[]string{"env=prod", "team=ops"}, 0, []string{"No matching records"}, nil},
+		{[]string{"env"}, 2, []string{`invalid tag "env", want NAME=VALUE`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.tags, ","), func(t *testing.T) {
+			args := []string{"list", "--no-sync"}
+			for _, tag := range tt.tags {
+				args = append(args, "--tag", tag)
+			}
+			out, status := runMain(t, dir, args...)
+			if status != tt.status {
+				t.Errorf("exit status %d, want %d", status, tt.status)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(out, absent) {
+					t.Errorf("output lists %q:\n%s", absent, out)
+				}
+			}
+		})
+	}
+}
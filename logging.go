@@ -0,0 +1,55 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// setupLogging makes the configured logger the default of log/slog. The
+// log package writes through it too, so that the errors commands end with
+// are logged as errors with the same fields
+func setupLogging(config *Config, command string) error {
+	var level slog.Level
+	if config.Log.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Log.Level)); err != nil {
+			return fmt.Errorf("log.level: %w", err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch config.Log.Format {
+	case "", "text":
+		handler = slog.NewTextHandler(secrets.Writer(os.Stderr), opts)
+	case "json":
+		handler = slog.NewJSONHandler(secrets.Writer(os.Stderr), opts)
+	default:
+		return fmt.Errorf("log.format must be text or json, not %q", config.Log.Format)
+	}
+
+	logger := slog.New(handler).With("command", command)
+	if config.Network != "" {
+		logger = logger.With("network", config.Network)
+	}
+	if config.Ethereum.ChainID != 0 {
+		logger = logger.With("chain_id", config.Ethereum.ChainID)
+	}
+	slog.SetDefault(logger)
+	setLogOutput(slog.NewLogLogger(logger.Handler(), slog.LevelError).Writer())
+	return nil
+}
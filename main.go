@@ -0,0 +1,398 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/metrics"
+	"contract-storage-eth/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const usage = `Usage: contract-storage-eth [--config FILE] [--SETTING VALUE] [--network NAME] [--contract NAME] [--namespace PREFIX] [--output text|json] [--simulated] [--gas-report] [--gas-report-csv FILE] [--no-progress] [command] [flags]
+
+Commands:
+  init        Write a config file by answering questions, checking the node and key
+  deploy      Deploy the storage contract (default), behind its proxy with --upgrade
+  deployments List the recorded deployments, upgrades and rollbacks
+  rollback    Point the proxy back to its previous recorded implementation
+  save        Store a value, optionally superseding an earlier record
+  import      Write the records of a CSV, JSON Lines or JSON file, resumably
+  migrate     Copy the records of an old contract to a new one and verify them
+  delete      Delete the value of a key and field, softly with --soft
+  restore     Bring back the value of a soft-deleted key and field
+  prune       Delete the records that expired, in batches
+  get         Read the latest value of a key and field from the contract
+  events      List DataSaved events straight from the chain
+  dashboard   Show records, events, pending transactions, balance and gas live
+  watch       Print DataSaved events as they are mined, optionally by key prefix
+  find        Find records anchoring the content of a file
+  list        List indexed records, optionally filtered by tag
+  export      Export indexed records as CSV or JSON Lines
+  lineage     Show the chain of records superseding each other
+  history     Show every past value of a key, with its block, tx and time
+  index       Backfill and follow the local event index (sync, status, keys, reset)
+  verify-dir  Check every file of a directory against its anchored record
+  verify-data Compare the records with a file or database holding what they should be
+  verify-bytecode
+              Check that the deployed contract code matches the build
+  canary      Write, verify and read back a canary record
+  smoke-test  Run the save/get/delete/event matrix against the contract
+  resume      Continue waiting for a transaction interrupted by a signal
+  estimate    Estimate the gas, fees and fiat cost of saving a record
+  audit       Show the transactions of the audit log, with their receipts
+  bench       Write synthetic records and report throughput, latency and cost
+  queue       Show or drain writes queued while the contract rejected them
+  outbox      Queue writes durably and dispatch them (add, list, run, ...)
+  tx          Build, sign offline and broadcast transactions
+  meta        Sign saves as meta-transactions and relay them (sign, relay)
+  replicate   Show or retry saves still missing on replica networks
+  safe        Follow transactions proposed to a Safe (status, wait)
+  faucet      Request test ether for the signer from a testnet faucet
+  billing     Report gas, writes and storage bytes per tenant
+  serve       Run the HTTP API
+  s3-gateway  Serve an S3-compatible API storing objects as records
+  spool       Save the write requests dropped as files in a directory or pipe
+  publish     Publish the events to a Kafka topic or NATS subject, at least once
+  mirror      Mirror the records and events into PostgreSQL or MySQL tables
+  webhook     Manage webhook signing secrets (rotate, ping)
+  test        Run the end-to-end test against a throwaway dev node (e2e)
+
+Every setting of config.yaml has a flag named after its path, such as
+--server-write-token, and those of ethereum and build also a short one, such
+as --rpc-url, --gas-limit or --contract-name. Flags take precedence over the
+CSE_* environment variables, which take precedence over the file
+`
+
+func main() {
+	setLogOutput(os.Stderr)
+	if err := run(os.Args[1:]); err != nil {
+		os.Exit(reportError(err))
+	}
+}
+
+// run runs the command of the arguments. Its deferred cleanup is done by the
+// time an error reaches main
+func run(args []string) error {
+	// --network and --output apply to every command, so take them out
+	// first. Commands have an --output file flag of their own, so only the
+	// output modes are taken
+	network, args, err := globalFlag(args, "network", nil)
+	if err != nil {
+		return err
+	}
+	contract, args, err := globalFlag(args, "contract", nil)
+	if err != nil {
+		return err
+	}
+	outputMode, args, err := globalFlag(args, "output", func(v string) bool { return v == outputText || v == outputJSON })
+	if err != nil {
+		return err
+	}
+	namespace, args, err := globalFlag(args, "namespace", nil)
+	if err != nil {
+		return err
+	}
+	simulated, args, err := globalSwitch(args, "simulated")
+	if err != nil {
+		return err
+	}
+	gasReportFlag, args, err := globalSwitch(args, "gas-report")
+	if err != nil {
+		return err
+	}
+	gasReportCSV, args, err := globalFlag(args, "gas-report-csv", nil)
+	if err != nil {
+		return err
+	}
+	noProgressFlag, args, err := globalSwitch(args, "no-progress")
+	if err != nil {
+		return err
+	}
+	configFile, args, err := globalFlag(args, "config", nil)
+	if err != nil {
+		return err
+	}
+	if configFile == "" {
+		if configFile = os.Getenv(configEnv); configFile == "" {
+			configFile = "config.yaml"
+		}
+	}
+	settings, args, err := takeSettingFlags(args)
+	if err != nil {
+		return err
+	}
+	command := "deploy"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+	setupProgress(noProgressFlag, command)
+	// init writes the configuration, so it runs without one
+	if command == "init" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return runInit(ctx, configFile, args)
+	}
+	// Report failures to load the configuration as JSON already
+	if outputMode == outputJSON {
+		setupOutput(outputMode, command)
+	}
+
+	// Load configuration file
+	config, err := loadConfig(configFile, settings)
+	if err != nil {
+		return fail(codeConfig, "Failed to load config: %w", err)
+	}
+	if err := selectNetwork(config, network); err != nil {
+		return fail(codeConfig, "Failed to select network: %w", err)
+	}
+	// Flags and the environment take precedence over the profile too
+	if err := applyOverrides(config, settings); err != nil {
+		return fail(codeConfig, "Failed to load config: %w", err)
+	}
+	registerSecrets(config)
+	setLogOutput(secrets.Writer(logOutput))
+	if config.Network != "" {
+		fmt.Fprintf(os.Stderr, "Using network %s\n", config.Network)
+	}
+	if err := selectContract(config, contract); err != nil {
+		return fail(codeConfig, "Failed to select contract: %w", err)
+	}
+	if command != "help" {
+		if err := validateConfig(config, command, simulated); err != nil {
+			return fail(codeConfig, "Invalid config: %w", err)
+		}
+	}
+	if err := setupLogging(config, command); err != nil {
+		return fail(codeConfig, "Failed to set up logging: %w", err)
+	}
+	if outputMode == "" {
+		outputMode = config.Output
+	}
+	if err := setupOutput(outputMode, command); err != nil {
+		return fail(codeInvalidArgument, "Invalid output mode: %w", err)
+	}
+	setupEncryption(config)
+	if err := setupGasReport(config, gasReportFlag, gasReportCSV); err != nil {
+		return fail(codeConfig, "Invalid gas_report config: %w", err)
+	}
+	if err := setupNamespace(config, namespace); err != nil {
+		return fail(codeInvalidArgument, "Invalid namespace: %w", err)
+	}
+
+	// Cancel on SIGINT/SIGTERM so that pending work can be saved
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.Tracing.Enabled {
+		shutdown, err := tracing.Setup(ctx, tracing.Options{
+			Endpoint:    config.Tracing.Endpoint,
+			Insecure:    config.Tracing.Insecure,
+			Headers:     config.Tracing.Headers,
+			ServiceName: config.Tracing.ServiceName,
+			SampleRatio: config.Tracing.SampleRatio,
+		})
+		if err != nil {
+			return fail(codeConfig, "Failed to set up tracing: %w", err)
+		}
+		defer func() {
+			// Give up flushing when the collector is unreachable
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			shutdown(flushCtx)
+		}()
+	}
+	if simulated {
+		stopSimulated, err := setupSimulated(ctx, config, command)
+		if err != nil {
+			return fail(codeConfig, "Failed to start the simulated chain: %w", err)
+		}
+		defer stopSimulated()
+	}
+	if err := setupAddresses(ctx, config); err != nil {
+		return fail(codeConfig, "Failed to resolve address: %w", err)
+	}
+	closeAudit, err := setupAudit(config, command)
+	if err != nil {
+		return fail(codeConfig, "Failed to open audit log: %w", err)
+	}
+	defer closeAudit()
+	// serve and s3-gateway trace each request instead
+	if command != "serve" && command != "s3-gateway" {
+		var span trace.Span
+		ctx, span = tracing.Start(ctx, command)
+		defer span.End()
+	}
+
+	switch command {
+	case "deploy":
+		err = runDeploy(ctx, config, args)
+	case "deployments":
+		err = runDeployments(ctx, config, args)
+	case "rollback":
+		err = runRollback(ctx, config, args)
+	case "save":
+		err = runSave(ctx, config, args)
+	case "import":
+		err = runImport(ctx, config, args)
+	case "migrate":
+		err = runMigrate(ctx, config, args)
+	case "delete":
+		err = runDelete(ctx, config, args)
+	case "restore":
+		err = runRestore(ctx, config, args)
+	case "prune":
+		err = runPrune(ctx, config, args)
+	case "get":
+		err = runGet(ctx, config, args)
+	case "events":
+		err = runEvents(ctx, config, args)
+	case "dashboard":
+		err = runDashboard(ctx, config, args)
+	case "watch":
+		err = runWatch(ctx, config, args)
+	case "find":
+		err = runFind(ctx, config, args)
+	case "list":
+		err = runList(ctx, config, args)
+	case "export":
+		err = runExport(ctx, config, args)
+	case "index":
+		err = runIndex(ctx, config, args)
+	case "history":
+		err = runHistory(ctx, config, args)
+	case "lineage":
+		err = runLineage(ctx, config, args)
+	case "verify-data":
+		err = runVerifyData(ctx, config, args)
+	case "verify-dir":
+		err = runVerifyDir(ctx, config, args)
+	case "verify-bytecode":
+		err = runVerifyBytecode(ctx, config, args)
+	case "canary":
+		err = runCanary(ctx, config, args)
+	case "smoke-test":
+		err = runSmokeTest(ctx, config, args)
+	case "resume":
+		err = runResume(ctx, config, args)
+	case "audit":
+		err = runAudit(ctx, config, args)
+	case "bench":
+		err = runBench(ctx, config, args)
+	case "estimate":
+		err = runEstimate(ctx, config, args)
+	case "queue":
+		err = runQueue(ctx, config, args)
+	case "outbox":
+		err = runOutbox(ctx, config, args)
+	case "tx":
+		err = runTx(ctx, config, args)
+	case "meta":
+		err = runMeta(ctx, config, args)
+	case "replicate":
+		err = runReplicate(ctx, config, args)
+	case "safe":
+		err = runSafe(ctx, config, args)
+	case "faucet":
+		err = runFaucet(ctx, config, args)
+	case "billing":
+		err = runBilling(ctx, config, args)
+	case "spool":
+		err = runSpool(ctx, config, args)
+	case "serve":
+		err = runServe(ctx, config, args)
+	case "s3-gateway":
+		err = runS3Gateway(ctx, config, args)
+	case "publish":
+		err = runPublish(ctx, config, args)
+	case "mirror":
+		err = runMirror(ctx, config, args)
+	case "webhook":
+		err = runWebhook(ctx, config, args)
+	case "test":
+		err = runTest(ctx, config, args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", command, usage)
+		return exitError(2)
+	}
+	if err != nil {
+		return err
+	}
+	finishGasReport()
+	finishOutput()
+	return nil
+}
+
+// dialClient connects to the configured Ethereum endpoints
+func dialClient(ctx context.Context, config *Config) (*chain.Client, error) {
+	return dialEndpoints(ctx, config, config.Ethereum.RpcURL)
+}
+
+// dialEndpoints connects to the given endpoints with the connection
+// settings of config
+func dialEndpoints(ctx context.Context, config *Config, urls []string) (*chain.Client, error) {
+	// Refuse endpoints of another chain than the configured one, so that a
+	// wrong rpc_url cannot send a transaction meant for a testnet to mainnet
+	var chainID *big.Int
+	if config.Ethereum.ChainID != 0 {
+		chainID = big.NewInt(config.Ethereum.ChainID)
+	}
+	opts := chain.Options{
+		ChainID: chainID,
+		Retry: chain.RetryPolicy{
+			MaxAttempts: config.Ethereum.Retry.MaxAttempts,
+			BaseDelay:   config.Ethereum.Retry.BaseDelay,
+			MaxDelay:    config.Ethereum.Retry.MaxDelay,
+		},
+		CallTimeout:       config.Timeouts.RPC,
+		MaxBlockLag:       config.Ethereum.MaxBlockLag,
+		RequestsPerSecond: config.Ethereum.RateLimit.RequestsPerSecond,
+		Burst:             config.Ethereum.RateLimit.Burst,
+		BreakerThreshold:  config.Ethereum.CircuitBreaker.Threshold,
+		BreakerCooldown:   config.Ethereum.CircuitBreaker.Cooldown,
+		Logger:            slog.Default(),
+		OnError: func(url string, err error) {
+			metrics.RPCErrors.Inc()
+		},
+		OnSend: auditSent,
+	}
+	if simulatedChain != nil {
+		return chain.NewClient(simulatedChain.Client(), opts), nil
+	}
+	return chain.DialContext(ctx, urls, opts)
+}
+
+// withTimeout bounds ctx by the configured timeout of an operation, leaving
+// it unbounded when the timeout is zero
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
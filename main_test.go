@@ -0,0 +1,177 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/storage"
+)
+
+// mainArgsEnv makes the test binary run main with the arguments it holds,
+// separated by newlines, so that tests can run commands that exit
+const mainArgsEnv = "CONTRACT_STORAGE_ETH_TEST_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(mainArgsEnv); ok {
+		os.Args = append([]string{os.Args[0]}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command line args in a new process and returns its
+// combined output and exit status
+func runMain(t *testing.T, dir string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), mainArgsEnv+"="+strings.Join(args, "\n"))
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+// indexedDir returns a directory holding the index of the recorded chain
+// and a configuration using it, for commands run with --no-sync
+func indexedDir(t *testing.T) string {
+	t.Helper()
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	store, err := indexer.Open(filepath.Join(dir, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ix := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1})
+	if _, err := ix.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	config := fmt.Sprintf("ethereum:\n  rpc_url: http://127.0.0.1:1\n  chain_id: 1337\ncontract:\n  address: %q\nindex:\n  path: index.db\n", chain.Contract.Hex())
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDispatch(t *testing.T) {
+	dir := t.TempDir()
+	config := "ethereum:\n  rpc_url: http://127.0.0.1:1\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args    []string
+		message string
+		status  int
+	}{
+		{[]string{"help"}, "Usage:", 0},
+		{[]string{"publish"}, "Failed to connect to the broker", exitConfig},
+		{[]string{"publish", "--once"}, "Failed to connect to the broker", exitConfig},
+		{[]string{"mirror"}, "Failed to open mirror database", 1},
+		{[]string{"mirror", "--batch", "0"}, "--batch must be positive", 1},
+		{[]string{"get", "--key", "a"}, "contract.address is not configured", exitConfig},
+		{[]string{"--output", "json", "get", "--key", "a"}, `"code": "config"`, exitConfig},
+		{[]string{"get", "--bogus"}, "flag provided but not defined: -bogus", 2},
+		{[]string{"get", "-h"}, "Usage of get", 0},
+		{[]string{"no-such-command"}, "Unknown command: no-such-command", 2},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			out, status := runMain(t, dir, append([]string{"--no-progress"}, tt.args...)...)
+			if strings.Contains(out, "panic:") || !strings.Contains(out, tt.message) || status != tt.status {
+				t.Errorf("exit status %d, want %d with %q, output:\n%s", status, tt.status, tt.message, out)
+			}
+		})
+	}
+}
+
+// TestSafeProposalFailure checks that deploy fails when the Safe
+// Transaction Service refuses the proposal, rather than reporting success
+func TestSafeProposalFailure(t *testing.T) {
+	var proposed atomic.Bool
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			proposed.Store(true)
+			http.Error(w, `{"detail":"signature does not match"}`, http.StatusUnprocessableEntity)
+		case strings.Contains(r.URL.Path, "multisig-transactions"):
+			w.Write([]byte(`{"results":[]}`))
+		default:
+			w.Write([]byte(`{"nonce":"3"}`))
+		}
+	}))
+	defer service.Close()
+
+	// The node only needs to tell its chain
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := `"0x539"`
+		if req.Method == "eth_blockNumber" {
+			result = `"0x10"`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer node.Close()
+
+	dir := t.TempDir()
+	build := filepath.Join(dir, "build")
+	if err := os.Mkdir(build, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(build, "SaveContract.bin"): "0x6080604052",
+		filepath.Join(build, "SaveContract.abi"): storage.SaveContractABI,
+		filepath.Join(dir, "config.yaml"):        "ethereum:\n  rpc_url: " + node.URL + "\n  chain_id: 1337\n  private_key: " + testKey + "\nbuild:\n  directory: build\n  contract_name: SaveContract\nsafe:\n  address: \"0x00000000000000000000000000000000000000a5\"\n  service_url: " + service.URL + "\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, status := runMain(t, dir, "--no-progress", "--output", "json", "deploy")
+	if status == 0 || !strings.Contains(out, "Failed to propose Safe transaction") || strings.Contains(out, `"ok": true`) {
+		t.Errorf("exit status %d, want a failed proposal, output:\n%s", status, out)
+	}
+	if !proposed.Load() {
+		t.Error("the proposal never reached the Safe Transaction Service")
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/cache"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/forwarder"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const metaUsage = `Usage: contract-storage-eth meta <command> [flags]
+
+Commands:
+  sign   Sign a save as a meta-transaction with the configured key
+  relay  Send a signed save through the forwarder, paying its gas
+`
+
+func runMeta(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, metaUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "sign":
+		return metaSign(ctx, config, args[1:])
+	case "relay":
+		return metaRelay(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown meta command: %s\n\n%s", args[0], metaUsage)
+		return exitError(2)
+	}
+}
+
+// loadForwarder returns the configured forwarder on chainID
+func loadForwarder(config *Config, chainID *big.Int) (*forwarder.Forwarder, error) {
+	fc := config.Forwarder
+	if fc.Address == "" {
+		return nil, fail(codeConfig, "forwarder.address is not configured")
+	}
+	if !common.IsHexAddress(fc.Address) {
+		return nil, errors.New("forwarder.address is not a valid address")
+	}
+	kind := fc.Kind
+	if kind == "" {
+		kind = forwarder.KindERC2771
+	}
+	if kind != forwarder.KindERC2771 && kind != forwarder.KindMinimal {
+		return nil, fmt.Errorf("forwarder.kind must be %s or %s, not %q", forwarder.KindERC2771, forwarder.KindMinimal, kind)
+	}
+	return &forwarder.Forwarder{Kind: kind, Address: common.HexToAddress(fc.Address), ChainID: chainID, Name: fc.Name, Version: fc.Version}, nil
+}
+
+// metaSaveRequest returns the request of the forwarder saving value for
+// from, at its next nonce
+func metaSaveRequest(ctx context.Context, config *Config, client *chain.Client, f *forwarder.Forwarder, contract, from common.Address, key, field, value string) (*forwarder.Request, error) {
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsedABI.Pack("save", key, field, value)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := f.Nonce(ctx, client, from)
+	if err != nil {
+		return nil, fmt.Errorf("forwarder nonce: %w", err)
+	}
+	req := &forwarder.Request{From: from, To: contract, Value: new(big.Int), Gas: config.Forwarder.Gas, Nonce: nonce, Data: data}
+	if req.Gas == 0 {
+		// The request cannot be changed once signed, so its gas is
+		// estimated with a margin, the forwarder appending the address of
+		// the account to the calldata
+		gas, err := client.EstimateGas(ctx, ethereum.CallMsg{From: f.Address, To: &contract, Data: append(data, from.Bytes()...)})
+		if err != nil {
+			return nil, fmt.Errorf("estimate gas: %w", err)
+		}
+		req.Gas = gas + gas/4
+	}
+	if f.Kind == forwarder.KindERC2771 {
+		ttl := config.Forwarder.RequestTTL
+		if ttl <= 0 {
+			ttl = time.Hour
+		}
+		req.Deadline = uint64(time.Now().Add(ttl).Unix())
+	}
+	return req, nil
+}
+
+// checkMetaSave checks that req is a save of contract signed by its account,
+// the only calls the relayer pays for, and returns its key and field
+func checkMetaSave(f *forwarder.Forwarder, contract common.Address, req *forwarder.Request, sig []byte) (string, string, error) {
+	if req.To != contract {
+		return "", "", fmt.Errorf("the request calls %s, not the contract %s", req.To.Hex(), contract.Hex())
+	}
+	if req.Value != nil && req.Value.Sign() != 0 {
+		return "", "", errors.New("the request sends ether")
+	}
+	if f.Kind == forwarder.KindERC2771 && req.Deadline <= uint64(time.Now().Unix()) {
+		return "", "", errors.New("the request expired")
+	}
+	if err := f.Verify(req, sig); err != nil {
+		return "", "", err
+	}
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return "", "", err
+	}
+	method, err := parsedABI.MethodById(req.Data)
+	if err != nil || method.Name != "save" || len(method.Inputs) != 3 {
+		return "", "", errors.New("the request is not a save")
+	}
+	args, err := method.Inputs.Unpack(req.Data[4:])
+	if err != nil {
+		return "", "", fmt.Errorf("the request is not a save: %w", err)
+	}
+	return args[0].(string), args[1].(string), nil
+}
+
+// checkMetaNonce checks that the forwarder has not executed req already, a
+// replay the relayer would otherwise pay for until the forwarder reverts it
+func checkMetaNonce(ctx context.Context, client chain.ChainClient, f *forwarder.Forwarder, req *forwarder.Request) error {
+	nonce, err := f.Nonce(ctx, client, req.From)
+	if err != nil {
+		return err
+	}
+	if req.Nonce == nil || req.Nonce.Cmp(nonce) != 0 {
+		return fmt.Errorf("the request has nonce %v, the forwarder expects %s for %s", req.Nonce, nonce, req.From.Hex())
+	}
+	return nil
+}
+
+// sendMeta sends the execution of a signed request to the forwarder
+func sendMeta(ctx context.Context, config *Config, client *chain.Client, auth *bind.TransactOpts, f *forwarder.Forwarder, req *forwarder.Request, sig []byte) (*types.Transaction, error) {
+	data, err := f.ExecuteData(req, sig)
+	if err != nil {
+		return nil, err
+	}
+	auth.Context = ctx
+	if err := setFees(ctx, config, client, auth); err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(f.Address, abi.ABI{}, client, client, client).RawTransact(auth, data)
+}
+
+// waitMeta waits for the execution of a request saving to contract.
+// MinimalForwarder does not revert when the call it makes fails, so the
+// DataSaved event of the contract is looked for as well
+func waitMeta(ctx context.Context, config *Config, client chain.ChainClient, tx *types.Transaction, contract common.Address) (*types.Receipt, error) {
+	receipt, err := waitMinedWith(ctx, client, tx, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fail(codeTransactionFailed, "transaction %s reverted", tx.Hash().Hex())
+	}
+	for _, l := range receipt.Logs {
+		if _, err := storage.ParseDataSaved(*l); err == nil && l.Address == contract {
+			return receipt, nil
+		}
+	}
+	return receipt, fmt.Errorf("transaction %s was mined, but the forwarder's call to the contract failed", tx.Hash().Hex())
+}
+
+// metaFile is the file `meta sign` writes and `meta relay` reads
+type metaFile struct {
+	Request   *forwarder.Request `json:"request"`
+	Signature hexutil.Bytes      `json:"signature"`
+}
+
+// metaSign signs a save for the forwarder. The account needs no ether, the
+// node is only read for its nonce at the forwarder and the gas estimate
+func metaSign(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("meta sign", flag.ContinueOnError)
+	key := flags.String("key", "", "record key")
+	field := flags.String("field", "", "record field")
+	value := flags.String("value", "", "value to store")
+	valueFile := flags.String("value-file", "", "file whose content to store instead of --value")
+	outFile := flags.String("out", "meta.json", "file the signed request is written to")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "meta sign: --key is required")
+	}
+	sealed, err := sealOffline(ctx, config, *key, *field, *value, *valueFile)
+	if err != nil {
+		return err
+	}
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	f, err := loadForwarder(config, chainID)
+	if err != nil {
+		return err
+	}
+
+	s, err := loadKey(ctx, config.Ethereum.KeyConfig)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+	hashSigner, ok := s.(signer.HashSigner)
+	if !ok {
+		return fail(codeSigner, "This signer cannot sign meta-transactions, use a private key, keystore, mnemonic, Vault or KMS key")
+	}
+	req, err := metaSaveRequest(ctx, config, client, f, address, s.Address(), keyNamespace.Key(*key), *field, sealed)
+	if err != nil {
+		return fmt.Errorf("Failed to build the request: %w", err)
+	}
+	sig, err := f.Sign(ctx, hashSigner, req)
+	if err != nil {
+		return fmt.Errorf("Failed to sign the request: %w", err)
+	}
+
+	data, err := json.MarshalIndent(metaFile{Request: req, Signature: sig}, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(*outFile, append(data, '\n'))
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write the request: %w", err)
+	}
+	fmt.Printf("Signed the save of %s#%s from %s (forwarder nonce %s) to %s\n", keyNamespace.Key(*key), *field, req.From.Hex(), req.Nonce, *outFile)
+	if req.Deadline != 0 {
+		fmt.Printf("It must be relayed before %s\n", time.Unix(int64(req.Deadline), 0).Format(time.DateTime))
+	}
+	printResult(metaFile{Request: req, Signature: sig})
+	return nil
+}
+
+// metaRelayResult is the result of meta relay in JSON output mode
+type metaRelayResult struct {
+	From    common.Address `json:"from"`
+	Relayer common.Address `json:"relayer"`
+	TxHash  common.Hash    `json:"tx_hash"`
+	Block   uint64         `json:"block"`
+	GasUsed uint64         `json:"gas_used"`
+}
+
+func metaRelay(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("meta relay", flag.ContinueOnError)
+	inFile := flags.String("in", "meta.json", "file of the signed request")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*inFile)
+	if err != nil {
+		return fmt.Errorf("Failed to read the request: %w", err)
+	}
+	var in metaFile
+	if err := json.Unmarshal(data, &in); err != nil || in.Request == nil {
+		return fail(codeInvalidArgument, "Invalid request %s: %w", *inFile, err)
+	}
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	f, err := loadForwarder(config, chainID)
+	if err != nil {
+		return err
+	}
+	key, field, err := checkMetaSave(f, address, in.Request, in.Signature)
+	if err == nil {
+		err = checkMetaNonce(ctx, client, f, in.Request)
+	}
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid request: %w", err)
+	}
+
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	activeSigner, err := signers.Active(ctx)
+	if err != nil {
+		return fail(codeSigner, "No signer available: %w", err)
+	}
+	auth, err := signer.NewTransactOpts(ctx, activeSigner, chainID)
+	if err != nil {
+		return fail(codeSigner, "Failed to create auth: %w", err)
+	}
+
+	fmt.Printf("Relaying the save of %s#%s from %s, paid by %s\n", key, field, in.Request.From.Hex(), activeSigner.Address().Hex())
+	tx, err := sendMeta(ctx, config, client, auth, f, in.Request, in.Signature)
+	if err != nil {
+		return fmt.Errorf("Failed to relay the save: %w", err)
+	}
+	fmt.Printf("Transaction: %s\n", tx.Hash().Hex())
+	receipt, err := waitMeta(ctx, config, client, tx, address)
+	if err != nil {
+		return fail(codeTransactionFailed, "Failed to wait for transaction: %w", err)
+	}
+	fmt.Printf("Saved in block %d, gas used %d\n", receipt.BlockNumber.Uint64(), receipt.GasUsed)
+	printResult(metaRelayResult{From: in.Request.From, Relayer: activeSigner.Address(), TxHash: tx.Hash(), Block: receipt.BlockNumber.Uint64(), GasUsed: receipt.GasUsed})
+	return nil
+}
+
+// metaRelayer implements api.Relayer over the records of serve mode
+type metaRelayer struct {
+	records   *recordService
+	forwarder *forwarder.Forwarder
+}
+
+// Prepare implements api.Relayer
+func (m *metaRelayer) Prepare(ctx context.Context, req api.PrepareRequest) (*api.PreparedRequest, error) {
+	key, writes, err := m.records.seal(ctx, req.SaveRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(writes) > 1 {
+		return nil, fmt.Errorf("%w: chunked values cannot be sent as meta-transactions, the value is longer than storage.chunk_size", api.ErrInvalidRecord)
+	}
+	request, err := metaSaveRequest(ctx, m.records.config, m.records.client, m.forwarder, m.records.address, req.From, key, req.Field, writes[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := m.forwarder.TypedData(request)
+	if err != nil {
+		return nil, err
+	}
+	return &api.PreparedRequest{Request: request, TypedData: typed}, nil
+}
+
+// Relay implements api.Relayer
+func (m *metaRelayer) Relay(ctx context.Context, req api.RelayRequest) (*api.WriteResult, error) {
+	s := m.records
+	key, field, err := checkMetaSave(m.forwarder, s.address, req.Request, req.Signature)
+	if err == nil {
+		err = checkMetaNonce(ctx, s.client, m.forwarder, req.Request)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err)
+	}
+	active, err := s.signers.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := signer.NewTransactOpts(ctx, active, new(big.Int).SetUint64(s.ns.ChainID))
+	if err != nil {
+		return nil, err
+	}
+
+	// The relayer's nonce is shared with the saves of /records
+	s.mu.Lock()
+	tx, err := sendMeta(ctx, s.config, s.client, auth, m.forwarder, req.Request, req.Signature)
+	s.mu.Unlock()
+	if err != nil {
+		if chain.IsReverted(err) {
+			return nil, fmt.Errorf("%w: %v", api.ErrRejected, err)
+		}
+		return nil, err
+	}
+	receipt, err := waitMeta(ctx, s.config, s.client, tx, s.address)
+	if err != nil && receipt == nil {
+		return nil, fmt.Errorf("transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", api.ErrRejected, err)
+	}
+	if s.cache != nil {
+		if err := s.cache.Invalidate(ctx, cache.Pair{Key: key, Field: field}); err != nil {
+			slog.Warn("Failed to invalidate cache", "key", key, "field", field, "error", err)
+		}
+	}
+	return &api.WriteResult{ID: s.ns.ID(key, field, 0).String(), TxHash: tx.Hash(), Block: receipt.BlockNumber.Uint64()}, nil
+}
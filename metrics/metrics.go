@@ -0,0 +1,100 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics holds the Prometheus metrics of the tool. They are
+// recorded by every command but only exposed by the long-running ones.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds the metrics below and the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// TransactionsSent counts the transactions sent, by contract method.
+	TransactionsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cse_transactions_sent_total",
+		Help: "Transactions sent to the chain, by contract method.",
+	}, []string{"method"})
+	// ConfirmationSeconds measures how long sent transactions take to be
+	// mined, by receipt status.
+	ConfirmationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cse_confirmation_seconds",
+		Help:    "Time from sending a transaction to its receipt, by receipt status.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 11),
+	}, []string{"status"})
+	// GasUsed counts the gas of mined transactions, by receipt status.
+	GasUsed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cse_gas_used_total",
+		Help: "Gas used by mined transactions, by receipt status.",
+	}, []string{"status"})
+	// RPCErrors counts the failed requests to RPC endpoints, including
+	// those retried or failed over.
+	RPCErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "cse_rpc_errors_total",
+		Help: "Failed requests to RPC endpoints, including retried ones.",
+	})
+	// CacheRequests counts cache lookups, by cache and result ("hit" or
+	// "miss").
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cse_cache_requests_total",
+		Help: "Cache lookups, by cache and result.",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		TransactionsSent,
+		ConfirmationSeconds,
+		GasUsed,
+		RPCErrors,
+		CacheRequests,
+	)
+}
+
+// Status returns the status label of a receipt status.
+func Status(status uint64) string {
+	if status == 1 {
+		return "success"
+	}
+	return "reverted"
+}
+
+// CacheLookup records a lookup in the named cache.
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// Gauge registers a gauge computed by fn on every scrape, such as the
+// depth of a queue kept in a file.
+func Gauge(name, help string, fn func() float64) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, fn))
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/metrics"
+)
+
+func TestHandler(t *testing.T) {
+	metrics.TransactionsSent.WithLabelValues("save").Inc()
+	metrics.CacheLookup("headers", true)
+	metrics.Gauge("cse_test_depth", "Test gauge.", func() float64 { return 3 })
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`cse_transactions_sent_total{method="save"} 1`,
+		`cse_cache_requests_total{cache="headers",result="hit"} 1`,
+		`cse_test_depth 3`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("missing %s in:\n%s", want, body)
+		}
+	}
+}
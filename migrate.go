@@ -0,0 +1,286 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/importer"
+	"contract-storage-eth/progress"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Statuses of the records of a migration report
+const (
+	migrationUnchanged = "unchanged"
+	migrationWritten   = "written"
+	migrationVerified  = "verified"
+	migrationMismatch  = "mismatch"
+	migrationFailed    = "failed"
+	migrationPlanned   = "planned"
+)
+
+func runMigrate(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fromFlag := flags.String("from", "", "address of the old contract to read the records from")
+	toFlag := flags.String("to", config.Contract.Address, "address of the new contract to write them to")
+	fromBlock := flags.Uint64("from-block", config.Index.StartBlock, "first block to scan for records of the old contract")
+	batch := flags.Int("batch", 100, "records sent before waiting for them")
+	concurrency := flags.Int("concurrency", 8, "transactions of each nonce lane awaiting confirmation at once")
+	reportFile := flags.String("report", "migration-report.json", "file the migration report is written to")
+	dryRun := flags.Bool("dry-run", false, "only report what would be written")
+	addSignerFlags(flags, config)
+	addPoolFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *fromFlag == "" || *toFlag == "" {
+		return fail(codeInvalidArgument, "Invalid flags: --from and --to must be contract addresses or ENS names")
+	}
+	if *batch <= 0 || *concurrency <= 0 {
+		return fail(codeInvalidArgument, "Invalid flags: --batch and --concurrency must be positive")
+	}
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return err
+	} else if viaSafe && !*dryRun {
+		return errors.New("migrate sends its transactions directly, unset safe.address to use it")
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	from, err := resolveAddress(ctx, config, client, *fromFlag)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --from: %w", err)
+	}
+	to, err := resolveAddress(ctx, config, client, *toFlag)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --to: %w", err)
+	}
+	if from == to {
+		return fail(codeInvalidArgument, "Invalid flags: --from and --to are the same contract")
+	}
+
+	report := &migrationReport{ChainID: chainID.Uint64(), From: from, To: to, StartedAt: time.Now().UTC(), DryRun: *dryRun}
+	records, err := contractState(ctx, client, config, from, *fromBlock)
+	if err != nil {
+		return fmt.Errorf("Failed to read the old contract: %w", err)
+	}
+	fmt.Printf("Found %d record(s) on %s\n", len(records), from.Hex())
+
+	// Records the new contract holds already are not written again, which
+	// also resumes an interrupted migration
+	var items []importItem
+	for _, rec := range records {
+		entry := migrationEntry{Key: rec.Key, Field: rec.Field, Status: migrationPlanned}
+		current, err := readValue(ctx, client, config, to, rec.Key, rec.Field, nil)
+		if err != nil {
+			return fmt.Errorf("Failed to read the new contract: %w", err)
+		}
+		if current == rec.Value {
+			entry.Status = migrationUnchanged
+		} else if !*dryRun {
+			items = append(items, importItem{index: len(report.Records), record: rec})
+		}
+		report.Records = append(report.Records, entry)
+	}
+	fmt.Printf("%d record(s) to write to %s\n", len(records)-report.count(migrationUnchanged), to.Hex())
+	if *dryRun {
+		if err := writeMigrationReport(*reportFile, report); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if len(items) > 0 {
+		im, closeSigners, err := newImportRun(ctx, config, client, to, *concurrency)
+		if err != nil {
+			return err
+		}
+		defer closeSigners()
+		im.raw = true
+		im.progress = startProgress("Migrating", int64(len(items)), progress.Options{Unit: "records", Rate: true})
+		im.onResult = func(index int, tx common.Hash, err error) {
+			entry := &report.Records[index]
+			if tx != (common.Hash{}) {
+				entry.TxHash = &tx
+			}
+			entry.Status = migrationWritten
+			if err != nil {
+				entry.Status, entry.Error = migrationFailed, err.Error()
+			}
+		}
+		for start := 0; start < len(items); start += *batch {
+			if err := im.sendBatch(ctx, items[start:min(start+*batch, len(items))], 0); err != nil {
+				im.progress.Finish()
+				report.FinishedAt = time.Now().UTC()
+				if err := writeMigrationReport(*reportFile, report); err != nil {
+					return err
+				}
+				return fmt.Errorf("Migration stopped, run it again to resume: %w", err)
+			}
+		}
+		im.progress.Finish()
+	}
+
+	// Check every record on the new contract, including the unchanged ones
+	fmt.Println("Verifying the records of the new contract...")
+	for i, rec := range records {
+		entry := &report.Records[i]
+		if entry.Status == migrationFailed {
+			continue
+		}
+		current, err := readValue(ctx, client, config, to, rec.Key, rec.Field, nil)
+		switch {
+		case err != nil:
+			entry.Status, entry.Error = migrationFailed, "verify: "+err.Error()
+		case current != rec.Value:
+			entry.Status, entry.Error = migrationMismatch, "the new contract holds another value"
+		case entry.Status == migrationWritten:
+			entry.Status = migrationVerified
+		}
+	}
+	report.FinishedAt = time.Now().UTC()
+	if err := writeMigrationReport(*reportFile, report); err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %d record(s) from %s to %s: %d written, %d unchanged, %d mismatched, %d failed\n",
+		len(records), from.Hex(), to.Hex(), report.count(migrationVerified), report.count(migrationUnchanged), report.count(migrationMismatch), report.count(migrationFailed))
+	if bad := report.count(migrationMismatch) + report.count(migrationFailed); bad > 0 {
+		printFailure("migration_incomplete", fmt.Sprintf("%d record(s) not migrated", bad), report)
+		return exitError(1)
+	}
+	printResult(report)
+	return nil
+}
+
+// migrationReport is what migrate writes to its report file
+type migrationReport struct {
+	ChainID    uint64           `json:"chain_id"`
+	From       common.Address   `json:"from"`
+	To         common.Address   `json:"to"`
+	DryRun     bool             `json:"dry_run,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Summary    map[string]int   `json:"summary"`
+	Records    []migrationEntry `json:"records"`
+}
+
+type migrationEntry struct {
+	Key    string       `json:"key"`
+	Field  string       `json:"field"`
+	Status string       `json:"status"`
+	TxHash *common.Hash `json:"tx_hash,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+func (r *migrationReport) count(status string) int {
+	n := 0
+	for _, entry := range r.Records {
+		if entry.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func writeMigrationReport(path string, report *migrationReport) error {
+	report.Summary = map[string]int{}
+	for _, entry := range report.Records {
+		report.Summary[entry.Status]++
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(path, append(data, '\n'))
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write migration report: %w", err)
+	}
+	fmt.Printf("Migration report written to %s\n", path)
+	return nil
+}
+
+// contractState returns the latest value of every key/field pair of a
+// contract from its DataSaved events since fromBlock, in the order of their
+// latest writes, leaving out those whose latest value is empty
+func contractState(ctx context.Context, client *chain.Client, config *Config, address common.Address, fromBlock uint64) ([]*importer.Record, error) {
+	last, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := config.Index.BatchSize
+	if batch == 0 {
+		batch = 2000
+	}
+
+	type position struct{ block, log uint64 }
+	latest := map[[2]string]*importer.Record{}
+	order := map[[2]string]position{}
+	for from := fromBlock; from <= last; from += batch {
+		to := min(from+batch-1, last)
+		q, err := storage.DataSavedQuery(address, from, &to)
+		if err != nil {
+			return nil, err
+		}
+		logs, err := client.FilterLogs(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			ev, err := storage.ParseDataSaved(l)
+			if err != nil {
+				slog.Warn("Skipping undecodable log", "tx_hash", l.TxHash.Hex(), "log_index", l.Index, "error", err)
+				continue
+			}
+			kf := [2]string{ev.Key, ev.Field}
+			latest[kf] = &importer.Record{Key: ev.Key, Field: ev.Field, Value: ev.Value}
+			order[kf] = position{l.BlockNumber, uint64(l.Index)}
+		}
+	}
+
+	var records []*importer.Record
+	for _, rec := range latest {
+		if rec.Value != "" {
+			records = append(records, rec)
+		}
+	}
+	slices.SortFunc(records, func(a, b *importer.Record) int {
+		pa, pb := order[[2]string{a.Key, a.Field}], order[[2]string{b.Key, b.Field}]
+		if pa.block != pb.block {
+			return cmp.Compare(pa.block, pb.block)
+		}
+		return cmp.Compare(pa.log, pb.log)
+	})
+	return records, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"contract-storage-eth/fixtures"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fixtureNode answers the eth_* methods reading logs from the recorded
+// chain, keeping the block ranges queried
+type fixtureNode struct {
+	chain  *fixtures.Chain
+	ranges [][2]uint64
+}
+
+type filterArgs struct {
+	FromBlock *hexutil.Big     `json:"fromBlock"`
+	ToBlock   *hexutil.Big     `json:"toBlock"`
+	Address   []common.Address `json:"address"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
+
+func (n *fixtureNode) ChainId() *hexutil.Big { return (*hexutil.Big)(big.NewInt(1337)) }
+
+func (n *fixtureNode) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	head, err := n.chain.BlockNumber(ctx)
+	return hexutil.Uint64(head), err
+}
+
+func (n *fixtureNode) GetLogs(ctx context.Context, args filterArgs) ([]types.Log, error) {
+	n.ranges = append(n.ranges, [2]uint64{args.FromBlock.ToInt().Uint64(), args.ToBlock.ToInt().Uint64()})
+	return n.chain.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: args.FromBlock.ToInt(), ToBlock: args.ToBlock.ToInt(), Addresses: args.Address, Topics: args.Topics})
+}
+
+func TestContractState(t *testing.T) {
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &fixtureNode{chain: chain}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	endpoint := httptest.NewServer(server)
+	defer endpoint.Close()
+	defer server.Stop()
+
+	ctx := context.Background()
+	var config Config
+	config.Index.BatchSize = 4
+	client, err := dialEndpoints(ctx, &config, []string{endpoint.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	records, err := contractState(ctx, client, &config, chain.Contract, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// invoice-42 was written again after plain
+	want := []string{"plain#note", "invoice-42#pdf", "doc#sha256", "invoice-43#pdf"}
+	if len(records) != len(want) {
+		t.Fatalf("%d records, want %v", len(records), want)
+	}
+	for i, r := range records {
+		if r.Key+"#"+r.Field != want[i] {
+			t.Errorf("record %d is %s#%s, want %s", i, r.Key, r.Field, want[i])
+		}
+	}
+	if records[0].Value != "legacy plain value" || decodeValue(records[1].Value) != "invoice 42 v2" {
+		t.Errorf("records hold %q and %q, want the latest values", records[0].Value, decodeValue(records[1].Value))
+	}
+
+	head, _ := chain.BlockNumber(ctx)
+	for i, r := range node.ranges {
+		if r[1]-r[0]+1 > 4 || (i == 0 && r[0] != 2) || (i > 0 && r[0] != node.ranges[i-1][1]+1) || (i == len(node.ranges)-1 && r[1] != head) {
+			t.Errorf("queried blocks %v, want batches of 4 from block 2 to %d", node.ranges, head)
+			break
+		}
+	}
+}
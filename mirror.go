@@ -0,0 +1,209 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/sqlmirror"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// mirrorDrivers are the database/sql drivers of the dialects of
+// mirror.driver
+var mirrorDrivers = map[string]string{
+	sqlmirror.Postgres: "pgx",
+	sqlmirror.MySQL:    "mysql",
+}
+
+func runMirror(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("mirror", flag.ContinueOnError)
+	once := flags.Bool("once", false, "sync and mirror once, then exit")
+	rebuild := flags.Bool("rebuild", false, "empty the tables and mirror every event again")
+	interval := flags.Duration("interval", config.Index.SyncInterval, "delay between syncs")
+	batch := flags.Int("batch", 1000, "events written per database transaction")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return fail(codeInvalidArgument, "Invalid flags: --batch must be positive")
+	}
+
+	m, db, err := openMirror(ctx, config)
+	if err != nil {
+		return fmt.Errorf("Failed to open mirror database: %w", err)
+	}
+	defer db.Close()
+	if *rebuild {
+		if err := m.Reset(ctx); err != nil {
+			return fmt.Errorf("Failed to reset mirror: %w", err)
+		}
+		fmt.Println("Mirror tables emptied")
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return fail(codeIndex, "Failed to sync index: %w", err)
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return err
+	}
+
+	if *interval <= 0 {
+		*interval = 15 * time.Second
+	}
+	if !*once {
+		fmt.Println("Mirroring new events, press Ctrl+C to stop")
+	}
+	result := &mirrorResult{}
+	for done := false; !done; {
+		// A failed sync still leaves what the index holds to mirror
+		head, err := ix.Sync(ctx)
+		switch {
+		case err == nil:
+			result.Head = head
+		case *once:
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		case ctx.Err() == nil:
+			slog.Warn("Failed to sync index", "error", err)
+		}
+
+		n, err := mirrorIndex(ctx, store, m, *batch)
+		result.Events += n
+		switch {
+		case err == nil && n > 0:
+			slog.Info("Mirrored events", "events", n, "head", head)
+		case err != nil && *once:
+			return fmt.Errorf("Failed to mirror events: %w", err)
+		case err != nil && ctx.Err() == nil:
+			slog.Warn("Failed to mirror events", "error", err)
+		}
+
+		if *once {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-time.After(*interval):
+		}
+	}
+
+	if result.Cursor, err = m.Cursor(context.WithoutCancel(ctx)); err != nil {
+		return fmt.Errorf("Failed to read mirror cursor: %w", err)
+	}
+	fmt.Printf("Mirrored %d event(s), the mirror resumes at block %d\n", result.Events, result.Cursor.Block)
+	printResult(result)
+	return nil
+}
+
+// mirrorResult is the JSON result of mirror
+type mirrorResult struct {
+	Events int              `json:"events"`
+	Head   uint64           `json:"head,omitempty"`
+	Cursor sqlmirror.Cursor `json:"cursor"`
+}
+
+// openMirror connects to the database of mirror.dsn, creating the tables
+// it lacks
+func openMirror(ctx context.Context, config *Config) (*sqlmirror.Mirror, *sql.DB, error) {
+	dialect := config.Mirror.Driver
+	driver, ok := mirrorDrivers[dialect]
+	if !ok {
+		return nil, nil, fmt.Errorf("mirror.driver must be %s or %s, not %q", sqlmirror.Postgres, sqlmirror.MySQL, dialect)
+	}
+	dsn, err := resolveSecret(config.Mirror.DSN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mirror.dsn: %w", err)
+	}
+	if dsn == "" {
+		return nil, nil, fail(codeConfig, "mirror.dsn is not configured")
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := sqlmirror.New(db, sqlmirror.Options{Dialect: dialect, TablePrefix: config.Mirror.TablePrefix})
+	if err == nil {
+		err = m.Migrate(ctx)
+	}
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return m, db, nil
+}
+
+// mirrorIndex writes the indexed events after the cursor of the mirror,
+// with their transactions, batch events per database transaction. It
+// returns how many it wrote
+func mirrorIndex(ctx context.Context, store *indexer.Store, m *sqlmirror.Mirror, batch int) (int, error) {
+	cursor, err := m.Cursor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for {
+		records, err := store.Query(indexer.Query{FromBlock: cursor.Block, FromLog: cursor.Log, Limit: batch})
+		if err != nil || len(records) == 0 {
+			return total, err
+		}
+		var txs []*indexer.Transaction
+		seen := map[common.Hash]bool{}
+		for _, r := range records {
+			if seen[r.TxHash] {
+				continue
+			}
+			seen[r.TxHash] = true
+			t, err := store.Transaction(r.TxHash)
+			if errors.Is(err, indexer.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return total, err
+			}
+			txs = append(txs, t)
+		}
+		if err := m.Apply(ctx, records, txs); err != nil {
+			return total, err
+		}
+		total += len(records)
+		last := records[len(records)-1]
+		cursor = sqlmirror.Cursor{Block: last.BlockNumber, Log: last.LogIndex + 1}
+		if len(records) < batch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mocks has gomock implementations of the interfaces code using
+// this module depends on, so that it can be unit-tested without a chain:
+// chain.ChainClient for the node, signer.Signer and signer.Selector for
+// the keys, and storage.ContractBinder for the contract.
+//
+// The mocks are generated; run `go generate ./mocks` after changing one of
+// the interfaces.
+package mocks
+
+//go:generate go run go.uber.org/mock/mockgen -destination chain.go -package mocks -write_package_comment=false -copyright_file header.txt contract-storage-eth/chain ChainClient
+//go:generate go run go.uber.org/mock/mockgen -destination signer.go -package mocks -write_package_comment=false -copyright_file header.txt contract-storage-eth/signer Signer,Selector
+//go:generate go run go.uber.org/mock/mockgen -destination storage.go -package mocks -write_package_comment=false -copyright_file header.txt contract-storage-eth/storage ContractBinder
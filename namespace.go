@@ -0,0 +1,46 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/keyspace"
+)
+
+// keyNamespace is the namespace of the keys given to the commands, put in
+// front of them on the contract
+var keyNamespace keyspace.Namespace
+
+// setupNamespace selects the namespace of storage.namespace, or the one
+// given with --namespace
+func setupNamespace(config *Config, name string) error {
+	if name == "" {
+		name = config.Storage.Namespace
+	}
+	ns, err := keyspace.Parse(name)
+	if err != nil {
+		return err
+	}
+	keyNamespace = ns
+	return nil
+}
+
+// scopeRecords returns the indexed records of the namespace, or all of them
+func scopeRecords(records []*indexer.Record, all bool) []*indexer.Record {
+	if all {
+		return records
+	}
+	return keyNamespace.Records(records)
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package offline carries transactions between an online machine, which
+// builds them with the nonce and fees of the network, and an air-gapped
+// one holding the key, which signs them without a connection. The signed
+// transaction goes back to the online machine to be broadcast.
+package offline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Version is the version of the files written by this package.
+const Version = 1
+
+// Unsigned is a transaction to sign, with all the fields the signer needs.
+type Unsigned struct {
+	Version int    `json:"version"`
+	ChainID uint64 `json:"chain_id"`
+	// Description tells the reviewer what the transaction does, such as
+	// "save invoice-42#pdf". It is not signed.
+	Description string         `json:"description"`
+	From        common.Address `json:"from"`
+	// To is nil for contract deployments.
+	To    *common.Address `json:"to"`
+	Nonce uint64          `json:"nonce"`
+	Gas   uint64          `json:"gas"`
+	// GasPrice is set for legacy transactions, and MaxFeePerGas and
+	// MaxPriorityFeePerGas for EIP-1559 ones.
+	GasPrice             *big.Int      `json:"gas_price,omitempty"`
+	MaxFeePerGas         *big.Int      `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int      `json:"max_priority_fee_per_gas,omitempty"`
+	Value                *big.Int      `json:"value"`
+	Data                 hexutil.Bytes `json:"data"`
+	CreatedAt            time.Time     `json:"created_at"`
+}
+
+// Validate checks that u is complete.
+func (u *Unsigned) Validate() error {
+	switch {
+	case u.Version != Version:
+		return fmt.Errorf("unsupported version %d, want %d", u.Version, Version)
+	case u.ChainID == 0:
+		return errors.New("chain_id is missing")
+	case u.Gas == 0:
+		return errors.New("gas is missing")
+	case u.To == nil && len(u.Data) == 0:
+		return errors.New("a deployment needs the code of the contract as data")
+	case u.GasPrice != nil && (u.MaxFeePerGas != nil || u.MaxPriorityFeePerGas != nil):
+		return errors.New("gas_price and the EIP-1559 fees are both set")
+	case u.GasPrice == nil && (u.MaxFeePerGas == nil || u.MaxPriorityFeePerGas == nil):
+		return errors.New("either gas_price or max_fee_per_gas and max_priority_fee_per_gas are required")
+	case u.MaxFeePerGas != nil && u.MaxFeePerGas.Cmp(u.MaxPriorityFeePerGas) < 0:
+		return errors.New("max_priority_fee_per_gas is above max_fee_per_gas")
+	}
+	return nil
+}
+
+// Transaction returns the unsigned transaction of u.
+func (u *Unsigned) Transaction() (*types.Transaction, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	value := u.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	if u.GasPrice != nil {
+		return types.NewTx(&types.LegacyTx{Nonce: u.Nonce, GasPrice: u.GasPrice, Gas: u.Gas, To: u.To, Value: value, Data: u.Data}), nil
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   new(big.Int).SetUint64(u.ChainID),
+		Nonce:     u.Nonce,
+		GasTipCap: u.MaxPriorityFeePerGas,
+		GasFeeCap: u.MaxFeePerGas,
+		Gas:       u.Gas,
+		To:        u.To,
+		Value:     value,
+		Data:      u.Data,
+	}), nil
+}
+
+// MaxCost returns the most the transaction can cost, its value included.
+func (u *Unsigned) MaxCost() *big.Int {
+	price := u.GasPrice
+	if price == nil {
+		price = u.MaxFeePerGas
+	}
+	cost := new(big.Int).Mul(price, new(big.Int).SetUint64(u.Gas))
+	if u.Value != nil {
+		cost.Add(cost, u.Value)
+	}
+	return cost
+}
+
+// Matches tells how tx, a signed transaction, differs from the one of u.
+func (u *Unsigned) Matches(tx *types.Transaction) error {
+	want, err := u.Transaction()
+	if err != nil {
+		return err
+	}
+	switch {
+	case want.Type() != tx.Type():
+		return fmt.Errorf("type %d, want %d", tx.Type(), want.Type())
+	case tx.Nonce() != want.Nonce() || tx.Gas() != want.Gas():
+		return fmt.Errorf("nonce %d and gas %d, want %d and %d", tx.Nonce(), tx.Gas(), want.Nonce(), want.Gas())
+	case tx.GasFeeCap().Cmp(want.GasFeeCap()) != 0 || tx.GasTipCap().Cmp(want.GasTipCap()) != 0:
+		return errors.New("the fees differ")
+	case (tx.To() == nil) != (want.To() == nil) || tx.To() != nil && *tx.To() != *want.To():
+		return errors.New("the recipient differs")
+	case tx.Value().Cmp(want.Value()) != 0 || !bytes.Equal(tx.Data(), want.Data()):
+		return errors.New("the value or data differ")
+	}
+	return nil
+}
+
+// Signed is a transaction ready to be broadcast.
+type Signed struct {
+	Version     int            `json:"version"`
+	ChainID     uint64         `json:"chain_id"`
+	Description string         `json:"description"`
+	From        common.Address `json:"from"`
+	Hash        common.Hash    `json:"hash"`
+	// Raw is the signed transaction, as sent to the node.
+	Raw      hexutil.Bytes `json:"raw"`
+	SignedAt time.Time     `json:"signed_at"`
+}
+
+// Sign signs u with s, which must be the account of u.From.
+func Sign(ctx context.Context, s signer.Signer, u *Unsigned) (*Signed, error) {
+	if s.Address() != u.From {
+		return nil, fmt.Errorf("the transaction is from %s, not from the signer %s", u.From.Hex(), s.Address().Hex())
+	}
+	tx, err := u.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := s.SignTx(ctx, tx, new(big.Int).SetUint64(u.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	out := &Signed{
+		Version:     Version,
+		ChainID:     u.ChainID,
+		Description: u.Description,
+		From:        u.From,
+		Hash:        signed.Hash(),
+		Raw:         raw,
+		SignedAt:    time.Now().UTC(),
+	}
+	// Catch signers that signed something else than asked
+	if tx, err = out.Transaction(); err == nil {
+		err = u.Matches(tx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("the signer returned another transaction: %w", err)
+	}
+	return out, nil
+}
+
+// Transaction decodes the signed transaction of s, checking that it is the
+// one described: its hash, chain and sender.
+func (s *Signed) Transaction() (*types.Transaction, error) {
+	if s.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d, want %d", s.Version, Version)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(s.Raw); err != nil {
+		return nil, fmt.Errorf("raw transaction: %w", err)
+	}
+	if tx.Hash() != s.Hash {
+		return nil, fmt.Errorf("the raw transaction is %s, not %s", tx.Hash().Hex(), s.Hash.Hex())
+	}
+	chainID := new(big.Int).SetUint64(s.ChainID)
+	if tx.Protected() && tx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("the transaction is signed for chain %s, not %d", tx.ChainId(), s.ChainID)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	if from != s.From {
+		return nil, fmt.Errorf("the transaction is signed by %s, not %s", from.Hex(), s.From.Hex())
+	}
+	return tx, nil
+}
+
+// Write writes v, an Unsigned or Signed, to path as indented JSON. The
+// file is not replaced if it exists, so that a transaction is not lost.
+func Write(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadUnsigned reads and validates the unsigned transaction of path.
+func ReadUnsigned(path string) (*Unsigned, error) {
+	u := new(Unsigned)
+	if err := read(path, u); err != nil {
+		return nil, err
+	}
+	if err := u.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return u, nil
+}
+
+// ReadSigned reads the signed transaction of path.
+func ReadSigned(path string) (*Signed, error) {
+	s := new(Signed)
+	if err := read(path, s); err != nil {
+		return nil, err
+	}
+	if len(s.Raw) == 0 {
+		return nil, fmt.Errorf("%s: not a signed transaction", path)
+	}
+	return s, nil
+}
+
+func read(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/offline"
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newSigner(t *testing.T) *signer.KeySigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer.NewKeySigner(key)
+}
+
+func unsigned(from common.Address) *offline.Unsigned {
+	to := common.HexToAddress("0x5f0a")
+	return &offline.Unsigned{
+		Version:              offline.Version,
+		ChainID:              11155111,
+		Description:          "save invoice-42#pdf",
+		From:                 from,
+		To:                   &to,
+		Nonce:                7,
+		Gas:                  60000,
+		MaxFeePerGas:         big.NewInt(3e9),
+		MaxPriorityFeePerGas: big.NewInt(1e9),
+		Value:                new(big.Int),
+		Data:                 []byte{0xde, 0xad, 0xbe, 0xef},
+		CreatedAt:            time.Now().UTC(),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := newSigner(t)
+	dir := t.TempDir()
+	if err := offline.Write(filepath.Join(dir, "tx.json"), unsigned(s.Address())); err != nil {
+		t.Fatal(err)
+	}
+	u, err := offline.ReadUnsigned(filepath.Join(dir, "tx.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := offline.Sign(context.Background(), s, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.Write(filepath.Join(dir, "signed.json"), signed); err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.Write(filepath.Join(dir, "signed.json"), signed); err == nil {
+		t.Error("replaced an existing file")
+	}
+
+	back, err := offline.ReadSigned(filepath.Join(dir, "signed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := back.Transaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.DynamicFeeTxType || tx.Nonce() != 7 || tx.Hash() != signed.Hash || back.Description != u.Description {
+		t.Errorf("got %+v", back)
+	}
+	if err := u.Matches(tx); err != nil {
+		t.Error(err)
+	}
+	other := unsigned(s.Address())
+	other.Nonce++
+	if err := other.Matches(tx); err == nil {
+		t.Error("matched another nonce")
+	}
+}
+
+func TestLegacyDeploy(t *testing.T) {
+	s := newSigner(t)
+	u := unsigned(s.Address())
+	u.To, u.MaxFeePerGas, u.MaxPriorityFeePerGas, u.GasPrice = nil, nil, nil, big.NewInt(2e9)
+	signed, err := offline.Sign(context.Background(), s, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := signed.Transaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.LegacyTxType || tx.To() != nil || tx.ChainId().Uint64() != u.ChainID {
+		t.Errorf("got type %d to %v chain %s", tx.Type(), tx.To(), tx.ChainId())
+	}
+	if want := big.NewInt(2e9 * 60000); u.MaxCost().Cmp(want) != 0 {
+		t.Errorf("max cost %s, want %s", u.MaxCost(), want)
+	}
+}
+
+func TestRejected(t *testing.T) {
+	s := newSigner(t)
+	if _, err := offline.Sign(context.Background(), newSigner(t), unsigned(s.Address())); err == nil || !strings.Contains(err.Error(), "not from the signer") {
+		t.Errorf("signed for another account: %v", err)
+	}
+
+	u := unsigned(s.Address())
+	u.GasPrice = big.NewInt(1)
+	if err := u.Validate(); err == nil {
+		t.Error("validated both kinds of fees")
+	}
+
+	signed, err := offline.Sign(context.Background(), s, unsigned(s.Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := *signed
+	tampered.From = common.HexToAddress("0x01")
+	if _, err := tampered.Transaction(); err == nil {
+		t.Error("accepted another sender")
+	}
+	tampered = *signed
+	tampered.ChainID = 1
+	if _, err := tampered.Transaction(); err == nil {
+		t.Error("accepted another chain")
+	}
+
+	path := filepath.Join(t.TempDir(), "tx.json")
+	os.WriteFile(path, []byte(`{"version": 1, "chain_id": 1, "gas": 1, "gas_price": 1, "to": "0x01", "extra": true}`), 0o600)
+	if _, err := offline.ReadUnsigned(path); err == nil {
+		t.Error("read a file with an unknown field")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"contract-storage-eth/arweave"
+	"contract-storage-eth/ipfs"
+)
+
+// Stores of the values too large to keep on chain
+const (
+	offloadArweave = "arweave"
+	offloadIPFS    = "ipfs"
+)
+
+// offloadTarget returns where a sealed value is to be stored instead of on
+// chain, or "" when it stays on chain. Arweave wins when both apply
+func offloadTarget(config *Config, sealed string) string {
+	switch {
+	case config.Arweave.Enabled && len(sealed) > config.Arweave.StoreAbove:
+		return offloadArweave
+	case config.IPFS.Enabled && len(sealed) > config.IPFS.StoreAbove:
+		return offloadIPFS
+	}
+	return ""
+}
+
+// offloadValue stores a sealed value on Arweave or IPFS when it is longer
+// than their store_above, returning the pointer to write on chain in its
+// place
+func offloadValue(ctx context.Context, config *Config, sealed string) (string, error) {
+	switch offloadTarget(config, sealed) {
+	case offloadArweave:
+		uploader, err := arweaveUploader(config)
+		if err != nil {
+			return "", err
+		}
+		ctx, cancel := withTimeout(ctx, arweaveTimeout(config))
+		defer cancel()
+		p, err := arweave.Put(ctx, uploader, sealed)
+		if err != nil {
+			return "", err
+		}
+		return p.String(), nil
+	case offloadIPFS:
+		pinner, err := ipfsPinner(config)
+		if err != nil {
+			return "", err
+		}
+		ctx, cancel := withTimeout(ctx, ipfsTimeout(config))
+		defer cancel()
+		p, err := ipfs.Put(ctx, pinner, sealed)
+		if err != nil {
+			return "", err
+		}
+		return p.String(), nil
+	}
+	return sealed, nil
+}
+
+// offloadPlaceholder returns a pointer as long as the one offloadValue
+// would write, without storing anything, for estimates
+func offloadPlaceholder(config *Config, sealed string) string {
+	sum := sha256.Sum256([]byte(sealed))
+	switch offloadTarget(config, sealed) {
+	case offloadArweave:
+		p := &arweave.Pointer{ID: base64.RawURLEncoding.EncodeToString(make([]byte, sha256.Size)), Length: len(sealed), SHA256: hex.EncodeToString(sum[:])}
+		return p.String()
+	case offloadIPFS:
+		// CIDv1 of SHA-256 digests are 59 characters long in base32
+		p := &ipfs.Pointer{CID: "bafkrei" + strings.Repeat("a", 52), Length: len(sealed), SHA256: hex.EncodeToString(sum[:])}
+		return p.String()
+	}
+	return sealed
+}
+
+// resolveValue returns the value a stored pointer points to, fetched from
+// Arweave or IPFS, or the stored value when it is no pointer
+func resolveValue(ctx context.Context, config *Config, value string) (string, error) {
+	if p, err := arweave.Parse(value); err != nil {
+		return "", err
+	} else if p != nil {
+		ctx, cancel := withTimeout(ctx, arweaveTimeout(config))
+		defer cancel()
+		resolved, err := arweave.Get(ctx, arweaveFetcher(config), p)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch %s from Arweave: %w", p.ID, err)
+		}
+		return resolved, nil
+	}
+
+	p, err := ipfs.Parse(value)
+	if err != nil || p == nil {
+		return value, err
+	}
+	ctx, cancel := withTimeout(ctx, ipfsTimeout(config))
+	defer cancel()
+	resolved, err := ipfs.Get(ctx, ipfsFetcher(config), p)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s from IPFS: %w", p.CID, err)
+	}
+	return resolved, nil
+}
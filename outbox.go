@@ -0,0 +1,475 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/outbox"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const outboxUsage = `Usage: contract-storage-eth outbox <command> [flags]
+
+Commands:
+  add       Queue a save for the dispatcher to send
+  list      List the queued writes and what became of them
+  show      Show one write, by its ID
+  retry     Queue a failed write again
+  cancel    Drop a write not signed yet
+  purge     Delete the writes done before --older-than
+  run       Send the queued writes until interrupted
+`
+
+func runOutbox(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, outboxUsage)
+		return exitError(2)
+	}
+	if config.Outbox.File == "" {
+		return fail(codeConfig, "outbox.file is not configured")
+	}
+
+	switch args[0] {
+	case "add":
+		return outboxAdd(ctx, config, args[1:])
+	case "list":
+		return outboxList(ctx, config, args[1:])
+	case "show", "retry", "cancel":
+		return outboxItem(ctx, config, args[0], args[1:])
+	case "purge":
+		return outboxPurge(ctx, config, args[1:])
+	case "run":
+		return outboxRun(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown outbox command: %s\n\n%s", args[0], outboxUsage)
+		return exitError(2)
+	}
+}
+
+func openOutbox(config *Config) (*outbox.Store, error) {
+	store, err := outbox.Open(config.Outbox.File)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open outbox: %w", err)
+	}
+	return store, nil
+}
+
+// outboxAdd seals a value as save does and queues its write. Values are
+// queued with their final content, which the dispatcher sends as is
+func outboxAdd(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("outbox add", flag.ContinueOnError)
+	key := flags.String("key", "", "record key")
+	field := flags.String("field", "", "record field")
+	value := flags.String("value", "", "value to store")
+	valueFile := flags.String("value-file", "", "file whose content to store instead of --value")
+	tags := tagFlag{}
+	flags.Var(tags, "tag", "tag the record with NAME=VALUE (repeatable)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "outbox add: --key is required")
+	}
+	content := []byte(*value)
+	if *valueFile != "" {
+		var err error
+		if content, err = os.ReadFile(*valueFile); err != nil {
+			return fmt.Errorf("Failed to read value file: %w", err)
+		}
+	}
+	codec, err := checkSchema(config, *key, *field, content)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid value: %w", err)
+	}
+	*key = keyNamespace.Key(*key)
+	sealed, err := sealValueAs(config, content, indexer.TagMeta(tags), codec)
+	if err != nil {
+		return fmt.Errorf("Failed to seal value: %w", err)
+	}
+	if sealed, err = offloadValue(ctx, config, sealed); err != nil {
+		return fmt.Errorf("Failed to store value off chain: %w", err)
+	}
+	if writes := splitValue(config, *field, sealed); len(writes) > 1 {
+		return fmt.Errorf("The value is %d bytes, more than storage.chunk_size, and chunked values cannot be queued in the outbox", len(content))
+	}
+
+	store, err := openOutbox(config)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	item, err := store.Enqueue(ctx, *key, *field, sealed)
+	if err != nil {
+		return fmt.Errorf("Failed to queue write: %w", err)
+	}
+	fmt.Printf("Queued write %d of %s#%s in %s\n", item.ID, item.Key, item.Field, config.Outbox.File)
+	printResult(item)
+	return nil
+}
+
+// outboxListResult is the JSON result of outbox list
+type outboxListResult struct {
+	Counts map[outbox.State]int `json:"counts"`
+	Items  []*outbox.Item       `json:"items"`
+}
+
+func outboxList(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("outbox list", flag.ContinueOnError)
+	states := flags.String("state", "", "only list writes in these states, comma separated: "+joinOutboxStates())
+	limit := flags.Int("limit", 100, "latest writes listed at most, 0 for all")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	filter := outbox.Filter{Limit: *limit}
+	if *states != "" {
+		for _, s := range strings.Split(*states, ",") {
+			state, err := outbox.ParseState(strings.TrimSpace(s))
+			if err != nil {
+				return fail(codeInvalidArgument, "Invalid --state: %w", err)
+			}
+			filter.States = append(filter.States, state)
+		}
+	}
+
+	store, err := openOutbox(config)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	items, err := store.List(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("Failed to list outbox: %w", err)
+	}
+	counts, err := store.Counts(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to list outbox: %w", err)
+	}
+	if output.json {
+		printResult(outboxListResult{Counts: counts, Items: items})
+		return nil
+	}
+
+	var summary []string
+	for _, state := range outbox.States {
+		if counts[state] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[state], state))
+		}
+	}
+	if len(summary) == 0 {
+		fmt.Println("No writes in the outbox")
+		return nil
+	}
+	fmt.Printf("Outbox %s: %s\n", config.Outbox.File, strings.Join(summary, ", "))
+	if len(items) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKEY\tFIELD\tSTATE\tATTEMPTS\tNONCE\tTX\tUPDATED\tERROR")
+	for _, item := range items {
+		nonce, tx := "-", "-"
+		if item.Nonce != nil {
+			nonce = strconv.FormatUint(*item.Nonce, 10)
+		}
+		if item.TxHash != nil {
+			tx = item.TxHash.Hex()[:18] + "..."
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			item.ID, item.Key, item.Field, item.State, item.Attempts, nonce, tx, item.UpdatedAt.Local().Format(time.DateTime), item.Error)
+	}
+	tw.Flush()
+	return nil
+}
+
+func joinOutboxStates() string {
+	names := make([]string, len(outbox.States))
+	for i, state := range outbox.States {
+		names[i] = string(state)
+	}
+	return strings.Join(names, ", ")
+}
+
+// outboxItem runs the commands taking the ID of a write
+func outboxItem(ctx context.Context, config *Config, command string, args []string) error {
+	if len(args) != 1 {
+		return fail(codeInvalidArgument, "outbox %s: the ID of a write is required", command)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid ID: %v", args[0])
+	}
+
+	store, err := openOutbox(config)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	switch command {
+	case "retry":
+		err = store.Retry(ctx, id)
+	case "cancel":
+		err = store.Cancel(ctx, id)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to %s write %d: %w", command, id, err)
+	}
+	item, err := store.Get(ctx, id)
+	if err != nil {
+		return fail(codeNotFound, "Write %d not found: %w", id, err)
+	}
+	if output.json {
+		printResult(item)
+		return nil
+	}
+
+	fmt.Printf("Write %d: %s#%s, %s after %d attempt(s)\n", item.ID, item.Key, item.Field, item.State, item.Attempts)
+	fmt.Printf("  Value:    %d bytes\n", len(item.Value))
+	fmt.Printf("  Queued:   %s\n", item.CreatedAt.Local().Format(time.DateTime))
+	fmt.Printf("  Updated:  %s\n", item.UpdatedAt.Local().Format(time.DateTime))
+	if item.State == outbox.StateQueued && item.NextAttempt.After(time.Now()) {
+		fmt.Printf("  Next try: %s\n", item.NextAttempt.Local().Format(time.DateTime))
+	}
+	if item.TxHash != nil {
+		fmt.Printf("  From:     %s, nonce %d\n", item.From.Hex(), *item.Nonce)
+		fmt.Printf("  Tx:       %s\n", item.TxHash.Hex())
+	}
+	if item.Block != 0 {
+		fmt.Printf("  Block:    %d\n", item.Block)
+	}
+	if item.Error != "" {
+		fmt.Printf("  Error:    %s\n", item.Error)
+	}
+	return nil
+}
+
+func outboxPurge(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("outbox purge", flag.ContinueOnError)
+	olderThan := flags.Duration("older-than", 7*24*time.Hour, "delete the writes mined, failed or canceled longer ago than this")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := openOutbox(config)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	n, err := store.Purge(ctx, time.Now().Add(-*olderThan))
+	if err != nil {
+		return fmt.Errorf("Failed to purge outbox: %w", err)
+	}
+	fmt.Printf("Deleted %d write(s) from the outbox\n", n)
+	printResult(map[string]int{"deleted": n})
+	return nil
+}
+
+// outboxRun dispatches the outbox in the foreground. Several may run on the
+// same file, only one of them sending at a time
+func outboxRun(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("outbox run", flag.ContinueOnError)
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return err
+	} else if viaSafe {
+		return errors.New("The outbox sends its transactions directly, unset safe.address to use it")
+	}
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	backend, err := newOutboxBackend(ctx, config, client, address)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	defer backend.Close()
+
+	store, err := openOutbox(config)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	host, _ := os.Hostname()
+	d := &outbox.Dispatcher{
+		Store:         store,
+		Backend:       backend,
+		MaxAttempts:   config.Outbox.MaxAttempts,
+		RetryDelay:    config.Outbox.RetryDelay,
+		PollInterval:  config.Outbox.PollInterval,
+		Confirmations: config.Confirmation.Confirmations,
+		Owner:         fmt.Sprintf("%s:%d", host, os.Getpid()),
+		OnChange: func(item *outbox.Item) {
+			attrs := []any{"id", item.ID, "key", item.Key, "field", item.Field, "state", item.State, "attempts", item.Attempts}
+			if item.TxHash != nil {
+				attrs = append(attrs, "tx_hash", item.TxHash.Hex())
+			}
+			switch {
+			case item.State == outbox.StateFailed:
+				slog.Error("Outbox write failed", append(attrs, "error", item.Error)...)
+			case item.Error != "":
+				slog.Warn("Outbox write will be retried", append(attrs, "error", item.Error, "next_attempt", item.NextAttempt)...)
+			default:
+				slog.Info("Outbox write "+string(item.State), attrs...)
+			}
+			streamResult(item)
+		},
+	}
+	fmt.Printf("Dispatching %s to %s, press Ctrl+C to stop\n", config.Outbox.File, address.Hex())
+	if err := d.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("Outbox dispatcher stopped: %w", err)
+	}
+	fmt.Println("Stopped, the writes in flight are sent on the next run")
+	return nil
+}
+
+// outboxBackend sends the writes of the outbox from the active signer
+type outboxBackend struct {
+	config   *Config
+	client   *chain.Client
+	contract storage.ContractBinder
+	signers  signer.Selector
+	chainID  *big.Int
+	// audited holds the transactions whose receipts were recorded
+	audited map[common.Hash]bool
+}
+
+func newOutboxBackend(ctx context.Context, config *Config, client *chain.Client, address common.Address) (*outboxBackend, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := storage.Bind(address, client)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	return &outboxBackend{
+		config:   config,
+		client:   client,
+		contract: contract,
+		signers:  signers,
+		chainID:  chainID,
+		audited:  map[common.Hash]bool{},
+	}, nil
+}
+
+func (b *outboxBackend) Close() {
+	if closer, ok := b.signers.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// From implements outbox.Backend
+func (b *outboxBackend) From(ctx context.Context) (common.Address, error) {
+	s, err := b.signers.Active(ctx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return s.Address(), nil
+}
+
+// PendingNonce implements outbox.Backend
+func (b *outboxBackend) PendingNonce(ctx context.Context, from common.Address) (uint64, error) {
+	return b.client.PendingNonceAt(ctx, from)
+}
+
+// Nonce implements outbox.Backend
+func (b *outboxBackend) Nonce(ctx context.Context, from common.Address) (uint64, error) {
+	return b.client.NonceAt(ctx, from, nil)
+}
+
+// Sign implements outbox.Backend. The transaction is only signed, the
+// dispatcher broadcasts it once stored
+func (b *outboxBackend) Sign(ctx context.Context, item *outbox.Item, from common.Address, nonce uint64) (*types.Transaction, error) {
+	s, err := b.signers.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.Address() != from {
+		return nil, fmt.Errorf("the active signer changed from %s to %s", from.Hex(), s.Address().Hex())
+	}
+	auth, err := signer.NewTransactOpts(ctx, s, b.chainID)
+	if err != nil {
+		return nil, err
+	}
+	auth.NoSend = true
+	auth.Nonce = new(big.Int).SetUint64(nonce)
+	return sendSave(ctx, b.client, b.contract, auth, b.config, item.Key, item.Field, item.Value)
+}
+
+// Send implements outbox.Backend
+func (b *outboxBackend) Send(ctx context.Context, tx *types.Transaction) error {
+	err := b.client.SendTransaction(ctx, tx)
+	if chain.IsAlreadyKnown(err) {
+		return nil
+	}
+	return err
+}
+
+// Receipt implements outbox.Backend, recording the gas and audit entry of
+// each transaction once
+func (b *outboxBackend) Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.client.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !b.audited[hash] {
+		b.audited[hash] = true
+		if tx, _, err := b.client.TransactionByHash(ctx, hash); err == nil {
+			recordGas(tx, receipt)
+			auditMined(tx, receipt)
+		}
+	}
+	return receipt, nil
+}
+
+// BlockNumber implements outbox.Backend
+func (b *outboxBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return b.client.BlockNumber(ctx)
+}
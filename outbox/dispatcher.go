@@ -0,0 +1,273 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"contract-storage-eth/chain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend signs and submits the transactions of a Dispatcher.
+type Backend interface {
+	// From is the account signing the transactions.
+	From(ctx context.Context) (common.Address, error)
+	// PendingNonce returns the next nonce of the account, counting the
+	// transactions in the mempool, and Nonce the next one once those mined
+	// are counted only.
+	PendingNonce(ctx context.Context, from common.Address) (uint64, error)
+	Nonce(ctx context.Context, from common.Address) (uint64, error)
+	// Sign returns the signed save of item, as the transaction of nonce.
+	Sign(ctx context.Context, item *Item, from common.Address, nonce uint64) (*types.Transaction, error)
+	// Send broadcasts tx. It may be called again for a transaction the node
+	// knows already, which must not be an error.
+	Send(ctx context.Context, tx *types.Transaction) error
+	// Receipt returns the receipt of the transaction once it is mined, and
+	// nil until then.
+	Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+	// BlockNumber returns the number of the latest block.
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// Dispatcher sends the items of a Store through a Backend, one transaction
+// at a time, in the order they were queued.
+type Dispatcher struct {
+	Store   *Store
+	Backend Backend
+	// MaxAttempts bounds the transactions signed for an item, including
+	// those that reverted, before it fails. Defaults to 5.
+	MaxAttempts int
+	// RetryDelay is the wait before the second attempt, doubled for each
+	// later one. Defaults to 10 seconds.
+	RetryDelay time.Duration
+	// PollInterval is how often the receipt of a sent transaction and the
+	// queue when empty are checked. Defaults to 2 seconds.
+	PollInterval time.Duration
+	// Confirmations is the number of blocks, the one of the transaction
+	// included, an item waits for before it is mined. Defaults to 1.
+	Confirmations uint64
+	// Owner names the dispatcher in the lease of the store.
+	Owner string
+	// OnChange, when set, is called each time an item changes state.
+	OnChange func(item *Item)
+}
+
+// Run dispatches items until ctx is done. Only the dispatcher holding the
+// lease of the store sends, the others wait for it to expire.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	poll := d.PollInterval
+	if poll <= 0 {
+		poll = 2 * time.Second
+	}
+	defer d.Store.release(context.WithoutCancel(ctx), d.Owner)
+	for {
+		worked := false
+		held, err := d.Store.acquire(ctx, d.Owner, 5*poll)
+		if err == nil && held {
+			worked, err = d.Step(ctx)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if worked {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// Step moves the next item forward, reporting whether it changed state.
+// Failures of the backend are recorded on the item and retried, so the
+// error returned is that of the store.
+func (d *Dispatcher) Step(ctx context.Context) (bool, error) {
+	item, err := d.Store.next(ctx, time.Now())
+	if err != nil || item == nil {
+		return false, err
+	}
+	previous := item.State
+	switch item.State {
+	case StateQueued:
+		d.sign(ctx, item)
+	case StateSigned:
+		d.send(ctx, item)
+	case StateSent:
+		if !d.check(ctx, item) {
+			return false, nil
+		}
+	}
+	if err := d.Store.update(ctx, item); err != nil {
+		return false, err
+	}
+	if item.State == previous {
+		return false, nil
+	}
+	if d.OnChange != nil {
+		d.OnChange(item)
+	}
+	// Retries wait for their turn, anything else goes on at once
+	return item.State != StateQueued, nil
+}
+
+// sign signs the transaction of a queued item with the next nonce of the
+// account. It is stored before it is broadcast, so that a restart sends
+// the same one
+func (d *Dispatcher) sign(ctx context.Context, item *Item) {
+	from, err := d.Backend.From(ctx)
+	if err != nil {
+		d.retry(item, fmt.Errorf("signer: %w", err), false)
+		return
+	}
+	nonce, err := d.Backend.PendingNonce(ctx, from)
+	if err != nil {
+		d.retry(item, err, false)
+		return
+	}
+	tx, err := d.Backend.Sign(ctx, item, from, nonce)
+	if err != nil {
+		d.retry(item, err, true)
+		return
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		d.retry(item, err, true)
+		return
+	}
+	hash := tx.Hash()
+	item.State, item.Attempts, item.Error = StateSigned, item.Attempts+1, ""
+	item.From, item.Nonce, item.TxHash, item.RawTx = &from, &nonce, &hash, raw
+}
+
+// send broadcasts the signed transaction of item. When the node refuses it,
+// its nonce may have been taken by another transaction of the account, in
+// which case the item is signed again
+func (d *Dispatcher) send(ctx context.Context, item *Item) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(item.RawTx); err != nil {
+		d.retry(item, fmt.Errorf("stored transaction: %w", err), true)
+		return
+	}
+	err := d.Backend.Send(ctx, tx)
+	if err == nil {
+		item.State, item.Error = StateSent, ""
+		return
+	}
+	if receipt, rerr := d.Backend.Receipt(ctx, tx.Hash()); rerr == nil && receipt != nil {
+		// Sent before a restart, and mined since
+		item.State, item.Error = StateSent, ""
+		return
+	}
+	if taken, nerr := d.nonceTaken(ctx, item); nerr == nil && taken {
+		d.retry(item, fmt.Errorf("nonce %d used by another transaction: %w", *item.Nonce, err), false)
+		return
+	}
+	// Kept signed, to be broadcast again
+	item.Error = err.Error()
+}
+
+// check looks for the receipt of the transaction of item, reporting whether
+// the item moved on
+func (d *Dispatcher) check(ctx context.Context, item *Item) bool {
+	receipt, err := d.Backend.Receipt(ctx, *item.TxHash)
+	if err == nil && receipt != nil {
+		head, err := d.Backend.BlockNumber(ctx)
+		if err != nil || head+1 < receipt.BlockNumber.Uint64()+max(d.Confirmations, 1) {
+			return false
+		}
+		d.settle(item, receipt)
+		return true
+	}
+	if err == nil {
+		if taken, nerr := d.nonceTaken(ctx, item); nerr == nil && taken {
+			// Another transaction took the nonce, such as a replacement
+			// sent by hand
+			d.retry(item, fmt.Errorf("transaction %s replaced, nonce %d used by another transaction", item.TxHash.Hex(), *item.Nonce), false)
+			return true
+		}
+		// Broadcast again in case the node dropped it. The node refusing it
+		// for good, as when the account cannot pay for it any more, fails
+		// the item rather than waiting for a receipt that will not come
+		tx := new(types.Transaction)
+		if tx.UnmarshalBinary(item.RawTx) == nil {
+			err := d.Backend.Send(ctx, tx)
+			if err != nil && !chain.IsAlreadyKnown(err) && !chain.IsRetriable(err) {
+				item.State, item.Error = StateFailed, fmt.Sprintf("rebroadcast %s: %v", item.TxHash.Hex(), err)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nonceTaken reports whether the nonce of item is used by a mined
+// transaction
+func (d *Dispatcher) nonceTaken(ctx context.Context, item *Item) (bool, error) {
+	nonce, err := d.Backend.Nonce(ctx, *item.From)
+	if err != nil {
+		return false, err
+	}
+	if nonce <= *item.Nonce {
+		return false, nil
+	}
+	// Mined, but maybe as this transaction, whose receipt is checked last
+	receipt, err := d.Backend.Receipt(ctx, *item.TxHash)
+	return err == nil && receipt == nil, err
+}
+
+// settle records the receipt of the transaction of item
+func (d *Dispatcher) settle(item *Item, receipt *types.Receipt) {
+	item.Block = receipt.BlockNumber.Uint64()
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		item.State, item.Error = StateMined, ""
+		return
+	}
+	d.retry(item, fmt.Errorf("transaction %s reverted", item.TxHash.Hex()), false)
+}
+
+// retry queues item again after err, failing it for good once it used its
+// attempts. An attempt is a transaction signed or one that could not be:
+// count is set for the latter, and unset for errors of the node or signer
+// and for transactions counted when they were signed
+func (d *Dispatcher) retry(item *Item, err error, count bool) {
+	if count {
+		item.Attempts++
+	}
+	item.Error = err.Error()
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if item.Attempts >= maxAttempts {
+		item.State = StateFailed
+		return
+	}
+	delay := d.RetryDelay
+	if delay <= 0 {
+		delay = 10 * time.Second
+	}
+	item.State = StateQueued
+	item.NextAttempt = time.Now().Add(delay << min(max(item.Attempts-1, 0), 10))
+}
@@ -0,0 +1,348 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox is a durable queue of outbound saves. Writes are enqueued
+// in a SQLite database, then signed and submitted one at a time by a
+// Dispatcher. Each transaction is stored signed before it is broadcast, so
+// a dispatcher restarting after a crash broadcasts the same transaction
+// again instead of writing the record twice.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	_ "modernc.org/sqlite"
+)
+
+// State is where an item is in the queue.
+type State string
+
+// States of items. Queued items wait to be signed, signed ones to be
+// broadcast and sent ones to be mined. Mined, failed and canceled items
+// are done.
+const (
+	StateQueued   State = "queued"
+	StateSigned   State = "signed"
+	StateSent     State = "sent"
+	StateMined    State = "mined"
+	StateFailed   State = "failed"
+	StateCanceled State = "canceled"
+)
+
+// States lists the states in the order items go through them.
+var States = []State{StateQueued, StateSigned, StateSent, StateMined, StateFailed, StateCanceled}
+
+// ParseState returns the state named s.
+func ParseState(s string) (State, error) {
+	for _, state := range States {
+		if string(state) == s {
+			return state, nil
+		}
+	}
+	return "", fmt.Errorf("unknown state %q", s)
+}
+
+// Done reports whether items in the state are finished with.
+func (s State) Done() bool {
+	return s == StateMined || s == StateFailed || s == StateCanceled
+}
+
+// ErrNotFound is returned for unknown items.
+var ErrNotFound = errors.New("no such item")
+
+// Item is a queued save with the state of its transaction.
+type Item struct {
+	ID       int64  `json:"id"`
+	Key      string `json:"key"`
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	State    State  `json:"state"`
+	Attempts int    `json:"attempts"`
+	// From, Nonce, TxHash and RawTx are those of the latest signed
+	// transaction.
+	From   *common.Address `json:"from,omitempty"`
+	Nonce  *uint64         `json:"nonce,omitempty"`
+	TxHash *common.Hash    `json:"tx_hash,omitempty"`
+	RawTx  []byte          `json:"-"`
+	Block  uint64          `json:"block,omitempty"`
+	// Error is why the latest attempt failed.
+	Error       string    `json:"error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key TEXT NOT NULL,
+	field TEXT NOT NULL,
+	value TEXT NOT NULL,
+	state TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	sender TEXT,
+	nonce INTEGER,
+	tx_hash TEXT,
+	raw_tx BLOB,
+	block INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	next_attempt INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS outbox_state ON outbox (state, id);
+CREATE TABLE IF NOT EXISTS outbox_lease (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	owner TEXT NOT NULL,
+	expires INTEGER NOT NULL
+);
+`
+
+const columns = "id, key, field, value, state, attempts, sender, nonce, tx_hash, raw_tx, block, error, next_attempt, created_at, updated_at"
+
+// Store is the queue. Several processes can open it at once, such as one
+// enqueueing writes while another dispatches them.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the queue at path, creating it if needed.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Enqueue appends a save to the queue.
+func (s *Store) Enqueue(ctx context.Context, key, field, value string) (*Item, error) {
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, "INSERT INTO outbox (key, field, value, state, next_attempt, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		key, field, value, StateQueued, now.UnixMilli(), now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Get returns the item id.
+func (s *Store) Get(ctx context.Context, id int64) (*Item, error) {
+	items, err := s.query(ctx, "SELECT "+columns+" FROM outbox WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return items[0], nil
+}
+
+// Filter selects the items of List.
+type Filter struct {
+	// States keeps the items in one of them, all items when empty.
+	States []State
+	// Limit bounds the number of items, the latest ones, when positive.
+	Limit int
+}
+
+// List returns the items of f, oldest first.
+func (s *Store) List(ctx context.Context, f Filter) ([]*Item, error) {
+	query, args := "SELECT "+columns+" FROM outbox", []any{}
+	if len(f.States) > 0 {
+		query += " WHERE state IN (?" + strings.Repeat(", ?", len(f.States)-1) + ")"
+		for _, state := range f.States {
+			args = append(args, state)
+		}
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+	items, err := s.query(ctx, query, args...)
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items, err
+}
+
+// Counts returns the number of items in each state.
+func (s *Store) Counts(ctx context.Context) (map[State]int, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT state, COUNT(*) FROM outbox GROUP BY state")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[State]int{}
+	for rows.Next() {
+		var state State
+		var n int
+		if err := rows.Scan(&state, &n); err != nil {
+			return nil, err
+		}
+		counts[state] = n
+	}
+	return counts, rows.Err()
+}
+
+// next returns the item the dispatcher works on next: the oldest one in
+// flight, which must settle before another is signed, or else the oldest
+// queued one due at now. It returns nil when there is none.
+func (s *Store) next(ctx context.Context, now time.Time) (*Item, error) {
+	items, err := s.query(ctx, "SELECT "+columns+" FROM outbox WHERE state IN (?, ?) ORDER BY id LIMIT 1", StateSigned, StateSent)
+	if err != nil || len(items) > 0 {
+		return first(items), err
+	}
+	items, err = s.query(ctx, "SELECT "+columns+" FROM outbox WHERE state = ? AND next_attempt <= ? ORDER BY id LIMIT 1", StateQueued, now.UnixMilli())
+	return first(items), err
+}
+
+func first(items []*Item) *Item {
+	if len(items) == 0 {
+		return nil
+	}
+	return items[0]
+}
+
+// update stores the fields of item the dispatcher changes.
+func (s *Store) update(ctx context.Context, item *Item) error {
+	item.UpdatedAt = time.Now().UTC()
+	var sender, hash any
+	if item.From != nil {
+		sender = item.From.Hex()
+	}
+	if item.TxHash != nil {
+		hash = item.TxHash.Hex()
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE outbox SET state = ?, attempts = ?, sender = ?, nonce = ?, tx_hash = ?, raw_tx = ?, block = ?, error = ?, next_attempt = ?, updated_at = ? WHERE id = ?",
+		item.State, item.Attempts, sender, item.Nonce, hash, item.RawTx, item.Block, item.Error, item.NextAttempt.UnixMilli(), item.UpdatedAt.UnixMilli(), item.ID)
+	return err
+}
+
+// Retry queues a failed item again, with its attempts reset.
+func (s *Store) Retry(ctx context.Context, id int64) error {
+	return s.transition(ctx, id, StateFailed, StateQueued, "attempts = 0, error = '', next_attempt = ?", time.Now().UnixMilli())
+}
+
+// Cancel takes a queued item out of the queue. Items signed already may be
+// mined whatever happens, so they cannot be canceled.
+func (s *Store) Cancel(ctx context.Context, id int64) error {
+	return s.transition(ctx, id, StateQueued, StateCanceled, "error = 'canceled'")
+}
+
+// transition moves item id from one state to another, setting more columns
+func (s *Store) transition(ctx context.Context, id int64, from, to State, set string, args ...any) error {
+	args = append([]any{to, time.Now().UnixMilli()}, args...)
+	args = append(args, id, from)
+	res, err := s.db.ExecContext(ctx, "UPDATE outbox SET state = ?, updated_at = ?, "+set+" WHERE id = ? AND state = ?", args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	item, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("item %d is %s, not %s", id, item.State, from)
+}
+
+// Purge removes the items done before t and returns their number.
+func (s *Store) Purge(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM outbox WHERE state IN (?, ?, ?) AND updated_at < ?", StateMined, StateFailed, StateCanceled, before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// acquire takes or renews the lease of the dispatcher for ttl, reporting
+// whether owner holds it. A single dispatcher holds it at a time, so that
+// two never sign items at once.
+func (s *Store) acquire(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `INSERT INTO outbox_lease (id, owner, expires) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET owner = excluded.owner, expires = excluded.expires
+		WHERE outbox_lease.owner = excluded.owner OR outbox_lease.expires < ?`,
+		owner, now.Add(ttl).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// release gives up the lease of owner.
+func (s *Store) release(ctx context.Context, owner string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM outbox_lease WHERE owner = ?", owner)
+	return err
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) ([]*Item, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*Item
+	for rows.Next() {
+		var (
+			item                   Item
+			sender, hash           sql.NullString
+			nonce                  sql.NullInt64
+			next, created, updated int64
+		)
+		if err := rows.Scan(&item.ID, &item.Key, &item.Field, &item.Value, &item.State, &item.Attempts, &sender, &nonce, &hash, &item.RawTx, &item.Block, &item.Error, &next, &created, &updated); err != nil {
+			return nil, err
+		}
+		if sender.Valid {
+			from := common.HexToAddress(sender.String)
+			item.From = &from
+		}
+		if nonce.Valid {
+			n := uint64(nonce.Int64)
+			item.Nonce = &n
+		}
+		if hash.Valid {
+			h := common.HexToHash(hash.String)
+			item.TxHash = &h
+		}
+		item.NextAttempt, item.CreatedAt, item.UpdatedAt = time.UnixMilli(next).UTC(), time.UnixMilli(created).UTC(), time.UnixMilli(updated).UTC()
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
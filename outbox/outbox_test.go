@@ -0,0 +1,370 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"contract-storage-eth/outbox"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeChain mines every transaction sent to it at once
+type fakeChain struct {
+	from     common.Address
+	nonce    uint64
+	mempool  map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	sent     []common.Hash
+	// revert makes the transactions of these keys revert
+	revert map[string]bool
+	// pause keeps the transactions sent in the mempool
+	pause bool
+	head  uint64
+	// sendErr makes the node refuse the transactions sent
+	sendErr error
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		from:     common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7"),
+		mempool:  map[common.Hash]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{},
+		revert:   map[string]bool{},
+	}
+}
+
+func (c *fakeChain) From(ctx context.Context) (common.Address, error) { return c.from, nil }
+
+func (c *fakeChain) PendingNonce(ctx context.Context, from common.Address) (uint64, error) {
+	return c.nonce + uint64(len(c.mempool)), nil
+}
+
+func (c *fakeChain) Nonce(ctx context.Context, from common.Address) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *fakeChain) Sign(ctx context.Context, item *outbox.Item, from common.Address, nonce uint64) (*types.Transaction, error) {
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &c.from, Data: []byte(item.Key + "#" + item.Field), Gas: 21000, GasPrice: big.NewInt(1)}), nil
+}
+
+func (c *fakeChain) Send(ctx context.Context, tx *types.Transaction) error {
+	if c.sendErr != nil {
+		return c.sendErr
+	}
+	if _, ok := c.receipts[tx.Hash()]; ok {
+		return errors.New("nonce too low")
+	}
+	if _, ok := c.mempool[tx.Hash()]; ok {
+		return errors.New("already known")
+	}
+	if tx.Nonce() < c.nonce {
+		return errors.New("nonce too low")
+	}
+	c.sent = append(c.sent, tx.Hash())
+	c.mempool[tx.Hash()] = tx
+	if !c.pause {
+		c.mine()
+	}
+	return nil
+}
+
+// mine includes the transactions of the mempool in a block of their own
+func (c *fakeChain) mine() {
+	for hash, tx := range c.mempool {
+		c.head++
+		status := types.ReceiptStatusSuccessful
+		if c.revert[string(tx.Data())] {
+			status = types.ReceiptStatusFailed
+		}
+		c.receipts[hash] = &types.Receipt{Status: status, BlockNumber: new(big.Int).SetUint64(c.head), TxHash: hash}
+		c.nonce++
+		delete(c.mempool, hash)
+	}
+}
+
+func (c *fakeChain) Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return c.receipts[hash], nil
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) { return c.head, nil }
+
+func open(t *testing.T, path string) *outbox.Store {
+	t.Helper()
+	store, err := outbox.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// drain steps the dispatcher until it has nothing to do
+func drain(t *testing.T, d *outbox.Dispatcher) {
+	t.Helper()
+	for range 100 {
+		worked, err := d.Step(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !worked {
+			return
+		}
+	}
+	t.Fatal("dispatcher did not settle")
+}
+
+func TestDispatch(t *testing.T) {
+	ctx := context.Background()
+	store := open(t, filepath.Join(t.TempDir(), "outbox.db"))
+	chain := newFakeChain()
+	var changes []outbox.State
+	d := &outbox.Dispatcher{Store: store, Backend: chain, OnChange: func(item *outbox.Item) { changes = append(changes, item.State) }}
+
+	for _, key := range []string{"a", "b"} {
+		if _, err := store.Enqueue(ctx, key, "f", "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	drain(t, d)
+
+	items, err := store.List(ctx, outbox.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range items {
+		if item.State != outbox.StateMined || item.Attempts != 1 || *item.Nonce != uint64(i) || item.Block == 0 {
+			t.Errorf("item %d: %+v", item.ID, item)
+		}
+	}
+	if len(chain.sent) != 2 {
+		t.Errorf("sent %d transactions, want 2", len(chain.sent))
+	}
+	if want := "signed sent mined signed sent mined"; joinStates(changes) != want {
+		t.Errorf("changes %s, want %s", joinStates(changes), want)
+	}
+	counts, err := store.Counts(ctx)
+	if err != nil || counts[outbox.StateMined] != 2 {
+		t.Errorf("counts %v, %v", counts, err)
+	}
+}
+
+func joinStates(states []outbox.State) string {
+	s := ""
+	for i, state := range states {
+		if i > 0 {
+			s += " "
+		}
+		s += string(state)
+	}
+	return s
+}
+
+func TestRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	chain := newFakeChain()
+	chain.pause = true
+
+	// The first dispatcher stops right after signing, as if it crashed
+	store := open(t, path)
+	if _, err := store.Enqueue(ctx, "a", "f", "v"); err != nil {
+		t.Fatal(err)
+	}
+	first := &outbox.Dispatcher{Store: store, Backend: chain}
+	if _, err := first.Step(ctx); err != nil {
+		t.Fatal(err)
+	}
+	signed, _ := store.Get(ctx, 1)
+	if signed.State != outbox.StateSigned || signed.TxHash == nil {
+		t.Fatalf("after signing: %+v", signed)
+	}
+	store.Close()
+
+	store = open(t, path)
+	second := &outbox.Dispatcher{Store: store, Backend: chain}
+	drain(t, second)
+	chain.mine()
+	drain(t, second)
+
+	item, _ := store.Get(ctx, 1)
+	if item.State != outbox.StateMined || *item.TxHash != *signed.TxHash || item.Attempts != 1 {
+		t.Errorf("after the restart: %+v", item)
+	}
+	if len(chain.sent) != 1 || chain.sent[0] != *signed.TxHash {
+		t.Errorf("sent %v, want the transaction signed before the restart", chain.sent)
+	}
+}
+
+func TestConfirmations(t *testing.T) {
+	ctx := context.Background()
+	store := open(t, filepath.Join(t.TempDir(), "outbox.db"))
+	chain := newFakeChain()
+	d := &outbox.Dispatcher{Store: store, Backend: chain, Confirmations: 3}
+	store.Enqueue(ctx, "a", "f", "v")
+	drain(t, d)
+	if item, _ := store.Get(ctx, 1); item.State != outbox.StateSent {
+		t.Fatalf("mined before its confirmations: %+v", item)
+	}
+	chain.head += 2
+	drain(t, d)
+	if item, _ := store.Get(ctx, 1); item.State != outbox.StateMined {
+		t.Errorf("not mined after its confirmations: %+v", item)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	store := open(t, filepath.Join(t.TempDir(), "outbox.db"))
+	chain := newFakeChain()
+	chain.revert["bad#f"] = true
+	d := &outbox.Dispatcher{Store: store, Backend: chain, MaxAttempts: 2, RetryDelay: time.Millisecond}
+
+	store.Enqueue(ctx, "bad", "f", "v")
+	for range 3 {
+		drain(t, d)
+		time.Sleep(5 * time.Millisecond)
+	}
+	item, _ := store.Get(ctx, 1)
+	if item.State != outbox.StateFailed || item.Attempts != 2 || item.Error == "" {
+		t.Fatalf("after reverting twice: %+v", item)
+	}
+
+	delete(chain.revert, "bad#f")
+	if err := store.Retry(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	drain(t, d)
+	if item, _ := store.Get(ctx, 1); item.State != outbox.StateMined {
+		t.Errorf("after a retry: %+v", item)
+	}
+	if err := store.Retry(ctx, 1); err == nil {
+		t.Error("retried a mined item")
+	}
+}
+
+func TestNonceTaken(t *testing.T) {
+	ctx := context.Background()
+	store := open(t, filepath.Join(t.TempDir(), "outbox.db"))
+	chain := newFakeChain()
+	chain.pause = true
+	d := &outbox.Dispatcher{Store: store, Backend: chain, RetryDelay: time.Millisecond}
+
+	store.Enqueue(ctx, "a", "f", "v")
+	drain(t, d)
+	// Another transaction of the account is mined with the same nonce
+	chain.mempool = map[common.Hash]*types.Transaction{}
+	chain.nonce++
+	chain.pause = false
+	drain(t, d)
+	time.Sleep(5 * time.Millisecond)
+	drain(t, d)
+
+	item, _ := store.Get(ctx, 1)
+	if item.State != outbox.StateMined || *item.Nonce != 1 || item.Attempts != 2 {
+		t.Errorf("after the nonce was taken: %+v", item)
+	}
+}
+
+func TestRebroadcast(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+		state   outbox.State
+	}{
+		{"accepted", nil, outbox.StateSent},
+		{"node busy", context.DeadlineExceeded, outbox.StateSent},
+		{"refused", errors.New("insufficient funds for gas * price + value"), outbox.StateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := open(t, filepath.Join(t.TempDir(), "outbox.db"))
+			chain := newFakeChain()
+			chain.pause = true
+			d := &outbox.Dispatcher{Store: store, Backend: chain}
+
+			store.Enqueue(ctx, "a", "f", "v")
+			drain(t, d)
+			// The node drops the transaction, and answers the rebroadcast
+			chain.mempool = map[common.Hash]*types.Transaction{}
+			chain.sendErr = tt.sendErr
+			drain(t, d)
+
+			item, _ := store.Get(ctx, 1)
+			if item.State != tt.state {
+				t.Fatalf("after the rebroadcast: %+v, want %s", item, tt.state)
+			}
+			if tt.state == outbox.StateFailed && item.Error == "" {
+				t.Error("failed without an error")
+			}
+			if tt.sendErr == nil && len(chain.mempool) != 1 {
+				t.Errorf("%d transactions in the mempool after the rebroadcast, want 1", len(chain.mempool))
+			}
+		})
+	}
+}
+
+func TestCancelAndPurge(t *testing.T) {
+	ctx := context.Background()
+	store := open(t, filepath.Join(t.TempDir(), "outbox.db"))
+	store.Enqueue(ctx, "a", "f", "v")
+	if err := store.Cancel(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Cancel(ctx, 1); err == nil {
+		t.Error("canceled twice")
+	}
+	if err := store.Cancel(ctx, 7); !errors.Is(err, outbox.ErrNotFound) {
+		t.Errorf("canceled an unknown item: %v", err)
+	}
+	if n, err := store.Purge(ctx, time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("purged %d, %v", n, err)
+	}
+}
+
+func TestLease(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	store := open(t, path)
+	chain := newFakeChain()
+	first := &outbox.Dispatcher{Store: store, Backend: chain, Owner: "first", PollInterval: 10 * time.Millisecond}
+	second := &outbox.Dispatcher{Store: open(t, path), Backend: chain, Owner: "second", PollInterval: 10 * time.Millisecond}
+
+	done := make(chan struct{})
+	go func() {
+		first.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	store.Enqueue(context.Background(), "a", "f", "v")
+	secondCtx, cancelSecond := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelSecond()
+	if err := second.Run(secondCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	<-done
+	if item, _ := store.Get(context.Background(), 1); item.State != outbox.StateMined {
+		t.Errorf("item %+v", item)
+	}
+}
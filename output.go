@@ -0,0 +1,252 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"contract-storage-eth/chain"
+	"contract-storage-eth/fees"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// Output modes of --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// output is where command results go. In JSON mode the result object is
+// the only thing written to stdout: human-readable progress is sent to
+// stderr instead
+var output struct {
+	json    bool
+	command string
+	stdout  io.Writer
+	done    bool
+}
+
+// commandResult is the single object a command prints in JSON mode
+type commandResult struct {
+	Command string       `json:"command"`
+	OK      bool         `json:"ok"`
+	Result  interface{}  `json:"result,omitempty"`
+	Error   *resultError `json:"error,omitempty"`
+}
+
+type resultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Codes of the failure classes, as the error object of JSON mode reports
+// them
+const (
+	codeError             = "error"
+	codeConfig            = "config"
+	codeInvalidArgument   = "invalid_argument"
+	codeNotFound          = "not_found"
+	codeRPCUnavailable    = "rpc_unavailable"
+	codeSigner            = "signer"
+	codeInsufficientFunds = "insufficient_funds"
+	codeIndex             = "index"
+	codeTransactionFailed = "transaction_failed"
+	codeReceiptTimeout    = "receipt_timeout"
+)
+
+// Exit statuses of the failure classes scripts may branch on. Any other
+// error exits with 1, usage errors with 2, and the verify commands report
+// their findings with 3 to 5
+const (
+	exitConfig            = 10
+	exitRPCUnavailable    = 11
+	exitInsufficientFunds = 12
+	exitTransactionFailed = 13
+	exitReceiptTimeout    = 14
+)
+
+var exitStatuses = map[string]int{
+	codeConfig:            exitConfig,
+	codeRPCUnavailable:    exitRPCUnavailable,
+	codeInsufficientFunds: exitInsufficientFunds,
+	codeTransactionFailed: exitTransactionFailed,
+	codeReceiptTimeout:    exitReceiptTimeout,
+}
+
+// classError is an error of a failure class, which the commands return up
+// to main
+type classError struct {
+	code string
+	err  error
+}
+
+func (e *classError) Error() string { return e.err.Error() }
+func (e *classError) Unwrap() error { return e.err }
+
+// fail returns the error formatted as fmt.Errorf does, of the failure class
+// code
+func fail(code, format string, args ...interface{}) error {
+	return &classError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// exitError ends a command that reported why it failed itself, such as a
+// usage error or a finding of the verify commands, with its exit status
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+// parseFlags parses the flags of a command, failing as flag.ExitOnError
+// would exit: with 2 once the flag package printed the error and usage, and
+// with 0 when help was asked for
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitError(0)
+	case err != nil:
+		return exitError(2)
+	}
+	return nil
+}
+
+// errorCode returns the failure class of err. An unreachable node explains
+// any failure, whatever the command was doing. Otherwise the innermost
+// class wins, as it says most precisely what went wrong, and errors of the
+// node itself are classified by what it answered
+func errorCode(err error) string {
+	if chain.IsUnreachable(err) {
+		return codeRPCUnavailable
+	}
+	code := codeError
+	var classed *classError
+	for e := err; errors.As(e, &classed); e = classed.err {
+		code = classed.code
+	}
+	if code != codeError {
+		return code
+	}
+	var funds *fees.InsufficientFundsError
+	switch {
+	case errors.As(err, &funds), chain.IsInsufficientFunds(err):
+		return codeInsufficientFunds
+	case chain.IsReverted(err):
+		return codeTransactionFailed
+	}
+	return codeError
+}
+
+// exitStatus returns the exit status of the error of a command
+func exitStatus(err error) int {
+	var exit exitError
+	if errors.As(err, &exit) {
+		return int(exit)
+	}
+	if status, ok := exitStatuses[errorCode(err)]; ok {
+		return status
+	}
+	return 1
+}
+
+// reportError reports the error of a command, as the error object in JSON
+// mode, and returns the status to exit with
+func reportError(err error) int {
+	var exit exitError
+	if errors.As(err, &exit) {
+		return int(exit)
+	}
+	log.Print(err)
+	if output.json {
+		printCommandResult(commandResult{Error: &resultError{Code: errorCode(err), Message: err.Error()}})
+	}
+	return exitStatus(err)
+}
+
+// logOutput is where the log package writes
+var logOutput io.Writer = os.Stderr
+
+// setLogOutput makes w the writer of the log package
+func setLogOutput(w io.Writer) {
+	logOutput = w
+	log.SetOutput(w)
+}
+
+// setupOutput switches to an output mode
+func setupOutput(mode, command string) error {
+	switch mode {
+	case "", outputText:
+		output.stdout = os.Stdout
+		return nil
+	case outputJSON:
+	default:
+		return fmt.Errorf("output must be text or json, not %q", mode)
+	}
+
+	if !output.json {
+		output.json, output.command, output.stdout = true, command, secrets.Writer(os.Stdout)
+		os.Stdout = os.Stderr
+	}
+	return nil
+}
+
+// printResult prints the result of a successful command in JSON mode. It
+// does nothing in text mode, where the command prints its own output
+func printResult(v interface{}) {
+	if output.json {
+		printCommandResult(commandResult{OK: true, Result: v})
+	}
+}
+
+// printFailure prints the result of a command that ran but failed, which
+// then exits with its failure status. In text mode, the command has printed
+// what failed already
+func printFailure(code, message string, v interface{}) {
+	if output.json {
+		printCommandResult(commandResult{Result: v, Error: &resultError{Code: code, Message: message}})
+	}
+}
+
+// streamResult prints one of the results of a command running until it is
+// interrupted, as a line of JSON. Such commands print no final object, so
+// that their output is JSON Lines
+func streamResult(v interface{}) {
+	if !output.json {
+		return
+	}
+	output.done = true
+	json.NewEncoder(output.stdout).Encode(v)
+}
+
+// finishOutput prints a bare success result for commands that had nothing
+// to report
+func finishOutput() {
+	if output.json && !output.done {
+		printCommandResult(commandResult{OK: true})
+	}
+}
+
+func printCommandResult(r commandResult) {
+	if output.done {
+		return
+	}
+	output.done = true
+	r.Command = output.command
+	encoder := json.NewEncoder(output.stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(r)
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/fees"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestErrorCode(t *testing.T) {
+	// A real refused connection, as a dead node gives
+	_, refused := net.Dial("tcp", "127.0.0.1:1")
+	if refused == nil {
+		t.Skip("something listens on 127.0.0.1:1")
+	}
+	timeout := fail(codeReceiptTimeout, "no receipt within timeouts.confirmation (%s): %w", 2*time.Minute, context.DeadlineExceeded)
+	short := &fees.InsufficientFundsError{Account: common.HexToAddress(testAccount), Balance: big.NewInt(1), Cost: big.NewInt(2), Required: big.NewInt(3)}
+
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"not found while waiting", fail(codeTransactionFailed, "Failed to wait for transaction: %w", ethereum.NotFound), codeTransactionFailed, exitTransactionFailed},
+		{"invalid argument of a dead node", fail(codeInvalidArgument, "Invalid --address: %w", fmt.Errorf("resolve name.eth: %w", refused)), codeRPCUnavailable, exitRPCUnavailable},
+		{"index of a dead node", fail(codeIndex, "Failed to sync index: %w", refused), codeRPCUnavailable, exitRPCUnavailable},
+		{"every endpoint cooling down", fail(codeNotFound, "No value stored: %w", chain.ErrCircuitOpen), codeRPCUnavailable, exitRPCUnavailable},
+		{"connect", fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", refused), codeRPCUnavailable, exitRPCUnavailable},
+		{"receipt timeout", fail(codeTransactionFailed, "Failed to wait for save transaction: %w", timeout), codeReceiptTimeout, exitReceiptTimeout},
+		{"replacements timeout", fail(codeTransactionFailed, "Failed to wait for transaction: %w", fail(codeReceiptTimeout, "none mined within timeouts.confirmation (%s): %w", time.Minute, context.DeadlineExceeded)), codeReceiptTimeout, exitReceiptTimeout},
+		{"unsettled queued write", unsettledError{fail(codeTransactionFailed, "Failed to wait for save transaction %s, run `resume` to continue: %w", "0x01", io.ErrUnexpectedEOF)}, codeTransactionFailed, exitTransactionFailed},
+		{"reverted", fail(codeTransactionFailed, "Transaction %s failed!", "0x01"), codeTransactionFailed, exitTransactionFailed},
+		{"estimate reverted", fmt.Errorf("Failed to estimate deployment gas: %w", errors.New("execution reverted: paused")), codeTransactionFailed, exitTransactionFailed},
+		{"balance check", fail(codeInsufficientFunds, "Not enough funds for %s: %w. Top up the account or lower balance_check.buffer_percent and balance_check.min_balance", "the transaction", short), codeInsufficientFunds, exitInsufficientFunds},
+		{"funds the node refused", fmt.Errorf("Failed to call save function: %w", errors.New("insufficient funds for gas * price + value: balance 0, tx cost 21000, overshot 21000")), codeInsufficientFunds, exitInsufficientFunds},
+		{"config", fail(codeConfig, "Failed to load config: %w", errors.New("yaml: line 2: did not find expected key")), codeConfig, exitConfig},
+		{"tracing", fail(codeConfig, "Failed to set up tracing: %w", errors.New("invalid OTLP header")), codeConfig, exitConfig},
+		{"setting not configured", fmt.Errorf("Failed to open mirror database: %w", fail(codeConfig, "mirror.dsn is not configured")), codeConfig, exitConfig},
+		{"invalid value", fail(codeInvalidArgument, "Invalid value: %w", errors.New("field amount: not a number")), codeInvalidArgument, 1},
+		{"required flag", fail(codeInvalidArgument, "save: --key is required"), codeInvalidArgument, 1},
+		{"no value", fail(codeNotFound, "No value stored for %s#%s", "invoice-42", "pdf"), codeNotFound, 1},
+		{"signer", fail(codeSigner, "Failed to load private key: %w", errors.New("no key configured")), codeSigner, 1},
+		{"unclassified", fmt.Errorf("HTTP server failed: %w", errors.New("use of closed network connection")), codeError, 1},
+		{"usage", exitError(2), codeError, 2},
+		{"verify finding", exitError(exitVerifyMismatch), codeError, exitVerifyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, status := errorCode(tt.err), exitStatus(tt.err); code != tt.code || status != tt.status {
+				t.Errorf("%q: code %s, status %d, want %s, %d", tt.err, code, status, tt.code, tt.status)
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args   []string
+		status int // -1 when the flags parse
+	}{
+		{[]string{"--key", "a"}, -1},
+		{[]string{"--bogus"}, 2},
+		{[]string{"--key"}, 2},
+		{[]string{"-h"}, 0},
+	}
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("get", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		flags.String("key", "", "record key")
+		err := parseFlags(flags, tt.args)
+		var exit exitError
+		if tt.status < 0 && err != nil || tt.status >= 0 && (!errors.As(err, &exit) || int(exit) != tt.status) {
+			t.Errorf("%q: %v, want exit status %d", tt.args, err, tt.status)
+		}
+	}
+}
+
+// TestSetupErrorCodes checks that failing to set up a command is reported
+// as a configuration problem
+func TestSetupErrorCodes(t *testing.T) {
+	// The node only needs to tell its chain, which has no ENS registry
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x539"}`, req.ID)
+	}))
+	defer node.Close()
+	base := "ethereum:\n  rpc_url: " + node.URL + "\n"
+	address := "contract:\n  address: \"0x00000000000000000000000000000000000000c5\"\n"
+
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		files   map[string]string
+		message string
+	}{
+		{"select contract", base + address, []string{"--contract", "not an alias"}, nil, "Failed to select contract"},
+		{"logging", base + address + "log:\n  format: xml\n", nil, nil, "Failed to set up logging"},
+		// Code that reverts when deployed
+		{"simulated chain", base + "build:\n  directory: .\n  contract_name: Broken\n", []string{"--simulated"}, map[string]string{"Broken.bin": "0xfe", "Broken.abi": "[]"}, "Failed to start the simulated chain"},
+		{"resolve address", base + "contract:\n  address: storage.eth\n", nil, nil, "Failed to resolve address"},
+		{"audit log", base + address + "audit:\n  file: missing/audit.log\n", nil, nil, "Failed to open audit log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{"config.yaml": tt.config}
+			for name, content := range tt.files {
+				files[name] = content
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			args := append([]string{"--no-progress", "--output", "json"}, tt.args...)
+			out, status := runMain(t, dir, append(args, "get", "--key", "a")...)
+			if status != exitConfig || !strings.Contains(out, tt.message) || !strings.Contains(out, `"code": "config"`) {
+				t.Errorf("exit status %d, want %d with %q, output:\n%s", status, exitConfig, tt.message, out)
+			}
+		})
+	}
+}
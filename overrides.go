@@ -0,0 +1,216 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envPrefix starts the names of the environment variables overriding the
+// settings of config.yaml
+const envPrefix = "CSE_"
+
+// configEnv names the configuration file when --config is not given
+const configEnv = envPrefix + "CONFIG"
+
+// shortFlagSections are the sections whose settings also have a flag
+// without the section name, such as --rpc-url for ethereum.rpc_url
+var shortFlagSections = []string{"ethereum", "build"}
+
+// globalSettings are top-level settings with a global flag of their own
+var globalSettings = map[string]bool{"network": true, "output": true}
+
+// settingFlag is the flag of a setting
+type settingFlag struct {
+	path string
+	bool bool
+}
+
+// settingFlags returns the flags of every setting by name: the path of its
+// YAML keys joined by hyphens, such as --ethereum-gas-limit, and for the
+// settings of shortFlagSections the same without the section when no other
+// setting takes that name, such as --gas-limit
+func settingFlags() map[string]settingFlag {
+	return settingFlagsOf(reflect.ValueOf(&Config{}).Elem())
+}
+
+// settingFlagsOf returns the flags of the settings of config, a struct
+// laid out like Config
+func settingFlagsOf(config reflect.Value) map[string]settingFlag {
+	flags := map[string]settingFlag{}
+	var short []string
+	shortPaths := map[string][]string{}
+	walkSettings(config, "", func(path string, v reflect.Value) error {
+		if globalSettings[path] {
+			return nil
+		}
+		flag := settingFlag{path: path, bool: v.Kind() == reflect.Bool}
+		flags[hyphenate(path)] = flag
+		if section, rest, ok := strings.Cut(path, "."); ok {
+			for _, s := range shortFlagSections {
+				if s == section {
+					name := hyphenate(rest)
+					if len(shortPaths[name]) == 0 {
+						short = append(short, name)
+					}
+					shortPaths[name] = append(shortPaths[name], path)
+				}
+			}
+		}
+		return nil
+	})
+	for _, name := range short {
+		if paths := shortPaths[name]; len(paths) == 1 {
+			if _, taken := flags[name]; !taken {
+				flags[name] = settingFlag{path: paths[0], bool: flags[hyphenate(paths[0])].bool}
+			}
+		}
+	}
+	return flags
+}
+
+func hyphenate(path string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(path)
+}
+
+// settingValue is the value of a setting given as a flag
+type settingValue struct {
+	flag, value string
+}
+
+// takeSettingFlags takes the flags of settings out of args, wherever they
+// are, and returns their values by setting path. Boolean settings may be
+// given alone, as --strict-keys, or with a value, as --strict-keys=false
+func takeSettingFlags(args []string) (map[string]settingValue, []string, error) {
+	flags := settingFlags()
+	values := map[string]settingValue{}
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		flag, ok := flags[name]
+		if !ok || !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+		switch {
+		case hasValue:
+		case flag.bool:
+			value = "true"
+		case i+1 < len(args):
+			i++
+			value = args[i]
+		default:
+			return nil, nil, fmt.Errorf("--%s requires a value", name)
+		}
+		values[flag.path] = settingValue{flag: "--" + name, value: value}
+	}
+	return values, rest, nil
+}
+
+// applyOverrides sets the settings given in the environment and then the
+// ones given as flags, so that flags take precedence over the environment
+// and both over config.yaml. CSE_ETHEREUM_RPC_URL replaces
+// ethereum.rpc_url, and so on for every field, the path of its YAML keys in
+// upper case joined by underscores. Strings are taken as they are and other
+// values are parsed as YAML, so lists are given as ["a", "b"] and whole
+// maps, such as CSE_NETWORKS, as YAML mappings. The variable or flag that
+// set each setting is recorded in config.overrides
+func applyOverrides(config *Config, flagValues map[string]settingValue) error {
+	overrides := map[string]string{}
+	err := walkSettings(reflect.ValueOf(config).Elem(), "", func(path string, v reflect.Value) error {
+		if value, ok := os.LookupEnv(envName(path)); ok {
+			if err := setSetting(v, value); err != nil {
+				return fmt.Errorf("%s: %w", envName(path), err)
+			}
+			overrides[path] = envName(path)
+		}
+		if value, ok := flagValues[path]; ok {
+			if err := setSetting(v, value.value); err != nil {
+				return fmt.Errorf("%s: %w", value.flag, err)
+			}
+			overrides[path] = value.flag
+		}
+		return nil
+	})
+	config.overrides = overrides
+	return err
+}
+
+// setSetting sets a setting from its text
+func setSetting(v reflect.Value, value string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(value)
+		return nil
+	}
+	// Decode into a fresh value, so that lists and maps are replaced
+	// rather than merged
+	fresh := reflect.New(v.Type())
+	if err := yaml.UnmarshalStrict([]byte(value), fresh.Interface()); err != nil {
+		return err
+	}
+	v.Set(fresh.Elem())
+	return nil
+}
+
+// envName returns the environment variable of a setting path
+func envName(path string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// walkSettings calls fn with the path and value of every setting below v,
+// a struct of the configuration. Structs are walked into, while maps and
+// lists are settings of their own
+func walkSettings(v reflect.Value, prefix string, fn func(path string, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		path := prefix
+		if opts != "inline" {
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if path != "" {
+				path += "."
+			}
+			path += name
+		}
+		value := v.Field(i)
+		// Types decoding themselves, such as URLList, are single settings
+		if _, custom := value.Addr().Interface().(yaml.Unmarshaler); value.Kind() == reflect.Struct && !custom {
+			if err := walkSettings(value, path, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
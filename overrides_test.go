@@ -0,0 +1,276 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSettingFlags(t *testing.T) {
+	var config struct {
+		Ethereum struct {
+			Timeout string `yaml:"timeout"`
+			Strict  bool   `yaml:"strict"`
+			Server  struct {
+				Port int `yaml:"port"`
+			} `yaml:"server"`
+		} `yaml:"ethereum"`
+		Build struct {
+			Timeout   string `yaml:"timeout"`
+			Directory string `yaml:"directory"`
+		} `yaml:"build"`
+		Server struct {
+			Port int `yaml:"port"`
+		} `yaml:"server"`
+	}
+	flags := settingFlagsOf(reflect.ValueOf(&config).Elem())
+	want := map[string]settingFlag{
+		"ethereum-timeout":     {path: "ethereum.timeout"},
+		"ethereum-strict":      {path: "ethereum.strict", bool: true},
+		"ethereum-server-port": {path: "ethereum.server.port"},
+		"build-timeout":        {path: "build.timeout"},
+		"build-directory":      {path: "build.directory"},
+		"server-port":          {path: "server.port"},
+		// Settings of one section only have a flag without it
+		"strict":    {path: "ethereum.strict", bool: true},
+		"directory": {path: "build.directory"},
+		// timeout is claimed by both sections, and server-port by
+		// server.port already
+	}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("got %v, want %v", flags, want)
+	}
+
+	configFlags := settingFlags()
+	for name, path := range map[string]string{
+		"rpc-url":                "ethereum.rpc_url",
+		"ethereum-rpc-url":       "ethereum.rpc_url",
+		"contract-name":          "build.contract_name",
+		"standby-private-key":    "ethereum.standby.private_key",
+		"storage-chunk-size":     "storage.chunk_size",
+		"server-idempotency-ttl": "server.idempotency.ttl",
+	} {
+		if configFlags[name].path != path {
+			t.Errorf("--%s sets %q, want %s", name, configFlags[name].path, path)
+		}
+	}
+	for _, name := range []string{"network", "output", "chunk-size"} {
+		if flag, ok := configFlags[name]; ok {
+			t.Errorf("--%s sets %s", name, flag.path)
+		}
+	}
+}
+
+func TestTakeSettingFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		values map[string]string
+		rest   []string
+		err    string
+	}{
+		{
+			name:   "anywhere",
+			args:   []string{"--gas-limit", "300000", "save", "k", "--ethereum-chain-id=5", "v"},
+			values: map[string]string{"ethereum.gas_limit": "300000", "ethereum.chain_id": "5"},
+			rest:   []string{"save", "k", "v"},
+		},
+		{
+			name:   "single dash",
+			args:   []string{"-gas-limit=1", "deploy"},
+			values: map[string]string{"ethereum.gas_limit": "1"},
+			rest:   []string{"deploy"},
+		},
+		{
+			name:   "bool alone",
+			args:   []string{"--strict-keys", "deploy"},
+			values: map[string]string{"ethereum.strict_keys": "true"},
+			rest:   []string{"deploy"},
+		},
+		{
+			name:   "bool last",
+			args:   []string{"deploy", "--strict-keys"},
+			values: map[string]string{"ethereum.strict_keys": "true"},
+			rest:   []string{"deploy"},
+		},
+		{
+			name:   "bool with a value",
+			args:   []string{"--strict-keys=false", "deploy"},
+			values: map[string]string{"ethereum.strict_keys": "false"},
+			rest:   []string{"deploy"},
+		},
+		{
+			name:   "command flags left",
+			args:   []string{"save", "--field", "pdf", "--rpc-url", "http://node:8545", "k", "v"},
+			values: map[string]string{"ethereum.rpc_url": "http://node:8545"},
+			rest:   []string{"save", "--field", "pdf", "k", "v"},
+		},
+		{
+			name:   "passthrough",
+			args:   []string{"save", "--chain-id", "5", "--", "--gas-limit", "1", "k"},
+			values: map[string]string{"ethereum.chain_id": "5"},
+			rest:   []string{"save", "--", "--gas-limit", "1", "k"},
+		},
+		{
+			name: "missing value",
+			args: []string{"deploy", "--gas-limit"},
+			err:  "--gas-limit requires a value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, rest, err := takeSettingFlags(tt.args)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for path, v := range values {
+				got[path] = v.value
+			}
+			if !reflect.DeepEqual(got, tt.values) || !slices.Equal(rest, tt.rest) {
+				t.Errorf("got %v and %q, want %v and %q", got, rest, tt.values, tt.rest)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	config := &Config{}
+	config.Ethereum.GasLimit = 100000
+	config.Ethereum.ChainID = 1
+	config.Ethereum.StrictKeys = true
+	config.Server.AllowedIPs = []string{"192.168.0.0/16"}
+	config.Contracts = map[string]string{"old": "0x0000000000000000000000000000000000000001"}
+	config.Storage.ChunkSize = 8192
+
+	t.Setenv("CSE_ETHEREUM_GAS_LIMIT", "200000")
+	t.Setenv("CSE_ETHEREUM_CHAIN_ID", "5")
+	t.Setenv("CSE_SERVER_ALLOWED_IPS", `["10.0.0.0/8", "127.0.0.1"]`)
+	t.Setenv("CSE_CONTRACTS", "{main: \"0x00000000000000000000000000000000000000aa\"}")
+	flags, _, err := takeSettingFlags([]string{"--gas-limit", "300000", "--strict-keys=false", "--webhook-urls", "[http://a, http://b]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyOverrides(config, flags); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags win over the environment, which wins over the file
+	if config.Ethereum.GasLimit != 300000 || config.Ethereum.ChainID != 5 || config.Storage.ChunkSize != 8192 {
+		t.Errorf("gas limit %d, chain ID %d, chunk size %d", config.Ethereum.GasLimit, config.Ethereum.ChainID, config.Storage.ChunkSize)
+	}
+	if config.Ethereum.StrictKeys {
+		t.Error("--strict-keys=false ignored")
+	}
+	// Lists and maps are replaced, not merged
+	if !slices.Equal(config.Server.AllowedIPs, []string{"10.0.0.0/8", "127.0.0.1"}) || !slices.Equal(config.Webhook.URLs, []string{"http://a", "http://b"}) {
+		t.Errorf("allowed IPs %q, webhook URLs %q", config.Server.AllowedIPs, config.Webhook.URLs)
+	}
+	if !reflect.DeepEqual(config.Contracts, map[string]string{"main": "0x00000000000000000000000000000000000000aa"}) {
+		t.Errorf("contracts %v", config.Contracts)
+	}
+	want := map[string]string{
+		"ethereum.gas_limit":   "--gas-limit",
+		"ethereum.chain_id":    "CSE_ETHEREUM_CHAIN_ID",
+		"ethereum.strict_keys": "--strict-keys",
+		"server.allowed_ips":   "CSE_SERVER_ALLOWED_IPS",
+		"contracts":            "CSE_CONTRACTS",
+		"webhook.urls":         "--webhook-urls",
+	}
+	if !reflect.DeepEqual(config.overrides, want) {
+		t.Errorf("overrides %v, want %v", config.overrides, want)
+	}
+
+	t.Setenv("CSE_ETHEREUM_GAS_LIMIT", "lots")
+	if err := applyOverrides(config, nil); err == nil || !strings.HasPrefix(err.Error(), "CSE_ETHEREUM_GAS_LIMIT: ") {
+		t.Errorf("invalid value: %v", err)
+	}
+	flags, _, _ = takeSettingFlags([]string{"--chain-id", "[5]"})
+	t.Setenv("CSE_ETHEREUM_GAS_LIMIT", "1")
+	if err := applyOverrides(config, flags); err == nil || !strings.HasPrefix(err.Error(), "--chain-id: ") {
+		t.Errorf("invalid flag value: %v", err)
+	}
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	config := "ethereum:\n  rpc_url: http://127.0.0.1:1\n  strict_keys: true\n"
+	if err := os.WriteFile(file, []byte(config+"  private_key: "+testKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(file, nil); err == nil || !strings.Contains(err.Error(), "ethereum.private_key must be read") {
+		t.Errorf("plain key in the file: got %v", err)
+	}
+
+	// A key from the environment is not written in the file
+	if err := os.WriteFile(file, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CSE_ETHEREUM_PRIVATE_KEY", testKey)
+	loaded, err := loadConfig(file, nil)
+	if err != nil {
+		t.Fatalf("strict keys refuse a key from the environment: %v", err)
+	}
+	if loaded.Ethereum.PrivateKey != testKey {
+		t.Errorf("private key %q, want the one of CSE_ETHEREUM_PRIVATE_KEY", loaded.Ethereum.PrivateKey)
+	}
+
+	// Problems of overridden settings name the variable that set them
+	t.Setenv("CSE_ETHEREUM_RPC_URL", "ftp://node.example.com")
+	out, status := runMain(t, dir, "get", "--key", "a")
+	if status != exitConfig || !strings.Contains(out, "(set by CSE_ETHEREUM_RPC_URL)") {
+		t.Errorf("exit status %d, output:\n%s", status, out)
+	}
+}
+
+func TestConfigFileAndSettingFlags(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "staging.yaml"), []byte("ethereum:\n  rpc_url: ftp://staging.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// No config.yaml: the file comes from --config, or CSE_CONFIG
+	out, status := runMain(t, dir, "--config", "staging.yaml", "get", "--key", "a")
+	if status != exitConfig || !strings.Contains(out, "ethereum.rpc_url") || strings.Contains(out, "(set by") {
+		t.Errorf("--config: exit status %d, output:\n%s", status, out)
+	}
+	t.Setenv(configEnv, "staging.yaml")
+	out, status = runMain(t, dir, "--rpc-url", "wss://", "get", "--key", "a")
+	if status != exitConfig || !strings.Contains(out, "(set by --rpc-url)") {
+		t.Errorf("%s and --rpc-url: exit status %d, output:\n%s", configEnv, status, out)
+	}
+	t.Setenv("CSE_ETHEREUM_RPC_URL", "wss://")
+	out, status = runMain(t, dir, "--ethereum-rpc-url", "ws://", "get", "--key", "a")
+	if status != exitConfig || !strings.Contains(out, "(set by --ethereum-rpc-url)") {
+		t.Errorf("flag over the environment: exit status %d, output:\n%s", status, out)
+	}
+
+	out, status = runMain(t, dir, "--config", "missing.yaml", "get", "--key", "a")
+	if status == 0 || !strings.Contains(out, "missing.yaml") {
+		t.Errorf("missing --config file: exit status %d, output:\n%s", status, out)
+	}
+}
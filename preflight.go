@@ -0,0 +1,85 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/fees"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// balanceBuffer returns the configured margin over estimated costs
+func balanceBuffer(config *Config) (fees.Buffer, error) {
+	buffer := fees.Buffer{Percent: config.BalanceCheck.BufferPercent}
+	if config.BalanceCheck.MinBalance != "" {
+		minimum, err := fees.ParseEther(config.BalanceCheck.MinBalance)
+		if err != nil {
+			return buffer, fmt.Errorf("balance_check.min_balance: %w", err)
+		}
+		buffer.Minimum = minimum
+	}
+	return buffer, nil
+}
+
+// checkFunds aborts before sending anything when account cannot pay cost
+// plus the configured buffer, rather than running dry halfway through
+func checkFunds(ctx context.Context, config *Config, client chain.ChainClient, account common.Address, cost *big.Int, what string) error {
+	if config.BalanceCheck.Disable {
+		return nil
+	}
+	buffer, err := balanceBuffer(config)
+	if err != nil {
+		return err
+	}
+
+	balance, err := fees.CheckBalance(ctx, client, account, cost, buffer)
+	var short *fees.InsufficientFundsError
+	if errors.As(err, &short) {
+		return fail(codeInsufficientFunds, "Not enough funds for %s: %w. Top up the account or lower balance_check.buffer_percent and balance_check.min_balance", what, err)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to check balance: %w", err)
+	}
+	fmt.Printf("Balance check: %s ETH covers %s ETH estimated for %s\n", fees.FormatEther(balance), fees.FormatEther(cost), what)
+	return nil
+}
+
+// estimateWrites returns the maximum cost of saving every write in turn,
+// including their L1 data fees on rollups
+func estimateWrites(ctx context.Context, config *Config, client *chain.Client, from, address common.Address, parsedABI abi.ABI, writes []queuedWrite) (*big.Int, error) {
+	e, err := newEstimator(config, client, "")
+	if err != nil {
+		return nil, err
+	}
+	// Fiat prices are not needed, nor worth failing the check for
+	e.From, e.Price = from, nil
+
+	cost := new(big.Int)
+	for _, w := range writes {
+		est, err := e.EstimateCall(ctx, address, parsedABI, "save", w.Key, w.Field, w.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s#%s: %w", w.Key, w.Field, err)
+		}
+		cost.Add(cost, est.MaxCost)
+	}
+	return cost, nil
+}
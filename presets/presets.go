@@ -0,0 +1,96 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package presets describes well-known chains, so that a network can be
+// configured by name with only an RPC URL and a key.
+package presets
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Preset holds the settings of a well-known chain.
+type Preset struct {
+	Name    string
+	ChainID uint64
+	// Explorer is the base URL of the block explorer, without a trailing
+	// slash.
+	Explorer string
+	// Testnet chains hold no real value.
+	Testnet bool
+	// MinPriorityFee is the lowest tip in wei that gets transactions
+	// included on the chain, nil when the node's suggestion is fine.
+	MinPriorityFee *big.Int
+	// Rollup is the rollup stack of L2 chains, which decides how their L1
+	// data fee is priced.
+	Rollup string
+	// ENSRegistry is the address of the ENS registry, empty on chains
+	// without ENS.
+	ENSRegistry string
+}
+
+// Rollup stacks with an L1 data fee.
+const (
+	RollupOPStack  = "op-stack"
+	RollupArbitrum = "arbitrum"
+)
+
+// ensRegistry is the address of the ENS registry on mainnet and its testnets
+const ensRegistry = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+
+var presets = []Preset{
+	{Name: "mainnet", ChainID: 1, Explorer: "https://etherscan.io", ENSRegistry: ensRegistry},
+	{Name: "sepolia", ChainID: 11155111, Explorer: "https://sepolia.etherscan.io", Testnet: true, ENSRegistry: ensRegistry},
+	// Polygon PoS nodes refuse tips below a network-wide minimum
+	{Name: "polygon", ChainID: 137, Explorer: "https://polygonscan.com", MinPriorityFee: big.NewInt(30 * params.GWei)},
+	{Name: "bsc", ChainID: 56, Explorer: "https://bscscan.com", MinPriorityFee: big.NewInt(params.GWei / 10)},
+	// Arbitrum ignores tips, the sequencer orders transactions first come
+	// first served
+	{Name: "arbitrum", ChainID: 42161, Explorer: "https://arbiscan.io", Rollup: RollupArbitrum},
+	// OP-stack nodes may suggest no tip at all while blocks are not full
+	{Name: "optimism", ChainID: 10, Explorer: "https://optimistic.etherscan.io", MinPriorityFee: big.NewInt(params.GWei / 1000), Rollup: RollupOPStack},
+	{Name: "base", ChainID: 8453, Explorer: "https://basescan.org", MinPriorityFee: big.NewInt(params.GWei / 1000), Rollup: RollupOPStack},
+}
+
+// Lookup returns the preset of the given name, ignoring case.
+func Lookup(name string) (Preset, bool) {
+	for _, p := range presets {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// ByChainID returns the preset of a chain.
+func ByChainID(id uint64) (Preset, bool) {
+	for _, p := range presets {
+		if p.ChainID == id {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Names returns the names of all presets.
+func Names() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
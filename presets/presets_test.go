@@ -0,0 +1,45 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package presets_test
+
+import (
+	"strings"
+	"testing"
+
+	"contract-storage-eth/presets"
+)
+
+func TestPresetsAreUnique(t *testing.T) {
+	chains := map[uint64]string{}
+	for _, name := range presets.Names() {
+		p, ok := presets.Lookup(strings.ToUpper(name))
+		if !ok || p.Name != name {
+			t.Fatalf("%s: looked up %+v", name, p)
+		}
+		if other, dup := chains[p.ChainID]; dup {
+			t.Errorf("%s and %s are both chain %d", name, other, p.ChainID)
+		}
+		chains[p.ChainID] = name
+		if byID, _ := presets.ByChainID(p.ChainID); byID.Name != name {
+			t.Errorf("chain %d is %s, want %s", p.ChainID, byID.Name, name)
+		}
+		if p.Explorer == "" || strings.HasSuffix(p.Explorer, "/") {
+			t.Errorf("%s: explorer %q", name, p.Explorer)
+		}
+	}
+	if _, ok := presets.Lookup("ropsten"); ok {
+		t.Error("found a preset of a retired testnet")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"contract-storage-eth/confirm"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/progress"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/term"
+)
+
+// noProgress turns the progress bars off, with --no-progress for CI logs
+var noProgress bool
+
+// daemonCommands run until interrupted, waiting for many transactions at
+// once or drawing the whole terminal, so they show no progress bars
+var daemonCommands = []string{"serve", "s3-gateway", "spool", "publish", "mirror", "dashboard"}
+
+// setupProgress shows progress bars unless --no-progress is given or the
+// command is a daemon
+func setupProgress(disabled bool, command string) {
+	noProgress = disabled || slices.Contains(daemonCommands, command)
+}
+
+// startProgress shows the progress of a long operation on stderr, redrawn
+// in place on a terminal. It returns nil, which renders nothing, when
+// progress is off
+func startProgress(label string, total int64, opts progress.Options) *progress.Bar {
+	if noProgress {
+		return nil
+	}
+	opts.Terminal = term.IsTerminal(int(os.Stderr.Fd()))
+	return progress.Start(os.Stderr, label, total, opts)
+}
+
+// indexProgress returns the OnProgress of an indexer, showing a bar while
+// a sync scans more than a batch of blocks, as backfills do
+func indexProgress() func(scanned, total uint64) {
+	if noProgress {
+		return nil
+	}
+	var bar *progress.Bar
+	return func(scanned, total uint64) {
+		if bar == nil && scanned == total {
+			return
+		}
+		if bar == nil {
+			bar = startProgress("Scanning blocks", int64(total), progress.Options{Unit: "blocks", Rate: true})
+		}
+		bar.Set(int64(scanned))
+		if scanned == total {
+			bar.Finish()
+			bar = nil
+		}
+	}
+}
+
+// confirmationProgress shows the stages and confirmations of a transaction
+// as a bar, returning nil when progress is off
+func confirmationProgress(tx *types.Transaction, confirmations uint64) (*progress.Bar, func(confirm.Progress)) {
+	bar := startProgress(fmt.Sprintf("Transaction %s", tx.Hash().Hex()), int64(max(confirmations, 1)), progress.Options{Unit: "confirmations"})
+	if bar == nil {
+		return nil, nil
+	}
+	return bar, func(p confirm.Progress) {
+		bar.Set(int64(p.Confirmations))
+		if p.BlockNumber > 0 {
+			bar.SetNote(fmt.Sprintf("%s in block %d", p.Stage, p.BlockNumber))
+		} else {
+			bar.SetNote(p.Stage.String())
+		}
+	}
+}
+
+// feeNote describes the fee of a transaction for a progress bar
+func feeNote(tx *types.Transaction) string {
+	return fmt.Sprintf("max fee %.2f gwei", fees.Gwei(tx.GasFeeCap()))
+}
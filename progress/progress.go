@@ -0,0 +1,218 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress renders the progress of long operations, such as bulk
+// imports and backfills, as a line redrawn in place on a terminal or
+// printed at intervals in logs.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default intervals between renders.
+const (
+	terminalInterval = 200 * time.Millisecond
+	logInterval      = 10 * time.Second
+)
+
+// Options configures a Bar.
+type Options struct {
+	// Unit names what is counted, such as "records" or "blocks".
+	Unit string
+	// Rate shows the units done per second.
+	Rate bool
+	// Terminal redraws the line in place. Otherwise a line is printed
+	// every Interval while the progress changes.
+	Terminal bool
+	// Interval is the delay between renders, 200ms on a terminal and 10s
+	// otherwise by default.
+	Interval time.Duration
+}
+
+// Bar reports the progress of an operation counting up to a total. Its
+// methods are safe for concurrent use, and a nil *Bar renders nothing, so
+// that callers need not check whether progress is shown.
+type Bar struct {
+	w     io.Writer
+	label string
+	opts  Options
+	start time.Time
+
+	mu       sync.Mutex
+	total    int64
+	done     int64
+	note     string
+	last     string
+	finished bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// Start returns a bar rendering the progress towards total to w until
+// Finish is called. A total of zero is unknown.
+func Start(w io.Writer, label string, total int64, opts Options) *Bar {
+	b := &Bar{w: w, label: label, opts: opts, total: total, start: time.Now(), stop: make(chan struct{}), stopped: make(chan struct{})}
+	if b.opts.Interval <= 0 {
+		b.opts.Interval = logInterval
+		if b.opts.Terminal {
+			b.opts.Interval = terminalInterval
+		}
+	}
+	go b.run()
+	return b
+}
+
+func (b *Bar) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			b.render(false)
+			b.mu.Unlock()
+		}
+	}
+}
+
+// Add counts n more units done.
+func (b *Bar) Add(n int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done += n
+}
+
+// Set sets the units done.
+func (b *Bar) Set(done int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = done
+}
+
+// SetTotal changes the total, zero when it is unknown.
+func (b *Bar) SetTotal(total int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total = total
+}
+
+// SetNote sets the text shown after the counts, such as the current fee
+// or stage.
+func (b *Bar) SetNote(note string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.note = note
+}
+
+// Finish stops rendering and prints the final line. Later calls do
+// nothing.
+func (b *Bar) Finish() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.finished {
+		b.mu.Unlock()
+		return
+	}
+	b.finished = true
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.stopped
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.render(true)
+}
+
+// render writes the line when it changed, ending it on the last render.
+// The caller holds the lock.
+func (b *Bar) render(final bool) {
+	line := b.line()
+	switch {
+	case b.opts.Terminal && final:
+		fmt.Fprintf(b.w, "\r%s\x1b[K\n", line)
+	case b.opts.Terminal:
+		fmt.Fprintf(b.w, "\r%s\x1b[K", line)
+	case line != b.last || final:
+		fmt.Fprintln(b.w, line)
+	}
+	b.last = line
+}
+
+// String returns the current progress line.
+func (b *Bar) String() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.line()
+}
+
+func (b *Bar) line() string {
+	elapsed := time.Since(b.start)
+	count := fmt.Sprint(b.done)
+	if b.total > 0 {
+		count = fmt.Sprintf("%d/%d", b.done, b.total)
+	}
+	if b.opts.Unit != "" {
+		count += " " + b.opts.Unit
+	}
+	parts := []string{count}
+	if b.total > 0 {
+		parts[0] += fmt.Sprintf(" (%d%%)", min(100*b.done/b.total, 100))
+	}
+	if b.opts.Rate && elapsed >= time.Second {
+		parts = append(parts, fmt.Sprintf("%.1f/s", float64(b.done)/elapsed.Seconds()))
+	}
+	parts = append(parts, formatDuration(elapsed))
+	if b.total > 0 && b.done > 0 && b.done < b.total {
+		eta := time.Duration(float64(elapsed) * float64(b.total-b.done) / float64(b.done))
+		parts = append(parts, "ETA "+formatDuration(eta))
+	}
+	if b.note != "" {
+		parts = append(parts, b.note)
+	}
+	return b.label + ": " + strings.Join(parts, ", ")
+}
+
+// formatDuration rounds d to the second, or to the tenth of a second
+// below a minute.
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
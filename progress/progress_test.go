@@ -0,0 +1,67 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/progress"
+)
+
+func TestFinish(t *testing.T) {
+	var out bytes.Buffer
+	bar := progress.Start(&out, "Importing", 4, progress.Options{Unit: "records", Interval: time.Hour})
+	bar.Add(1)
+	bar.Add(2)
+	bar.SetNote("fee 1.50 gwei")
+	bar.Finish()
+	bar.Finish()
+
+	want := regexp.MustCompile(`^Importing: 3/4 records \(75%\), [0-9.]+m?s, ETA [0-9.]+m?s, fee 1.50 gwei\n$`)
+	if !want.MatchString(out.String()) {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	var out bytes.Buffer
+	bar := progress.Start(&out, "Scanning", 0, progress.Options{Unit: "blocks", Terminal: true, Interval: time.Millisecond})
+	bar.Set(2000)
+	time.Sleep(20 * time.Millisecond)
+	bar.Finish()
+
+	lines := strings.Split(out.String(), "\r")
+	if len(lines) < 3 || !strings.HasSuffix(out.String(), "\x1b[K\n") {
+		t.Fatalf("got %q, want redrawn lines", out.String())
+	}
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "Scanning: 2000 blocks, ") || strings.Contains(last, "ETA") {
+		t.Errorf("got final line %q", last)
+	}
+}
+
+func TestNil(t *testing.T) {
+	var bar *progress.Bar
+	bar.Add(1)
+	bar.SetTotal(2)
+	bar.SetNote("note")
+	bar.Finish()
+	if bar.String() != "" {
+		t.Errorf("nil bar rendered %q", bar.String())
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"contract-storage-eth/importer"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func runPrune(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	batch := flags.Int("batch", 100, "records deleted before waiting for them")
+	concurrency := flags.Int("concurrency", 8, "transactions of each nonce lane awaiting confirmation at once")
+	limit := flags.Int("limit", 0, "delete at most this many records, the first to expire (default all)")
+	dryRun := flags.Bool("dry-run", false, "only list the expired records")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	allNamespaces := flags.Bool("all-namespaces", false, "prune the records of every namespace, not only the selected one")
+	addSignerFlags(flags, config)
+	addPoolFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *batch <= 0 || *concurrency <= 0 {
+		return fail(codeInvalidArgument, "Invalid flags: --batch and --concurrency must be positive")
+	}
+	if *limit < 0 {
+		return fail(codeInvalidArgument, "Invalid --limit: it may not be negative")
+	}
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return err
+	} else if viaSafe && !*dryRun {
+		return errors.New("prune sends its transactions directly, unset safe.address to use it")
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+
+	expired, err := store.Expired(time.Now())
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+	expired = scopeRecords(expired, *allNamespaces)
+	if *limit > 0 && len(expired) > *limit {
+		expired = expired[:*limit]
+	}
+	result := pruneResult{DryRun: *dryRun, Records: []prunedRecord{}}
+	for _, r := range expired {
+		result.Records = append(result.Records, prunedRecord{ID: recordID(ns, r).String(), Key: r.Key, Field: r.Field, ExpiresAt: *r.ExpiresAt, Status: pruneExpired})
+	}
+	if len(expired) == 0 {
+		fmt.Println("No expired records")
+		printResult(result)
+		return nil
+	}
+	fmt.Printf("%d expired record(s):\n", len(expired))
+	for _, r := range result.Records {
+		fmt.Printf("  %s  expired %s\n", r.ID, r.ExpiresAt.Format(time.RFC3339))
+	}
+	if *dryRun {
+		printResult(result)
+		return nil
+	}
+
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	// The contract has no delete: an empty value reads as unset
+	im, closeSigners, err := newImportRun(ctx, config, client, address, *concurrency)
+	if err != nil {
+		return err
+	}
+	defer closeSigners()
+	im.raw = true
+	im.onResult = func(index int, tx common.Hash, err error) {
+		entry := &result.Records[index]
+		if tx != (common.Hash{}) {
+			entry.TxHash = &tx
+		}
+		entry.Status = pruneDeleted
+		if err != nil {
+			entry.Status, entry.Error = pruneFailed, err.Error()
+		}
+	}
+	items := make([]importItem, len(expired))
+	for i, r := range expired {
+		items[i] = importItem{index: i, record: &importer.Record{Key: r.Key, Field: r.Field}}
+	}
+	var sendErr error
+	for start := 0; start < len(items) && sendErr == nil; start += *batch {
+		sendErr = im.sendBatch(ctx, items[start:min(start+*batch, len(items))], 0)
+	}
+
+	failed := len(im.failures)
+	fmt.Printf("Pruned %d of %d expired record(s), %d failed\n", im.imported, len(expired), failed)
+	if sendErr != nil || failed > 0 {
+		message := fmt.Sprintf("%d record(s) not pruned", len(expired)-im.imported)
+		if sendErr != nil {
+			message += ": " + sendErr.Error()
+		}
+		printFailure("prune_incomplete", message, result)
+		return exitError(1)
+	}
+	printResult(result)
+	return nil
+}
+
+// Statuses of the records of prune
+const (
+	pruneExpired = "expired"
+	pruneDeleted = "deleted"
+	pruneFailed  = "failed"
+)
+
+// pruneResult is the result of prune in JSON output mode
+type pruneResult struct {
+	DryRun  bool           `json:"dry_run,omitempty"`
+	Records []prunedRecord `json:"records"`
+}
+
+type prunedRecord struct {
+	ID        string       `json:"id"`
+	Key       string       `json:"key"`
+	Field     string       `json:"field"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Status    string       `json:"status"`
+	TxHash    *common.Hash `json:"tx_hash,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// recordExpiry returns the expiry of a record written now from --ttl or
+// --expires-at, zero when neither is set
+func recordExpiry(ttl time.Duration, at string) (time.Time, error) {
+	switch {
+	case ttl != 0 && at != "":
+		return time.Time{}, errors.New("use either --ttl or --expires-at")
+	case ttl < 0:
+		return time.Time{}, errors.New("--ttl must be positive")
+	case ttl > 0:
+		return time.Now().Add(ttl).Truncate(time.Second), nil
+	}
+	t, err := parseDay(at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--expires-at: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/publish"
+)
+
+// Brokers of publish.broker
+const (
+	brokerKafka = "kafka"
+	brokerNATS  = "nats"
+)
+
+func runPublish(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	once := flags.Bool("once", false, "sync and publish once, then exit")
+	interval := flags.Duration("interval", config.Index.SyncInterval, "delay between syncs")
+	batch := flags.Int("batch", config.Publish.Batch, "events published before checkpointing")
+	fromStart := flags.Bool("from-start", false, "without a checkpoint, publish the events indexed already too")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	publisher, err := newPublisher(config)
+	if err != nil {
+		return fmt.Errorf("Failed to connect to the broker: %w", err)
+	}
+	defer publisher.Close()
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return fail(codeIndex, "Failed to sync index: %w", err)
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return err
+	}
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := loadPublishCursor(config, store, *fromStart)
+	if err != nil {
+		return fmt.Errorf("Failed to load publish checkpoint: %w", err)
+	}
+	relay := &publish.Relay{
+		Source:     store,
+		Publisher:  publisher,
+		Namespace:  ns,
+		Batch:      *batch,
+		Checkpoint: func(c publish.Cursor) error { return savePublishCursor(config, c) },
+	}
+
+	if *interval <= 0 {
+		*interval = 15 * time.Second
+	}
+	if !*once {
+		fmt.Printf("Publishing new events to %s, press Ctrl+C to stop\n", config.Publish.Broker)
+	}
+	result := &publishResult{Broker: config.Publish.Broker}
+	for done := false; !done; {
+		if _, err := ix.Sync(ctx); err != nil {
+			if *once {
+				return fail(codeIndex, "Failed to sync index: %w", err)
+			}
+			if ctx.Err() == nil {
+				slog.Warn("Failed to sync index", "error", err)
+			}
+		}
+
+		// Events that failed are published again from the checkpoint on
+		// the next pass
+		var n int
+		cursor, n, err = relay.Run(ctx, cursor)
+		result.Events += n
+		switch {
+		case err != nil && *once:
+			return fmt.Errorf("Failed to publish events: %w", err)
+		case err != nil && ctx.Err() == nil:
+			slog.Warn("Failed to publish events", "error", err)
+		case n > 0:
+			slog.Info("Published events", "events", n, "block", cursor.Block)
+		}
+
+		if *once {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-time.After(*interval):
+		}
+	}
+
+	result.Cursor = cursor
+	fmt.Printf("Published %d event(s), resuming at block %d\n", result.Events, cursor.Block)
+	printResult(result)
+	return nil
+}
+
+// publishResult is the JSON result of publish
+type publishResult struct {
+	Broker string         `json:"broker"`
+	Events int            `json:"events"`
+	Cursor publish.Cursor `json:"cursor"`
+}
+
+// newPublisher connects to the broker of publish.broker
+func newPublisher(config *Config) (publish.Publisher, error) {
+	switch config.Publish.Broker {
+	case brokerKafka:
+		password, err := resolveSecret(config.Publish.Kafka.Password)
+		if err != nil {
+			return nil, fmt.Errorf("publish.kafka.password: %w", err)
+		}
+		return publish.NewKafka(publish.KafkaOptions{
+			Brokers:  config.Publish.Kafka.Brokers,
+			Topic:    config.Publish.Kafka.Topic,
+			Username: config.Publish.Kafka.Username,
+			Password: password,
+			TLS:      config.Publish.Kafka.TLS,
+		})
+	case brokerNATS:
+		token, err := resolveSecret(config.Publish.NATS.Token)
+		if err != nil {
+			return nil, fmt.Errorf("publish.nats.token: %w", err)
+		}
+		return publish.NewNATS(publish.NATSOptions{
+			URL:         config.Publish.NATS.URL,
+			Subject:     config.Publish.NATS.Subject,
+			Credentials: config.Publish.NATS.Credentials,
+			Token:       token,
+		})
+	case "":
+		return nil, fail(codeConfig, "publish.broker is not configured")
+	}
+	return nil, fmt.Errorf("publish.broker must be kafka or nats, not %q", config.Publish.Broker)
+}
+
+// publishCursorPath returns the configured checkpoint file
+func publishCursorPath(config *Config) string {
+	if config.Publish.CursorFile != "" {
+		return config.Publish.CursorFile
+	}
+	return "publish_cursor.json"
+}
+
+// loadPublishCursor reads the checkpoint. Without one, publishing starts
+// after the events already indexed, or at the first one with fromStart
+func loadPublishCursor(config *Config, store *indexer.Store, fromStart bool) (publish.Cursor, error) {
+	var cursor publish.Cursor
+	data, err := os.ReadFile(publishCursorPath(config))
+	if errors.Is(err, os.ErrNotExist) {
+		if fromStart {
+			return cursor, nil
+		}
+		cursor.Block, err = store.NextBlock(0)
+		return cursor, err
+	}
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%s: %w", publishCursorPath(config), err)
+	}
+	return cursor, nil
+}
+
+func savePublishCursor(config *Config, cursor publish.Cursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(publishCursorPath(config), data)
+}
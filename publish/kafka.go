@@ -0,0 +1,87 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// KafkaOptions configures a Kafka publisher.
+type KafkaOptions struct {
+	Brokers []string
+	Topic   string
+	// Username and Password authenticate with SASL/PLAIN when set.
+	Username string
+	Password string
+	// TLS connects to the brokers over TLS.
+	TLS bool
+}
+
+// Kafka publishes to a Kafka topic, keying messages by record key and
+// waiting for every in-sync replica to acknowledge them.
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+// NewKafka returns a publisher to opts.Topic.
+func NewKafka(opts KafkaOptions) (*Kafka, error) {
+	if len(opts.Brokers) == 0 || opts.Topic == "" {
+		return nil, errors.New("publish: kafka needs brokers and a topic")
+	}
+	transport := &kafka.Transport{}
+	if opts.Username != "" {
+		transport.SASL = plain.Mechanism{Username: opts.Username, Password: opts.Password}
+	}
+	if opts.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return &Kafka{writer: &kafka.Writer{
+		Addr:         kafka.TCP(opts.Brokers...),
+		Topic:        opts.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		Transport:    transport,
+	}}, nil
+}
+
+// Publish implements Publisher.
+func (k *Kafka) Publish(ctx context.Context, msgs []Message) error {
+	out := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = kafka.Message{
+			Key:   []byte(m.Key),
+			Value: m.Body,
+			Headers: []kafka.Header{
+				{Key: "type", Value: []byte(m.Type)},
+				{Key: "id", Value: []byte(m.ID)},
+			},
+		}
+	}
+	if err := k.writer.WriteMessages(ctx, out...); err != nil {
+		return fmt.Errorf("publish: kafka: %w", err)
+	}
+	return nil
+}
+
+// Close implements Publisher.
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
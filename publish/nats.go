@@ -0,0 +1,90 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSOptions configures a NATS publisher.
+type NATSOptions struct {
+	URL     string
+	Subject string
+	// Credentials is a credentials file, Token an authentication token,
+	// when the server requires them.
+	Credentials string
+	Token       string
+}
+
+// NATS publishes to a subject of a JetStream stream, which acknowledges
+// each message once stored and drops the ones it already holds by their
+// record ID.
+type NATS struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+// NewNATS connects to opts.URL. A stream must capture opts.Subject.
+func NewNATS(opts NATSOptions) (*NATS, error) {
+	if opts.Subject == "" {
+		return nil, errors.New("publish: nats needs a subject")
+	}
+	var options []nats.Option
+	if opts.Credentials != "" {
+		options = append(options, nats.UserCredentials(opts.Credentials))
+	}
+	if opts.Token != "" {
+		options = append(options, nats.Token(opts.Token))
+	}
+	url := opts.URL
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	conn, err := nats.Connect(url, options...)
+	if err != nil {
+		return nil, fmt.Errorf("publish: nats: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("publish: nats: %w", err)
+	}
+	return &NATS{conn: conn, js: js, subject: opts.Subject}, nil
+}
+
+// Publish implements Publisher.
+func (n *NATS) Publish(ctx context.Context, msgs []Message) error {
+	for _, m := range msgs {
+		msg := nats.NewMsg(n.subject)
+		msg.Data = m.Body
+		msg.Header.Set(nats.MsgIdHdr, m.ID)
+		msg.Header.Set("Cse-Type", m.Type)
+		if _, err := n.js.PublishMsg(msg, nats.Context(ctx)); err != nil {
+			return fmt.Errorf("publish: nats: %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
+
+// Close implements Publisher.
+func (n *NATS) Close() error {
+	n.conn.Close()
+	return nil
+}
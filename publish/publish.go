@@ -0,0 +1,135 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package publish streams indexed DataSaved events to a message broker,
+// a Kafka topic or a NATS JetStream subject, for downstream pipelines.
+//
+// Delivery is at least once: events are published in chain order, a batch
+// at a time, and the position after a batch is checkpointed only once the
+// broker acknowledged all of it. A publisher restarted from its checkpoint
+// may publish the last events again, so consumers should deduplicate them
+// by their record ID, which JetStream does by itself.
+package publish
+
+import (
+	"context"
+	"encoding/json"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+)
+
+// Types of the published events. Writes of an empty value are deletes.
+const (
+	EventSaved   = "data.saved"
+	EventDeleted = "data.deleted"
+)
+
+// Message is an event as published.
+type Message struct {
+	// Key is the record key, which keeps the events of a key in order on
+	// partitioned topics.
+	Key string
+	// ID is the record ID of the event.
+	ID   string
+	Type string
+	// Body is the event as JSON.
+	Body []byte
+}
+
+// event is the body of a message: the indexed record with its type and ID
+type event struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	*indexer.Record
+}
+
+// NewMessage returns the message of an indexed record.
+func NewMessage(ns recordid.Namespace, r *indexer.Record) (Message, error) {
+	e := event{Type: EventSaved, ID: ns.ID(r.Key, r.Field, r.Version).String(), Record: r}
+	if r.Value == "" {
+		e.Type = EventDeleted
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Key: r.Key, ID: e.ID, Type: e.Type, Body: body}, nil
+}
+
+// Publisher sends messages to a broker.
+type Publisher interface {
+	// Publish returns once the broker acknowledged every message.
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// Cursor is the position after the last published event.
+type Cursor struct {
+	Block uint64 `json:"block"`
+	Log   uint   `json:"log"`
+}
+
+// Source is where the events are read from, such as an *indexer.Store.
+type Source interface {
+	Query(q indexer.Query) ([]*indexer.Record, error)
+}
+
+// Relay publishes the events of a source.
+type Relay struct {
+	Source    Source
+	Publisher Publisher
+	Namespace recordid.Namespace
+	// Batch is the number of events published at once, 100 when zero.
+	Batch int
+	// Checkpoint saves the cursor once a batch is acknowledged.
+	Checkpoint func(Cursor) error
+}
+
+// Run publishes every event after cursor, returning the new cursor and the
+// number of events published. It stops at the first batch that fails,
+// returning the cursor after the last acknowledged one.
+func (r *Relay) Run(ctx context.Context, cursor Cursor) (Cursor, int, error) {
+	batch := r.Batch
+	if batch <= 0 {
+		batch = 100
+	}
+	total := 0
+	for {
+		records, err := r.Source.Query(indexer.Query{FromBlock: cursor.Block, FromLog: cursor.Log, Limit: batch})
+		if err != nil || len(records) == 0 {
+			return cursor, total, err
+		}
+		msgs := make([]Message, len(records))
+		for i, rec := range records {
+			if msgs[i], err = NewMessage(r.Namespace, rec); err != nil {
+				return cursor, total, err
+			}
+		}
+		if err := r.Publisher.Publish(ctx, msgs); err != nil {
+			return cursor, total, err
+		}
+		last := records[len(records)-1]
+		cursor = Cursor{Block: last.BlockNumber, Log: last.LogIndex + 1}
+		total += len(records)
+		if r.Checkpoint != nil {
+			if err := r.Checkpoint(cursor); err != nil {
+				return cursor, total, err
+			}
+		}
+		if len(records) < batch {
+			return cursor, total, nil
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/publish"
+	"contract-storage-eth/recordid"
+)
+
+// source serves records from memory, in chain order
+type source []*indexer.Record
+
+func (s source) Query(q indexer.Query) ([]*indexer.Record, error) {
+	var out []*indexer.Record
+	for _, r := range s {
+		if r.BlockNumber < q.FromBlock || r.BlockNumber == q.FromBlock && r.LogIndex < q.FromLog {
+			continue
+		}
+		if out = append(out, r); len(out) == q.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// broker records what it acknowledged, failing the publishes after the
+// first ok ones
+type broker struct {
+	ok        int
+	published []publish.Message
+}
+
+func (b *broker) Publish(ctx context.Context, msgs []publish.Message) error {
+	if b.ok == 0 {
+		return errors.New("broker unavailable")
+	}
+	b.ok--
+	b.published = append(b.published, msgs...)
+	return nil
+}
+
+func (b *broker) Close() error { return nil }
+
+func TestRelay(t *testing.T) {
+	records := source{
+		{Key: "a", Field: "f", Value: "1", Version: 1, BlockNumber: 1},
+		{Key: "b", Field: "f", Value: "1", Version: 1, BlockNumber: 1, LogIndex: 1},
+		{Key: "a", Field: "f", Value: "", Version: 2, BlockNumber: 2},
+	}
+	b := &broker{ok: 1}
+	var checkpoints []publish.Cursor
+	relay := &publish.Relay{
+		Source:     records,
+		Publisher:  b,
+		Namespace:  recordid.Namespace{ChainID: 1337},
+		Batch:      2,
+		Checkpoint: func(c publish.Cursor) error { checkpoints = append(checkpoints, c); return nil },
+	}
+
+	// The second batch fails, so the cursor stays after the first one
+	cursor, n, err := relay.Run(context.Background(), publish.Cursor{})
+	if err == nil || n != 2 || cursor != (publish.Cursor{Block: 1, Log: 2}) {
+		t.Fatalf("published %d, cursor %+v, %v", n, cursor, err)
+	}
+	b.ok = 1
+	if cursor, n, err = relay.Run(context.Background(), cursor); err != nil || n != 1 || cursor != (publish.Cursor{Block: 2, Log: 1}) {
+		t.Fatalf("resumed with %d, cursor %+v, %v", n, cursor, err)
+	}
+	if len(checkpoints) != 2 || checkpoints[1] != cursor {
+		t.Errorf("checkpoints %+v", checkpoints)
+	}
+
+	if len(b.published) != 3 {
+		t.Fatalf("published %d messages, want 3", len(b.published))
+	}
+	last := b.published[2]
+	var body struct {
+		Type, ID, Key string
+		Version       uint64
+	}
+	if err := json.Unmarshal(last.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if last.Type != publish.EventDeleted || body.Type != publish.EventDeleted || body.Key != "a" || body.Version != 2 || body.ID != last.ID || last.Key != "a" {
+		t.Errorf("published %+v with body %+v", last, body)
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const defaultProbeInterval = 30 * time.Second
+
+const queueUsage = `Usage: contract-storage-eth queue <command> [flags]
+
+Commands:
+  status    List the writes waiting for the contract to accept writes
+  drain     Send the queued writes, waiting while the contract rejects them
+`
+
+// queuedWrite is a save the contract rejected, kept until it accepts
+// writes again
+type queuedWrite struct {
+	Key      string    `json:"key"`
+	Field    string    `json:"field"`
+	Value    string    `json:"value"`
+	Reason   string    `json:"reason,omitempty"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// queuePath returns the configured write queue file
+func queuePath(config *Config) string {
+	if config.WriteQueue.File != "" {
+		return config.WriteQueue.File
+	}
+	return "write_queue.json"
+}
+
+// loadQueue reads the queued writes, oldest first
+func loadQueue(config *Config) ([]queuedWrite, error) {
+	data, err := os.ReadFile(queuePath(config))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var queue []queuedWrite
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("%s: %w", queuePath(config), err)
+	}
+	return queue, nil
+}
+
+// saveQueue replaces the queue file, removing it once the queue is empty
+func saveQueue(config *Config, queue []queuedWrite) error {
+	if len(queue) == 0 {
+		err := os.Remove(queuePath(config))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(queuePath(config), data)
+}
+
+// enqueueWrite appends a write to the queue and returns the queue length
+func enqueueWrite(config *Config, w queuedWrite) (int, error) {
+	queue, err := loadQueue(config)
+	if err != nil {
+		return 0, err
+	}
+	w.QueuedAt = time.Now().UTC()
+	queue = append(queue, w)
+	return len(queue), saveQueue(config, queue)
+}
+
+// dequeueWrite removes w from the head of the queue. Writes queued in the
+// meantime by other processes are kept.
+func dequeueWrite(config *Config, w queuedWrite) error {
+	queue, err := loadQueue(config)
+	if err != nil {
+		return err
+	}
+	if len(queue) > 0 && queue[0] == w {
+		queue = queue[1:]
+	}
+	return saveQueue(config, queue)
+}
+
+// requeueWrite puts w back at the head of the queue
+func requeueWrite(config *Config, w queuedWrite) error {
+	queue, err := loadQueue(config)
+	if err != nil {
+		return err
+	}
+	return saveQueue(config, append([]queuedWrite{w}, queue...))
+}
+
+func runQueue(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, queueUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "status":
+		queue, err := loadQueue(config)
+		if err != nil {
+			return fmt.Errorf("Failed to load write queue: %w", err)
+		}
+		if output.json {
+			printResult(queueResult{Queued: append([]queuedWrite{}, queue...)})
+			return nil
+		}
+		if len(queue) == 0 {
+			fmt.Println("No queued writes")
+			return nil
+		}
+		fmt.Printf("%d queued write(s) in %s:\n", len(queue), queuePath(config))
+		for _, w := range queue {
+			fmt.Printf("  %s#%s  queued %s  %s\n", w.Key, w.Field, w.QueuedAt.Format("2006-01-02 15:04:05"), w.Reason)
+		}
+	case "drain":
+		return drainQueue(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown queue command: %s\n\n%s", args[0], queueUsage)
+		return exitError(2)
+	}
+	return nil
+}
+
+// drainQueue sends the queued writes in order, waiting for the contract to
+// accept writes whenever it rejects the next one
+func drainQueue(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("queue drain", flag.ContinueOnError)
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	queue, err := loadQueue(config)
+	if err != nil {
+		return fmt.Errorf("Failed to load write queue: %w", err)
+	}
+	if len(queue) == 0 {
+		fmt.Println("No queued writes")
+		printResult(drainResult{})
+		return nil
+	}
+
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	activeSigner, err := signers.Active(ctx)
+	if err != nil {
+		return fail(codeSigner, "No signer available: %w", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	auth, err := signer.NewTransactOpts(ctx, activeSigner, chainID)
+	if err != nil {
+		return fail(codeSigner, "Failed to create auth: %w", err)
+	}
+
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return fmt.Errorf("Failed to parse ABI: %w", err)
+	}
+	contract := bind.NewBoundContract(address, parsedABI, client, client, client)
+
+	interval := config.WriteQueue.ProbeInterval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+
+	fmt.Printf("Draining %d queued write(s) to %s\n", len(queue), address.Hex())
+	rejecting, checked := false, false
+	var result drainResult
+	for {
+		// Reload every round, saves keep queueing behind the drained writes
+		if queue, err = loadQueue(config); err != nil {
+			return fmt.Errorf("Failed to load write queue: %w", err)
+		}
+		if len(queue) == 0 {
+			break
+		}
+		w := queue[0]
+
+		// Probe with a gas estimate of the next write, which reverts for as
+		// long as the contract refuses it
+		data, err := parsedABI.Pack("save", w.Key, w.Field, w.Value)
+		if err != nil {
+			return fmt.Errorf("Failed to encode queued write: %w", err)
+		}
+		_, err = client.EstimateGas(ctx, ethereum.CallMsg{From: activeSigner.Address(), To: &address, Data: data})
+		if err == nil {
+			if rejecting {
+				fmt.Println("Contract accepts writes again, resuming")
+				rejecting = false
+			}
+			// Writes can only be estimated once the contract accepts them
+			if !checked {
+				cost, err := estimateWrites(ctx, config, client, activeSigner.Address(), address, parsedABI, queue)
+				if err != nil {
+					return fmt.Errorf("Failed to estimate queued writes: %w", err)
+				}
+				if err := checkFunds(ctx, config, client, activeSigner.Address(), cost, fmt.Sprintf("%d queued write(s)", len(queue))); err != nil {
+					return err
+				}
+				checked = true
+			}
+			err = sendQueued(ctx, client, contract, auth, config, activeSigner.Address(), address, w)
+		}
+
+		switch {
+		case errors.As(err, new(unsettledError)):
+			return err
+		case err == nil:
+			fmt.Printf("Saved %s#%s, %d write(s) left\n", w.Key, w.Field, len(queue)-1)
+			result.Drained++
+			continue
+		case errors.Is(err, context.Canceled):
+			fmt.Printf("Interrupted, queued writes are kept in %s\n", queuePath(config))
+			result.Interrupted, result.Left = true, len(queue)
+			printResult(result)
+			return nil
+		case chain.IsReverted(err):
+			if !rejecting {
+				fmt.Printf("Contract is not accepting writes (%v), probing every %s\n", err, interval)
+				rejecting = true
+			}
+		default:
+			slog.Warn("Probe failed, retrying", "delay", interval, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Printf("Interrupted, queued writes are kept in %s\n", queuePath(config))
+			result.Interrupted, result.Left = true, len(queue)
+			printResult(result)
+			return nil
+		case <-time.After(interval):
+		}
+	}
+	fmt.Println("Write queue drained")
+	printResult(result)
+	return nil
+}
+
+// queueResult is the result of queue status in JSON output mode
+type queueResult struct {
+	Queued []queuedWrite `json:"queued"`
+}
+
+// drainResult is the result of queue drain in JSON output mode
+type drainResult struct {
+	Drained     int  `json:"drained"`
+	Left        int  `json:"left"`
+	Interrupted bool `json:"interrupted"`
+}
+
+// unsettledError is the failure to wait for a queued write that was sent.
+// It ends the drain, as sending more would leave the write untracked
+type unsettledError struct{ error }
+
+func (e unsettledError) Unwrap() error { return e.error }
+
+// sendQueued sends the write at the head of the queue and waits until it
+// is settled. Once broadcast the write leaves the queue and is tracked as
+// the pending transaction, so that `resume` picks it up after an
+// interruption. A write reverting on-chain goes back to the head of the
+// queue.
+func sendQueued(ctx context.Context, client *chain.Client, contract storage.ContractBinder, auth *bind.TransactOpts, config *Config, from, address common.Address, w queuedWrite) error {
+	tx, err := sendSave(ctx, client, contract, auth, config, w.Key, w.Field, w.Value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Save transaction: %s\n", tx.Hash().Hex())
+	trackPending(config, "save", from, address, tx)
+	if err := dequeueWrite(config, w); err != nil {
+		return err
+	}
+
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err == nil {
+		receipt, err = watchReorg(ctx, client, tx, receipt, config)
+	}
+	if errors.Is(err, context.Canceled) {
+		reportInterrupted(config, tx, err)
+		return err
+	}
+	if err != nil {
+		return unsettledError{fail(codeTransactionFailed, "Failed to wait for save transaction %s, run `resume` to continue: %w", tx.Hash().Hex(), err)}
+	}
+	clearPending(config)
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		if err := requeueWrite(config, w); err != nil {
+			return err
+		}
+		return fmt.Errorf("execution reverted in transaction %s", tx.Hash().Hex())
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteQueue(t *testing.T) {
+	var config Config
+	config.WriteQueue.File = filepath.Join(t.TempDir(), "queue.json")
+	first := queuedWrite{Key: "a", Field: "f", Value: "1"}
+	second := queuedWrite{Key: "b", Field: "f", Value: "2"}
+
+	for i, w := range []queuedWrite{first, second} {
+		n, err := enqueueWrite(&config, w)
+		if err != nil || n != i+1 {
+			t.Fatalf("enqueueWrite = %d, %v; want %d", n, err, i+1)
+		}
+	}
+	queue, err := loadQueue(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 2 || queue[0].Key != "a" || queue[1].Key != "b" || queue[0].QueuedAt.IsZero() {
+		t.Fatalf("queue %+v, want a then b with their queue time", queue)
+	}
+
+	// Only the head is removed, and only if it is the write that was sent
+	if err := dequeueWrite(&config, queue[1]); err != nil {
+		t.Fatal(err)
+	}
+	if queue, _ = loadQueue(&config); len(queue) != 2 {
+		t.Errorf("dequeuing a write that is not the head changed the queue to %+v", queue)
+	}
+	head := queue[0]
+	if err := dequeueWrite(&config, head); err != nil {
+		t.Fatal(err)
+	}
+	if err := requeueWrite(&config, head); err != nil {
+		t.Fatal(err)
+	}
+	if queue, _ = loadQueue(&config); len(queue) != 2 || queue[0] != head {
+		t.Errorf("requeued write is not the head of %+v", queue)
+	}
+
+	for _, w := range queue {
+		if err := dequeueWrite(&config, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(config.WriteQueue.File); !os.IsNotExist(err) {
+		t.Errorf("empty queue left its file: %v", err)
+	}
+}
+
+func TestSaveQueuesBehindEarlierWrites(t *testing.T) {
+	dir := t.TempDir()
+	config := "ethereum:\n  rpc_url: http://127.0.0.1:1\n  chain_id: 1337\n  private_key: " + testKey + "\n" +
+		"contract:\n  address: \"0x3A220f351252089D385b29beca14e27F204c296A\"\n" +
+		"write_queue:\n  enabled: true\n  file: queue.json\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	queued := `[{"key": "first", "field": "f", "value": "1", "reason": "execution reverted: paused", "queued_at": "2026-01-02T03:04:05Z"}]`
+	if err := os.WriteFile(filepath.Join(dir, "queue.json"), []byte(queued), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The unreachable node shows the write was never sent
+	out, status := runMain(t, dir, "save", "--key", "second", "--field", "f", "--value", "2")
+	if status != 0 || !strings.Contains(out, "Queued second#f in queue.json (2 write(s) waiting)") {
+		t.Fatalf("save: exit status %d, output:\n%s", status, out)
+	}
+
+	out, status = runMain(t, dir, "queue", "status")
+	if status != 0 {
+		t.Fatalf("queue status: exit status %d, output:\n%s", status, out)
+	}
+	first, second := strings.Index(out, "first#f"), strings.Index(out, "second#f")
+	if !strings.Contains(out, "2 queued write(s)") || first < 0 || second < first || !strings.Contains(out, "queued behind earlier writes") {
+		t.Errorf("queue status lists the writes out of order:\n%s", out)
+	}
+}
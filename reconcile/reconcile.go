@@ -0,0 +1,110 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reconcile compares the records of the contract with a source of
+// truth holding what they should be, such as the database of the
+// application writing them.
+package reconcile
+
+import (
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/importer"
+	"contract-storage-eth/indexer"
+)
+
+// Status is the outcome of comparing one key/field pair.
+type Status string
+
+// Statuses of Entry.
+const (
+	// Match is a pair holding the same value on both sides.
+	Match Status = "MATCH"
+	// Missing is a pair of the source the contract does not hold.
+	Missing Status = "MISSING"
+	// Extra is a pair the contract holds but the source does not.
+	Extra Status = "EXTRA"
+	// Mismatch is a pair holding different values on both sides.
+	Mismatch Status = "MISMATCH"
+)
+
+// Entry is the outcome of comparing one key/field pair. Want is the record
+// of the source and Have the latest record of the contract, each nil when
+// that side does not hold the pair.
+type Entry struct {
+	Key    string
+	Field  string
+	Status Status
+	Want   *importer.Record
+	Have   *indexer.Record
+}
+
+// Diff compares the records of a source with the current state of the
+// contract, the latest records of each key/field pair as export.Latest
+// returns them. Source records with an empty value read as unset, and a
+// pair listed twice in the source takes its last value.
+//
+// A value matches when it is the stored value itself, or the content of the
+// envelope stored, decrypted with one of keys if needed, so that the source
+// can hold either. Entries follow the order of the source, then the extra
+// records in chain order.
+func Diff(source []*importer.Record, state []*indexer.Record, keys ...*envelope.Key) []Entry {
+	have := map[indexer.Ref]*indexer.Record{}
+	for _, r := range state {
+		have[indexer.Ref{Key: r.Key, Field: r.Field}] = r
+	}
+	want := map[indexer.Ref]*importer.Record{}
+	var order []indexer.Ref
+	for _, rec := range source {
+		ref := indexer.Ref{Key: rec.Key, Field: rec.Field}
+		if _, ok := want[ref]; !ok {
+			order = append(order, ref)
+		}
+		want[ref] = rec
+	}
+
+	var entries []Entry
+	for _, ref := range order {
+		rec := want[ref]
+		if rec.Value == "" {
+			continue
+		}
+		entry := Entry{Key: ref.Key, Field: ref.Field, Want: rec, Have: have[ref]}
+		switch {
+		case entry.Have == nil:
+			entry.Status = Missing
+		case Equal(rec.Value, entry.Have.Value, keys...):
+			entry.Status = Match
+		default:
+			entry.Status = Mismatch
+		}
+		entries = append(entries, entry)
+	}
+	for _, r := range state {
+		ref := indexer.Ref{Key: r.Key, Field: r.Field}
+		if rec, ok := want[ref]; !ok || rec.Value == "" {
+			entries = append(entries, Entry{Key: r.Key, Field: r.Field, Status: Extra, Have: r})
+		}
+	}
+	return entries
+}
+
+// Equal reports whether a value of the source matches a stored value,
+// either as is or as the content of its envelope.
+func Equal(want, stored string, keys ...*envelope.Key) bool {
+	if want == stored {
+		return true
+	}
+	content, err := envelope.Decode(stored, keys...)
+	return err == nil && string(content) == want
+}
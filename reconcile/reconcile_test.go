@@ -0,0 +1,57 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/importer"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/reconcile"
+)
+
+func TestDiff(t *testing.T) {
+	sealed, err := envelope.Seal([]byte("sealed"), envelope.Options{Codec: envelope.CodecText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := []*importer.Record{
+		{Key: "a", Value: "1"},
+		{Key: "b", Field: "pdf", Value: "old"},
+		{Key: "c", Value: "sealed"},
+		{Key: "missing", Value: "x"},
+		{Key: "b", Field: "pdf", Value: "2"},
+		{Key: "deleted", Value: ""},
+	}
+	state := []*indexer.Record{
+		{Key: "a", Value: "1"},
+		{Key: "extra", Value: "y"},
+		{Key: "b", Field: "pdf", Value: "3"},
+		{Key: "c", Value: sealed},
+		{Key: "deleted", Value: "z"},
+	}
+
+	var got []string
+	for _, e := range reconcile.Diff(source, state) {
+		got = append(got, fmt.Sprintf("%s:%s#%s", e.Status, e.Key, e.Field))
+	}
+	want := "MATCH:a# MISMATCH:b#pdf MATCH:c# MISSING:missing# EXTRA:extra# EXTRA:deleted#"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
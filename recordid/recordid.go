@@ -0,0 +1,158 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recordid implements canonical record identifiers of the form
+// ethstore://<chainid>/<contract>/<key>[?version=N][#<field>], which name a
+// record unambiguously across chains and deployments.
+package recordid
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Scheme is the URI scheme of record IDs.
+const Scheme = "ethstore"
+
+const prefix = Scheme + "://"
+
+// Namespace is the deployment records are stored in.
+type Namespace struct {
+	ChainID  uint64
+	Contract common.Address
+}
+
+// ID returns the ID of a record of the namespace. Version 0 means the
+// latest version.
+func (ns Namespace) ID(key, field string, version uint64) ID {
+	return ID{Namespace: ns, Key: key, Field: field, Version: version}
+}
+
+// String formats the namespace as ethstore://<chainid>/<contract>.
+func (ns Namespace) String() string {
+	return fmt.Sprintf("%s%d/%s", prefix, ns.ChainID, ns.Contract.Hex())
+}
+
+// ID identifies a record, or its latest version when Version is 0.
+type ID struct {
+	Namespace
+	Key     string
+	Field   string
+	Version uint64
+}
+
+// String formats the ID. Key and field are percent-encoded, so keys holding
+// slashes and fields holding '#' or '?' round-trip through Parse.
+func (id ID) String() string {
+	s := id.Namespace.String() + "/" + url.PathEscape(id.Key)
+	if id.Version > 0 {
+		s += "?version=" + strconv.FormatUint(id.Version, 10)
+	}
+	if id.Field != "" {
+		s += "#" + url.PathEscape(id.Field)
+	}
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler, so that IDs are strings in
+// JSON.
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *ID) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// IsID reports whether s uses the record ID scheme, telling IDs apart from
+// plain keys.
+func IsID(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// Parse parses a record ID.
+func Parse(s string) (ID, error) {
+	var id ID
+	rest, ok := strings.CutPrefix(s, prefix)
+	if !ok {
+		return id, fmt.Errorf("record ID %q does not start with %s", s, prefix)
+	}
+
+	rest, fragment, hasField := strings.Cut(rest, "#")
+	if hasField {
+		field, err := url.PathUnescape(fragment)
+		if err != nil {
+			return id, fmt.Errorf("invalid field in record ID %q: %w", s, err)
+		}
+		id.Field = field
+	}
+	rest, query, hasQuery := strings.Cut(rest, "?")
+	if hasQuery {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return id, fmt.Errorf("invalid query in record ID %q: %w", s, err)
+		}
+		if v := values.Get("version"); v != "" {
+			version, err := strconv.ParseUint(v, 10, 64)
+			if err != nil || version == 0 {
+				return id, fmt.Errorf("invalid version in record ID %q", s)
+			}
+			id.Version = version
+		}
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 3 || parts[2] == "" {
+		return id, fmt.Errorf("record ID %q is not %s<chainid>/<contract>/<key>", s, prefix)
+	}
+	chainID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || chainID == 0 {
+		return id, fmt.Errorf("invalid chain ID in record ID %q", s)
+	}
+	if !common.IsHexAddress(parts[1]) {
+		return id, fmt.Errorf("invalid contract address in record ID %q", s)
+	}
+	key, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return id, fmt.Errorf("invalid key in record ID %q: %w", s, err)
+	}
+	id.Namespace = Namespace{ChainID: chainID, Contract: common.HexToAddress(parts[1])}
+	id.Key = key
+	return id, nil
+}
+
+// ErrOtherNamespace is returned by Check for IDs of another deployment.
+var ErrOtherNamespace = errors.New("record ID belongs to another deployment")
+
+// Check returns an error wrapping ErrOtherNamespace unless id is in ns.
+func (ns Namespace) Check(id ID) error {
+	if id.ChainID != ns.ChainID {
+		return fmt.Errorf("%w: %s is on chain %d, not %d", ErrOtherNamespace, id, id.ChainID, ns.ChainID)
+	}
+	if id.Contract != ns.Contract {
+		return fmt.Errorf("%w: %s is stored in contract %s, not %s", ErrOtherNamespace, id, id.Contract.Hex(), ns.Contract.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recordid_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"contract-storage-eth/recordid"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ns = recordid.Namespace{ChainID: 11155111, Contract: common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		id   recordid.ID
+		want string
+	}{
+		{ns.ID("invoice-42", "pdf", 0), "ethstore://11155111/0x5FbDB2315678afecb367f032d93F642f64180aa3/invoice-42#pdf"},
+		{ns.ID("acme/invoice-42", "pdf", 2), "ethstore://11155111/0x5FbDB2315678afecb367f032d93F642f64180aa3/acme%2Finvoice-42?version=2#pdf"},
+		{ns.ID("k", "", 0), "ethstore://11155111/0x5FbDB2315678afecb367f032d93F642f64180aa3/k"},
+		{ns.ID("a?b#c", "x#y?z@1 é", 0), "ethstore://11155111/0x5FbDB2315678afecb367f032d93F642f64180aa3/a%3Fb%23c#x%23y%3Fz@1%20%C3%A9"},
+	} {
+		if got := tc.id.String(); got != tc.want {
+			t.Errorf("got %s\nwant %s", got, tc.want)
+		}
+		parsed, err := recordid.Parse(tc.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != tc.id {
+			t.Errorf("%s parsed as %+v", tc.want, parsed)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		"invoice-42#pdf",
+		"ethstore://",
+		"ethstore://1/0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		"ethstore://1/0x5FbDB2315678afecb367f032d93F642f64180aa3/",
+		"ethstore://0/0x5FbDB2315678afecb367f032d93F642f64180aa3/k",
+		"ethstore://1/contract/k",
+		"ethstore://1/0x5FbDB2315678afecb367f032d93F642f64180aa3/k?version=0",
+		"ethstore://1/0x5FbDB2315678afecb367f032d93F642f64180aa3/%zz",
+	} {
+		if _, err := recordid.Parse(s); err == nil {
+			t.Errorf("%q parsed", s)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := ns.Check(ns.ID("k", "f", 0)); err != nil {
+		t.Error(err)
+	}
+	other := recordid.Namespace{ChainID: 1, Contract: ns.Contract}
+	if err := ns.Check(other.ID("k", "f", 0)); !errors.Is(err, recordid.ErrOtherNamespace) {
+		t.Errorf("other chain: %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]recordid.ID{"id": ns.ID("k", "f", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]recordid.ID
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["id"] != ns.ID("k", "f", 1) {
+		t.Errorf("%s decoded as %+v", data, decoded)
+	}
+}
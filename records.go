@@ -0,0 +1,226 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"sync"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/cache"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tombstone"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// recordService performs the contract operations of the /records
+// endpoints. signers is nil when writes are disabled
+type recordService struct {
+	config  *Config
+	client  *chain.Client
+	address common.Address
+	ns      recordid.Namespace
+	signers signer.Selector
+	// cache holds the values read, nil without a cache
+	cache *cache.Redis
+
+	// mu serializes writes so that concurrent requests don't race for the
+	// same nonce
+	mu sync.Mutex
+}
+
+// Save implements api.Records
+func (s *recordService) Save(ctx context.Context, req api.SaveRequest) (*api.WriteResult, error) {
+	key, writes, err := s.seal(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// Chunks go first, each mined before the manifest is written
+	for i, w := range writes[:len(writes)-1] {
+		if _, err := s.write(ctx, key, w.Field, w.Value); err != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(writes)-1, err)
+		}
+	}
+	return s.write(ctx, key, req.Field, writes[len(writes)-1].Value)
+}
+
+// seal returns the namespaced key of req and the writes storing its value:
+// its chunks if any, then the value or its manifest
+func (s *recordService) seal(ctx context.Context, req api.SaveRequest) (string, []storedWrite, error) {
+	meta := indexer.TagMeta(req.Tags)
+	if req.Supersedes != "" {
+		ref, err := indexer.ParseRef(req.Supersedes, req.Field)
+		if recordid.IsID(req.Supersedes) {
+			ref, err = resolveRef(s.ns, req.Supersedes, req.Field)
+		} else {
+			ref.Key = keyNamespace.Key(ref.Key)
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: supersedes: %v", api.ErrInvalidRecord, err)
+		}
+		meta[indexer.MetaSupersedes] = ref.String()
+	}
+	if req.ExpiresAt != nil {
+		maps.Copy(meta, indexer.ExpiryMeta(*req.ExpiresAt))
+	}
+	value, codec := []byte(req.Value), envelope.CodecBytes
+	if req.Encoding != "" {
+		var err error
+		if value, err = decodeBinary(req.Value, req.Encoding); err != nil {
+			return "", nil, fmt.Errorf("%w: value: %v", api.ErrInvalidRecord, err)
+		}
+	}
+	if c, err := checkSchema(s.config, req.Key, req.Field, value); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err)
+	} else if req.Encoding == "" {
+		codec = c
+	}
+	sealed, err := sealValueAs(s.config, value, meta, codec)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err)
+	}
+	if target := offloadTarget(s.config, sealed); req.ExpiresAt != nil && target != "" {
+		return "", nil, fmt.Errorf("%w: values stored off chain cannot expire, the value is longer than %s.store_above", api.ErrInvalidRecord, target)
+	}
+	if sealed, err = offloadValue(ctx, s.config, sealed); err != nil {
+		return "", nil, err
+	}
+	writes := splitValue(s.config, req.Field, sealed)
+	if req.ExpiresAt != nil && len(writes) > 1 {
+		return "", nil, fmt.Errorf("%w: chunked values cannot expire, the value is longer than storage.chunk_size", api.ErrInvalidRecord)
+	}
+	return keyNamespace.Key(req.Key), writes, nil
+}
+
+// Get implements api.Records
+func (s *recordService) Get(ctx context.Context, key, field string) (*api.Record, error) {
+	ref, err := resolveRef(s.ns, key, field)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err)
+	}
+	if ref.Version > 0 {
+		return nil, fmt.Errorf("%w: only the latest value can be read from the contract", api.ErrInvalidRecord)
+	}
+
+	var value string
+	if s.cache != nil {
+		value, err = s.cachedValue(ctx, ref.Key, ref.Field)
+	} else {
+		value, err = readRecordValue(ctx, s.client, s.config, s.address, ref.Key, ref.Field, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, fmt.Errorf("%w for %s#%s", api.ErrNotFound, ref.Key, ref.Field)
+	}
+	content, encoding := recordContent(value)
+	return &api.Record{
+		ID:       s.ns.ID(ref.Key, ref.Field, 0).String(),
+		Key:      ref.Key,
+		Field:    ref.Field,
+		Value:    value,
+		Content:  content,
+		Encoding: encoding,
+	}, nil
+}
+
+// Delete implements api.Records. Values are soft-deleted when
+// storage.soft_delete is set
+func (s *recordService) Delete(ctx context.Context, key, field string) (*api.WriteResult, error) {
+	return s.remove(ctx, key, field, s.config.Storage.SoftDelete)
+}
+
+// remove deletes the value of a key and field. The contract has no delete,
+// so the value is replaced with an empty one, which reads as unset, or with
+// a tombstone, which reads as unset too but keeps the value restorable
+func (s *recordService) remove(ctx context.Context, key, field string, soft bool) (*api.WriteResult, error) {
+	record, err := s.Get(ctx, key, field)
+	if err != nil {
+		return nil, err
+	}
+	value := ""
+	if soft {
+		value = tombstone.New(time.Now())
+	}
+	return s.write(ctx, record.Key, record.Field, value)
+}
+
+// write saves value and waits for the transaction to be mined
+func (s *recordService) write(ctx context.Context, key, field, value string) (*api.WriteResult, error) {
+	if s.signers == nil {
+		return nil, errors.New("writes are disabled")
+	}
+	contract, err := storage.Bind(s.address, s.client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	tx, err := s.send(ctx, contract, key, field, value)
+	s.mu.Unlock()
+	if err != nil {
+		if chain.IsReverted(err) {
+			return nil, fmt.Errorf("%w: %v", api.ErrRejected, err)
+		}
+		return nil, err
+	}
+
+	receipt, err := waitMined(ctx, s.client, tx, s.config)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w: transaction %s reverted", api.ErrRejected, tx.Hash().Hex())
+	}
+	// Reads after the write get the new value without waiting for the
+	// index to see it
+	if s.cache != nil {
+		if err := s.cache.Invalidate(ctx, cache.Pair{Key: key, Field: field}); err != nil {
+			slog.Warn("Failed to invalidate cache", "key", key, "field", field, "error", err)
+		}
+	}
+	return &api.WriteResult{
+		ID:     s.ns.ID(key, field, 0).String(),
+		TxHash: tx.Hash(),
+		Block:  receipt.BlockNumber.Uint64(),
+	}, nil
+}
+
+// send signs and sends a save transaction with the active signer
+func (s *recordService) send(ctx context.Context, contract storage.ContractBinder, key, field, value string) (*types.Transaction, error) {
+	active, err := s.signers.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := signer.NewTransactOpts(ctx, active, new(big.Int).SetUint64(s.ns.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	return sendSave(ctx, s.client, contract, auth, s.config, key, field, value)
+}
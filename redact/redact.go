@@ -0,0 +1,199 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact hides known secrets, such as private keys and API tokens,
+// from the text written to logs and command output.
+package redact
+
+import (
+	"cmp"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Mask replaces each secret.
+const Mask = "[REDACTED]"
+
+// minLength is the shortest secret hidden: shorter values, such as a PIN
+// or a test token, would hide common words of the output too.
+const minLength = 6
+
+// Set holds the secrets to hide. The zero value is empty and ready to use,
+// and a Set is safe for concurrent use.
+type Set struct {
+	mu       sync.RWMutex
+	secrets  map[string]bool
+	replacer *strings.Replacer
+}
+
+// Add adds secrets to the set. Hex values are also hidden with and without
+// their 0x prefix, and in either case.
+func (s *Set) Add(secrets ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.secrets == nil {
+		s.secrets = map[string]bool{}
+	}
+	added := false
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < minLength {
+			continue
+		}
+		variants := []string{secret}
+		if hex := strings.TrimPrefix(strings.TrimPrefix(secret, "0x"), "0X"); isHex(hex) {
+			variants = append(variants, strings.ToLower(hex), strings.ToUpper(hex))
+		}
+		// Mnemonics may be printed with their words on other spacing
+		if words := strings.Fields(secret); len(words) > 1 {
+			variants = append(variants, strings.Join(words, " "))
+		}
+		for _, v := range variants {
+			if !s.secrets[v] {
+				s.secrets[v], added = true, true
+			}
+		}
+	}
+	if added {
+		s.replacer = nil
+	}
+}
+
+// AddURL adds the parts of a URL that usually hold credentials: the
+// password, the query parameters named like keys or tokens and the path
+// segments and values that look like API keys, such as the project ID of
+// https://mainnet.infura.io/v3/ID.
+func (s *Set) AddURL(raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return
+	}
+	var secrets []string
+	if password, ok := u.User.Password(); ok {
+		secrets = append(secrets, password)
+	}
+	for name, values := range u.Query() {
+		for _, value := range values {
+			if secretParameter(name) || looksLikeKey(value) {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if looksLikeKey(segment) {
+			secrets = append(secrets, segment)
+		}
+	}
+	s.Add(secrets...)
+}
+
+// String returns s with every secret replaced by Mask.
+func (s *Set) String(text string) string {
+	s.mu.RLock()
+	replacer := s.replacer
+	s.mu.RUnlock()
+	if replacer == nil {
+		s.mu.Lock()
+		if s.replacer == nil {
+			s.replacer = s.build()
+		}
+		replacer = s.replacer
+		s.mu.Unlock()
+	}
+	return replacer.Replace(text)
+}
+
+// build returns the replacer of the secrets, longest first so that a
+// secret containing another is hidden whole.
+func (s *Set) build() *strings.Replacer {
+	secrets := make([]string, 0, len(s.secrets))
+	for secret := range s.secrets {
+		secrets = append(secrets, secret)
+	}
+	// strings.Replacer tries the old strings in argument order at each
+	// position
+	slices.SortFunc(secrets, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, Mask)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// Writer returns a writer hiding the secrets of what it writes to w. Each
+// write is redacted on its own, which suits writers of whole lines such as
+// loggers.
+func (s *Set) Writer(w io.Writer) io.Writer {
+	return writer{set: s, w: w}
+}
+
+type writer struct {
+	set *Set
+	w   io.Writer
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.set.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// secretParameter reports whether a query parameter is named like one
+// holding a credential.
+func secretParameter(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"key", "token", "secret", "password", "auth", "sig"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHex(s string) bool {
+	if len(s) < minLength {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// looksLikeKey reports whether a path segment is a long token mixing
+// letters and digits rather than a word such as "v3" or "rpc".
+func looksLikeKey(segment string) bool {
+	if len(segment) < 16 {
+		return false
+	}
+	letters, digits := false, false
+	for _, r := range segment {
+		switch {
+		case unicode.IsLetter(r):
+			letters = true
+		case unicode.IsDigit(r):
+			digits = true
+		case r != '-' && r != '_':
+			return false
+		}
+	}
+	return letters && digits
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/redact"
+)
+
+func TestString(t *testing.T) {
+	var s redact.Set
+	key := "0xB71C71A67E1177AD4E901695E1B4B9EE17AE16C6668D313EAC2F96DBCDA3F291"
+	s.Add(key, "abandon  ability able about above absent absorb abstract absurd abuse access accident", "1234")
+
+	for _, text := range []string{
+		"private key " + key,
+		"private key " + strings.ToLower(key[2:]),
+		"invalid mnemonic abandon ability able about above absent absorb abstract absurd abuse access accident",
+	} {
+		if got := s.String(text); strings.Contains(got, "b71c") || strings.Contains(strings.ToLower(got), "abandon") || !strings.Contains(got, redact.Mask) {
+			t.Errorf("String(%q) = %q", text, got)
+		}
+	}
+	if got := s.String("pin 1234"); got != "pin 1234" {
+		t.Errorf("hid a short value: %q", got)
+	}
+}
+
+func TestAddURL(t *testing.T) {
+	var s redact.Set
+	s.AddURL("https://mainnet.infura.io/v3/9aa3d95b3bc440fa88ea12eaa4456161")
+	s.AddURL("redis://:hunter22@localhost:6379/0")
+	s.AddURL("https://api.example.com/rpc?apikey=K3yK3yK3y&chain=mainnet")
+
+	got := s.String(`Post "https://mainnet.infura.io/v3/9aa3d95b3bc440fa88ea12eaa4456161": 429; redis://:hunter22@localhost:6379/0; ?apikey=K3yK3yK3y&chain=mainnet`)
+	want := `Post "https://mainnet.infura.io/v3/[REDACTED]": 429; redis://:[REDACTED]@localhost:6379/0; ?apikey=[REDACTED]&chain=mainnet`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if got := s.String("https://mainnet.infura.io/v3/rpc"); got != "https://mainnet.infura.io/v3/rpc" {
+		t.Errorf("hid a path: %s", got)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var s redact.Set
+	var buf bytes.Buffer
+	w := s.Writer(&buf)
+	w.Write([]byte("token s3cr3t-token\n"))
+	s.Add("s3cr3t-token")
+	w.Write([]byte("token s3cr3t-token\n"))
+	if want := "token s3cr3t-token\ntoken [REDACTED]\n"; buf.String() != want {
+		t.Errorf("wrote %q", buf.String())
+	}
+}
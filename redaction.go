@@ -0,0 +1,101 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"contract-storage-eth/redact"
+)
+
+// secrets are hidden from the logs, the fatal errors and the JSON output.
+// They are collected from the configuration when the command starts, and
+// from the sources secrets are read from as they are read
+var secrets redact.Set
+
+// secretSettings are the names of the settings holding a secret or a
+// reference to one
+var secretSettings = map[string]bool{
+	"private_key": true, "phrase": true, "token": true, "secret_id": true,
+	"admin_token": true, "write_token": true, "password": true,
+	"credentials": true, "jwt": true, "secret_key": true,
+}
+
+// registerSecrets adds the secrets of config to secrets: the settings of
+// secretSettings and the variables they refer to with env:NAME, the
+// variables named by passphrase_env and secret_env, the credentials of
+// URLs and the values of headers
+func registerSecrets(config *Config) {
+	secrets.Add(os.Getenv(defaultPassphraseEnv), os.Getenv(defaultSecretEnv))
+	registerSecretsOf(reflect.ValueOf(config).Elem(), "")
+}
+
+func registerSecretsOf(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			registerSecretsOf(v.Elem(), name)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.IsExported() {
+				tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+				registerSecretsOf(v.Field(i), tag)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			registerSecretsOf(v.Index(i), name)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if name == "headers" && v.MapIndex(key).Kind() == reflect.String {
+				secrets.Add(v.MapIndex(key).String())
+				continue
+			}
+			registerSecretsOf(v.MapIndex(key), name)
+		}
+	case reflect.String:
+		registerSecret(name, v.String())
+	}
+}
+
+func registerSecret(name, value string) {
+	if value == "" {
+		return
+	}
+	switch {
+	case secretSettings[name]:
+		if env, ok := strings.CutPrefix(value, secretEnvPrefix); ok {
+			value = os.Getenv(env)
+		}
+		secrets.Add(value)
+	case strings.HasSuffix(name, "passphrase_env") || strings.HasSuffix(name, "secret_env"):
+		secrets.Add(os.Getenv(value))
+	case strings.HasSuffix(name, "url") || strings.HasSuffix(name, "urls"):
+		secrets.AddURL(value)
+	case name == "dsn":
+		secrets.AddURL(value)
+		// user:password@tcp(host)/db of MySQL
+		if at := strings.LastIndex(value, "@"); at > 0 {
+			if _, password, ok := strings.Cut(value[:at], ":"); ok && !strings.Contains(password, "/") {
+				secrets.Add(password)
+			}
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const replicateUsage = `Usage: contract-storage-eth replicate <command> [flags]
+
+Commands:
+  status    List saved records some replica networks still lack
+  retry     Write the lagging records to their replica networks again
+`
+
+// replicaWrite is a saved record that some replica networks still lack
+type replicaWrite struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+	// Source is the ID of the record on the primary network
+	Source  string    `json:"source"`
+	SavedAt time.Time `json:"saved_at"`
+	// Pending maps the lagging networks to their last error
+	Pending map[string]string `json:"pending"`
+}
+
+// replicationPath returns the configured file of lagging replicas
+func replicationPath(config *Config) string {
+	if config.Replication.File != "" {
+		return config.Replication.File
+	}
+	return "replication.json"
+}
+
+// loadReplication reads the lagging replicas, oldest first
+func loadReplication(config *Config) ([]replicaWrite, error) {
+	data, err := os.ReadFile(replicationPath(config))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var writes []replicaWrite
+	if err := json.Unmarshal(data, &writes); err != nil {
+		return nil, fmt.Errorf("%s: %w", replicationPath(config), err)
+	}
+	return writes, nil
+}
+
+// saveReplication replaces the replication file, removing it once every
+// replica caught up
+func saveReplication(config *Config, writes []replicaWrite) error {
+	if len(writes) == 0 {
+		err := os.Remove(replicationPath(config))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(writes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(replicationPath(config), data)
+}
+
+// replicaConfig returns the configuration of a replica network, applied
+// over the top-level settings like --network would
+func replicaConfig(config *Config, network string) (*Config, error) {
+	replica := *config
+	if config.base != nil {
+		replica = *config.base
+	}
+	profile, ok := config.Networks[network]
+	if !ok {
+		return nil, fail(codeConfig, "replication network %q is not configured under networks", network)
+	}
+	// The top-level contract is on another chain
+	if profile.ContractAddress == "" {
+		return nil, fmt.Errorf("replication network %q sets no contract_address", network)
+	}
+	if err := selectNetwork(&replica, network); err != nil {
+		return nil, err
+	}
+	return &replica, nil
+}
+
+// writeReplica saves value on the contract of a replica network and
+// returns the ID of the record there. A replica already holding the value,
+// from an attempt whose outcome was lost, is not written again
+func writeReplica(ctx context.Context, config *Config, key, field, value string) (recordid.ID, error) {
+	address, err := contractAddress(config)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	ns := recordid.Namespace{ChainID: chainID.Uint64(), Contract: address}
+	if stored, err := readValue(ctx, client, config, address, key, field, nil); err == nil && stored == value {
+		return ns.ID(key, field, 0), nil
+	}
+
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	active, err := signers.Active(ctx)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	auth, err := signer.NewTransactOpts(ctx, active, chainID)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	contract, err := storage.Bind(address, client)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+
+	tx, err := sendSave(ctx, client, contract, auth, config, key, field, value)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err != nil {
+		return recordid.ID{}, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return recordid.ID{}, fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+	return ns.ID(key, field, 0), nil
+}
+
+// replicate mirrors a saved record to the replica networks one after the
+// other, keeping the networks that failed for `replicate retry`. It returns
+// the errors of those networks
+func replicate(ctx context.Context, config *Config, w replicaWrite) (map[string]string, error) {
+	w.Pending = map[string]string{}
+	for _, network := range config.Replication.Networks {
+		if network == config.Network {
+			continue
+		}
+		if err := replicateTo(ctx, config, network, w); err != nil {
+			fmt.Printf("Replication to %s failed: %v\n", network, err)
+			w.Pending[network] = err.Error()
+		}
+	}
+	if len(w.Pending) == 0 {
+		return nil, nil
+	}
+
+	writes, err := loadReplication(config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load replication file: %w", err)
+	}
+	w.SavedAt = time.Now().UTC()
+	if err := saveReplication(config, append(writes, w)); err != nil {
+		return nil, fmt.Errorf("Failed to save replication file: %w", err)
+	}
+	fmt.Printf("%d replica(s) lagging, kept in %s\n", len(w.Pending), replicationPath(config))
+	fmt.Println("Run `contract-storage-eth replicate retry` to write them again")
+	return w.Pending, nil
+}
+
+func replicateTo(ctx context.Context, config *Config, network string, w replicaWrite) error {
+	replica, err := replicaConfig(config, network)
+	if err != nil {
+		return err
+	}
+	id, err := writeReplica(ctx, replica, w.Key, w.Field, w.Value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replicated to %s: %s\n", network, id)
+	return nil
+}
+
+func runReplicate(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, replicateUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "status":
+		return runReplicateStatus(config, args[1:])
+	case "retry":
+		return runReplicateRetry(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown replicate command: %s\n\n%s", args[0], replicateUsage)
+		return exitError(2)
+	}
+}
+
+// replicationResult is the result of replicate in JSON output mode
+type replicationResult struct {
+	Lagging []replicaWrite `json:"lagging"`
+}
+
+func runReplicateStatus(config *Config, args []string) error {
+	flags := flag.NewFlagSet("replicate status", flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	writes, err := loadReplication(config)
+	if err != nil {
+		return fmt.Errorf("Failed to load replication file: %w", err)
+	}
+	if output.json {
+		printResult(replicationResult{Lagging: append([]replicaWrite{}, writes...)})
+		return nil
+	}
+	if len(writes) == 0 {
+		fmt.Println("Every replica is up to date")
+		return nil
+	}
+	fmt.Printf("%d record(s) with lagging replicas in %s:\n", len(writes), replicationPath(config))
+	for _, w := range writes {
+		fmt.Printf("  %s  saved %s\n", w.Source, w.SavedAt.Format("2006-01-02 15:04:05"))
+		networks := make([]string, 0, len(w.Pending))
+		for network := range w.Pending {
+			networks = append(networks, network)
+		}
+		sort.Strings(networks)
+		for _, network := range networks {
+			fmt.Printf("    %s: %s\n", network, w.Pending[network])
+		}
+	}
+	return nil
+}
+
+func runReplicateRetry(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("replicate retry", flag.ContinueOnError)
+	interval := flags.Duration("interval", 0, "keep retrying at this interval until every replica caught up")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	for {
+		writes, err := loadReplication(config)
+		if err != nil {
+			return fmt.Errorf("Failed to load replication file: %w", err)
+		}
+		if len(writes) == 0 {
+			fmt.Println("Every replica is up to date")
+			printResult(replicationResult{Lagging: []replicaWrite{}})
+			return nil
+		}
+
+		var lagging []replicaWrite
+		for _, w := range writes {
+			for network := range w.Pending {
+				if ctx.Err() != nil {
+					break
+				}
+				if err := replicateTo(ctx, config, network, w); err != nil {
+					fmt.Printf("Replication of %s to %s failed: %v\n", w.Source, network, err)
+					w.Pending[network] = err.Error()
+					continue
+				}
+				delete(w.Pending, network)
+			}
+			if len(w.Pending) > 0 {
+				lagging = append(lagging, w)
+			}
+		}
+		// Keep the records saved meanwhile
+		current, err := loadReplication(config)
+		if err != nil {
+			return fmt.Errorf("Failed to load replication file: %w", err)
+		}
+		if len(current) > len(writes) {
+			lagging = append(lagging, current[len(writes):]...)
+		}
+		if err := saveReplication(config, lagging); err != nil {
+			return fmt.Errorf("Failed to save replication file: %w", err)
+		}
+		if len(lagging) == 0 {
+			fmt.Println("Every replica is up to date")
+			printResult(replicationResult{Lagging: []replicaWrite{}})
+			return nil
+		}
+		if *interval <= 0 {
+			message := fmt.Sprintf("%d record(s) still lagging", len(lagging))
+			fmt.Println(message)
+			printFailure("replicas_lagging", message, replicationResult{Lagging: lagging})
+			return exitError(1)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Printf("Interrupted, lagging replicas are kept in %s\n", replicationPath(config))
+			printResult(replicationResult{Lagging: lagging})
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
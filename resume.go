@@ -0,0 +1,295 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	"contract-storage-eth/chain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func runResume(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("resume", flag.ContinueOnError)
+	bump := flags.Int("bump", 0, "replace the transaction, raising its fee by this percentage (nodes usually require at least 10)")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	pending, err := loadPending(config)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println("No pending transaction to resume")
+		printResult(resumeResult{})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load pending transaction: %w", err)
+	}
+	txs, err := pending.transactions()
+	if err != nil {
+		return fmt.Errorf("Failed to decode pending transaction: %w", err)
+	}
+	tx := txs[0]
+	fmt.Printf("Resuming %s transaction %s (nonce %d) sent at %s\n", pending.Kind, tx.Hash().Hex(), tx.Nonce(), pending.SentAt.Format("2006-01-02 15:04:05"))
+	if len(txs) > 1 {
+		fmt.Printf("It replaced %d earlier transaction(s) of the nonce, any of which may be mined instead\n", len(txs)-1)
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	landed, err := minedTx(ctx, client, txs)
+	if err != nil {
+		return fmt.Errorf("Failed to check transaction: %w", err)
+	}
+
+	switch {
+	case landed != nil:
+		if *bump > 0 {
+			fmt.Println("Transaction is already mined, not replacing it")
+		}
+	case *bump > 0:
+		replacement, err := replaceTx(ctx, client, config, pending, tx, *bump)
+		if errors.Is(err, chain.ErrNonceConflict) {
+			fmt.Println("Nonce was used while replacing, one of the transactions sent was probably mined")
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to replace transaction: %w", err)
+		}
+		// Any of the transactions may still be mined, so they are all kept
+		if next, err := pending.replacedBy(replacement); err != nil {
+			return fmt.Errorf("Failed to save pending transaction: %w", err)
+		} else if err := savePending(config, next); err != nil {
+			return fmt.Errorf("Failed to save pending transaction: %w", err)
+		}
+		txs = append([]*types.Transaction{replacement}, txs...)
+		tx = replacement
+		fmt.Printf("Replaced with transaction %s\n", tx.Hash().Hex())
+	default:
+		// The node may have forgotten the transaction while we were away
+		_, _, err := client.TransactionByHash(ctx, tx.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			if err := client.SendTransaction(ctx, tx); err != nil {
+				return fmt.Errorf("Failed to rebroadcast transaction: %w", err)
+			}
+			fmt.Println("Transaction was no longer known to the node, rebroadcast it")
+		} else if err != nil {
+			return fmt.Errorf("Failed to check transaction: %w", err)
+		}
+	}
+
+	fmt.Println("Waiting for transaction confirmation...")
+	tx, receipt, err := waitLanded(ctx, client, txs, pending.From, config)
+	if err != nil {
+		reportInterrupted(config, txs[0], err)
+		return fail(codeTransactionFailed, "Failed to wait for transaction: %w", err)
+	}
+	if tx != txs[0] {
+		fmt.Printf("Transaction %s was mined instead of its replacement %s\n", tx.Hash().Hex(), txs[0].Hash().Hex())
+	}
+
+	receipt, err = watchReorg(ctx, client, tx, receipt, config)
+	if err != nil {
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to watch transaction for reorgs: %w", err)
+	}
+	clearPending(config)
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fail(codeTransactionFailed, "Transaction %s failed!", tx.Hash().Hex())
+	}
+	fmt.Printf("Transaction %s succeeded in block %d\n", tx.Hash().Hex(), receipt.BlockNumber.Uint64())
+	result := resumeResult{Kind: pending.Kind, TxHash: tx.Hash().Hex(), Block: receipt.BlockNumber.Uint64(), GasUsed: receipt.GasUsed}
+	if pending.Kind == "deploy" {
+		fmt.Printf("Contract address: %s\n", pending.Contract.Hex())
+		result.Contract = pending.Contract.Hex()
+	}
+	printResult(result)
+	return nil
+}
+
+// resumeResult is the result of resume in JSON output mode, empty when no
+// transaction was pending
+type resumeResult struct {
+	Kind     string `json:"kind,omitempty"`
+	TxHash   string `json:"tx_hash,omitempty"`
+	Block    uint64 `json:"block,omitempty"`
+	GasUsed  uint64 `json:"gas_used,omitempty"`
+	Contract string `json:"contract,omitempty"`
+}
+
+// minedTx returns the transaction of txs that is mined, nil when none is
+func minedTx(ctx context.Context, client chain.ChainClient, txs []*types.Transaction) (*types.Transaction, error) {
+	for _, tx := range txs {
+		_, err := client.TransactionReceipt(ctx, tx.Hash())
+		if err == nil {
+			return tx, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// waitLanded waits for whichever of txs, transactions of the same nonce
+// sent by from, is mined first, and returns it with its receipt once it has
+// the configured confirmations. It fails when the nonce is used by another
+// transaction than those
+func waitLanded(ctx context.Context, client chain.ChainClient, txs []*types.Transaction, from common.Address, config *Config) (*types.Transaction, *types.Receipt, error) {
+	if len(txs) == 1 {
+		receipt, err := waitMined(ctx, client, txs[0], config)
+		return txs[0], receipt, err
+	}
+
+	waitCtx, cancel := withTimeout(ctx, config.Timeouts.Confirmation)
+	defer cancel()
+	interval := config.Confirmation.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		tx, err := landedTx(waitCtx, client, txs, from)
+		if err == nil && tx == nil {
+			select {
+			case <-waitCtx.Done():
+				err = waitCtx.Err()
+			case <-time.After(interval):
+				continue
+			}
+		}
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fail(codeReceiptTimeout, "none mined within timeouts.confirmation (%s): %w", config.Timeouts.Confirmation, err)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		receipt, err := waitMined(ctx, client, tx, config)
+		return tx, receipt, err
+	}
+}
+
+// landedTx returns the transaction of txs that is mined, nil while the
+// nonce of from has not advanced past theirs
+func landedTx(ctx context.Context, client chain.ChainClient, txs []*types.Transaction, from common.Address) (*types.Transaction, error) {
+	if tx, err := minedTx(ctx, client, txs); tx != nil || err != nil {
+		return tx, err
+	}
+	nonce, err := client.NonceAt(ctx, from, nil)
+	if err != nil || nonce <= txs[0].Nonce() {
+		return nil, err
+	}
+	// One of txs may have been mined since they were checked
+	if tx, err := minedTx(ctx, client, txs); tx != nil || err != nil {
+		return tx, err
+	}
+	return nil, fmt.Errorf("nonce %d of %s was used by another transaction than the %d sent", txs[0].Nonce(), from.Hex(), len(txs))
+}
+
+// replaceTx signs and sends a copy of tx with the same nonce and a fee
+// raised by percent
+func replaceTx(ctx context.Context, client chain.ChainClient, config *Config, pending *pendingTx, tx *types.Transaction, percent int) (*types.Transaction, error) {
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	active, err := signers.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if active.Address() != pending.From {
+		return nil, fmt.Errorf("transaction was sent by %s but the active signer is %s", pending.From.Hex(), active.Address().Hex())
+	}
+
+	data, err := bumpFee(tx, percent)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := active.SignTx(ctx, types.NewTx(data), chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.SendTransaction(ctx, replacement); err != nil {
+		return nil, err
+	}
+	return replacement, nil
+}
+
+// bumpFee copies tx with every fee field raised by percent
+func bumpFee(tx *types.Transaction, percent int) (types.TxData, error) {
+	bump := func(v *big.Int) *big.Int {
+		n := new(big.Int).Mul(v, big.NewInt(int64(100+percent)))
+		return n.Div(n, big.NewInt(100))
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: bump(tx.GasPrice()),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}, nil
+	case types.AccessListTxType:
+		return &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   bump(tx.GasPrice()),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	case types.DynamicFeeTxType:
+		return &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  bump(tx.GasTipCap()),
+			GasFeeCap:  bump(tx.GasFeeCap()),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("cannot replace transaction of type %d", tx.Type())
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/mocks"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+)
+
+// The first development account of Hardhat and Anvil
+const (
+	testKey     = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	testAccount = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+)
+
+// nonceTxs returns a transaction of nonce 7 and the replacement of it
+func nonceTxs() (original, replacement *types.Transaction) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	original = types.NewTx(&types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(1e9), Gas: 50000, To: &to})
+	replacement = types.NewTx(&types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(12e8), Gas: 50000, To: &to})
+	return original, replacement
+}
+
+func waitConfig() *Config {
+	config := &Config{}
+	config.Confirmation.PollInterval = time.Millisecond
+	config.Timeouts.Confirmation = time.Second
+	return config
+}
+
+func TestWaitLanded(t *testing.T) {
+	from := common.HexToAddress(testAccount)
+	original, replacement := nonceTxs()
+	txs := []*types.Transaction{replacement, original}
+	ctx := context.Background()
+
+	t.Run("replaced transaction mined", func(t *testing.T) {
+		client := mocks.NewMockChainClient(gomock.NewController(t))
+		mined := &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: original.Hash(), BlockNumber: big.NewInt(5)}
+		client.EXPECT().TransactionReceipt(gomock.Any(), replacement.Hash()).Return(nil, ethereum.NotFound).AnyTimes()
+		gomock.InOrder(
+			client.EXPECT().TransactionReceipt(gomock.Any(), original.Hash()).Return(nil, ethereum.NotFound),
+			client.EXPECT().TransactionReceipt(gomock.Any(), original.Hash()).Return(mined, nil).MinTimes(1),
+		)
+		client.EXPECT().NonceAt(gomock.Any(), from, nil).Return(uint64(7), nil)
+		client.EXPECT().HeaderByNumber(gomock.Any(), nil).Return(&types.Header{Number: big.NewInt(5)}, nil)
+
+		tx, receipt, err := waitLanded(ctx, client, txs, from, waitConfig())
+		if err != nil || tx != original || receipt != mined {
+			t.Errorf("got %v, %v, %v, want the original transaction", tx, receipt, err)
+		}
+	})
+
+	t.Run("nonce used elsewhere", func(t *testing.T) {
+		client := mocks.NewMockChainClient(gomock.NewController(t))
+		client.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, ethereum.NotFound).AnyTimes()
+		client.EXPECT().NonceAt(gomock.Any(), from, nil).Return(uint64(8), nil)
+
+		_, _, err := waitLanded(ctx, client, txs, from, waitConfig())
+		if err == nil || !strings.Contains(err.Error(), "nonce 7 of "+testAccount+" was used by another transaction") {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("none mined", func(t *testing.T) {
+		client := mocks.NewMockChainClient(gomock.NewController(t))
+		client.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, ethereum.NotFound).AnyTimes()
+		client.EXPECT().NonceAt(gomock.Any(), from, nil).Return(uint64(7), nil).AnyTimes()
+		config := waitConfig()
+		config.Timeouts.Confirmation = 20 * time.Millisecond
+
+		_, _, err := waitLanded(ctx, client, txs, from, config)
+		if err == nil || !strings.Contains(err.Error(), "none mined within timeouts.confirmation (20ms)") {
+			t.Errorf("got %v", err)
+		}
+	})
+}
+
+func TestReplaceTx(t *testing.T) {
+	client := mocks.NewMockChainClient(gomock.NewController(t))
+	config := &Config{}
+	config.Ethereum.PrivateKey = testKey
+	original, _ := nonceTxs()
+	pending := &pendingTx{Kind: "save", From: common.HexToAddress(testAccount)}
+
+	var sent *types.Transaction
+	client.EXPECT().ChainID(gomock.Any()).Return(big.NewInt(1337), nil)
+	client.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx *types.Transaction) error {
+		sent = tx
+		return nil
+	})
+	replacement, err := replaceTx(context.Background(), client, config, pending, original, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replacement != sent || replacement.Nonce() != 7 || replacement.GasPrice().Cmp(big.NewInt(12e8)) != 0 || replacement.Gas() != original.Gas() {
+		t.Errorf("replaced with %+v", replacement)
+	}
+	if sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), replacement); err != nil || sender != pending.From {
+		t.Errorf("replacement signed by %s, %v", sender.Hex(), err)
+	}
+
+	// Only the sender of the transaction can replace it
+	pending.From = common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	if _, err := replaceTx(context.Background(), client, config, pending, original, 20); err == nil || !strings.Contains(err.Error(), "active signer") {
+		t.Errorf("replaced a transaction of another account: %v", err)
+	}
+}
+
+func TestPendingReplacements(t *testing.T) {
+	config := &Config{}
+	config.State.File = filepath.Join(t.TempDir(), "pending_tx.json")
+	original, replacement := nonceTxs()
+	from := common.HexToAddress(testAccount)
+
+	first, err := newPendingTx("save", from, common.Address{}, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := first.replacedBy(replacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := second.replacedBy(types.NewTx(&types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(15e8)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := savePending(config, third); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadPending(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs, err := loaded.transactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 3 || txs[0].Hash() != third.Hash || txs[1].Hash() != replacement.Hash() || txs[2].Hash() != original.Hash() {
+		t.Errorf("transactions %v, want the latest first", txs)
+	}
+	if loaded.Kind != "save" || loaded.From != from || loaded.Nonce != 7 {
+		t.Errorf("loaded %+v", loaded)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpc serves the storage contract over gRPC. The messages and
+// service stubs are generated from storage.proto.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative storage.proto
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Options configures a Server.
+type Options struct {
+	// Records performs the contract operations.
+	Records api.Records
+	// Index backs ListKeys and StreamEvents. It is kept in sync elsewhere.
+	Index *indexer.Store
+	// Namespace names the records in responses.
+	Namespace recordid.Namespace
+	// WriteToken protects Save and Delete, which are disabled when empty.
+	WriteToken string
+	// PollInterval is how often followed streams look for new events.
+	// Defaults to a second.
+	PollInterval time.Duration
+}
+
+// Bounds of the pages of ListKeys and of the batches of StreamEvents.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	streamBatch     = 500
+)
+
+// Server implements StorageServiceServer.
+type Server struct {
+	UnimplementedStorageServiceServer
+	opts Options
+}
+
+// NewServer returns a gRPC server serving the storage service.
+func NewServer(opts Options, serverOpts ...grpc.ServerOption) *grpc.Server {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	server := grpc.NewServer(serverOpts...)
+	RegisterStorageServiceServer(server, &Server{opts: opts})
+	return server
+}
+
+// Save implements StorageServiceServer.
+func (s *Server) Save(ctx context.Context, req *SaveRequest) (*WriteResult, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	save := api.SaveRequest{
+		Key:            req.Key,
+		Field:          req.Field,
+		Value:          req.Value,
+		Tags:           req.Tags,
+		Supersedes:     req.Supersedes,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	if len(req.ValueBytes) > 0 {
+		save.Value, save.Encoding = base64.StdEncoding.EncodeToString(req.ValueBytes), "base64"
+	}
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "expires_at must be an RFC 3339 time")
+		}
+		save.ExpiresAt = &t
+	}
+	result, err := s.opts.Records.Save(ctx, save)
+	if err != nil {
+		return nil, recordError(err)
+	}
+	return writeResult(result), nil
+}
+
+// Get implements StorageServiceServer.
+func (s *Server) Get(ctx context.Context, req *GetRequest) (*Record, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	record, err := s.opts.Records.Get(ctx, req.Key, req.Field)
+	if err != nil {
+		return nil, recordError(err)
+	}
+	out := &Record{
+		Id:      record.ID,
+		Key:     record.Key,
+		Field:   record.Field,
+		Value:   record.Value,
+		Content: record.Content,
+	}
+	if record.Encoding == "base64" {
+		if out.ContentBytes, err = base64.StdEncoding.DecodeString(record.Content); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		out.Content = ""
+	}
+	// Strings must be UTF-8, which binary values stored without an
+	// envelope are not
+	if !utf8.ValidString(out.Value) {
+		out.Value = ""
+	}
+	return out, nil
+}
+
+// Delete implements StorageServiceServer.
+func (s *Server) Delete(ctx context.Context, req *DeleteRequest) (*WriteResult, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	result, err := s.opts.Records.Delete(ctx, req.Key, req.Field)
+	if err != nil {
+		return nil, recordError(err)
+	}
+	return writeResult(result), nil
+}
+
+// ListKeys implements StorageServiceServer. Page tokens hold the last
+// key/field pair of the previous page.
+func (s *Server) ListKeys(ctx context.Context, req *ListKeysRequest) (*ListKeysResponse, error) {
+	size := int(req.PageSize)
+	switch {
+	case size < 0 || size > maxPageSize:
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", maxPageSize)
+	case size == 0:
+		size = defaultPageSize
+	}
+	var after []string
+	if req.PageToken != "" {
+		data, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+		if err == nil {
+			err = json.Unmarshal(data, &after)
+		}
+		if err != nil || len(after) != 2 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	refs, err := s.opts.Index.Keys(req.Prefix)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &ListKeysResponse{}
+	for _, ref := range refs {
+		if after != nil && (ref.Key < after[0] || ref.Key == after[0] && ref.Field <= after[1]) {
+			continue
+		}
+		if len(resp.Keys) == size {
+			data, _ := json.Marshal([]string{resp.Keys[size-1].Key, resp.Keys[size-1].Field})
+			resp.NextPageToken = base64.RawURLEncoding.EncodeToString(data)
+			break
+		}
+		resp.Keys = append(resp.Keys, &KeyField{Key: ref.Key, Field: ref.Field, Version: ref.Version})
+	}
+	return resp, nil
+}
+
+// StreamEvents implements StorageServiceServer.
+func (s *Server) StreamEvents(req *StreamEventsRequest, stream StorageService_StreamEventsServer) error {
+	q := indexer.Query{Key: req.Key, Field: req.Field, FromBlock: req.FromBlock, Tags: req.Tags, Limit: streamBatch}
+	for {
+		records, err := s.opts.Index.Query(q)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		for _, r := range records {
+			if err := stream.Send(s.event(r)); err != nil {
+				return err
+			}
+		}
+		if n := len(records); n > 0 {
+			// Resume after the last event sent
+			q.FromBlock, q.FromLog = records[n-1].BlockNumber, records[n-1].LogIndex+1
+		}
+		if len(records) == streamBatch {
+			continue
+		}
+		if !req.Follow {
+			return nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+func (s *Server) event(r *indexer.Record) *Event {
+	return &Event{
+		Id:          s.opts.Namespace.ID(r.Key, r.Field, r.Version).String(),
+		Key:         r.Key,
+		Field:       r.Field,
+		Value:       r.Value,
+		BlockNumber: r.BlockNumber,
+		BlockHash:   r.BlockHash.Hex(),
+		TxHash:      r.TxHash.Hex(),
+		LogIndex:    uint32(r.LogIndex),
+		Timestamp:   r.Timestamp,
+		Writer:      r.Writer.Hex(),
+		Version:     r.Version,
+		Tags:        r.Tags,
+	}
+}
+
+// authorize checks the write token of a call.
+func (s *Server) authorize(ctx context.Context) error {
+	if s.opts.WriteToken == "" {
+		return status.Error(codes.PermissionDenied, "write API is disabled")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.WriteToken)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid write token")
+}
+
+// recordError converts an error of api.Records to a gRPC status.
+func recordError(err error) error {
+	switch {
+	case errors.Is(err, api.ErrInvalidRecord):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, api.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, api.ErrRejected):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, api.ErrKeyReused):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, api.ErrInProgress):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+func writeResult(result *api.WriteResult) *WriteResult {
+	return &WriteResult{Id: result.ID, TxHash: result.TxHash.Hex(), Block: result.Block, Replayed: result.Replayed}
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/rpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeRecords holds records in memory
+type fakeRecords map[string]api.SaveRequest
+
+func (f fakeRecords) Save(ctx context.Context, req api.SaveRequest) (*api.WriteResult, error) {
+	f[req.Key+"#"+req.Field] = req
+	return &api.WriteResult{ID: req.Key, Block: 7}, nil
+}
+
+func (f fakeRecords) Get(ctx context.Context, key, field string) (*api.Record, error) {
+	req, ok := f[key+"#"+field]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s#%s", api.ErrNotFound, key, field)
+	}
+	return &api.Record{ID: key, Key: key, Field: field, Value: req.Value, Content: req.Value, Encoding: req.Encoding}, nil
+}
+
+func (f fakeRecords) Delete(ctx context.Context, key, field string) (*api.WriteResult, error) {
+	if _, err := f.Get(ctx, key, field); err != nil {
+		return nil, err
+	}
+	delete(f, key+"#"+field)
+	return &api.WriteResult{ID: key, Block: 8}, nil
+}
+
+// dial serves the storage service over an in-memory connection
+func dial(t *testing.T, records api.Records) rpc.StorageServiceClient {
+	t.Helper()
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ix := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1})
+	if _, err := ix.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.NewServer(rpc.Options{
+		Records:    records,
+		Index:      store,
+		Namespace:  recordid.Namespace{ChainID: 1337, Contract: chain.Contract},
+		WriteToken: "secret",
+	})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewStorageServiceClient(conn)
+}
+
+func TestWrites(t *testing.T) {
+	records := fakeRecords{}
+	client := dial(t, records)
+	ctx := context.Background()
+
+	if _, err := client.Save(ctx, &rpc.SaveRequest{Key: "a", Value: "1"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("saved without a token: %v", err)
+	}
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	if _, err := client.Save(authed, &rpc.SaveRequest{Key: "a", Value: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	record, err := client.Get(ctx, &rpc.GetRequest{Key: "a"})
+	if err != nil || record.Value != "1" {
+		t.Fatalf("got %v, %v", record, err)
+	}
+	if _, err := client.Delete(authed, &rpc.DeleteRequest{Key: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(ctx, &rpc.GetRequest{Key: "a"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("got %v after delete, want NotFound", err)
+	}
+}
+
+func TestBinaryValues(t *testing.T) {
+	records := fakeRecords{}
+	client := dial(t, records)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	value := []byte{0xde, 0xad, 0x00, 0xff}
+	if _, err := client.Save(ctx, &rpc.SaveRequest{Key: "sig", ValueBytes: value}); err != nil {
+		t.Fatal(err)
+	}
+	if req := records["sig#"]; req.Encoding != "base64" || req.Value != "3q0A/w==" {
+		t.Errorf("saved %+v", req)
+	}
+	record, err := client.Get(ctx, &rpc.GetRequest{Key: "sig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(record.ContentBytes, value) || record.Content != "" {
+		t.Errorf("got content %q, bytes %x", record.Content, record.ContentBytes)
+	}
+}
+
+func TestListKeys(t *testing.T) {
+	client := dial(t, fakeRecords{})
+
+	var got []string
+	req := &rpc.ListKeysRequest{PageSize: 3}
+	for pages := 0; ; pages++ {
+		resp, err := client.ListKeys(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range resp.Keys {
+			got = append(got, fmt.Sprintf("%s#%s@%d", k.Key, k.Field, k.Version))
+		}
+		if resp.NextPageToken == "" {
+			if pages != 1 {
+				t.Errorf("listed %d pages, want 2", pages+1)
+			}
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if want := "doc#sha256@1 invoice-42#pdf@2 invoice-43#pdf@1 plain#note@1"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	client := dial(t, fakeRecords{})
+
+	stream, err := client.StreamEvents(context.Background(), &rpc.StreamEventsRequest{Key: "invoice-42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s#%s@%d", event.Key, event.Field, event.Version))
+	}
+	if want := "invoice-42#pdf@1 invoice-42#pdf@2"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
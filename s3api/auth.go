@@ -0,0 +1,307 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of AWS Signature Version 4.
+const (
+	signingAlgorithm  = "AWS4-HMAC-SHA256"
+	chunkAlgorithm    = "AWS4-HMAC-SHA256-PAYLOAD"
+	signingTerminator = "aws4_request"
+	signingService    = "s3"
+	amzDateFormat     = "20060102T150405Z"
+
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	// streamingTrailer is the aws-chunked payload with unsigned chunks
+	// followed by trailing checksums, which newer SDKs send.
+	streamingTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	// maxClockSkew is how far the date of a signed request may be from
+	// the time it is received.
+	maxClockSkew = 15 * time.Minute
+	// maxPresignedExpiry is the longest a presigned URL is valid for.
+	maxPresignedExpiry = 7 * 24 * time.Hour
+)
+
+// emptyHash is the SHA-256 of an empty payload
+var emptyHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+// signature is the verified signature of a request
+type signature struct {
+	date  time.Time
+	scope string
+	key   []byte
+	// seed is the signature of the request, which the signature of its
+	// first payload chunk follows from
+	seed string
+	// payload is the x-amz-content-sha256 the request was signed with
+	payload string
+}
+
+var (
+	errSignatureMismatch = newError(http.StatusForbidden, "SignatureDoesNotMatch",
+		"The request signature we calculated does not match the signature you provided.")
+	errInvalidAccessKey = newError(http.StatusForbidden, "InvalidAccessKeyId",
+		"The AWS access key ID you provided does not exist in our records.")
+	errRequestExpired = newError(http.StatusForbidden, "AccessDenied", "Request has expired.")
+	errIncompleteBody = newError(http.StatusBadRequest, "IncompleteBody", "The request body is not a valid aws-chunked payload.")
+)
+
+func errMalformedAuth(message string) *Error {
+	return newError(http.StatusBadRequest, "AuthorizationHeaderMalformed", message)
+}
+
+// authenticate verifies the signature of a request, either in its
+// Authorization header or in the query string of a presigned URL. It
+// returns nil for the anonymous requests Options.PublicRead allows
+func (s *Server) authenticate(r *http.Request) (*signature, error) {
+	query := r.URL.Query()
+	var (
+		credential, signedHeaders, provided, amzDate, payload string
+		expires                                               time.Duration
+	)
+	switch header := r.Header.Get("Authorization"); {
+	case query.Has("X-Amz-Algorithm"):
+		if query.Get("X-Amz-Algorithm") != signingAlgorithm {
+			return nil, errMalformedAuth("unsupported X-Amz-Algorithm")
+		}
+		credential, signedHeaders, provided = query.Get("X-Amz-Credential"), query.Get("X-Amz-SignedHeaders"), query.Get("X-Amz-Signature")
+		amzDate, payload = query.Get("X-Amz-Date"), unsignedPayload
+		seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > maxPresignedExpiry {
+			return nil, errMalformedAuth("X-Amz-Expires must be between 1 and 604800 seconds")
+		}
+		expires = time.Duration(seconds) * time.Second
+	case header != "":
+		rest, ok := strings.CutPrefix(header, signingAlgorithm+" ")
+		if !ok {
+			return nil, errMalformedAuth("only " + signingAlgorithm + " signatures are supported")
+		}
+		for _, part := range strings.Split(rest, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = value
+			case "Signature":
+				provided = value
+			}
+		}
+		if amzDate = r.Header.Get("X-Amz-Date"); amzDate == "" {
+			amzDate = r.Header.Get("Date")
+		}
+		if payload = r.Header.Get("X-Amz-Content-Sha256"); payload == "" {
+			if r.ContentLength != 0 {
+				return nil, newError(http.StatusBadRequest, "InvalidRequest", "Missing required header for this request: x-amz-content-sha256")
+			}
+			payload = emptyHash
+		}
+	case s.opts.PublicRead && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		return nil, nil
+	default:
+		return nil, errAccessDenied
+	}
+
+	// Credential is ACCESS_KEY/DATE/REGION/SERVICE/aws4_request
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[3] != signingService || parts[4] != signingTerminator {
+		return nil, errMalformedAuth("invalid credential scope " + strconv.Quote(credential))
+	}
+	if subtle.ConstantTimeCompare([]byte(parts[0]), []byte(s.opts.AccessKey)) != 1 {
+		return nil, errInvalidAccessKey
+	}
+	date, err := parseAmzDate(amzDate)
+	if err != nil || date.Format("20060102") != parts[1] {
+		return nil, errMalformedAuth("invalid or missing request date")
+	}
+	now := time.Now()
+	switch {
+	case expires > 0 && now.After(date.Add(expires)):
+		return nil, errRequestExpired
+	case expires == 0 && (now.Sub(date) > maxClockSkew || date.Sub(now) > maxClockSkew):
+		return nil, newError(http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the current time is too large.")
+	}
+
+	sig := &signature{
+		date:    date,
+		scope:   strings.Join(parts[1:], "/"),
+		key:     signingKey(s.opts.SecretKey, parts[1], parts[2]),
+		payload: payload,
+	}
+	headers := strings.Split(signedHeaders, ";")
+	if !slices.Contains(headers, "host") {
+		return nil, errMalformedAuth("the host header must be signed")
+	}
+	canonical := canonicalRequest(r, headers, payload, expires > 0)
+	sig.seed = hex.EncodeToString(hmacSHA256(sig.key, sig.stringToSign(signingAlgorithm, hashHex([]byte(canonical)))))
+	if !hmac.Equal([]byte(sig.seed), []byte(provided)) {
+		return nil, errSignatureMismatch
+	}
+	return sig, nil
+}
+
+func parseAmzDate(s string) (time.Time, error) {
+	if t, err := time.Parse(amzDateFormat, s); err == nil {
+		return t, nil
+	}
+	return http.ParseTime(s)
+}
+
+// stringToSign returns the string the signature of a request or chunk
+// signs, the scope followed by lines
+func (sig *signature) stringToSign(algorithm string, lines ...string) string {
+	return strings.Join(append([]string{algorithm, sig.date.UTC().Format(amzDateFormat), sig.scope}, lines...), "\n")
+}
+
+// signingKey derives the key signing the requests of a day and region
+func signingKey(secret, day, region string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, signingService)
+	return hmacSHA256(key, signingTerminator)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalRequest returns the canonical form of a request its signature
+// signs. The signature itself is left out of the query of presigned URLs
+func canonicalRequest(r *http.Request, signedHeaders []string, payload string, presigned bool) string {
+	var params []string
+	for name, values := range r.URL.Query() {
+		if presigned && name == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	slices.Sort(params)
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		headers.WriteString(name + ":" + headerValue(r, name) + "\n")
+	}
+
+	path := r.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		r.Method,
+		uriEncode(path, false),
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payload,
+	}, "\n")
+}
+
+// headerValue returns the canonical value of a signed header. net/http
+// moves Host and Content-Length out of the header map
+func headerValue(r *http.Request, name string) string {
+	switch {
+	case name == "host":
+		return r.Host
+	case name == "content-length" && r.Header.Get(name) == "":
+		return strconv.FormatInt(r.ContentLength, 10)
+	}
+	values := r.Header.Values(name)
+	for i, v := range values {
+		values[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return strings.Join(values, ",")
+}
+
+// uriEncode escapes s as Signature Version 4 does: every byte but the
+// unreserved characters, and the slashes of paths
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// decodeChunks decodes an aws-chunked payload, each chunk of which is
+// "SIZE[;chunk-signature=SIGNATURE]\r\nDATA\r\n", ending with an empty
+// chunk possibly followed by trailing headers. The chunk signatures are
+// verified when sig is set
+func decodeChunks(raw []byte, sig *signature) ([]byte, error) {
+	var body []byte
+	previous := ""
+	if sig != nil {
+		previous = sig.seed
+	}
+	for {
+		line, rest, ok := bytes.Cut(raw, []byte("\r\n"))
+		if !ok {
+			return nil, errIncompleteBody
+		}
+		sizeHex, ext, _ := strings.Cut(string(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil || size < 0 || size > int64(len(rest)) {
+			return nil, errIncompleteBody
+		}
+		data := rest[:size]
+		if sig != nil {
+			provided, _ := strings.CutPrefix(ext, "chunk-signature=")
+			want := hex.EncodeToString(hmacSHA256(sig.key, sig.stringToSign(chunkAlgorithm, previous, emptyHash, hashHex(data))))
+			if !hmac.Equal([]byte(want), []byte(provided)) {
+				return nil, errSignatureMismatch
+			}
+			previous = want
+		}
+		if size == 0 {
+			// Trailing headers, if any, hold checksums the gateway does
+			// not need
+			return body, nil
+		}
+		body = append(body, data...)
+		if raw, ok = bytes.CutPrefix(rest[size:], []byte("\r\n")); !ok {
+			return nil, errIncompleteBody
+		}
+	}
+}
@@ -0,0 +1,461 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3api
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/tombstone"
+)
+
+// Tags of the records of objects, when Options.Tags is set.
+const (
+	TagContentType = "content-type"
+	TagSize        = "size"
+	TagETag        = "etag"
+)
+
+const (
+	defaultContentType = "binary/octet-stream"
+	maxListKeys        = 1000
+	timeFormat         = "2006-01-02T15:04:05.000Z"
+)
+
+// objectKey returns the record key of an object
+func objectKey(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+// putObject serves PutObject. The body is saved as a binary value, which
+// Records stores off chain or in chunks when it is large
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, bucket, key string, sig *signature) {
+	body, err := s.readBody(r, sig)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if len(body) == 0 && !s.opts.Tags {
+		// An empty value reads as no value
+		writeError(w, r, newError(http.StatusBadRequest, "InvalidArgument", "Empty objects require storage.envelope."))
+		return
+	}
+
+	etag := md5Hex(body)
+	req := api.SaveRequest{
+		Key:      objectKey(bucket, key),
+		Value:    base64.StdEncoding.EncodeToString(body),
+		Encoding: "base64",
+	}
+	if s.opts.Tags {
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = defaultContentType
+		}
+		req.Tags = map[string]string{
+			TagContentType: contentType,
+			TagSize:        strconv.Itoa(len(body)),
+			TagETag:        etag,
+		}
+	}
+	if _, err := s.opts.Records.Save(r.Context(), req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(etag))
+	w.WriteHeader(http.StatusOK)
+}
+
+// readBody reads the body of a PutObject request, checking it against the
+// payload hash it was signed with and its Content-MD5
+func (s *Server) readBody(r *http.Request, sig *signature) ([]byte, error) {
+	limit := s.opts.MaxObjectSize
+	payload := unsignedPayload
+	if sig != nil {
+		payload = sig.payload
+	}
+	streaming := payload == streamingPayload || payload == streamingTrailer
+	if streaming {
+		decoded, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err == nil && decoded > limit {
+			return nil, errEntityTooLarge
+		}
+		// Room for the framing of the chunks
+		limit += limit/64 + 64<<10
+	} else if r.ContentLength > limit {
+		return nil, errEntityTooLarge
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, errIncompleteBody
+	}
+	if int64(len(raw)) > limit {
+		return nil, errEntityTooLarge
+	}
+	body := raw
+	switch payload {
+	case streamingPayload:
+		body, err = decodeChunks(raw, sig)
+	case streamingTrailer:
+		body, err = decodeChunks(raw, nil)
+	case unsignedPayload:
+	default:
+		if hashHex(raw) != payload {
+			err = newError(http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > s.opts.MaxObjectSize {
+		return nil, errEntityTooLarge
+	}
+
+	if digest := r.Header.Get("Content-MD5"); digest != "" {
+		want, err := base64.StdEncoding.DecodeString(digest)
+		if err != nil || len(want) != md5.Size {
+			return nil, newError(http.StatusBadRequest, "InvalidDigest", "The Content-MD5 you specified was invalid.")
+		}
+		if sum := md5.Sum(body); !bytes.Equal(sum[:], want) {
+			return nil, newError(http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+		}
+	}
+	return body, nil
+}
+
+// getObject serves GetObject and HeadObject, with ranges and conditions
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	record, err := s.opts.Records.Get(r.Context(), objectKey(bucket, key), "")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	content, err := recordContent(record)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	contentType := recordTags(record.Value)[TagContentType]
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	var modified time.Time
+	if stored, err := s.opts.Index.Get(indexer.Ref{Key: s.opts.Namespace.Key(objectKey(bucket, key))}); err == nil {
+		modified = time.Unix(int64(stored.Timestamp), 0)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", strconv.Quote(md5Hex(content)))
+	http.ServeContent(w, r, "", modified, bytes.NewReader(content))
+}
+
+// deleteObject serves DeleteObject, which succeeds for missing objects too
+func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	if _, err := s.opts.Records.Delete(r.Context(), objectKey(bucket, key), ""); err != nil && !errors.Is(err, api.ErrNotFound) {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteBucket serves DeleteBucket. Buckets are implicit, so this only
+// checks that the bucket is empty
+func (s *Server) deleteBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	objects, err := s.objects(bucket, "")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if len(objects) > 0 {
+		writeError(w, r, errBucketNotEmpty)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// storedObject is an object found in the index
+type storedObject struct {
+	key    string
+	record *indexer.Record
+}
+
+// objects returns the objects of a bucket whose key starts with prefix,
+// ordered by key
+func (s *Server) objects(bucket, prefix string) ([]storedObject, error) {
+	objects, err := s.stored(objectKey(bucket, prefix))
+	for i := range objects {
+		objects[i].key = strings.TrimPrefix(objects[i].key, bucket+"/")
+	}
+	return objects, err
+}
+
+// stored returns the records holding a value whose key starts with prefix,
+// as objects named by their record key
+func (s *Server) stored(prefix string) ([]storedObject, error) {
+	refs, err := s.opts.Index.Keys(s.opts.Namespace.Key(prefix))
+	if err != nil {
+		return nil, err
+	}
+	var objects []storedObject
+	for _, ref := range refs {
+		// Other fields hold the chunks of large values
+		if ref.Field != "" {
+			continue
+		}
+		local, ok := s.opts.Namespace.Local(ref.Key)
+		if !ok {
+			continue
+		}
+		record, err := s.opts.Index.Get(ref)
+		if err != nil {
+			return nil, err
+		}
+		if record.Value == "" || tombstone.Is(record.Value) {
+			continue
+		}
+		objects = append(objects, storedObject{key: local, record: record})
+	}
+	return objects, nil
+}
+
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name        string   `xml:"Name"`
+	Prefix      string   `xml:"Prefix"`
+	Delimiter   string   `xml:"Delimiter,omitempty"`
+	MaxKeys     int      `xml:"MaxKeys"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	// Marker and NextMarker page ListObjects
+	Marker     *string `xml:"Marker"`
+	NextMarker string  `xml:"NextMarker,omitempty"`
+	// The others page ListObjectsV2
+	KeyCount              *int           `xml:"KeyCount"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	Contents              []objectInfo   `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type objectInfo struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// listObjects serves ListObjects and ListObjectsV2
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	result := listBucketResult{
+		Name:      bucket,
+		Prefix:    query.Get("prefix"),
+		Delimiter: query.Get("delimiter"),
+		MaxKeys:   maxListKeys,
+	}
+	if query.Has("max-keys") {
+		n, err := strconv.Atoi(query.Get("max-keys"))
+		if err != nil || n < 0 {
+			writeError(w, r, newError(http.StatusBadRequest, "InvalidArgument", "max-keys must be a non-negative integer."))
+			return
+		}
+		result.MaxKeys = min(n, maxListKeys)
+	}
+
+	v2 := query.Get("list-type") == "2"
+	after := query.Get("marker")
+	if v2 {
+		result.StartAfter, result.ContinuationToken = query.Get("start-after"), query.Get("continuation-token")
+		after = result.StartAfter
+		if result.ContinuationToken != "" {
+			token, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+			if err != nil {
+				writeError(w, r, newError(http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect."))
+				return
+			}
+			after = string(token)
+		}
+	} else {
+		result.Marker = &after
+	}
+
+	objects, err := s.objects(bucket, result.Prefix)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	count, last := 0, ""
+	for _, object := range objects {
+		if object.key <= after {
+			continue
+		}
+		if result.Delimiter != "" {
+			if i := strings.Index(object.key[len(result.Prefix):], result.Delimiter); i >= 0 {
+				// Keys past the delimiter are rolled up in their prefix
+				p := object.key[:len(result.Prefix)+i+len(result.Delimiter)]
+				if p == last || strings.HasPrefix(after, p) {
+					continue
+				}
+				if count == result.MaxKeys {
+					result.IsTruncated = true
+					break
+				}
+				result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: p})
+				count, last = count+1, p
+				continue
+			}
+		}
+		if count == result.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+		info, err := s.objectInfo(r.Context(), bucket, object)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		result.Contents = append(result.Contents, info)
+		count, last = count+1, object.key
+	}
+
+	if result.IsTruncated {
+		if v2 {
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+		} else {
+			result.NextMarker = last
+		}
+	}
+	if v2 {
+		result.KeyCount = &count
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// objectInfo describes a listed object. Its size and ETag come from its
+// tags, or else from its value, which is read for them
+func (s *Server) objectInfo(ctx context.Context, bucket string, object storedObject) (objectInfo, error) {
+	info := objectInfo{
+		Key:          object.key,
+		LastModified: time.Unix(int64(object.record.Timestamp), 0).UTC().Format(timeFormat),
+		StorageClass: "STANDARD",
+	}
+	size, err := strconv.ParseInt(object.record.Tags[TagSize], 10, 64)
+	if etag := object.record.Tags[TagETag]; err == nil && etag != "" {
+		info.Size, info.ETag = size, strconv.Quote(etag)
+		return info, nil
+	}
+
+	record, err := s.opts.Records.Get(ctx, objectKey(bucket, object.key), "")
+	if err != nil {
+		return info, err
+	}
+	content, err := recordContent(record)
+	if err != nil {
+		return info, err
+	}
+	info.Size, info.ETag = int64(len(content)), strconv.Quote(md5Hex(content))
+	return info, nil
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name     `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+	Owner   bucketOwner  `xml:"Owner"`
+	Buckets []bucketInfo `xml:"Buckets>Bucket"`
+}
+
+type bucketOwner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type bucketInfo struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+// listBuckets serves ListBuckets: the buckets holding objects, created
+// with their first object still stored
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	objects, err := s.stored("")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := listAllMyBucketsResult{Owner: bucketOwner{ID: s.opts.AccessKey, DisplayName: s.opts.AccessKey}}
+	created := map[string]uint64{}
+	for _, object := range objects {
+		name, _, ok := strings.Cut(object.key, "/")
+		if !ok || name == "" {
+			continue
+		}
+		if at, seen := created[name]; !seen {
+			result.Buckets = append(result.Buckets, bucketInfo{Name: name})
+			created[name] = object.record.Timestamp
+		} else {
+			created[name] = min(at, object.record.Timestamp)
+		}
+	}
+	for i := range result.Buckets {
+		result.Buckets[i].CreationDate = time.Unix(int64(created[result.Buckets[i].Name]), 0).UTC().Format(timeFormat)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// recordContent returns the content of the record of an object
+func recordContent(record *api.Record) ([]byte, error) {
+	if record.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(record.Content)
+	}
+	return []byte(record.Content), nil
+}
+
+// recordTags returns the tags of a stored value
+func recordTags(value string) map[string]string {
+	env, err := envelope.Parse(value)
+	if err != nil {
+		return nil
+	}
+	tags := map[string]string{}
+	for name, v := range env.Meta {
+		if tag, ok := strings.CutPrefix(name, indexer.MetaTagPrefix); ok {
+			tags[tag] = v
+		}
+	}
+	return tags
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,287 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3api serves a minimal S3-compatible API backed by the records of
+// the storage contract, so that S3 clients can store their objects on the
+// chain. An object is the record whose key is its bucket and object key
+// joined by a slash; a bucket exists once it holds an object.
+package s3api
+
+import (
+	"encoding/xml"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/keyspace"
+	"contract-storage-eth/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Defaults of Options.
+const (
+	defaultRegion        = "us-east-1"
+	defaultMaxObjectSize = 64 << 20
+)
+
+// Options configure a Server.
+type Options struct {
+	// Records stores the objects.
+	Records api.Records
+	// Index lists the buckets and objects, which listings miss until it
+	// has synced their writes.
+	Index *indexer.Store
+	// Namespace is the key namespace of Records, left out of listings.
+	Namespace keyspace.Namespace
+	// AccessKey and SecretKey are the credentials requests must be signed
+	// with, using AWS Signature Version 4.
+	AccessKey string
+	SecretKey string
+	// Region is the region GetBucketLocation reports. Defaults to
+	// us-east-1.
+	Region string
+	// Domain enables virtual-hosted-style requests, whose host is the
+	// bucket followed by a dot and Domain. Other requests are path-style.
+	Domain string
+	// PublicRead lets anonymous requests read objects.
+	PublicRead bool
+	// Tags stores the content type, size and ETag of objects as record
+	// tags, which requires envelopes. Without them, objects are served as
+	// binary/octet-stream and listings read each object for its size.
+	Tags bool
+	// MaxObjectSize is the size of the largest object accepted. Defaults
+	// to 64 MiB.
+	MaxObjectSize int64
+}
+
+// Server is the HTTP handler of the S3 API.
+type Server struct {
+	opts Options
+}
+
+// NewServer returns a server with the given options.
+func NewServer(opts Options) *Server {
+	if opts.Region == "" {
+		opts.Region = defaultRegion
+	}
+	if opts.MaxObjectSize <= 0 {
+		opts.MaxObjectSize = defaultMaxObjectSize
+	}
+	return &Server{opts: opts}
+}
+
+// ServeHTTP implements http.Handler. Each request is traced as a span
+// named after its method, continuing the trace of the caller's traceparent
+// header.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key := s.target(r)
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracing.Start(ctx, "s3 "+r.Method, attribute.String("s3.bucket", bucket), attribute.String("s3.key", key))
+	defer span.End()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	s.serve(sw, r.WithContext(ctx), bucket, key)
+	span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+	if sw.status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(sw.status))
+	}
+}
+
+// statusWriter records the status of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// serve routes a request to its operation
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	auth, err := s.authenticate(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch {
+	case bucket == "" && r.Method == http.MethodGet:
+		s.listBuckets(w, r)
+	case bucket == "":
+		writeError(w, r, errMethodNotAllowed)
+	case key == "":
+		s.serveBucket(w, r, bucket)
+	default:
+		s.serveObject(w, r, bucket, key, auth)
+	}
+}
+
+// serveBucket serves the bucket operations. Buckets are implicit, so
+// creating one does nothing
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && query.Has("location"):
+		region := s.opts.Region
+		if region == defaultRegion {
+			// S3 reports its first region as no location constraint
+			region = ""
+		}
+		writeXML(w, http.StatusOK, locationConstraint{Region: region})
+	case r.Method == http.MethodGet && unsupported(query):
+		writeError(w, r, errNotImplemented)
+	case r.Method == http.MethodGet:
+		s.listObjects(w, r, bucket)
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && len(query) == 0:
+		w.Header().Set("Location", "/"+bucket)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && len(query) == 0:
+		s.deleteBucket(w, r, bucket)
+	case r.Method == http.MethodPut, r.Method == http.MethodDelete, r.Method == http.MethodPost:
+		writeError(w, r, errNotImplemented)
+	default:
+		writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// serveObject serves the object operations
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucket, key string, auth *signature) {
+	if unsupported(r.URL.Query()) || r.Header.Get("X-Amz-Copy-Source") != "" {
+		// Multipart uploads, copies, ACLs and the like
+		writeError(w, r, errNotImplemented)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.getObject(w, r, bucket, key)
+	case http.MethodPut:
+		s.putObject(w, r, bucket, key, auth)
+	case http.MethodDelete:
+		s.deleteObject(w, r, bucket, key)
+	default:
+		writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// target returns the bucket and object key a request is for
+func (s *Server) target(r *http.Request) (bucket, key string) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if s.opts.Domain != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if b, ok := strings.CutSuffix(host, "."+s.opts.Domain); ok {
+			return b, path
+		}
+	}
+	bucket, key, _ = strings.Cut(path, "/")
+	return bucket, key
+}
+
+// subresources are the query parameters of the S3 operations the server
+// does not implement
+var subresources = []string{
+	"acl", "cors", "delete", "lifecycle", "policy", "tagging", "uploadId",
+	"uploads", "versioning", "versions", "website",
+}
+
+func unsupported(query map[string][]string) bool {
+	for _, name := range subresources {
+		if _, ok := query[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Error is an S3 error response.
+type Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource,omitempty"`
+
+	status int
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func newError(status int, code, message string) *Error {
+	return &Error{Code: code, Message: message, status: status}
+}
+
+var (
+	errAccessDenied     = newError(http.StatusForbidden, "AccessDenied", "Access Denied")
+	errMethodNotAllowed = newError(http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	errNotImplemented   = newError(http.StatusNotImplemented, "NotImplemented", "The gateway does not implement this operation.")
+	errNoSuchKey        = newError(http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+	errEntityTooLarge   = newError(http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed object size.")
+	errBucketNotEmpty   = newError(http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty.")
+)
+
+// recordError returns the S3 error of an error of Records
+func recordError(err error) *Error {
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		return errNoSuchKey
+	case errors.Is(err, api.ErrInvalidRecord):
+		return newError(http.StatusBadRequest, "InvalidArgument", err.Error())
+	case errors.Is(err, api.ErrRejected):
+		return newError(http.StatusForbidden, "AccessDenied", err.Error())
+	}
+	slog.Error("S3 request failed", "error", err)
+	return newError(http.StatusInternalServerError, "InternalError", err.Error())
+}
+
+// writeError answers with err, an *Error or an error of Records
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = recordError(err)
+	}
+	if r.Method == http.MethodHead {
+		// HEAD responses have no body
+		w.WriteHeader(e.status)
+		return
+	}
+	resp := *e
+	resp.Resource = r.URL.Path
+	writeXML(w, e.status, resp)
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(v)
+}
+
+type locationConstraint struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+	Region  string   `xml:",chardata"`
+}
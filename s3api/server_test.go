@@ -0,0 +1,316 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/s3api"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+var credentials = aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+// fakeRecords seals the values it saves into the index, as the contract
+// and the indexer would
+type fakeRecords struct {
+	store *indexer.Store
+	block uint64
+}
+
+func (f *fakeRecords) Save(ctx context.Context, req api.SaveRequest) (*api.WriteResult, error) {
+	value, err := base64.StdEncoding.DecodeString(req.Value)
+	if err != nil || req.Encoding != "base64" {
+		return nil, fmt.Errorf("%w: want a base64 value", api.ErrInvalidRecord)
+	}
+	sealed, err := envelope.Seal(value, envelope.Options{Codec: envelope.CodecBytes, Meta: indexer.TagMeta(req.Tags)})
+	if err != nil {
+		return nil, err
+	}
+	return f.write(req.Key, sealed)
+}
+
+func (f *fakeRecords) Get(ctx context.Context, key, field string) (*api.Record, error) {
+	record, err := f.store.Get(indexer.Ref{Key: key, Field: field})
+	if err != nil || record.Value == "" {
+		return nil, fmt.Errorf("%w for %s#%s", api.ErrNotFound, key, field)
+	}
+	env, err := envelope.Parse(record.Value)
+	if err != nil {
+		return nil, err
+	}
+	content, err := env.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &api.Record{Key: key, Value: record.Value, Content: base64.StdEncoding.EncodeToString(content), Encoding: "base64"}, nil
+}
+
+func (f *fakeRecords) Delete(ctx context.Context, key, field string) (*api.WriteResult, error) {
+	if _, err := f.Get(ctx, key, field); err != nil {
+		return nil, err
+	}
+	return f.write(key, "")
+}
+
+func (f *fakeRecords) write(key, value string) (*api.WriteResult, error) {
+	f.block++
+	record := &indexer.Record{Key: key, Value: value, BlockNumber: f.block, Timestamp: 1700000000 + f.block}
+	if err := f.store.Commit([]*indexer.Record{record}, nil, f.block+1); err != nil {
+		return nil, err
+	}
+	return &api.WriteResult{ID: key, Block: f.block}, nil
+}
+
+func serve(t *testing.T, opts s3api.Options) *httptest.Server {
+	t.Helper()
+	store, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	opts.Records, opts.Index = &fakeRecords{store: store}, store
+	opts.AccessKey, opts.SecretKey, opts.Tags = credentials.AccessKeyID, credentials.SecretAccessKey, true
+	server := httptest.NewServer(s3api.NewServer(opts))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// do sends a request signed with the given credentials, the body hashed
+// into its signature
+func do(t *testing.T, server *httptest.Server, creds aws.Credentials, method, path string, body []byte, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	hash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
+	if creds.AccessKeyID != "" {
+		if err := v4.NewSigner().SignHTTP(context.Background(), creds, req, hex.EncodeToString(hash[:]), "s3", "us-east-1", time.Now(), disableEscaping); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return send(t, req)
+}
+
+func send(t *testing.T, req *http.Request) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+// disableEscaping signs paths as S3 clients do, escaped once
+func disableEscaping(o *v4.SignerOptions) {
+	o.DisableURIPathEscaping = true
+}
+
+func TestObjects(t *testing.T) {
+	server := serve(t, s3api.Options{})
+
+	resp, _ := do(t, server, credentials, http.MethodPut, "/files/docs/hello%20world.txt", []byte("hello, chain"), http.Header{"Content-Type": {"text/plain"}})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"a08ead873c322a01d42f17b867248447"` {
+		t.Fatalf("put: %s, ETag %s", resp.Status, resp.Header.Get("ETag"))
+	}
+
+	resp, body := do(t, server, credentials, http.MethodGet, "/files/docs/hello%20world.txt", nil, nil)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello, chain" || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("get: %s %q, Content-Type %s", resp.Status, body, resp.Header.Get("Content-Type"))
+	}
+	resp, body = do(t, server, credentials, http.MethodGet, "/files/docs/hello%20world.txt", nil, http.Header{"Range": {"bytes=7-"}})
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "chain" {
+		t.Errorf("ranged get: %s %q", resp.Status, body)
+	}
+	resp, body = do(t, server, credentials, http.MethodHead, "/files/docs/hello%20world.txt", nil, nil)
+	if resp.StatusCode != http.StatusOK || len(body) != 0 || resp.ContentLength != 12 {
+		t.Errorf("head: %s, %d bytes, length %d", resp.Status, len(body), resp.ContentLength)
+	}
+
+	resp, _ = do(t, server, credentials, http.MethodDelete, "/files/docs/hello%20world.txt", nil, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: %s", resp.Status)
+	}
+	resp, body = do(t, server, credentials, http.MethodGet, "/files/docs/hello%20world.txt", nil, nil)
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "<Code>NoSuchKey</Code>") {
+		t.Errorf("get after delete: %s %s", resp.Status, body)
+	}
+	if resp, _ = do(t, server, credentials, http.MethodDelete, "/files/missing", nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete of a missing object: %s", resp.Status)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server := serve(t, s3api.Options{PublicRead: true})
+	do(t, server, credentials, http.MethodPut, "/files/a", []byte("a"), nil)
+
+	wrong := credentials
+	wrong.SecretAccessKey = "not the secret"
+	for _, tt := range []struct {
+		name   string
+		creds  aws.Credentials
+		method string
+		want   string
+	}{
+		{"wrong secret", wrong, http.MethodPut, "SignatureDoesNotMatch"},
+		{"anonymous write", aws.Credentials{}, http.MethodPut, "AccessDenied"},
+		{"unknown access key", aws.Credentials{AccessKeyID: "AKIDOTHER", SecretAccessKey: "x"}, http.MethodGet, "InvalidAccessKeyId"},
+	} {
+		resp, body := do(t, server, tt.creds, tt.method, "/files/a", []byte("b"), nil)
+		if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "<Code>"+tt.want+"</Code>") {
+			t.Errorf("%s: %s %s", tt.name, resp.Status, body)
+		}
+	}
+	if resp, body := do(t, server, aws.Credentials{}, http.MethodGet, "/files/a", nil, nil); resp.StatusCode != http.StatusOK || string(body) != "a" {
+		t.Errorf("anonymous read: %s %q", resp.Status, body)
+	}
+
+	// Presigned URLs
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/files/a?X-Amz-Expires=60", nil)
+	url, _, err := v4.NewSigner().PresignHTTP(context.Background(), credentials, req, "UNSIGNED-PAYLOAD", "s3", "us-east-1", time.Now(), disableEscaping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	if resp, body := send(t, req); resp.StatusCode != http.StatusOK || string(body) != "a" {
+		t.Errorf("presigned get: %s %q", resp.Status, body)
+	}
+	req, _ = http.NewRequest(http.MethodGet, strings.Replace(url, "files/a", "files/b", 1), nil)
+	if resp, _ := send(t, req); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("presigned get of another object: %s", resp.Status)
+	}
+}
+
+func TestStreamingUpload(t *testing.T) {
+	server := serve(t, s3api.Options{})
+	chunks := [][]byte{bytes.Repeat([]byte("x"), 8192), []byte("tail"), nil}
+
+	put := func(tamper bool) (*http.Response, []byte) {
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/files/big", nil)
+		req.Header.Set("X-Amz-Content-Sha256", "STREAMING-AWS4-HMAC-SHA256-PAYLOAD")
+		req.Header.Set("X-Amz-Decoded-Content-Length", "8196")
+		now := time.Now().UTC()
+		if err := v4.NewSigner().SignHTTP(context.Background(), credentials, req, "STREAMING-AWS4-HMAC-SHA256-PAYLOAD", "s3", "us-east-1", now, disableEscaping); err != nil {
+			t.Fatal(err)
+		}
+		_, seed, _ := strings.Cut(req.Header.Get("Authorization"), "Signature=")
+
+		key := []byte("AWS4" + credentials.SecretAccessKey)
+		for _, part := range []string{now.Format("20060102"), "us-east-1", "s3", "aws4_request"} {
+			key = sign(key, part)
+		}
+		var body bytes.Buffer
+		previous := seed
+		for _, chunk := range chunks {
+			hash := sha256.Sum256(chunk)
+			previous = hex.EncodeToString(sign(key, strings.Join([]string{
+				"AWS4-HMAC-SHA256-PAYLOAD", now.Format("20060102T150405Z"), now.Format("20060102") + "/us-east-1/s3/aws4_request",
+				previous, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", hex.EncodeToString(hash[:]),
+			}, "\n")))
+			if tamper && len(chunk) > 0 {
+				chunk = bytes.ToUpper(chunk)
+			}
+			fmt.Fprintf(&body, "%x;chunk-signature=%s\r\n%s\r\n", len(chunk), previous, chunk)
+		}
+		req.Body, req.ContentLength = io.NopCloser(&body), int64(body.Len())
+		return send(t, req)
+	}
+
+	if resp, body := put(true); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("tampered upload: %s %s", resp.Status, body)
+	}
+	if resp, body := put(false); resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload: %s %s", resp.Status, body)
+	}
+	resp, body := do(t, server, credentials, http.MethodGet, "/files/big", nil, nil)
+	if resp.StatusCode != http.StatusOK || len(body) != 8196 || !bytes.HasSuffix(body, []byte("xtail")) {
+		t.Errorf("get: %s, %d bytes", resp.Status, len(body))
+	}
+}
+
+func sign(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func TestListObjects(t *testing.T) {
+	server := serve(t, s3api.Options{})
+	for _, key := range []string{"files/a.txt", "files/docs/1.txt", "files/docs/2.txt", "files/z.txt", "other/b.txt"} {
+		do(t, server, credentials, http.MethodPut, "/"+key, []byte(key), nil)
+	}
+	do(t, server, credentials, http.MethodDelete, "/files/z.txt", nil, nil)
+
+	list := func(query string) string {
+		t.Helper()
+		resp, body := do(t, server, credentials, http.MethodGet, "/files?"+query, nil, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("list %s: %s %s", query, resp.Status, body)
+		}
+		return string(body)
+	}
+
+	body := list("list-type=2&delimiter=%2F&max-keys=1")
+	if !strings.Contains(body, "<Key>a.txt</Key>") || !strings.Contains(body, "<IsTruncated>true</IsTruncated>") || strings.Contains(body, "docs/") {
+		t.Fatalf("first page: %s", body)
+	}
+	_, token, _ := strings.Cut(body, "<NextContinuationToken>")
+	token, _, _ = strings.Cut(token, "<")
+	body = list("list-type=2&delimiter=%2F&continuation-token=" + token)
+	if !strings.Contains(body, "<CommonPrefixes><Prefix>docs/</Prefix></CommonPrefixes>") || strings.Contains(body, "<Contents>") || !strings.Contains(body, "<KeyCount>1</KeyCount>") {
+		t.Errorf("second page: %s", body)
+	}
+
+	body = list("prefix=docs%2F")
+	if !strings.Contains(body, "<Key>docs/1.txt</Key>") || !strings.Contains(body, "<Key>docs/2.txt</Key>") || !strings.Contains(body, "<Size>16</Size>") {
+		t.Errorf("prefix listing: %s", body)
+	}
+
+	_, body2 := do(t, server, credentials, http.MethodGet, "/", nil, nil)
+	if !strings.Contains(string(body2), "<Name>files</Name>") || !strings.Contains(string(body2), "<Name>other</Name>") {
+		t.Errorf("buckets: %s", body2)
+	}
+	if resp, _ := do(t, server, credentials, http.MethodDelete, "/other", nil, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("delete of a bucket with objects: %s", resp.Status)
+	}
+}
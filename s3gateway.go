@@ -0,0 +1,108 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+
+	"contract-storage-eth/s3api"
+	"contract-storage-eth/transport"
+)
+
+// runS3Gateway serves the S3-compatible API, storing each object as the
+// record BUCKET/KEY through the same path as the saves of serve
+func runS3Gateway(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("s3-gateway", flag.ContinueOnError)
+	address := flags.String("address", config.S3.Address, "listen address")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if config.S3.AccessKey == "" || config.S3.SecretKey == "" {
+		return fail(codeConfig, "Invalid s3 config: access_key and secret_key must be set")
+	}
+	secretKey, err := resolveSecret(config.S3.SecretKey)
+	if err != nil {
+		return fail(codeConfig, "Invalid s3 config: secret_key: %w", err)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	// Listings come from the index, kept synced as for serve
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return fmt.Errorf("Failed to create indexer: %w", err)
+	}
+	go keepIndexSynced(ctx, ix, config.Index.SyncInterval, config.Timeouts.Sync)
+
+	records, err := newRecordService(ctx, config, store, client)
+	if err != nil {
+		return fmt.Errorf("Failed to set up records: %w", err)
+	}
+	if records.signers == nil {
+		if records.signers, err = loadSigners(ctx, config); err != nil {
+			return fail(codeSigner, "Failed to load private key: %w", err)
+		}
+	}
+	if closer, ok := records.signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	server := &http.Server{
+		Addr: *address,
+		Handler: s3api.NewServer(s3api.Options{
+			Records:       records,
+			Index:         store,
+			Namespace:     keyNamespace,
+			AccessKey:     config.S3.AccessKey,
+			SecretKey:     secretKey,
+			Region:        config.S3.Region,
+			Domain:        config.S3.Domain,
+			PublicRead:    config.S3.PublicRead,
+			Tags:          config.Storage.Envelope,
+			MaxObjectSize: config.S3.MaxObjectSize,
+		}),
+	}
+	go shutdownOnDone(ctx, config, server)
+
+	listenOpts, security := serverTransport(config)
+	listener, err := transport.Listen(*address, listenOpts)
+	if err != nil {
+		return fmt.Errorf("Failed to listen: %w", err)
+	}
+	fmt.Printf("Serving S3 API on %s%s\n", *address, security)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("S3 server failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/safe"
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const safeUsage = `Usage: contract-storage-eth safe <command> <safe tx hash>
+
+Commands:
+  status    Show the confirmations and execution of a proposed transaction
+  wait      Wait until a proposed transaction is executed
+`
+
+const safeOrigin = "contract-storage-eth"
+
+// safeAddress returns the configured Safe, or false when transactions are
+// sent directly
+func safeAddress(config *Config) (common.Address, bool, error) {
+	if config.Safe.Address == "" {
+		return common.Address{}, false, nil
+	}
+	if !common.IsHexAddress(config.Safe.Address) {
+		return common.Address{}, false, errors.New("safe.address is not a valid address")
+	}
+	return common.HexToAddress(config.Safe.Address), true, nil
+}
+
+// safeService returns the configured Safe Transaction Service client
+func safeService(config *Config) (*safe.Service, error) {
+	if config.Safe.ServiceURL == "" {
+		return nil, fail(codeConfig, "safe.service_url is not configured")
+	}
+	return safe.NewService(config.Safe.ServiceURL), nil
+}
+
+// proposeSafe signs tx as the proposer and submits it to the Safe, waiting
+// for its execution when configured
+func proposeSafe(ctx context.Context, config *Config, client *chain.Client, proposer signer.Signer, address common.Address, build func(nonce uint64) (*safe.Transaction, error)) error {
+	service, err := safeService(config)
+	if err != nil {
+		return err
+	}
+	hashSigner, ok := proposer.(signer.HashSigner)
+	if !ok {
+		return fail(codeSigner, "The %T signer cannot sign Safe proposals, use a private key, keystore, mnemonic, Vault or KMS key", proposer)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	nonce, err := service.NextNonce(ctx, address)
+	if err != nil {
+		return fmt.Errorf("Failed to get Safe nonce: %w", err)
+	}
+	tx, err := build(nonce)
+	if err != nil {
+		return fmt.Errorf("Failed to build Safe transaction: %w", err)
+	}
+
+	hash := safe.Hash(address, chainID, tx)
+	sig, err := hashSigner.SignHash(ctx, hash[:])
+	if err == nil {
+		sig, err = safe.Signature(sig)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to sign Safe transaction: %w", err)
+	}
+	if err := service.Propose(ctx, address, tx, hash, proposer.Address(), sig, safeOrigin); err != nil {
+		return fmt.Errorf("Failed to propose Safe transaction: %w", err)
+	}
+	fmt.Printf("Proposed Safe transaction %s to %s (nonce %d) from %s\n", hash.Hex(), address.Hex(), tx.Nonce, proposer.Address().Hex())
+
+	if !config.Safe.Wait {
+		fmt.Printf("Run `contract-storage-eth safe wait %s` to follow it until the owners execute it\n", hash.Hex())
+		proposerAddress := proposer.Address()
+		printResult(safeResult{SafeTxHash: hash, Safe: address, Nonce: &tx.Nonce, Proposer: &proposerAddress})
+		return nil
+	}
+	if err := waitSafe(ctx, config, client, service, hash); err != nil {
+		return err
+	}
+	return nil
+}
+
+func runSafe(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, safeUsage)
+		return exitError(2)
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("safe "+command, flag.ContinueOnError)
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || (command != "status" && command != "wait") {
+		fmt.Fprint(os.Stderr, safeUsage)
+		return exitError(2)
+	}
+	raw, err := hexutil.Decode(flags.Arg(0))
+	if err != nil || len(raw) != common.HashLength {
+		return fail(codeInvalidArgument, "Invalid Safe transaction hash: %v", flags.Arg(0))
+	}
+	hash := common.BytesToHash(raw)
+
+	service, err := safeService(config)
+	if err != nil {
+		return err
+	}
+
+	if command == "status" {
+		status, err := service.Status(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("Failed to get Safe transaction: %w", err)
+		}
+		printSafeStatus(hash, status)
+		if status.IsExecuted && status.TransactionHash != nil {
+			fmt.Printf("Executed in transaction %s\n", status.TransactionHash.Hex())
+		}
+		printResult(safeResult{
+			SafeTxHash:            hash,
+			Safe:                  status.Safe,
+			Confirmations:         len(status.Confirmations),
+			ConfirmationsRequired: status.ConfirmationsRequired,
+			Executed:              status.IsExecuted,
+			TxHash:                status.TransactionHash,
+		})
+		return nil
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	if err := waitSafe(ctx, config, client, service, hash); err != nil {
+		return err
+	}
+	return nil
+}
+
+// waitSafe waits for a proposal to be executed and for the execution
+// transaction to be confirmed
+func waitSafe(ctx context.Context, config *Config, client chain.ChainClient, service *safe.Service, hash common.Hash) error {
+	fmt.Printf("Waiting for the Safe owners to execute %s...\n", hash.Hex())
+	status, err := service.Wait(ctx, hash, config.Safe.PollInterval, func(s *safe.Status) {
+		printSafeStatus(hash, s)
+	})
+	if err != nil {
+		return fail(codeTransactionFailed, "Failed to wait for Safe transaction: %w", err)
+	}
+
+	tx, _, err := client.TransactionByHash(ctx, *status.TransactionHash)
+	if err != nil {
+		return fmt.Errorf("Failed to get execution transaction: %w", err)
+	}
+	fmt.Printf("Executed in transaction %s\n", tx.Hash().Hex())
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err != nil {
+		return fail(codeTransactionFailed, "Failed to wait for execution transaction: %w", err)
+	}
+	receipt, err = watchReorg(ctx, client, tx, receipt, config)
+	if err != nil {
+		return fail(codeTransactionFailed, "Failed to watch execution transaction for reorgs: %w", err)
+	}
+
+	// A Safe can report a failed inner call with ExecutionFailure instead of
+	// reverting
+	if receipt.Status != types.ReceiptStatusSuccessful || (status.IsSuccessful != nil && !*status.IsSuccessful) {
+		return fail(codeTransactionFailed, "Safe transaction failed!")
+	}
+	result := safeResult{
+		SafeTxHash:            hash,
+		Safe:                  status.Safe,
+		Confirmations:         len(status.Confirmations),
+		ConfirmationsRequired: status.ConfirmationsRequired,
+		Executed:              true,
+		TxHash:                status.TransactionHash,
+		Block:                 receipt.BlockNumber.Uint64(),
+	}
+	if address, err := safe.CreatedContract(receipt); err == nil {
+		fmt.Printf("Contract address: %s\n", address.Hex())
+		result.Contract = &address
+	}
+	fmt.Printf("Safe transaction executed in block %d\n", receipt.BlockNumber.Uint64())
+	printResult(result)
+	return nil
+}
+
+// safeResult is the result of Safe proposals and of the safe command in
+// JSON output mode
+type safeResult struct {
+	SafeTxHash            common.Hash     `json:"safe_tx_hash"`
+	Safe                  common.Address  `json:"safe"`
+	Nonce                 *uint64         `json:"nonce,omitempty"`
+	Proposer              *common.Address `json:"proposer,omitempty"`
+	Confirmations         int             `json:"confirmations"`
+	ConfirmationsRequired int             `json:"confirmations_required,omitempty"`
+	Executed              bool            `json:"executed"`
+	// TxHash is the execution transaction
+	TxHash   *common.Hash    `json:"tx_hash,omitempty"`
+	Block    uint64          `json:"block,omitempty"`
+	Contract *common.Address `json:"contract,omitempty"`
+}
+
+func printSafeStatus(hash common.Hash, status *safe.Status) {
+	state := "awaiting confirmations"
+	if status.IsExecuted {
+		state = "executed"
+	}
+	fmt.Printf("Safe transaction %s: %d/%d confirmations, %s\n", hash.Hex(), len(status.Confirmations), status.ConfirmationsRequired, state)
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package safe proposes transactions to a Safe (formerly Gnosis Safe)
+// multisig through the Safe Transaction Service, instead of sending them
+// from a single account, and follows them until the owners execute them.
+//
+// Safe contracts v1.3.0 and later are supported; earlier versions hash
+// transactions without the chain id.
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Operations of a Safe transaction.
+const (
+	Call         uint8 = 0
+	DelegateCall uint8 = 1
+)
+
+// DefaultCreateCall is the CreateCall library of the Safe v1.3.0
+// deployments, deployed at the same address on most chains.
+var DefaultCreateCall = common.HexToAddress("0x7cbB62EaA69F79e6873cD1ecB2392971036cFAa4")
+
+var (
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	safeTxTypeHash = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+
+	// ContractCreation(address) of CreateCall, the address is indexed from
+	// v1.4.0 on
+	contractCreationTopic = crypto.Keccak256Hash([]byte("ContractCreation(address)"))
+
+	createCallABI = mustParseABI(`[{"type":"function","name":"performCreate","stateMutability":"nonpayable",
+		"inputs":[{"name":"value","type":"uint256"},{"name":"deploymentData","type":"bytes"}],
+		"outputs":[{"name":"newContract","type":"address"}]}]`)
+)
+
+// Transaction is a Safe transaction. Gas refunds are not used: proposals
+// leave SafeTxGas, BaseGas, GasPrice, GasToken and RefundReceiver zero and
+// the executing owner pays for gas.
+type Transaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      uint8
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          uint64
+}
+
+// NewCall returns a transaction calling to with data.
+func NewCall(to common.Address, data []byte, nonce uint64) *Transaction {
+	return &Transaction{To: to, Value: new(big.Int), Data: data, Operation: Call, SafeTxGas: new(big.Int), BaseGas: new(big.Int), GasPrice: new(big.Int), Nonce: nonce}
+}
+
+// NewCreate returns a transaction deploying code from the Safe itself, by
+// delegating a call to the CreateCall library.
+func NewCreate(createCall common.Address, code []byte, nonce uint64) (*Transaction, error) {
+	data, err := createCallABI.Pack("performCreate", new(big.Int), code)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewCall(createCall, data, nonce)
+	tx.Operation = DelegateCall
+	return tx, nil
+}
+
+// Hash returns the EIP-712 hash of tx for the Safe at safe on chainID,
+// which owners sign to approve it.
+func Hash(safe common.Address, chainID *big.Int, tx *Transaction) common.Hash {
+	domain := crypto.Keccak256(domainTypeHash[:], word(chainID), common.LeftPadBytes(safe[:], 32))
+	message := crypto.Keccak256(
+		safeTxTypeHash[:],
+		common.LeftPadBytes(tx.To[:], 32),
+		word(tx.Value),
+		crypto.Keccak256(tx.Data),
+		word(new(big.Int).SetUint64(uint64(tx.Operation))),
+		word(tx.SafeTxGas),
+		word(tx.BaseGas),
+		word(tx.GasPrice),
+		common.LeftPadBytes(tx.GasToken[:], 32),
+		common.LeftPadBytes(tx.RefundReceiver[:], 32),
+		word(new(big.Int).SetUint64(tx.Nonce)),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain, message)
+}
+
+// Signature converts a [R || S || V] signature with V 0 or 1 to the owner
+// signature format of Safe, where V is 27 or 28.
+func Signature(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("signature has %d bytes, want %d", len(sig), crypto.SignatureLength)
+	}
+	out := append([]byte{}, sig...)
+	if out[64] < 27 {
+		out[64] += 27
+	}
+	return out, nil
+}
+
+// CreatedContract returns the address of the contract deployed by a
+// NewCreate transaction, from the receipt of its execution.
+func CreatedContract(receipt *types.Receipt) (common.Address, error) {
+	for _, l := range receipt.Logs {
+		if len(l.Topics) == 0 || l.Topics[0] != contractCreationTopic {
+			continue
+		}
+		if len(l.Topics) > 1 {
+			return common.BytesToAddress(l.Topics[1][:]), nil
+		}
+		if len(l.Data) == 32 {
+			return common.BytesToAddress(l.Data), nil
+		}
+	}
+	return common.Address{}, errors.New("no ContractCreation event in the receipt")
+}
+
+func word(n *big.Int) []byte {
+	if n == nil {
+		n = new(big.Int)
+	}
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safe_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/safe"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var (
+	safeAddress = common.HexToAddress("0x5afe5afe5afe5afe5afe5afe5afe5afe5afe5afe")
+	contract    = common.HexToAddress("0x3A220f351252089D385b29beca14e27F204c296A")
+)
+
+// TestHash checks the Safe transaction hash against the generic EIP-712
+// implementation of go-ethereum.
+func TestHash(t *testing.T) {
+	create, err := safe.NewCreate(safe.DefaultCreateCall, []byte{0x60, 0x80, 0x60, 0x40, 0x52}, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tx := range map[string]*safe.Transaction{
+		"call":   safe.NewCall(contract, []byte("save call data"), 3),
+		"create": create,
+	} {
+		typed := apitypes.TypedData{
+			Types: apitypes.Types{
+				"EIP712Domain": {{Name: "chainId", Type: "uint256"}, {Name: "verifyingContract", Type: "address"}},
+				"SafeTx": {
+					{Name: "to", Type: "address"}, {Name: "value", Type: "uint256"}, {Name: "data", Type: "bytes"},
+					{Name: "operation", Type: "uint8"}, {Name: "safeTxGas", Type: "uint256"}, {Name: "baseGas", Type: "uint256"},
+					{Name: "gasPrice", Type: "uint256"}, {Name: "gasToken", Type: "address"}, {Name: "refundReceiver", Type: "address"},
+					{Name: "nonce", Type: "uint256"},
+				},
+			},
+			PrimaryType: "SafeTx",
+			Domain:      apitypes.TypedDataDomain{ChainId: math.NewHexOrDecimal256(1337), VerifyingContract: safeAddress.Hex()},
+			Message: apitypes.TypedDataMessage{
+				"to": tx.To.Hex(), "value": tx.Value.String(), "data": hexutil.Encode(tx.Data),
+				"operation": hexutil.EncodeUint64(uint64(tx.Operation)), "safeTxGas": "0", "baseGas": "0", "gasPrice": "0",
+				"gasToken": tx.GasToken.Hex(), "refundReceiver": tx.RefundReceiver.Hex(), "nonce": hexutil.EncodeUint64(tx.Nonce),
+			},
+		}
+		want, _, err := apitypes.TypedDataAndHash(typed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := safe.Hash(safeAddress, big.NewInt(1337), tx); got != common.BytesToHash(want) {
+			t.Errorf("%s: hash %s, want %x", name, got.Hex(), want)
+		}
+	}
+}
+
+// TestPropose checks the requests sent to the Safe Transaction Service.
+func TestPropose(t *testing.T) {
+	var proposal map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/safes/{address}/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"address":"`+safeAddress.Hex()+`","nonce":"4","threshold":2}`)
+	})
+	mux.HandleFunc("GET /api/v1/safes/{address}/multisig-transactions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("nonce__gte") != "4" || r.URL.Query().Get("executed") != "false" {
+			t.Errorf("queued proposals query %s", r.URL.RawQuery)
+		}
+		io.WriteString(w, `{"count":2,"results":[{"nonce":5},{"nonce":4}]}`)
+	})
+	mux.HandleFunc("POST /api/v1/safes/{address}/multisig-transactions/", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&proposal); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	service := safe.NewService(server.URL)
+	nonce, err := service.NextNonce(ctx, safeAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 6 {
+		t.Errorf("next nonce %d, want 6 after the queued proposals", nonce)
+	}
+
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := safe.NewCall(contract, []byte("save call data"), nonce)
+	hash := safe.Hash(safeAddress, big.NewInt(1337), tx)
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig, err = safe.Signature(sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Propose(ctx, safeAddress, tx, hash, crypto.PubkeyToAddress(key.PublicKey), sig, "test"); err != nil {
+		t.Fatal(err)
+	}
+	fixtures.GoldenJSON(t, "proposal", proposal)
+}
+
+func TestCreatedContract(t *testing.T) {
+	created := common.HexToAddress("0x00000000000000000000000000000000000c0de0")
+	topic := crypto.Keccak256Hash([]byte("ContractCreation(address)"))
+	for name, l := range map[string]*types.Log{
+		"v1.3.0": {Topics: []common.Hash{topic}, Data: common.LeftPadBytes(created[:], 32)},
+		"v1.4.1": {Topics: []common.Hash{topic, common.BytesToHash(created[:])}},
+	} {
+		got, err := safe.CreatedContract(&types.Receipt{Logs: []*types.Log{{Topics: []common.Hash{{}}}, l}})
+		if err != nil || got != created {
+			t.Errorf("%s: got %s, %v", name, got.Hex(), err)
+		}
+	}
+	if _, err := safe.CreatedContract(&types.Receipt{}); err == nil {
+		t.Error("no error without a ContractCreation event")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Service is a client of the Safe Transaction Service API.
+type Service struct {
+	// URL of the service for the chain, such as
+	// https://safe-transaction-mainnet.safe.global.
+	URL string
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+}
+
+// NewService returns a client of the service at url.
+func NewService(url string) *Service {
+	return &Service{URL: strings.TrimRight(url, "/"), HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// number decodes the integers of the service, sent as JSON numbers or
+// strings depending on its version
+type number uint64
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	*n = number(v)
+	return err
+}
+
+// NextNonce returns the nonce for a new proposal: the nonce of the Safe,
+// or the one following the proposals already queued.
+func (s *Service) NextNonce(ctx context.Context, safe common.Address) (uint64, error) {
+	var info struct {
+		Nonce number `json:"nonce"`
+	}
+	if err := s.request(ctx, http.MethodGet, "/api/v1/safes/"+safe.Hex()+"/", nil, &info); err != nil {
+		return 0, err
+	}
+	var queued struct {
+		Results []struct {
+			Nonce number `json:"nonce"`
+		} `json:"results"`
+	}
+	path := fmt.Sprintf("/api/v1/safes/%s/multisig-transactions/?executed=false&nonce__gte=%d&ordering=-nonce&limit=1", safe.Hex(), info.Nonce)
+	if err := s.request(ctx, http.MethodGet, path, nil, &queued); err != nil {
+		return 0, err
+	}
+	if len(queued.Results) > 0 {
+		return uint64(queued.Results[0].Nonce) + 1, nil
+	}
+	return uint64(info.Nonce), nil
+}
+
+// Propose submits tx for approval by the owners of safe. sender must be an
+// owner or a delegate of the Safe, and signature its signature of hash in
+// the format returned by Signature.
+func (s *Service) Propose(ctx context.Context, safe common.Address, tx *Transaction, hash common.Hash, sender common.Address, signature []byte, origin string) error {
+	body := map[string]interface{}{
+		"safe":                    safe.Hex(),
+		"to":                      tx.To.Hex(),
+		"value":                   tx.Value.String(),
+		"data":                    hexutil.Encode(tx.Data),
+		"operation":               tx.Operation,
+		"safeTxGas":               tx.SafeTxGas.String(),
+		"baseGas":                 tx.BaseGas.String(),
+		"gasPrice":                tx.GasPrice.String(),
+		"gasToken":                tx.GasToken.Hex(),
+		"refundReceiver":          tx.RefundReceiver.Hex(),
+		"nonce":                   tx.Nonce,
+		"contractTransactionHash": hash.Hex(),
+		"sender":                  sender.Hex(),
+		"signature":               hexutil.Encode(signature),
+		"origin":                  origin,
+	}
+	return s.request(ctx, http.MethodPost, "/api/v1/safes/"+safe.Hex()+"/multisig-transactions/", body, nil)
+}
+
+// Status is the state of a proposed transaction.
+type Status struct {
+	Safe                  common.Address `json:"safe"`
+	ConfirmationsRequired int            `json:"confirmationsRequired"`
+	Confirmations         []struct {
+		Owner common.Address `json:"owner"`
+	} `json:"confirmations"`
+	IsExecuted   bool  `json:"isExecuted"`
+	IsSuccessful *bool `json:"isSuccessful"`
+	// TransactionHash is the hash of the execution transaction.
+	TransactionHash *common.Hash `json:"transactionHash"`
+}
+
+// Status returns the state of the proposal with the given Safe
+// transaction hash.
+func (s *Service) Status(ctx context.Context, hash common.Hash) (*Status, error) {
+	var status Status
+	if err := s.request(ctx, http.MethodGet, "/api/v1/multisig-transactions/"+hash.Hex()+"/", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Wait polls the proposal until it is executed, calling onChange whenever
+// its number of confirmations changes.
+func (s *Service) Wait(ctx context.Context, hash common.Hash, interval time.Duration, onChange func(*Status)) (*Status, error) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	seen := -1
+	for {
+		status, err := s.Status(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if onChange != nil && len(status.Confirmations) != seen {
+			onChange(status)
+			seen = len(status.Confirmations)
+		}
+		if status.IsExecuted && status.TransactionHash != nil {
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// request calls the service and decodes the JSON response into out
+func (s *Service) request(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		if len(data) > 0 {
+			return fmt.Errorf("safe transaction service: %s: %s", resp.Status, bytes.TrimSpace(data))
+		}
+		return fmt.Errorf("safe transaction service: %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("safe transaction service: invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/metrics"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/safe"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tracing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func runSave(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("save", flag.ContinueOnError)
+	key := flags.String("key", "", "record key")
+	field := flags.String("field", "", "record field")
+	value := flags.String("value", "", "value to store")
+	valueFile := flags.String("value-file", "", "file whose content to store instead of --value")
+	valueHex := flags.String("value-hex", "", "binary value to store, hex encoded")
+	valueBase64 := flags.String("value-base64", "", "binary value to store, base64 encoded")
+	binary := flags.Bool("binary", false, "store the value as bytes even if it is valid text")
+	supersedes := flags.String("supersedes", "", "record this one replaces, as key[#field][@version]")
+	ttl := flags.Duration("ttl", 0, "let the record expire after this long, for prune to delete it")
+	expiresAt := flags.String("expires-at", "", "let the record expire at this time, RFC 3339 or a UTC date")
+	tags := tagFlag{}
+	flags.Var(tags, "tag", "tag the record with NAME=VALUE (repeatable)")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *key == "" {
+		return fail(codeInvalidArgument, "save: --key is required")
+	}
+	content := []byte(*value)
+	switch {
+	case *valueFile != "":
+		var err error
+		if content, err = os.ReadFile(*valueFile); err != nil {
+			return fmt.Errorf("Failed to read value file: %w", err)
+		}
+	case *valueHex != "":
+		var err error
+		if content, err = decodeBinary(*valueHex, encodingHex); err != nil {
+			return fail(codeInvalidArgument, "Invalid --value-hex: %w", err)
+		}
+		*binary = true
+	case *valueBase64 != "":
+		var err error
+		if content, err = decodeBinary(*valueBase64, encodingBase64); err != nil {
+			return fail(codeInvalidArgument, "Invalid --value-base64: %w", err)
+		}
+		*binary = true
+	}
+	codec, err := checkSchema(config, *key, *field, content)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid value: %w", err)
+	}
+	if *binary {
+		codec = envelope.CodecBytes
+	}
+	*key = keyNamespace.Key(*key)
+
+	meta := indexer.TagMeta(tags)
+	if *supersedes != "" {
+		ref, err := indexer.ParseRef(*supersedes, *field)
+		if err != nil {
+			return fail(codeInvalidArgument, "Invalid --supersedes: %w", err)
+		}
+		ref.Key = keyNamespace.Key(ref.Key)
+		meta[indexer.MetaSupersedes] = ref.String()
+	}
+	expiry, err := recordExpiry(*ttl, *expiresAt)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid flags: %w", err)
+	}
+	if !expiry.IsZero() {
+		maps.Copy(meta, indexer.ExpiryMeta(expiry))
+	}
+	sealed, err := sealValueAs(config, content, meta, codec)
+	if err != nil {
+		return fmt.Errorf("Failed to seal value: %w", err)
+	}
+	// The index reads the expiry from the envelope, which a pointer hides
+	target := offloadTarget(config, sealed)
+	if !expiry.IsZero() && target != "" {
+		return fmt.Errorf("The value is %d bytes, more than %s.store_above, and values stored off chain cannot expire", len(content), target)
+	}
+	if sealed, err = offloadValue(ctx, config, sealed); err != nil {
+		return fmt.Errorf("Failed to store value off chain: %w", err)
+	}
+	// Large values are written as chunks, then the manifest in place of
+	// the value
+	writes := splitValue(config, *field, sealed)
+	chunks := writes[:len(writes)-1]
+	sealed = writes[len(writes)-1].Value
+
+	safeAddr, viaSafe, err := safeAddress(config)
+	if err != nil {
+		return err
+	}
+	// The index reads the expiry from the envelope, which a manifest hides
+	if !expiry.IsZero() && len(chunks) > 0 {
+		return fmt.Errorf("The value is %d bytes, more than storage.chunk_size, and chunked values cannot expire", len(content))
+	}
+	if viaSafe && len(chunks) > 0 {
+		return fmt.Errorf("The value is %d bytes, more than storage.chunk_size, and chunked values cannot be proposed to a Safe", len(content))
+	}
+
+	// Keep writes in order while earlier ones wait for the contract
+	if config.WriteQueue.Enabled && !viaSafe {
+		queue, err := loadQueue(config)
+		if err != nil {
+			return fmt.Errorf("Failed to load write queue: %w", err)
+		}
+		if len(queue) > 0 {
+			if err := queueChunks(config, *key, chunks, "queued behind earlier writes"); err != nil {
+				return err
+			}
+			if err := queueSave(config, queuedWrite{Key: *key, Field: *field, Value: sealed, Reason: "queued behind earlier writes"}); err != nil {
+				return err
+			}
+			return nil
+		}
+	}
+
+	address, err := contractAddress(config)
+	if err != nil {
+		return err
+	}
+	for _, network := range config.Replication.Networks {
+		if _, err := replicaConfig(config, network); err != nil {
+			return err
+		}
+	}
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := signers.(io.Closer); ok {
+		defer closer.Close()
+	}
+	activeSigner, err := signers.Active(ctx)
+	if err != nil {
+		return fail(codeSigner, "No signer available: %w", err)
+	}
+	parsedABI, err := storage.ABI()
+	if err != nil {
+		return fmt.Errorf("Failed to parse ABI: %w", err)
+	}
+
+	// Let the Safe owners send it instead, from the Safe
+	if viaSafe {
+		return proposeSafe(ctx, config, client, activeSigner, safeAddr, func(nonce uint64) (*safe.Transaction, error) {
+			data, err := parsedABI.Pack("save", *key, *field, sealed)
+			if err != nil {
+				return nil, err
+			}
+			return safe.NewCall(address, data, nonce), nil
+		})
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	auth, err := signer.NewTransactOpts(ctx, activeSigner, chainID)
+	if err != nil {
+		return fail(codeSigner, "Failed to create auth: %w", err)
+	}
+
+	contract := bind.NewBoundContract(address, parsedABI, client, client, client)
+
+	err = saveChunks(ctx, client, contract, auth, config, address, *key, chunks)
+	var tx *types.Transaction
+	if err == nil {
+		tx, err = sendSave(ctx, client, contract, auth, config, *key, *field, sealed)
+	}
+	if err != nil && config.WriteQueue.Enabled && chain.IsReverted(err) {
+		fmt.Printf("Contract rejected the write, it may be paused or being upgraded: %v\n", err)
+		if err := queueChunks(config, *key, chunks, err.Error()); err != nil {
+			return err
+		}
+		if err := queueSave(config, queuedWrite{Key: *key, Field: *field, Value: sealed, Reason: err.Error()}); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to call save function: %w", err)
+	}
+	fmt.Printf("Save transaction: %s\n", tx.Hash().Hex())
+	if link := explorerURL(config, "tx/"+tx.Hash().Hex()); link != "" {
+		fmt.Printf("Explorer: %s\n", link)
+	}
+	trackPending(config, "save", activeSigner.Address(), address, tx)
+
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err != nil {
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to wait for save transaction: %w", err)
+	}
+	receipt, err = watchReorg(ctx, client, tx, receipt, config)
+	if err != nil {
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to watch save transaction for reorgs: %w", err)
+	}
+	clearPending(config)
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fail(codeTransactionFailed, "Save function call failed!")
+	}
+	ns := recordid.Namespace{ChainID: chainID.Uint64(), Contract: address}
+	id := ns.ID(*key, *field, 0)
+	fmt.Printf("Saved %s in block %d\n", id, receipt.BlockNumber.Uint64())
+
+	result := saveResult{ID: id.String(), TxHash: tx.Hash(), Block: receipt.BlockNumber.Uint64(), GasUsed: receipt.GasUsed}
+	if len(chunks) > 0 {
+		result.Chunks = len(chunks)
+		fmt.Printf("Stored in %d chunk(s)\n", len(chunks))
+	}
+	if len(config.Replication.Networks) > 0 {
+		if result.LaggingReplicas, err = replicateValue(ctx, config, ns, *key, *field, sealed, chunks); err != nil {
+			return err
+		}
+	}
+	printResult(result)
+	return nil
+}
+
+// replicateValue writes the chunks of a value, then the value, to the
+// replica networks, and returns the networks that failed any of them
+func replicateValue(ctx context.Context, config *Config, ns recordid.Namespace, key, field, value string, chunks []storedWrite) (map[string]string, error) {
+	writes := make([]replicaWrite, 0, len(chunks)+1)
+	for _, c := range chunks {
+		writes = append(writes, replicaWrite{Key: key, Field: c.Field, Value: c.Value, Source: ns.ID(key, c.Field, 0).String()})
+	}
+	writes = append(writes, replicaWrite{Key: key, Field: field, Value: value, Source: ns.ID(key, field, 0).String()})
+
+	var lagging map[string]string
+	for _, w := range writes {
+		pending, err := replicate(ctx, config, w)
+		if err != nil {
+			return nil, err
+		}
+		for network, reason := range pending {
+			if lagging == nil {
+				lagging = map[string]string{}
+			}
+			// The first failure is the telling one
+			if _, ok := lagging[network]; !ok {
+				lagging[network] = reason
+			}
+		}
+	}
+	return lagging, nil
+}
+
+// queueChunks queues the chunks of a value ahead of its manifest
+func queueChunks(config *Config, key string, chunks []storedWrite, reason string) error {
+	for _, c := range chunks {
+		if _, err := enqueueWrite(config, queuedWrite{Key: key, Field: c.Field, Value: c.Value, Reason: reason}); err != nil {
+			return fmt.Errorf("Failed to queue write: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveResult is the result of save in JSON output mode
+type saveResult struct {
+	ID      string      `json:"id"`
+	TxHash  common.Hash `json:"tx_hash"`
+	Block   uint64      `json:"block"`
+	GasUsed uint64      `json:"gas_used"`
+	// Chunks is the number of chunks a large value was split in
+	Chunks int `json:"chunks,omitempty"`
+	// LaggingReplicas maps the replica networks that failed to their error
+	LaggingReplicas map[string]string `json:"lagging_replicas,omitempty"`
+}
+
+// queuedResult is the result of a queued save in JSON output mode
+type queuedResult struct {
+	Queued  bool   `json:"queued"`
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Reason  string `json:"reason"`
+	Queue   string `json:"queue"`
+	Waiting int    `json:"waiting"`
+}
+
+// queueSave queues a write for `queue drain`
+func queueSave(config *Config, w queuedWrite) error {
+	n, err := enqueueWrite(config, w)
+	if err != nil {
+		return fmt.Errorf("Failed to queue write: %w", err)
+	}
+	fmt.Printf("Queued %s#%s in %s (%d write(s) waiting)\n", w.Key, w.Field, queuePath(config), n)
+	fmt.Println("Run `contract-storage-eth queue drain` to send them once the contract accepts writes again")
+	printResult(queuedResult{Queued: true, Key: w.Key, Field: w.Field, Reason: w.Reason, Queue: queuePath(config), Waiting: n})
+	return nil
+}
+
+// sealValue wraps value in an envelope when enabled in the configuration,
+// compressing and encrypting it as configured. Metadata, compression and
+// encryption can only be stored inside an envelope. Values that are not
+// UTF-8 text are sealed as bytes.
+func sealValue(config *Config, value []byte, meta map[string]string) (string, error) {
+	return sealValueAs(config, value, meta, contentCodec(value))
+}
+
+// sealValueAs is sealValue with the codec of the value given
+func sealValueAs(config *Config, value []byte, meta map[string]string, codec envelope.Codec) (string, error) {
+	if !config.Storage.Envelope {
+		if config.Storage.Encryption.Enabled {
+			return "", errors.New("storage.encryption requires storage.envelope")
+		}
+		if c := config.Storage.Compression; c != "" && c != "none" {
+			return "", errors.New("storage.compression requires storage.envelope")
+		}
+		if len(meta) > 0 {
+			return "", errors.New("record metadata requires storage.envelope")
+		}
+		return string(value), nil
+	}
+
+	hashAlg, err := envelope.ParseHashAlg(config.Storage.HashAlg)
+	if err != nil {
+		return "", err
+	}
+	compression, err := envelope.ParseCompression(config.Storage.Compression)
+	if err != nil {
+		return "", err
+	}
+	key, err := valueKey()
+	if err != nil {
+		return "", err
+	}
+	return envelope.Seal(value, envelope.Options{
+		Codec:         codec,
+		HashAlg:       hashAlg,
+		Meta:          meta,
+		Compression:   compression,
+		CompressAbove: config.Storage.CompressAbove,
+		Key:           key,
+	})
+}
+
+// sendSave calls save(key, field, value) on the contract, signing again
+// with a fresh nonce if it was already used. A nonce set in auth is the
+// caller's to manage, so it is not retried
+func sendSave(ctx context.Context, client *chain.Client, contract storage.ContractBinder, auth *bind.TransactOpts, config *Config, key, field, value string) (*types.Transaction, error) {
+	txCtx, cancel := withTimeout(ctx, config.Timeouts.Transaction)
+	defer cancel()
+	txCtx, span := tracing.Start(txCtx, "contract.save", attribute.String("record.key", key), attribute.String("record.field", field), attribute.Int("record.value_bytes", len(value)))
+
+	var tx *types.Transaction
+	retriable := chain.IsNonceTooLow
+	if auth.Nonce != nil {
+		retriable = func(error) bool { return false }
+	}
+	err := chain.Retry(txCtx, client.Policy(), retriable, func(ctx context.Context) error {
+		auth.Context = ctx
+		if err := setFees(ctx, config, client, auth); err != nil {
+			return err
+		}
+		var err error
+		tx, err = contract.Transact(auth, "save", key, field, value)
+		return err
+	})
+	if err == nil {
+		metrics.TransactionsSent.WithLabelValues("save").Inc()
+		span.SetAttributes(attribute.String("tx.hash", tx.Hash().Hex()), attribute.Int64("tx.gas_limit", int64(tx.Gas())))
+	}
+	tracing.End(span, err)
+	return tx, err
+}
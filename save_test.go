@@ -0,0 +1,66 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/recordid"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestReplicateValue(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{Network: "main"}
+	config.Networks = map[string]NetworkConfig{"main": {}, "asia": {}}
+	// main is skipped as the source, asia sets no contract and europe is
+	// not configured
+	config.Replication.Networks = []string{"main", "asia", "europe"}
+	config.Replication.File = filepath.Join(dir, "replication.json")
+	ns := recordid.Namespace{ChainID: 1337, Contract: common.HexToAddress("0x01")}
+	chunks := []storedWrite{{Field: "doc#0", Value: "a"}, {Field: "doc#1", Value: "b"}}
+
+	lagging, err := replicateValue(context.Background(), config, ns, "key", "doc", "manifest", chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lagging) != 2 || !strings.Contains(lagging["asia"], "contract_address") || !strings.Contains(lagging["europe"], "not configured") {
+		t.Errorf("lagging = %v, want asia and europe", lagging)
+	}
+	writes, err := loadReplication(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, w := range writes {
+		fields = append(fields, w.Field)
+		if len(w.Pending) != 2 {
+			t.Errorf("%s pending on %v, want asia and europe", w.Field, w.Pending)
+		}
+	}
+	if got := strings.Join(fields, ","); got != "doc#0,doc#1,doc" {
+		t.Errorf("kept writes %s, want the chunks then the value", got)
+	}
+
+	// A chunk that cannot be kept for a retry fails the save
+	config.Replication.File = dir
+	if _, err := replicateValue(context.Background(), config, ns, "key", "doc", "manifest", chunks); err == nil {
+		t.Error("replicateValue succeeded without a replication file")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema validates JSON document values against JSON Schemas before
+// they are written, so that malformed records are rejected before any gas
+// is spent on them.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Rule applies the JSON Schema in File to the values of the records whose
+// key starts with KeyPrefix and whose field is Field, any field when Field
+// is empty.
+type Rule struct {
+	KeyPrefix string `yaml:"key_prefix"`
+	Field     string `yaml:"field"`
+	File      string `yaml:"file"`
+}
+
+func (r Rule) matches(key, field string) bool {
+	return strings.HasPrefix(key, r.KeyPrefix) && (r.Field == "" || r.Field == field)
+}
+
+// Set holds the compiled schemas of rules, the first matching rule
+// applying to a record.
+type Set struct {
+	rules   []Rule
+	schemas []*jsonschema.Schema
+}
+
+// Load compiles the schema of each rule. Schemas may use any draft from 4
+// to 2020-12, draft 2020-12 when they do not say, and reference other
+// files by relative path.
+func Load(rules []Rule) (*Set, error) {
+	s := &Set{rules: rules}
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	for _, r := range rules {
+		if r.File == "" {
+			return nil, fmt.Errorf("schema: rule for %q#%q has no file", r.KeyPrefix, r.Field)
+		}
+		compiled, err := compiler.Compile(r.File)
+		if err != nil {
+			return nil, fmt.Errorf("schema: %w", err)
+		}
+		s.schemas = append(s.schemas, compiled)
+	}
+	return s, nil
+}
+
+// Error is a value rejected by a schema.
+type Error struct {
+	// Schema is the file of the schema.
+	Schema string
+	// Problems describe what is wrong, each naming the JSON pointer of the
+	// part of the document at fault.
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("value does not match schema %s: %s", e.Schema, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks a value against the schema of the first rule matching its
+// key and field. It reports whether one applies, and returns an *Error when
+// the value is not a JSON document valid against it.
+func (s *Set) Validate(key, field string, value []byte) (bool, error) {
+	for i, r := range s.rules {
+		if !r.matches(key, field) {
+			continue
+		}
+		doc, err := decode(value)
+		if err != nil {
+			return true, &Error{Schema: r.File, Problems: []string{"not a JSON document: " + err.Error()}}
+		}
+		if err := s.schemas[i].Validate(doc); err != nil {
+			verr, ok := err.(*jsonschema.ValidationError)
+			if !ok {
+				return true, err
+			}
+			found := problems(verr)
+			// Properties are checked in no particular order
+			sort.Strings(found)
+			return true, &Error{Schema: r.File, Problems: found}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// decode parses one JSON document, keeping numbers exact
+func decode(value []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("data after the document")
+	}
+	return doc, nil
+}
+
+// problems returns the innermost errors of a validation error, which say
+// what is wrong rather than which keyword failed
+func problems(verr *jsonschema.ValidationError) []string {
+	if len(verr.Causes) == 0 {
+		location := verr.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{location + ": " + verr.Message}
+	}
+	var out []string
+	for _, cause := range verr.Causes {
+		out = append(out, problems(cause)...)
+	}
+	return out
+}
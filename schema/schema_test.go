@@ -0,0 +1,65 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/schema"
+)
+
+func TestValidate(t *testing.T) {
+	set, err := schema.Load([]schema.Rule{
+		{KeyPrefix: "invoice-", Field: "data", File: "testdata/invoice.json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		key, field, value string
+		applies           bool
+		problems          string
+	}{
+		{"invoice-42", "data", `{"number": "INV-42", "amount": 12.5, "currency": "EUR"}`, true, ""},
+		{"invoice-42", "pdf", `not json`, false, ""},
+		{"order-1", "data", `not json`, false, ""},
+		{"invoice-42", "data", `{"number": "INV-42", "amount": 1} {}`, true, "not a JSON document: data after the document"},
+		{"invoice-42", "data", `{"number": "42", "amount": -1, "extra": true}`, true,
+			"/: additionalProperties 'extra' not allowed; /amount: must be >= 0 but found -1; /number: does not match pattern '^INV-[0-9]+$'"},
+		{"invoice-43", "data", `{"amount": "12"}`, true, "/: missing properties: 'number'; /amount: expected number, but got string"},
+	} {
+		applies, err := set.Validate(tt.key, tt.field, []byte(tt.value))
+		if applies != tt.applies {
+			t.Errorf("%s#%s: applies %v, want %v", tt.key, tt.field, applies, tt.applies)
+		}
+		var serr *schema.Error
+		switch {
+		case tt.problems == "" && err != nil:
+			t.Errorf("%s: %v", tt.value, err)
+		case tt.problems == "":
+		case !errors.As(err, &serr):
+			t.Errorf("%s: got %v, want a schema error", tt.value, err)
+		case strings.Join(serr.Problems, "; ") != tt.problems:
+			t.Errorf("%s: got problems\n%s\nwant\n%s", tt.value, strings.Join(serr.Problems, "; "), tt.problems)
+		}
+	}
+
+	if _, err := schema.Load([]schema.Rule{{File: "testdata/missing.json"}}); err == nil {
+		t.Error("loaded a missing schema")
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sync"
+
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/schema"
+)
+
+// valueSchemas holds the compiled schemas of storage.schemas, loaded on
+// first use
+var valueSchemas struct {
+	once sync.Once
+	set  *schema.Set
+	err  error
+}
+
+// checkSchema validates a value against the schema of storage.schemas its
+// key and field match, if any, and returns the codec to seal it with: JSON
+// for the documents a schema applies to
+func checkSchema(config *Config, key, field string, value []byte) (envelope.Codec, error) {
+	valueSchemas.once.Do(func() {
+		valueSchemas.set, valueSchemas.err = schema.Load(config.Storage.Schemas)
+	})
+	if valueSchemas.err != nil {
+		return 0, valueSchemas.err
+	}
+	applies, err := valueSchemas.set.Validate(key, field, value)
+	if err != nil {
+		return 0, err
+	}
+	if applies {
+		return envelope.CodecJSON, nil
+	}
+	return contentCodec(value), nil
+}
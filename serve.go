@@ -0,0 +1,374 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/canary"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/idempotency"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/metrics"
+	"contract-storage-eth/rpc"
+	"contract-storage-eth/transport"
+	"contract-storage-eth/webhook"
+)
+
+func runServe(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	address := flags.String("address", config.Server.Address, "listen address")
+	grpcAddress := flags.String("grpc-address", config.Server.GRPCAddress, "listen address of the gRPC API, none when empty")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	if err := checkIndexChain(ctx, client, store); err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	ix, err := newIndexer(config, client, store)
+	if err != nil {
+		return fmt.Errorf("Failed to create indexer: %w", err)
+	}
+	go keepIndexSynced(ctx, ix, config.Index.SyncInterval, config.Timeouts.Sync)
+
+	keyring, err := loadWebhookKeyring(config)
+	if err != nil {
+		return fmt.Errorf("Failed to load webhook secrets: %w", err)
+	}
+
+	dispatcher, err := newDispatcher(config, keyring)
+	if err != nil {
+		return fmt.Errorf("Failed to open webhook dead letter queue: %w", err)
+	}
+
+	tenancy, err := loadTenancy(config)
+	if err != nil {
+		return fail(codeConfig, "Invalid billing config: %w", err)
+	}
+
+	estimator, err := newEstimator(config, client, config.Estimate.From)
+	if err != nil {
+		return fail(codeConfig, "Invalid estimate config: %w", err)
+	}
+	estimate, err := saveEstimator(config, estimator)
+	if err != nil {
+		return fmt.Errorf("Failed to set up estimates: %w", err)
+	}
+
+	var runner *canary.Runner
+	if config.Canary.Enabled {
+		if runner, err = startCanary(ctx, config, client); err != nil {
+			return err
+		}
+	}
+
+	records, err := newRecordService(ctx, config, store, client)
+	if err != nil {
+		return fmt.Errorf("Failed to set up records: %w", err)
+	}
+
+	if records.cache, err = openCache(ctx, config, records.ns); err != nil {
+		return fmt.Errorf("Failed to connect to Redis: %w", err)
+	}
+	if records.cache != nil {
+		defer records.cache.Close()
+		go invalidateCache(ctx, config, store, records.cache)
+	}
+
+	// Retried saves are answered from the idempotency store, keyed by
+	// contract so that networks can share it
+	var writes api.Records = records
+	if config.Server.Idempotency.File != "" && records.signers != nil {
+		keys, err := idempotency.Open(config.Server.Idempotency.File, config.Server.Idempotency.TTL)
+		if err != nil {
+			return fmt.Errorf("Failed to open idempotency store: %w", err)
+		}
+		defer keys.Close()
+		writes = idempotency.Records(records, keys, records.ns.String())
+	}
+
+	// Saves signed by other accounts are relayed through the forwarder,
+	// from the signer of the /records writes
+	var relayer api.Relayer
+	if config.Forwarder.Address != "" && records.signers != nil {
+		f, err := loadForwarder(config, new(big.Int).SetUint64(records.ns.ChainID))
+		if err != nil {
+			return err
+		}
+		relayer = &metaRelayer{records: records, forwarder: f}
+	}
+
+	registerGauges(config, store, dispatcher)
+
+	if len(config.Webhook.URLs) > 0 {
+		cursor, err := loadWebhookCursor(config, store)
+		if err != nil {
+			return fmt.Errorf("Failed to load webhook cursor: %w", err)
+		}
+		go notifyWebhooks(ctx, config, store, dispatcher, records.ns, cursor)
+	}
+
+	server := &http.Server{
+		Addr: *address,
+		Handler: api.NewServer(api.Options{
+			Index:           store,
+			Tenancy:         tenancy,
+			AdminToken:      config.Server.AdminToken,
+			Keyring:         keyring,
+			RotationOverlap: config.Webhook.RotationOverlap,
+			Dispatcher:      dispatcher,
+			Estimate:        estimate,
+			Canary:          runner,
+			Records:         writes,
+			Relayer:         relayer,
+			WriteToken:      config.Server.WriteToken,
+			Namespace:       records.ns,
+			PollInterval:    config.Index.SyncInterval,
+			Checks:          readinessChecks(client, records),
+			Metrics:         metrics.Handler(),
+			AllowedOrigins:  config.Server.AllowedOrigins,
+		}),
+	}
+	go shutdownOnDone(ctx, config, server)
+
+	listenOpts, security := serverTransport(config)
+	listener, err := transport.Listen(*address, listenOpts)
+	if err != nil {
+		return fmt.Errorf("Failed to listen: %w", err)
+	}
+
+	// A failing gRPC server closes the HTTP one, to end the command with
+	// its error
+	grpcErr := make(chan error, 1)
+	if *grpcAddress != "" {
+		// gRPC clients refuse a TLS connection that did not select HTTP/2
+		grpcOpts := listenOpts
+		grpcOpts.NextProtos = []string{"h2"}
+		grpcListener, err := transport.Listen(*grpcAddress, grpcOpts)
+		if err != nil {
+			return fmt.Errorf("Failed to listen for gRPC: %w", err)
+		}
+		grpcServer := rpc.NewServer(rpc.Options{
+			Records:      writes,
+			Index:        store,
+			Namespace:    records.ns,
+			WriteToken:   config.Server.WriteToken,
+			PollInterval: config.Index.SyncInterval,
+		})
+		go func() {
+			<-ctx.Done()
+			grpcServer.GracefulStop()
+		}()
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				grpcErr <- err
+				server.Close()
+			}
+		}()
+		fmt.Printf("Serving gRPC API on %s%s\n", *grpcAddress, security)
+	}
+
+	fmt.Printf("Serving HTTP API on %s%s\n", *address, security)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP server failed: %w", err)
+	}
+	select {
+	case err := <-grpcErr:
+		return fmt.Errorf("gRPC server failed: %w", err)
+	default:
+		return nil
+	}
+}
+
+// serverTransport returns the listener options of the servers, from the
+// TLS and allowed IPs of server, and how they secure connections
+func serverTransport(config *Config) (transport.Options, string) {
+	opts := transport.Options{
+		CertFile:     config.Server.TLS.CertFile,
+		KeyFile:      config.Server.TLS.KeyFile,
+		ClientCAFile: config.Server.TLS.ClientCAFile,
+		AllowedIPs:   config.Server.AllowedIPs,
+	}
+	switch {
+	case config.Server.TLS.ClientCAFile != "":
+		return opts, " over mutual TLS"
+	case config.Server.TLS.CertFile != "":
+		return opts, " over TLS"
+	}
+	return opts, ""
+}
+
+// shutdownOnDone shuts server down gracefully once ctx is done, within
+// timeouts.shutdown
+func shutdownOnDone(ctx context.Context, config *Config, server *http.Server) {
+	<-ctx.Done()
+	timeout := config.Timeouts.Shutdown
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+}
+
+// newRecordService returns the service behind /records, loading the
+// signers only when writes are enabled with server.write_token
+func newRecordService(ctx context.Context, config *Config, store *indexer.Store, client *chain.Client) (*recordService, error) {
+	ns, err := recordNamespace(ctx, config, store, client)
+	if err != nil {
+		return nil, err
+	}
+	records := &recordService{config: config, client: client, address: ns.Contract, ns: ns}
+	if config.Server.WriteToken != "" {
+		if records.signers, err = loadSigners(ctx, config); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// readinessChecks returns the checks of GET /readyz: the node answers, on
+// the chain of the records, the contract is deployed there and, when
+// writes are enabled, a signer is available
+func readinessChecks(client *chain.Client, records *recordService) []api.Check {
+	checks := []api.Check{
+		{Name: "rpc", Run: func(ctx context.Context) error {
+			_, err := client.BlockNumber(ctx)
+			return err
+		}},
+		{Name: "chain_id", Run: func(ctx context.Context) error {
+			id, err := client.ChainID(ctx)
+			if err != nil {
+				return err
+			}
+			if !id.IsUint64() || id.Uint64() != records.ns.ChainID {
+				return fmt.Errorf("node serves chain %s, want %d", id, records.ns.ChainID)
+			}
+			return nil
+		}},
+		{Name: "contract", Run: func(ctx context.Context) error {
+			code, err := client.CodeAt(ctx, records.address, nil)
+			if err != nil {
+				return err
+			}
+			if len(code) == 0 {
+				return fmt.Errorf("no contract code at %s", records.address.Hex())
+			}
+			return nil
+		}},
+	}
+	if records.signers != nil {
+		checks = append(checks, api.Check{Name: "signer", Run: func(ctx context.Context) error {
+			_, err := records.signers.Active(ctx)
+			return err
+		}})
+	}
+	return checks
+}
+
+// registerGauges exposes the depth of the queues kept in files and the
+// progress of the index
+func registerGauges(config *Config, store *indexer.Store, dispatcher *webhook.Dispatcher) {
+	metrics.Gauge("cse_write_queue_depth", "Writes waiting in the write queue.", func() float64 {
+		queue, _ := loadQueue(config)
+		return float64(len(queue))
+	})
+	metrics.Gauge("cse_replication_lagging", "Saved records some replica networks still lack.", func() float64 {
+		writes, _ := loadReplication(config)
+		return float64(len(writes))
+	})
+	metrics.Gauge("cse_webhook_dead_letters", "Webhook deliveries in the dead letter queue.", func() float64 {
+		return float64(len(dispatcher.DLQ.List()))
+	})
+	metrics.Gauge("cse_index_next_block", "First block the index has not synced yet.", func() float64 {
+		next, _ := store.NextBlock(0)
+		return float64(next)
+	})
+}
+
+// startCanary runs canaries in the background, at startup and then every
+// canary.interval, logging failures
+func startCanary(ctx context.Context, config *Config, client *chain.Client) (*canary.Runner, error) {
+	address, err := contractAddress(config)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := loadSigners(ctx, config)
+	if err != nil {
+		return nil, fail(codeSigner, "Failed to load private key for the canary: %w", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	runner, err := newCanary(config, client, signers, chainID, address)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to set up canary: %w", err)
+	}
+	runner.OnResult = func(result *canary.Result) {
+		if !result.OK && ctx.Err() == nil {
+			slog.Error("Canary failed", "stage", result.Stage, "key", result.Key, "tx_hash", result.TxHash.Hex(), "error", result.Error)
+		}
+	}
+	go runner.Start(ctx, config.Canary.Interval)
+	return runner, nil
+}
+
+// keepIndexSynced syncs the index periodically until ctx is done, giving up
+// on a single sync after timeout
+func keepIndexSynced(ctx context.Context, ix *indexer.Indexer, interval, timeout time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	for {
+		syncCtx, cancel := withTimeout(ctx, timeout)
+		head, err := ix.Sync(syncCtx)
+		cancel()
+		switch {
+		case err == nil:
+			slog.Debug("Index synced", "block", head)
+		case ctx.Err() == nil:
+			slog.Warn("Failed to sync index", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// JSON-RPC error code of unknown methods
+const methodNotFoundCode = -32601
+
+// ExternalSigner delegates signing to an external signer service over its
+// JSON-RPC API: Clef (account_list, account_signTransaction) or Web3Signer
+// (eth_accounts, eth_signTransaction). No key is held in-process.
+type ExternalSigner struct {
+	client  *rpc.Client
+	clef    bool
+	address common.Address
+}
+
+// NewExternalSigner connects to the signer at url (HTTP, WebSocket or IPC
+// path) and selects account, which may be left zero when the signer
+// manages a single account.
+func NewExternalSigner(ctx context.Context, url string, account common.Address) (*ExternalSigner, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	s := &ExternalSigner{client: client, clef: true}
+
+	// Clef only answers account_*, Web3Signer only eth_*
+	addresses, err := s.accounts(ctx)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+		s.clef = false
+		addresses, err = s.accounts(ctx)
+	}
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("external signer: list accounts: %w", err)
+	}
+
+	switch {
+	case account != (common.Address{}):
+		for _, a := range addresses {
+			if a == account {
+				s.address = a
+			}
+		}
+		if s.address == (common.Address{}) {
+			client.Close()
+			return nil, fmt.Errorf("external signer: account %s is not managed by %s", account.Hex(), url)
+		}
+	case len(addresses) == 1:
+		s.address = addresses[0]
+	default:
+		client.Close()
+		return nil, fmt.Errorf("external signer: %s manages %d accounts, select one", url, len(addresses))
+	}
+	return s, nil
+}
+
+func (s *ExternalSigner) accounts(ctx context.Context) ([]common.Address, error) {
+	method := "eth_accounts"
+	if s.clef {
+		method = "account_list"
+	}
+	var addresses []common.Address
+	err := s.client.CallContext(ctx, &addresses, method)
+	return addresses, err
+}
+
+// Address returns the selected account of the signer.
+func (s *ExternalSigner) Address() common.Address {
+	return s.address
+}
+
+// txArgs is the transaction format accepted by both signers
+type txArgs struct {
+	From                 common.Address    `json:"from"`
+	To                   *common.Address   `json:"to,omitempty"`
+	Gas                  hexutil.Uint64    `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big      `json:"value"`
+	Nonce                hexutil.Uint64    `json:"nonce"`
+	Data                 hexutil.Bytes     `json:"data"`
+	ChainID              *hexutil.Big      `json:"chainId"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+}
+
+// SignTx asks the external signer to sign tx. Clef may let its operator
+// edit a transaction before approving it, so the result is rejected unless
+// it is exactly tx, signed by the selected account.
+func (s *ExternalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := txArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		if list := tx.AccessList(); len(list) > 0 {
+			args.AccessList = &list
+		}
+	default:
+		return nil, fmt.Errorf("external signer: unsupported transaction type %d", tx.Type())
+	}
+
+	var raw hexutil.Bytes
+	if s.clef {
+		var result struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+			return nil, fmt.Errorf("external signer: %w", err)
+		}
+		raw = result.Raw
+	} else if err := s.client.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("external signer: decode signed transaction: %w", err)
+	}
+	txSigner := types.LatestSignerForChainID(chainID)
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, errors.New("external signer: the signed transaction differs from the requested one")
+	}
+	if from, err := types.Sender(txSigner, signed); err != nil || from != s.address {
+		return nil, fmt.Errorf("external signer: transaction not signed by %s", s.address.Hex())
+	}
+	return signed, nil
+}
+
+// Ping checks that the signer still answers.
+func (s *ExternalSigner) Ping(ctx context.Context) error {
+	_, err := s.accounts(ctx)
+	return err
+}
+
+// Close closes the connection to the signer.
+func (s *ExternalSigner) Close() error {
+	s.client.Close()
+	return nil
+}
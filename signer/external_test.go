@@ -0,0 +1,191 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// signArgs is the transaction the signers receive
+type signArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+// fakeSigner holds keys and signs what it is asked, after edit changed
+// the transaction as a Clef operator may
+type fakeSigner struct {
+	keys []*ecdsa.PrivateKey
+	edit func(gas uint64) uint64
+	with *ecdsa.PrivateKey
+}
+
+func (f *fakeSigner) addresses() []common.Address {
+	var addresses []common.Address
+	for _, key := range f.keys {
+		addresses = append(addresses, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return addresses
+}
+
+func (f *fakeSigner) sign(args signArgs) (hexutil.Bytes, error) {
+	gas := uint64(args.Gas)
+	if f.edit != nil {
+		gas = f.edit(gas)
+	}
+	var data types.TxData = &types.LegacyTx{Nonce: uint64(args.Nonce), To: args.To, Gas: gas, GasPrice: args.GasPrice.ToInt(), Value: args.Value.ToInt(), Data: args.Data}
+	if args.MaxFeePerGas != nil {
+		data = &types.DynamicFeeTx{ChainID: args.ChainID.ToInt(), Nonce: uint64(args.Nonce), To: args.To, Gas: gas, GasFeeCap: args.MaxFeePerGas.ToInt(), GasTipCap: args.MaxPriorityFeePerGas.ToInt(), Value: args.Value.ToInt(), Data: args.Data}
+	}
+	key := f.with
+	for _, k := range f.keys {
+		if key == nil && crypto.PubkeyToAddress(k.PublicKey) == args.From {
+			key = k
+		}
+	}
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(args.ChainID.ToInt()), data)
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
+
+// clefAPI answers the account_* methods of Clef
+type clefAPI struct{ *fakeSigner }
+
+func (c clefAPI) List() []common.Address { return c.addresses() }
+
+func (c clefAPI) SignTransaction(args signArgs) (map[string]hexutil.Bytes, error) {
+	raw, err := c.sign(args)
+	return map[string]hexutil.Bytes{"raw": raw}, err
+}
+
+// web3SignerAPI answers the eth_* methods of Web3Signer
+type web3SignerAPI struct{ *fakeSigner }
+
+func (w web3SignerAPI) Accounts() []common.Address { return w.addresses() }
+
+func (w web3SignerAPI) SignTransaction(args signArgs) (hexutil.Bytes, error) { return w.sign(args) }
+
+func serveSigner(t *testing.T, namespace string, api interface{}) string {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName(namespace, api); err != nil {
+		t.Fatal(err)
+	}
+	endpoint := httptest.NewServer(server)
+	t.Cleanup(func() {
+		endpoint.Close()
+		server.Stop()
+	})
+	return endpoint.URL
+}
+
+func TestExternalSigner(t *testing.T) {
+	first, _ := crypto.HexToECDSA("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	second, _ := crypto.HexToECDSA("59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d")
+	firstAddress := crypto.PubkeyToAddress(first.PublicKey)
+	secondAddress := crypto.PubkeyToAddress(second.PublicKey)
+	to := common.HexToAddress("0x3A220f351252089D385b29beca14e27F204c296A")
+	chainID := big.NewInt(1337)
+	txs := map[string]*types.Transaction{
+		"legacy":      types.NewTx(&types.LegacyTx{Nonce: 3, To: &to, Gas: 60000, GasPrice: big.NewInt(2e9), Value: big.NewInt(0), Data: []byte{1, 2, 3, 4}}),
+		"dynamic fee": types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 4, To: &to, Gas: 60000, GasFeeCap: big.NewInt(3e9), GasTipCap: big.NewInt(1e9), Value: big.NewInt(0)}),
+	}
+
+	for _, kind := range []string{"clef", "web3signer"} {
+		serve := func(f *fakeSigner) string {
+			if kind == "clef" {
+				return serveSigner(t, "account", clefAPI{f})
+			}
+			return serveSigner(t, "eth", web3SignerAPI{f})
+		}
+		t.Run(kind, func(t *testing.T) {
+			ctx := context.Background()
+			url := serve(&fakeSigner{keys: []*ecdsa.PrivateKey{first, second}})
+			if _, err := signer.NewExternalSigner(ctx, url, common.Address{}); err == nil || !strings.Contains(err.Error(), "manages 2 accounts, select one") {
+				t.Errorf("no account selected: got %v", err)
+			}
+			other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+			if _, err := signer.NewExternalSigner(ctx, url, other); err == nil || !strings.Contains(err.Error(), "is not managed by") {
+				t.Errorf("unmanaged account: got %v", err)
+			}
+
+			s, err := signer.NewExternalSigner(ctx, url, secondAddress)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if s.Address() != secondAddress {
+				t.Errorf("address %s, want %s", s.Address().Hex(), secondAddress.Hex())
+			}
+			if err := s.Ping(ctx); err != nil {
+				t.Errorf("Ping: %v", err)
+			}
+			for name, tx := range txs {
+				signed, err := s.SignTx(ctx, tx, chainID)
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					continue
+				}
+				if from, _ := types.Sender(types.LatestSignerForChainID(chainID), signed); from != secondAddress || signed.Nonce() != tx.Nonce() {
+					t.Errorf("%s: signed by %s with nonce %d", name, from.Hex(), signed.Nonce())
+				}
+			}
+
+			edited, err := signer.NewExternalSigner(ctx, serve(&fakeSigner{keys: []*ecdsa.PrivateKey{first}, edit: func(gas uint64) uint64 { return gas * 2 }}), common.Address{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer edited.Close()
+			if edited.Address() != firstAddress {
+				t.Errorf("single account signer selected %s", edited.Address().Hex())
+			}
+			if _, err := edited.SignTx(ctx, txs["legacy"], chainID); err == nil || !strings.Contains(err.Error(), "differs from the requested one") {
+				t.Errorf("edited transaction: got %v", err)
+			}
+
+			impostor, err := signer.NewExternalSigner(ctx, serve(&fakeSigner{keys: []*ecdsa.PrivateKey{first}, with: second}), common.Address{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer impostor.Close()
+			if _, err := impostor.SignTx(ctx, txs["dynamic fee"], chainID); err == nil || !strings.Contains(err.Error(), "not signed by") {
+				t.Errorf("signed by another account: got %v", err)
+			}
+		})
+	}
+}
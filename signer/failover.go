@@ -0,0 +1,128 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Selector picks the signer for the next transaction.
+type Selector interface {
+	Active(ctx context.Context) (Signer, error)
+}
+
+type fixed struct {
+	s Signer
+}
+
+// Fixed returns a selector that always uses s.
+func Fixed(s Signer) Selector {
+	return fixed{s: s}
+}
+
+func (f fixed) Active(ctx context.Context) (Signer, error) {
+	return f.s, nil
+}
+
+// Close releases the signer when it holds a device or connection.
+func (f fixed) Close() error {
+	return closeSigner(f.s)
+}
+
+func closeSigner(s Signer) error {
+	if closer, ok := s.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Failover chooses between a primary signer and a warm standby. The standby
+// account only takes over after the lock is acquired, so several standby
+// instances never broadcast from the same account at once.
+//
+// Because primary and standby are different accounts with their own nonces,
+// the signer must be chosen before a transaction is built: call Active for
+// every transaction and build the transaction options from its result.
+type Failover struct {
+	primary   Signer
+	secondary Signer
+	lock      Locker
+
+	// OnSwitch is called when the active signer changes.
+	OnSwitch func(active Signer, reason error)
+
+	mu      sync.Mutex
+	standby bool
+}
+
+// NewFailover returns a failover between primary and secondary guarded by lock.
+func NewFailover(primary, secondary Signer, lock Locker) *Failover {
+	return &Failover{primary: primary, secondary: secondary, lock: lock}
+}
+
+// Active returns the signer to use for the next transaction. The primary is
+// used while it is healthy; otherwise the standby is used once the lock is
+// held. Switching back to the primary releases the lock.
+func (f *Failover) Active(ctx context.Context) (Signer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	primaryErr := Ping(ctx, f.primary)
+	if primaryErr == nil {
+		if f.standby {
+			f.standby = false
+			if err := f.lock.Unlock(); err != nil {
+				return nil, err
+			}
+			f.notify(f.primary, nil)
+		}
+		return f.primary, nil
+	}
+
+	if err := f.lock.Lock(ctx); err != nil {
+		return nil, fmt.Errorf("%w: primary: %v, standby: %w", ErrUnavailable, primaryErr, err)
+	}
+	if err := Ping(ctx, f.secondary); err != nil {
+		return nil, fmt.Errorf("%w: primary: %v, standby: %v", ErrUnavailable, primaryErr, err)
+	}
+	if !f.standby {
+		f.standby = true
+		f.notify(f.secondary, primaryErr)
+	}
+	return f.secondary, nil
+}
+
+// Close releases the lock if the standby is active, and both signers.
+func (f *Failover) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var err error
+	if f.standby {
+		f.standby = false
+		err = f.lock.Unlock()
+	}
+	return errors.Join(err, closeSigner(f.primary), closeSigner(f.secondary))
+}
+
+func (f *Failover) notify(active Signer, reason error) {
+	if f.OnSwitch != nil {
+		f.OnSwitch(active, reason)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// pingSigner is a signer whose health check fails while down is set.
+type pingSigner struct {
+	address common.Address
+	down    error
+}
+
+func (s *pingSigner) Address() common.Address { return s.address }
+
+func (s *pingSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return tx, nil
+}
+
+func (s *pingSigner) Ping(ctx context.Context) error { return s.down }
+
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "signer.lock")
+	primary := &pingSigner{address: common.HexToAddress("0x01")}
+	standby := &pingSigner{address: common.HexToAddress("0x02")}
+	lock := &signer.FileLock{Path: path, TTL: time.Minute, Owner: "standby/1"}
+	failover := signer.NewFailover(primary, standby, lock)
+	var switches []common.Address
+	failover.OnSwitch = func(active signer.Signer, reason error) {
+		switches = append(switches, active.Address())
+	}
+
+	active := func(want *pingSigner) {
+		t.Helper()
+		got, err := failover.Active(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("active signer %s, want %s", got.Address(), want.address)
+		}
+	}
+
+	active(primary)
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock taken while the primary is healthy: %v", err)
+	}
+
+	primary.down = errors.New("connection refused")
+	active(standby)
+	active(standby)
+	if got := leaseOwner(t, path); got != lock.Owner {
+		t.Fatalf("lease owned by %q, want %q", got, lock.Owner)
+	}
+
+	// Another standby instance cannot take over while the lease is held
+	other := signer.NewFailover(primary, standby, &signer.FileLock{Path: path, TTL: time.Minute, Owner: "standby/2"})
+	if _, err := other.Active(ctx); !errors.Is(err, signer.ErrUnavailable) || !errors.Is(err, signer.ErrLocked) {
+		t.Fatalf("second standby: err = %v, want ErrUnavailable and ErrLocked", err)
+	}
+
+	// The primary recovering releases the lock
+	primary.down = nil
+	active(primary)
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock still held after the primary recovered: %v", err)
+	}
+	if want := []common.Address{standby.address, primary.address}; len(switches) != 2 || switches[0] != want[0] || switches[1] != want[1] {
+		t.Errorf("switches %v, want %v", switches, want)
+	}
+}
+
+func TestFailoverStandbyDown(t *testing.T) {
+	primary := &pingSigner{address: common.HexToAddress("0x01"), down: errors.New("primary down")}
+	standby := &pingSigner{address: common.HexToAddress("0x02"), down: errors.New("standby down")}
+	lock := &signer.FileLock{Path: filepath.Join(t.TempDir(), "signer.lock"), Owner: "standby/1"}
+	failover := signer.NewFailover(primary, standby, lock)
+
+	if _, err := failover.Active(context.Background()); !errors.Is(err, signer.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if err := failover.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP-44 path of the first Ethereum account.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// ErrInvalidMnemonic is returned for phrases that are not valid BIP-39
+// mnemonics (unknown words or a bad checksum).
+var ErrInvalidMnemonic = errors.New("invalid BIP-39 mnemonic")
+
+// NewMnemonicSigner derives the key at path from a BIP-39 mnemonic and its
+// optional passphrase.
+func NewMnemonicSigner(mnemonic, passphrase string, path accounts.DerivationPath) (*KeySigner, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidMnemonic, err)
+	}
+	key, err := DeriveKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+// DeriveKey derives the BIP-32 private key at path from a seed.
+func DeriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+
+	n := crypto.S256().Params().N
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errors.New("invalid master key")
+	}
+
+	for _, index := range path {
+		mac := hmac.New(sha512.New, chainCode)
+		if index >= 0x80000000 {
+			// Hardened child: 0x00 || ser256(k) || ser32(i)
+			mac.Write([]byte{0})
+			mac.Write(key.FillBytes(make([]byte, 32)))
+		} else {
+			// Normal child: serP(K) || ser32(i)
+			priv, err := crypto.ToECDSA(key.FillBytes(make([]byte, 32)))
+			if err != nil {
+				return nil, err
+			}
+			mac.Write(crypto.CompressPubkey(&priv.PublicKey))
+		}
+		mac.Write(binary.BigEndian.AppendUint32(nil, index))
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, errors.New("invalid child key, try the next index")
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, errors.New("invalid child key, try the next index")
+		}
+		chainCode = sum[32:]
+	}
+
+	return crypto.ToECDSA(key.FillBytes(make([]byte, 32)))
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMnemonicSigner(t *testing.T) {
+	tests := []struct {
+		mnemonic, path string
+		address        string
+	}{
+		// The mnemonic of the Hardhat and Anvil development accounts
+		{"test test test test test test test test test test test junk", signer.DefaultDerivationPath, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{"test test test test test test test test test test test junk", "m/44'/60'/0'/0/1", "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+		{"test test test test test test test test test test test junk", "m/44'/60'/0'/0/9", "0xa0Ee7A142d267C1f36714E4a8F75612F20a79720"},
+		// The all-zero entropy BIP-39 test vector
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", signer.DefaultDerivationPath, "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := accounts.ParseDerivationPath(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := signer.NewMnemonicSigner(tt.mnemonic, "", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Address(); got != common.HexToAddress(tt.address) {
+				t.Errorf("address %s, want %s", got.Hex(), tt.address)
+			}
+		})
+	}
+
+	path, _ := accounts.ParseDerivationPath(signer.DefaultDerivationPath)
+	withPassphrase, err := signer.NewMnemonicSigner(tests[0].mnemonic, "TREZOR", path)
+	if err != nil || withPassphrase.Address() == common.HexToAddress(tests[0].address) {
+		t.Errorf("passphrase ignored: %v", err)
+	}
+	for _, mnemonic := range []string{
+		"test test test test test test test test test test test test",
+		"test test test test test test test test test test test jink",
+		"",
+	} {
+		if _, err := signer.NewMnemonicSigner(mnemonic, "", path); !errors.Is(err, signer.ErrInvalidMnemonic) {
+			t.Errorf("%q: got %v, want ErrInvalidMnemonic", mnemonic, err)
+		}
+	}
+}
+
+// TestDeriveKey checks the derivation of test vector 1 of BIP-32, whose path
+// mixes hardened and normal children
+func TestDeriveKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	path, err := accounts.ParseDerivationPath("m/0'/1/2'/2/1000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := signer.DeriveKey(seed, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(crypto.FromECDSA(key)); got != "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8" {
+		t.Errorf("derived %s", got)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"errors"
+	"testing"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestKeystoreSigner(t *testing.T) {
+	key, err := crypto.HexToECDSA("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := signer.NewKeystoreSigner(account.URL.Path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != account.Address {
+		t.Errorf("address %s, want %s", s.Address(), account.Address)
+	}
+
+	if _, err := signer.NewKeystoreSigner(account.URL.Path, "wrong"); !errors.Is(err, keystore.ErrDecrypt) {
+		t.Errorf("wrong passphrase: err = %v, want ErrDecrypt", err)
+	}
+	if _, err := signer.NewKeystoreSigner(account.URL.Path+".missing", "correct horse"); err == nil {
+		t.Error("opened a missing keystore file")
+	}
+}
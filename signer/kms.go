@@ -0,0 +1,136 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KMSClient is the subset of the AWS KMS API used by KMSSigner.
+type KMSClient interface {
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+// KMSSigner signs with an asymmetric ECC_SECG_P256K1 key held by AWS KMS.
+// The private key never leaves KMS; each transaction costs one Sign call.
+type KMSSigner struct {
+	client  KMSClient
+	keyID   string
+	pub     *ecdsa.PublicKey
+	address common.Address
+}
+
+// NewKMSSigner looks up the public key of keyID and returns a signer for
+// the matching account.
+func NewKMSSigner(ctx context.Context, client KMSClient, keyID string) (*KMSSigner, error) {
+	out, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("kms: get public key: %w", err)
+	}
+	if out.KeySpec != kmstypes.KeySpecEccSecgP256k1 {
+		return nil, fmt.Errorf("kms: key %s has spec %s, want %s", keyID, out.KeySpec, kmstypes.KeySpecEccSecgP256k1)
+	}
+
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(out.PublicKey, &info); err != nil {
+		return nil, fmt.Errorf("kms: parse public key: %w", err)
+	}
+	pub, err := crypto.UnmarshalPubkey(info.PublicKey.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("kms: parse public key: %w", err)
+	}
+	return &KMSSigner{client: client, keyID: keyID, pub: pub, address: crypto.PubkeyToAddress(*pub)}, nil
+}
+
+// Address returns the account of the KMS key.
+func (s *KMSSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx with the latest signer for chainID.
+func (s *KMSSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	txSigner := types.LatestSignerForChainID(chainID)
+	sig, err := s.sign(ctx, txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(txSigner, sig)
+}
+
+// SignHash signs a 32-byte digest with the KMS key.
+func (s *KMSSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return s.sign(ctx, hash)
+}
+
+// Ping checks that the key can still be read.
+func (s *KMSSigner) Ping(ctx context.Context) error {
+	_, err := s.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(s.keyID)})
+	return err
+}
+
+// sign returns the 65-byte [R || S || V] signature of digest.
+func (s *KMSSigner) sign(ctx context.Context, digest []byte) ([]byte, error) {
+	out, err := s.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      kmstypes.MessageTypeDigest,
+		SigningAlgorithm: kmstypes.SigningAlgorithmSpecEcdsaSha256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kms: sign: %w", err)
+	}
+
+	var der struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(out.Signature, &der); err != nil {
+		return nil, fmt.Errorf("kms: parse signature: %w", err)
+	}
+
+	// KMS does not produce canonical signatures: Ethereum only accepts the
+	// lower of S and N-S
+	n := crypto.S256().Params().N
+	if der.S.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		der.S.Sub(n, der.S)
+	}
+
+	// KMS does not return the recovery id either, try both
+	sig := make([]byte, crypto.SignatureLength)
+	der.R.FillBytes(sig[:32])
+	der.S.FillBytes(sig[32:64])
+	want := crypto.FromECDSAPub(s.pub)
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		if pub, err := crypto.Ecrecover(digest, sig); err == nil && string(pub) == string(want) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("kms: signature does not match the key")
+}
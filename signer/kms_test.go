@@ -0,0 +1,196 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/signer"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeKMS holds a secp256k1 key the way AWS KMS does: the public key comes
+// as a DER SubjectPublicKeyInfo and signatures as DER without a recovery id.
+type fakeKMS struct {
+	key     *ecdsa.PrivateKey
+	keySpec kmstypes.KeySpec
+	// highS returns the non-canonical N-S, as KMS does half of the time.
+	highS bool
+	// signWith signs with another key than the one it reports.
+	signWith *ecdsa.PrivateKey
+	// recoveryIDs counts the recovery ids of the signatures handed out.
+	recoveryIDs [2]int
+}
+
+func (f *fakeKMS) GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	pub := crypto.FromECDSAPub(&f.key.PublicKey)
+	der, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+			Parameters: asn1.RawValue{FullBytes: mustMarshal(asn1.ObjectIdentifier{1, 3, 132, 0, 10})},
+		},
+		PublicKey: asn1.BitString{Bytes: pub, BitLength: 8 * len(pub)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	spec := f.keySpec
+	if spec == "" {
+		spec = kmstypes.KeySpecEccSecgP256k1
+	}
+	return &kms.GetPublicKeyOutput{KeyId: params.KeyId, KeySpec: spec, PublicKey: der}, nil
+}
+
+func (f *fakeKMS) Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	if params.MessageType != kmstypes.MessageTypeDigest || params.SigningAlgorithm != kmstypes.SigningAlgorithmSpecEcdsaSha256 {
+		return nil, errors.New("unexpected signing request")
+	}
+	key := f.key
+	if f.signWith != nil {
+		key = f.signWith
+	}
+	sig, err := crypto.Sign(params.Message, key)
+	if err != nil {
+		return nil, err
+	}
+	f.recoveryIDs[sig[64]]++
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if f.highS {
+		s.Sub(crypto.S256().Params().N, s)
+	}
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: params.KeyId, Signature: der}, nil
+}
+
+func mustMarshal(v interface{}) []byte {
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
+
+// testKey returns a fixed key, different for every seed.
+func testKey(t *testing.T, seed byte) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte{seed}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKMSSigner(t *testing.T) {
+	ctx := context.Background()
+	chainID := big.NewInt(1337)
+	halfN := new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+	for _, highS := range []bool{false, true} {
+		fake := &fakeKMS{highS: highS}
+		// Enough keys and transactions to need both recovery ids
+		for seed := byte(0); seed < 8; seed++ {
+			fake.key = testKey(t, seed)
+			s, err := signer.NewKMSSigner(ctx, fake, "alias/test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := crypto.PubkeyToAddress(fake.key.PublicKey)
+			if s.Address() != want {
+				t.Fatalf("address %s, want %s", s.Address(), want)
+			}
+
+			tx := types.NewTx(&types.DynamicFeeTx{
+				ChainID:   chainID,
+				Nonce:     uint64(seed),
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(2),
+				Gas:       21000,
+				To:        &common.Address{},
+			})
+			signed, err := s.SignTx(ctx, tx, chainID)
+			if err != nil {
+				t.Fatalf("high S %v, key %d: %v", highS, seed, err)
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sender != want {
+				t.Errorf("high S %v, key %d: sender %s, want %s", highS, seed, sender, want)
+			}
+			if _, _, sv := signed.RawSignatureValues(); sv.Cmp(halfN) > 0 {
+				t.Errorf("high S %v, key %d: S is not canonical", highS, seed)
+			}
+		}
+		if fake.recoveryIDs[0] == 0 || fake.recoveryIDs[1] == 0 {
+			t.Errorf("high S %v: recovery ids %v, want both", highS, fake.recoveryIDs)
+		}
+	}
+}
+
+func TestKMSSignerSignHash(t *testing.T) {
+	fake := &fakeKMS{key: testKey(t, 1), highS: true}
+	s, err := signer.NewKMSSigner(context.Background(), fake, "alias/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := crypto.Keccak256([]byte("safe transaction"))
+	sig, err := s.SignHash(context.Background(), digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(*pub) != s.Address() {
+		t.Errorf("signature recovers %s, want %s", crypto.PubkeyToAddress(*pub), s.Address())
+	}
+}
+
+func TestKMSSignerErrors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := signer.NewKMSSigner(ctx, &fakeKMS{key: testKey(t, 1), keySpec: kmstypes.KeySpecEccNistP256}, "alias/test")
+	if err == nil || !strings.Contains(err.Error(), "spec") {
+		t.Errorf("P-256 key: err = %v, want a key spec error", err)
+	}
+
+	s, err := signer.NewKMSSigner(ctx, &fakeKMS{key: testKey(t, 1), signWith: testKey(t, 2)}, "alias/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SignHash(ctx, crypto.Keccak256([]byte("x"))); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("signature of another key: err = %v", err)
+	}
+}
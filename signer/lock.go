@@ -0,0 +1,174 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Locker guards broadcasting with a shared account, so that only one
+// process at a time uses it.
+type Locker interface {
+	// Lock acquires the lock, or refreshes it when it is already held.
+	Lock(ctx context.Context) error
+	// Unlock releases the lock.
+	Unlock() error
+}
+
+// ErrLocked is returned when the lock is held by someone else.
+var ErrLocked = errors.New("lock held by another process")
+
+// FileLock is a lease stored in a file. Placed on storage shared between
+// hosts (e.g. NFS) it coordinates standby instances across machines. A lease
+// that has not been refreshed within TTL is considered abandoned and taken
+// over.
+type FileLock struct {
+	Path string
+	// TTL is the lifetime of an unrefreshed lease, DefaultLockTTL when zero.
+	TTL   time.Duration
+	Owner string
+}
+
+// DefaultLockTTL is the lease lifetime of a FileLock without a TTL.
+const DefaultLockTTL = time.Minute
+
+// NewFileLock returns a lock at path owned by this process.
+func NewFileLock(path string, ttl time.Duration) *FileLock {
+	host, _ := os.Hostname()
+	return &FileLock{Path: path, TTL: ttl, Owner: fmt.Sprintf("%s/%d", host, os.Getpid())}
+}
+
+// Lock acquires or refreshes the lease.
+func (l *FileLock) Lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := l.create()
+	if !errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	owner, info, err := readLease(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Released in the meantime
+		return l.createOrLocked()
+	}
+	if err != nil {
+		return err
+	}
+	if owner == l.Owner {
+		now := time.Now()
+		if err := os.Chtimes(l.Path, now, now); !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		// Set aside by a standby checking whether it is stale
+		return l.createOrLocked()
+	}
+
+	ttl := l.TTL
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+	if time.Since(info.ModTime()) <= ttl {
+		return fmt.Errorf("%w: %s", ErrLocked, owner)
+	}
+	if err := l.takeOver(owner, info); err != nil {
+		return err
+	}
+	return l.createOrLocked()
+}
+
+// createOrLocked writes the lease, failing with ErrLocked when another
+// process has written one first.
+func (l *FileLock) createOrLocked() error {
+	err := l.create()
+	if errors.Is(err, os.ErrExist) {
+		return ErrLocked
+	}
+	return err
+}
+
+// create writes the lease if there is none.
+func (l *FileLock) create() error {
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(l.Owner)
+	return errors.Join(err, f.Close())
+}
+
+// takeOver removes the stale lease of owner, last refreshed as info tells.
+// Removing the file directly could delete the lease another standby wrote
+// after taking over first, or the primary after refreshing, so the file is
+// first renamed aside, which only one process can do, and put back if it
+// turns out not to be the stale lease.
+func (l *FileLock) takeOver(owner string, info os.FileInfo) error {
+	aside := fmt.Sprintf("%s.%s.stale", l.Path, strings.NewReplacer("/", "-", `\`, "-").Replace(l.Owner))
+	if err := os.Rename(l.Path, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+
+	current, currentInfo, err := readLease(aside)
+	if err != nil {
+		return err
+	}
+	if current == owner && currentInfo.ModTime().Equal(info.ModTime()) {
+		return nil
+	}
+	// The lease was renewed or replaced: restore it, unless yet another one
+	// has been written since
+	if err := os.Link(aside, l.Path); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrLocked, current)
+}
+
+func readLease(path string) (string, os.FileInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.TrimSpace(string(data)), info, nil
+}
+
+// Unlock removes the lease if this process holds it.
+func (l *FileLock) Unlock() error {
+	owner, err := os.ReadFile(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(owner)) != l.Owner {
+		return nil
+	}
+	return os.Remove(l.Path)
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"contract-storage-eth/signer"
+)
+
+func newLock(path, owner string, ttl time.Duration) *signer.FileLock {
+	return &signer.FileLock{Path: path, TTL: ttl, Owner: owner}
+}
+
+// writeLease writes the lease of owner as last refreshed age ago.
+func writeLease(t *testing.T, path, owner string, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(owner), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	then := time.Now().Add(-age)
+	if err := os.Chtimes(path, then, then); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func leaseOwner(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFileLockContention(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "signer.lock")
+	a := newLock(path, "host-a/1", time.Minute)
+	b := newLock(path, "host-b/2", time.Minute)
+
+	if err := a.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Lock(ctx); !errors.Is(err, signer.ErrLocked) {
+		t.Fatalf("second holder: err = %v, want ErrLocked", err)
+	}
+	// Refreshing and releasing someone else's lease leave it alone
+	if err := a.Lock(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if err := b.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if got := leaseOwner(t, path); got != a.Owner {
+		t.Fatalf("lease owned by %q after another process unlocked, want %q", got, a.Owner)
+	}
+
+	if err := a.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Lock(ctx); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	if got := leaseOwner(t, path); got != b.Owner {
+		t.Errorf("lease owned by %q, want %q", got, b.Owner)
+	}
+}
+
+func TestFileLockStaleTakeover(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		age   time.Duration
+		taken bool
+	}{
+		{"fresh", time.Minute, 10 * time.Second, false},
+		{"stale", time.Minute, 2 * time.Minute, true},
+		{"default TTL fresh", 0, 30 * time.Second, false},
+		{"default TTL stale", 0, signer.DefaultLockTTL + time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "signer.lock")
+			writeLease(t, path, "crashed/1", tt.age)
+
+			err := newLock(path, "standby/2", tt.ttl).Lock(context.Background())
+			switch {
+			case tt.taken && err != nil:
+				t.Fatalf("takeover: %v", err)
+			case !tt.taken && !errors.Is(err, signer.ErrLocked):
+				t.Fatalf("err = %v, want ErrLocked", err)
+			}
+			want := "crashed/1"
+			if tt.taken {
+				want = "standby/2"
+			}
+			if got := leaseOwner(t, path); got != want {
+				t.Errorf("lease owned by %q, want %q", got, want)
+			}
+			// Nothing is left beside the lease
+			if matches, _ := filepath.Glob(path + ".*"); len(matches) != 0 {
+				t.Errorf("files left behind: %v", matches)
+			}
+		})
+	}
+}
+
+func TestFileLockConcurrentTakeover(t *testing.T) {
+	const standbys = 8
+	for round := 0; round < 20; round++ {
+		path := filepath.Join(t.TempDir(), "signer.lock")
+		writeLease(t, path, "crashed/1", time.Hour)
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			holders []string
+		)
+		start := make(chan struct{})
+		for i := 0; i < standbys; i++ {
+			lock := newLock(path, fmt.Sprintf("standby/%d", i), time.Minute)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				err := lock.Lock(context.Background())
+				if err != nil && !errors.Is(err, signer.ErrLocked) {
+					t.Errorf("%s: %v", lock.Owner, err)
+				}
+				if err == nil {
+					mu.Lock()
+					holders = append(holders, lock.Owner)
+					mu.Unlock()
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		if len(holders) != 1 {
+			t.Fatalf("round %d: %d standbys hold the lock: %v", round, len(holders), holders)
+		}
+		if got := leaseOwner(t, path); got != holders[0] {
+			t.Fatalf("round %d: lease owned by %q, but %q acquired it", round, got, holders[0])
+		}
+	}
+}
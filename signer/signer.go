@@ -0,0 +1,119 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer abstracts where transaction signatures come from, so the
+// rest of the tool does not need to hold raw private keys.
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions for a single account.
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// HealthChecker is implemented by signers backed by a remote service that
+// can become unavailable.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// HashSigner is implemented by signers that can sign an arbitrary 32-byte
+// digest, as needed for off-chain approvals such as Safe transactions. The
+// signature is [R || S || V] with V 0 or 1.
+type HashSigner interface {
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// KeySigner signs with an in-memory private key.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner returns a signer for the given private key.
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// NewKeySignerFromHex parses a hex private key (with or without 0x prefix).
+func NewKeySignerFromHex(hexKey string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(trimHexPrefix(hexKey))
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+// Address returns the account of the key.
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx with the latest signer for chainID.
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// SignHash signs a 32-byte digest with the key.
+func (s *KeySigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+// NewTransactOpts builds bind transaction options that sign through s.
+func NewTransactOpts(ctx context.Context, s Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+	from := s.Address()
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(ctx, tx, chainID)
+		},
+		Context: ctx,
+	}, nil
+}
+
+// Ping checks the signer when it supports health checks.
+func Ping(ctx context.Context, s Signer) error {
+	if hc, ok := s.(HealthChecker); ok {
+		return hc.Ping(ctx)
+	}
+	return nil
+}
+
+func trimHexPrefix(s string) string {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		return s[2:]
+	}
+	return s
+}
+
+// ErrUnavailable is returned when no signer can be used.
+var ErrUnavailable = errors.New("signer unavailable")
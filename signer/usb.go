@@ -0,0 +1,186 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Hardware wallet kinds supported by NewHardwareSigner.
+const (
+	Ledger = "ledger"
+	Trezor = "trezor"
+)
+
+// HardwareOptions configure a hardware wallet signer.
+type HardwareOptions struct {
+	// Kind is Ledger or Trezor.
+	Kind string
+	// Path is the derivation path of the account on the device.
+	Path accounts.DerivationPath
+	// Prompt asks the user for the Trezor PIN or passphrase.
+	Prompt func(message string) (string, error)
+	// Notify tells the user what to do on the device.
+	Notify func(message string)
+	// ConnectTimeout bounds the wait for a device to be plugged in. It
+	// defaults to one minute.
+	ConnectTimeout time.Duration
+}
+
+// HardwareSigner signs on a Ledger or Trezor device. Every transaction has
+// to be confirmed on the device, after its summary was shown via Notify.
+type HardwareSigner struct {
+	name    string
+	wallet  accounts.Wallet
+	account accounts.Account
+	notify  func(message string)
+}
+
+// NewHardwareSigner opens the first connected device of the given kind and
+// derives the account at opts.Path.
+func NewHardwareSigner(ctx context.Context, opts HardwareOptions) (*HardwareSigner, error) {
+	var hub *usbwallet.Hub
+	var err error
+	var name string
+	switch opts.Kind {
+	case Ledger:
+		hub, err = usbwallet.NewLedgerHub()
+		name = "Ledger"
+	case Trezor:
+		hub, err = usbwallet.NewTrezorHubWithHID()
+		name = "Trezor"
+	default:
+		return nil, fmt.Errorf("unknown hardware wallet %q, want %s or %s", opts.Kind, Ledger, Trezor)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	notify := opts.Notify
+	if notify == nil {
+		notify = func(string) {}
+	}
+	timeout := opts.ConnectTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	// Wait for a device to show up
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var wallet accounts.Wallet
+	for asked := false; wallet == nil; asked = true {
+		if wallets := hub.Wallets(); len(wallets) > 0 {
+			wallet = wallets[0]
+			break
+		}
+		if !asked {
+			notify(fmt.Sprintf("Connect and unlock your %s", name))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s: no device found: %w", name, ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+
+	if err := openWallet(wallet, name, opts); err != nil {
+		return nil, err
+	}
+	if opts.Kind == Ledger {
+		notify("Open the Ethereum app on your Ledger")
+	}
+	account, err := wallet.Derive(opts.Path, true)
+	if err != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("%s: deriving %s: %w", name, opts.Path, err)
+	}
+	return &HardwareSigner{name: name, wallet: wallet, account: account, notify: notify}, nil
+}
+
+// openWallet opens the device, answering the Trezor PIN and passphrase
+// requests through opts.Prompt.
+func openWallet(wallet accounts.Wallet, name string, opts HardwareOptions) error {
+	secret := ""
+	for {
+		err := wallet.Open(secret)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, usbwallet.ErrTrezorPINNeeded) && opts.Prompt != nil:
+			secret, err = opts.Prompt("Trezor PIN (type the positions shown on the device, laid out like a numeric keypad: 7 8 9 / 4 5 6 / 1 2 3)")
+		case errors.Is(err, usbwallet.ErrTrezorPassphraseNeeded) && opts.Prompt != nil:
+			secret, err = opts.Prompt("Trezor passphrase (empty for none)")
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+}
+
+// Address returns the account derived on the device.
+func (s *HardwareSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignTx shows what is being signed and waits for it to be confirmed on
+// the device.
+func (s *HardwareSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.notify(fmt.Sprintf("Confirm on your %s: %s", s.name, Describe(tx, chainID)))
+	signed, err := s.wallet.SignTx(s.account, tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	return signed, nil
+}
+
+// Ping checks that the device is still connected and usable.
+func (s *HardwareSigner) Ping(ctx context.Context) error {
+	_, err := s.wallet.Status()
+	return err
+}
+
+// Close releases the device.
+func (s *HardwareSigner) Close() error {
+	return s.wallet.Close()
+}
+
+// Describe summarizes a transaction so that it can be checked against what
+// a hardware wallet displays.
+func Describe(tx *types.Transaction, chainID *big.Int) string {
+	var b strings.Builder
+	if tx.To() == nil {
+		fmt.Fprintf(&b, "deploy contract (%d bytes of code)", len(tx.Data()))
+	} else {
+		fmt.Fprintf(&b, "call %s", tx.To().Hex())
+		if len(tx.Data()) >= 4 {
+			fmt.Fprintf(&b, " with data 0x%x... (%d bytes)", tx.Data()[:4], len(tx.Data()))
+		}
+	}
+	fmt.Fprintf(&b, ", value %s wei, nonce %d, gas limit %d", tx.Value(), tx.Nonce(), tx.Gas())
+	gwei := new(big.Float).Quo(new(big.Float).SetInt(tx.GasFeeCap()), big.NewFloat(params.GWei))
+	fmt.Fprintf(&b, ", max fee %s gwei, chain %s", gwei.Text('f', 2), chainID)
+	return b.String()
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestDescribe(t *testing.T) {
+	to := common.HexToAddress("0x3A220f351252089D385b29beca14e27F204c296A")
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+		want string
+	}{
+		{
+			name: "call",
+			tx: types.NewTx(&types.DynamicFeeTx{
+				Nonce: 7, To: &to, Gas: 90000, GasFeeCap: big.NewInt(25 * params.GWei), Value: big.NewInt(0),
+				Data: []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02},
+			}),
+			want: "call 0x3A220f351252089D385b29beca14e27F204c296A with data 0xdeadbeef... (6 bytes), value 0 wei, nonce 7, gas limit 90000, max fee 25.00 gwei, chain 11155111",
+		},
+		{
+			name: "deployment",
+			tx:   types.NewTx(&types.LegacyTx{Gas: 500000, GasPrice: big.NewInt(1500000000), Data: make([]byte, 1234)}),
+			want: "deploy contract (1234 bytes of code), value 0 wei, nonce 0, gas limit 500000, max fee 1.50 gwei, chain 11155111",
+		},
+		{
+			name: "transfer",
+			tx:   types.NewTx(&types.LegacyTx{Nonce: 1, To: &to, Gas: 21000, GasPrice: big.NewInt(params.GWei), Value: big.NewInt(1000)}),
+			want: "call 0x3A220f351252089D385b29beca14e27F204c296A, value 1000 wei, nonce 1, gas limit 21000, max fee 1.00 gwei, chain 11155111",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signer.Describe(tt.tx, big.NewInt(11155111)); got != tt.want {
+				t.Errorf("Describe =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHardwareSignerWithoutDevice(t *testing.T) {
+	path, err := accounts.ParseDerivationPath("m/44'/60'/0'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.NewHardwareSigner(context.Background(), signer.HardwareOptions{Kind: "keepkey", Path: path}); err == nil || !strings.Contains(err.Error(), `unknown hardware wallet "keepkey"`) {
+		t.Errorf("unknown kind: got %v", err)
+	}
+
+	var notes []string
+	_, err = signer.NewHardwareSigner(context.Background(), signer.HardwareOptions{
+		Kind:           signer.Ledger,
+		Path:           path,
+		Notify:         func(message string) { notes = append(notes, message) },
+		ConnectTimeout: 50 * time.Millisecond,
+	})
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Skipf("no USB access: %v", err)
+	}
+	if err == nil {
+		t.Skip("a Ledger is connected")
+	}
+	if !strings.Contains(err.Error(), "Ledger: no device found") {
+		t.Errorf("got %v, want no device found", err)
+	}
+	if len(notes) != 1 || notes[0] != "Connect and unlock your Ledger" {
+		t.Errorf("notified %q, want one request to connect the device", notes)
+	}
+}
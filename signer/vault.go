@@ -0,0 +1,168 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultOptions locate a private key stored in a HashiCorp Vault KV secret.
+// Vault's transit engine has no secp256k1 keys, so the key is fetched and
+// kept in memory only.
+type VaultOptions struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// Namespace is sent as X-Vault-Namespace when set (Vault Enterprise).
+	Namespace string
+	// Token authenticates the requests. It is ignored when RoleID is set.
+	Token string
+	// RoleID and SecretID log in with the AppRole auth method mounted at
+	// AppRoleMount (default "approle").
+	RoleID       string
+	SecretID     string
+	AppRoleMount string
+	// Mount is the KV secrets engine mount, "secret" by default.
+	Mount string
+	// KVVersion is the KV engine version, 1 or 2 (the default).
+	KVVersion int
+	// Path of the secret inside the mount.
+	Path string
+	// Field of the secret holding the hex private key, "private_key" by
+	// default.
+	Field string
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+}
+
+// NewVaultSigner reads the private key from Vault and returns a signer for
+// it.
+func NewVaultSigner(ctx context.Context, opts VaultOptions) (*KeySigner, error) {
+	if opts.Address == "" || opts.Path == "" {
+		return nil, errors.New("vault: address and path are required")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.Mount == "" {
+		opts.Mount = "secret"
+	}
+	if opts.Field == "" {
+		opts.Field = "private_key"
+	}
+
+	token := opts.Token
+	if opts.RoleID != "" {
+		mount := opts.AppRoleMount
+		if mount == "" {
+			mount = "approle"
+		}
+		var login struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}
+		body := map[string]string{"role_id": opts.RoleID, "secret_id": opts.SecretID}
+		if err := vaultRequest(ctx, opts, http.MethodPost, "auth/"+mount+"/login", "", body, &login); err != nil {
+			return nil, fmt.Errorf("vault: approle login: %w", err)
+		}
+		token = login.Auth.ClientToken
+	}
+	if token == "" {
+		return nil, errors.New("vault: no token or approle configured")
+	}
+
+	var data map[string]interface{}
+	path := strings.Trim(opts.Mount, "/") + "/" + strings.TrimLeft(opts.Path, "/")
+	if opts.KVVersion == 1 {
+		var secret struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := vaultRequest(ctx, opts, http.MethodGet, path, token, nil, &secret); err != nil {
+			return nil, fmt.Errorf("vault: read %s: %w", path, err)
+		}
+		data = secret.Data
+	} else {
+		path = strings.Trim(opts.Mount, "/") + "/data/" + strings.TrimLeft(opts.Path, "/")
+		var secret struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		if err := vaultRequest(ctx, opts, http.MethodGet, path, token, nil, &secret); err != nil {
+			return nil, fmt.Errorf("vault: read %s: %w", path, err)
+		}
+		data = secret.Data.Data
+	}
+
+	key, ok := data[opts.Field].(string)
+	if !ok || key == "" {
+		return nil, fmt.Errorf("vault: secret %s has no %q field", path, opts.Field)
+	}
+	return NewKeySignerFromHex(strings.TrimSpace(key))
+}
+
+// vaultRequest calls the Vault HTTP API and decodes the JSON response
+// into out.
+func vaultRequest(ctx context.Context, opts VaultOptions, method, path, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(opts.Address, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if opts.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", opts.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return errors.New(resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
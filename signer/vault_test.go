@@ -0,0 +1,118 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	vaultKey     = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	vaultAccount = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+)
+
+// fakeVault serves one secret from a KV v1 mount "kv" and a KV v2 mount
+// "secret", and logs in the AppRole role-1/secret-1
+func fakeVault(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := func(status int, v interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(v)
+		}
+		denied := map[string][]string{"errors": {"permission denied"}}
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var login map[string]string
+			json.NewDecoder(r.Body).Decode(&login)
+			if r.Method != http.MethodPost || login["role_id"] != "role-1" || login["secret_id"] != "secret-1" {
+				reply(http.StatusBadRequest, map[string][]string{"errors": {"invalid role or secret ID"}})
+				return
+			}
+			reply(http.StatusOK, map[string]interface{}{"auth": map[string]string{"client_token": "approle-token"}})
+			return
+		}
+		token := r.Header.Get("X-Vault-Token")
+		if token != "root-token" && token != "approle-token" {
+			reply(http.StatusForbidden, denied)
+			return
+		}
+		secret := map[string]string{"private_key": vaultKey + "\n", "other": "not a key"}
+		switch r.URL.Path {
+		case "/v1/kv/app/key":
+			reply(http.StatusOK, map[string]interface{}{"data": secret})
+		case "/v1/secret/data/app/key":
+			if r.Header.Get("X-Vault-Namespace") != "team" {
+				reply(http.StatusNotFound, map[string][]string{"errors": {}})
+				return
+			}
+			reply(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"data": secret}})
+		default:
+			reply(http.StatusNotFound, map[string][]string{"errors": {}})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultSigner(t *testing.T) {
+	vault := fakeVault(t)
+	tests := []struct {
+		name string
+		opts signer.VaultOptions
+		err  string
+	}{
+		{"kv v2 with token", signer.VaultOptions{Token: "root-token", Namespace: "team", Path: "app/key"}, ""},
+		{"kv v1", signer.VaultOptions{Token: "root-token", Mount: "kv", KVVersion: 1, Path: "/app/key"}, ""},
+		{"approle", signer.VaultOptions{RoleID: "role-1", SecretID: "secret-1", Namespace: "team", Path: "app/key"}, ""},
+		{"approle rejected", signer.VaultOptions{RoleID: "role-1", SecretID: "wrong", Path: "app/key"}, "approle login: 400 Bad Request: invalid role or secret ID"},
+		{"wrong token", signer.VaultOptions{Token: "stale", Namespace: "team", Path: "app/key"}, "403 Forbidden: permission denied"},
+		{"not a key", signer.VaultOptions{Token: "root-token", Mount: "kv", KVVersion: 1, Path: "app/key", Field: "other"}, "invalid hex"},
+		{"missing field", signer.VaultOptions{Token: "root-token", Mount: "kv", KVVersion: 1, Path: "app/key", Field: "absent"}, `secret kv/app/key has no "absent" field`},
+		{"missing secret", signer.VaultOptions{Token: "root-token", Namespace: "team", Path: "app/other"}, "read secret/data/app/other: 404 Not Found"},
+		{"no credentials", signer.VaultOptions{Path: "app/key"}, "no token or approle configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Address = vault.URL + "/"
+			s, err := signer.NewVaultSigner(context.Background(), tt.opts)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got %v, want error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Address() != common.HexToAddress(vaultAccount) {
+				t.Errorf("address %s, want %s", s.Address().Hex(), vaultAccount)
+			}
+		})
+	}
+
+	if _, err := signer.NewVaultSigner(context.Background(), signer.VaultOptions{Token: "root-token"}); err == nil {
+		t.Error("options without address and path were accepted")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simchain runs an in-memory Ethereum chain in the process, so that
+// the storage contract can be deployed, written and read without an RPC
+// endpoint, funded keys or a network.
+//
+// The chain is a go-ethereum node with no networking and no data
+// directory. A simulated beacon mines a block as soon as a transaction is
+// sent, so transactions are mined right away and the chain only grows when
+// something is sent, on top of an empty block 1. Everything is lost when
+// the chain is closed.
+package simchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ChainID is the chain ID of the simulated chain, the one of geth's
+// development mode.
+const ChainID = 1337
+
+// DevKey is the private key of the geth development mode account, which
+// the simulated chain funds unless Options.Accounts is set. It is public:
+// never use it on a real network.
+const DevKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+// indexTimeout bounds the wait for the transaction indexer to start.
+const indexTimeout = 10 * time.Second
+
+// DefaultBalance is the balance of each funded account, 1000 ether.
+var DefaultBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+
+// Options configures a simulated chain.
+type Options struct {
+	// Accounts are funded in the genesis block, the account of DevKey
+	// when empty.
+	Accounts []common.Address
+	// Balance of each account, DefaultBalance when nil.
+	Balance *big.Int
+	// GasLimit of the blocks, the default of geth when zero.
+	GasLimit uint64
+}
+
+// Chain is a running simulated chain.
+type Chain struct {
+	stack  *node.Node
+	beacon *catalyst.SimulatedBeacon
+}
+
+// DevAccount returns the key and address of DevKey.
+func DevAccount() (*ecdsa.PrivateKey, common.Address) {
+	key, err := crypto.HexToECDSA(DevKey)
+	if err != nil {
+		panic(err)
+	}
+	return key, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// New starts a simulated chain.
+func New(opts Options) (*Chain, error) {
+	accounts := opts.Accounts
+	if len(accounts) == 0 {
+		_, dev := DevAccount()
+		accounts = []common.Address{dev}
+	}
+	balance := opts.Balance
+	if balance == nil {
+		balance = DefaultBalance
+	}
+	alloc := types.GenesisAlloc{}
+	for _, a := range accounts {
+		alloc[a] = types.Account{Balance: new(big.Int).Set(balance)}
+	}
+	genesis := &core.Genesis{
+		Config:   params.AllDevChainProtocolChanges,
+		GasLimit: ethconfig.Defaults.Miner.GasCeil,
+		BaseFee:  big.NewInt(params.InitialBaseFee),
+		Alloc:    alloc,
+	}
+	if opts.GasLimit != 0 {
+		genesis.GasLimit = opts.GasLimit
+	}
+
+	// No data directory, IPC endpoint or peers
+	nodeConf := node.DefaultConfig
+	nodeConf.DataDir = ""
+	nodeConf.IPCPath = ""
+	nodeConf.P2P = p2p.Config{NoDiscovery: true}
+	stack, err := node.New(&nodeConf)
+	if err != nil {
+		return nil, fmt.Errorf("create node: %w", err)
+	}
+
+	ethConf := ethconfig.Defaults
+	ethConf.Genesis = genesis
+	ethConf.SyncMode = ethconfig.FullSync
+	ethConf.TxPool.NoLocals = true
+	if opts.GasLimit != 0 {
+		ethConf.Miner.GasCeil = opts.GasLimit
+	}
+	backend, err := eth.New(stack, &ethConf)
+	if err != nil {
+		stack.Close()
+		return nil, fmt.Errorf("create eth service: %w", err)
+	}
+	// The log filters of eth_getLogs and eth_newFilter are a service of
+	// their own
+	filterSystem := filters.NewFilterSystem(backend.APIBackend, filters.Config{})
+	stack.RegisterAPIs([]rpc.API{{Namespace: "eth", Service: filters.NewFilterAPI(filterSystem)}})
+	// Period 0 mines a block as soon as a transaction is sent, which the
+	// API of the beacon takes care of
+	beacon, err := catalyst.NewSimulatedBeacon(0, common.Address{}, backend)
+	if err != nil {
+		stack.Close()
+		return nil, fmt.Errorf("create beacon: %w", err)
+	}
+	catalyst.RegisterSimulatedBeaconAPIs(stack, beacon)
+	stack.RegisterLifecycle(beacon)
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return nil, fmt.Errorf("start node: %w", err)
+	}
+	// Receipts are looked up with an error until the transaction indexer
+	// has gone through the chain, which it only does once a block is
+	// mined: mine an empty one
+	beacon.Commit()
+	for deadline := time.Now().Add(indexTimeout); ; time.Sleep(10 * time.Millisecond) {
+		if progress, err := backend.BlockChain().TxIndexProgress(); err != nil || progress.Done() {
+			break
+		}
+		if time.Now().After(deadline) {
+			stack.Close()
+			return nil, errors.New("transaction indexer did not start")
+		}
+	}
+	return &Chain{stack: stack, beacon: beacon}, nil
+}
+
+// Client returns a new connection to the chain, which the caller closes.
+func (c *Chain) Client() *ethclient.Client {
+	return ethclient.NewClient(c.stack.Attach())
+}
+
+// Commit mines a block even if no transaction is pending, which is how
+// the time of the chain moves on.
+func (c *Chain) Commit() common.Hash {
+	return c.beacon.Commit()
+}
+
+// Close stops the chain, discarding its state.
+func (c *Chain) Close() error {
+	return c.stack.Close()
+}
+
+// Deploy deploys contract creation code signed by key and returns the
+// address of the contract once it is mined.
+func (c *Chain) Deploy(ctx context.Context, key *ecdsa.PrivateKey, code []byte) (common.Address, error) {
+	client := c.Client()
+	defer client.Close()
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(ChainID))
+	if err != nil {
+		return common.Address{}, err
+	}
+	auth.Context = ctx
+	address, tx, _, err := bind.DeployContract(auth, abi.ABI{}, code, client)
+	if err != nil {
+		return common.Address{}, err
+	}
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, fmt.Errorf("deployment %s reverted", tx.Hash().Hex())
+	}
+	return address, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simchain_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"contract-storage-eth/simchain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// answer is the creation code of a contract whose every call returns 42
+var answer = common.FromHex("69602a60005260206000f3600052600a6016f3")
+
+func TestDeployAndCall(t *testing.T) {
+	sim, err := simchain.New(simchain.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	ctx := context.Background()
+
+	key, dev := simchain.DevAccount()
+	address, err := sim.Deploy(ctx, key, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := sim.Client()
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil || chainID.Int64() != simchain.ChainID {
+		t.Fatalf("chain ID %v, %v", chainID, err)
+	}
+	if n, err := client.BlockNumber(ctx); err != nil || n != 2 {
+		t.Errorf("block %d, %v after the empty first block and one transaction", n, err)
+	}
+	out, err := client.CallContract(ctx, ethereum.CallMsg{From: dev, To: &address}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := new(big.Int).SetBytes(out); got.Int64() != 42 {
+		t.Errorf("call returned %x", out)
+	}
+	balance, err := client.BalanceAt(ctx, dev, nil)
+	if err != nil || balance.Cmp(simchain.DefaultBalance) >= 0 {
+		t.Errorf("balance %v, %v after paying for the deployment", balance, err)
+	}
+}
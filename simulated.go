@@ -0,0 +1,107 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"contract-storage-eth/simchain"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// simulatedChain is the in-memory chain of --simulated, which dialEndpoints
+// connects to instead of the configured endpoints
+var simulatedChain *simchain.Chain
+
+// setupSimulated starts an in-memory chain for --simulated and points the
+// configuration at it, with the funded development account as the signer.
+// Every command but deploy finds the contract of
+// the build directory deployed already. The returned function stops the
+// chain and removes the temporary directory
+func setupSimulated(ctx context.Context, config *Config, command string) (func(), error) {
+	dir, err := os.MkdirTemp("", "contract-storage-eth-simulated-")
+	if err != nil {
+		return nil, err
+	}
+	sim, err := simchain.New(simchain.Options{})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	cleanup := func() {
+		sim.Close()
+		os.RemoveAll(dir)
+	}
+
+	simulatedChain = sim
+	useDevChain(config, URLList{"simulated"}, dir)
+	config.Ethereum.KeyConfig = KeyConfig{PrivateKey: simchain.DevKey}
+	config.Ethereum.Standby.KeyConfig = KeyConfig{}
+	fmt.Fprintf(os.Stderr, "Using a simulated chain (chain ID %d), discarded on exit\n", simchain.ChainID)
+
+	if command == "deploy" || command == "help" {
+		return cleanup, nil
+	}
+	bytecodeFile := filepath.Join(config.Build.Directory, config.Build.ContractName+".bin")
+	bytecode, err := os.ReadFile(bytecodeFile)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	key, _ := simchain.DevAccount()
+	address, err := sim.Deploy(ctx, key, common.FromHex(strings.TrimSpace(string(bytecode))))
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("deploy %s: %w", bytecodeFile, err)
+	}
+	config.Contract.Address = address.Hex()
+	fmt.Fprintf(os.Stderr, "Deployed %s to the simulated chain at %s\n", config.Build.ContractName, address.Hex())
+	return cleanup, nil
+}
+
+// useDevChain points the configuration at a development chain served at
+// urls, which forgets everything when stopped: the index and state files go
+// to dir, and transactions count as confirmed once mined. The chain mines
+// no block but for the transactions sent, so waiting for more than the
+// block of a transaction would never end
+func useDevChain(config *Config, urls URLList, dir string) {
+	config.Ethereum.RpcURL = urls
+	config.Ethereum.WsURL = ""
+	config.Ethereum.ChainID = simchain.ChainID
+	config.Ethereum.Rollup = ""
+	config.Safe.Address = ""
+	config.Replication.Networks = nil
+	config.Confirmation.Confirmations = 1
+	config.Confirmation.Finalized = false
+	config.Confirmation.PollInterval = 100 * time.Millisecond
+	config.Reorg.Depth = 0
+	config.Index.Path = filepath.Join(dir, "index.db")
+	config.Server.Idempotency.File = filepath.Join(dir, "idempotency.db")
+	config.Index.StartBlock = 0
+	config.Index.SyncInterval = time.Second
+	config.State.File = filepath.Join(dir, "pending_tx.json")
+	config.WriteQueue.File = filepath.Join(dir, "write_queue.json")
+	config.Replication.File = filepath.Join(dir, "replication.json")
+	config.Publish.CursorFile = filepath.Join(dir, "publish_cursor.json")
+	config.Audit.File = filepath.Join(dir, "audit.log")
+	config.Deployments.File = filepath.Join(dir, "deployments.json")
+	config.Outbox.File = filepath.Join(dir, "outbox.db")
+}
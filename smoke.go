@@ -0,0 +1,166 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/chunk"
+	"contract-storage-eth/smoke"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tombstone"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func runSmokeTest(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("smoke-test", flag.ContinueOnError)
+	only := flags.String("case", "", "comma-separated names of the cases to run, all by default")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	cases, err := smokeCases(config, *only)
+	if err != nil {
+		return fail(codeConfig, "Invalid smoke_test config: %w", err)
+	}
+	s, closeService, err := cliRecordService(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	fmt.Printf("Running %d smoke test case(s) against %s\n", len(cases), s.address.Hex())
+	result := runSmoke(ctx, s, cases)
+	if !result.OK {
+		printFailure("smoke_test_failed", fmt.Sprintf("%d of %d case(s) failed", result.Failed(), len(result.Cases)), result)
+		return exitError(1)
+	}
+	printResult(result)
+	return nil
+}
+
+// smokeCases returns the configured cases, the default matrix when none
+// is, restricted to the comma-separated names of only
+func smokeCases(config *Config, only string) ([]smoke.Case, error) {
+	cases := config.SmokeTest.Cases
+	if len(cases) == 0 {
+		cases = smoke.DefaultCases
+	}
+	if err := smoke.Validate(cases); err != nil {
+		return nil, err
+	}
+	if only == "" {
+		return cases, nil
+	}
+	var selected []smoke.Case
+	for _, name := range strings.Split(only, ",") {
+		i := slices.IndexFunc(cases, func(c smoke.Case) bool { return c.Name == strings.TrimSpace(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("no case named %q", name)
+		}
+		selected = append(selected, cases[i])
+	}
+	return selected, nil
+}
+
+// runSmoke runs cases through the record service, printing each operation
+func runSmoke(ctx context.Context, s *recordService, cases []smoke.Case) *smoke.Result {
+	runner := &smoke.Runner{
+		KeyPrefix: s.config.SmokeTest.KeyPrefix,
+		Write: func(ctx context.Context, key, field, value string) (common.Hash, error) {
+			var written *api.WriteResult
+			var err error
+			if value == "" {
+				written, err = s.Delete(ctx, key, field)
+			} else {
+				written, err = s.Save(ctx, api.SaveRequest{Key: key, Field: field, Value: value})
+			}
+			if err != nil {
+				return common.Hash{}, err
+			}
+			return written.TxHash, nil
+		},
+		Read: func(ctx context.Context, key, field string) (string, bool, error) {
+			record, err := s.Get(ctx, key, field)
+			if errors.Is(err, api.ErrNotFound) {
+				return "", false, nil
+			} else if err != nil {
+				return "", false, err
+			}
+			return record.Content, true, nil
+		},
+		Event: func(ctx context.Context, tx common.Hash, key, field string) ([]string, error) {
+			return smokeEvents(ctx, s, tx, keyNamespace.Key(key), field)
+		},
+		OnOp: func(c *smoke.CaseResult, op *smoke.OpResult) {
+			if op.OK {
+				fmt.Printf("PASS  %-10s %-7s %s\n", c.Name, op.Op, op.Duration.Round(time.Millisecond))
+			} else {
+				fmt.Printf("FAIL  %-10s %-7s %s: %s\n", c.Name, op.Op, op.Duration.Round(time.Millisecond), op.Error)
+			}
+		},
+	}
+	result := runner.Run(ctx, cases)
+	fmt.Printf("Smoke test: %d of %d case(s) passed in %s\n", len(result.Cases)-result.Failed(), len(result.Cases), result.Duration.Round(time.Millisecond))
+	return result
+}
+
+// smokeEvents returns the contents of the DataSaved events tx emitted for
+// the stored key and field, empty for deletes. Chunk manifests are only
+// opened when they are what the contract holds now
+func smokeEvents(ctx context.Context, s *recordService, tx common.Hash, key, field string) ([]string, error) {
+	receipt, err := s.client.TransactionReceipt(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	var contents []string
+	for _, l := range receipt.Logs {
+		if l.Address != s.address {
+			continue
+		}
+		ev, err := storage.ParseDataSaved(*l)
+		if err != nil || ev.Key != key || ev.Field != field {
+			continue
+		}
+		value := ev.Value
+		if tombstone.Is(value) {
+			value = ""
+		} else if m, err := chunk.Parse(value); err == nil && m != nil {
+			if stored, err := readValue(ctx, s.client, s.config, s.address, key, field, nil); err == nil && stored == value {
+				value, err = readRecordValue(ctx, s.client, s.config, s.address, key, field, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else if value, err = resolveValue(ctx, s.config, value); err != nil {
+			return nil, err
+		}
+		content := ""
+		if value != "" {
+			content, _ = recordContent(value)
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package smoke runs a matrix of save, get, delete and event checks
+// against a deployed storage contract, for verifying a deployment before
+// it takes traffic. Each case is a record and the operations run on it in
+// order; a case stops at its first failed operation, and the run fails if
+// any case does.
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Operations of a case.
+const (
+	// OpSave writes the value of the case.
+	OpSave = "save"
+	// OpGet reads the record back: it must hold the value of the case,
+	// or be unset after a delete.
+	OpGet = "get"
+	// OpEvent checks the DataSaved event of the latest save or delete of
+	// the case.
+	OpEvent = "event"
+	// OpDelete deletes the record.
+	OpDelete = "delete"
+)
+
+// DefaultKeyPrefix is put in front of the names of the cases to make
+// their keys.
+const DefaultKeyPrefix = "_smoke/"
+
+// DefaultOps are the operations of a case that lists none.
+var DefaultOps = []string{OpSave, OpEvent, OpGet, OpDelete, OpEvent, OpGet}
+
+// DefaultCases is the matrix run when none is configured: short, Unicode,
+// JSON and longer values, each saved, checked and deleted again.
+var DefaultCases = []Case{
+	{Name: "text", Value: "smoke test"},
+	{Name: "unicode", Value: "grüße, 世界 ✓"},
+	{Name: "json", Value: `{"smoke":true,"n":1}`},
+	{Name: "long", Value: longValue},
+}
+
+var longValue = func() string {
+	b := make([]byte, 512)
+	for i := range b {
+		b[i] = "abcdefghijklmnopqrstuvwxyz0123456789"[i%36]
+	}
+	return string(b)
+}()
+
+// Case is a record of the matrix.
+type Case struct {
+	Name string `yaml:"name" json:"name"`
+	// Key is the key of the record, DefaultKeyPrefix and Name when empty.
+	Key string `yaml:"key" json:"key,omitempty"`
+	// Field is the field of the record, "value" when empty.
+	Field string `yaml:"field" json:"field,omitempty"`
+	Value string `yaml:"value" json:"value"`
+	// Ops run in order, DefaultOps when empty. Without a save, get checks
+	// the value the contract holds already.
+	Ops []string `yaml:"ops" json:"ops,omitempty"`
+}
+
+// Validate checks the names and operations of cases.
+func Validate(cases []Case) error {
+	names := map[string]bool{}
+	for i, c := range cases {
+		if c.Name == "" {
+			return fmt.Errorf("case %d has no name", i+1)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("case %s is listed twice", c.Name)
+		}
+		names[c.Name] = true
+		written := false
+		for _, op := range c.Ops {
+			switch op {
+			case OpSave, OpDelete:
+				written = true
+			case OpGet:
+			case OpEvent:
+				if !written {
+					return fmt.Errorf("case %s: %s needs a save or delete before it", c.Name, op)
+				}
+			default:
+				return fmt.Errorf("case %s: unknown operation %q, want %s, %s, %s or %s", c.Name, op, OpSave, OpGet, OpEvent, OpDelete)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFunc saves value under key and field, or deletes the record when
+// value is empty, and returns the mined transaction.
+type WriteFunc func(ctx context.Context, key, field, value string) (common.Hash, error)
+
+// ReadFunc returns the content of a record, found false when it is unset.
+type ReadFunc func(ctx context.Context, key, field string) (content string, found bool, err error)
+
+// EventFunc returns the contents of the DataSaved events tx emitted for key
+// and field.
+type EventFunc func(ctx context.Context, tx common.Hash, key, field string) ([]string, error)
+
+// Runner runs the cases against a contract.
+type Runner struct {
+	Write WriteFunc
+	Read  ReadFunc
+	Event EventFunc
+	// KeyPrefix makes the keys of the cases without one,
+	// DefaultKeyPrefix when empty.
+	KeyPrefix string
+	// OnOp is called after every operation.
+	OnOp func(c *CaseResult, op *OpResult)
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	OK       bool          `json:"ok"`
+	Duration time.Duration `json:"duration"`
+	Cases    []*CaseResult `json:"cases"`
+}
+
+// Failed returns the number of failed cases.
+func (r *Result) Failed() int {
+	n := 0
+	for _, c := range r.Cases {
+		if !c.OK {
+			n++
+		}
+	}
+	return n
+}
+
+// CaseResult is the outcome of a case.
+type CaseResult struct {
+	Name  string      `json:"name"`
+	Key   string      `json:"key"`
+	Field string      `json:"field"`
+	OK    bool        `json:"ok"`
+	Ops   []*OpResult `json:"ops"`
+}
+
+// OpResult is the outcome of an operation.
+type OpResult struct {
+	Op       string        `json:"op"`
+	OK       bool          `json:"ok"`
+	Duration time.Duration `json:"duration"`
+	TxHash   *common.Hash  `json:"tx_hash,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Run runs cases in order.
+func (r *Runner) Run(ctx context.Context, cases []Case) *Result {
+	start := time.Now()
+	result := &Result{OK: true}
+	for _, c := range cases {
+		cr := r.runCase(ctx, c)
+		result.Cases = append(result.Cases, cr)
+		result.OK = result.OK && cr.OK
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case) *CaseResult {
+	prefix := r.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	cr := &CaseResult{Name: c.Name, Key: c.Key, Field: c.Field, OK: true}
+	if cr.Key == "" {
+		cr.Key = prefix + c.Name
+	}
+	if cr.Field == "" {
+		cr.Field = "value"
+	}
+	ops := c.Ops
+	if len(ops) == 0 {
+		ops = DefaultOps
+	}
+
+	// want is what the record should hold, unset after a delete or when
+	// the case has an empty value
+	want, set := c.Value, c.Value != ""
+	var last common.Hash
+	for _, op := range ops {
+		start := time.Now()
+		or := &OpResult{Op: op}
+		var err error
+		switch op {
+		case OpSave, OpDelete:
+			value := c.Value
+			if op == OpDelete {
+				value = ""
+			}
+			if last, err = r.Write(ctx, cr.Key, cr.Field, value); err == nil {
+				or.TxHash = &last
+				want, set = value, value != ""
+			}
+		case OpGet:
+			err = r.checkRead(ctx, cr.Key, cr.Field, want, set)
+		case OpEvent:
+			err = r.checkEvent(ctx, last, cr.Key, cr.Field, want)
+		default:
+			err = fmt.Errorf("unknown operation %q", op)
+		}
+		or.Duration = time.Since(start)
+		if err != nil {
+			or.Error = err.Error()
+		} else {
+			or.OK = true
+		}
+		cr.Ops = append(cr.Ops, or)
+		if r.OnOp != nil {
+			r.OnOp(cr, or)
+		}
+		if err != nil {
+			cr.OK = false
+			break
+		}
+	}
+	return cr
+}
+
+func (r *Runner) checkRead(ctx context.Context, key, field, want string, set bool) error {
+	content, found, err := r.Read(ctx, key, field)
+	switch {
+	case err != nil:
+		return err
+	case !set && found:
+		return fmt.Errorf("read %q, want the record unset", abbreviate(content))
+	case set && !found:
+		return fmt.Errorf("record is unset, want %q", abbreviate(want))
+	case set && content != want:
+		return fmt.Errorf("read %q, want %q", abbreviate(content), abbreviate(want))
+	}
+	return nil
+}
+
+func (r *Runner) checkEvent(ctx context.Context, tx common.Hash, key, field, want string) error {
+	if tx == (common.Hash{}) {
+		return fmt.Errorf("no save or delete to check the event of")
+	}
+	contents, err := r.Event(ctx, tx, key, field)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(contents, want) {
+		return fmt.Errorf("transaction %s emitted %d DataSaved event(s) for %s#%s, none with %q", tx.Hex(), len(contents), key, field, abbreviate(want))
+	}
+	return nil
+}
+
+// abbreviate shortens long values in messages
+func abbreviate(s string) string {
+	if len(s) <= 64 {
+		return s
+	}
+	return s[:61] + "..."
+}
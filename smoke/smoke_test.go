@@ -0,0 +1,128 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smoke_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/smoke"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeContract keeps written values and the value each transaction wrote
+type fakeContract struct {
+	values map[string]string
+	events map[common.Hash]string
+	// lost drops the saves of some keys, which then read as before
+	lost string
+}
+
+func newFakeContract() *fakeContract {
+	return &fakeContract{values: map[string]string{}, events: map[common.Hash]string{}}
+}
+
+func (c *fakeContract) runner() *smoke.Runner {
+	return &smoke.Runner{
+		Write: func(ctx context.Context, key, field, value string) (common.Hash, error) {
+			tx := common.BigToHash(big.NewInt(int64(len(c.events) + 1)))
+			c.events[tx] = value
+			if key != c.lost {
+				c.values[key+"#"+field] = value
+			}
+			return tx, nil
+		},
+		Read: func(ctx context.Context, key, field string) (string, bool, error) {
+			value := c.values[key+"#"+field]
+			return value, value != "", nil
+		},
+		Event: func(ctx context.Context, tx common.Hash, key, field string) ([]string, error) {
+			value, ok := c.events[tx]
+			if !ok {
+				return nil, fmt.Errorf("no transaction %s", tx.Hex())
+			}
+			return []string{value}, nil
+		},
+	}
+}
+
+func TestDefaultCases(t *testing.T) {
+	c := newFakeContract()
+	result := c.runner().Run(context.Background(), smoke.DefaultCases)
+	if !result.OK || len(result.Cases) != len(smoke.DefaultCases) {
+		t.Fatalf("result %+v", result)
+	}
+	for _, cr := range result.Cases {
+		if !cr.OK || len(cr.Ops) != len(smoke.DefaultOps) || cr.Key != smoke.DefaultKeyPrefix+cr.Name || cr.Field != "value" {
+			t.Errorf("case %+v", cr)
+		}
+	}
+	if len(c.events) != 2*len(smoke.DefaultCases) {
+		t.Errorf("%d writes, want a save and a delete per case", len(c.events))
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	c := newFakeContract()
+	c.lost = "_smoke/lost"
+	c.values["_smoke/stale#value"] = "old"
+	r := c.runner()
+	r.OnOp = func(cr *smoke.CaseResult, op *smoke.OpResult) {
+		if cr.Name == "lost" && op.Op == smoke.OpDelete {
+			t.Error("lost ran past its failed get")
+		}
+	}
+	result := r.Run(context.Background(), []smoke.Case{
+		{Name: "lost", Value: "a", Ops: []string{smoke.OpSave, smoke.OpEvent, smoke.OpGet, smoke.OpDelete}},
+		{Name: "stale", Value: "new", Ops: []string{smoke.OpGet}},
+		{Name: "ok", Value: "b"},
+	})
+	if result.OK || result.Failed() != 2 {
+		t.Fatalf("result %+v, want 2 failed cases", result)
+	}
+	lost := result.Cases[0]
+	if len(lost.Ops) != 3 || !lost.Ops[1].OK || lost.Ops[2].OK || !strings.Contains(lost.Ops[2].Error, "unset") {
+		t.Errorf("lost %+v", lost.Ops)
+	}
+	if stale := result.Cases[1]; stale.OK || !strings.Contains(stale.Ops[0].Error, `"old"`) {
+		t.Errorf("stale %+v", stale.Ops[0])
+	}
+	if !result.Cases[2].OK {
+		t.Error("a failed case stopped the next ones")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cases []smoke.Case
+		err   string
+	}{
+		"default":         {smoke.DefaultCases, ""},
+		"no name":         {[]smoke.Case{{Value: "a"}}, "no name"},
+		"duplicate":       {[]smoke.Case{{Name: "a"}, {Name: "a"}}, "twice"},
+		"unknown op":      {[]smoke.Case{{Name: "a", Ops: []string{"put"}}}, "unknown operation"},
+		"event first":     {[]smoke.Case{{Name: "a", Ops: []string{smoke.OpEvent, smoke.OpSave}}}, "needs a save"},
+		"event of delete": {[]smoke.Case{{Name: "a", Ops: []string{smoke.OpDelete, smoke.OpEvent}}}, ""},
+	} {
+		err := smoke.Validate(tc.cases)
+		if (err == nil) != (tc.err == "") || err != nil && !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got %v, want %q", name, err, tc.err)
+		}
+	}
+}
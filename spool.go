@@ -0,0 +1,311 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/chain"
+	"contract-storage-eth/idempotency"
+)
+
+// Subdirectories of the spool directory: a request is claimed into
+// processing before it is written, then filed under done or failed
+const (
+	spoolProcessing = "processing"
+	spoolDone       = "done"
+	spoolFailed     = "failed"
+)
+
+// spoolReceipt is written to done/NAME.receipt.json for a saved request
+type spoolReceipt struct {
+	File string `json:"file"`
+	api.WriteResult
+	GasUsed uint64    `json:"gas_used,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// spoolFailure is written to failed/NAME.error.json for a request that
+// could not be saved
+type spoolFailure struct {
+	File     string    `json:"file"`
+	Code     string    `json:"code"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// spoolSummary is the result of spool --once
+type spoolSummary struct {
+	Saved  int `json:"saved"`
+	Failed int `json:"failed"`
+}
+
+func runSpool(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("spool", flag.ContinueOnError)
+	dir := flags.String("dir", config.Spool.Directory, "directory watched for *.json write requests")
+	fifo := flags.String("fifo", config.Spool.FIFO, "named pipe read for write requests, one JSON object per line")
+	interval := flags.Duration("interval", config.Spool.PollInterval, "how often the directory is checked")
+	once := flags.Bool("once", false, "process the requests in the directory and exit")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		*dir = "spool"
+	}
+	if *interval <= 0 {
+		*interval = 2 * time.Second
+	}
+	for _, sub := range []string{spoolProcessing, spoolDone, spoolFailed} {
+		if err := os.MkdirAll(filepath.Join(*dir, sub), 0o755); err != nil {
+			return fmt.Errorf("Failed to create spool directory: %w", err)
+		}
+	}
+	if *fifo != "" {
+		if info, err := os.Stat(*fifo); err != nil {
+			return fail(codeInvalidArgument, "Invalid --fifo: %w", err)
+		} else if info.Mode()&os.ModeNamedPipe == 0 {
+			return fail(codeInvalidArgument, "Invalid --fifo: %s is not a named pipe, create it with mkfifo", *fifo)
+		}
+	}
+
+	records, closeRecords, err := cliRecordService(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeRecords()
+	var writes api.Records = records
+	if config.Server.Idempotency.File != "" {
+		keys, err := idempotency.Open(config.Server.Idempotency.File, config.Server.Idempotency.TTL)
+		if err != nil {
+			return fmt.Errorf("Failed to open idempotency store: %w", err)
+		}
+		defer keys.Close()
+		writes = idempotency.Records(records, keys, records.ns.String())
+	}
+	sp := &spooler{dir: *dir, records: writes, client: records.client}
+	if err := sp.recover(); err != nil {
+		return fmt.Errorf("Failed to recover spool: %w", err)
+	}
+
+	if *once {
+		if err := sp.processAll(ctx); err != nil {
+			return fmt.Errorf("Spool stopped: %w", err)
+		}
+		fmt.Printf("Processed %d request(s): %d saved, %d failed\n", sp.saved+sp.failed, sp.saved, sp.failed)
+		summary := spoolSummary{Saved: sp.saved, Failed: sp.failed}
+		if sp.failed > 0 {
+			printFailure("spool_failed", fmt.Sprintf("%d request(s) failed", sp.failed), summary)
+			return exitError(1)
+		}
+		printResult(summary)
+		return nil
+	}
+
+	if *fifo != "" {
+		go sp.readFIFO(ctx, *fifo, *interval)
+	}
+	fmt.Printf("Watching %s for write requests, press Ctrl+C to stop\n", *dir)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if err := sp.processAll(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to read spool directory", "dir", *dir, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// spooler saves the requests of a spool directory one at a time, in the
+// order of their names
+type spooler struct {
+	dir     string
+	records api.Records
+	client  *chain.Client
+
+	saved, failed int
+}
+
+// recover puts back the requests a stopped spool was processing, so that
+// they are processed again
+func (sp *spooler) recover() error {
+	entries, err := os.ReadDir(filepath.Join(sp.dir, spoolProcessing))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		slog.Warn("Retrying spooled request interrupted earlier", "file", entry.Name())
+		if err := os.Rename(filepath.Join(sp.dir, spoolProcessing, entry.Name()), filepath.Join(sp.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processAll processes the requests waiting in the directory. Files not
+// named *.json are left alone, so that other systems can write a request
+// under another name and rename it once complete
+func (sp *spooler) processAll(ctx context.Context) error {
+	entries, err := os.ReadDir(sp.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := sp.process(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process saves one request, returning an error only when the spool
+// cannot go on
+func (sp *spooler) process(ctx context.Context, name string) error {
+	claimed := filepath.Join(sp.dir, spoolProcessing, name)
+	if err := os.Rename(filepath.Join(sp.dir, name), claimed); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(claimed)
+	if err != nil {
+		return err
+	}
+
+	var req api.SaveRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return sp.fail(name, fmt.Errorf("%w: %v", api.ErrInvalidRecord, err))
+	}
+	result, err := sp.records.Save(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		// Interrupted: processed again at the next start
+		return ctx.Err()
+	}
+	if err != nil {
+		return sp.fail(name, err)
+	}
+
+	receipt := spoolReceipt{File: name, WriteResult: *result, SavedAt: time.Now().UTC()}
+	if !result.Replayed {
+		if r, err := sp.client.TransactionReceipt(ctx, result.TxHash); err == nil {
+			receipt.GasUsed = r.GasUsed
+		}
+	}
+	dest := sp.destination(spoolDone, name)
+	if err := writeSpoolJSON(strings.TrimSuffix(dest, ".json")+".receipt.json", receipt); err != nil {
+		return err
+	}
+	if err := os.Rename(claimed, dest); err != nil {
+		return err
+	}
+	sp.saved++
+	fmt.Printf("Saved %s as %s in block %d\n", name, result.ID, result.Block)
+	streamResult(receipt)
+	return nil
+}
+
+// fail files a request under failed, next to its error
+func (sp *spooler) fail(name string, err error) error {
+	failure := spoolFailure{File: name, Code: errorCode(err), Error: err.Error(), FailedAt: time.Now().UTC()}
+	if errors.Is(err, api.ErrInvalidRecord) {
+		failure.Code = codeInvalidArgument
+	}
+	dest := sp.destination(spoolFailed, name)
+	if err := writeSpoolJSON(strings.TrimSuffix(dest, ".json")+".error.json", failure); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(sp.dir, spoolProcessing, name), dest); err != nil {
+		return err
+	}
+	sp.failed++
+	slog.Warn("Spooled request failed", "file", name, "error", err)
+	streamResult(failure)
+	return nil
+}
+
+// destination returns where a request is filed under sub, keeping earlier
+// requests of the same name by suffixing the time to the new one
+func (sp *spooler) destination(sub, name string) string {
+	dest := filepath.Join(sp.dir, sub, name)
+	if _, err := os.Stat(dest); err == nil {
+		dest = filepath.Join(sp.dir, sub, strings.TrimSuffix(name, ".json")+"."+time.Now().UTC().Format("20060102T150405.000000000")+".json")
+	}
+	return dest
+}
+
+// readFIFO turns each line written to the named pipe into a request file
+// of the directory, named after the time it was read
+func (sp *spooler) readFIFO(ctx context.Context, path string, retry time.Duration) {
+	n := 0
+	for ctx.Err() == nil {
+		// Opening blocks until a writer opens the pipe, and reading ends
+		// when the last one closes it
+		f, err := os.Open(path)
+		if err != nil {
+			slog.Error("Failed to open spool FIFO", "path", path, "error", err)
+			time.Sleep(retry)
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			n++
+			name := fmt.Sprintf("fifo-%s-%06d.json", time.Now().UTC().Format("20060102T150405.000"), n)
+			if err := writeFileAtomic(filepath.Join(sp.dir, name), append(slices.Clone(line), '\n')); err != nil {
+				slog.Error("Failed to spool FIFO request", "path", path, "error", err)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			slog.Error("Failed to read spool FIFO", "path", path, "error", err)
+		}
+		f.Close()
+	}
+}
+
+func writeSpoolJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/api"
+)
+
+// spoolRecords saves every request but those of key "paused", as replays
+// of earlier saves so that no receipt is looked up
+type spoolRecords struct{ saved []string }
+
+func (r *spoolRecords) Save(ctx context.Context, req api.SaveRequest) (*api.WriteResult, error) {
+	if req.Key == "paused" {
+		return nil, api.ErrRejected
+	}
+	r.saved = append(r.saved, req.Key+"#"+req.Field+"="+req.Value)
+	return &api.WriteResult{ID: req.Key + "#" + req.Field, Block: 7, Replayed: true}, nil
+}
+
+func (r *spoolRecords) Get(ctx context.Context, key, field string) (*api.Record, error) {
+	return nil, api.ErrNotFound
+}
+
+func (r *spoolRecords) Delete(ctx context.Context, key, field string) (*api.WriteResult, error) {
+	return nil, api.ErrNotFound
+}
+
+// listDir returns the names of the files of dir
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func TestSpooler(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{spoolProcessing, spoolDone, spoolFailed} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"processing/0-interrupted.json": `{"key": "a", "field": "f", "value": "0"}`,
+		"1-save.json":                   `{"key": "a", "field": "f", "value": "1"}`,
+		"2-unknown-field.json":          `{"key": "a", "value": "2", "color": "red"}`,
+		"3-rejected.json":               `{"key": "paused", "field": "f", "value": "3"}`,
+		"4-partial.json.tmp":            `{"key": "a"`,
+		".5-hidden.json":                `{"key": "a", "field": "f", "value": "5"}`,
+		"done/1-save.json":              `{"key": "a", "field": "f", "value": "earlier"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := &spoolRecords{}
+	sp := &spooler{dir: dir, records: records}
+	if err := sp.recover(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.processAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests are saved in the order of their names, the interrupted one
+	// again
+	if want := []string{"a#f=0", "a#f=1"}; !slices.Equal(records.saved, want) {
+		t.Errorf("saved %v, want %v", records.saved, want)
+	}
+	if sp.saved != 2 || sp.failed != 2 {
+		t.Errorf("%d saved and %d failed, want 2 and 2", sp.saved, sp.failed)
+	}
+	if left := listDir(t, dir); !slices.Equal(left, []string{".5-hidden.json", "4-partial.json.tmp"}) {
+		t.Errorf("left in the spool: %v", left)
+	}
+	if left := listDir(t, filepath.Join(dir, spoolProcessing)); len(left) != 0 {
+		t.Errorf("left in processing: %v", left)
+	}
+
+	done := listDir(t, filepath.Join(dir, spoolDone))
+	if len(done) != 5 || !slices.Contains(done, "0-interrupted.receipt.json") || !slices.Contains(done, "1-save.json") {
+		t.Errorf("done holds %v, want both requests with their receipts and the earlier request", done)
+	}
+	if earlier, _ := os.ReadFile(filepath.Join(dir, spoolDone, "1-save.json")); !strings.Contains(string(earlier), "earlier") {
+		t.Errorf("the earlier request of the same name was replaced by %s", earlier)
+	}
+	var receipt spoolReceipt
+	data, _ := os.ReadFile(filepath.Join(dir, spoolDone, "0-interrupted.receipt.json"))
+	if err := json.Unmarshal(data, &receipt); err != nil || receipt.File != "0-interrupted.json" || receipt.ID != "a#f" || receipt.Block != 7 {
+		t.Errorf("receipt %+v, %v", receipt, err)
+	}
+
+	for name, code := range map[string]string{"2-unknown-field": codeInvalidArgument, "3-rejected": errorCode(api.ErrRejected)} {
+		var failure spoolFailure
+		data, err := os.ReadFile(filepath.Join(dir, spoolFailed, name+".error.json"))
+		if err == nil {
+			err = json.Unmarshal(data, &failure)
+		}
+		if err != nil || failure.Code != code || failure.File != name+".json" {
+			t.Errorf("%s: failure %+v, %v; want code %s", name, failure, err, code)
+		}
+		if _, err := os.Stat(filepath.Join(dir, spoolFailed, name+".json")); err != nil {
+			t.Errorf("%s: request not filed under failed: %v", name, err)
+		}
+	}
+}
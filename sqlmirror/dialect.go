@@ -0,0 +1,185 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlmirror
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// dialect holds what differs between the SQL of the databases.
+type dialect struct {
+	// schema creates the tables, with {name} placeholders for their names.
+	schema []string
+	// numbered means placeholders are $1, $2... rather than ?.
+	numbered bool
+	// onConflict returns the clause updating the row an insert conflicts
+	// with.
+	onConflict func(key, columns []string) string
+}
+
+var dialects = map[string]*dialect{
+	Postgres: {
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS {events} (
+				block_number BIGINT NOT NULL,
+				log_index INTEGER NOT NULL,
+				record_key TEXT NOT NULL,
+				record_field TEXT NOT NULL,
+				value TEXT NOT NULL,
+				version BIGINT NOT NULL,
+				block_hash CHAR(66) NOT NULL,
+				tx_hash CHAR(66) NOT NULL,
+				writer CHAR(42) NOT NULL,
+				block_time TIMESTAMPTZ NOT NULL,
+				expires_at TIMESTAMPTZ,
+				tags JSONB,
+				supersedes_key TEXT,
+				supersedes_field TEXT,
+				supersedes_version BIGINT,
+				PRIMARY KEY (block_number, log_index)
+			)`,
+			`CREATE INDEX IF NOT EXISTS {events}_key ON {events} (record_key, record_field, version)`,
+			`CREATE TABLE IF NOT EXISTS {records} (
+				record_key TEXT NOT NULL,
+				record_field TEXT NOT NULL,
+				value TEXT NOT NULL,
+				version BIGINT NOT NULL,
+				block_number BIGINT NOT NULL,
+				tx_hash CHAR(66) NOT NULL,
+				writer CHAR(42) NOT NULL,
+				block_time TIMESTAMPTZ NOT NULL,
+				expires_at TIMESTAMPTZ,
+				tags JSONB,
+				PRIMARY KEY (record_key, record_field)
+			)`,
+			`CREATE TABLE IF NOT EXISTS {transactions} (
+				hash CHAR(66) PRIMARY KEY,
+				block_number BIGINT NOT NULL,
+				block_time TIMESTAMPTZ NOT NULL,
+				sender CHAR(42) NOT NULL,
+				gas_used BIGINT NOT NULL,
+				failed BOOLEAN NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS {mirror} (
+				name TEXT PRIMARY KEY,
+				next_block BIGINT NOT NULL,
+				next_log INTEGER NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+		},
+		numbered: true,
+		onConflict: func(key, columns []string) string {
+			var set []string
+			for _, c := range columns {
+				if !slices.Contains(key, c) {
+					set = append(set, c+" = EXCLUDED."+c)
+				}
+			}
+			return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(key, ", "), strings.Join(set, ", "))
+		},
+	},
+	// Keys and fields can only be part of primary keys up to some length
+	// in MySQL, so they are mirrored up to 255 characters
+	MySQL: {
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS {events} (
+				block_number BIGINT UNSIGNED NOT NULL,
+				log_index INT UNSIGNED NOT NULL,
+				record_key VARCHAR(255) NOT NULL,
+				record_field VARCHAR(255) NOT NULL,
+				value LONGTEXT NOT NULL,
+				version BIGINT UNSIGNED NOT NULL,
+				block_hash CHAR(66) NOT NULL,
+				tx_hash CHAR(66) NOT NULL,
+				writer CHAR(42) NOT NULL,
+				block_time DATETIME NOT NULL,
+				expires_at DATETIME NULL,
+				tags JSON NULL,
+				supersedes_key VARCHAR(255) NULL,
+				supersedes_field VARCHAR(255) NULL,
+				supersedes_version BIGINT UNSIGNED NULL,
+				PRIMARY KEY (block_number, log_index),
+				INDEX events_key (record_key, record_field, version)
+			) CHARACTER SET utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS {records} (
+				record_key VARCHAR(255) NOT NULL,
+				record_field VARCHAR(255) NOT NULL,
+				value LONGTEXT NOT NULL,
+				version BIGINT UNSIGNED NOT NULL,
+				block_number BIGINT UNSIGNED NOT NULL,
+				tx_hash CHAR(66) NOT NULL,
+				writer CHAR(42) NOT NULL,
+				block_time DATETIME NOT NULL,
+				expires_at DATETIME NULL,
+				tags JSON NULL,
+				PRIMARY KEY (record_key, record_field)
+			) CHARACTER SET utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS {transactions} (
+				hash CHAR(66) PRIMARY KEY,
+				block_number BIGINT UNSIGNED NOT NULL,
+				block_time DATETIME NOT NULL,
+				sender CHAR(42) NOT NULL,
+				gas_used BIGINT UNSIGNED NOT NULL,
+				failed BOOLEAN NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS {mirror} (
+				name VARCHAR(64) PRIMARY KEY,
+				next_block BIGINT UNSIGNED NOT NULL,
+				next_log INT UNSIGNED NOT NULL,
+				updated_at DATETIME NOT NULL
+			)`,
+		},
+		onConflict: func(key, columns []string) string {
+			var set []string
+			for _, c := range columns {
+				if !slices.Contains(key, c) {
+					set = append(set, c+" = VALUES("+c+")")
+				}
+			}
+			return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+		},
+	},
+}
+
+// rebind replaces the ? placeholders of a statement with the ones of the
+// dialect
+func (d *dialect) rebind(stmt string) string {
+	if !d.numbered {
+		return stmt
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range stmt {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// sortedColumns returns the columns of a row in a stable order
+func sortedColumns(row map[string]interface{}) []string {
+	columns := make([]string, 0, len(row))
+	for c := range row {
+		columns = append(columns, c)
+	}
+	slices.Sort(columns)
+	return columns
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlmirror mirrors the index into the tables of a PostgreSQL or
+// MySQL database, so that reports can query the records with SQL instead
+// of reading the chain:
+//
+//   - <prefix>events holds every DataSaved event, as the index does;
+//   - <prefix>records holds the latest non-empty value of every key/field
+//     pair;
+//   - <prefix>transactions holds the transactions of the events;
+//   - <prefix>mirror holds how far the mirror got.
+//
+// Events are written in chain order, each batch in a single database
+// transaction along with the cursor, so a mirror stopped at any point
+// resumes where it left off. Writes are upserts, so mirroring an event
+// again changes nothing.
+package sqlmirror
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"contract-storage-eth/indexer"
+)
+
+// Dialects of the supported databases.
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+)
+
+// DefaultTablePrefix is the prefix of the table names when
+// Options.TablePrefix is empty.
+const DefaultTablePrefix = "cse_"
+
+// Options configures a Mirror.
+type Options struct {
+	// Dialect is Postgres or MySQL.
+	Dialect string
+	// TablePrefix starts the name of every table, DefaultTablePrefix when
+	// empty.
+	TablePrefix string
+}
+
+// Cursor is the position after the last mirrored event.
+type Cursor struct {
+	Block uint64
+	Log   uint
+}
+
+// Mirror writes indexed events to the tables of a database.
+type Mirror struct {
+	db      *sql.DB
+	dialect *dialect
+	prefix  string
+}
+
+// New returns a mirror writing to db, which must be a database of
+// opts.Dialect.
+func New(db *sql.DB, opts Options) (*Mirror, error) {
+	d, ok := dialects[opts.Dialect]
+	if !ok {
+		return nil, fmt.Errorf("sqlmirror: unknown dialect %q, want %s or %s", opts.Dialect, Postgres, MySQL)
+	}
+	prefix := opts.TablePrefix
+	if prefix == "" {
+		prefix = DefaultTablePrefix
+	}
+	for _, c := range prefix {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return nil, fmt.Errorf("sqlmirror: table prefix %q may only hold letters, digits and underscores", prefix)
+		}
+	}
+	return &Mirror{db: db, dialect: d, prefix: prefix}, nil
+}
+
+// Migrate creates the tables that do not exist yet.
+func (m *Mirror) Migrate(ctx context.Context) error {
+	for _, stmt := range m.dialect.schema {
+		if _, err := m.db.ExecContext(ctx, m.tables(stmt)); err != nil {
+			return fmt.Errorf("sqlmirror: migrate: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reset empties the tables, so that the next Apply starts from the first
+// event again.
+func (m *Mirror) Reset(ctx context.Context) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"events", "records", "transactions", "mirror"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+m.prefix+table); err != nil {
+			return fmt.Errorf("sqlmirror: reset: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Cursor returns the position after the last mirrored event, the zero
+// Cursor when nothing was mirrored yet.
+func (m *Mirror) Cursor(ctx context.Context) (Cursor, error) {
+	var c Cursor
+	err := m.db.QueryRowContext(ctx, m.tables(m.dialect.rebind("SELECT next_block, next_log FROM {mirror} WHERE name = ?")), cursorName).Scan(&c.Block, &c.Log)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Cursor{}, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("sqlmirror: %w", err)
+	}
+	return c, nil
+}
+
+// cursorName is the row of the mirror table holding the cursor
+const cursorName = "cursor"
+
+// Apply writes records, in chain order, and the transactions holding them
+// in a single database transaction, moving the cursor after the last
+// record.
+func (m *Mirror) Apply(ctx context.Context, records []*indexer.Record, txs []*indexer.Transaction) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, t := range txs {
+		if err := m.upsert(ctx, tx, "transactions", []string{"hash"}, map[string]interface{}{
+			"hash":         t.Hash.Hex(),
+			"block_number": t.BlockNumber,
+			"block_time":   blockTime(t.Timestamp),
+			"sender":       t.From.Hex(),
+			"gas_used":     t.GasUsed,
+			"failed":       t.Failed,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range records {
+		row, err := eventRow(r)
+		if err != nil {
+			return err
+		}
+		if err := m.upsert(ctx, tx, "events", []string{"block_number", "log_index"}, row); err != nil {
+			return err
+		}
+
+		// The latest write of a pair replaces its row, and an empty value
+		// removes it
+		if r.Value == "" {
+			_, err = tx.ExecContext(ctx, m.tables(m.dialect.rebind("DELETE FROM {records} WHERE record_key = ? AND record_field = ?")), r.Key, r.Field)
+		} else {
+			delete(row, "log_index")
+			delete(row, "block_hash")
+			delete(row, "supersedes_key")
+			delete(row, "supersedes_field")
+			delete(row, "supersedes_version")
+			err = m.upsert(ctx, tx, "records", []string{"record_key", "record_field"}, row)
+		}
+		if err != nil {
+			return fmt.Errorf("sqlmirror: %w", err)
+		}
+	}
+
+	last := records[len(records)-1]
+	if err := m.upsert(ctx, tx, "mirror", []string{"name"}, map[string]interface{}{
+		"name":       cursorName,
+		"next_block": last.BlockNumber,
+		"next_log":   last.LogIndex + 1,
+		"updated_at": time.Now().UTC(),
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// eventRow returns the columns of the event row of a record
+func eventRow(r *indexer.Record) (map[string]interface{}, error) {
+	row := map[string]interface{}{
+		"block_number":       r.BlockNumber,
+		"log_index":          r.LogIndex,
+		"record_key":         r.Key,
+		"record_field":       r.Field,
+		"value":              r.Value,
+		"version":            r.Version,
+		"block_hash":         r.BlockHash.Hex(),
+		"tx_hash":            r.TxHash.Hex(),
+		"writer":             r.Writer.Hex(),
+		"block_time":         blockTime(r.Timestamp),
+		"expires_at":         nil,
+		"tags":               nil,
+		"supersedes_key":     nil,
+		"supersedes_field":   nil,
+		"supersedes_version": nil,
+	}
+	if r.ExpiresAt != nil {
+		row["expires_at"] = r.ExpiresAt.UTC()
+	}
+	if len(r.Tags) > 0 {
+		tags, err := json.Marshal(r.Tags)
+		if err != nil {
+			return nil, err
+		}
+		row["tags"] = string(tags)
+	}
+	if s := r.Supersedes; s != nil {
+		row["supersedes_key"], row["supersedes_field"], row["supersedes_version"] = s.Key, s.Field, s.Version
+	}
+	return row, nil
+}
+
+func blockTime(timestamp uint64) time.Time {
+	return time.Unix(int64(timestamp), 0).UTC()
+}
+
+// upsert inserts a row, or updates the row with the same key columns
+func (m *Mirror) upsert(ctx context.Context, tx *sql.Tx, table string, key []string, row map[string]interface{}) error {
+	columns := sortedColumns(row)
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		values[i] = row[c]
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s%s (%s) VALUES (%s) %s", m.prefix, table,
+		strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "), m.dialect.onConflict(key, columns))
+	if _, err := tx.ExecContext(ctx, m.dialect.rebind(stmt), values...); err != nil {
+		return fmt.Errorf("sqlmirror: %s: %w", table, err)
+	}
+	return nil
+}
+
+// tables replaces the {name} placeholders of a statement with the names of
+// the tables
+func (m *Mirror) tables(stmt string) string {
+	for _, table := range []string{"events", "records", "transactions", "mirror"} {
+		stmt = strings.ReplaceAll(stmt, "{"+table+"}", m.prefix+table)
+	}
+	return stmt
+}
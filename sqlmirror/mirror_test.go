@@ -0,0 +1,141 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlmirror_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/sqlmirror"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "modernc.org/sqlite"
+)
+
+// openMirror mirrors into a SQLite database, which takes the SQL of the
+// PostgreSQL dialect
+func openMirror(t *testing.T) (*sqlmirror.Mirror, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "mirror.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := sqlmirror.New(db, sqlmirror.Options{Dialect: sqlmirror.Postgres})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return m, db
+}
+
+func record(block uint64, log uint, key, value string, version uint64) *indexer.Record {
+	return &indexer.Record{Key: key, Field: "f", Value: value, Version: version, BlockNumber: block, LogIndex: log, TxHash: common.Hash{byte(block)}, Timestamp: 1700000000 + block}
+}
+
+func TestApply(t *testing.T) {
+	m, db := openMirror(t)
+	ctx := context.Background()
+
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := record(1, 0, "a", "1", 1)
+	first.Tags, first.ExpiresAt = map[string]string{"team": "ops"}, &expires
+	txs := []*indexer.Transaction{{Hash: common.Hash{1}, BlockNumber: 1, GasUsed: 21000}}
+	if err := m.Apply(ctx, []*indexer.Record{first, record(1, 1, "b", "1", 1)}, txs); err != nil {
+		t.Fatal(err)
+	}
+	// Applying a batch again changes nothing
+	if err := m.Apply(ctx, []*indexer.Record{first}, txs); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Apply(ctx, []*indexer.Record{record(2, 0, "a", "2", 2), record(2, 3, "b", "", 2)}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var events, transactions int
+	db.QueryRow("SELECT COUNT(*) FROM cse_events").Scan(&events)
+	db.QueryRow("SELECT COUNT(*) FROM cse_transactions").Scan(&transactions)
+	if events != 4 || transactions != 1 {
+		t.Errorf("mirrored %d events and %d transactions, want 4 and 1", events, transactions)
+	}
+
+	// b was emptied, a holds its second value
+	rows, err := db.Query("SELECT record_key, value, version, tags FROM cse_records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var key, value string
+		var version uint64
+		var tags sql.NullString
+		if err := rows.Scan(&key, &value, &version, &tags); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, key+"="+value)
+		if version != 2 || tags.Valid {
+			t.Errorf("%s is at version %d with tags %v", key, version, tags)
+		}
+	}
+	if len(got) != 1 || got[0] != "a=2" {
+		t.Errorf("records are %v, want [a=2]", got)
+	}
+
+	var tags string
+	db.QueryRow("SELECT tags FROM cse_events WHERE block_number = 1 AND log_index = 0").Scan(&tags)
+	if tags != `{"team":"ops"}` {
+		t.Errorf("tags are %s", tags)
+	}
+
+	cursor, err := m.Cursor(ctx)
+	if err != nil || cursor != (sqlmirror.Cursor{Block: 2, Log: 4}) {
+		t.Errorf("cursor is %+v, %v", cursor, err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	m, db := openMirror(t)
+	ctx := context.Background()
+	if err := m.Apply(ctx, []*indexer.Record{record(1, 0, "a", "1", 1)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if cursor, err := m.Cursor(ctx); err != nil || cursor != (sqlmirror.Cursor{}) {
+		t.Errorf("cursor is %+v, %v after reset", cursor, err)
+	}
+	var records int
+	db.QueryRow("SELECT COUNT(*) FROM cse_records").Scan(&records)
+	if records != 0 {
+		t.Errorf("%d records left after reset", records)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := sqlmirror.New(nil, sqlmirror.Options{Dialect: "oracle"}); err == nil {
+		t.Error("accepted an unknown dialect")
+	}
+	if _, err := sqlmirror.New(nil, sqlmirror.Options{Dialect: sqlmirror.MySQL, TablePrefix: "x; DROP"}); err == nil {
+		t.Error("accepted a table prefix that is no identifier")
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// pendingTx is a sent transaction that is not confirmed yet, persisted so
+// that waiting can be resumed after the process was interrupted
+type pendingTx struct {
+	Kind     string         `json:"kind"`
+	Hash     common.Hash    `json:"hash"`
+	Nonce    uint64         `json:"nonce"`
+	From     common.Address `json:"from"`
+	Contract common.Address `json:"contract"`
+	RawTx    hexutil.Bytes  `json:"raw_tx"`
+	SentAt   time.Time      `json:"sent_at"`
+	// Replaced are the transactions of the same nonce it replaced, oldest
+	// first, any of which may still be mined instead
+	Replaced []hexutil.Bytes `json:"replaced,omitempty"`
+}
+
+// statePath returns the configured pending transaction state file
+func statePath(config *Config) string {
+	if config.State.File != "" {
+		return config.State.File
+	}
+	return "pending_tx.json"
+}
+
+// newPendingTx describes a transaction sent by from to contract
+func newPendingTx(kind string, from, contract common.Address, tx *types.Transaction) (*pendingTx, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &pendingTx{
+		Kind:     kind,
+		Hash:     tx.Hash(),
+		Nonce:    tx.Nonce(),
+		From:     from,
+		Contract: contract,
+		RawTx:    raw,
+		SentAt:   time.Now().UTC(),
+	}, nil
+}
+
+// transaction decodes the signed transaction
+func (p *pendingTx) transaction() (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(p.RawTx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// transactions decodes every transaction sent for the nonce, the latest
+// first
+func (p *pendingTx) transactions() ([]*types.Transaction, error) {
+	raws := []hexutil.Bytes{p.RawTx}
+	for i := len(p.Replaced) - 1; i >= 0; i-- {
+		raws = append(raws, p.Replaced[i])
+	}
+	txs := make([]*types.Transaction, len(raws))
+	for i, raw := range raws {
+		txs[i] = new(types.Transaction)
+		if err := txs[i].UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+	}
+	return txs, nil
+}
+
+// replacedBy describes tx, sent to replace the transaction of p, keeping
+// the transactions sent before
+func (p *pendingTx) replacedBy(tx *types.Transaction) (*pendingTx, error) {
+	next, err := newPendingTx(p.Kind, p.From, p.Contract, tx)
+	if err != nil {
+		return nil, err
+	}
+	next.Replaced = append(slices.Clone(p.Replaced), p.RawTx)
+	return next, nil
+}
+
+// savePending writes the state file, replacing it atomically
+func savePending(config *Config, p *pendingTx) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(statePath(config), data)
+}
+
+// writeFileAtomic replaces path with data through a temporary file, so
+// that readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadPending reads the state file. It returns an error wrapping
+// os.ErrNotExist when nothing is pending.
+func loadPending(config *Config) (*pendingTx, error) {
+	data, err := os.ReadFile(statePath(config))
+	if err != nil {
+		return nil, err
+	}
+
+	var p pendingTx
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", statePath(config), err)
+	}
+	return &p, nil
+}
+
+// clearPending removes the state file once the transaction is settled
+func clearPending(config *Config) {
+	if err := os.Remove(statePath(config)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove state file", "path", statePath(config), "error", err)
+	}
+}
+
+// trackPending records a sent transaction in the state file. Failing to do
+// so only costs the ability to resume, so it is not fatal.
+func trackPending(config *Config, kind string, from, contract common.Address, tx *types.Transaction) {
+	p, err := newPendingTx(kind, from, contract, tx)
+	if err == nil {
+		err = savePending(config, p)
+	}
+	if err != nil {
+		slog.Warn("Failed to save pending transaction", "path", statePath(config), "tx_hash", tx.Hash().Hex(), "error", err)
+	}
+}
+
+// reportInterrupted tells how to continue when waiting for a transaction
+// was interrupted by a signal
+func reportInterrupted(config *Config, tx *types.Transaction, err error) {
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("Interrupted while waiting for transaction %s, saved it to %s\n", tx.Hash().Hex(), statePath(config))
+		fmt.Println("Run `contract-storage-eth resume` to continue waiting or `resume --bump 20` to raise its fee")
+	}
+}
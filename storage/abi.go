@@ -0,0 +1,72 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage provides access to a deployed SaveContract.
+package storage
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// SaveContractABI is the ABI of Storage.sol. Its entries are ordered by
+// hand: go-ethereum names overloaded methods in order of appearance, so
+// save(string,string,string) comes first to be "save" and the struct
+// variant is "save0".
+const SaveContractABI = `[
+	{"anonymous":false,"inputs":[
+		{"indexed":false,"internalType":"string","name":"key","type":"string"},
+		{"indexed":false,"internalType":"string","name":"field","type":"string"},
+		{"indexed":false,"internalType":"string","name":"value","type":"string"}],
+	"name":"DataSaved","type":"event"},
+	{"inputs":[
+		{"internalType":"string","name":"_key","type":"string"},
+		{"internalType":"string","name":"_field","type":"string"}],
+	"name":"get","outputs":[
+		{"internalType":"string","name":"","type":"string"}],
+	"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"data","outputs":[
+		{"internalType":"string","name":"key","type":"string"},
+		{"internalType":"string","name":"field","type":"string"},
+		{"internalType":"string","name":"value","type":"string"}],
+	"stateMutability":"view","type":"function"},
+	{"inputs":[
+		{"internalType":"string","name":"_key","type":"string"},
+		{"internalType":"string","name":"_field","type":"string"},
+		{"internalType":"string","name":"_value","type":"string"}],
+	"name":"save","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[
+		{"components":[
+			{"internalType":"string","name":"key","type":"string"},
+			{"internalType":"string","name":"field","type":"string"},
+			{"internalType":"string","name":"value","type":"string"}],
+		"internalType":"struct SaveContract.DataItem","name":"_data","type":"tuple"}],
+	"name":"save","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+var (
+	parsedABI    abi.ABI
+	parsedABIErr error
+	parseOnce    sync.Once
+)
+
+// ABI returns the parsed SaveContract ABI.
+func ABI() (abi.ABI, error) {
+	parseOnce.Do(func() {
+		parsedABI, parsedABIErr = abi.JSON(strings.NewReader(SaveContractABI))
+	})
+	return parsedABI, parsedABIErr
+}
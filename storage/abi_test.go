@@ -0,0 +1,50 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"bytes"
+	"testing"
+
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestSaveOverload checks that "save" packs the three-string overload of the
+// contract, which the commands call
+func TestSaveOverload(t *testing.T) {
+	parsed, err := storage.ABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parsed.Pack("save", "invoice-42", "pdf", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := crypto.Keccak256([]byte("save(string,string,string)"))[:4]; !bytes.Equal(data[:4], want) {
+		t.Fatalf("save packs selector %x, want %x", data[:4], want)
+	}
+	args, err := parsed.Methods["save"].Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 3 || args[0] != "invoice-42" || args[1] != "pdf" || args[2] != "v1" {
+		t.Errorf("unpacked %v", args)
+	}
+	if want := crypto.Keccak256([]byte("save((string,string,string))"))[:4]; !bytes.Equal(parsed.Methods["save0"].ID, want) {
+		t.Errorf("save0 has selector %x, want the struct variant %x", parsed.Methods["save0"].ID, want)
+	}
+}
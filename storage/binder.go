@@ -0,0 +1,38 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ContractBinder calls and transacts with a deployed storage contract by
+// method name, as a *bind.BoundContract does. Code taking one can be
+// tested against the generated implementation of the mocks package.
+type ContractBinder interface {
+	Call(opts *bind.CallOpts, results *[]interface{}, method string, params ...interface{}) error
+	Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error)
+}
+
+// Bind returns the storage contract at address, reached through backend.
+func Bind(address common.Address, backend bind.ContractBackend) (ContractBinder, error) {
+	parsedABI, err := ABI()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsedABI, backend, backend, backend), nil
+}
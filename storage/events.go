@@ -0,0 +1,91 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DataSaved is a decoded DataSaved event.
+type DataSaved struct {
+	Key   string
+	Field string
+	Value string
+	Raw   types.Log
+}
+
+// ErrNotDataSaved is returned when a log is not a DataSaved event.
+var ErrNotDataSaved = errors.New("log is not a DataSaved event")
+
+// DataSavedTopic returns the topic identifying DataSaved events.
+func DataSavedTopic() (common.Hash, error) {
+	parsed, err := ABI()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return parsed.Events["DataSaved"].ID, nil
+}
+
+// ParseDataSaved decodes a DataSaved log.
+func ParseDataSaved(log types.Log) (*DataSaved, error) {
+	parsed, err := ABI()
+	if err != nil {
+		return nil, err
+	}
+
+	event := parsed.Events["DataSaved"]
+	if len(log.Topics) == 0 || log.Topics[0] != event.ID {
+		return nil, ErrNotDataSaved
+	}
+
+	values, err := event.Inputs.Unpack(log.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3 {
+		return nil, ErrNotDataSaved
+	}
+
+	return &DataSaved{
+		Key:   values[0].(string),
+		Field: values[1].(string),
+		Value: values[2].(string),
+		Raw:   log,
+	}, nil
+}
+
+// DataSavedQuery returns a filter for DataSaved events of the contract in
+// the inclusive block range [from, to]. A nil to means the latest block.
+func DataSavedQuery(address common.Address, from uint64, to *uint64) (ethereum.FilterQuery, error) {
+	topic, err := DataSavedTopic()
+	if err != nil {
+		return ethereum.FilterQuery{}, err
+	}
+
+	q := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		Addresses: []common.Address{address},
+		Topics:    [][]common.Hash{{topic}},
+	}
+	if to != nil {
+		q.ToBlock = new(big.Int).SetUint64(*to)
+	}
+	return q, nil
+}
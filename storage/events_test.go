@@ -0,0 +1,124 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type decoded struct {
+	Block   uint64 `json:"block"`
+	Index   uint   `json:"index"`
+	Address string `json:"address"`
+	Key     string `json:"key,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func TestParseDataSavedRecordedChain(t *testing.T) {
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []decoded
+	for _, b := range chain.Blocks {
+		for _, r := range b.Receipts {
+			for _, l := range r.Logs {
+				d := decoded{Block: l.BlockNumber, Index: l.Index, Address: l.Address.Hex()}
+				ev, err := storage.ParseDataSaved(*l)
+				if err != nil {
+					d.Error = err.Error()
+				} else {
+					d.Key, d.Field, d.Value = ev.Key, ev.Field, ev.Value
+				}
+				got = append(got, d)
+			}
+		}
+	}
+	fixtures.GoldenJSON(t, "recorded_chain", got)
+}
+
+func TestParseDataSavedMalformed(t *testing.T) {
+	logs, err := fixtures.Logs("malformed_logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, l := range logs {
+		ev, err := storage.ParseDataSaved(l)
+		if err == nil {
+			t.Errorf("log %d: decoded malformed log as %+v", l.Index, ev)
+			continue
+		}
+		got = append(got, fmt.Sprintf("log %d: %v", l.Index, err))
+	}
+	fixtures.Golden(t, "malformed_logs", []byte(strings.Join(got, "\n")+"\n"))
+}
+
+func TestABIVariants(t *testing.T) {
+	current, err := storage.ABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants := map[string]abi.ABI{"current": current}
+	for _, name := range []string{"v1", "indexed"} {
+		data, err := fixtures.ABI(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if variants[name], err = abi.JSON(strings.NewReader(data)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	// Every variant shares the event id and the save selector, which is
+	// what lets the tool talk to contracts deployed from older sources
+	var lines []string
+	for _, name := range []string{"current", "v1", "indexed"} {
+		a := variants[name]
+		lines = append(lines, fmt.Sprintf("%s: event %s %s", name, a.Events["DataSaved"].Sig, a.Events["DataSaved"].ID.Hex()))
+		var methods []string
+		for _, m := range a.Methods {
+			methods = append(methods, fmt.Sprintf("%s: method %s %s as %q", name, m.Sig, hexutil.Encode(m.ID), m.Name))
+		}
+		sort.Strings(methods)
+		lines = append(lines, methods...)
+	}
+	fixtures.Golden(t, "abi_variants", []byte(strings.Join(lines, "\n")+"\n"))
+
+	want, err := current.Pack("save", "k", "f", "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := variants["v1"].Pack("save0", "k", "f", "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(got) != hexutil.Encode(want) {
+		t.Errorf("v1 save calldata %x, want %x", got, want)
+	}
+}
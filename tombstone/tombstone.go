@@ -0,0 +1,49 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tombstone implements the markers soft deletes write in place of
+// a value. Readers treat a tombstone as no value, but the value it hides
+// stays in the history of the key and field, from where it can be restored.
+package tombstone
+
+import (
+	"strings"
+	"time"
+)
+
+// Prefix marks a stored value as a tombstone. It cannot start an envelope
+// or a chunk manifest.
+const Prefix = "cse-deleted:"
+
+// New returns the tombstone of a value deleted at a time.
+func New(at time.Time) string {
+	return Prefix + at.UTC().Format(time.RFC3339)
+}
+
+// Is reports whether a stored value is a tombstone.
+func Is(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Parse returns the time a tombstone was written at, and false when the
+// value is no tombstone. A tombstone with an unreadable time is still one,
+// deleted at the zero time.
+func Parse(value string) (time.Time, bool) {
+	s, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	at, _ := time.Parse(time.RFC3339, s)
+	return at, true
+}
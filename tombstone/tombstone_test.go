@@ -0,0 +1,47 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tombstone_test
+
+import (
+	"testing"
+	"time"
+
+	"contract-storage-eth/envelope"
+	"contract-storage-eth/tombstone"
+)
+
+func TestTombstone(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	value := tombstone.New(at)
+	if value != "cse-deleted:2025-06-01T10:30:00Z" {
+		t.Errorf("New = %q", value)
+	}
+	if got, ok := tombstone.Parse(value); !ok || !got.Equal(at) {
+		t.Errorf("Parse = %v, %v", got, ok)
+	}
+	if _, ok := tombstone.Parse("cse-deleted:garbage"); !ok {
+		t.Error("a tombstone with an unreadable time is not one")
+	}
+
+	sealed, err := envelope.Seal([]byte("cse-deleted:2025-06-01T10:30:00Z"), envelope.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"", "plain value", sealed, "cse-chunked:{}"} {
+		if tombstone.Is(v) {
+			t.Errorf("%q taken for a tombstone", v)
+		}
+	}
+}
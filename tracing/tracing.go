@@ -0,0 +1,100 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing exports OpenTelemetry spans over OTLP. Until Setup is
+// called, spans are dropped at no cost, so packages can always start them.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configure the exporter.
+type Options struct {
+	// Endpoint is the host:port of the OTLP/gRPC collector. When empty,
+	// the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or
+	// localhost:4317 is used.
+	Endpoint string
+	// Insecure disables TLS to the collector.
+	Insecure bool
+	// Headers are sent with every export, such as authentication tokens.
+	Headers map[string]string
+	// ServiceName names the service in traces, "contract-storage-eth"
+	// when empty.
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded. Traces started
+	// by a caller follow the caller's decision. Zero records every trace.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider exporting to the collector,
+// and the W3C trace context propagator. The returned function flushes
+// the spans still buffered and must be called before exiting.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exportOpts := []otlptracegrpc.Option{}
+	if opts.Endpoint != "" {
+		exportOpts = append(exportOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+	}
+	if opts.Insecure {
+		exportOpts = append(exportOpts, otlptracegrpc.WithInsecure())
+	}
+	if len(opts.Headers) > 0 {
+		exportOpts = append(exportOpts, otlptracegrpc.WithHeaders(opts.Headers))
+	}
+	exporter, err := otlptracegrpc.New(ctx, exportOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.ServiceName
+	if name == "" {
+		name = "contract-storage-eth"
+	}
+	ratio := opts.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(name))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of the tool.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer("contract-storage-eth").Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, recording err as its status when not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
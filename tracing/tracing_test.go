@@ -0,0 +1,54 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"contract-storage-eth/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	ctx, parent := tracing.Start(context.Background(), "save", attribute.String("record.key", "a"))
+	_, child := tracing.Start(ctx, "eth_sendRawTransaction")
+	tracing.End(child, errors.New("nonce too low"))
+	tracing.End(parent, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	rpcSpan, saveSpan := spans[0], spans[1]
+	if rpcSpan.Parent.SpanID() != saveSpan.SpanContext.SpanID() {
+		t.Error("the RPC span is not a child of the save span")
+	}
+	if rpcSpan.Status.Code != codes.Error || rpcSpan.Status.Description != "nonce too low" || len(rpcSpan.Events) != 1 {
+		t.Errorf("failed span has status %+v and events %v", rpcSpan.Status, rpcSpan.Events)
+	}
+	if saveSpan.Status.Code != codes.Unset || saveSpan.Attributes[0].Value.AsString() != "a" {
+		t.Errorf("save span has status %+v and attributes %v", saveSpan.Status, saveSpan.Attributes)
+	}
+}
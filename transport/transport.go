@@ -0,0 +1,185 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transport secures the listeners of serve mode with TLS, optional
+// client certificate authentication and IP allowlists, so every server
+// built on them gets the same transport-level protection.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// Options configure Listen.
+type Options struct {
+	// CertFile and KeyFile hold the PEM server certificate and key. TLS is
+	// disabled when both are empty.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM certificates of the authorities whose
+	// client certificates are accepted. When set, clients must present a
+	// valid certificate (mutual TLS).
+	ClientCAFile string
+	// AllowedIPs lists the addresses and CIDR ranges that may connect.
+	// All addresses are allowed when empty.
+	AllowedIPs []string
+	// NextProtos lists the application protocols offered during the TLS
+	// handshake (ALPN), in order of preference. gRPC clients require "h2".
+	// Defaults to HTTP/2 and HTTP/1.1.
+	NextProtos []string
+}
+
+// DefaultNextProtos are the protocols of an HTTP server.
+var DefaultNextProtos = []string{"h2", "http/1.1"}
+
+// Allowlist is a set of address ranges allowed to connect.
+type Allowlist []netip.Prefix
+
+// ParseAllowlist parses single addresses ("10.0.0.5") and CIDR ranges
+// ("10.0.0.0/8").
+func ParseAllowlist(entries []string) (Allowlist, error) {
+	var list Allowlist
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return list, nil
+}
+
+// Allows reports whether addr is in the list. An empty list allows every
+// address.
+func (a Allowlist) Allows(addr netip.Addr) bool {
+	if len(a) == 0 {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Listener wraps l, closing connections from addresses not in the list
+// before anything is read from them.
+func (a Allowlist) Listener(l net.Listener) net.Listener {
+	if len(a) == 0 {
+		return l
+	}
+	return &allowListener{Listener: l, allow: a}
+}
+
+type allowListener struct {
+	net.Listener
+	allow Allowlist
+}
+
+func (l *allowListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		addr, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+		if err == nil && l.allow.Allows(addr.Addr()) {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+// ErrNoCertificate is returned when client authentication is configured
+// without a server certificate.
+var ErrNoCertificate = errors.New("client CA requires a server certificate and key")
+
+// TLSConfig builds the server TLS configuration, or returns nil when TLS is
+// disabled.
+func TLSConfig(opts Options) (*tls.Config, error) {
+	if opts.CertFile == "" && opts.KeyFile == "" {
+		if opts.ClientCAFile != "" {
+			return nil, ErrNoCertificate
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   opts.NextProtos,
+	}
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = DefaultNextProtos
+	}
+
+	if opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", opts.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Listen listens on the TCP address, applying the allowlist and TLS
+// settings of opts.
+func Listen(address string, opts Options) (net.Listener, error) {
+	allow, err := ParseAllowlist(opts.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	config, err := TLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	l = allow.Listener(l)
+	if config != nil {
+		l = tls.NewListener(l, config)
+	}
+	return l, nil
+}
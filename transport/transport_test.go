@@ -0,0 +1,312 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"contract-storage-eth/transport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// testPKI is a CA with a server certificate for 127.0.0.1 and a client
+// certificate, written to a temporary directory.
+type testPKI struct {
+	caFile, certFile, keyFile string
+	pool                      *x509.CertPool
+	client                    tls.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey := newKey(t)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, ips ...net.IP) ([]byte, *ecdsa.PrivateKey) {
+		key := newKey(t)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  ips,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, key
+	}
+
+	pki := &testPKI{
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "server.pem"),
+		keyFile:  filepath.Join(dir, "server.key"),
+		pool:     x509.NewCertPool(),
+	}
+	pki.pool.AddCert(ca)
+	writePEM(t, pki.caFile, "CERTIFICATE", caDER)
+
+	serverDER, serverKey := issue(2, x509.ExtKeyUsageServerAuth, net.IPv4(127, 0, 0, 1))
+	writePEM(t, pki.certFile, "CERTIFICATE", serverDER)
+	keyDER, err := x509.MarshalECPrivateKey(serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, pki.keyFile, "EC PRIVATE KEY", keyDER)
+
+	clientDER, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+	pki.client = tls.Certificate{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}
+	return pki
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveEcho accepts connections on l and echoes what they send.
+func serveEcho(t *testing.T, l net.Listener) {
+	t.Helper()
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+}
+
+func TestAllowlist(t *testing.T) {
+	list, err := transport.ParseAllowlist([]string{"10.0.0.0/8", " 192.168.1.5 ", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"11.0.0.1", false},
+		{"192.168.1.5", true},
+		{"192.168.1.6", false},
+		{"::ffff:10.0.0.1", true},
+		{"::1", true},
+	}
+	for _, tt := range tests {
+		if got := list.Allows(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	if !transport.Allowlist(nil).Allows(netip.MustParseAddr("8.8.8.8")) {
+		t.Error("empty allowlist should allow every address")
+	}
+	if _, err := transport.ParseAllowlist([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("ParseAllowlist accepted an invalid range")
+	}
+}
+
+func TestListenRefusesDisallowedAddress(t *testing.T) {
+	l, err := transport.Listen("127.0.0.1:0", transport.Options{AllowedIPs: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveEcho(t, l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("ping"))
+	if n, err := conn.Read(make([]byte, 4)); err == nil {
+		t.Fatalf("read %d bytes from a connection that should be closed", n)
+	}
+}
+
+func TestListenTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	l, err := transport.Listen("127.0.0.1:0", transport.Options{
+		CertFile:   pki.certFile,
+		KeyFile:    pki.keyFile,
+		AllowedIPs: []string{"127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveEcho(t, l)
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+		RootCAs:    pki.pool,
+		NextProtos: []string{"http/1.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if got := conn.ConnectionState().NegotiatedProtocol; got != "http/1.1" {
+		t.Errorf("negotiated protocol %q, want http/1.1", got)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("echo = %q", buf)
+	}
+}
+
+func TestListenMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	l, err := transport.Listen("127.0.0.1:0", transport.Options{
+		CertFile:     pki.certFile,
+		KeyFile:      pki.keyFile,
+		ClientCAFile: pki.caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveEcho(t, l)
+
+	exchange := func(config *tls.Config) error {
+		conn, err := tls.Dial("tcp", l.Addr().String(), config)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		// With TLS 1.3 the server's verdict on the client certificate only
+		// arrives with the first read.
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			return err
+		}
+		_, err = io.ReadFull(conn, make([]byte, 4))
+		return err
+	}
+
+	if err := exchange(&tls.Config{RootCAs: pki.pool}); err == nil {
+		t.Error("server accepted a client without a certificate")
+	}
+	if err := exchange(&tls.Config{RootCAs: pki.pool, Certificates: []tls.Certificate{pki.client}}); err != nil {
+		t.Errorf("client with a certificate: %v", err)
+	}
+}
+
+func TestTLSConfigRequiresCertificate(t *testing.T) {
+	_, err := transport.TLSConfig(transport.Options{ClientCAFile: "ca.pem"})
+	if !errors.Is(err, transport.ErrNoCertificate) {
+		t.Errorf("err = %v, want ErrNoCertificate", err)
+	}
+	config, err := transport.TLSConfig(transport.Options{})
+	if err != nil || config != nil {
+		t.Errorf("TLSConfig without files = %v, %v; want nil, nil", config, err)
+	}
+}
+
+func TestGRPCOverTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	l, err := transport.Listen("127.0.0.1:0", transport.Options{
+		CertFile:   pki.certFile,
+		KeyFile:    pki.keyFile,
+		NextProtos: []string{"h2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(l)
+	defer server.Stop()
+
+	creds := credentials.NewTLS(&tls.Config{RootCAs: pki.pool})
+	client, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(client).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status = %v", resp.Status)
+	}
+}
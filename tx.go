@@ -0,0 +1,402 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/deployments"
+	"contract-storage-eth/fees"
+	"contract-storage-eth/offline"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/tombstone"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const txUsage = `Usage: contract-storage-eth tx <command> [flags]
+
+Commands:
+  build      Write an unsigned deploy, save or delete to a file (online)
+  sign       Sign the transaction of a file with the configured key (offline)
+  broadcast  Send a signed transaction and wait for it (online)
+`
+
+func runTx(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, txUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "build":
+		return txBuild(ctx, config, args[1:])
+	case "sign":
+		return txSign(ctx, config, args[1:])
+	case "broadcast":
+		return txBroadcast(ctx, config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown tx command: %s\n\n%s", args[0], txUsage)
+		return exitError(2)
+	}
+}
+
+// txBuild prices a transaction at the current nonce and fees of the
+// account, for a machine without a connection to sign
+func txBuild(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fail(codeInvalidArgument, "tx build: the operation is required: deploy, save or delete")
+	}
+	op := args[0]
+	flags := flag.NewFlagSet("tx build "+op, flag.ContinueOnError)
+	fromFlag := flags.String("from", "", "account that will sign the transaction")
+	nonce := flags.Int64("nonce", -1, "nonce of the transaction, the next one of the account when negative")
+	outFile := flags.String("out", "tx.json", "file the unsigned transaction is written to")
+	key := flags.String("key", "", "record key, for save and delete")
+	field := flags.String("field", "", "record field, for save and delete")
+	value := flags.String("value", "", "value to store, for save")
+	valueFile := flags.String("value-file", "", "file whose content to store instead of --value")
+	soft := flags.Bool("soft", config.Storage.SoftDelete, "write a tombstone restore can undo instead of clearing the value, for delete")
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	if *fromFlag == "" {
+		return fail(codeInvalidArgument, "tx build: --from is required, the address or ENS name of the signing account")
+	}
+	if _, viaSafe, err := safeAddress(config); err != nil {
+		return err
+	} else if viaSafe {
+		return errors.New("Transactions proposed to a Safe are signed by its owners, unset safe.address to build one")
+	}
+
+	var to *common.Address
+	var data []byte
+	var description string
+	switch op {
+	case "deploy":
+		bytecode, err := os.ReadFile(filepath.Join(config.Build.Directory, config.Build.ContractName+".bin"))
+		if err != nil {
+			return fmt.Errorf("Failed to read bytecode file: %w", err)
+		}
+		data = common.FromHex(strings.TrimSpace(string(bytecode)))
+		description = fmt.Sprintf("deploy %s (%d bytes of code)", config.Build.ContractName, len(data))
+	case "save", "delete":
+		if *key == "" {
+			return fail(codeInvalidArgument, "tx build %s: --key is required", op)
+		}
+		address, err := contractAddress(config)
+		if err != nil {
+			return err
+		}
+		to = &address
+		stored := ""
+		if op == "save" {
+			if stored, err = sealOffline(ctx, config, *key, *field, *value, *valueFile); err != nil {
+				return err
+			}
+		} else if *soft {
+			stored = tombstone.New(time.Now())
+		}
+		parsedABI, err := storage.ABI()
+		if err != nil {
+			return fmt.Errorf("Failed to parse ABI: %w", err)
+		}
+		if data, err = parsedABI.Pack("save", keyNamespace.Key(*key), *field, stored); err != nil {
+			return fmt.Errorf("Failed to encode the save call: %w", err)
+		}
+		description = fmt.Sprintf("%s %s#%s on %s", op, keyNamespace.Key(*key), *field, address.Hex())
+	default:
+		return fmt.Errorf("tx build: unknown operation %q, want deploy, save or delete", op)
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	from, err := resolveAddress(ctx, config, client, *fromFlag)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid --from: %w", err)
+	}
+
+	u := &offline.Unsigned{
+		Version:     offline.Version,
+		ChainID:     chainID.Uint64(),
+		Description: description,
+		From:        from,
+		To:          to,
+		Value:       new(big.Int),
+		Data:        data,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if *nonce >= 0 {
+		u.Nonce = uint64(*nonce)
+	} else if u.Nonce, err = client.PendingNonceAt(ctx, from); err != nil {
+		return fmt.Errorf("Failed to get nonce: %w", err)
+	}
+	if u.Gas = config.Ethereum.GasLimit; u.Gas == 0 {
+		if u.Gas, err = client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: to, Data: data}); err != nil {
+			return fmt.Errorf("Failed to estimate gas: %w", err)
+		}
+	}
+	e, err := newEstimator(config, client, from.Hex())
+	if err != nil {
+		return err
+	}
+	e.Price = nil
+	est, err := e.ForTx(ctx, to, data, u.Gas)
+	if err != nil {
+		return fmt.Errorf("Failed to get fees: %w", err)
+	}
+	if est.BaseFee == nil {
+		u.GasPrice = est.MaxFee
+	} else {
+		u.MaxFeePerGas, u.MaxPriorityFeePerGas = est.MaxFee, est.PriorityFee
+	}
+	if err := checkFunds(ctx, config, client, from, est.MaxCost, "the transaction"); err != nil {
+		return err
+	}
+
+	if err := offline.Write(*outFile, u); err != nil {
+		return fmt.Errorf("Failed to write unsigned transaction: %w", err)
+	}
+	describeOffline(u.Description, u)
+	fmt.Printf("Unsigned transaction written to %s, sign it with `tx sign --in %s`\n", *outFile, *outFile)
+	printResult(u)
+	return nil
+}
+
+// sealOffline seals a value as save does. Chunks would take transactions
+// of their own, so chunked values are refused
+func sealOffline(ctx context.Context, config *Config, key, field, value, valueFile string) (string, error) {
+	content := []byte(value)
+	if valueFile != "" {
+		var err error
+		if content, err = os.ReadFile(valueFile); err != nil {
+			return "", fmt.Errorf("Failed to read value file: %w", err)
+		}
+	}
+	codec, err := checkSchema(config, key, field, content)
+	if err != nil {
+		return "", fail(codeInvalidArgument, "Invalid value: %w", err)
+	}
+	sealed, err := sealValueAs(config, content, nil, codec)
+	if err != nil {
+		return "", fmt.Errorf("Failed to seal value: %w", err)
+	}
+	if sealed, err = offloadValue(ctx, config, sealed); err != nil {
+		return "", fmt.Errorf("Failed to store value off chain: %w", err)
+	}
+	if writes := splitValue(config, field, sealed); len(writes) > 1 {
+		return "", fmt.Errorf("The value is %d bytes, more than storage.chunk_size, and chunked values cannot be signed offline", len(content))
+	}
+	return sealed, nil
+}
+
+// describeOffline prints what a transaction does for its reviewer
+func describeOffline(description string, u *offline.Unsigned) {
+	fmt.Printf("Transaction: %s\n", description)
+	fmt.Printf("  From:     %s, nonce %d\n", u.From.Hex(), u.Nonce)
+	if u.To != nil {
+		fmt.Printf("  To:       %s\n", u.To.Hex())
+	} else {
+		fmt.Printf("  Contract: %s\n", crypto.CreateAddress(u.From, u.Nonce).Hex())
+	}
+	fmt.Printf("  Chain:    %d\n", u.ChainID)
+	fmt.Printf("  Gas:      %d\n", u.Gas)
+	if u.GasPrice != nil {
+		fmt.Printf("  Price:    %.2f gwei\n", fees.Gwei(u.GasPrice))
+	} else {
+		fmt.Printf("  Max fee:  %.2f gwei (tip %.2f gwei)\n", fees.Gwei(u.MaxFeePerGas), fees.Gwei(u.MaxPriorityFeePerGas))
+	}
+	fmt.Printf("  Max cost: %s ETH\n", fees.FormatEther(u.MaxCost()))
+	fmt.Printf("  Data:     %d bytes, Keccak-256 %s\n", len(u.Data), crypto.Keccak256Hash(u.Data).Hex())
+}
+
+// txSign signs an unsigned transaction without connecting to the network
+func txSign(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("tx sign", flag.ContinueOnError)
+	inFile := flags.String("in", "tx.json", "file of the unsigned transaction")
+	outFile := flags.String("out", "", "file the signed transaction is written to, the input file with .signed before its extension by default")
+	addSignerFlags(flags, config)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	u, err := offline.ReadUnsigned(*inFile)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid unsigned transaction: %w", err)
+	}
+	if config.Ethereum.ChainID != 0 && uint64(config.Ethereum.ChainID) != u.ChainID {
+		return fail(codeInvalidArgument, "Invalid unsigned transaction: it is for chain %d, and the configured network is chain %d", u.ChainID, config.Ethereum.ChainID)
+	}
+	describeOffline(u.Description, u)
+
+	s, err := loadKey(ctx, config.Ethereum.KeyConfig)
+	if err != nil {
+		return fail(codeSigner, "Failed to load private key: %w", err)
+	}
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+	signed, err := offline.Sign(ctx, s, u)
+	if err != nil {
+		return fmt.Errorf("Failed to sign transaction: %w", err)
+	}
+	if *outFile == "" {
+		ext := filepath.Ext(*inFile)
+		*outFile = strings.TrimSuffix(*inFile, ext) + ".signed" + ext
+	}
+	if err := offline.Write(*outFile, signed); err != nil {
+		return fmt.Errorf("Failed to write signed transaction: %w", err)
+	}
+	fmt.Printf("Signed transaction %s written to %s, send it with `tx broadcast --in %s`\n", signed.Hash.Hex(), *outFile, *outFile)
+	printResult(signed)
+	return nil
+}
+
+// txBroadcastResult is the result of tx broadcast in JSON output mode
+type txBroadcastResult struct {
+	TxHash common.Hash    `json:"tx_hash"`
+	From   common.Address `json:"from"`
+	// Contract is the contract created by a deployment
+	Contract *common.Address `json:"contract,omitempty"`
+	Block    uint64          `json:"block,omitempty"`
+	GasUsed  uint64          `json:"gas_used,omitempty"`
+}
+
+// txBroadcast sends a signed transaction, or waits for it when it was sent
+// already
+func txBroadcast(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("tx broadcast", flag.ContinueOnError)
+	inFile := flags.String("in", "tx.signed.json", "file of the signed transaction")
+	noWait := flags.Bool("no-wait", false, "exit once the transaction is sent")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	signed, err := offline.ReadSigned(*inFile)
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid signed transaction: %w", err)
+	}
+	tx, err := signed.Transaction()
+	if err != nil {
+		return fail(codeInvalidArgument, "Invalid signed transaction: %w", err)
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to get chain ID: %w", err)
+	}
+	if chainID.Uint64() != signed.ChainID {
+		return fail(codeInvalidArgument, "Invalid signed transaction: it is for chain %d, and the node is on chain %s", signed.ChainID, chainID)
+	}
+
+	fmt.Printf("Transaction: %s\n", signed.Description)
+	_, err = client.TransactionReceipt(ctx, tx.Hash())
+	sent := err == nil
+	if !sent {
+		if !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("Failed to look up transaction: %w", err)
+		}
+		next, err := client.NonceAt(ctx, signed.From, nil)
+		if err != nil {
+			return fmt.Errorf("Failed to get nonce: %w", err)
+		}
+		if next > tx.Nonce() {
+			return fmt.Errorf("Nonce %d of %s was used by another transaction, build and sign this one again", tx.Nonce(), signed.From.Hex())
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil && !chain.IsAlreadyKnown(err) {
+			return fmt.Errorf("Failed to send transaction: %w", err)
+		}
+		fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
+	} else {
+		fmt.Printf("Transaction %s was sent already\n", tx.Hash().Hex())
+	}
+	if link := explorerURL(config, "tx/"+tx.Hash().Hex()); link != "" {
+		fmt.Printf("Explorer: %s\n", link)
+	}
+
+	result := txBroadcastResult{TxHash: tx.Hash(), From: signed.From}
+	contract := common.Address{}
+	if tx.To() == nil {
+		contract = crypto.CreateAddress(signed.From, tx.Nonce())
+		result.Contract = &contract
+		fmt.Printf("Contract address: %s\n", contract.Hex())
+	} else {
+		contract = *tx.To()
+	}
+	if *noWait {
+		printResult(result)
+		return nil
+	}
+
+	kind := "save"
+	if tx.To() == nil {
+		kind = "deploy"
+	}
+	trackPending(config, kind, signed.From, contract, tx)
+	receipt, err := waitMined(ctx, client, tx, config)
+	if err != nil {
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to wait for transaction: %w", err)
+	}
+	if receipt, err = watchReorg(ctx, client, tx, receipt, config); err != nil {
+		reportInterrupted(config, tx, err)
+		return fail(codeTransactionFailed, "Failed to watch transaction for reorgs: %w", err)
+	}
+	clearPending(config)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fail(codeTransactionFailed, "Transaction failed!")
+	}
+	result.Block, result.GasUsed = receipt.BlockNumber.Uint64(), receipt.GasUsed
+	fmt.Printf("Mined in block %d, gas used %d\n", result.Block, result.GasUsed)
+
+	if tx.To() == nil {
+		recordDeployment(ctx, config, client, &deployments.Deployment{
+			Kind:    deployments.KindDeploy,
+			ChainID: signed.ChainID,
+			Address: contract,
+			Signer:  signed.From,
+			TxHash:  tx.Hash(),
+			Block:   result.Block,
+		})
+	}
+	printResult(result)
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"contract-storage-eth/ens"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Bounds of gas_limit: below the cost of a plain transfer nothing can be
+// sent, and limits above any block's are usually a gwei amount or a typo
+const (
+	minGasLimit = 21_000
+	maxGasLimit = 1_000_000_000
+)
+
+// commandsUsingBuild read the contract build from build.directory
+var commandsUsingBuild = []string{"deploy", "verify-bytecode", "test"}
+
+// configProblem is a setting that cannot work, with the path of its field
+type configProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// configProblems lists every problem found in a configuration
+type configProblems []configProblem
+
+func (p configProblems) Error() string {
+	lines := make([]string, len(p))
+	for i, problem := range p {
+		lines[i] = fmt.Sprintf("  %s: %s", problem.Field, problem.Message)
+	}
+	return fmt.Sprintf("%d problem(s) in config:\n%s", len(p), strings.Join(lines, "\n"))
+}
+
+func (p *configProblems) add(field, format string, args ...interface{}) {
+	*p = append(*p, configProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateConfig checks the settings of the configuration file up front, so
+// that a wrong value is reported with its field rather than failing deep in
+// a command. Network profiles are checked whether selected or not
+func validateConfig(config *Config, command string, simulated bool) error {
+	file := config
+	if config.base != nil {
+		file = config.base
+	}
+	var problems configProblems
+
+	checkEndpoints(&problems, "ethereum.rpc_url", file.Ethereum.RpcURL)
+	checkWebSocket(&problems, "ethereum.ws_url", file.Ethereum.WsURL)
+	checkKey(&problems, "ethereum", file.Ethereum.KeyConfig)
+	checkKey(&problems, "ethereum.standby", file.Ethereum.Standby.KeyConfig)
+	checkChainID(&problems, "ethereum.chain_id", file.Ethereum.ChainID)
+	checkGasLimit(&problems, "ethereum.gas_limit", file.Ethereum.GasLimit)
+	for _, name := range slices.Sorted(maps.Keys(file.Networks)) {
+		network, prefix := file.Networks[name], "networks."+name
+		checkEndpoints(&problems, prefix+".rpc_url", network.RpcURL)
+		checkWebSocket(&problems, prefix+".ws_url", network.WsURL)
+		checkKey(&problems, prefix, network.KeyConfig)
+		checkChainID(&problems, prefix+".chain_id", network.ChainID)
+		checkGasLimit(&problems, prefix+".gas_limit", network.GasLimit)
+		aliases := file.Contracts
+		if len(network.Contracts) > 0 {
+			aliases = network.Contracts
+		}
+		checkAddress(&problems, aliases, prefix+".contract_address", network.ContractAddress)
+	}
+
+	for _, setting := range []struct{ field, value string }{
+		{"contract.address", file.Contract.Address},
+		{"contract.proxy_admin", file.Contract.ProxyAdmin},
+		{"safe.address", file.Safe.Address},
+		{"forwarder.address", file.Forwarder.Address},
+		{"estimate.from", file.Estimate.From},
+	} {
+		checkAddress(&problems, file.Contracts, setting.field, setting.value)
+	}
+	if file.ENS.Registry != "" && !common.IsHexAddress(file.ENS.Registry) {
+		problems.add("ens.registry", "%q is not an address", file.ENS.Registry)
+	}
+
+	if simulated || slices.Contains(commandsUsingBuild, command) {
+		switch info, err := os.Stat(config.Build.Directory); {
+		case config.Build.Directory == "":
+			problems.add("build.directory", "is required, the directory holding the compiled contract")
+		case err != nil:
+			problems.add("build.directory", "%s does not exist, compile the contract into it first (see Prerequisites for Deployment)", config.Build.Directory)
+		case !info.IsDir():
+			problems.add("build.directory", "%s is not a directory", config.Build.Directory)
+		}
+		if config.Build.ContractName == "" {
+			problems.add("build.contract_name", "is required, the base name of the .bin and .abi files")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	for i, problem := range problems {
+		path, _, _ := strings.Cut(problem.Field, "[")
+		if source := config.overrides[path]; source != "" {
+			problems[i].Message += fmt.Sprintf(" (set by %s)", source)
+		}
+	}
+	return problems
+}
+
+// checkEndpoints checks JSON-RPC endpoints, which are HTTP or WebSocket
+// URLs, or paths of IPC sockets
+func checkEndpoints(problems *configProblems, field string, urls URLList) {
+	for i, raw := range urls {
+		name := field
+		if len(urls) > 1 {
+			name = fmt.Sprintf("%s[%d]", field, i)
+		}
+		if !strings.Contains(raw, "://") {
+			if raw == "" {
+				problems.add(name, "is empty")
+			}
+			continue
+		}
+		u, err := url.Parse(raw)
+		switch {
+		case err != nil:
+			problems.add(name, "invalid URL: %v", err)
+		case !slices.Contains([]string{"http", "https", "ws", "wss"}, u.Scheme):
+			problems.add(name, "scheme %q is not supported, use http, https, ws or wss", u.Scheme)
+		case u.Host == "":
+			problems.add(name, "%q has no host", raw)
+		}
+	}
+}
+
+func checkWebSocket(problems *configProblems, field, raw string) {
+	if raw == "" {
+		return
+	}
+	if u, err := url.Parse(raw); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		problems.add(field, "%q is not a ws:// or wss:// URL", raw)
+	}
+}
+
+// checkKey checks the key settings that can be checked without reading
+// the key. Secret references are only resolved when the key is used
+func checkKey(problems *configProblems, prefix string, key KeyConfig) {
+	if key.PrivateKey != "" && !isSecretReference(key.PrivateKey) {
+		hex := strings.TrimPrefix(strings.TrimPrefix(key.PrivateKey, "0x"), "0X")
+		switch {
+		case len(hex) != 64:
+			problems.add(prefix+".private_key", "must be 64 hex digits (32 bytes), not %d characters", len(hex))
+		case strings.Trim(strings.ToLower(hex), "0123456789abcdef") != "":
+			problems.add(prefix+".private_key", "holds characters other than hex digits")
+		}
+	}
+	if key.SignerAccount != "" && !common.IsHexAddress(key.SignerAccount) {
+		problems.add(prefix+".signer_account", "%q is not an address", key.SignerAccount)
+	}
+	if key.SignerURL != "" {
+		// Like a node, an external signer is dialed over HTTP, WebSocket or IPC
+		checkEndpoints(problems, prefix+".signer_url", URLList{key.SignerURL})
+	}
+	if key.Keystore.File != "" {
+		if _, err := os.Stat(key.Keystore.File); err != nil {
+			problems.add(prefix+".keystore.file", "%s cannot be read: %v", key.Keystore.File, err)
+		}
+	}
+	if key.Mnemonic.Phrase != "" && !isSecretReference(key.Mnemonic.Phrase) {
+		if n := len(strings.Fields(key.Mnemonic.Phrase)); n%3 != 0 || n < 12 || n > 24 {
+			problems.add(prefix+".mnemonic.phrase", "has %d words, a BIP-39 mnemonic has 12, 15, 18, 21 or 24", n)
+		}
+	}
+}
+
+func checkChainID(problems *configProblems, field string, id int64) {
+	if id < 0 {
+		problems.add(field, "must be positive, or 0 to take the chain ID of the node")
+	}
+}
+
+func checkGasLimit(problems *configProblems, field string, limit uint64) {
+	if limit != 0 && (limit < minGasLimit || limit > maxGasLimit) {
+		problems.add(field, "%d is outside %d to %d, or 0 to estimate it", limit, minGasLimit, maxGasLimit)
+	}
+}
+
+// checkAddress checks a setting taking an address, an ENS name or an alias
+// of contracts
+func checkAddress(problems *configProblems, aliases map[string]string, field, value string) {
+	if value == "" || common.IsHexAddress(value) || ens.IsName(value) {
+		return
+	}
+	if _, ok := aliases[value]; ok {
+		return
+	}
+	if strings.HasPrefix(value, "0x") {
+		problems.add(field, "%q is not an address: it must be 0x followed by 40 hex digits", value)
+		return
+	}
+	problems.add(field, "%q is neither an address, an ENS name nor an alias of contracts", value)
+}
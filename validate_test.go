@@ -0,0 +1,66 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckKeySignerURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"http://127.0.0.1:8550", true},
+		{"https://signer.internal", true},
+		{"ws://127.0.0.1:8550", true},
+		{"wss://signer.internal/ws", true},
+		{"~/.clef/clef.ipc", true},
+		{"/var/run/clef/clef.ipc", true},
+		{"ftp://signer.internal", false},
+		{"http://", false},
+		{"http://%zz", false},
+	}
+	for _, tt := range tests {
+		var problems configProblems
+		checkKey(&problems, "ethereum", KeyConfig{SignerURL: tt.url})
+		if ok := len(problems) == 0; ok != tt.ok {
+			t.Errorf("signer_url %q: problems %v, want ok %v", tt.url, problems, tt.ok)
+		}
+		for _, problem := range problems {
+			if problem.Field != "ethereum.signer_url" {
+				t.Errorf("signer_url %q: problem reported on %s", tt.url, problem.Field)
+			}
+		}
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	for path, want := range map[string]string{
+		"~/.clef/clef.ipc":      filepath.Join(home, ".clef", "clef.ipc"),
+		"/tmp/clef.ipc":         "/tmp/clef.ipc",
+		"http://127.0.0.1:8550": "http://127.0.0.1:8550",
+	} {
+		if got := expandHome(path); got != want {
+			t.Errorf("expandHome(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
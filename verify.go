@@ -0,0 +1,298 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+)
+
+// Exit codes of verify-dir and verify-data, so CI gates can tell the
+// failures apart
+const (
+	exitVerifyMissing  = 3
+	exitVerifyMismatch = 4
+	exitVerifyExtra    = 5
+)
+
+type verifyStatus string
+
+const (
+	statusVerified verifyStatus = "VERIFIED"
+	statusMissing  verifyStatus = "MISSING"
+	statusMismatch verifyStatus = "MISMATCH"
+)
+
+// verifyResult is the outcome of checking one file
+type verifyResult struct {
+	Path   string
+	Status verifyStatus
+	Record *indexer.Record
+	Detail string
+}
+
+func runVerifyDir(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("verify-dir", flag.ContinueOnError)
+	manifestFile := flags.String("manifest", "", "CSV file mapping paths to record keys (path,key[,field])")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: contract-storage-eth verify-dir <directory> [--manifest keys.csv] [--no-sync]")
+		flags.PrintDefaults()
+	}
+	// Accept the directory before or after the flags
+	var dir string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		dir, args = args[0], args[1:]
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if dir == "" && flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+	if dir == "" {
+		flags.Usage()
+		return exitError(2)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+
+	var manifest map[string]indexer.Ref
+	if *manifestFile != "" {
+		if manifest, err = readManifest(*manifestFile, ns); err != nil {
+			return fmt.Errorf("Failed to read manifest: %w", err)
+		}
+	}
+
+	results, err := verifyDir(store, ns, dir, manifest)
+	if err != nil {
+		return fmt.Errorf("Failed to verify directory: %w", err)
+	}
+
+	counts := map[verifyStatus]int{}
+	files := make([]verifiedFile, 0, len(results))
+	for _, r := range results {
+		counts[r.Status]++
+		file := verifiedFile{Path: r.Path, Status: r.Status, Detail: r.Detail}
+		if r.Record != nil {
+			file.Record = &api.Event{ID: recordID(ns, r.Record).String(), Record: r.Record}
+		}
+		files = append(files, file)
+		if output.json {
+			continue
+		}
+		line := fmt.Sprintf("%-8s  %s", r.Status, r.Path)
+		if r.Record != nil {
+			line += fmt.Sprintf("  %s  block %d  tx %s", recordID(ns, r.Record), r.Record.BlockNumber, r.Record.TxHash.Hex())
+		}
+		if r.Detail != "" {
+			line += "  (" + r.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+	summary := fmt.Sprintf("%d verified, %d missing, %d mismatched", counts[statusVerified], counts[statusMissing], counts[statusMismatch])
+	fmt.Printf("\n%s\n", summary)
+
+	result := verifyDirResult{Verified: counts[statusVerified], Missing: counts[statusMissing], Mismatched: counts[statusMismatch], Files: files}
+	switch {
+	case counts[statusMismatch] > 0:
+		printFailure("mismatch", summary, result)
+		return exitError(exitVerifyMismatch)
+	case counts[statusMissing] > 0:
+		printFailure("missing", summary, result)
+		return exitError(exitVerifyMissing)
+	}
+	printResult(result)
+	return nil
+}
+
+// verifyDirResult is the result of verify-dir in JSON output mode
+type verifyDirResult struct {
+	Verified   int            `json:"verified"`
+	Missing    int            `json:"missing"`
+	Mismatched int            `json:"mismatched"`
+	Files      []verifiedFile `json:"files"`
+}
+
+type verifiedFile struct {
+	Path   string       `json:"path"`
+	Status verifyStatus `json:"status"`
+	Record *api.Event   `json:"record,omitempty"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+// verifyDir checks every file under dir. Files listed in the manifest are
+// compared with the latest version of their record; other files are looked
+// up by content hash. Manifest entries without a file are reported missing.
+func verifyDir(store *indexer.Store, ns recordid.Namespace, dir string, manifest map[string]indexer.Ref) ([]verifyResult, error) {
+	var results []verifyResult
+	seen := map[string]bool{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		result := verifyResult{Path: rel}
+		if ref, ok := manifest[rel]; ok {
+			record, err := store.Get(ref)
+			switch {
+			case errors.Is(err, indexer.ErrNotFound):
+				result.Status = statusMissing
+				result.Detail = "no record for " + refID(ns, ref).String()
+			case err != nil:
+				return err
+			case record.Anchors(content):
+				result.Status, result.Record = statusVerified, record
+			default:
+				result.Status, result.Record = statusMismatch, record
+				result.Detail = "content differs from the anchored value"
+			}
+		} else {
+			records, err := findByContent(store, content)
+			if err != nil {
+				return err
+			}
+			if len(records) == 0 {
+				result.Status = statusMissing
+			} else {
+				result.Status, result.Record = statusVerified, records[len(records)-1]
+			}
+		}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var absent []string
+	for path := range manifest {
+		if !seen[path] {
+			absent = append(absent, path)
+		}
+	}
+	sort.Strings(absent)
+	for _, path := range absent {
+		results = append(results, verifyResult{Path: path, Status: statusMissing, Detail: "file not found"})
+	}
+	return results, nil
+}
+
+// findByContent returns the records anchoring content, in chain order
+func findByContent(store *indexer.Store, content []byte) ([]*indexer.Record, error) {
+	seen := map[string]bool{}
+	var records []*indexer.Record
+	for _, h := range indexer.ContentHashes(content) {
+		found, err := store.FindByValueHash(h)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range found {
+			id := fmt.Sprintf("%s/%d", r.TxHash.Hex(), r.LogIndex)
+			if !seen[id] {
+				seen[id] = true
+				records = append(records, r)
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].BlockNumber != records[j].BlockNumber {
+			return records[i].BlockNumber < records[j].BlockNumber
+		}
+		return records[i].LogIndex < records[j].LogIndex
+	})
+	return records, nil
+}
+
+// readManifest parses path,key[,field] rows, skipping a header row. The key
+// may be a record ID of the deployment instead
+func readManifest(name string, ns recordid.Namespace) (map[string]indexer.Ref, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	manifest := map[string]indexer.Ref{}
+	for first := true; ; first = false {
+		row, err := r.Read()
+		if err == io.EOF {
+			return manifest, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if first && (strings.EqualFold(row[0], "path") || strings.EqualFold(row[0], "file")) {
+			continue
+		}
+		if len(row) < 2 || row[0] == "" || row[1] == "" {
+			return nil, fmt.Errorf("%s:%d: expected path,key[,field]", name, line)
+		}
+		field := ""
+		if len(row) > 2 {
+			field = row[2]
+		}
+		ref, err := resolveRef(ns, row[1], field)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		manifest[filepath.ToSlash(filepath.Clean(row[0]))] = ref
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		manifest string
+		status   int
+		want     []string
+	}{
+		{
+			name:   "anchored content",
+			files:  map[string]string{"doc.txt": "anchored document", "invoice.pdf": "invoice 42 v2"},
+			status: 0,
+			want:   []string{"VERIFIED  doc.txt", "VERIFIED  invoice.pdf", "2 verified, 0 missing, 0 mismatched"},
+		},
+		{
+			name:   "unknown content",
+			files:  map[string]string{"doc.txt": "anchored document", "other.txt": "never saved"},
+			status: exitVerifyMissing,
+			want:   []string{"MISSING   other.txt", "1 verified, 1 missing, 0 mismatched"},
+		},
+		{
+			name:     "superseded version",
+			files:    map[string]string{"invoice.pdf": "invoice 42 v1"},
+			manifest: "path,key,field\ninvoice.pdf,invoice-42,pdf\n",
+			status:   exitVerifyMismatch,
+			want:     []string{"MISMATCH  invoice.pdf", "content differs from the anchored value"},
+		},
+		{
+			name:     "listed file absent",
+			files:    map[string]string{"doc.txt": "anchored document"},
+			manifest: "doc.txt,doc,sha256\ngone.pdf,invoice-43,pdf\n",
+			status:   exitVerifyMissing,
+			want:     []string{"VERIFIED  doc.txt", "MISSING   gone.pdf  (file not found)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := indexedDir(t)
+			files := filepath.Join(dir, "files")
+			if err := os.Mkdir(files, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(files, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			args := []string{"verify-dir", "files", "--no-sync"}
+			if tt.manifest != "" {
+				if err := os.WriteFile(filepath.Join(dir, "keys.csv"), []byte(tt.manifest), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--manifest", "keys.csv")
+			}
+
+			out, status := runMain(t, dir, args...)
+			if status != tt.status {
+				t.Errorf("exit status %d, want %d", status, tt.status)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
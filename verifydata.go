@@ -0,0 +1,219 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/export"
+	"contract-storage-eth/importer"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/reconcile"
+
+	// Database drivers of verify-data --driver
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+func runVerifyData(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("verify-data", flag.ContinueOnError)
+	format := flags.String("format", "", "source file format: csv, jsonl or json (default from the file extension)")
+	driver := flags.String("driver", "mysql", "database driver of --dsn: mysql or postgres")
+	dsn := flags.String("dsn", "", "database to read the source records from, instead of a file")
+	query := flags.String("query", "", "query selecting the source records from --dsn, with key, field and value columns")
+	prefix := flags.String("prefix", "", "only compare the keys starting with this prefix")
+	noSync := flags.Bool("no-sync", false, "query the local index without syncing it first")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: contract-storage-eth verify-data <file> [flags]")
+		fmt.Fprintln(flags.Output(), "       contract-storage-eth verify-data --dsn DSN --query QUERY [flags]")
+		flags.PrintDefaults()
+	}
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	if (len(positional) == 1) == (*dsn != "") || len(positional) > 1 {
+		flags.Usage()
+		return exitError(2)
+	}
+	if *dsn != "" && *query == "" {
+		return fail(codeInvalidArgument, "Invalid flags: --query is required with --dsn")
+	}
+
+	// Read the source first, as it is what a mistake is most likely in
+	source, err := readSource(ctx, positional, *format, *driver, *dsn, *query)
+	if err != nil {
+		return fmt.Errorf("Failed to read source records: %w", err)
+	}
+
+	store, err := openIndex(config)
+	if err != nil {
+		return fail(codeIndex, "Failed to open index: %w", err)
+	}
+	defer store.Close()
+
+	if !*noSync {
+		if err := syncIndex(ctx, config, store); err != nil {
+			return fail(codeIndex, "Failed to sync index: %w", err)
+		}
+	}
+	ns, err := recordNamespace(ctx, config, store, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+	records, err := store.Query(indexer.Query{})
+	if err != nil {
+		return fail(codeIndex, "Failed to query index: %w", err)
+	}
+
+	// The source holds the keys of the namespace, the contract their
+	// stored form
+	*prefix = keyNamespace.Key(*prefix)
+	var state []*indexer.Record
+	for _, r := range export.Latest(records) {
+		if strings.HasPrefix(r.Key, *prefix) {
+			state = append(state, r)
+		}
+	}
+	var wanted []*importer.Record
+	for _, rec := range source {
+		rec.Key = keyNamespace.Key(rec.Key)
+		if strings.HasPrefix(rec.Key, *prefix) {
+			wanted = append(wanted, rec)
+		}
+	}
+
+	counts := map[reconcile.Status]int{}
+	result := verifyDataResult{Source: len(wanted), Entries: []verifiedData{}}
+	for _, e := range reconcile.Diff(wanted, state, decryptionKeys()...) {
+		counts[e.Status]++
+		if e.Status == reconcile.Match {
+			continue
+		}
+		entry := verifiedData{Status: e.Status, Key: e.Key, Field: e.Field}
+		if e.Have != nil {
+			entry.Record = &api.Event{ID: recordID(ns, e.Have).String(), Record: e.Have}
+		}
+		if e.Want != nil {
+			entry.Value = e.Want.Value
+		}
+		result.Entries = append(result.Entries, entry)
+		if output.json {
+			continue
+		}
+		line := fmt.Sprintf("%-8s  %s", e.Status, refID(ns, indexer.Ref{Key: e.Key, Field: e.Field}))
+		if e.Have != nil {
+			line = fmt.Sprintf("%-8s  %s  block %d  tx %s", e.Status, recordID(ns, e.Have), e.Have.BlockNumber, e.Have.TxHash.Hex())
+		}
+		fmt.Println(line)
+	}
+	result.Matched, result.Missing, result.Extra, result.Mismatched = counts[reconcile.Match], counts[reconcile.Missing], counts[reconcile.Extra], counts[reconcile.Mismatch]
+	summary := fmt.Sprintf("%d matched, %d missing, %d extra, %d mismatched", result.Matched, result.Missing, result.Extra, result.Mismatched)
+	fmt.Printf("\n%s\n", summary)
+
+	switch {
+	case result.Mismatched > 0:
+		printFailure("mismatch", summary, result)
+		return exitError(exitVerifyMismatch)
+	case result.Missing > 0:
+		printFailure("missing", summary, result)
+		return exitError(exitVerifyMissing)
+	case result.Extra > 0:
+		printFailure("extra", summary, result)
+		return exitError(exitVerifyExtra)
+	}
+	printResult(result)
+	return nil
+}
+
+// verifyDataResult is the result of verify-data in JSON output mode. Its
+// entries are the pairs that did not match.
+type verifyDataResult struct {
+	Source     int            `json:"source"`
+	Matched    int            `json:"matched"`
+	Missing    int            `json:"missing"`
+	Extra      int            `json:"extra"`
+	Mismatched int            `json:"mismatched"`
+	Entries    []verifiedData `json:"entries"`
+}
+
+type verifiedData struct {
+	Status reconcile.Status `json:"status"`
+	Key    string           `json:"key"`
+	Field  string           `json:"field"`
+	Value  string           `json:"value,omitempty"`
+	Record *api.Event       `json:"record,omitempty"`
+}
+
+// readSource reads the records of the source of truth, a file or the result
+// of a database query
+func readSource(ctx context.Context, files []string, format, driver, dsn, query string) ([]*importer.Record, error) {
+	var reader *importer.Reader
+	if dsn != "" {
+		switch driver {
+		case "mysql", "postgres":
+		default:
+			return nil, fmt.Errorf("unknown driver %q, want mysql or postgres", driver)
+		}
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		if reader, err = importer.NewRowsReader(rows); err != nil {
+			return nil, err
+		}
+	} else {
+		path := files[0]
+		if format == "" {
+			var err error
+			if format, err = importer.FormatOf(path); err != nil {
+				return nil, err
+			}
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if reader, err = importer.NewReader(f, format); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []*importer.Record
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/storage"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func runWatch(ctx context.Context, config *Config, args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	prefix := flags.String("prefix", "", "only show events of keys starting with this prefix")
+	field := flags.String("field", "", "only show events of this field")
+	fromBlock := flags.Uint64("from-block", 0, "also show the events since this block (default new events only)")
+	interval := flags.Duration("interval", 5*time.Second, "delay between polls when subscriptions are not available")
+	poll := flags.Bool("poll", false, "poll for new events instead of subscribing")
+	raw := flags.Bool("raw", false, "print stored values without opening their envelope")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	client, err := dialClient(ctx, config)
+	if err != nil {
+		return fail(codeRPCUnavailable, "Failed to connect to Ethereum node: %w", err)
+	}
+	defer client.Close()
+
+	ns, err := recordNamespace(ctx, config, nil, client)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve record IDs: %w", err)
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get latest block: %w", err)
+	}
+
+	w := &watcher{
+		config: config,
+		client: client,
+		ns:     ns,
+		prefix: keyNamespace.Key(*prefix),
+		field:  *field,
+		raw:    *raw,
+		next:   head + 1,
+	}
+	if isFlagSet(flags, "from-block") {
+		w.next = *fromBlock
+	}
+
+	fmt.Printf("Watching DataSaved events of %s from block %d, press Ctrl+C to stop\n", ns.Contract.Hex(), w.next)
+
+	// Each round subscribes before catching up by polling, so that no event
+	// falls in between: events delivered twice are dropped by position. It
+	// then follows the subscription until it ends, or polls once without
+	// one
+	subscribe := !*poll
+	for ctx.Err() == nil {
+		var sub *subscription
+		if subscribe {
+			sub, err = w.subscribe(ctx)
+			switch {
+			case errors.Is(err, rpc.ErrNotificationsUnsupported):
+				subscribe = false
+				slog.Info("The node does not support subscriptions, polling for new events", "interval", *interval)
+			case err != nil && ctx.Err() == nil:
+				slog.Warn("Failed to subscribe to events, polling for new events", "interval", *interval, "error", err)
+			}
+		}
+		if err := w.pollOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to poll for events", "error", err)
+		}
+
+		if sub != nil {
+			err := sub.forward(ctx, w.print)
+			sub.close()
+			if ctx.Err() == nil {
+				slog.Warn("Event subscription ended, subscribing again", "delay", *interval, "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(*interval):
+		}
+	}
+	return nil
+}
+
+// watcher prints the DataSaved events matching its filters once each, in
+// chain order
+type watcher struct {
+	config *Config
+	client *chain.Client
+	ns     recordid.Namespace
+	prefix string
+	field  string
+	raw    bool
+
+	// next is the first block not queried yet, and last the position of
+	// the last event printed
+	next uint64
+	last *types.Log
+}
+
+// catchUp prints the events of the blocks up to head not seen yet
+func (w *watcher) catchUp(ctx context.Context, head uint64) error {
+	batch := w.config.Index.BatchSize
+	if batch == 0 {
+		batch = 2000
+	}
+	// Providers cap the range of a query
+	for w.next <= head {
+		to := min(w.next+batch-1, head)
+		q, err := storage.DataSavedQuery(w.ns.Contract, w.next, &to)
+		if err != nil {
+			return err
+		}
+		logs, err := w.client.FilterLogs(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			w.print(l)
+		}
+		w.next = to + 1
+	}
+	return nil
+}
+
+// pollOnce prints the events mined since the last poll
+func (w *watcher) pollOnce(ctx context.Context) error {
+	head, err := w.client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	return w.catchUp(ctx, head)
+}
+
+// print prints an event unless it is filtered out or was printed already.
+// Events removed by a reorg, which only subscriptions report, are printed
+// as such
+func (w *watcher) print(l types.Log) {
+	if !l.Removed && w.last != nil && (l.BlockNumber < w.last.BlockNumber || l.BlockNumber == w.last.BlockNumber && l.Index <= w.last.Index) {
+		return
+	}
+	ev, err := storage.ParseDataSaved(l)
+	if err != nil {
+		slog.Warn("Skipping undecodable log", "tx_hash", l.TxHash.Hex(), "log_index", l.Index, "error", err)
+		return
+	}
+	if !l.Removed {
+		w.last = &l
+		w.next = max(w.next, l.BlockNumber+1)
+	}
+	if !strings.HasPrefix(ev.Key, w.prefix) || (w.field != "" && ev.Field != w.field) {
+		return
+	}
+
+	value := ev.Value
+	if !w.raw {
+		value = decodeValue(value)
+	}
+	id := w.ns.ID(ev.Key, ev.Field, 0)
+	if output.json {
+		streamResult(chainEvent{Block: l.BlockNumber, TxHash: l.TxHash, LogIndex: l.Index, ID: id.String(), Key: ev.Key, Field: ev.Field, Value: value, Removed: l.Removed})
+		return
+	}
+	if l.Removed {
+		fmt.Printf("block %d  tx %s  %s  removed by a reorg\n", l.BlockNumber, l.TxHash.Hex(), id)
+		return
+	}
+	fmt.Printf("block %d  tx %s  %s  %s\n", l.BlockNumber, l.TxHash.Hex(), id, value)
+}
+
+// subscription is a live log subscription, on a connection of its own when
+// ethereum.ws_url is set
+type subscription struct {
+	logs chan types.Log
+	sub  ethereum.Subscription
+	// own is the connection of the subscription, nil when it shares the
+	// one of the watcher
+	own *chain.Client
+}
+
+// subscribe subscribes to the DataSaved events of the contract
+func (w *watcher) subscribe(ctx context.Context) (*subscription, error) {
+	client, own := w.client, (*chain.Client)(nil)
+	if w.config.Ethereum.WsURL != "" {
+		var err error
+		if own, err = dialEndpoints(ctx, w.config, []string{w.config.Ethereum.WsURL}); err != nil {
+			return nil, err
+		}
+		client = own
+	}
+	q, err := storage.DataSavedQuery(w.ns.Contract, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Subscriptions only deliver new events, catchUp queries the older ones
+	q.FromBlock = nil
+
+	s := &subscription{logs: make(chan types.Log, 64), own: own}
+	if s.sub, err = client.SubscribeFilterLogs(ctx, q, s.logs); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// forward passes the events of the subscription to fn until ctx is done or
+// the subscription fails
+func (s *subscription) forward(ctx context.Context, fn func(types.Log)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-s.sub.Err():
+			return err
+		case l := <-s.logs:
+			fn(l)
+		}
+	}
+}
+
+func (s *subscription) close() {
+	if s.sub != nil {
+		s.sub.Unsubscribe()
+	}
+	if s.own != nil {
+		s.own.Close()
+	}
+}
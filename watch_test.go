@@ -0,0 +1,95 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/storage"
+)
+
+// captureStdout returns what fn prints
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	defer func() { os.Stdout = saved }()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestWatcherPrint(t *testing.T) {
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := storage.DataSavedQuery(chain.Contract, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, err := chain.FilterLogs(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &watcher{ns: recordid.Namespace{ChainID: 1337, Contract: chain.Contract}, prefix: "invoice-", field: "pdf", next: 1}
+	out := captureStdout(t, func() {
+		for _, l := range logs {
+			w.print(l)
+		}
+		// A subscription delivering events the poll printed already
+		for _, l := range logs {
+			w.print(l)
+		}
+		removed := logs[len(logs)-1]
+		removed.Removed = true
+		w.print(removed)
+	})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{
+		"block 2  tx " + logs[0].TxHash.Hex() + "  ethstore://1337/" + chain.Contract.Hex() + "/invoice-42#pdf  invoice 42 v1",
+		"block 3  tx ",
+		"block 6  tx ",
+		"block 6  tx " + logs[len(logs)-1].TxHash.Hex() + "  ethstore://1337/" + chain.Contract.Hex() + "/invoice-43#pdf  removed by a reorg",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("printed\n%s\nwant %d lines", out, len(want))
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf("line %d is\n%s\nwant\n%s", i, line, want[i])
+		}
+	}
+	if w.next != 7 {
+		t.Errorf("next block %d, want 7", w.next)
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"contract-storage-eth/api"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/webhook"
+)
+
+const webhookUsage = `Usage: contract-storage-eth webhook <command> [flags]
+
+Commands:
+  rotate       Create a new signing secret, keeping the old ones valid for the overlap window
+  ping         Send a signed test delivery to every configured URL
+  dlq list     Show the deliveries that failed every attempt
+  dlq retry    Send dead-lettered deliveries again (by id, or --all)
+`
+
+// loadWebhookKeyring opens the configured webhook secrets file
+func loadWebhookKeyring(config *Config) (*webhook.Keyring, error) {
+	path := config.Webhook.SecretsFile
+	if path == "" {
+		path = "webhook_secrets.json"
+	}
+	return webhook.LoadKeyring(path)
+}
+
+// newDispatcher returns the retrying webhook sender, dead-lettering
+// deliveries that keep failing in the configured file
+func newDispatcher(config *Config, keyring *webhook.Keyring) (*webhook.Dispatcher, error) {
+	path := config.Webhook.DLQFile
+	if path == "" {
+		path = "webhook_dlq.json"
+	}
+	dlq, err := webhook.OpenDLQ(path)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook.Dispatcher{
+		Sender: webhook.NewSender(keyring, config.Webhook.Timeout),
+		DLQ:    dlq,
+		Policy: webhook.RetryPolicy{
+			MaxAttempts: config.Webhook.Retry.MaxAttempts,
+			BaseDelay:   config.Webhook.Retry.BaseDelay,
+			MaxDelay:    config.Webhook.Retry.MaxDelay,
+		},
+		Logger: slog.Default(),
+	}, nil
+}
+
+func runWebhook(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, webhookUsage)
+		return exitError(2)
+	}
+
+	keyring, err := loadWebhookKeyring(config)
+	if err != nil {
+		return fmt.Errorf("Failed to load webhook secrets: %w", err)
+	}
+
+	switch args[0] {
+	case "rotate":
+		flags := flag.NewFlagSet("webhook rotate", flag.ContinueOnError)
+		overlap := flags.Duration("overlap", config.Webhook.RotationOverlap, "how long the replaced secrets stay valid")
+		if err := parseFlags(flags, args[1:]); err != nil {
+			return err
+		}
+
+		secret, err := keyring.Rotate(*overlap)
+		if err != nil {
+			return fmt.Errorf("Failed to rotate webhook secret: %w", err)
+		}
+		fmt.Printf("New secret id: %s\n", secret.ID)
+		fmt.Printf("New secret key: %s\n", hex.EncodeToString(secret.Key))
+		fmt.Printf("Previous secrets remain valid for %s\n", *overlap)
+		printResult(rotateResult{ID: secret.ID, Key: hex.EncodeToString(secret.Key), CreatedAt: secret.CreatedAt, Overlap: overlap.String()})
+	case "ping":
+		sender := webhook.NewSender(keyring, config.Webhook.Timeout)
+		payload := &webhook.Payload{Type: "ping", Timestamp: time.Now().UTC()}
+		var result deliveriesResult
+		for _, url := range config.Webhook.URLs {
+			if err := sender.Deliver(ctx, url, payload); err != nil {
+				slog.Error("Webhook delivery failed", "url", url, "error", err)
+				result.add(delivery{URL: url, Error: err.Error()})
+				continue
+			}
+			fmt.Printf("Delivered ping to %s\n", url)
+			result.add(delivery{URL: url, Delivered: true})
+		}
+		if err := result.finish(); err != nil {
+			return err
+		}
+	case "dlq":
+		dispatcher, err := newDispatcher(config, keyring)
+		if err != nil {
+			return fmt.Errorf("Failed to open webhook dead letter queue: %w", err)
+		}
+		if err := runWebhookDLQ(ctx, dispatcher, args[1:]); err != nil {
+			return err
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown webhook command: %s\n\n%s", args[0], webhookUsage)
+		return exitError(2)
+	}
+	return nil
+}
+
+func runWebhookDLQ(ctx context.Context, dispatcher *webhook.Dispatcher, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, webhookUsage)
+		return exitError(2)
+	}
+
+	switch args[0] {
+	case "list":
+		letters := dispatcher.DLQ.List()
+		if output.json {
+			printResult(deadLettersResult{DeadLetters: append([]*webhook.DeadLetter{}, letters...)})
+			return nil
+		}
+		if len(letters) == 0 {
+			fmt.Println("No dead-lettered deliveries")
+			return nil
+		}
+		fmt.Printf("%d dead-lettered deliveries:\n", len(letters))
+		for _, d := range letters {
+			fmt.Printf("  %s  %s  %s  %d attempt(s), last %s\n", d.ID, d.Payload.Type, d.URL, d.Attempts, d.LastTry.Format("2006-01-02 15:04:05"))
+			fmt.Printf("      %s\n", d.LastError)
+		}
+	case "retry":
+		flags := flag.NewFlagSet("webhook dlq retry", flag.ContinueOnError)
+		all := flags.Bool("all", false, "retry every dead-lettered delivery")
+		if err := parseFlags(flags, args[1:]); err != nil {
+			return err
+		}
+
+		ids := flags.Args()
+		if *all {
+			ids = nil
+			for _, d := range dispatcher.DLQ.List() {
+				ids = append(ids, d.ID)
+			}
+		}
+		if len(ids) == 0 {
+			fmt.Fprint(os.Stderr, "Usage: contract-storage-eth webhook dlq retry <id>... | --all\n")
+			return exitError(2)
+		}
+		var result deliveriesResult
+		for _, id := range ids {
+			if err := dispatcher.Redeliver(ctx, id); err != nil {
+				slog.Error("Webhook redelivery failed", "dead_letter", id, "error", err)
+				result.add(delivery{ID: id, Error: err.Error()})
+				continue
+			}
+			fmt.Printf("Delivered %s\n", id)
+			result.add(delivery{ID: id, Delivered: true})
+		}
+		if err := result.finish(); err != nil {
+			return err
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown webhook dlq command: %s\n\n%s", args[0], webhookUsage)
+		return exitError(2)
+	}
+	return nil
+}
+
+// rotateResult is the result of webhook rotate in JSON output mode, as
+// POST /webhooks/secrets/rotate answers it
+type rotateResult struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	Overlap   string    `json:"overlap"`
+}
+
+// deadLettersResult is the result of webhook dlq list in JSON output mode
+type deadLettersResult struct {
+	DeadLetters []*webhook.DeadLetter `json:"dead_letters"`
+}
+
+// deliveriesResult is the result of webhook ping and webhook dlq retry in
+// JSON output mode
+type deliveriesResult struct {
+	Deliveries []delivery `json:"deliveries"`
+	failed     int
+}
+
+type delivery struct {
+	// URL is the receiver of a ping and ID the dead letter retried
+	URL       string `json:"url,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (r *deliveriesResult) add(d delivery) {
+	r.Deliveries = append(r.Deliveries, d)
+	if !d.Delivered {
+		r.failed++
+	}
+}
+
+// finish prints the result, failing with exit status 1 when a delivery
+// failed
+func (r *deliveriesResult) finish() error {
+	if r.failed == 0 {
+		printResult(r)
+		return nil
+	}
+	printFailure("delivery_failed", fmt.Sprintf("%d of %d deliveries failed", r.failed, len(r.Deliveries)), r)
+	return exitError(1)
+}
+
+// webhookCursor is the position after the last event notified
+type webhookCursor struct {
+	Block uint64 `json:"block"`
+	Log   uint   `json:"log"`
+}
+
+// webhookCursorPath returns the configured cursor file
+func webhookCursorPath(config *Config) string {
+	if config.Webhook.CursorFile != "" {
+		return config.Webhook.CursorFile
+	}
+	return "webhook_cursor.json"
+}
+
+// loadWebhookCursor reads the cursor, starting after the events already
+// indexed when there is none yet
+func loadWebhookCursor(config *Config, store *indexer.Store) (webhookCursor, error) {
+	var cursor webhookCursor
+	data, err := os.ReadFile(webhookCursorPath(config))
+	if errors.Is(err, os.ErrNotExist) {
+		cursor.Block, err = store.NextBlock(0)
+		return cursor, err
+	}
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%s: %w", webhookCursorPath(config), err)
+	}
+	return cursor, nil
+}
+
+// notifyWebhooks posts every DataSaved event the index syncs after cursor
+// to the webhook URLs until ctx is done, as "data.saved", or "data.deleted"
+// for empty values. Events are notified in chain order, each to all
+// receivers at once; deliveries that keep failing go to the dead letter
+// queue
+func notifyWebhooks(ctx context.Context, config *Config, store *indexer.Store, dispatcher *webhook.Dispatcher, ns recordid.Namespace, cursor webhookCursor) {
+	interval := config.Index.SyncInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	for {
+		records, err := store.Query(indexer.Query{FromBlock: cursor.Block, FromLog: cursor.Log})
+		if err != nil {
+			slog.Warn("Failed to query index for webhooks", "error", err)
+		}
+		for _, r := range records {
+			id := recordID(ns, r).String()
+			payload := &webhook.Payload{
+				Type:      "data.saved",
+				Timestamp: time.Now().UTC(),
+				Data:      api.Event{ID: id, Record: r},
+			}
+			if r.Value == "" {
+				payload.Type = "data.deleted"
+			}
+
+			var wg sync.WaitGroup
+			for _, url := range config.Webhook.URLs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := dispatcher.Dispatch(ctx, url, payload); err != nil {
+						slog.Error("Webhook delivery failed", "url", url, "id", id, "key", r.Key, "field", r.Field, "tx_hash", r.TxHash.Hex(), "error", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			// Interrupted deliveries are dead-lettered too, so the event is
+			// not notified again after a restart
+			cursor = webhookCursor{Block: r.BlockNumber, Log: r.LogIndex + 1}
+			if err := saveWebhookCursor(config, cursor); err != nil {
+				slog.Warn("Failed to save webhook cursor", "error", err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func saveWebhookCursor(config *Config, cursor webhookCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(webhookCursorPath(config), data)
+}
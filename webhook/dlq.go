@@ -0,0 +1,154 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeadLetter is a delivery that failed every attempt. It is kept until it
+// is delivered again or dropped.
+type DeadLetter struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Payload   *Payload  `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FirstTry  time.Time `json:"first_try"`
+	LastTry   time.Time `json:"last_try"`
+}
+
+// DLQ is the dead letter queue of failed deliveries, persisted in a JSON
+// file. It is safe for concurrent use within a process.
+type DLQ struct {
+	path string
+
+	mu      sync.Mutex
+	letters []*DeadLetter
+}
+
+// ErrNotFound is returned for an unknown dead letter id.
+var ErrNotFound = errors.New("webhook: no such dead letter")
+
+// OpenDLQ reads the dead letter queue at path, which may not exist yet.
+func OpenDLQ(path string) (*DLQ, error) {
+	q := &DLQ{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &q.letters); err != nil {
+		return nil, fmt.Errorf("webhook: invalid dead letter file %s: %w", path, err)
+	}
+	return q, nil
+}
+
+// List returns the dead letters, oldest first.
+func (q *DLQ) List() []*DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*DeadLetter{}, q.letters...)
+}
+
+// Get returns the dead letter with the given id.
+func (q *DLQ) Get(id string) (*DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, d := range q.letters {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Add stores a failed delivery, assigning its id when empty, or replaces
+// the dead letter with the same id.
+func (q *DLQ) Add(d *DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if d.ID == "" {
+		id := make([]byte, 6)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		d.ID = hex.EncodeToString(id)
+	}
+	letters := make([]*DeadLetter, 0, len(q.letters)+1)
+	replaced := false
+	for _, existing := range q.letters {
+		if existing.ID == d.ID {
+			existing, replaced = d, true
+		}
+		letters = append(letters, existing)
+	}
+	if !replaced {
+		letters = append(letters, d)
+	}
+	return q.saveLocked(letters)
+}
+
+// Remove drops a dead letter.
+func (q *DLQ) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	letters := make([]*DeadLetter, 0, len(q.letters))
+	for _, d := range q.letters {
+		if d.ID != id {
+			letters = append(letters, d)
+		}
+	}
+	if len(letters) == len(q.letters) {
+		return ErrNotFound
+	}
+	return q.saveLocked(letters)
+}
+
+// saveLocked replaces the file atomically, so that a crash never loses the
+// letters already stored
+func (q *DLQ) saveLocked(letters []*DeadLetter) error {
+	data, err := json.MarshalIndent(letters, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return err
+	}
+	q.letters = letters
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how failed deliveries are retried before they are
+// dead-lettered.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on every
+	// further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when no policy is configured. It keeps trying
+// for about a quarter of an hour.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 8,
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    5 * time.Minute,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+// backoff returns the jittered delay before the given retry (1-based).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Equal jitter, so that receivers coming back are not hit by every
+	// sender at once
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// StatusError is returned when a receiver answers with a non-2xx status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook: %s answered %s", e.URL, e.Status)
+}
+
+// retriable reports whether a failed delivery may succeed later. Client
+// errors other than timeouts and rate limits mean the receiver rejects the
+// payload, so retrying it right away is pointless.
+func retriable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) && status.StatusCode >= 400 && status.StatusCode < 500 {
+		return status.StatusCode == http.StatusRequestTimeout || status.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// ErrDeadLettered is returned by Dispatcher.Dispatch when a delivery failed
+// and was stored in the dead letter queue.
+var ErrDeadLettered = errors.New("webhook: delivery dead-lettered")
+
+// Dispatcher delivers payloads with retries, storing those that keep
+// failing in a dead letter queue so that none is lost.
+type Dispatcher struct {
+	Sender *Sender
+	DLQ    *DLQ
+	Policy RetryPolicy
+	// OnRetry is called after each failed attempt that will be retried.
+	OnRetry func(url string, attempt int, delay time.Duration, err error)
+	// Logger, when set, receives each retry as a warning. Deliveries that
+	// finally fail are returned rather than logged.
+	Logger *slog.Logger
+}
+
+// Dispatch delivers payload to url, retrying with exponential backoff. When
+// every attempt fails, or ctx is done first, the delivery is dead-lettered
+// and an error wrapping ErrDeadLettered is returned.
+func (d *Dispatcher) Dispatch(ctx context.Context, url string, payload *Payload) error {
+	letter := &DeadLetter{URL: url, Payload: payload, FirstTry: time.Now().UTC()}
+	return d.deliver(ctx, letter)
+}
+
+// Redeliver tries a dead letter again with the retry policy, removing it
+// from the queue once delivered and updating it otherwise.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) error {
+	letter, err := d.DLQ.Get(id)
+	if err != nil {
+		return err
+	}
+	copied := *letter
+	if err := d.deliver(ctx, &copied); err != nil {
+		return err
+	}
+	return d.DLQ.Remove(id)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, letter *DeadLetter) error {
+	policy := d.Policy.withDefaults()
+	var err error
+	for attempt := 1; ; attempt++ {
+		letter.Attempts++
+		letter.LastTry = time.Now().UTC()
+		if err = d.Sender.Deliver(ctx, letter.URL, letter.Payload); err == nil {
+			return nil
+		}
+		if !retriable(err) || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+		delay := policy.backoff(attempt)
+		if d.Logger != nil {
+			d.Logger.WarnContext(ctx, "Webhook delivery failed, retrying", "url", letter.URL, "type", letter.Payload.Type, "attempt", attempt, "delay", delay, "error", err)
+		}
+		if d.OnRetry != nil {
+			d.OnRetry(letter.URL, attempt, delay, err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	letter.LastError = err.Error()
+	if ctx.Err() != nil {
+		letter.LastError = fmt.Sprintf("%v (interrupted: %v)", err, ctx.Err())
+	}
+	if dlqErr := d.DLQ.Add(letter); dlqErr != nil {
+		return fmt.Errorf("%w, and storing it failed: %v", err, dlqErr)
+	}
+	return fmt.Errorf("%w as %s: %v", ErrDeadLettered, letter.ID, err)
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"contract-storage-eth/webhook"
+)
+
+// receiver answers the given statuses in turn, then 200
+func receiver(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newDispatcher(t *testing.T, dir string) *webhook.Dispatcher {
+	t.Helper()
+	keyring, err := webhook.LoadKeyring(filepath.Join(dir, "secrets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dlq, err := webhook.OpenDLQ(filepath.Join(dir, "dlq.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &webhook.Dispatcher{
+		Sender: webhook.NewSender(keyring, time.Second),
+		DLQ:    dlq,
+		Policy: webhook.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond},
+	}
+}
+
+func TestDispatchRetries(t *testing.T) {
+	d := newDispatcher(t, t.TempDir())
+	server, calls := receiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	if err := d.Dispatch(context.Background(), server.URL, &webhook.Payload{Type: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("%d attempts, want 3", calls.Load())
+	}
+	if n := len(d.DLQ.List()); n != 0 {
+		t.Errorf("%d dead letters after a successful retry", n)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	d := newDispatcher(t, dir)
+	down, downCalls := receiver(t, 500, 500, 500)
+	rejecting, rejectingCalls := receiver(t, http.StatusBadRequest)
+
+	ctx := context.Background()
+	if err := d.Dispatch(ctx, down.URL, &webhook.Payload{Type: "down"}); !errors.Is(err, webhook.ErrDeadLettered) {
+		t.Fatalf("got %v, want a dead-lettered delivery", err)
+	}
+	if downCalls.Load() != 3 {
+		t.Errorf("%d attempts, want 3", downCalls.Load())
+	}
+	if err := d.Dispatch(ctx, rejecting.URL, &webhook.Payload{Type: "rejected"}); !errors.Is(err, webhook.ErrDeadLettered) {
+		t.Fatalf("got %v, want a dead-lettered delivery", err)
+	}
+	if rejectingCalls.Load() != 1 {
+		t.Errorf("400 retried %d times", rejectingCalls.Load()-1)
+	}
+
+	// Dead letters survive a restart
+	d = newDispatcher(t, dir)
+	letters := d.DLQ.List()
+	if len(letters) != 2 || letters[0].Payload.Type != "down" || letters[0].Attempts != 3 || letters[1].Attempts != 1 {
+		t.Fatalf("dead letters %+v", letters)
+	}
+
+	// Both receivers answer 200 by now
+	for _, letter := range letters {
+		if err := d.Redeliver(ctx, letter.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(d.DLQ.List()); n != 0 {
+		t.Errorf("%d dead letters left after redelivery", n)
+	}
+	if err := d.Redeliver(ctx, letters[0].ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("redelivering twice: %v", err)
+	}
+}
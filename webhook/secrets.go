@@ -0,0 +1,140 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook delivers signed JSON notifications to HTTP receivers.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Secret is an HMAC key used to sign deliveries.
+type Secret struct {
+	ID        string     `json:"id"`
+	Key       []byte     `json:"key"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (s *Secret) active(now time.Time) bool {
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+// Keyring holds the signing secrets, persisted in a JSON file. The newest
+// secret is the primary one; secrets it replaced stay valid until the end
+// of their overlap window so receivers can switch over without dropping
+// deliveries.
+type Keyring struct {
+	path string
+
+	mu      sync.Mutex
+	secrets []*Secret
+}
+
+// ErrNoSecret is returned when the keyring has no active secret.
+var ErrNoSecret = errors.New("webhook: no active signing secret")
+
+// LoadKeyring reads the keyring at path, creating it with a fresh secret
+// if it does not exist yet.
+func LoadKeyring(path string) (*Keyring, error) {
+	k := &Keyring{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if _, err := k.Rotate(0); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &k.secrets); err != nil {
+		return nil, fmt.Errorf("webhook: invalid secrets file %s: %w", path, err)
+	}
+	return k, nil
+}
+
+// Active returns the secrets that are still valid, newest first.
+func (k *Keyring) Active() []*Secret {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.activeLocked(time.Now())
+}
+
+func (k *Keyring) activeLocked(now time.Time) []*Secret {
+	var active []*Secret
+	for i := len(k.secrets) - 1; i >= 0; i-- {
+		if k.secrets[i].active(now) {
+			active = append(active, k.secrets[i])
+		}
+	}
+	return active
+}
+
+// Rotate creates a new primary secret. The previous secrets remain valid
+// for overlap; expired secrets are dropped. The new secret is returned so
+// it can be handed to receivers.
+func (k *Keyring) Rotate(overlap time.Duration) (*Secret, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now().UTC()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	expires := now.Add(overlap)
+	secrets := []*Secret{}
+	for _, s := range k.secrets {
+		if !s.active(now) {
+			continue
+		}
+		if s.ExpiresAt == nil || s.ExpiresAt.After(expires) {
+			s.ExpiresAt = &expires
+		}
+		secrets = append(secrets, s)
+	}
+
+	secret := &Secret{
+		ID:        now.Format("20060102") + "-" + hex.EncodeToString(id),
+		Key:       key,
+		CreatedAt: now,
+	}
+	secrets = append(secrets, secret)
+
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(k.path, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	k.secrets = secrets
+	return secret, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Sender posts signed payloads to receivers.
+type Sender struct {
+	keyring *Keyring
+	client  *http.Client
+}
+
+// NewSender returns a sender signing with keyring.
+func NewSender(keyring *Keyring, timeout time.Duration) *Sender {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Sender{keyring: keyring, client: &http.Client{Timeout: timeout}}
+}
+
+// Deliver posts payload to url once and fails unless the receiver answers
+// 2xx, with a *StatusError for other answers. Use a Dispatcher to retry.
+func (s *Sender) Deliver(ctx context.Context, url string, payload *Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := Sign(req.Header, s.keyring.Active(), body, time.Now()); err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery.
+const (
+	HeaderKeyID     = "X-CSE-Key-Id"
+	HeaderTimestamp = "X-CSE-Timestamp"
+	HeaderSignature = "X-CSE-Signature"
+)
+
+// ErrInvalidSignature is returned by Verify when no signature matches.
+var ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+// signature computes the HMAC-SHA256 of "<timestamp>.<body>".
+func signature(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign adds the signature headers for body to h. The primary secret's id is
+// sent in X-CSE-Key-Id, and X-CSE-Signature carries one "<id>=<hex>" entry
+// per active secret, so receivers holding either the old or the new secret
+// can verify deliveries during a rotation.
+func Sign(h http.Header, secrets []*Secret, body []byte, now time.Time) error {
+	if len(secrets) == 0 {
+		return ErrNoSecret
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	parts := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		parts = append(parts, s.ID+"="+signature(s.Key, timestamp, body))
+	}
+
+	h.Set(HeaderKeyID, secrets[0].ID)
+	h.Set(HeaderTimestamp, timestamp)
+	h.Set(HeaderSignature, strings.Join(parts, ","))
+	return nil
+}
+
+// Verify checks a delivery against the secrets known to the receiver,
+// rejecting timestamps older than tolerance to prevent replays.
+func Verify(h http.Header, secrets []*Secret, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp := h.Get(HeaderTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrInvalidSignature
+		}
+	}
+
+	for _, part := range strings.Split(h.Get(HeaderSignature), ",") {
+		id, sig, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		for _, s := range secrets {
+			if s.ID == id && hmac.Equal([]byte(sig), []byte(signature(s.Key, timestamp, body))) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
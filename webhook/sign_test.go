@@ -0,0 +1,120 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"contract-storage-eth/webhook"
+)
+
+var (
+	signedAt = time.Unix(1700000000, 0)
+	payload  = []byte(`{"event":"DataSaved","key":"invoice-42"}`)
+	current  = &webhook.Secret{ID: "20231114-new", Key: []byte("whsec-test-key")}
+	previous = &webhook.Secret{ID: "20231101-old", Key: []byte("whsec-old-key")}
+)
+
+// Signatures of payload at signedAt, computed independently with Python's
+// hmac module
+const (
+	currentSignature  = "24212ff53ac2ed57fcaebfe1009c4e474b06229a9ec76c6282bbbbd8af249676"
+	previousSignature = "611be6da593d9f7b8273546f4a6de95ae6a22bc067bf478814fd997c260eca74"
+)
+
+func TestSign(t *testing.T) {
+	h := http.Header{}
+	if err := webhook.Sign(h, []*webhook.Secret{current, previous}, payload, signedAt); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		webhook.HeaderKeyID:     current.ID,
+		webhook.HeaderTimestamp: "1700000000",
+		webhook.HeaderSignature: current.ID + "=" + currentSignature + "," + previous.ID + "=" + previousSignature,
+	}
+	for name, value := range want {
+		if got := h.Get(name); got != value {
+			t.Errorf("%s: %q, want %q", name, got, value)
+		}
+	}
+
+	if err := webhook.Sign(http.Header{}, nil, payload, signedAt); !errors.Is(err, webhook.ErrNoSecret) {
+		t.Errorf("signed without secrets: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signed := http.Header{}
+	if err := webhook.Sign(signed, []*webhook.Secret{current, previous}, payload, signedAt); err != nil {
+		t.Fatal(err)
+	}
+	const tolerance = 5 * time.Minute
+
+	tests := []struct {
+		name    string
+		header  func(http.Header)
+		body    []byte
+		secrets []*webhook.Secret
+		now     time.Time
+		ok      bool
+	}{
+		{name: "signed", ok: true},
+		{name: "receiver with the previous secret", secrets: []*webhook.Secret{previous}, ok: true},
+		{name: "within the tolerance", now: signedAt.Add(tolerance), ok: true},
+		{name: "tampered body", body: []byte(`{"event":"DataSaved","key":"invoice-43"}`)},
+		{name: "tampered signature", header: func(h http.Header) {
+			h.Set(webhook.HeaderSignature, current.ID+"="+previousSignature)
+		}},
+		{name: "signature moved to another secret", header: func(h http.Header) {
+			h.Set(webhook.HeaderSignature, previous.ID+"="+currentSignature)
+		}},
+		{name: "unknown secret", secrets: []*webhook.Secret{{ID: current.ID, Key: []byte("other")}}},
+		{name: "no signature", header: func(h http.Header) { h.Del(webhook.HeaderSignature) }},
+		{name: "malformed timestamp", header: func(h http.Header) { h.Set(webhook.HeaderTimestamp, "yesterday") }},
+		{name: "replayed", now: signedAt.Add(tolerance + time.Second)},
+		{name: "from the future", now: signedAt.Add(-tolerance - time.Second)},
+		{name: "replayed with a new timestamp", now: signedAt.Add(time.Hour), header: func(h http.Header) {
+			h.Set(webhook.HeaderTimestamp, strconv.FormatInt(signedAt.Add(time.Hour).Unix(), 10))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := signed.Clone()
+			if tt.header != nil {
+				tt.header(h)
+			}
+			b, secrets, now := payload, []*webhook.Secret{current}, signedAt
+			if tt.body != nil {
+				b = tt.body
+			}
+			if tt.secrets != nil {
+				secrets = tt.secrets
+			}
+			if !tt.now.IsZero() {
+				now = tt.now
+			}
+			err := webhook.Verify(h, secrets, b, tolerance, now)
+			if tt.ok && err != nil {
+				t.Errorf("rejected: %v", err)
+			} else if !tt.ok && !errors.Is(err, webhook.ErrInvalidSignature) {
+				t.Errorf("got %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
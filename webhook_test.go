@@ -0,0 +1,123 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"contract-storage-eth/fixtures"
+	"contract-storage-eth/indexer"
+	"contract-storage-eth/recordid"
+	"contract-storage-eth/webhook"
+)
+
+func TestNotifyWebhooks(t *testing.T) {
+	chain, err := fixtures.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	store, err := indexer.Open(filepath.Join(dir, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := indexer.New(chain, chain.Contract, store, indexer.Options{StartBlock: 1}).Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var config Config
+	config.Webhook.SecretsFile = filepath.Join(dir, "webhook_secrets.json")
+	config.Webhook.DLQFile = filepath.Join(dir, "webhook_dlq.json")
+	config.Webhook.CursorFile = filepath.Join(dir, "webhook_cursor.json")
+	config.Index.SyncInterval = 10 * time.Millisecond
+	keyring, err := loadWebhookKeyring(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher, err := newDispatcher(&config, keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify(r.Header, keyring.Active(), body, time.Minute, time.Now()); err != nil {
+			t.Errorf("unsigned delivery: %v", err)
+		}
+		var payload struct {
+			Type string `json:"type"`
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		json.Unmarshal(body, &payload)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, payload.Type+" "+payload.Data.ID[strings.LastIndex(payload.Data.ID, "/")+1:])
+	}))
+	defer receiver.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown receiver", http.StatusGone)
+	}))
+	defer rejecting.Close()
+	config.Webhook.URLs = []string{receiver.URL, rejecting.URL}
+
+	// Notify the events after the first version of invoice-42
+	ns := recordid.Namespace{ChainID: 1337, Contract: chain.Contract}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		notifyWebhooks(ctx, &config, store, dispatcher, ns, webhookCursor{Block: 3})
+	}()
+	// The cursor moves once an event went to every receiver
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if cursor, _ := os.ReadFile(config.Webhook.CursorFile); string(cursor) == `{"block":6,"log":1}` {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the events were not all notified")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"data.saved invoice-42?version=2#pdf", "data.saved doc?version=1#sha256", "data.saved invoice-43?version=1#pdf"}
+	if strings.Join(received, "\n") != strings.Join(want, "\n") {
+		t.Errorf("received\n%s\nwant\n%s", strings.Join(received, "\n"), strings.Join(want, "\n"))
+	}
+	if letters := dispatcher.DLQ.List(); len(letters) != 3 || letters[0].URL != rejecting.URL {
+		t.Errorf("%d dead letters, want the 3 deliveries to the rejecting receiver", len(letters))
+	}
+}
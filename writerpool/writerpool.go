@@ -0,0 +1,241 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package writerpool sends saves from several accounts at once. Each worker
+// owns a nonce lane, an account whose transactions it numbers itself, so
+// that it keeps several transactions in flight without waiting for each to
+// be mined and workers never compete for a nonce. Submit blocks while the
+// queue is full, holding back producers faster than the chain.
+package writerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrClosed is returned by Submit once the pool is closed.
+var ErrClosed = errors.New("writer pool closed")
+
+// Op is a save to send.
+type Op struct {
+	Key   string
+	Field string
+	Value string
+	// ID identifies the op in its Result, such as the index of a record.
+	ID int
+}
+
+// Result is the outcome of an op. Tx is nil when the op could not be sent.
+type Result struct {
+	Op      Op
+	Lane    int
+	Tx      *types.Transaction
+	Receipt *types.Receipt
+	Err     error
+}
+
+// Sent reports whether the transaction of the op was sent.
+func (r *Result) Sent() bool {
+	return r.Tx != nil
+}
+
+// Lane sends the transactions of one account.
+type Lane interface {
+	// Address is the account.
+	Address() common.Address
+	// PendingNonce returns the next nonce of the account, counting the
+	// transactions in the mempool.
+	PendingNonce(ctx context.Context) (uint64, error)
+	// Send signs and sends op as the transaction of nonce.
+	Send(ctx context.Context, op Op, nonce uint64) (*types.Transaction, error)
+	// Wait waits for tx to be mined.
+	Wait(ctx context.Context, tx *types.Transaction) (*types.Receipt, error)
+}
+
+// Options configures a Pool.
+type Options struct {
+	// InFlight bounds the transactions of each lane awaiting confirmation.
+	// Defaults to 1, which sends the transactions of a lane one by one.
+	InFlight int
+	// QueueSize is the number of ops waiting for a lane before Submit
+	// blocks. Defaults to the number of lanes times InFlight.
+	QueueSize int
+	// OnSent is called when the transaction of an op is sent.
+	OnSent func(lane int, op Op, tx *types.Transaction)
+	// OnResult is called once for each op submitted, when it is mined,
+	// failed or could not be sent.
+	OnResult func(Result)
+}
+
+// Pool sends the ops submitted to it through its lanes. Callbacks are
+// called one at a time, so they need no locking of their own.
+type Pool struct {
+	opts  Options
+	queue chan Op
+	lanes []Lane
+
+	// mu serializes the callbacks
+	mu      sync.Mutex
+	workers sync.WaitGroup
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Start starts a worker for each lane, sending the ops submitted until ctx
+// is done. Transactions sent are still awaited after ctx is done, so that
+// their results are known.
+func Start(ctx context.Context, lanes []Lane, opts Options) *Pool {
+	if opts.InFlight <= 0 {
+		opts.InFlight = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = len(lanes) * opts.InFlight
+	}
+	p := &Pool{opts: opts, queue: make(chan Op, opts.QueueSize), lanes: lanes, closed: make(chan struct{})}
+	for i, lane := range lanes {
+		p.workers.Add(1)
+		go p.work(ctx, i, lane)
+	}
+	return p
+}
+
+// Submit queues op, blocking while the queue is full. It returns ErrClosed
+// once the pool is closed, or the error of ctx when it is done first.
+func (p *Pool) Submit(ctx context.Context, op Op) error {
+	select {
+	case <-p.closed:
+		return ErrClosed
+	default:
+	}
+	select {
+	case p.queue <- op:
+		return nil
+	case <-p.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Queued returns the number of ops waiting for a lane.
+func (p *Pool) Queued() int {
+	return len(p.queue)
+}
+
+// Close stops accepting ops and waits for the results of those submitted.
+// Submit must not be running.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		close(p.queue)
+	})
+	p.workers.Wait()
+}
+
+// work sends the ops of the queue from lane, numbering them from the
+// pending nonce of its account
+func (p *Pool) work(ctx context.Context, index int, lane Lane) {
+	defer p.workers.Done()
+	var (
+		waits  sync.WaitGroup
+		slots  = make(chan struct{}, p.opts.InFlight)
+		nonce  uint64
+		synced bool
+	)
+	defer waits.Wait()
+
+	for {
+		// Ops stay in the queue while the lane is full, for other lanes or
+		// to block Submit
+		slots <- struct{}{}
+		op, ok := <-p.queue
+		if !ok {
+			return
+		}
+		if err := ctx.Err(); err != nil {
+			<-slots
+			p.result(Result{Op: op, Lane: index, Err: err})
+			continue
+		}
+		tx, err := p.send(ctx, lane, op, &nonce, &synced)
+		if err != nil {
+			<-slots
+			p.result(Result{Op: op, Lane: index, Err: err})
+			continue
+		}
+		if p.opts.OnSent != nil {
+			p.mu.Lock()
+			p.opts.OnSent(index, op, tx)
+			p.mu.Unlock()
+		}
+
+		waits.Add(1)
+		go func() {
+			defer waits.Done()
+			defer func() { <-slots }()
+			receipt, err := lane.Wait(context.WithoutCancel(ctx), tx)
+			if err == nil && receipt.Status != types.ReceiptStatusSuccessful {
+				err = fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+			}
+			p.result(Result{Op: op, Lane: index, Tx: tx, Receipt: receipt, Err: err})
+		}()
+	}
+}
+
+// send sends op with the next nonce of the lane. After an error the nonce
+// is read from the node again, and op sent once more when another
+// transaction of the account took the nonce it was sent with
+func (p *Pool) send(ctx context.Context, lane Lane, op Op, nonce *uint64, synced *bool) (*types.Transaction, error) {
+	if !*synced {
+		n, err := lane.PendingNonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+		*nonce, *synced = n, true
+	}
+	tx, err := lane.Send(ctx, op, *nonce)
+	if err != nil {
+		used := *nonce
+		n, nerr := lane.PendingNonce(ctx)
+		if nerr != nil {
+			*synced = false
+			return nil, err
+		}
+		*nonce = n
+		if n <= used {
+			return nil, err
+		}
+		if tx, err = lane.Send(ctx, op, n); err != nil {
+			*synced = false
+			return nil, err
+		}
+	}
+	*nonce++
+	return tx, nil
+}
+
+func (p *Pool) result(r Result) {
+	if p.opts.OnResult == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.opts.OnResult(r)
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writerpool_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"contract-storage-eth/writerpool"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeLane is an account whose transactions are mined once release is
+// closed, or at once when it is nil
+type fakeLane struct {
+	address common.Address
+	release chan struct{}
+
+	mu      sync.Mutex
+	pending uint64
+	nonces  []uint64
+	// taken is a nonce another sender uses first
+	taken *uint64
+	fail  error
+}
+
+func (l *fakeLane) Address() common.Address { return l.address }
+
+func (l *fakeLane) PendingNonce(ctx context.Context) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pending, nil
+}
+
+func (l *fakeLane) Send(ctx context.Context, op writerpool.Op, nonce uint64) (*types.Transaction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fail != nil {
+		return nil, l.fail
+	}
+	if l.taken != nil && *l.taken == nonce {
+		l.taken = nil
+		l.pending = nonce + 1
+		return nil, errors.New("nonce too low")
+	}
+	if nonce != l.pending {
+		return nil, errors.New("nonce gap")
+	}
+	l.pending++
+	l.nonces = append(l.nonces, nonce)
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &l.address, Data: []byte(op.Key)}), nil
+}
+
+func (l *fakeLane) Wait(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	if l.release != nil {
+		<-l.release
+	}
+	return &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(int64(tx.Nonce()))}, nil
+}
+
+func TestLanes(t *testing.T) {
+	lanes := []*fakeLane{{address: common.HexToAddress("0x1")}, {address: common.HexToAddress("0x2"), pending: 40}}
+	var results []writerpool.Result
+	pool := writerpool.Start(context.Background(), []writerpool.Lane{lanes[0], lanes[1]}, writerpool.Options{
+		InFlight: 4,
+		OnResult: func(r writerpool.Result) { results = append(results, r) },
+	})
+	for i := range 50 {
+		if err := pool.Submit(context.Background(), writerpool.Op{Key: "k", ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.Close()
+
+	if len(results) != 50 {
+		t.Fatalf("got %d results, want 50", len(results))
+	}
+	seen := map[int]bool{}
+	for _, r := range results {
+		if r.Err != nil || !r.Sent() {
+			t.Errorf("op %d: %v", r.Op.ID, r.Err)
+		}
+		seen[r.Op.ID] = true
+	}
+	if len(seen) != 50 {
+		t.Errorf("got the results of %d ops, want 50", len(seen))
+	}
+	// Each lane numbers its transactions without gaps from its own nonce
+	total := 0
+	for i, lane := range lanes {
+		start := uint64(i * 40)
+		for j, n := range lane.nonces {
+			if n != start+uint64(j) {
+				t.Errorf("lane %d sent nonces %v", i, lane.nonces)
+				break
+			}
+		}
+		total += len(lane.nonces)
+	}
+	if total != 50 {
+		t.Errorf("sent %d transactions, want 50", total)
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	lane := &fakeLane{release: make(chan struct{})}
+	pool := writerpool.Start(context.Background(), []writerpool.Lane{lane}, writerpool.Options{InFlight: 2, QueueSize: 3})
+
+	// Two ops in flight and three queued fill the pool
+	for i := range 5 {
+		if err := pool.Submit(context.Background(), writerpool.Op{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.Submit(ctx, writerpool.Op{ID: 5}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("submitted to a full pool: %v", err)
+	}
+	if n := pool.Queued(); n != 3 {
+		t.Errorf("%d ops queued, want 3", n)
+	}
+	close(lane.release)
+	pool.Close()
+	if err := pool.Submit(context.Background(), writerpool.Op{}); !errors.Is(err, writerpool.ErrClosed) {
+		t.Errorf("submitted to a closed pool: %v", err)
+	}
+}
+
+func TestNonceTaken(t *testing.T) {
+	taken := uint64(1)
+	lane := &fakeLane{taken: &taken}
+	var results []writerpool.Result
+	pool := writerpool.Start(context.Background(), []writerpool.Lane{lane}, writerpool.Options{
+		OnResult: func(r writerpool.Result) { results = append(results, r) },
+	})
+	for i := range 3 {
+		pool.Submit(context.Background(), writerpool.Op{ID: i})
+	}
+	pool.Close()
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("op %d: %v", r.Op.ID, r.Err)
+		}
+	}
+	if got := lane.nonces; len(got) != 3 || got[0] != 0 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("sent nonces %v, want [0 2 3]", got)
+	}
+
+	lane.fail = errors.New("insufficient funds")
+	results = nil
+	pool = writerpool.Start(context.Background(), []writerpool.Lane{lane}, writerpool.Options{
+		OnResult: func(r writerpool.Result) { results = append(results, r) },
+	})
+	pool.Submit(context.Background(), writerpool.Op{ID: 7})
+	pool.Close()
+	if len(results) != 1 || results[0].Sent() || results[0].Err == nil {
+		t.Errorf("got %+v for an op that cannot be sent", results)
+	}
+}
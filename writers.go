@@ -0,0 +1,165 @@
+// Copyright 2025 The Casibase Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+
+	"contract-storage-eth/chain"
+	"contract-storage-eth/signer"
+	"contract-storage-eth/storage"
+	"contract-storage-eth/writerpool"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// addPoolFlags registers the flags of the writer pool
+func addPoolFlags(flags *flag.FlagSet, config *Config) {
+	flags.IntVar(&config.WriterPool.Workers, "workers", config.WriterPool.Workers, "nonce lanes sending at once, each from an account of its own")
+}
+
+// saveLane is a nonce lane of the writer pool, saving to the contract from
+// one signer
+type saveLane struct {
+	config   *Config
+	client   *chain.Client
+	contract storage.ContractBinder
+	auth     *bind.TransactOpts
+}
+
+// Address implements writerpool.Lane
+func (l *saveLane) Address() common.Address {
+	return l.auth.From
+}
+
+// PendingNonce implements writerpool.Lane
+func (l *saveLane) PendingNonce(ctx context.Context) (uint64, error) {
+	return l.client.PendingNonceAt(ctx, l.auth.From)
+}
+
+// Send implements writerpool.Lane. The worker of the lane sends one
+// transaction at a time, so auth is not shared
+func (l *saveLane) Send(ctx context.Context, op writerpool.Op, nonce uint64) (*types.Transaction, error) {
+	l.auth.Nonce = new(big.Int).SetUint64(nonce)
+	return sendSave(ctx, l.client, l.contract, l.auth, l.config, op.Key, op.Field, op.Value)
+}
+
+// Wait implements writerpool.Lane
+func (l *saveLane) Wait(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	return waitMinedWith(ctx, l.client, tx, l.config, nil)
+}
+
+// saveLanes returns the lanes of the writer pool saving to the contract at
+// address. The returned function releases their signers
+func saveLanes(ctx context.Context, config *Config, client *chain.Client, address common.Address) ([]writerpool.Lane, func(), error) {
+	signers, closeSigners, err := laneSigners(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		closeSigners()
+		return nil, nil, err
+	}
+	contract, err := storage.Bind(address, client)
+	if err != nil {
+		closeSigners()
+		return nil, nil, err
+	}
+
+	var lanes []writerpool.Lane
+	seen := map[common.Address]bool{}
+	for _, s := range signers {
+		if seen[s.Address()] {
+			closeSigners()
+			return nil, nil, fmt.Errorf("writer pool: %s is the account of two lanes", s.Address().Hex())
+		}
+		seen[s.Address()] = true
+		auth, err := signer.NewTransactOpts(ctx, s, chainID)
+		if err != nil {
+			closeSigners()
+			return nil, nil, err
+		}
+		lanes = append(lanes, &saveLane{config: config, client: client, contract: contract, auth: auth})
+	}
+	return lanes, closeSigners, nil
+}
+
+// laneSigners returns the signers of the lanes of the writer pool: the keys
+// of writer_pool.lanes, or else writer_pool.workers accounts derived from
+// the mnemonic from account_index on. A single lane signs with the active
+// signer, which the standby may replace
+func laneSigners(ctx context.Context, config *Config) ([]signer.Signer, func(), error) {
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+	add := func(s signer.Signer) {
+		if c, ok := s.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+	}
+
+	pool := config.WriterPool
+	var keys []KeyConfig
+	switch {
+	case len(pool.Lanes) > 0:
+		keys = pool.Lanes
+	case pool.Workers > 1:
+		if config.Ethereum.Mnemonic.Phrase == "" {
+			return nil, nil, errors.New("writer pool: several workers need a mnemonic to derive their accounts from, or writer_pool.lanes")
+		}
+		for i := range pool.Workers {
+			key := config.Ethereum.KeyConfig
+			key.Mnemonic.AccountIndex += uint32(i)
+			keys = append(keys, key)
+		}
+	default:
+		selector, err := loadSigners(ctx, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		if c, ok := selector.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+		active, err := selector.Active(ctx)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("no signer available: %w", err)
+		}
+		return []signer.Signer{active}, closeAll, nil
+	}
+
+	var signers []signer.Signer
+	for i, key := range keys {
+		s, err := loadKey(ctx, key)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("writer pool lane %d: %w", i+1, err)
+		}
+		add(s)
+		signers = append(signers, s)
+	}
+	return signers, closeAll, nil
+}